@@ -65,6 +65,11 @@ func (a *App) SetAttachmentDir(dir string) {
 	a.workflow.SetAttachmentDir(dir)
 }
 
+// SetRouter routes chat messages to cheaper models when their preset asks.
+func (a *App) SetRouter(r *modelplugin.Router) {
+	a.workflow.SetRouter(r)
+}
+
 func (a *App) Register(api huma.API) {
 	a.endpoints.Register(api)
 }
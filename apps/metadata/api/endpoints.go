@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"net/http"
+	"time"
 
 	"github.com/danielgtaylor/huma/v2"
 
@@ -47,9 +48,14 @@ type UseCases struct {
 	ListPlans          *usecases.ListPlans
 	UpdatePlan         *usecases.UpdatePlan
 	DeletePlan         *usecases.DeletePlan
+	ExportPlan         *usecases.ExportPlan
+	ImportPlan         *usecases.ImportPlan
+	ListPlanRevisions  *usecases.ListPlanRevisions
 	ListPlanRuns       *usecases.ListPlanRuns
 	GetPlanRun         *usecases.GetPlanRun
 	PlanRunner         *plans.Runner
+	PlanTemplates      *plans.Templates
+	PlanBlackouts      *plans.Blackouts
 	CreateGuardProfile *usecases.CreateGuardProfile
 	ListGuardProfiles  *usecases.ListGuardProfiles
 	UpdateGuardProfile *usecases.UpdateGuardProfile
@@ -108,11 +114,31 @@ func (e *Endpoints) Register(api huma.API) {
 	huma.Register(api, huma.Operation{OperationID: "list-plans", Method: http.MethodGet, Path: "/api/plans"}, e.listPlans)
 	huma.Register(api, huma.Operation{OperationID: "update-plan", Method: http.MethodPut, Path: "/api/plans/{id}"}, e.updatePlan)
 	huma.Register(api, huma.Operation{OperationID: "delete-plan", Method: http.MethodDelete, Path: "/api/plans/{id}", DefaultStatus: 204}, e.deletePlan)
+	huma.Register(api, huma.Operation{OperationID: "get-plan-schema", Method: http.MethodGet, Path: "/api/plans/schema"}, e.getPlanSchema)
+	huma.Register(api, huma.Operation{OperationID: "validate-plan", Method: http.MethodPost, Path: "/api/plans/validate"}, e.validatePlan)
+	huma.Register(api, huma.Operation{OperationID: "list-plan-metrics", Method: http.MethodGet, Path: "/api/plans/metrics"}, e.listPlanMetrics)
+	huma.Register(api, huma.Operation{OperationID: "get-plan-metrics", Method: http.MethodGet, Path: "/api/plans/{id}/metrics"}, e.getPlanMetrics)
+	huma.Register(api, huma.Operation{OperationID: "preview-plan-schedule", Method: http.MethodPost, Path: "/api/plans/schedule-preview"}, e.previewPlanSchedule)
+	huma.Register(api, huma.Operation{OperationID: "import-plan", Method: http.MethodPost, Path: "/api/plans/import"}, e.importPlan)
+	huma.Register(api, huma.Operation{OperationID: "export-plan", Method: http.MethodGet, Path: "/api/plans/{id}/export"}, e.exportPlan)
+	huma.Register(api, huma.Operation{OperationID: "list-plan-revisions", Method: http.MethodGet, Path: "/api/plans/{id}/revisions"}, e.listPlanRevisions)
+
+	huma.Register(api, huma.Operation{OperationID: "list-plan-templates", Method: http.MethodGet, Path: "/api/plan-templates"}, e.listPlanTemplates)
+	huma.Register(api, huma.Operation{OperationID: "get-plan-template", Method: http.MethodGet, Path: "/api/plan-templates/{id}"}, e.getPlanTemplate)
+	huma.Register(api, huma.Operation{OperationID: "create-plan-template", Method: http.MethodPost, Path: "/api/plan-templates", DefaultStatus: 201}, e.createPlanTemplate)
+	huma.Register(api, huma.Operation{OperationID: "delete-plan-template", Method: http.MethodDelete, Path: "/api/plan-templates/{id}", DefaultStatus: 204}, e.deletePlanTemplate)
+	huma.Register(api, huma.Operation{OperationID: "instantiate-plan-template", Method: http.MethodPost, Path: "/api/plan-templates/{id}/instantiate", DefaultStatus: 201}, e.instantiatePlanTemplate)
+	huma.Register(api, huma.Operation{OperationID: "list-plan-blackouts", Method: http.MethodGet, Path: "/api/plan-blackouts"}, e.listPlanBlackouts)
+	huma.Register(api, huma.Operation{OperationID: "create-plan-blackout", Method: http.MethodPost, Path: "/api/plan-blackouts", DefaultStatus: 201}, e.createPlanBlackout)
+	huma.Register(api, huma.Operation{OperationID: "update-plan-blackout", Method: http.MethodPut, Path: "/api/plan-blackouts/{id}"}, e.updatePlanBlackout)
+	huma.Register(api, huma.Operation{OperationID: "delete-plan-blackout", Method: http.MethodDelete, Path: "/api/plan-blackouts/{id}", DefaultStatus: 204}, e.deletePlanBlackout)
 
 	huma.Register(api, huma.Operation{OperationID: "list-plan-runs", Method: http.MethodGet, Path: "/api/plans/{planId}/runs"}, e.listPlanRuns)
 	huma.Register(api, huma.Operation{OperationID: "trigger-plan-run", Method: http.MethodPost, Path: "/api/plans/{planId}/runs", DefaultStatus: 201}, e.triggerPlanRun)
 	huma.Register(api, huma.Operation{OperationID: "get-plan-run", Method: http.MethodGet, Path: "/api/plan-runs/{id}"}, e.getPlanRun)
 	huma.Register(api, huma.Operation{OperationID: "cancel-plan-run", Method: http.MethodPost, Path: "/api/plan-runs/{id}/cancel"}, e.cancelPlanRun)
+	huma.Register(api, huma.Operation{OperationID: "resolve-plan-approval", Method: http.MethodPost, Path: "/api/plan-runs/{id}/approval"}, e.resolvePlanApproval)
+	huma.Register(api, huma.Operation{OperationID: "plan-webhook", Method: http.MethodPost, Path: "/api/hooks/plans/{planId}", DefaultStatus: 202}, e.planWebhook)
 
 	huma.Register(api, huma.Operation{OperationID: "create-guard-profile", Method: http.MethodPost, Path: "/api/guard-profiles", DefaultStatus: 201}, e.createGuardProfile)
 	huma.Register(api, huma.Operation{OperationID: "list-guard-profiles", Method: http.MethodGet, Path: "/api/guard-profiles"}, e.listGuardProfiles)
@@ -240,13 +266,16 @@ func (e *Endpoints) deleteModel(ctx context.Context, input *ModelIDInput) (*stru
 
 func (e *Endpoints) createPreset(ctx context.Context, input *CreatePresetInput) (*PresetOutput, error) {
 	p, err := e.uc.CreatePreset.Execute(ctx, types.Preset{
-		Name:            input.Body.Name,
-		ChatModelID:     input.Body.ChatModelID,
-		SummaryModelID:  input.Body.SummaryModelID,
-		ImageModelID:    input.Body.ImageModelID,
-		FallbackModelID: input.Body.FallbackModelID,
-		Temperature:     input.Body.Temperature,
-		SystemPrompt:    input.Body.SystemPrompt,
+		Name:             input.Body.Name,
+		ChatModelID:      input.Body.ChatModelID,
+		SummaryModelID:   input.Body.SummaryModelID,
+		ImageModelID:     input.Body.ImageModelID,
+		FallbackModelID:  input.Body.FallbackModelID,
+		FallbackModelIDs: input.Body.FallbackModelIDs,
+		Temperature:      input.Body.Temperature,
+		SystemPrompt:     input.Body.SystemPrompt,
+		Generation:       generationFromBody(input.Body.Generation),
+		Routing:          routingFromBody(input.Body.Routing),
 	})
 	if err != nil {
 		return nil, mapErr(err)
@@ -264,14 +293,17 @@ func (e *Endpoints) listPresets(ctx context.Context, _ *struct{}) (*PresetsOutpu
 
 func (e *Endpoints) updatePreset(ctx context.Context, input *UpdatePresetInput) (*PresetOutput, error) {
 	p, err := e.uc.UpdatePreset.Execute(ctx, types.Preset{
-		ID:              input.ID,
-		Name:            input.Body.Name,
-		ChatModelID:     input.Body.ChatModelID,
-		SummaryModelID:  input.Body.SummaryModelID,
-		ImageModelID:    input.Body.ImageModelID,
-		FallbackModelID: input.Body.FallbackModelID,
-		Temperature:     input.Body.Temperature,
-		SystemPrompt:    input.Body.SystemPrompt,
+		ID:               input.ID,
+		Name:             input.Body.Name,
+		ChatModelID:      input.Body.ChatModelID,
+		SummaryModelID:   input.Body.SummaryModelID,
+		ImageModelID:     input.Body.ImageModelID,
+		FallbackModelID:  input.Body.FallbackModelID,
+		FallbackModelIDs: input.Body.FallbackModelIDs,
+		Temperature:      input.Body.Temperature,
+		SystemPrompt:     input.Body.SystemPrompt,
+		Generation:       generationFromBody(input.Body.Generation),
+		Routing:          routingFromBody(input.Body.Routing),
 	})
 	if err != nil {
 		return nil, mapErr(err)
@@ -391,7 +423,7 @@ func (e *Endpoints) listPlans(ctx context.Context, _ *struct{}) (*PlansOutput, e
 }
 
 func (e *Endpoints) updatePlan(ctx context.Context, input *UpdatePlanInput) (*PlanOutput, error) {
-	p, err := e.uc.UpdatePlan.Execute(ctx, planFromUpdateInput(input))
+	p, err := e.uc.UpdatePlan.Execute(ctx, planFromUpdateInput(input), input.Body.PresetID, input.Body.ModelID)
 	if err != nil {
 		return nil, mapErr(err)
 	}
@@ -405,6 +437,150 @@ func (e *Endpoints) deletePlan(ctx context.Context, input *PlanIDInput) (*struct
 	return nil, nil
 }
 
+func (e *Endpoints) getPlanSchema(_ context.Context, _ *struct{}) (*PlanDocumentOutput, error) {
+	return &PlanDocumentOutput{ContentType: "application/schema+json", Body: plans.DocumentSchema}, nil
+}
+
+func (e *Endpoints) validatePlan(_ context.Context, input *ValidatePlanInput) (*ValidatePlanOutput, error) {
+	return toValidatePlanOutput(e.uc.ImportPlan.Validate(input.Body.Document)), nil
+}
+
+func (e *Endpoints) previewPlanSchedule(_ context.Context, input *PlanSchedulePreviewInput) (*PlanSchedulePreviewOutput, error) {
+	count := input.Body.Count
+	if count == 0 {
+		count = 5
+	}
+	times, err := plans.NextFireTimes(input.Body.Schedule, input.Body.Timezone, time.Now(), count)
+	if err != nil {
+		return nil, mapErr(err)
+	}
+	return toPlanSchedulePreviewOutput(times), nil
+}
+
+func (e *Endpoints) listPlanMetrics(ctx context.Context, input *AllPlanMetricsInput) (*AllPlanMetricsOutput, error) {
+	if e.uc.PlanRunner == nil {
+		return nil, huma.NewError(http.StatusServiceUnavailable, "plan runner not available")
+	}
+	items, err := e.uc.PlanRunner.AllPlanMetrics(ctx, metricsSince(input.Days))
+	if err != nil {
+		return nil, mapErr(err)
+	}
+	return &AllPlanMetricsOutput{Body: items}, nil
+}
+
+func (e *Endpoints) getPlanMetrics(ctx context.Context, input *PlanMetricsInput) (*PlanMetricsOutput, error) {
+	if e.uc.PlanRunner == nil {
+		return nil, huma.NewError(http.StatusServiceUnavailable, "plan runner not available")
+	}
+	m, err := e.uc.PlanRunner.PlanMetrics(ctx, input.ID, metricsSince(input.Days))
+	if err != nil {
+		return nil, mapErr(err)
+	}
+	return &PlanMetricsOutput{Body: m}, nil
+}
+
+func metricsSince(days int) time.Time {
+	window := plans.DefaultMetricsWindow
+	if days > 0 {
+		window = time.Duration(days) * 24 * time.Hour
+	}
+	return time.Now().UTC().Add(-window)
+}
+
+func (e *Endpoints) importPlan(ctx context.Context, input *ImportPlanInput) (*PlanOutput, error) {
+	p, err := e.uc.ImportPlan.Execute(ctx, input.Body.Document, input.Body.PlanID)
+	if err != nil {
+		return nil, mapErr(err)
+	}
+	return toPlanOutput(p), nil
+}
+
+func (e *Endpoints) exportPlan(ctx context.Context, input *ExportPlanInput) (*PlanDocumentOutput, error) {
+	doc, filename, err := e.uc.ExportPlan.Execute(ctx, input.ID, input.Version)
+	if err != nil {
+		return nil, mapErr(err)
+	}
+	return toPlanDocumentOutput(doc, filename), nil
+}
+
+func (e *Endpoints) listPlanRevisions(ctx context.Context, input *PlanIDInput) (*PlanRevisionsOutput, error) {
+	items, err := e.uc.ListPlanRevisions.Execute(ctx, input.ID)
+	if err != nil {
+		return nil, mapErr(err)
+	}
+	return &PlanRevisionsOutput{Body: items}, nil
+}
+
+func (e *Endpoints) listPlanTemplates(ctx context.Context, _ *struct{}) (*PlanTemplatesOutput, error) {
+	items, err := e.uc.PlanTemplates.List(ctx)
+	if err != nil {
+		return nil, mapErr(err)
+	}
+	return &PlanTemplatesOutput{Body: items}, nil
+}
+
+func (e *Endpoints) getPlanTemplate(ctx context.Context, input *PlanIDInput) (*PlanTemplateOutput, error) {
+	t, err := e.uc.PlanTemplates.Get(ctx, input.ID)
+	if err != nil {
+		return nil, mapErr(err)
+	}
+	return &PlanTemplateOutput{Body: t}, nil
+}
+
+func (e *Endpoints) createPlanTemplate(ctx context.Context, input *CreatePlanTemplateInput) (*PlanTemplateOutput, error) {
+	t, err := e.uc.PlanTemplates.SaveFromPlan(ctx, input.Body.PlanID, input.Body.Name, input.Body.Description)
+	if err != nil {
+		return nil, mapErr(err)
+	}
+	return &PlanTemplateOutput{Body: t}, nil
+}
+
+func (e *Endpoints) deletePlanTemplate(ctx context.Context, input *PlanIDInput) (*struct{}, error) {
+	if err := e.uc.PlanTemplates.Delete(ctx, input.ID); err != nil {
+		return nil, mapErr(err)
+	}
+	return nil, nil
+}
+
+func (e *Endpoints) instantiatePlanTemplate(ctx context.Context, input *InstantiatePlanTemplateInput) (*PlanOutput, error) {
+	p, err := e.uc.PlanTemplates.Instantiate(ctx, input.ID, input.Body)
+	if err != nil {
+		return nil, mapErr(err)
+	}
+	return toPlanOutput(p), nil
+}
+
+func (e *Endpoints) listPlanBlackouts(ctx context.Context, input *ListPlanBlackoutsInput) (*PlanBlackoutsOutput, error) {
+	items, err := e.uc.PlanBlackouts.List(ctx, input.PlanID)
+	if err != nil {
+		return nil, mapErr(err)
+	}
+	return toPlanBlackoutsOutput(items, time.Now()), nil
+}
+
+func (e *Endpoints) createPlanBlackout(ctx context.Context, input *PlanBlackoutInput) (*PlanBlackoutOutput, error) {
+	b, err := e.uc.PlanBlackouts.Create(ctx, planBlackoutFromInput("", input.Body))
+	if err != nil {
+		return nil, mapErr(err)
+	}
+	return toPlanBlackoutOutput(b, time.Now()), nil
+}
+
+func (e *Endpoints) updatePlanBlackout(ctx context.Context, input *UpdatePlanBlackoutInput) (*PlanBlackoutOutput, error) {
+	b, err := e.uc.PlanBlackouts.Update(ctx, planBlackoutFromInput(input.ID, input.Body))
+	if err != nil {
+		return nil, mapErr(err)
+	}
+	return toPlanBlackoutOutput(b, time.Now()), nil
+}
+
+func (e *Endpoints) deletePlanBlackout(ctx context.Context, input *PlanIDInput) (*struct{}, error) {
+	if err := e.uc.PlanBlackouts.Delete(ctx, input.ID); err != nil {
+		return nil, mapErr(err)
+	}
+	return nil, nil
+}
+
 func (e *Endpoints) listPlanRuns(ctx context.Context, input *ListPlanRunsInput) (*PlanRunsOutput, error) {
 	items, err := e.uc.ListPlanRuns.Execute(ctx, input.PlanID)
 	if err != nil {
@@ -417,7 +593,16 @@ func (e *Endpoints) triggerPlanRun(ctx context.Context, input *TriggerPlanRunInp
 	if e.uc.PlanRunner == nil {
 		return nil, huma.NewError(http.StatusServiceUnavailable, "plan runner not available")
 	}
-	run, err := e.uc.PlanRunner.TriggerRun(ctx, input.PlanID, "manual", input.Body.Input)
+	var run types.PlanRun
+	var err error
+	switch {
+	case input.Body.Simulate:
+		run, err = e.uc.PlanRunner.SimulateRun(ctx, input.PlanID, input.Body.Input, input.Body.Mocks)
+	case len(input.Body.Mocks) > 0:
+		return nil, huma.NewError(http.StatusUnprocessableEntity, "mocks only apply to simulation runs")
+	default:
+		run, err = e.uc.PlanRunner.TriggerRun(ctx, input.PlanID, "manual", input.Body.Input, input.Body.Force)
+	}
 	if err != nil {
 		return nil, mapErr(err)
 	}
@@ -443,6 +628,28 @@ func (e *Endpoints) cancelPlanRun(ctx context.Context, input *CancelPlanRunInput
 	return toPlanRunOutput(run), nil
 }
 
+func (e *Endpoints) resolvePlanApproval(ctx context.Context, input *ResolvePlanApprovalInput) (*PlanRunOutput, error) {
+	if e.uc.PlanRunner == nil {
+		return nil, huma.NewError(http.StatusServiceUnavailable, "plan runner not available")
+	}
+	run, err := e.uc.PlanRunner.ResolveApproval(ctx, input.ID, input.Body.Decision, "web", input.Body.Comment)
+	if err != nil {
+		return nil, mapErr(err)
+	}
+	return toPlanRunOutput(run), nil
+}
+
+func (e *Endpoints) planWebhook(ctx context.Context, input *PlanWebhookInput) (*PlanWebhookOutput, error) {
+	if e.uc.PlanRunner == nil {
+		return nil, huma.NewError(http.StatusServiceUnavailable, "plan runner not available")
+	}
+	res, err := e.uc.PlanRunner.TriggerWebhook(ctx, input.PlanID, webhookRequestFromInput(input))
+	if err != nil {
+		return nil, mapErr(err)
+	}
+	return toPlanWebhookOutput(res), nil
+}
+
 func (e *Endpoints) createGuardProfile(ctx context.Context, input *CreateGuardProfileInput) (*GuardProfileOutput, error) {
 	name, desc, caps, cmds := guardProfileFromCreateInput(input)
 	p, err := e.uc.CreateGuardProfile.Execute(ctx, name, desc, caps, cmds)
@@ -523,6 +730,10 @@ func mapErr(err error) error {
 		return huma.NewError(http.StatusNotFound, err.Error())
 	case errors.Is(err, base.ErrValidation):
 		return huma.NewError(http.StatusUnprocessableEntity, err.Error())
+	case errors.Is(err, base.ErrForbidden):
+		return huma.NewError(http.StatusForbidden, err.Error())
+	case errors.Is(err, base.ErrConflict):
+		return huma.NewError(http.StatusConflict, err.Error())
 	default:
 		return huma.NewError(http.StatusInternalServerError, err.Error())
 	}
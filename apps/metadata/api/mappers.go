@@ -2,7 +2,11 @@ package api
 
 import (
 	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
 
+	"mantis/apps/plans"
 	"mantis/core/types"
 )
 
@@ -75,14 +79,42 @@ func llmConnectionFromUpdateInput(input *UpdateLlmConnectionInput) (string, stri
 	return input.ID, input.Body.Provider, input.Body.BaseURL, input.Body.APIKey
 }
 
+func generationFromBody(b GenerationBody) types.GenerationOptions {
+	return types.GenerationOptions{
+		Temperature:       b.Temperature,
+		TopP:              b.TopP,
+		MaxTokens:         b.MaxTokens,
+		Stop:              b.Stop,
+		Seed:              b.Seed,
+		ToolChoice:        b.ToolChoice,
+		ResponseFormat:    b.ResponseFormat,
+		ParallelToolCalls: b.ParallelToolCalls,
+	}
+}
+
+func routingFromBody(b RoutingBody) types.PresetRouting {
+	return types.PresetRouting{Enabled: b.Enabled, LightModelID: b.LightModelID, TaskModelID: b.TaskModelID, EscalateAfter: b.EscalateAfter}
+}
+
+func priceFromBody(b *PriceBody) *types.ModelPrice {
+	if b == nil {
+		return nil
+	}
+	return &types.ModelPrice{Input: b.Input, Output: b.Output, CachedInput: b.CachedInput, CacheWrite: b.CacheWrite}
+}
+
 func modelFromCreateInput(input *CreateModelInput) types.Model {
 	return types.Model{
 		ConnectionID:  input.Body.ConnectionID,
 		Name:          input.Body.Name,
 		ThinkingMode:  input.Body.ThinkingMode,
+		ToolCalling:   input.Body.ToolCalling,
+		Vision:        input.Body.Vision,
 		ContextWindow: input.Body.ContextWindow,
 		ReserveTokens: input.Body.ReserveTokens,
 		CompactTokens: input.Body.CompactTokens,
+		Generation:    generationFromBody(input.Body.Generation),
+		Price:         priceFromBody(input.Body.Price),
 	}
 }
 
@@ -92,9 +124,13 @@ func modelFromUpdateInput(input *UpdateModelInput) types.Model {
 		ConnectionID:  input.Body.ConnectionID,
 		Name:          input.Body.Name,
 		ThinkingMode:  input.Body.ThinkingMode,
+		ToolCalling:   input.Body.ToolCalling,
+		Vision:        input.Body.Vision,
 		ContextWindow: input.Body.ContextWindow,
 		ReserveTokens: input.Body.ReserveTokens,
 		CompactTokens: input.Body.CompactTokens,
+		Generation:    generationFromBody(input.Body.Generation),
+		Price:         priceFromBody(input.Body.Price),
 	}
 }
 
@@ -151,6 +187,15 @@ func planFromCreateInput(input *CreatePlanInput) types.Plan {
 		Enabled:     input.Body.Enabled,
 		Parameters:  input.Body.Parameters,
 		Graph:       input.Body.Graph,
+		Timezone:    input.Body.Timezone,
+		Jitter:      input.Body.Jitter,
+		CatchUp:     input.Body.CatchUp,
+		Concurrency: input.Body.Concurrency,
+		Webhook:     input.Body.Webhook,
+		Notify:      input.Body.Notify,
+		SLA:         input.Body.SLA,
+		PresetID:    input.Body.PresetID,
+		ModelID:     input.Body.ModelID,
 	}
 }
 
@@ -163,9 +208,87 @@ func planFromUpdateInput(input *UpdatePlanInput) types.Plan {
 		Enabled:     input.Body.Enabled,
 		Parameters:  input.Body.Parameters,
 		Graph:       input.Body.Graph,
+		Timezone:    input.Body.Timezone,
+		Jitter:      input.Body.Jitter,
+		CatchUp:     input.Body.CatchUp,
+		Concurrency: input.Body.Concurrency,
+		Webhook:     input.Body.Webhook,
+		Notify:      input.Body.Notify,
+		SLA:         input.Body.SLA,
 	}
 }
 
+func webhookRequestFromInput(input *PlanWebhookInput) plans.WebhookRequest {
+	token := strings.TrimSpace(input.Token)
+	if token == "" {
+		token = strings.TrimSpace(input.MantisToken)
+	}
+	if token == "" && strings.HasPrefix(input.Authorization, "Bearer ") {
+		token = strings.TrimSpace(strings.TrimPrefix(input.Authorization, "Bearer "))
+	}
+	key := input.IdempotencyKey
+	if key == "" {
+		key = input.GitHubDelivery
+	}
+	return plans.WebhookRequest{
+		Source:         webhookSource(input),
+		Token:          token,
+		Signature:      input.Signature,
+		IdempotencyKey: key,
+		Body:           input.RawBody,
+	}
+}
+
+// webhookSource prefers an explicit ?source= and otherwise recognises the
+// senders we document (GitHub, Alertmanager, Grafana) by their headers.
+func webhookSource(input *PlanWebhookInput) string {
+	if input.Source != "" {
+		return input.Source
+	}
+	ua := strings.ToLower(input.UserAgent)
+	switch {
+	case input.GitHubEvent != "" || strings.HasPrefix(ua, "github-hookshot"):
+		return "github"
+	case strings.HasPrefix(ua, "alertmanager"):
+		return "alertmanager"
+	case strings.HasPrefix(ua, "grafana"):
+		return "grafana"
+	}
+	return ""
+}
+
+func toPlanWebhookOutput(res plans.WebhookResult) *PlanWebhookOutput {
+	out := &PlanWebhookOutput{}
+	out.Body.RunID = res.Run.ID
+	out.Body.Status = res.Run.Status
+	out.Body.Duplicate = res.Duplicate
+	return out
+}
+
+func toPlanDocumentOutput(doc []byte, filename string) *PlanDocumentOutput {
+	return &PlanDocumentOutput{
+		ContentType:        "application/yaml",
+		ContentDisposition: fmt.Sprintf("attachment; filename=%q", filename),
+		Body:               doc,
+	}
+}
+
+func toPlanSchedulePreviewOutput(times []time.Time) *PlanSchedulePreviewOutput {
+	out := &PlanSchedulePreviewOutput{}
+	out.Body.Times = times
+	return out
+}
+
+func toValidatePlanOutput(problems []string) *ValidatePlanOutput {
+	out := &ValidatePlanOutput{}
+	out.Body.Valid = len(problems) == 0
+	out.Body.Errors = problems
+	if out.Body.Errors == nil {
+		out.Body.Errors = []string{}
+	}
+	return out
+}
+
 func toPlanRunOutput(r types.PlanRun) *PlanRunOutput {
 	return &PlanRunOutput{Body: r}
 }
@@ -174,6 +297,42 @@ func toPlanRunsOutput(items []types.PlanRun) *PlanRunsOutput {
 	return &PlanRunsOutput{Body: items}
 }
 
+func toPlanBlackoutItem(b types.PlanBlackout, now time.Time) PlanBlackoutItem {
+	item := PlanBlackoutItem{PlanBlackout: b}
+	if until, ok := plans.BlackoutEnd(b, now); ok {
+		item.ActiveUntil = &until
+	}
+	return item
+}
+
+func toPlanBlackoutOutput(b types.PlanBlackout, now time.Time) *PlanBlackoutOutput {
+	return &PlanBlackoutOutput{Body: toPlanBlackoutItem(b, now)}
+}
+
+func toPlanBlackoutsOutput(items []types.PlanBlackout, now time.Time) *PlanBlackoutsOutput {
+	out := make([]PlanBlackoutItem, len(items))
+	for i, b := range items {
+		out[i] = toPlanBlackoutItem(b, now)
+	}
+	return &PlanBlackoutsOutput{Body: out}
+}
+
+func planBlackoutFromInput(id string, body PlanBlackoutBody) types.PlanBlackout {
+	enabled := body.Enabled == nil || *body.Enabled
+	return types.PlanBlackout{
+		ID:       id,
+		Name:     body.Name,
+		PlanID:   body.PlanID,
+		Enabled:  enabled,
+		Action:   body.Action,
+		StartsAt: body.StartsAt,
+		EndsAt:   body.EndsAt,
+		Schedule: body.Schedule,
+		Duration: body.Duration,
+		Timezone: body.Timezone,
+	}
+}
+
 func toGuardProfileOutput(p types.GuardProfile) *GuardProfileOutput {
 	return &GuardProfileOutput{Body: p}
 }
@@ -2,6 +2,7 @@ package api
 
 import (
 	"encoding/json"
+	"time"
 
 	"mantis/core/types"
 )
@@ -73,10 +74,15 @@ type CreateModelInput struct {
 	Body struct {
 		ConnectionID  string `json:"connectionId" required:"true" minLength:"1"`
 		Name          string `json:"name" required:"true" minLength:"1"`
-		ThinkingMode  string `json:"thinkingMode" enum:",skip,inline"`
+		ThinkingMode  string `json:"thinkingMode" enum:",skip,inline,extended"`
+		ToolCalling   string `json:"toolCalling,omitempty" enum:",native,prompt" doc:"How tools reach the model: native function calling (default) or listed in the system prompt for models without it"`
+		Vision        bool   `json:"vision,omitempty" doc:"The model takes images; images attached in chat are sent to it directly"`
 		ContextWindow int    `json:"contextWindow,omitempty" minimum:"0"`
 		ReserveTokens int    `json:"reserveTokens,omitempty" minimum:"0"`
 		CompactTokens int    `json:"compactTokens,omitempty" minimum:"0"`
+
+		Generation GenerationBody `json:"generation,omitempty" doc:"Default generation options for calls to this model"`
+		Price      *PriceBody     `json:"price,omitempty" doc:"Price in USD per million tokens, used to cost calls in the usage ledger"`
 	}
 }
 
@@ -85,13 +91,43 @@ type UpdateModelInput struct {
 	Body struct {
 		ConnectionID  string `json:"connectionId" required:"true" minLength:"1"`
 		Name          string `json:"name" required:"true" minLength:"1"`
-		ThinkingMode  string `json:"thinkingMode" enum:",skip,inline"`
+		ThinkingMode  string `json:"thinkingMode" enum:",skip,inline,extended"`
+		ToolCalling   string `json:"toolCalling,omitempty" enum:",native,prompt" doc:"How tools reach the model: native function calling (default) or listed in the system prompt for models without it"`
+		Vision        bool   `json:"vision,omitempty" doc:"The model takes images; images attached in chat are sent to it directly"`
 		ContextWindow int    `json:"contextWindow,omitempty" minimum:"0"`
 		ReserveTokens int    `json:"reserveTokens,omitempty" minimum:"0"`
 		CompactTokens int    `json:"compactTokens,omitempty" minimum:"0"`
+
+		Generation GenerationBody `json:"generation,omitempty" doc:"Default generation options for calls to this model"`
+		Price      *PriceBody     `json:"price,omitempty" doc:"Price in USD per million tokens, used to cost calls in the usage ledger"`
 	}
 }
 
+type GenerationBody struct {
+	Temperature       *float64 `json:"temperature,omitempty" minimum:"0" maximum:"2"`
+	TopP              *float64 `json:"topP,omitempty" minimum:"0" maximum:"1"`
+	MaxTokens         int      `json:"maxTokens,omitempty" minimum:"0"`
+	Stop              []string `json:"stop,omitempty" maxItems:"4"`
+	Seed              *int64   `json:"seed,omitempty"`
+	ToolChoice        string   `json:"toolChoice,omitempty" doc:"auto, none, required or the name of a tool to force"`
+	ResponseFormat    string   `json:"responseFormat,omitempty" enum:"text,json_object"`
+	ParallelToolCalls *bool    `json:"parallelToolCalls,omitempty"`
+}
+
+type RoutingBody struct {
+	Enabled       bool   `json:"enabled"`
+	LightModelID  string `json:"lightModelId,omitempty" doc:"Model for trivial chat; empty uses taskModelId"`
+	TaskModelID   string `json:"taskModelId,omitempty" doc:"Model for a single server task; empty uses the chat model"`
+	EscalateAfter int    `json:"escalateAfter,omitempty" minimum:"0" doc:"Move to the chat model after this many failed tool iterations; 0 never does"`
+}
+
+type PriceBody struct {
+	Input       float64 `json:"input" minimum:"0"`
+	Output      float64 `json:"output" minimum:"0"`
+	CachedInput float64 `json:"cachedInput,omitempty" minimum:"0" doc:"Defaults to the input price"`
+	CacheWrite  float64 `json:"cacheWrite,omitempty" minimum:"0" doc:"Defaults to the input price"`
+}
+
 type PresetOutput struct {
 	Body types.Preset
 }
@@ -106,26 +142,34 @@ type PresetIDInput struct {
 
 type CreatePresetInput struct {
 	Body struct {
-		Name            string   `json:"name" required:"true" minLength:"1"`
-		ChatModelID     string   `json:"chatModelId" required:"true" minLength:"1"`
-		SummaryModelID  string   `json:"summaryModelId"`
-		ImageModelID    string   `json:"imageModelId"`
-		FallbackModelID string   `json:"fallbackModelId"`
-		Temperature     *float64 `json:"temperature"`
-		SystemPrompt    string   `json:"systemPrompt"`
+		Name             string   `json:"name" required:"true" minLength:"1"`
+		ChatModelID      string   `json:"chatModelId" required:"true" minLength:"1"`
+		SummaryModelID   string   `json:"summaryModelId"`
+		ImageModelID     string   `json:"imageModelId"`
+		FallbackModelID  string   `json:"fallbackModelId"`
+		FallbackModelIDs []string `json:"fallbackModelIds,omitempty" doc:"Further fallback models, tried in order after fallbackModelId"`
+		Temperature      *float64 `json:"temperature"`
+		SystemPrompt     string   `json:"systemPrompt"`
+
+		Generation GenerationBody `json:"generation,omitempty" doc:"Overrides the generation options of the model in use"`
+		Routing    RoutingBody    `json:"routing,omitempty" doc:"Sends each chat message to the cheapest model tier that can handle it"`
 	}
 }
 
 type UpdatePresetInput struct {
 	ID   string `path:"id"`
 	Body struct {
-		Name            string   `json:"name" required:"true" minLength:"1"`
-		ChatModelID     string   `json:"chatModelId" required:"true" minLength:"1"`
-		SummaryModelID  string   `json:"summaryModelId"`
-		ImageModelID    string   `json:"imageModelId"`
-		FallbackModelID string   `json:"fallbackModelId"`
-		Temperature     *float64 `json:"temperature"`
-		SystemPrompt    string   `json:"systemPrompt"`
+		Name             string   `json:"name" required:"true" minLength:"1"`
+		ChatModelID      string   `json:"chatModelId" required:"true" minLength:"1"`
+		SummaryModelID   string   `json:"summaryModelId"`
+		ImageModelID     string   `json:"imageModelId"`
+		FallbackModelID  string   `json:"fallbackModelId"`
+		FallbackModelIDs []string `json:"fallbackModelIds,omitempty" doc:"Further fallback models, tried in order after fallbackModelId"`
+		Temperature      *float64 `json:"temperature"`
+		SystemPrompt     string   `json:"systemPrompt"`
+
+		Generation GenerationBody `json:"generation,omitempty" doc:"Overrides the generation options of the model in use"`
+		Routing    RoutingBody    `json:"routing,omitempty" doc:"Sends each chat message to the cheapest model tier that can handle it"`
 	}
 }
 
@@ -231,24 +275,168 @@ type PlanIDInput struct {
 
 type CreatePlanInput struct {
 	Body struct {
-		Name        string          `json:"name" required:"true" minLength:"1"`
-		Description string          `json:"description"`
-		Schedule    string          `json:"schedule"`
-		Enabled     bool            `json:"enabled"`
-		Parameters  json.RawMessage `json:"parameters"`
-		Graph       types.PlanGraph `json:"graph"`
+		Name        string                `json:"name" required:"true" minLength:"1"`
+		Description string                `json:"description"`
+		Schedule    string                `json:"schedule"`
+		Enabled     bool                  `json:"enabled"`
+		Parameters  json.RawMessage       `json:"parameters"`
+		Graph       types.PlanGraph       `json:"graph"`
+		Timezone    string                `json:"timezone,omitempty" doc:"IANA timezone the schedule is evaluated in; empty means server time"`
+		Jitter      string                `json:"jitter,omitempty" doc:"Random start delay of up to this duration for scheduled runs, e.g. 2m"`
+		CatchUp     types.PlanCatchUp     `json:"catchUp,omitempty" enum:"skip,run_once,run_all"`
+		Concurrency types.PlanConcurrency `json:"concurrency,omitempty" enum:"allow,skip,queue,cancel_previous"`
+		Webhook     *types.PlanWebhook    `json:"webhook,omitempty"`
+		Notify      *types.PlanNotify     `json:"notify,omitempty" doc:"Run report delivery; omit to keep the current setting, send an empty when to turn reports off"`
+		SLA         *types.PlanSLA        `json:"sla,omitempty" doc:"Max run duration and consecutive failures before an alert; omit to keep the current SLA, send an empty object to remove it"`
+		PresetID    string                `json:"presetId,omitempty" doc:"Preset for nodes that don't pin their own; empty uses the default chat preset"`
+		ModelID     string                `json:"modelId,omitempty" doc:"Model for nodes that don't pin their own; overrides presetId"`
 	}
 }
 
 type UpdatePlanInput struct {
 	ID   string `path:"id"`
 	Body struct {
-		Name        string          `json:"name" required:"true" minLength:"1"`
-		Description string          `json:"description"`
-		Schedule    string          `json:"schedule"`
-		Enabled     bool            `json:"enabled"`
-		Parameters  json.RawMessage `json:"parameters"`
-		Graph       types.PlanGraph `json:"graph"`
+		Name        string                `json:"name" required:"true" minLength:"1"`
+		Description string                `json:"description"`
+		Schedule    string                `json:"schedule"`
+		Enabled     bool                  `json:"enabled"`
+		Parameters  json.RawMessage       `json:"parameters"`
+		Graph       types.PlanGraph       `json:"graph"`
+		Timezone    string                `json:"timezone,omitempty" doc:"IANA timezone the schedule is evaluated in; empty means server time"`
+		Jitter      string                `json:"jitter,omitempty" doc:"Random start delay of up to this duration for scheduled runs, e.g. 2m"`
+		CatchUp     types.PlanCatchUp     `json:"catchUp,omitempty" enum:"skip,run_once,run_all"`
+		Concurrency types.PlanConcurrency `json:"concurrency,omitempty" enum:"allow,skip,queue,cancel_previous"`
+		Webhook     *types.PlanWebhook    `json:"webhook,omitempty"`
+		Notify      *types.PlanNotify     `json:"notify,omitempty" doc:"Run report delivery; omit to keep the current setting, send an empty when to turn reports off"`
+		SLA         *types.PlanSLA        `json:"sla,omitempty" doc:"Max run duration and consecutive failures before an alert; omit to keep the current SLA, send an empty object to remove it"`
+		PresetID    *string               `json:"presetId,omitempty" doc:"Preset for nodes that don't pin their own; omit to keep the current preset, send an empty string to use the default chat preset"`
+		ModelID     *string               `json:"modelId,omitempty" doc:"Model for nodes that don't pin their own; overrides presetId. Omit to keep the current model, send an empty string to clear it"`
+	}
+}
+
+type PlanSchedulePreviewInput struct {
+	Body struct {
+		Schedule string `json:"schedule" required:"true" minLength:"1"`
+		Timezone string `json:"timezone,omitempty"`
+		Count    int    `json:"count,omitempty" minimum:"0" maximum:"50" doc:"Number of fire times, default 5"`
+	}
+}
+
+type PlanSchedulePreviewOutput struct {
+	Body struct {
+		Times []time.Time `json:"times"`
+	}
+}
+
+type PlanMetricsInput struct {
+	ID   string `path:"id"`
+	Days int    `query:"days" minimum:"0" maximum:"365" doc:"Look-back window in days, default 30"`
+}
+
+type PlanMetricsOutput struct {
+	Body types.PlanMetrics
+}
+
+type AllPlanMetricsInput struct {
+	Days int `query:"days" minimum:"0" maximum:"365" doc:"Look-back window in days, default 30"`
+}
+
+type AllPlanMetricsOutput struct {
+	Body []types.PlanMetrics
+}
+
+type PlanRevisionsOutput struct {
+	Body []types.PlanRevision
+}
+
+type PlanTemplateOutput struct {
+	Body types.PlanTemplate
+}
+
+type PlanTemplatesOutput struct {
+	Body []types.PlanTemplate
+}
+
+type CreatePlanTemplateInput struct {
+	Body struct {
+		PlanID      string `json:"planId" minLength:"1" doc:"Plan to save as a template"`
+		Name        string `json:"name,omitempty" doc:"Template name; defaults to the plan's"`
+		Description string `json:"description,omitempty" doc:"Template description; defaults to the plan's"`
+	}
+}
+
+type InstantiatePlanTemplateInput struct {
+	ID   string `path:"id"`
+	Body types.PlanTemplateInstance
+}
+
+// PlanBlackoutItem is a blackout window with when it closes if it is open.
+type PlanBlackoutItem struct {
+	types.PlanBlackout
+	ActiveUntil *time.Time `json:"activeUntil,omitempty" doc:"Set while the window is open"`
+}
+
+type PlanBlackoutOutput struct {
+	Body PlanBlackoutItem
+}
+
+type PlanBlackoutsOutput struct {
+	Body []PlanBlackoutItem
+}
+
+type ListPlanBlackoutsInput struct {
+	PlanID string `query:"planId" doc:"Only global windows and this plan's"`
+}
+
+type PlanBlackoutBody struct {
+	Name     string                   `json:"name" minLength:"1"`
+	PlanID   string                   `json:"planId,omitempty" doc:"Plan the window applies to; all plans when empty"`
+	Enabled  *bool                    `json:"enabled,omitempty" doc:"Defaults to true"`
+	Action   types.PlanBlackoutAction `json:"action,omitempty" enum:"skip,defer" doc:"What happens to scheduled and webhook runs in the window; defaults to skip"`
+	StartsAt *time.Time               `json:"startsAt,omitempty" doc:"Start of a one-off window"`
+	EndsAt   *time.Time               `json:"endsAt,omitempty" doc:"End of a one-off window"`
+	Schedule string                   `json:"schedule,omitempty" doc:"Cron expression at which a recurring window opens"`
+	Duration string                   `json:"duration,omitempty" doc:"How long a recurring window stays open, e.g. 8h"`
+	Timezone string                   `json:"timezone,omitempty" doc:"IANA timezone of the schedule; server-local when empty"`
+}
+
+type PlanBlackoutInput struct {
+	Body PlanBlackoutBody
+}
+
+type UpdatePlanBlackoutInput struct {
+	ID   string `path:"id"`
+	Body PlanBlackoutBody
+}
+
+type ExportPlanInput struct {
+	ID      string `path:"id"`
+	Version int    `query:"version" minimum:"0" doc:"Export this revision instead of the current plan"`
+}
+
+type PlanDocumentOutput struct {
+	ContentType        string `header:"Content-Type"`
+	ContentDisposition string `header:"Content-Disposition"`
+	Body               []byte
+}
+
+type ImportPlanInput struct {
+	Body struct {
+		Document string `json:"document" minLength:"1" doc:"YAML plan document"`
+		PlanID   string `json:"planId,omitempty" doc:"Replace this plan instead of creating a new one"`
+	}
+}
+
+type ValidatePlanInput struct {
+	Body struct {
+		Document string `json:"document" doc:"YAML plan document"`
+	}
+}
+
+type ValidatePlanOutput struct {
+	Body struct {
+		Valid  bool     `json:"valid"`
+		Errors []string `json:"errors"`
 	}
 }
 
@@ -268,6 +456,14 @@ type CancelPlanRunInput struct {
 	ID string `path:"id"`
 }
 
+type ResolvePlanApprovalInput struct {
+	ID   string `path:"id"`
+	Body struct {
+		Decision string `json:"decision" enum:"approve,reject"`
+		Comment  string `json:"comment,omitempty"`
+	}
+}
+
 type ListPlanRunsInput struct {
 	PlanID string `path:"planId"`
 }
@@ -275,7 +471,32 @@ type ListPlanRunsInput struct {
 type TriggerPlanRunInput struct {
 	PlanID string `path:"planId"`
 	Body   struct {
-		Input map[string]any `json:"input,omitempty"`
+		Input    map[string]any    `json:"input,omitempty"`
+		Simulate bool              `json:"simulate,omitempty" doc:"Dry run: stub out SSH, upload and notification tools"`
+		Mocks    map[string]string `json:"mocks,omitempty" doc:"Canned results for stubbed tools, keyed by tool name or *"`
+		Force    bool              `json:"force,omitempty" doc:"Start the run even if the plan is in a blackout window"`
+	}
+}
+
+type PlanWebhookInput struct {
+	PlanID         string `path:"planId"`
+	Token          string `query:"token"`
+	Source         string `query:"source"`
+	Authorization  string `header:"Authorization"`
+	MantisToken    string `header:"X-Mantis-Token"`
+	Signature      string `header:"X-Hub-Signature-256"`
+	IdempotencyKey string `header:"Idempotency-Key"`
+	GitHubDelivery string `header:"X-GitHub-Delivery"`
+	GitHubEvent    string `header:"X-GitHub-Event"`
+	UserAgent      string `header:"User-Agent"`
+	RawBody        []byte
+}
+
+type PlanWebhookOutput struct {
+	Body struct {
+		RunID     string `json:"runId"`
+		Status    string `json:"status"`
+		Duplicate bool   `json:"duplicate"`
 	}
 }
 
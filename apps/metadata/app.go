@@ -27,11 +27,19 @@ func NewApp(
 	skillStore protocols.Store[string, types.Skill],
 	planStore protocols.Store[string, types.Plan],
 	runStore protocols.Store[string, types.PlanRun],
+	revisionStore protocols.Store[string, types.PlanRevision],
 	planRunner *plans.Runner,
+	planTemplates *plans.Templates,
+	planBlackouts *plans.Blackouts,
 	guardProfileStore protocols.Store[string, types.GuardProfile],
 	channelStore protocols.Store[string, types.Channel],
 	llmCatalogs map[string]protocols.LLMCatalog,
 ) *App {
+	createPlan := usecases.NewCreatePlan(planStore)
+	updatePlan := usecases.NewUpdatePlan(planStore)
+	if planTemplates != nil {
+		planTemplates.SetCreatePlan(createPlan.Execute)
+	}
 	return &App{
 		endpoints: api.NewEndpoints(api.UseCases{
 			GetSettings:        usecases.NewGetSettings(settingsStore),
@@ -63,13 +71,18 @@ func NewApp(
 			DeleteSkill:        usecases.NewDeleteSkill(skillStore),
 			AddMemory:          usecases.NewAddMemory(connectionStore),
 			DeleteMemory:       usecases.NewDeleteMemory(connectionStore),
-			CreatePlan:         usecases.NewCreatePlan(planStore),
+			CreatePlan:         createPlan,
 			ListPlans:          usecases.NewListPlans(planStore),
-			UpdatePlan:         usecases.NewUpdatePlan(planStore),
+			UpdatePlan:         updatePlan,
 			DeletePlan:         usecases.NewDeletePlan(planStore),
+			ExportPlan:         usecases.NewExportPlan(planStore, revisionStore),
+			ImportPlan:         usecases.NewImportPlan(createPlan, updatePlan),
+			ListPlanRevisions:  usecases.NewListPlanRevisions(revisionStore),
 			ListPlanRuns:       usecases.NewListPlanRuns(runStore),
 			GetPlanRun:         usecases.NewGetPlanRun(runStore),
 			PlanRunner:         planRunner,
+			PlanTemplates:      planTemplates,
+			PlanBlackouts:      planBlackouts,
 			CreateGuardProfile: usecases.NewCreateGuardProfile(guardProfileStore),
 			ListGuardProfiles:  usecases.NewListGuardProfiles(guardProfileStore),
 			UpdateGuardProfile: usecases.NewUpdateGuardProfile(guardProfileStore),
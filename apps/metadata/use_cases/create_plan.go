@@ -2,10 +2,14 @@ package usecases
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
 	"strings"
 
 	"github.com/google/uuid"
 
+	"mantis/apps/plans"
 	"mantis/core/base"
 	"mantis/core/protocols"
 	"mantis/core/types"
@@ -25,6 +29,33 @@ func (uc *CreatePlan) Execute(ctx context.Context, p types.Plan) (types.Plan, er
 	if strings.TrimSpace(p.Name) == "" {
 		return types.Plan{}, base.ErrValidation
 	}
+	if !plans.ValidConcurrency(p.Concurrency) {
+		return types.Plan{}, fmt.Errorf("%w: unknown concurrency policy %q", base.ErrValidation, p.Concurrency)
+	}
+	if p.Concurrency == "" {
+		p.Concurrency = types.PlanConcurrencyAllow
+	}
+	if err := plans.ValidateScheduleOptions(p); err != nil {
+		return types.Plan{}, fmt.Errorf("%w: %v", base.ErrValidation, err)
+	}
+	if p.CatchUp == "" {
+		p.CatchUp = types.PlanCatchUpSkip
+	}
+	webhook, err := prepareWebhook(p.Webhook, nil)
+	if err != nil {
+		return types.Plan{}, err
+	}
+	p.Webhook = webhook
+	notify, err := prepareNotify(p.Notify, nil)
+	if err != nil {
+		return types.Plan{}, err
+	}
+	p.Notify = notify
+	sla, err := prepareSLA(p.SLA, nil)
+	if err != nil {
+		return types.Plan{}, err
+	}
+	p.SLA = sla
 	result, err := uc.store.Create(ctx, []types.Plan{p})
 	if err != nil {
 		return types.Plan{}, err
@@ -36,6 +67,12 @@ func normalizePlan(p types.Plan) types.Plan {
 	p.Name = strings.TrimSpace(p.Name)
 	p.Description = strings.TrimSpace(p.Description)
 	p.Schedule = strings.TrimSpace(p.Schedule)
+	p.Timezone = strings.TrimSpace(p.Timezone)
+	p.Jitter = strings.TrimSpace(p.Jitter)
+	p.CatchUp = types.PlanCatchUp(strings.TrimSpace(string(p.CatchUp)))
+	p.Concurrency = types.PlanConcurrency(strings.TrimSpace(string(p.Concurrency)))
+	p.PresetID = strings.TrimSpace(p.PresetID)
+	p.ModelID = strings.TrimSpace(p.ModelID)
 	if p.Graph.Nodes == nil {
 		p.Graph.Nodes = []types.PlanNode{}
 	}
@@ -44,3 +81,73 @@ func normalizePlan(p types.Plan) types.Plan {
 	}
 	return p
 }
+
+// prepareWebhook validates an incoming webhook config and makes sure an
+// enabled webhook always has a secret: an omitted secret keeps the existing
+// one, or a fresh one is generated. A nil config keeps the existing config so
+// clients that don't know about webhooks don't wipe it on save.
+func prepareWebhook(hook, existing *types.PlanWebhook) (*types.PlanWebhook, error) {
+	if hook == nil {
+		return existing, nil
+	}
+	h := *hook
+	h.Secret = strings.TrimSpace(h.Secret)
+	h.IdempotencyKey = strings.TrimSpace(h.IdempotencyKey)
+	if err := plans.ValidateWebhook(h); err != nil {
+		return nil, err
+	}
+	if h.Secret == "" && existing != nil {
+		h.Secret = existing.Secret
+	}
+	if h.Secret == "" && h.Enabled {
+		secret, err := generateWebhookSecret()
+		if err != nil {
+			return nil, err
+		}
+		h.Secret = secret
+	}
+	return &h, nil
+}
+
+// prepareNotify validates run report settings. Like webhooks, a nil config
+// keeps the existing one; an empty "when" turns reports off.
+func prepareNotify(n, existing *types.PlanNotify) (*types.PlanNotify, error) {
+	if n == nil {
+		return existing, nil
+	}
+	v := *n
+	v.ChannelID = strings.TrimSpace(v.ChannelID)
+	v.Recipient = strings.TrimSpace(v.Recipient)
+	if err := plans.ValidateNotify(v); err != nil {
+		return nil, err
+	}
+	if v.When == "" {
+		return nil, nil
+	}
+	return &v, nil
+}
+
+// prepareSLA validates a plan's SLA. A nil SLA keeps the existing one; an
+// SLA with nothing set removes it.
+func prepareSLA(sla, existing *types.PlanSLA) (*types.PlanSLA, error) {
+	if sla == nil {
+		return existing, nil
+	}
+	v := *sla
+	v.MaxDuration = strings.TrimSpace(v.MaxDuration)
+	if err := plans.ValidateSLA(v); err != nil {
+		return nil, err
+	}
+	if v.MaxDuration == "" && v.MaxConsecutiveFailures == 0 {
+		return nil, nil
+	}
+	return &v, nil
+}
+
+func generateWebhookSecret() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate webhook secret: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,50 @@
+package usecases
+
+import (
+	"context"
+	"strconv"
+
+	"mantis/apps/plans"
+	"mantis/core/base"
+	"mantis/core/protocols"
+	"mantis/core/types"
+)
+
+type ExportPlan struct {
+	store     protocols.Store[string, types.Plan]
+	revisions protocols.Store[string, types.PlanRevision]
+}
+
+func NewExportPlan(store protocols.Store[string, types.Plan], revisions protocols.Store[string, types.PlanRevision]) *ExportPlan {
+	return &ExportPlan{store: store, revisions: revisions}
+}
+
+// Execute renders the plan as a YAML document together with a suggested file
+// name. A positive version exports that revision instead of the current plan.
+func (uc *ExportPlan) Execute(ctx context.Context, id string, version int) ([]byte, string, error) {
+	items, err := uc.store.Get(ctx, []string{id})
+	if err != nil {
+		return nil, "", err
+	}
+	p, ok := items[id]
+	if !ok {
+		return nil, "", base.ErrNotFound
+	}
+	if version > 0 {
+		revs, err := uc.revisions.List(ctx, types.ListQuery{
+			Filter: map[string]string{"plan_id": id, "version": strconv.Itoa(version)},
+		})
+		if err != nil {
+			return nil, "", err
+		}
+		if len(revs) == 0 {
+			return nil, "", base.ErrNotFound
+		}
+		p = revs[0].Plan
+	}
+	doc, err := plans.ExportPlan(p)
+	if err != nil {
+		return nil, "", err
+	}
+	return doc, plans.DocumentFileName(p), nil
+}
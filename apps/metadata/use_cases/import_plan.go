@@ -0,0 +1,47 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"mantis/apps/plans"
+	"mantis/core/base"
+	"mantis/core/types"
+)
+
+type ImportPlan struct {
+	create *CreatePlan
+	update *UpdatePlan
+}
+
+func NewImportPlan(create *CreatePlan, update *UpdatePlan) *ImportPlan {
+	return &ImportPlan{create: create, update: update}
+}
+
+// Execute creates a plan from a YAML document, or replaces the plan with the
+// given ID. Nothing is saved unless the document passes Validate.
+func (uc *ImportPlan) Execute(ctx context.Context, document, planID string) (types.Plan, error) {
+	p, err := plans.ParsePlanDocument([]byte(document))
+	if err != nil {
+		return types.Plan{}, err
+	}
+	if problems := plans.ValidatePlan(p); len(problems) > 0 {
+		return types.Plan{}, fmt.Errorf("%w: %s", base.ErrValidation, strings.Join(problems, "; "))
+	}
+	if planID = strings.TrimSpace(planID); planID != "" {
+		p.ID = planID
+		return uc.update.Replace(ctx, p)
+	}
+	return uc.create.Execute(ctx, p)
+}
+
+// Validate parses and checks a YAML document without saving it and returns
+// every problem found.
+func (uc *ImportPlan) Validate(document string) []string {
+	p, err := plans.ParsePlanDocument([]byte(document))
+	if err != nil {
+		return []string{strings.TrimPrefix(err.Error(), base.ErrValidation.Error()+": ")}
+	}
+	return plans.ValidatePlan(p)
+}
@@ -0,0 +1,79 @@
+package usecases
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"mantis/core/types"
+)
+
+// dropBlock removes a top-level key and everything indented under it from a
+// YAML document.
+func dropBlock(doc, key string) string {
+	var out []string
+	skipping := false
+	for _, line := range strings.Split(doc, "\n") {
+		if strings.HasPrefix(line, key+":") {
+			skipping = true
+			continue
+		}
+		if skipping && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "-")) {
+			continue
+		}
+		skipping = false
+		out = append(out, line)
+	}
+	return strings.Join(out, "\n")
+}
+
+func TestImportPlan_ReplacesOmittedSettings(t *testing.T) {
+	stored := types.Plan{
+		ID:          "p1",
+		Name:        "Nightly",
+		Schedule:    "0 3 * * *",
+		CatchUp:     types.PlanCatchUpRunOnce,
+		Concurrency: types.PlanConcurrencyQueue,
+		Webhook:     &types.PlanWebhook{Enabled: true, Secret: "s3cret"},
+		Notify:      &types.PlanNotify{When: types.PlanNotifyAlways, ChannelID: "tg"},
+		SLA:         &types.PlanSLA{MaxDuration: "1h"},
+		Graph: types.PlanGraph{
+			Nodes: []types.PlanNode{{ID: "a", Type: types.PlanNodeAction, Prompt: "check"}},
+			Edges: []types.PlanEdge{},
+		},
+	}
+	store := newPlanStore(stored)
+	doc, _, err := NewExportPlan(store, nil).Execute(context.Background(), "p1", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edited := string(doc)
+	for _, key := range []string{"notify", "sla", "concurrency", "catchUp"} {
+		if !strings.Contains(edited, "\n"+key+":") {
+			t.Fatalf("export has no %s block:\n%s", key, edited)
+		}
+		edited = dropBlock(edited, key)
+	}
+
+	update := NewUpdatePlan(store)
+	got, err := NewImportPlan(NewCreatePlan(store), update).Execute(context.Background(), edited, "p1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Notify != nil || got.SLA != nil {
+		t.Fatalf("blocks removed from the document must be cleared: notify=%+v sla=%+v", got.Notify, got.SLA)
+	}
+	if got.Concurrency != types.PlanConcurrencyAllow || got.CatchUp != types.PlanCatchUpSkip {
+		t.Fatalf("expected default policies, got %q %q", got.Concurrency, got.CatchUp)
+	}
+	if got.Webhook == nil || got.Webhook.Secret != "s3cret" {
+		t.Fatalf("the webhook secret must carry over, got %+v", got.Webhook)
+	}
+
+	if got, err = NewImportPlan(NewCreatePlan(store), update).Execute(context.Background(), dropBlock(edited, "webhook"), "p1"); err != nil {
+		t.Fatal(err)
+	}
+	if got.Webhook != nil {
+		t.Fatalf("a removed webhook block must clear the webhook, got %+v", got.Webhook)
+	}
+}
@@ -0,0 +1,27 @@
+package usecases
+
+import (
+	"context"
+
+	"mantis/core/protocols"
+	"mantis/core/types"
+)
+
+type ListPlanRevisions struct {
+	store protocols.Store[string, types.PlanRevision]
+}
+
+func NewListPlanRevisions(store protocols.Store[string, types.PlanRevision]) *ListPlanRevisions {
+	return &ListPlanRevisions{store: store}
+}
+
+func (uc *ListPlanRevisions) Execute(ctx context.Context, planID string) ([]types.PlanRevision, error) {
+	items, err := uc.store.List(ctx, types.ListQuery{
+		Filter: map[string]string{"plan_id": planID},
+		Sort:   []types.Sort{{Field: "version", Dir: types.SortDirDesc}},
+	})
+	if items == nil {
+		items = []types.PlanRevision{}
+	}
+	return items, err
+}
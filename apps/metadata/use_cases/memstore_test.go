@@ -0,0 +1,50 @@
+package usecases
+
+import (
+	"context"
+
+	"mantis/core/types"
+)
+
+// memStore is an in-memory store for tests; Create and Update save items
+// under the ID that id returns.
+type memStore[ID comparable, Entity any] struct {
+	data map[ID]Entity
+	id   func(Entity) ID
+}
+
+func (s *memStore[ID, Entity]) Create(ctx context.Context, items []Entity) ([]Entity, error) {
+	return s.Update(ctx, items)
+}
+func (s *memStore[ID, Entity]) Get(_ context.Context, ids []ID) (map[ID]Entity, error) {
+	out := make(map[ID]Entity)
+	for _, id := range ids {
+		if v, ok := s.data[id]; ok {
+			out[id] = v
+		}
+	}
+	return out, nil
+}
+func (s *memStore[ID, Entity]) List(_ context.Context, _ types.ListQuery) ([]Entity, error) {
+	return nil, nil
+}
+func (s *memStore[ID, Entity]) Update(_ context.Context, items []Entity) ([]Entity, error) {
+	for _, v := range items {
+		s.data[s.id(v)] = v
+	}
+	return items, nil
+}
+func (s *memStore[ID, Entity]) Delete(_ context.Context, ids []ID) error {
+	for _, id := range ids {
+		delete(s.data, id)
+	}
+	return nil
+}
+
+func newPlanStore(plans ...types.Plan) *memStore[string, types.Plan] {
+	s := &memStore[string, types.Plan]{data: map[string]types.Plan{}, id: func(p types.Plan) string { return p.ID }}
+	for _, p := range plans {
+		s.data[p.ID] = p
+	}
+	return s
+}
@@ -2,8 +2,10 @@ package usecases
 
 import (
 	"context"
+	"fmt"
 	"strings"
 
+	"mantis/apps/plans"
 	"mantis/core/base"
 	"mantis/core/protocols"
 	"mantis/core/types"
@@ -17,18 +19,81 @@ func NewUpdatePlan(store protocols.Store[string, types.Plan]) *UpdatePlan {
 	return &UpdatePlan{store: store}
 }
 
-func (uc *UpdatePlan) Execute(ctx context.Context, p types.Plan) (types.Plan, error) {
+// Execute saves p over the stored plan. Webhook, notify and SLA settings
+// left nil keep their current values, and so do the plan's default preset
+// and model when presetID and modelID are nil; an empty string clears them.
+// p's own PresetID and ModelID are not used.
+func (uc *UpdatePlan) Execute(ctx context.Context, p types.Plan, presetID, modelID *string) (types.Plan, error) {
+	return uc.save(ctx, p, presetID, modelID, true)
+}
+
+// Replace saves p as the whole plan, the way an imported document replaces
+// one: settings p leaves out are cleared or reset to their defaults. Only
+// the webhook secret carries over, since documents never contain it.
+func (uc *UpdatePlan) Replace(ctx context.Context, p types.Plan) (types.Plan, error) {
+	return uc.save(ctx, p, &p.PresetID, &p.ModelID, false)
+}
+
+// save stores p over the existing plan. With keep, settings p leaves out
+// keep their stored values; without, they fall back to the defaults.
+func (uc *UpdatePlan) save(ctx context.Context, p types.Plan, presetID, modelID *string, keep bool) (types.Plan, error) {
 	existing, err := uc.store.Get(ctx, []string{p.ID})
 	if err != nil {
 		return types.Plan{}, err
 	}
-	if _, ok := existing[p.ID]; !ok {
+	kept, ok := existing[p.ID]
+	if !ok {
 		return types.Plan{}, base.ErrNotFound
 	}
+	if !keep {
+		webhook := kept.Webhook
+		if p.Webhook == nil {
+			webhook = nil
+		}
+		kept = types.Plan{
+			Concurrency: types.PlanConcurrencyAllow,
+			CatchUp:     types.PlanCatchUpSkip,
+			Webhook:     webhook,
+		}
+	}
+	p.PresetID, p.ModelID = kept.PresetID, kept.ModelID
+	if presetID != nil {
+		p.PresetID = *presetID
+	}
+	if modelID != nil {
+		p.ModelID = *modelID
+	}
 	p = normalizePlan(p)
 	if strings.TrimSpace(p.Name) == "" {
 		return types.Plan{}, base.ErrValidation
 	}
+	if !plans.ValidConcurrency(p.Concurrency) {
+		return types.Plan{}, fmt.Errorf("%w: unknown concurrency policy %q", base.ErrValidation, p.Concurrency)
+	}
+	if p.Concurrency == "" {
+		p.Concurrency = kept.Concurrency
+	}
+	if err := plans.ValidateScheduleOptions(p); err != nil {
+		return types.Plan{}, fmt.Errorf("%w: %v", base.ErrValidation, err)
+	}
+	if p.CatchUp == "" {
+		p.CatchUp = kept.CatchUp
+	}
+	webhook, err := prepareWebhook(p.Webhook, kept.Webhook)
+	if err != nil {
+		return types.Plan{}, err
+	}
+	p.Webhook = webhook
+	notify, err := prepareNotify(p.Notify, kept.Notify)
+	if err != nil {
+		return types.Plan{}, err
+	}
+	p.Notify = notify
+	sla, err := prepareSLA(p.SLA, kept.SLA)
+	if err != nil {
+		return types.Plan{}, err
+	}
+	p.SLA = sla
 	result, err := uc.store.Update(ctx, []types.Plan{p})
 	if err != nil {
 		return types.Plan{}, err
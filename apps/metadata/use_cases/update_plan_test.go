@@ -0,0 +1,45 @@
+package usecases
+
+import (
+	"context"
+	"testing"
+
+	"mantis/core/types"
+)
+
+func TestUpdatePlan_PresetAndModel(t *testing.T) {
+	stored := types.Plan{
+		ID:       "p1",
+		Name:     "Nightly",
+		PresetID: "cheap",
+		ModelID:  "gpt-mini",
+		Notify:   &types.PlanNotify{When: "failure", ChannelID: "tg"},
+	}
+	edit := types.Plan{ID: "p1", Name: "Nightly backup"}
+	empty, other := "", "  careful  "
+
+	cases := map[string]struct {
+		presetID, modelID *string
+		wantPreset        string
+		wantModel         string
+	}{
+		"omitted keeps both": {nil, nil, "cheap", "gpt-mini"},
+		"empty clears":       {&empty, &empty, "", ""},
+		"one at a time":      {&other, nil, "careful", "gpt-mini"},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			store := newPlanStore(stored)
+			got, err := NewUpdatePlan(store).Execute(context.Background(), edit, tc.presetID, tc.modelID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.PresetID != tc.wantPreset || got.ModelID != tc.wantModel {
+				t.Fatalf("preset, model = %q, %q; want %q, %q", got.PresetID, got.ModelID, tc.wantPreset, tc.wantModel)
+			}
+			if got.Name != "Nightly backup" || got.Notify == nil || got.Notify.ChannelID != "tg" {
+				t.Fatalf("unexpected plan: %+v", got)
+			}
+		})
+	}
+}
@@ -29,7 +29,6 @@ type App struct {
 	mu       sync.Mutex
 	sched    *robcron.Cron
 	entries  map[string]robcron.EntryID
-	parser   robcron.Parser
 	syncFreq time.Duration
 }
 
@@ -41,6 +40,7 @@ func NewApp(
 	presetStore protocols.Store[string, types.Preset],
 	planStore protocols.Store[string, types.Plan],
 	runStore protocols.Store[string, types.PlanRun],
+	channelStore protocols.Store[string, types.Channel],
 	agent *agents.MantisAgent,
 	artifactMgr *artifactplugin.Manager,
 	memoryExtractor pipeline.MemoryExtractor,
@@ -54,14 +54,12 @@ func NewApp(
 	modelResolver := modelplugin.NewResolver(nil, settingsStore, presetStore)
 	workflow := messageworkflow.New(messageStore, modelStore, sessionStore, agent, buf, modelResolver, artifactMgr, memoryExtractor, summ, nil)
 	sessionPolicy := sessionplugin.NewPolicy(sessionStore)
-	parser := robcron.NewParser(robcron.Minute | robcron.Hour | robcron.Dom | robcron.Month | robcron.Dow | robcron.Descriptor)
 
 	return &App{
 		workflow:  workflow,
-		runner:    NewRunner(planStore, runStore, messageStore, sessionPolicy, workflow, buf, agent.Limits()),
+		runner:    NewRunner(planStore, runStore, messageStore, channelStore, sessionPolicy, workflow, buf, agent.Limits()),
 		planStore: planStore,
 		entries:   make(map[string]robcron.EntryID),
-		parser:    parser,
 		syncFreq:  30 * time.Second,
 	}
 }
@@ -74,11 +72,26 @@ func (a *App) SetAttachmentDir(dir string) {
 	a.workflow.SetAttachmentDir(dir)
 }
 
+func (a *App) SetPublicURL(url string) {
+	a.runner.SetPublicURL(url)
+}
+
+func (a *App) SetBlackouts(b *Blackouts) {
+	a.runner.SetBlackouts(b)
+}
+
 func (a *App) Start(ctx context.Context) {
 	a.runner.RecoverStaleRuns(ctx)
+	a.runner.startDeferredRuns(ctx)
+
+	if allPlans, err := a.planStore.List(ctx, types.ListQuery{}); err != nil {
+		log.Printf("plans: catch-up: %v", err)
+	} else {
+		a.catchUp(ctx, allPlans, time.Now())
+	}
 
 	a.mu.Lock()
-	a.sched = robcron.New(robcron.WithParser(a.parser))
+	a.sched = robcron.New(robcron.WithParser(scheduleParser))
 	a.mu.Unlock()
 
 	a.syncPlans(ctx)
@@ -100,6 +113,7 @@ func (a *App) Start(ctx context.Context) {
 			return
 		case <-ticker.C:
 			a.syncPlans(ctx)
+			a.runner.startDeferredRuns(ctx)
 		}
 	}
 }
@@ -127,21 +141,31 @@ func (a *App) syncPlans(ctx context.Context) {
 			continue
 		}
 		plan := p
-		eid, err := a.sched.AddFunc(plan.Schedule, func() {
-			a.executePlan(context.Background(), plan)
-		})
+		sched, err := ParseSchedule(plan.Schedule, plan.Timezone)
 		if err != nil {
 			log.Printf("plans: invalid schedule for %s: %v", plan.ID, err)
 			continue
 		}
-		next[plan.ID] = eid
+		next[plan.ID] = a.sched.Schedule(sched, robcron.FuncJob(func() {
+			a.executePlan(ctx, plan)
+		}))
 	}
 	a.entries = next
 }
 
+// executePlan runs in a goroutine of its own, which cron starts for every
+// job, so the jitter wait only delays this plan. ctx is the scheduler's and
+// only bounds that wait.
 func (a *App) executePlan(ctx context.Context, plan types.Plan) {
+	if d := jitterDelay(plan); d > 0 {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(d):
+		}
+	}
 	log.Printf("plans: triggering scheduled run for plan=%s (%s)", plan.ID, plan.Name)
-	_, err := a.runner.TriggerRun(ctx, plan.ID, "schedule", nil)
+	_, err := a.runner.TriggerRun(context.Background(), plan.ID, "schedule", nil, false)
 	if err != nil {
 		log.Printf("plans: trigger run for plan=%s: %v", plan.ID, err)
 	}
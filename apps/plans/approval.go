@@ -0,0 +1,220 @@
+package plans
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"mantis/core/base"
+	"mantis/core/types"
+	adapter "mantis/infrastructure/adapters/channel"
+)
+
+const (
+	maxApprovalContext = 3000
+	approvalNotifyWait = 15 * time.Second
+	resolveWait        = 5 * time.Second
+)
+
+type approvalDecision struct {
+	decision   string
+	resolvedBy string
+	comment    string
+	done       chan struct{}
+}
+
+// ResolveApproval answers the approval step a run is waiting on. decision is
+// "approve" or "reject"; resolvedBy records who answered (e.g. "web",
+// "telegram:<chat>").
+func (r *Runner) ResolveApproval(ctx context.Context, runID, decision, resolvedBy, comment string) (types.PlanRun, error) {
+	decision = strings.ToLower(strings.TrimSpace(decision))
+	if decision != types.PlanEdgeApprove && decision != types.PlanEdgeReject {
+		return types.PlanRun{}, fmt.Errorf("%w: decision must be %q or %q", base.ErrValidation, types.PlanEdgeApprove, types.PlanEdgeReject)
+	}
+
+	r.mu.Lock()
+	ch, waiting := r.approvals[runID]
+	delete(r.approvals, runID)
+	r.mu.Unlock()
+
+	if waiting {
+		d := approvalDecision{
+			decision:   decision,
+			resolvedBy: strings.TrimSpace(resolvedBy),
+			comment:    strings.TrimSpace(comment),
+			done:       make(chan struct{}),
+		}
+		ch <- d
+		select {
+		case <-d.done:
+		case <-time.After(resolveWait):
+		}
+	}
+
+	runs, err := r.runStore.Get(ctx, []string{runID})
+	if err != nil {
+		return types.PlanRun{}, err
+	}
+	run, ok := runs[runID]
+	if !ok {
+		return types.PlanRun{}, base.ErrNotFound
+	}
+	if !waiting {
+		return types.PlanRun{}, fmt.Errorf("%w: run is %s, not waiting for approval", base.ErrConflict, run.Status)
+	}
+	return run, nil
+}
+
+// awaitApproval parks the run in "waiting" until someone resolves the
+// approval, the node's timeout fires or the run is cancelled. A waiting run
+// keeps its plan busy for concurrency policies but frees its slot in the
+// global run limit.
+func (r *Runner) awaitApproval(ctx context.Context, plan types.Plan, run *types.PlanRun, node types.PlanNode, message, lastOutput string) (string, error) {
+	ch := make(chan approvalDecision, 1)
+	r.mu.Lock()
+	r.approvals[run.ID] = ch
+	r.mu.Unlock()
+
+	timeout := nodeTimeout(node, r.limits.PlanApprovalTimeout)
+	approval := &types.PlanApproval{
+		Message:   message,
+		Context:   clip(strings.TrimSpace(lastOutput), maxApprovalContext),
+		ExpiresAt: time.Now().UTC().Add(timeout),
+	}
+	r.setStepApproval(run, node.ID, "waiting", approval)
+	run.Status = "waiting"
+	r.saveRun(run)
+	r.setWaiting(run.ID, true)
+	defer r.setWaiting(run.ID, false)
+
+	if !isSimulation(*run) {
+		r.notifyApproval(ctx, plan, *run, node, approval)
+	}
+	log.Printf("plans: run %s waiting for approval at node %s (timeout %s)", run.ID, node.ID, timeout)
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	var d approvalDecision
+	select {
+	case d = <-ch:
+	case <-timer.C:
+		if r.claimApproval(run.ID) {
+			d = approvalDecision{decision: approvalTimeoutBranch(node), resolvedBy: "timeout"}
+		} else {
+			d = <-ch
+		}
+	case <-ctx.Done():
+		if !r.claimApproval(run.ID) {
+			d = <-ch
+			close(d.done)
+		}
+		return "", ctx.Err()
+	}
+
+	now := time.Now().UTC()
+	approval.Decision = d.decision
+	approval.ResolvedBy = d.resolvedBy
+	approval.Comment = d.comment
+	approval.ResolvedAt = &now
+	run.Status = "running"
+	r.setStepApproval(run, node.ID, "completed", approval)
+	r.saveRun(run)
+	if d.done != nil {
+		close(d.done)
+	}
+	log.Printf("plans: run %s approval at node %s resolved: %s by %s", run.ID, node.ID, d.decision, d.resolvedBy)
+	return d.decision, nil
+}
+
+// claimApproval removes the run's pending approval so no resolver can answer
+// it any more. It returns false when a resolver got there first, in which case
+// its decision is already buffered on the channel.
+func (r *Runner) claimApproval(runID string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.approvals[runID]; !ok {
+		return false
+	}
+	delete(r.approvals, runID)
+	return true
+}
+
+func (r *Runner) setStepApproval(run *types.PlanRun, nodeID, status string, approval *types.PlanApproval) {
+	now := time.Now().UTC()
+	for i := range run.Steps {
+		if run.Steps[i].NodeID == nodeID {
+			run.Steps[i].Status = status
+			run.Steps[i].Approval = approval
+			if approval.Decision != "" {
+				run.Steps[i].Result = approval.Decision
+				run.Steps[i].FinishedAt = &now
+			}
+			break
+		}
+	}
+}
+
+func approvalTimeoutBranch(node types.PlanNode) string {
+	if strings.EqualFold(strings.TrimSpace(node.OnTimeout), types.PlanEdgeApprove) {
+		return types.PlanEdgeApprove
+	}
+	return types.PlanEdgeReject
+}
+
+// notifyApproval asks for a decision on Telegram when a bot with an allowed
+// user is configured. The web UI shows waiting runs regardless, so delivery
+// failures are only logged.
+func (r *Runner) notifyApproval(ctx context.Context, plan types.Plan, run types.PlanRun, node types.PlanNode, approval *types.PlanApproval) {
+	if r.channelStore == nil {
+		return
+	}
+	token, chatID, ok := r.approvalRecipient(ctx)
+	if !ok {
+		return
+	}
+	markup, _ := json.Marshal(map[string]any{
+		"inline_keyboard": [][]map[string]string{{
+			{"text": "Approve", "callback_data": "plan:approve:" + run.ID},
+			{"text": "Reject", "callback_data": "plan:reject:" + run.ID},
+		}},
+	})
+	sendCtx, cancel := context.WithTimeout(ctx, approvalNotifyWait)
+	defer cancel()
+	sender := adapter.NewTelegramResponseTo(token, strconv.FormatInt(chatID, 10))
+	if err := sender.SendWithMarkup(sendCtx, approvalText(plan, run, node, approval), markup); err != nil {
+		log.Printf("plans: approval notification for run %s: %v", run.ID, err)
+	}
+}
+
+func (r *Runner) approvalRecipient(ctx context.Context) (string, int64, bool) {
+	channels, err := r.channelStore.List(ctx, types.ListQuery{})
+	if err != nil {
+		log.Printf("plans: load channels for approval: %v", err)
+		return "", 0, false
+	}
+	for _, ch := range channels {
+		if ch.Type == "telegram" && strings.TrimSpace(ch.Token) != "" && len(ch.AllowedUserIDs) > 0 {
+			return strings.TrimSpace(ch.Token), ch.AllowedUserIDs[0], true
+		}
+	}
+	return "", 0, false
+}
+
+func approvalText(plan types.Plan, run types.PlanRun, node types.PlanNode, approval *types.PlanApproval) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Plan %q is waiting for approval\n\nStep: %s\n", plan.Name, nodeName(node))
+	if approval.Message != "" {
+		fmt.Fprintf(&sb, "\n%s\n", approval.Message)
+	}
+	if approval.Context != "" {
+		fmt.Fprintf(&sb, "\nContext so far:\n%s\n", approval.Context)
+	}
+	fmt.Fprintf(&sb, "\nRun %s — expires %s. Reply /approve %s or /reject %s [comment].",
+		run.ID[:min(8, len(run.ID))], approval.ExpiresAt.Format(time.RFC3339), run.ID, run.ID)
+	return sb.String()
+}
@@ -0,0 +1,170 @@
+package plans
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"mantis/core/base"
+	"mantis/core/types"
+	"mantis/shared"
+)
+
+func newApprovalRunner(timeout time.Duration) (*Runner, *memStore[types.PlanRun]) {
+	store := newRunStore()
+	limits := shared.DefaultLimits()
+	limits.PlanApprovalTimeout = timeout
+	return &Runner{
+		runStore:  store,
+		limits:    limits,
+		active:    map[string]string{},
+		waiting:   map[string]bool{},
+		approvals: map[string]chan approvalDecision{},
+	}, store
+}
+
+func approvalRun() (types.PlanRun, types.PlanNode) {
+	node := types.PlanNode{ID: "gate", Type: types.PlanNodeApproval, Label: "Sign-off"}
+	return types.PlanRun{
+		ID:     "run-1",
+		Status: "running",
+		Steps:  []types.PlanStepRun{{NodeID: "gate", Status: "running"}},
+	}, node
+}
+
+func waitForStatus(t *testing.T, store *memStore[types.PlanRun], runID, status string) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		runs, _ := store.Get(context.Background(), []string{runID})
+		if runs[runID].Status == status {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("run %s never reached status %q", runID, status)
+}
+
+// --- awaitApproval / ResolveApproval ---
+
+func TestAwaitApproval_Resolved(t *testing.T) {
+	r, store := newApprovalRunner(time.Hour)
+	run, node := approvalRun()
+
+	result := make(chan string, 1)
+	go func() {
+		d, _ := r.awaitApproval(context.Background(), types.Plan{}, &run, node, "Apply the change?", "diff: +1 -1")
+		result <- d
+	}()
+	waitForStatus(t, store, run.ID, "waiting")
+
+	got, err := r.ResolveApproval(context.Background(), run.ID, "approve", "web", "looks good")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := <-result; d != types.PlanEdgeApprove {
+		t.Fatalf("expected approve, got %q", d)
+	}
+	step := got.Steps[0]
+	if got.Status != "running" || step.Status != "completed" || step.Approval == nil {
+		t.Fatalf("unexpected run state: %+v", got)
+	}
+	if step.Approval.ResolvedBy != "web" || step.Approval.Comment != "looks good" || step.Approval.Context != "diff: +1 -1" {
+		t.Fatalf("approval not recorded: %+v", step.Approval)
+	}
+
+	if _, err := r.ResolveApproval(context.Background(), run.ID, "reject", "web", ""); !errors.Is(err, base.ErrConflict) {
+		t.Fatalf("second resolve should conflict, got %v", err)
+	}
+}
+
+func TestAwaitApproval_Timeout(t *testing.T) {
+	r, _ := newApprovalRunner(10 * time.Millisecond)
+	run, node := approvalRun()
+
+	d, err := r.awaitApproval(context.Background(), types.Plan{}, &run, node, "", "")
+	if err != nil || d != types.PlanEdgeReject {
+		t.Fatalf("expected reject on timeout, got %q %v", d, err)
+	}
+	if run.Steps[0].Approval.ResolvedBy != "timeout" {
+		t.Fatalf("expected timeout resolver, got %+v", run.Steps[0].Approval)
+	}
+
+	node.OnTimeout = "approve"
+	run, _ = approvalRun()
+	if d, _ := r.awaitApproval(context.Background(), types.Plan{}, &run, node, "", ""); d != types.PlanEdgeApprove {
+		t.Fatalf("expected approve on timeout, got %q", d)
+	}
+}
+
+func TestAwaitApproval_Cancelled(t *testing.T) {
+	r, _ := newApprovalRunner(time.Hour)
+	run, node := approvalRun()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := r.awaitApproval(ctx, types.Plan{}, &run, node, "", ""); err == nil {
+		t.Fatal("expected cancellation")
+	}
+	if len(r.approvals) != 0 || len(r.waiting) != 0 {
+		t.Fatal("cancelled approval should be cleaned up")
+	}
+}
+
+func TestResolveApproval_Invalid(t *testing.T) {
+	r, store := newApprovalRunner(time.Hour)
+	if _, err := r.ResolveApproval(context.Background(), "run-1", "maybe", "web", ""); !errors.Is(err, base.ErrValidation) {
+		t.Fatalf("expected validation error, got %v", err)
+	}
+	if _, err := r.ResolveApproval(context.Background(), "missing", "approve", "web", ""); !errors.Is(err, base.ErrNotFound) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+	store.put(types.PlanRun{ID: "done", Status: "completed"})
+	if _, err := r.ResolveApproval(context.Background(), "done", "approve", "web", ""); !errors.Is(err, base.ErrConflict) {
+		t.Fatalf("expected conflict, got %v", err)
+	}
+}
+
+// --- validateGraph ---
+
+func TestValidateGraph_ApprovalEdges(t *testing.T) {
+	g := types.PlanGraph{
+		Nodes: []types.PlanNode{
+			{ID: "prepare", Type: types.PlanNodeAction},
+			{ID: "gate", Type: types.PlanNodeApproval},
+			{ID: "apply", Type: types.PlanNodeAction},
+			{ID: "notify", Type: types.PlanNodeAction},
+		},
+		Edges: []types.PlanEdge{
+			{ID: "e1", Source: "prepare", Target: "gate"},
+			{ID: "e2", Source: "gate", Target: "apply", Label: "approve"},
+			{ID: "e3", Source: "gate", Target: "notify", Label: "reject"},
+		},
+	}
+	if err := validateGraph(g); err != nil {
+		t.Fatal(err)
+	}
+	g.Edges[2].Label = "no"
+	if err := validateGraph(g); err == nil {
+		t.Fatal("expected error for non approve/reject label")
+	}
+}
+
+func TestFindLabeledEdge_NoFallback(t *testing.T) {
+	g := types.PlanGraph{Edges: []types.PlanEdge{{ID: "e1", Source: "gate", Target: "apply", Label: "approve"}}}
+	if got := findLabeledEdge(g, "gate", "reject"); got != "" {
+		t.Fatalf("reject must not fall back to the approve edge, got %q", got)
+	}
+}
+
+func TestAtCapacity_IgnoresWaitingRuns(t *testing.T) {
+	r := newQueueRunner(1)
+	r.active["r1"] = "p1"
+	if !r.atCapacityLocked() {
+		t.Fatal("expected capacity reached")
+	}
+	r.waiting["r1"] = true
+	if r.atCapacityLocked() {
+		t.Fatal("waiting runs should not hold a global slot")
+	}
+}
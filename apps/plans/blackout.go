@@ -0,0 +1,352 @@
+package plans
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"mantis/core/base"
+	"mantis/core/protocols"
+	"mantis/core/types"
+)
+
+const (
+	// maxBlackoutDuration bounds a recurring window; a longer freeze is a
+	// one-off window with explicit dates.
+	maxBlackoutDuration = 31 * 24 * time.Hour
+	// maxWindowMerge bounds how many back-to-back recurring windows are
+	// joined when working out when a blackout ends.
+	maxWindowMerge = 100
+)
+
+// ValidateBlackout checks a blackout window: a one-off window needs both
+// ends, a recurring one a schedule and a positive duration, and not both.
+func ValidateBlackout(b types.PlanBlackout) error {
+	if strings.TrimSpace(b.Name) == "" {
+		return fmt.Errorf("name is required")
+	}
+	switch b.Action {
+	case types.PlanBlackoutSkip, types.PlanBlackoutDefer:
+	default:
+		return fmt.Errorf("unknown action %q (use skip or defer)", b.Action)
+	}
+	if tz := strings.TrimSpace(b.Timezone); tz != "" {
+		if _, err := time.LoadLocation(tz); err != nil {
+			return fmt.Errorf("unknown timezone %q", tz)
+		}
+	}
+	if strings.TrimSpace(b.Schedule) == "" {
+		if b.StartsAt == nil || b.EndsAt == nil {
+			return fmt.Errorf("set startsAt and endsAt, or a schedule and duration for a recurring window")
+		}
+		if !b.EndsAt.After(*b.StartsAt) {
+			return fmt.Errorf("endsAt must be after startsAt")
+		}
+		if strings.TrimSpace(b.Duration) != "" {
+			return fmt.Errorf("duration only applies to a recurring window")
+		}
+		return nil
+	}
+	if b.StartsAt != nil || b.EndsAt != nil {
+		return fmt.Errorf("a recurring window takes a schedule and duration, not startsAt and endsAt")
+	}
+	if _, err := ParseSchedule(b.Schedule, b.Timezone); err != nil {
+		return fmt.Errorf("invalid schedule %q: %v", b.Schedule, err)
+	}
+	d, err := time.ParseDuration(strings.TrimSpace(b.Duration))
+	if err != nil {
+		return fmt.Errorf("invalid duration %q: use a duration like 8h or 64h", b.Duration)
+	}
+	if d <= 0 || d > maxBlackoutDuration {
+		return fmt.Errorf("duration %q must be between 0 and %s", b.Duration, maxBlackoutDuration)
+	}
+	return nil
+}
+
+// BlackoutEnd reports whether a window is open at t and, if so, when it
+// closes. Recurring windows that overlap or touch are treated as one.
+func BlackoutEnd(b types.PlanBlackout, t time.Time) (time.Time, bool) {
+	if !b.Enabled {
+		return time.Time{}, false
+	}
+	if strings.TrimSpace(b.Schedule) == "" {
+		if b.StartsAt == nil || b.EndsAt == nil || t.Before(*b.StartsAt) || !t.Before(*b.EndsAt) {
+			return time.Time{}, false
+		}
+		return *b.EndsAt, true
+	}
+	sched, err := ParseSchedule(b.Schedule, b.Timezone)
+	if err != nil {
+		return time.Time{}, false
+	}
+	d, err := time.ParseDuration(strings.TrimSpace(b.Duration))
+	if err != nil || d <= 0 {
+		return time.Time{}, false
+	}
+	start := sched.Next(t.Add(-d))
+	if start.IsZero() || start.After(t) {
+		return time.Time{}, false
+	}
+	end := start.Add(d)
+	for range maxWindowMerge {
+		next := sched.Next(start)
+		if next.IsZero() || next.After(end) {
+			break
+		}
+		start, end = next, next.Add(d)
+	}
+	return end, true
+}
+
+// activeBlackout picks, among the windows covering a plan, the open one that
+// closes last.
+func activeBlackout(windows []types.PlanBlackout, planID string, t time.Time) (types.PlanBlackout, time.Time, bool) {
+	var found types.PlanBlackout
+	var until time.Time
+	for _, b := range windows {
+		if b.PlanID != "" && b.PlanID != planID {
+			continue
+		}
+		if end, ok := BlackoutEnd(b, t); ok && end.After(until) {
+			found, until = b, end
+		}
+	}
+	return found, until, !until.IsZero()
+}
+
+// Blackouts is the set of blackout windows, global and per plan.
+type Blackouts struct {
+	store     protocols.Store[string, types.PlanBlackout]
+	planStore protocols.Store[string, types.Plan]
+}
+
+func NewBlackouts(store protocols.Store[string, types.PlanBlackout], planStore protocols.Store[string, types.Plan]) *Blackouts {
+	return &Blackouts{store: store, planStore: planStore}
+}
+
+// List returns all windows, or with planID the global ones and that plan's.
+func (b *Blackouts) List(ctx context.Context, planID string) ([]types.PlanBlackout, error) {
+	items, err := b.store.List(ctx, types.ListQuery{
+		Sort: []types.Sort{{Field: "created_at", Dir: types.SortDirAsc}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := []types.PlanBlackout{}
+	for _, it := range items {
+		if planID == "" || it.PlanID == "" || it.PlanID == planID {
+			out = append(out, it)
+		}
+	}
+	return out, nil
+}
+
+func (b *Blackouts) Get(ctx context.Context, id string) (types.PlanBlackout, error) {
+	items, err := b.store.Get(ctx, []string{id})
+	if err != nil {
+		return types.PlanBlackout{}, err
+	}
+	item, ok := items[id]
+	if !ok {
+		return types.PlanBlackout{}, fmt.Errorf("%w: blackout %s", base.ErrNotFound, id)
+	}
+	return item, nil
+}
+
+func (b *Blackouts) Create(ctx context.Context, item types.PlanBlackout) (types.PlanBlackout, error) {
+	now := time.Now().UTC()
+	item.ID = uuid.New().String()
+	item.CreatedAt = &now
+	if err := b.check(ctx, &item); err != nil {
+		return types.PlanBlackout{}, err
+	}
+	created, err := b.store.Create(ctx, []types.PlanBlackout{item})
+	if err != nil {
+		return types.PlanBlackout{}, err
+	}
+	return created[0], nil
+}
+
+func (b *Blackouts) Update(ctx context.Context, item types.PlanBlackout) (types.PlanBlackout, error) {
+	existing, err := b.Get(ctx, item.ID)
+	if err != nil {
+		return types.PlanBlackout{}, err
+	}
+	item.CreatedAt = existing.CreatedAt
+	if err := b.check(ctx, &item); err != nil {
+		return types.PlanBlackout{}, err
+	}
+	updated, err := b.store.Update(ctx, []types.PlanBlackout{item})
+	if err != nil {
+		return types.PlanBlackout{}, err
+	}
+	return updated[0], nil
+}
+
+func (b *Blackouts) Delete(ctx context.Context, id string) error {
+	return b.store.Delete(ctx, []string{id})
+}
+
+// Active returns the open window covering a plan at t that closes last.
+func (b *Blackouts) Active(ctx context.Context, planID string, t time.Time) (types.PlanBlackout, time.Time, bool, error) {
+	items, err := b.List(ctx, planID)
+	if err != nil {
+		return types.PlanBlackout{}, time.Time{}, false, err
+	}
+	found, until, ok := activeBlackout(items, planID, t)
+	return found, until, ok, nil
+}
+
+// check normalizes a window and validates it, including that its plan exists.
+func (b *Blackouts) check(ctx context.Context, item *types.PlanBlackout) error {
+	item.Name = strings.TrimSpace(item.Name)
+	item.PlanID = strings.TrimSpace(item.PlanID)
+	item.Schedule = strings.TrimSpace(item.Schedule)
+	item.Duration = strings.TrimSpace(item.Duration)
+	item.Timezone = strings.TrimSpace(item.Timezone)
+	if item.Action == "" {
+		item.Action = types.PlanBlackoutSkip
+	}
+	if err := ValidateBlackout(*item); err != nil {
+		return fmt.Errorf("%w: %v", base.ErrValidation, err)
+	}
+	if item.PlanID != "" {
+		plans, err := b.planStore.Get(ctx, []string{item.PlanID})
+		if err != nil {
+			return err
+		}
+		if _, ok := plans[item.PlanID]; !ok {
+			return fmt.Errorf("%w: plan %s not found", base.ErrValidation, item.PlanID)
+		}
+	}
+	return nil
+}
+
+// manualTrigger reports whether a run was started by a person, from the web
+// UI or chat, rather than by a schedule or webhook.
+func manualTrigger(trigger string) bool {
+	return trigger == "manual" || trigger == "chat"
+}
+
+func (r *Runner) SetBlackouts(b *Blackouts) {
+	r.blackouts = b
+}
+
+// applyBlackout checks a new run against the blackout windows of its plan.
+// A manual run is refused unless forced; any other run is skipped, or
+// deferred to the end of the window with at most one deferred run per plan.
+// Simulations change nothing and are never held back.
+func (r *Runner) applyBlackout(ctx context.Context, plan types.Plan, run *types.PlanRun, force bool) error {
+	if r.blackouts == nil || run.Trigger == simulationTrigger {
+		return nil
+	}
+	b, until, ok, err := r.blackouts.Active(ctx, plan.ID, run.StartedAt)
+	if err != nil {
+		return fmt.Errorf("check blackouts: %w", err)
+	}
+	if !ok {
+		return nil
+	}
+	window := fmt.Sprintf("blackout %q until %s", b.Name, until.UTC().Format("2006-01-02 15:04 UTC"))
+	if manualTrigger(run.Trigger) {
+		if !force {
+			return fmt.Errorf("%w: plan %q is in %s; force the run to start it anyway", base.ErrConflict, plan.Name, window)
+		}
+		run.Reason = "forced during " + window
+		return nil
+	}
+	run.Reason = "skipped by " + window
+	if b.Action == types.PlanBlackoutDefer {
+		deferred, found, err := r.deferredRun(ctx, plan.ID)
+		if err != nil {
+			return err
+		}
+		if !found {
+			run.Status = "deferred"
+			run.Reason = "deferred by " + window
+			return nil
+		}
+		run.Reason = fmt.Sprintf("skipped by %s: run %s is already deferred", window, deferred.ID)
+	}
+	now := time.Now().UTC()
+	run.Status = "skipped"
+	run.FinishedAt = &now
+	skipPending(run)
+	return nil
+}
+
+func (r *Runner) deferredRun(ctx context.Context, planID string) (types.PlanRun, bool, error) {
+	runs, err := r.runStore.List(ctx, types.ListQuery{
+		Filter: map[string]string{"plan_id": planID, "status": "deferred"},
+		Page:   types.Page{Limit: 1},
+	})
+	if err != nil || len(runs) == 0 {
+		return types.PlanRun{}, false, err
+	}
+	return runs[0], true, nil
+}
+
+// startDeferredRuns starts the deferred runs whose plans are out of their
+// blackout windows, through the plan's concurrency policy like a new run.
+// The plan's current graph is used; a plan disabled in the meantime has its
+// run skipped.
+func (r *Runner) startDeferredRuns(ctx context.Context) {
+	runs, err := r.runStore.List(ctx, types.ListQuery{
+		Filter: map[string]string{"status": "deferred"},
+		Sort:   []types.Sort{{Field: "started_at", Dir: types.SortDirAsc}},
+	})
+	if err != nil {
+		log.Printf("plans: deferred runs: %v", err)
+		return
+	}
+	for _, run := range runs {
+		plan, err := r.loadPlan(ctx, run.PlanID)
+		if err != nil {
+			log.Printf("plans: deferred run %s: %v", run.ID, err)
+			continue
+		}
+		now := time.Now().UTC()
+		if r.blackouts != nil {
+			_, _, blocked, err := r.blackouts.Active(ctx, plan.ID, now)
+			if err != nil {
+				log.Printf("plans: deferred runs: check blackouts: %v", err)
+				return
+			}
+			if blocked {
+				continue
+			}
+		}
+		if !plan.Enabled || validateGraph(plan.Graph) != nil {
+			run.Status = "skipped"
+			run.Reason += "; the plan was disabled or changed to an invalid graph before the window ended"
+			run.FinishedAt = &now
+			skipPending(&run)
+			if _, err := r.runStore.Update(ctx, []types.PlanRun{run}); err != nil {
+				log.Printf("plans: deferred run %s: %v", run.ID, err)
+			}
+			continue
+		}
+		policy := concurrencyPolicy(plan.Concurrency)
+		if policy == types.PlanConcurrencyCancelPrevious {
+			r.cancelPlanRuns(ctx, plan.ID)
+		}
+		run.Status = "running"
+		run.StartedAt = now
+		run.Steps = initSteps(plan.Graph)
+
+		r.queueMu.Lock()
+		r.admitLocked(plan.ID, policy, &run)
+		if _, err := r.runStore.Update(ctx, []types.PlanRun{run}); err != nil {
+			r.queueMu.Unlock()
+			log.Printf("plans: deferred run %s: %v", run.ID, err)
+			continue
+		}
+		log.Printf("plans: starting deferred run %s for plan=%s", run.ID, plan.ID)
+		r.startAdmittedLocked(plan, run)
+		r.queueMu.Unlock()
+	}
+}
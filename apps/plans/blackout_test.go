@@ -0,0 +1,277 @@
+package plans
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"mantis/core/base"
+	"mantis/core/types"
+)
+
+func at(s string) time.Time {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func ptr[T any](v T) *T { return &v }
+
+func releaseFreeze() types.PlanBlackout {
+	return types.PlanBlackout{
+		ID: "freeze", Name: "Release freeze", Enabled: true, Action: types.PlanBlackoutSkip,
+		StartsAt: ptr(at("2026-12-20T00:00:00Z")), EndsAt: ptr(at("2027-01-05T00:00:00Z")),
+	}
+}
+
+func fridayEvenings() types.PlanBlackout {
+	return types.PlanBlackout{
+		ID: "friday", Name: "Friday evening", Enabled: true, Action: types.PlanBlackoutDefer,
+		Schedule: "0 16 * * 5", Duration: "8h", Timezone: "Europe/Berlin",
+	}
+}
+
+// --- ValidateBlackout ---
+
+func TestValidateBlackout(t *testing.T) {
+	if err := ValidateBlackout(releaseFreeze()); err != nil {
+		t.Fatalf("one-off window: %v", err)
+	}
+	if err := ValidateBlackout(fridayEvenings()); err != nil {
+		t.Fatalf("recurring window: %v", err)
+	}
+	cases := map[string]struct {
+		edit func(*types.PlanBlackout)
+		want string
+	}{
+		"no name":        {func(b *types.PlanBlackout) { b.Name = " " }, "name is required"},
+		"bad action":     {func(b *types.PlanBlackout) { b.Action = "pause" }, "unknown action"},
+		"open ended":     {func(b *types.PlanBlackout) { b.EndsAt = nil }, "set startsAt and endsAt"},
+		"reversed":       {func(b *types.PlanBlackout) { b.StartsAt, b.EndsAt = b.EndsAt, b.StartsAt }, "endsAt must be after"},
+		"both kinds":     {func(b *types.PlanBlackout) { b.Schedule, b.Duration = "0 16 * * 5", "8h" }, "not startsAt and endsAt"},
+		"duration alone": {func(b *types.PlanBlackout) { b.Duration = "8h" }, "only applies to a recurring window"},
+		"bad timezone":   {func(b *types.PlanBlackout) { b.Timezone = "Mars/Base" }, "unknown timezone"},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			b := releaseFreeze()
+			tc.edit(&b)
+			if err := ValidateBlackout(b); err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("expected error containing %q, got %v", tc.want, err)
+			}
+		})
+	}
+	b := fridayEvenings()
+	b.Duration = "800h"
+	if err := ValidateBlackout(b); err == nil {
+		t.Fatal("expected a too long recurring window to be rejected")
+	}
+}
+
+// --- BlackoutEnd ---
+
+func TestBlackoutEnd_OneOff(t *testing.T) {
+	b := releaseFreeze()
+	if end, ok := BlackoutEnd(b, at("2026-12-24T10:00:00Z")); !ok || !end.Equal(*b.EndsAt) {
+		t.Fatalf("expected open until %v, got %v %v", b.EndsAt, end, ok)
+	}
+	if _, ok := BlackoutEnd(b, at("2027-01-05T00:00:00Z")); ok {
+		t.Fatal("the end is exclusive")
+	}
+	b.Enabled = false
+	if _, ok := BlackoutEnd(b, at("2026-12-24T10:00:00Z")); ok {
+		t.Fatal("a disabled window is never open")
+	}
+}
+
+func TestBlackoutEnd_Recurring(t *testing.T) {
+	b := fridayEvenings()
+	// 2026-05-01 is a Friday; Berlin is UTC+2 in May.
+	end, ok := BlackoutEnd(b, at("2026-05-01T18:30:00Z"))
+	if !ok || !end.Equal(at("2026-05-01T22:00:00Z")) {
+		t.Fatalf("expected open until 22:00 UTC, got %v %v", end, ok)
+	}
+	if _, ok := BlackoutEnd(b, at("2026-05-01T13:59:00Z")); ok {
+		t.Fatal("window must not be open before 16:00 Berlin")
+	}
+	if _, ok := BlackoutEnd(b, at("2026-05-02T09:00:00Z")); ok {
+		t.Fatal("window must be closed on Saturday morning")
+	}
+}
+
+func TestBlackoutEnd_MergesOverlappingWindows(t *testing.T) {
+	b := types.PlanBlackout{Name: "busy", Enabled: true, Action: types.PlanBlackoutSkip, Schedule: "0 * * * *", Duration: "90m", Timezone: "UTC"}
+	end, ok := BlackoutEnd(b, at("2026-05-01T10:15:00Z"))
+	if !ok {
+		t.Fatal("expected open")
+	}
+	// The 09:00 window is open at 10:15. Hourly windows of 90 minutes never
+	// close, so the merge stops at its bound.
+	if want := at("2026-05-01T09:00:00Z").Add(time.Duration(maxWindowMerge)*time.Hour + 90*time.Minute); !end.Equal(want) {
+		t.Fatalf("expected %v, got %v", want, end)
+	}
+}
+
+func TestActiveBlackout(t *testing.T) {
+	freeze := releaseFreeze()
+	other := releaseFreeze()
+	other.ID, other.PlanID = "other", "p2"
+	other.EndsAt = ptr(at("2027-02-01T00:00:00Z"))
+	longer := releaseFreeze()
+	longer.ID, longer.PlanID = "longer", "p1"
+	longer.EndsAt = ptr(at("2027-01-10T00:00:00Z"))
+
+	now := at("2026-12-24T10:00:00Z")
+	b, until, ok := activeBlackout([]types.PlanBlackout{freeze, other}, "p1", now)
+	if !ok || b.ID != "freeze" || !until.Equal(*freeze.EndsAt) {
+		t.Fatalf("expected the global freeze, got %q until %v", b.ID, until)
+	}
+	b, _, _ = activeBlackout([]types.PlanBlackout{freeze, other, longer}, "p1", now)
+	if b.ID != "longer" {
+		t.Fatalf("expected the window closing last, got %q", b.ID)
+	}
+	if _, _, ok := activeBlackout([]types.PlanBlackout{other}, "p1", now); ok {
+		t.Fatal("another plan's window must not apply")
+	}
+}
+
+// --- Runner ---
+
+func newBlackoutRunner(windows ...types.PlanBlackout) (*Runner, *memStore[types.PlanRun], *memStore[types.Plan]) {
+	runs := newRunStore()
+	plans := newPlanStore()
+	r := &Runner{
+		runStore:      runs,
+		planStore:     plans,
+		maxConcurrent: 1,
+		active:        map[string]string{},
+		waiting:       map[string]bool{},
+	}
+	r.SetBlackouts(NewBlackouts(newMemStore(func(b types.PlanBlackout) string { return b.ID }, windows...), plans))
+	return r, runs, plans
+}
+
+func nowWindow(action types.PlanBlackoutAction) types.PlanBlackout {
+	now := time.Now().UTC()
+	return types.PlanBlackout{
+		ID: "w", Name: "Freeze", Enabled: true, Action: action,
+		StartsAt: ptr(now.Add(-time.Hour)), EndsAt: ptr(now.Add(time.Hour)),
+	}
+}
+
+func TestApplyBlackout_Manual(t *testing.T) {
+	r, _, _ := newBlackoutRunner(nowWindow(types.PlanBlackoutSkip))
+	plan := types.Plan{ID: "p1", Name: "Backup"}
+	run := types.PlanRun{ID: "r1", PlanID: "p1", Status: "running", Trigger: "manual", StartedAt: time.Now()}
+	err := r.applyBlackout(context.Background(), plan, &run, false)
+	if !errors.Is(err, base.ErrConflict) || !strings.Contains(err.Error(), `blackout "Freeze"`) {
+		t.Fatalf("expected a conflict naming the window, got %v", err)
+	}
+	if err := r.applyBlackout(context.Background(), plan, &run, true); err != nil {
+		t.Fatal(err)
+	}
+	if run.Status != "running" || !strings.HasPrefix(run.Reason, "forced during blackout") {
+		t.Fatalf("expected a forced run, got %q %q", run.Status, run.Reason)
+	}
+
+	sim := types.PlanRun{ID: "r2", PlanID: "p1", Status: "running", Trigger: simulationTrigger, StartedAt: time.Now()}
+	if err := r.applyBlackout(context.Background(), plan, &sim, false); err != nil || sim.Reason != "" {
+		t.Fatalf("simulations must not be held back: %v %q", err, sim.Reason)
+	}
+}
+
+func TestTriggerRun_ChatDuringBlackout(t *testing.T) {
+	r, runs, plans := newBlackoutRunner(nowWindow(types.PlanBlackoutSkip))
+	graph := types.PlanGraph{Nodes: []types.PlanNode{{ID: "a", Type: types.PlanNodeAction, Prompt: "x"}}}
+	plans.put(types.Plan{ID: "p1", Name: "Backup", Enabled: true, Graph: graph})
+	// Another plan holds the only slot, so a started run is queued rather
+	// than executed.
+	r.active["busy"] = "p3"
+
+	if _, err := r.TriggerRun(context.Background(), "p1", "chat", nil, false); !errors.Is(err, base.ErrConflict) {
+		t.Fatalf("expected a chat run in a blackout to be refused, got %v", err)
+	}
+	if len(runs.items) != 0 {
+		t.Fatalf("a refused run must not be stored, got %+v", runs.items)
+	}
+	run, err := r.TriggerRun(context.Background(), "p1", "chat", nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if run.Status != "queued" || !strings.HasPrefix(run.Reason, "forced during blackout") {
+		t.Fatalf("expected a forced run, got %q %q", run.Status, run.Reason)
+	}
+}
+
+func TestApplyBlackout_Skip(t *testing.T) {
+	r, _, _ := newBlackoutRunner(nowWindow(types.PlanBlackoutSkip))
+	run := types.PlanRun{ID: "r1", PlanID: "p1", Status: "running", Trigger: "schedule", StartedAt: time.Now(),
+		Steps: []types.PlanStepRun{{NodeID: "a", Status: "pending"}}}
+	if err := r.applyBlackout(context.Background(), types.Plan{ID: "p1"}, &run, false); err != nil {
+		t.Fatal(err)
+	}
+	if run.Status != "skipped" || run.FinishedAt == nil || run.Steps[0].Status != "skipped" {
+		t.Fatalf("expected a skipped run, got %+v", run)
+	}
+	if !strings.HasPrefix(run.Reason, `skipped by blackout "Freeze" until`) {
+		t.Fatalf("unexpected reason %q", run.Reason)
+	}
+}
+
+func TestApplyBlackout_DeferOnce(t *testing.T) {
+	r, runs, _ := newBlackoutRunner(nowWindow(types.PlanBlackoutDefer))
+	first := types.PlanRun{ID: "r1", PlanID: "p1", Status: "running", Trigger: "schedule", StartedAt: time.Now()}
+	if err := r.applyBlackout(context.Background(), types.Plan{ID: "p1"}, &first, false); err != nil {
+		t.Fatal(err)
+	}
+	if first.Status != "deferred" {
+		t.Fatalf("expected deferred, got %q", first.Status)
+	}
+	runs.put(first)
+
+	second := types.PlanRun{ID: "r2", PlanID: "p1", Status: "running", Trigger: "webhook", StartedAt: time.Now()}
+	if err := r.applyBlackout(context.Background(), types.Plan{ID: "p1"}, &second, false); err != nil {
+		t.Fatal(err)
+	}
+	if second.Status != "skipped" || !strings.Contains(second.Reason, "run r1 is already deferred") {
+		t.Fatalf("expected the second trigger to be skipped, got %q %q", second.Status, second.Reason)
+	}
+}
+
+func TestStartDeferredRuns(t *testing.T) {
+	r, runs, plans := newBlackoutRunner()
+	graph := types.PlanGraph{Nodes: []types.PlanNode{{ID: "a", Type: types.PlanNodeAction, Prompt: "x"}}}
+	plans.put(types.Plan{ID: "p1", Enabled: true, Graph: graph})
+	plans.put(types.Plan{ID: "p2", Enabled: false, Graph: graph})
+	runs.put(types.PlanRun{ID: "r1", PlanID: "p1", Status: "deferred", Trigger: "schedule", Reason: "deferred by blackout"})
+	runs.put(types.PlanRun{ID: "r2", PlanID: "p2", Status: "deferred", Trigger: "schedule", Reason: "deferred by blackout"})
+	// A run of another plan holds the only slot, so r1 is queued rather
+	// than executed.
+	r.active["busy"] = "p3"
+
+	r.startDeferredRuns(context.Background())
+
+	if got := runs.get("r1"); got.Status != "queued" || got.StartedAt.IsZero() || len(got.Steps) != 1 {
+		t.Fatalf("expected r1 to be queued with fresh steps, got %+v", got)
+	}
+	if len(r.queue) != 1 || r.queue[0].run.ID != "r1" {
+		t.Fatalf("expected r1 in the queue, got %+v", r.queue)
+	}
+	if got := runs.get("r2"); got.Status != "skipped" || !strings.Contains(got.Reason, "disabled") {
+		t.Fatalf("expected r2 to be skipped, got %q %q", got.Status, got.Reason)
+	}
+}
+
+func TestStartDeferredRuns_StillBlocked(t *testing.T) {
+	r, runs, plans := newBlackoutRunner(nowWindow(types.PlanBlackoutDefer))
+	plans.put(types.Plan{ID: "p1", Enabled: true})
+	runs.put(types.PlanRun{ID: "r1", PlanID: "p1", Status: "deferred", Trigger: "schedule"})
+	r.startDeferredRuns(context.Background())
+	if got := runs.get("r1").Status; got != "deferred" {
+		t.Fatalf("expected the run to stay deferred, got %q", got)
+	}
+}
@@ -0,0 +1,169 @@
+package plans
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"mantis/core/types"
+)
+
+type queuedRun struct {
+	plan types.Plan
+	run  types.PlanRun
+}
+
+func concurrencyPolicy(c types.PlanConcurrency) types.PlanConcurrency {
+	switch c {
+	case types.PlanConcurrencySkip, types.PlanConcurrencyQueue, types.PlanConcurrencyCancelPrevious:
+		return c
+	default:
+		return types.PlanConcurrencyAllow
+	}
+}
+
+// ValidConcurrency reports whether c is a known policy; empty means allow.
+func ValidConcurrency(c types.PlanConcurrency) bool {
+	return c == "" || concurrencyPolicy(c) == c
+}
+
+// launchLocked starts a run. Its cancel func is registered before the run
+// goroutine starts, so a cancel_previous trigger right behind it stops the
+// run instead of only marking it cancelled in the store.
+func (r *Runner) launchLocked(plan types.Plan, run types.PlanRun) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	r.mu.Lock()
+	r.cancels[run.ID] = cancel
+	r.done[run.ID] = done
+	r.mu.Unlock()
+	r.active[run.ID] = plan.ID
+	go r.execute(ctx, cancel, done, plan, run)
+}
+
+func (r *Runner) atCapacityLocked() bool {
+	return r.maxConcurrent > 0 && len(r.active)-len(r.waiting) >= r.maxConcurrent
+}
+
+// setWaiting marks a run as parked on an approval. Waiting runs stay active
+// for their plan's policy but do not count against the global limit, so a
+// pending sign-off cannot starve other plans; resuming may briefly exceed it.
+func (r *Runner) setWaiting(runID string, waiting bool) {
+	r.queueMu.Lock()
+	defer r.queueMu.Unlock()
+	if waiting {
+		r.waiting[runID] = true
+		r.dispatchLocked()
+		return
+	}
+	delete(r.waiting, runID)
+}
+
+func (r *Runner) planRunningLocked(planID string) bool {
+	for _, id := range r.active {
+		if id == planID {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Runner) planBusyLocked(planID string) bool {
+	if r.planRunningLocked(planID) {
+		return true
+	}
+	for _, q := range r.queue {
+		if q.plan.ID == planID {
+			return true
+		}
+	}
+	return false
+}
+
+// mustQueueLocked reports whether a new run has to wait: either the global
+// limit is reached, or the plan serializes its runs and one is already
+// running or waiting ahead of it.
+func (r *Runner) mustQueueLocked(planID string, policy types.PlanConcurrency) bool {
+	if r.atCapacityLocked() {
+		return true
+	}
+	return policy == types.PlanConcurrencyQueue && r.planBusyLocked(planID)
+}
+
+func (r *Runner) releaseSlot(runID string) {
+	r.queueMu.Lock()
+	defer r.queueMu.Unlock()
+	delete(r.active, runID)
+	delete(r.waiting, runID)
+	r.dispatchLocked()
+}
+
+// dispatchLocked starts queued runs in FIFO order while capacity allows,
+// skipping over runs whose plan is still busy with an earlier run.
+func (r *Runner) dispatchLocked() {
+	for i := 0; i < len(r.queue); {
+		if r.atCapacityLocked() {
+			return
+		}
+		q := r.queue[i]
+		if concurrencyPolicy(q.plan.Concurrency) == types.PlanConcurrencyQueue && r.planRunningLocked(q.plan.ID) {
+			i++
+			continue
+		}
+		r.queue = append(r.queue[:i], r.queue[i+1:]...)
+		q.run.Status = "running"
+		q.run.StartedAt = time.Now().UTC()
+		r.saveRun(&q.run)
+		log.Printf("plans: starting queued run %s for plan=%s", q.run.ID, q.plan.ID)
+		r.launchLocked(q.plan, q.run)
+	}
+}
+
+func (r *Runner) dequeue(runID string) bool {
+	r.queueMu.Lock()
+	defer r.queueMu.Unlock()
+	for i, q := range r.queue {
+		if q.run.ID == runID {
+			r.queue = append(r.queue[:i], r.queue[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Runner) queuedRuns() []types.PlanRun {
+	r.queueMu.Lock()
+	defer r.queueMu.Unlock()
+	runs := make([]types.PlanRun, len(r.queue))
+	for i, q := range r.queue {
+		runs[i] = q.run
+	}
+	return runs
+}
+
+// cancelPlanRuns cancels every running or queued run of a plan. It must be
+// called without queueMu held: CancelRun waits for the run goroutine, which
+// releases its slot under queueMu on exit.
+func (r *Runner) cancelPlanRuns(ctx context.Context, planID string) {
+	r.queueMu.Lock()
+	var ids []string
+	for runID, id := range r.active {
+		if id == planID {
+			ids = append(ids, runID)
+		}
+	}
+	for _, q := range r.queue {
+		if q.plan.ID == planID {
+			ids = append(ids, q.run.ID)
+		}
+	}
+	r.queueMu.Unlock()
+
+	for _, id := range ids {
+		if _, err := r.CancelRun(ctx, id); err != nil {
+			log.Printf("plans: cancel previous run %s: %v", id, err)
+		} else {
+			log.Printf("plans: cancelled previous run %s of plan=%s", id, planID)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package plans
+
+import (
+	"testing"
+
+	"mantis/core/types"
+)
+
+func newQueueRunner(max int) *Runner {
+	return &Runner{maxConcurrent: max, active: make(map[string]string), waiting: make(map[string]bool)}
+}
+
+func TestConcurrencyPolicy_DefaultsToAllow(t *testing.T) {
+	if got := concurrencyPolicy(""); got != types.PlanConcurrencyAllow {
+		t.Fatalf("expected allow, got %q", got)
+	}
+	if got := concurrencyPolicy("bogus"); got != types.PlanConcurrencyAllow {
+		t.Fatalf("expected allow for unknown, got %q", got)
+	}
+	if got := concurrencyPolicy(types.PlanConcurrencyQueue); got != types.PlanConcurrencyQueue {
+		t.Fatalf("expected queue, got %q", got)
+	}
+}
+
+func TestValidConcurrency(t *testing.T) {
+	for _, c := range []types.PlanConcurrency{"", "allow", "skip", "queue", "cancel_previous"} {
+		if !ValidConcurrency(c) {
+			t.Errorf("%q should be valid", c)
+		}
+	}
+	if ValidConcurrency("parallel") {
+		t.Error("unknown policy should be invalid")
+	}
+}
+
+func TestMustQueue_GlobalLimit(t *testing.T) {
+	r := newQueueRunner(2)
+	r.active["r1"] = "p1"
+	if r.mustQueueLocked("p2", types.PlanConcurrencyAllow) {
+		t.Fatal("should start while below the limit")
+	}
+	r.active["r2"] = "p3"
+	if !r.mustQueueLocked("p2", types.PlanConcurrencyAllow) {
+		t.Fatal("should queue at the limit")
+	}
+}
+
+func TestMustQueue_Unlimited(t *testing.T) {
+	r := newQueueRunner(0)
+	for i := 0; i < 100; i++ {
+		r.active[string(rune('a'+i))] = "p1"
+	}
+	if r.mustQueueLocked("p1", types.PlanConcurrencyAllow) {
+		t.Fatal("zero limit means unlimited")
+	}
+}
+
+func TestMustQueue_QueuePolicySerializesPlan(t *testing.T) {
+	r := newQueueRunner(10)
+	r.active["r1"] = "p1"
+	if !r.mustQueueLocked("p1", types.PlanConcurrencyQueue) {
+		t.Fatal("queue policy should wait for the running run of the same plan")
+	}
+	if r.mustQueueLocked("p1", types.PlanConcurrencyAllow) {
+		t.Fatal("allow policy should not wait")
+	}
+	if r.mustQueueLocked("p2", types.PlanConcurrencyQueue) {
+		t.Fatal("other plans should not wait")
+	}
+}
+
+func TestMustQueue_QueuePolicyKeepsFIFO(t *testing.T) {
+	r := newQueueRunner(10)
+	r.queue = []queuedRun{{plan: types.Plan{ID: "p1"}, run: types.PlanRun{ID: "q1"}}}
+	if !r.mustQueueLocked("p1", types.PlanConcurrencyQueue) {
+		t.Fatal("a new run must not overtake an already queued run of the same plan")
+	}
+}
+
+func TestPlanBusy(t *testing.T) {
+	r := newQueueRunner(0)
+	if r.planBusyLocked("p1") {
+		t.Fatal("idle plan reported busy")
+	}
+	r.queue = []queuedRun{{plan: types.Plan{ID: "p1"}, run: types.PlanRun{ID: "q1"}}}
+	if !r.planBusyLocked("p1") {
+		t.Fatal("plan with a queued run should be busy")
+	}
+	if r.planRunningLocked("p1") {
+		t.Fatal("queued is not running")
+	}
+}
+
+func TestDequeueAndQueuedRuns(t *testing.T) {
+	r := newQueueRunner(1)
+	r.queue = []queuedRun{
+		{plan: types.Plan{ID: "p1"}, run: types.PlanRun{ID: "q1", Status: "queued"}},
+		{plan: types.Plan{ID: "p2"}, run: types.PlanRun{ID: "q2", Status: "queued"}},
+		{plan: types.Plan{ID: "p3"}, run: types.PlanRun{ID: "q3", Status: "queued"}},
+	}
+	if !r.dequeue("q2") {
+		t.Fatal("expected q2 to be dequeued")
+	}
+	if r.dequeue("missing") {
+		t.Fatal("unknown run should not be dequeued")
+	}
+	runs := r.queuedRuns()
+	if len(runs) != 2 || runs[0].ID != "q1" || runs[1].ID != "q3" {
+		t.Fatalf("unexpected queue order: %+v", runs)
+	}
+}
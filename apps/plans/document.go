@@ -0,0 +1,283 @@
+package plans
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"mantis/core/base"
+	"mantis/core/types"
+)
+
+// DocumentVersion is the plan document format written by ExportPlan. Parsing
+// rejects documents from a newer format instead of silently dropping fields.
+const DocumentVersion = 1
+
+const documentHeader = "# Mantis plan document. Schema: GET /api/plans/schema\n"
+
+// planDocument is the YAML form of a plan. It carries everything needed to
+// recreate the plan except its ID and webhook secret, so it can be committed
+// to git and imported into another instance.
+type planDocument struct {
+	Version     int              `yaml:"version"`
+	Name        string           `yaml:"name"`
+	Description string           `yaml:"description,omitempty"`
+	Schedule    string           `yaml:"schedule,omitempty"`
+	Enabled     bool             `yaml:"enabled"`
+	Timezone    string           `yaml:"timezone,omitempty"`
+	Jitter      string           `yaml:"jitter,omitempty"`
+	CatchUp     string           `yaml:"catchUp,omitempty"`
+	Concurrency string           `yaml:"concurrency,omitempty"`
+	Parameters  map[string]any   `yaml:"parameters,omitempty"`
+	Webhook     *documentWebhook `yaml:"webhook,omitempty"`
+	Notify      *documentNotify  `yaml:"notify,omitempty"`
+	SLA         *documentSLA     `yaml:"sla,omitempty"`
+	PresetID    string           `yaml:"presetId,omitempty"`
+	ModelID     string           `yaml:"modelId,omitempty"`
+	Nodes       []documentNode   `yaml:"nodes"`
+	Edges       []documentEdge   `yaml:"edges"`
+}
+
+type documentWebhook struct {
+	Enabled        bool              `yaml:"enabled"`
+	Mapping        map[string]string `yaml:"mapping,omitempty"`
+	IdempotencyKey string            `yaml:"idempotencyKey,omitempty"`
+}
+
+type documentNotify struct {
+	When      string `yaml:"when"`
+	ChannelID string `yaml:"channelId,omitempty"`
+	Recipient string `yaml:"recipient,omitempty"`
+}
+
+type documentSLA struct {
+	MaxDuration            string `yaml:"maxDuration,omitempty"`
+	MaxConsecutiveFailures int    `yaml:"maxConsecutiveFailures,omitempty"`
+}
+
+type documentNode struct {
+	ID           string   `yaml:"id"`
+	Type         string   `yaml:"type"`
+	Label        string   `yaml:"label,omitempty"`
+	Prompt       string   `yaml:"prompt,omitempty"`
+	ClearContext bool     `yaml:"clearContext,omitempty"`
+	MaxRetries   int      `yaml:"maxRetries,omitempty"`
+	Timeout      string   `yaml:"timeout,omitempty"`
+	RetryDelay   string   `yaml:"retryDelay,omitempty"`
+	RetryOn      []string `yaml:"retryOn,omitempty,flow"`
+	OnTimeout    string   `yaml:"onTimeout,omitempty"`
+	Items        string   `yaml:"items,omitempty"`
+	Parallelism  int      `yaml:"parallelism,omitempty"`
+	PresetID     string   `yaml:"presetId,omitempty"`
+	ModelID      string   `yaml:"modelId,omitempty"`
+	Position     any      `yaml:"position,omitempty,flow"`
+}
+
+type documentEdge struct {
+	ID     string `yaml:"id"`
+	Source string `yaml:"source"`
+	Target string `yaml:"target"`
+	Label  string `yaml:"label,omitempty"`
+}
+
+// ExportPlan renders a plan as a versioned YAML document.
+func ExportPlan(plan types.Plan) ([]byte, error) {
+	doc := planDocument{
+		Version:     DocumentVersion,
+		Name:        plan.Name,
+		Description: plan.Description,
+		Schedule:    plan.Schedule,
+		Enabled:     plan.Enabled,
+		Timezone:    plan.Timezone,
+		Jitter:      plan.Jitter,
+		CatchUp:     string(plan.CatchUp),
+		Concurrency: string(plan.Concurrency),
+		PresetID:    plan.PresetID,
+		ModelID:     plan.ModelID,
+		Nodes:       make([]documentNode, len(plan.Graph.Nodes)),
+		Edges:       make([]documentEdge, len(plan.Graph.Edges)),
+	}
+	if len(plan.Parameters) > 0 {
+		if err := json.Unmarshal(plan.Parameters, &doc.Parameters); err != nil {
+			return nil, fmt.Errorf("plan parameters: %w", err)
+		}
+	}
+	if plan.Webhook != nil {
+		doc.Webhook = &documentWebhook{
+			Enabled:        plan.Webhook.Enabled,
+			Mapping:        plan.Webhook.Mapping,
+			IdempotencyKey: plan.Webhook.IdempotencyKey,
+		}
+	}
+	if plan.Notify != nil {
+		doc.Notify = &documentNotify{
+			When:      string(plan.Notify.When),
+			ChannelID: plan.Notify.ChannelID,
+			Recipient: plan.Notify.Recipient,
+		}
+	}
+	if plan.SLA != nil {
+		doc.SLA = &documentSLA{
+			MaxDuration:            plan.SLA.MaxDuration,
+			MaxConsecutiveFailures: plan.SLA.MaxConsecutiveFailures,
+		}
+	}
+	for i, n := range plan.Graph.Nodes {
+		node := documentNode{
+			ID:           n.ID,
+			Type:         string(n.Type),
+			Label:        n.Label,
+			Prompt:       n.Prompt,
+			ClearContext: n.ClearContext,
+			MaxRetries:   n.MaxRetries,
+			Timeout:      n.Timeout,
+			RetryDelay:   n.RetryDelay,
+			RetryOn:      n.RetryOn,
+			OnTimeout:    n.OnTimeout,
+			Items:        n.Items,
+			Parallelism:  n.Parallelism,
+			PresetID:     n.PresetID,
+			ModelID:      n.ModelID,
+		}
+		if len(n.Position) > 0 && string(n.Position) != "null" {
+			if err := json.Unmarshal(n.Position, &node.Position); err != nil {
+				return nil, fmt.Errorf("node %q position: %w", n.ID, err)
+			}
+		}
+		doc.Nodes[i] = node
+	}
+	for i, e := range plan.Graph.Edges {
+		doc.Edges[i] = documentEdge{ID: e.ID, Source: e.Source, Target: e.Target, Label: e.Label}
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(documentHeader)
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ParsePlanDocument decodes a YAML plan document into a plan without an ID.
+// Unknown fields are rejected so typos don't get lost on import. The result
+// is not validated; use ValidatePlan for that.
+func ParsePlanDocument(data []byte) (types.Plan, error) {
+	var doc planDocument
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&doc); err != nil {
+		if errors.Is(err, io.EOF) {
+			return types.Plan{}, fmt.Errorf("%w: empty plan document", base.ErrValidation)
+		}
+		return types.Plan{}, fmt.Errorf("%w: %v", base.ErrValidation, err)
+	}
+	switch {
+	case doc.Version == 0:
+		return types.Plan{}, fmt.Errorf("%w: plan document has no version", base.ErrValidation)
+	case doc.Version > DocumentVersion:
+		return types.Plan{}, fmt.Errorf("%w: plan document version %d is newer than supported version %d", base.ErrValidation, doc.Version, DocumentVersion)
+	}
+
+	plan := types.Plan{
+		Name:        doc.Name,
+		Description: doc.Description,
+		Schedule:    doc.Schedule,
+		Enabled:     doc.Enabled,
+		Timezone:    doc.Timezone,
+		Jitter:      doc.Jitter,
+		CatchUp:     types.PlanCatchUp(doc.CatchUp),
+		Concurrency: types.PlanConcurrency(doc.Concurrency),
+		PresetID:    doc.PresetID,
+		ModelID:     doc.ModelID,
+		Parameters:  json.RawMessage(`{}`),
+		Graph: types.PlanGraph{
+			Nodes: make([]types.PlanNode, len(doc.Nodes)),
+			Edges: make([]types.PlanEdge, len(doc.Edges)),
+		},
+	}
+	if doc.Parameters != nil {
+		raw, err := json.Marshal(doc.Parameters)
+		if err != nil {
+			return types.Plan{}, fmt.Errorf("%w: parameters: %v", base.ErrValidation, err)
+		}
+		plan.Parameters = raw
+	}
+	if doc.Webhook != nil {
+		plan.Webhook = &types.PlanWebhook{
+			Enabled:        doc.Webhook.Enabled,
+			Mapping:        doc.Webhook.Mapping,
+			IdempotencyKey: doc.Webhook.IdempotencyKey,
+		}
+	}
+	if doc.Notify != nil {
+		plan.Notify = &types.PlanNotify{
+			When:      types.PlanNotifyWhen(doc.Notify.When),
+			ChannelID: doc.Notify.ChannelID,
+			Recipient: doc.Notify.Recipient,
+		}
+	}
+	if doc.SLA != nil {
+		plan.SLA = &types.PlanSLA{
+			MaxDuration:            doc.SLA.MaxDuration,
+			MaxConsecutiveFailures: doc.SLA.MaxConsecutiveFailures,
+		}
+	}
+	for i, n := range doc.Nodes {
+		node := types.PlanNode{
+			ID:           n.ID,
+			Type:         types.PlanNodeType(n.Type),
+			Label:        n.Label,
+			Prompt:       n.Prompt,
+			ClearContext: n.ClearContext,
+			MaxRetries:   n.MaxRetries,
+			Timeout:      n.Timeout,
+			RetryDelay:   n.RetryDelay,
+			RetryOn:      n.RetryOn,
+			OnTimeout:    n.OnTimeout,
+			Items:        n.Items,
+			Parallelism:  n.Parallelism,
+			PresetID:     n.PresetID,
+			ModelID:      n.ModelID,
+		}
+		if n.Position != nil {
+			raw, err := json.Marshal(n.Position)
+			if err != nil {
+				return types.Plan{}, fmt.Errorf("%w: node %q position: %v", base.ErrValidation, n.ID, err)
+			}
+			node.Position = raw
+		}
+		plan.Graph.Nodes[i] = node
+	}
+	for i, e := range doc.Edges {
+		plan.Graph.Edges[i] = types.PlanEdge{ID: e.ID, Source: e.Source, Target: e.Target, Label: e.Label}
+	}
+	return plan, nil
+}
+
+// DocumentFileName suggests a file name for an exported plan.
+func DocumentFileName(plan types.Plan) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(plan.Name)) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			sb.WriteRune(r)
+		case r == ' ' || r == '-' || r == '_':
+			sb.WriteByte('-')
+		}
+	}
+	name := strings.Trim(sb.String(), "-")
+	if name == "" {
+		name = "plan"
+	}
+	return name + ".plan.yaml"
+}
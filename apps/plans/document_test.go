@@ -0,0 +1,210 @@
+package plans
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"mantis/core/types"
+)
+
+func samplePlan() types.Plan {
+	return types.Plan{
+		ID:          "p1",
+		Name:        "Disk check",
+		Description: "Checks free space and alerts",
+		Schedule:    "0 * * * *",
+		Enabled:     true,
+		Timezone:    "Europe/Berlin",
+		Jitter:      "2m",
+		CatchUp:     types.PlanCatchUpRunOnce,
+		Concurrency: types.PlanConcurrencySkip,
+		Parameters:  json.RawMessage(`{"type":"object","properties":{"host":{"type":"string","description":"Target host"}},"required":["host"]}`),
+		Webhook:     &types.PlanWebhook{Enabled: true, Secret: "s3cret", Mapping: map[string]string{"host": "$.host"}},
+		Notify:      &types.PlanNotify{When: types.PlanNotifyFailure, Recipient: "12345"},
+		Graph: types.PlanGraph{
+			Nodes: []types.PlanNode{
+				{ID: "check", Type: types.PlanNodeAction, Label: "Check", Prompt: "Run df -h on {{.host}}.\nReport usage.", Position: json.RawMessage(`{"x":100,"y":250.5}`), MaxRetries: 2, Timeout: "5m", RetryOn: []string{"timeout"}},
+				{ID: "full", Type: types.PlanNodeDecision, Label: "Full?", Prompt: "Is any disk above 90%?", Position: json.RawMessage(`{"x":100,"y":400}`)},
+				{ID: "gate", Type: types.PlanNodeApproval, Label: "Clean up?", OnTimeout: "reject", Position: json.RawMessage(`{"x":0,"y":550}`)},
+				{ID: "clean", Type: types.PlanNodeAction, Prompt: "Clean old logs", ClearContext: true, Position: json.RawMessage(`{"x":0,"y":700}`)},
+			},
+			Edges: []types.PlanEdge{
+				{ID: "e1", Source: "check", Target: "full"},
+				{ID: "e2", Source: "full", Target: "gate", Label: "yes"},
+				{ID: "e3", Source: "gate", Target: "clean", Label: "approve"},
+			},
+		},
+	}
+}
+
+func jsonEqual(t *testing.T, a, b any) bool {
+	t.Helper()
+	ja, err := canonicalJSON(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jb, err := canonicalJSON(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(ja) == string(jb)
+}
+
+// --- ExportPlan / ParsePlanDocument ---
+
+func TestPlanDocument_RoundTrip(t *testing.T) {
+	original := samplePlan()
+	doc, err := ExportPlan(original)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(doc), "s3cret") {
+		t.Fatal("webhook secret must not be exported")
+	}
+	if !strings.Contains(string(doc), "prompt: |-\n") {
+		t.Fatalf("multi-line prompts should use block style:\n%s", doc)
+	}
+
+	parsed, err := ParsePlanDocument(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := original
+	want.ID = ""
+	want.Webhook = &types.PlanWebhook{Enabled: true, Mapping: original.Webhook.Mapping}
+	if !jsonEqual(t, parsed, want) {
+		got, _ := json.Marshal(parsed)
+		exp, _ := json.Marshal(want)
+		t.Fatalf("round trip mismatch:\n got %s\nwant %s", got, exp)
+	}
+
+	again, err := ExportPlan(parsed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(again) != string(doc) {
+		t.Fatalf("export is not stable:\n%s\n---\n%s", doc, again)
+	}
+}
+
+func TestPlanDocument_EmptyPlan(t *testing.T) {
+	doc, err := ExportPlan(types.Plan{Name: "Empty", Parameters: json.RawMessage(`{}`)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := ParsePlanDocument(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Graph.Nodes == nil || parsed.Graph.Edges == nil || string(parsed.Parameters) != "{}" {
+		t.Fatalf("unexpected empty plan: %+v", parsed)
+	}
+}
+
+func TestParsePlanDocument_Rejects(t *testing.T) {
+	cases := map[string]string{
+		"empty":         "",
+		"no version":    "name: x\nnodes: []\nedges: []\n",
+		"newer version": "version: 99\nname: x\n",
+		"unknown field": "version: 1\nname: x\nnodes:\n  - id: a\n    type: action\n    promt: typo\n",
+		"not yaml":      "version: [1\n",
+	}
+	for name, doc := range cases {
+		if _, err := ParsePlanDocument([]byte(doc)); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestDocumentFileName(t *testing.T) {
+	cases := map[string]string{
+		"Disk check":     "disk-check.plan.yaml",
+		"  Nightly/ETL ": "nightlyetl.plan.yaml",
+		"???":            "plan.plan.yaml",
+	}
+	for in, want := range cases {
+		if got := DocumentFileName(types.Plan{Name: in}); got != want {
+			t.Errorf("%q: got %q, want %q", in, got, want)
+		}
+	}
+}
+
+// --- ValidatePlan ---
+
+func TestValidatePlan_Valid(t *testing.T) {
+	if problems := ValidatePlan(samplePlan()); len(problems) > 0 {
+		t.Fatalf("unexpected problems: %v", problems)
+	}
+}
+
+func TestValidatePlan_CollectsProblems(t *testing.T) {
+	p := samplePlan()
+	p.Name = " "
+	p.Schedule = "every hour"
+	p.Concurrency = "sometimes"
+	p.Graph.Edges = append(p.Graph.Edges, types.PlanEdge{ID: "e4", Source: "clean", Target: "missing"})
+	problems := ValidatePlan(p)
+	if len(problems) != 4 {
+		t.Fatalf("expected 4 problems, got %v", problems)
+	}
+}
+
+func TestValidatePlan_GraphRules(t *testing.T) {
+	p := samplePlan()
+	p.Graph.Edges = append(p.Graph.Edges, types.PlanEdge{ID: "e4", Source: "check", Target: "clean"})
+	problems := ValidatePlan(p)
+	if len(problems) != 1 || !strings.Contains(problems[0], "outgoing edges") {
+		t.Fatalf("expected validateGraph error, got %v", problems)
+	}
+}
+
+func TestValidateStructure(t *testing.T) {
+	node := func(id string, typ types.PlanNodeType) types.PlanNode { return types.PlanNode{ID: id, Type: typ} }
+	cases := map[string]types.PlanGraph{
+		"missing id":     {Nodes: []types.PlanNode{node("", types.PlanNodeAction)}},
+		"duplicate node": {Nodes: []types.PlanNode{node("a", types.PlanNodeAction), node("a", types.PlanNodeAction)}},
+		"unknown type":   {Nodes: []types.PlanNode{node("a", "loop")}},
+		"duplicate edge": {Nodes: []types.PlanNode{node("a", types.PlanNodeAction), node("b", types.PlanNodeAction)}, Edges: []types.PlanEdge{{ID: "e", Source: "a", Target: "b"}, {ID: "e", Source: "b", Target: "a"}}},
+		"dangling edge":  {Nodes: []types.PlanNode{node("a", types.PlanNodeAction)}, Edges: []types.PlanEdge{{ID: "e", Source: "a", Target: "b"}}},
+	}
+	for name, g := range cases {
+		if err := validateStructure(g); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestValidateParameters(t *testing.T) {
+	for _, ok := range []string{``, `{}`, `{"type":"object","properties":{"n":{"type":"integer"}},"required":["n"]}`} {
+		if err := validateParameters(json.RawMessage(ok)); err != nil {
+			t.Errorf("%s: %v", ok, err)
+		}
+	}
+	for _, bad := range []string{
+		`[]`,
+		`{"type":"array"}`,
+		`{"properties":{"d":{"type":"date"}}}`,
+		`{"properties":{"n":{"type":"string"}},"required":["m"]}`,
+	} {
+		if err := validateParameters(json.RawMessage(bad)); err == nil {
+			t.Errorf("%s: expected error", bad)
+		}
+	}
+}
+
+func TestDocumentSchema_IsJSON(t *testing.T) {
+	var schema map[string]any
+	if err := json.Unmarshal(DocumentSchema, &schema); err != nil {
+		t.Fatal(err)
+	}
+	props, _ := schema["properties"].(map[string]any)
+	var doc planDocument
+	for i := range reflect.TypeOf(doc).NumField() {
+		name, _, _ := strings.Cut(reflect.TypeOf(doc).Field(i).Tag.Get("yaml"), ",")
+		if _, ok := props[name]; !ok {
+			t.Errorf("schema is missing document field %q", name)
+		}
+	}
+}
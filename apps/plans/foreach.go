@@ -0,0 +1,521 @@
+package plans
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	sessionplugin "mantis/core/plugins/session"
+	"mantis/core/types"
+)
+
+const (
+	maxForeachItems       = 100
+	maxForeachParallelism = 10
+	maxItemResult         = 2000
+	// maxLoopSummaryResult bounds each item's output in the summary the step
+	// after a loop is given.
+	maxLoopSummaryResult = 300
+)
+
+// Prefixes of PlanNode.Items.
+const (
+	itemsFromInput = "input."
+	itemsFromStep  = "steps."
+)
+
+// parseItemsSource splits a foreach node's Items into its source ("input."
+// or "steps.") and the parameter or node it names.
+func parseItemsSource(items string) (string, string, error) {
+	items = strings.TrimSpace(items)
+	for _, prefix := range []string{itemsFromInput, itemsFromStep} {
+		if ref, ok := strings.CutPrefix(items, prefix); ok && strings.TrimSpace(ref) != "" {
+			return prefix, strings.TrimSpace(ref), nil
+		}
+	}
+	return "", "", fmt.Errorf("items must be %q or %q, got %q", itemsFromInput+"<parameter>", itemsFromStep+"<nodeId>", items)
+}
+
+// validateForeachNode checks the settings of a foreach node. Retries and
+// timeouts belong on the steps inside the loop.
+func validateForeachNode(node types.PlanNode) error {
+	if _, _, err := parseItemsSource(node.Items); err != nil {
+		return fmt.Errorf("foreach node %q: %w", node.ID, err)
+	}
+	if node.Parallelism < 0 || node.Parallelism > maxForeachParallelism {
+		return fmt.Errorf("foreach node %q: parallelism must be between 0 and %d", node.ID, maxForeachParallelism)
+	}
+	if node.MaxRetries > 0 || strings.TrimSpace(node.Timeout) != "" {
+		return fmt.Errorf("foreach node %q: set retries and timeouts on the steps inside the loop", node.ID)
+	}
+	return nil
+}
+
+func isEachEdge(e types.PlanEdge) bool {
+	return strings.EqualFold(strings.TrimSpace(e.Label), types.PlanEdgeEach)
+}
+
+// loopBody returns the nodes reachable from the first step of a foreach
+// node's body, following every edge.
+func loopBody(graph types.PlanGraph, entry string) map[string]bool {
+	body := map[string]bool{}
+	queue := []string{entry}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		if body[id] {
+			continue
+		}
+		body[id] = true
+		for _, e := range graph.Edges {
+			if e.Source == id {
+				queue = append(queue, e.Target)
+			}
+		}
+	}
+	return body
+}
+
+// validateLoops checks every foreach node: exactly one "each" edge, a body
+// that only runs actions and decisions and is closed off from the rest of
+// the graph, and an item source that exists outside the body.
+func validateLoops(graph types.PlanGraph, nodeTypes map[string]types.PlanNodeType) error {
+	eachEdges := make(map[string][]types.PlanEdge)
+	for _, e := range graph.Edges {
+		if !isEachEdge(e) {
+			continue
+		}
+		if nodeTypes[e.Source] != types.PlanNodeForeach {
+			return fmt.Errorf("edge %q: only foreach nodes have an %q edge", e.ID, types.PlanEdgeEach)
+		}
+		eachEdges[e.Source] = append(eachEdges[e.Source], e)
+	}
+	for _, n := range graph.Nodes {
+		if n.Type != types.PlanNodeForeach {
+			continue
+		}
+		edges := eachEdges[n.ID]
+		if len(edges) != 1 {
+			return fmt.Errorf("foreach node %q needs exactly one %q edge to the first step of its loop, has %d", n.ID, types.PlanEdgeEach, len(edges))
+		}
+		each := edges[0]
+		body := loopBody(graph, each.Target)
+		if body[n.ID] {
+			return fmt.Errorf("foreach node %q: its loop body leads back to the loop", n.ID)
+		}
+		for id := range body {
+			switch nodeTypes[id] {
+			case types.PlanNodeAction, types.PlanNodeDecision:
+			default:
+				return fmt.Errorf("foreach node %q: loop body can only contain action and decision nodes, %q is a %s", n.ID, id, nodeTypes[id])
+			}
+		}
+		for _, e := range graph.Edges {
+			if e.ID == each.ID {
+				continue
+			}
+			if body[e.Source] != body[e.Target] {
+				return fmt.Errorf("foreach node %q: edge %q crosses the loop body boundary; steps after the loop go on the foreach node's unlabeled edge", n.ID, e.ID)
+			}
+		}
+		if source, ref, _ := parseItemsSource(n.Items); source == itemsFromStep {
+			if _, ok := nodeTypes[ref]; !ok {
+				return fmt.Errorf("foreach node %q: items come from unknown step %q", n.ID, ref)
+			}
+			if ref == n.ID || body[ref] {
+				return fmt.Errorf("foreach node %q: items must come from a step before the loop", n.ID)
+			}
+		}
+	}
+	return nil
+}
+
+// parseItems turns an item source into a list. Lists are used as they are;
+// text may be a JSON array, one item per line or a comma-separated list.
+func parseItems(v any) ([]any, error) {
+	switch v := v.(type) {
+	case []any:
+		return v, nil
+	case []string:
+		out := make([]any, len(v))
+		for i, s := range v {
+			out[i] = s
+		}
+		return out, nil
+	case string:
+		if items, ok := jsonArray(v); ok {
+			return items, nil
+		}
+		sep := "\n"
+		if !strings.Contains(strings.TrimSpace(v), "\n") {
+			sep = ","
+		}
+		var out []any
+		for _, part := range strings.Split(v, sep) {
+			part = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(part), "-*•"))
+			if part != "" {
+				out = append(out, part)
+			}
+		}
+		return out, nil
+	case nil:
+		return nil, fmt.Errorf("no value")
+	}
+	return nil, fmt.Errorf("%T is not a list", v)
+}
+
+// jsonArray finds a JSON array in text: the whole text, or the span between
+// its first "[" and last "]", which covers fenced blocks and a sentence of
+// preamble.
+func jsonArray(text string) ([]any, bool) {
+	text = strings.TrimSpace(text)
+	start, end := strings.Index(text, "["), strings.LastIndex(text, "]")
+	if start < 0 || end < start {
+		return nil, false
+	}
+	var items []any
+	if err := json.Unmarshal([]byte(text[start:end+1]), &items); err != nil {
+		return nil, false
+	}
+	return items, true
+}
+
+// foreachItems resolves the list a foreach node iterates. A step's output
+// must contain a JSON array, so a chatty reply is not split into items.
+func (r *Runner) foreachItems(ctx context.Context, run types.PlanRun, node types.PlanNode, input map[string]any) ([]any, error) {
+	source, ref, err := parseItemsSource(node.Items)
+	if err != nil {
+		return nil, err
+	}
+	var items []any
+	switch source {
+	case itemsFromInput:
+		v, ok := input[ref]
+		if !ok {
+			return nil, fmt.Errorf("items: run input has no %q", ref)
+		}
+		if items, err = parseItems(v); err != nil {
+			return nil, fmt.Errorf("items: input %q: %w", ref, err)
+		}
+	case itemsFromStep:
+		content, err := r.stepOutput(ctx, run, ref)
+		if err != nil {
+			return nil, fmt.Errorf("items: %w", err)
+		}
+		var ok bool
+		if items, ok = jsonArray(content); !ok {
+			return nil, fmt.Errorf("items: output of step %q has no JSON array; ask the step to answer with one", ref)
+		}
+	}
+	if len(items) > maxForeachItems {
+		return nil, fmt.Errorf("items: %d items, at most %d are allowed", len(items), maxForeachItems)
+	}
+	return items, nil
+}
+
+// stepOutput returns the reply of a completed step of the run.
+func (r *Runner) stepOutput(ctx context.Context, run types.PlanRun, nodeID string) (string, error) {
+	for _, s := range run.Steps {
+		if s.NodeID != nodeID {
+			continue
+		}
+		if s.Status != "completed" || s.MessageID == "" {
+			return "", fmt.Errorf("step %q has no output in this run", nodeID)
+		}
+		msgs, err := r.messageStore.Get(ctx, []string{s.MessageID})
+		if err != nil {
+			return "", fmt.Errorf("load output of step %q: %w", nodeID, err)
+		}
+		msg, ok := msgs[s.MessageID]
+		if !ok {
+			return "", fmt.Errorf("output of step %q not found", nodeID)
+		}
+		return msg.Content, nil
+	}
+	return "", fmt.Errorf("unknown step %q", nodeID)
+}
+
+// itemInput exposes the current item to prompt templates as {{.item}} and
+// its zero-based position as {{.index}}.
+func itemInput(input map[string]any, item any, index int) map[string]any {
+	out := make(map[string]any, len(input)+2)
+	for k, v := range input {
+		out[k] = v
+	}
+	out["item"] = item
+	out["index"] = index
+	return out
+}
+
+// runForeach runs the loop body of a foreach node once per item, each item
+// in a session of its own, at most Parallelism items at a time. Every item
+// runs even when others fail; the step fails if any item did. The result
+// summarizes each item's outcome for the step after the loop.
+func (r *Runner) runForeach(ctx context.Context, plan types.Plan, run *types.PlanRun, node types.PlanNode, input map[string]any) (nodeResult, error) {
+	items, err := r.foreachItems(ctx, *run, node, input)
+	if err != nil {
+		return nodeResult{}, err
+	}
+	entry := findLabeledEdge(plan.Graph, node.ID, types.PlanEdgeEach)
+	idx := stepIndex(*run, node.ID)
+	if idx < 0 {
+		return nodeResult{}, fmt.Errorf("step %q not found in run", node.ID)
+	}
+
+	var mu sync.Mutex
+	run.Steps[idx].Items = make([]types.PlanItemRun, len(items))
+	for i, item := range items {
+		run.Steps[idx].Items[i] = types.PlanItemRun{Index: i, Item: item, Status: "pending"}
+	}
+	r.saveRun(run)
+	update := func(item types.PlanItemRun) {
+		mu.Lock()
+		defer mu.Unlock()
+		item.Steps = slices.Clone(item.Steps)
+		run.Steps[idx].Items[item.Index] = item
+		r.saveRun(run)
+	}
+
+	simRun := types.PlanRun{Trigger: run.Trigger, Mocks: run.Mocks}
+	parallelism := max(node.Parallelism, 1)
+	sem := make(chan struct{}, parallelism)
+	var wg sync.WaitGroup
+launch:
+	for i, item := range items {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			break launch
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			r.runItem(ctx, plan, run.ID, simRun, node, entry, i, item, input, update)
+		}()
+	}
+	wg.Wait()
+
+	finished := run.Steps[idx].Items
+	now := time.Now().UTC()
+	for i := range finished {
+		if finished[i].Status == "pending" {
+			finished[i].Status = "skipped"
+		}
+	}
+	summarizeBodySteps(run, loopBody(plan.Graph, entry), finished, now)
+	r.saveRun(run)
+
+	res := nodeResult{content: loopSummary(node, finished)}
+	if ctx.Err() != nil {
+		return res, ctx.Err()
+	}
+	var failed []string
+	for _, it := range finished {
+		if it.Status != "completed" {
+			failed = append(failed, itemLabel(it.Item))
+		}
+	}
+	if len(failed) > 0 {
+		return res, &stepFailure{
+			class: types.PlanRetryOnError,
+			msg:   fmt.Sprintf("%d of %d items failed: %s", len(failed), len(finished), clip(strings.Join(failed, ", "), maxFailureContext)),
+		}
+	}
+	return res, nil
+}
+
+// runItem walks the loop body for one item, recording each step it takes on
+// the item. Error edges inside the body work like they do in the plan.
+func (r *Runner) runItem(ctx context.Context, plan types.Plan, runID string, simRun types.PlanRun, loop types.PlanNode, entry string, index int, value any, input map[string]any, update func(types.PlanItemRun)) {
+	sessionID := fmt.Sprintf("plan:%s:%s:%s:%d", plan.ID, runID, loop.ID, index)
+	started := time.Now().UTC()
+	item := types.PlanItemRun{Index: index, Item: value, SessionID: sessionID, Status: "running", StartedAt: &started}
+	update(item)
+	finish := func(status, result string) {
+		now := time.Now().UTC()
+		item.Status, item.Result, item.FinishedAt = status, result, &now
+		update(item)
+	}
+
+	if r.buffer != nil {
+		r.buffer.MarkSessionActive(sessionID)
+		defer r.buffer.MarkSessionInactive(sessionID)
+	}
+	if _, err := r.sessionPolicy.Execute(ctx, sessionplugin.Input{
+		Mode:      sessionplugin.ModeEnsure,
+		SessionID: sessionID,
+		Source:    "plan",
+		Title:     fmt.Sprintf("Plan: %s · %s #%d", plan.Name, nodeName(loop), index+1),
+	}); err != nil {
+		log.Printf("plans: ensure item session: %v", err)
+		finish("failed", "could not create the item session")
+		return
+	}
+
+	nodeMap := make(map[string]types.PlanNode, len(plan.Graph.Nodes))
+	for _, n := range plan.Graph.Nodes {
+		nodeMap[n.ID] = n
+	}
+	input = itemInput(input, value, index)
+
+	var failedNode *types.PlanNode
+	var failedErr error
+	var lastOutput string
+	current := entry
+	for transitions := 1; current != ""; transitions++ {
+		if transitions > maxTransitions {
+			finish("failed", fmt.Sprintf("max transitions exceeded (%d)", maxTransitions))
+			return
+		}
+		if ctx.Err() != nil {
+			finish("cancelled", "cancelled")
+			return
+		}
+		node := nodeMap[current]
+
+		now := time.Now().UTC()
+		item.Steps = append(item.Steps, types.PlanStepRun{NodeID: current, Status: "running", StartedAt: &now})
+		step := &item.Steps[len(item.Steps)-1]
+		update(item)
+
+		stepInput := input
+		if failedNode != nil {
+			stepInput = failureInput(input, *failedNode, failedErr)
+		}
+		prompt := renderPrompt(node.Prompt, stepInput)
+		if node.Type == types.PlanNodeDecision {
+			prompt = decisionPrompt(node, stepInput)
+		}
+		if failedNode != nil {
+			prompt = errorHandlerPrompt(*failedNode, failedErr, prompt)
+			failedNode, failedErr = nil, nil
+		}
+
+		sim := stepSimulation(simRun)
+		res, attempts, err := r.executeWithRetry(ctx, sessionID, node, nodeModel(plan, node), prompt, sim)
+		finishedAt := time.Now().UTC()
+		step.Attempts, step.MessageID, step.FinishedAt = attempts, res.messageID, &finishedAt
+		step.ModelID, step.ModelName, step.PresetName = res.modelID, res.modelName, res.presetName
+		if sim != nil {
+			step.SimulatedCalls = sim.Calls()
+		}
+		if err != nil {
+			step.Status, step.Result = "failed", err.Error()
+			if ctx.Err() != nil {
+				step.Result = "cancelled"
+				finish("cancelled", "cancelled")
+				return
+			}
+			if handler := findErrorTarget(plan.Graph, current); handler != "" {
+				update(item)
+				failedNode, failedErr = &node, err
+				current = handler
+				continue
+			}
+			finish("failed", clip(err.Error(), maxItemResult))
+			return
+		}
+
+		step.Status = "completed"
+		if node.Type == types.PlanNodeDecision {
+			branch := parseDecision(res.content)
+			step.Result = branch
+			current = findEdgeTarget(plan.Graph, current, branch)
+		} else {
+			lastOutput = res.content
+			current = findNextNode(plan.Graph, current)
+		}
+		update(item)
+	}
+	finish("completed", clip(strings.TrimSpace(lastOutput), maxItemResult))
+}
+
+// summarizeBodySteps sets the run's own step for each body node from what
+// the items did with it: failed if it failed for any item, completed if it
+// ran, skipped otherwise.
+func summarizeBodySteps(run *types.PlanRun, body map[string]bool, items []types.PlanItemRun, now time.Time) {
+	for i := range run.Steps {
+		s := &run.Steps[i]
+		if !body[s.NodeID] {
+			continue
+		}
+		ran, failed := 0, 0
+		var first *time.Time
+		for _, it := range items {
+			for _, is := range it.Steps {
+				if is.NodeID != s.NodeID {
+					continue
+				}
+				ran++
+				if is.Status == "failed" {
+					failed++
+				}
+				if is.StartedAt != nil && (first == nil || is.StartedAt.Before(*first)) {
+					first = is.StartedAt
+				}
+			}
+		}
+		switch {
+		case ran == 0:
+			s.Status = "skipped"
+			continue
+		case failed > 0:
+			s.Status = "failed"
+			s.Result = fmt.Sprintf("failed %d of %d times", failed, ran)
+		default:
+			s.Status = "completed"
+			s.Result = fmt.Sprintf("ran %d times", ran)
+		}
+		s.StartedAt, s.FinishedAt = first, &now
+	}
+}
+
+// loopSummary lists each item's outcome for the step after the loop and for
+// run reports.
+func loopSummary(node types.PlanNode, items []types.PlanItemRun) string {
+	completed := 0
+	for _, it := range items {
+		if it.Status == "completed" {
+			completed++
+		}
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%d of %d items completed", completed, len(items))
+	for _, it := range items {
+		fmt.Fprintf(&sb, "\n- %s: %s", itemLabel(it.Item), it.Status)
+		if result := strings.TrimSpace(it.Result); result != "" {
+			fmt.Fprintf(&sb, " — %s", strings.ReplaceAll(clip(result, maxLoopSummaryResult), "\n", " "))
+		}
+	}
+	return sb.String()
+}
+
+func loopResultPrompt(loop types.PlanNode, summary, prompt string) string {
+	return fmt.Sprintf("The loop %q finished: %s\n\n%s", nodeName(loop), summary, prompt)
+}
+
+func itemLabel(item any) string {
+	if s, ok := item.(string); ok {
+		return clip(s, 80)
+	}
+	raw, err := json.Marshal(item)
+	if err != nil {
+		return fmt.Sprint(item)
+	}
+	return clip(string(raw), 80)
+}
+
+func stepIndex(run types.PlanRun, nodeID string) int {
+	for i := range run.Steps {
+		if run.Steps[i].NodeID == nodeID {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,209 @@
+package plans
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"mantis/core/types"
+)
+
+// loopGraph is start → loop, with the loop body check → fix and the loop
+// continuing to done.
+func loopGraph() types.PlanGraph {
+	return types.PlanGraph{
+		Nodes: []types.PlanNode{
+			{ID: "start", Type: types.PlanNodeAction, Prompt: "List hosts as a JSON array"},
+			{ID: "loop", Type: types.PlanNodeForeach, Items: "steps.start", Parallelism: 2},
+			{ID: "check", Type: types.PlanNodeDecision, Prompt: "Is the cert on {{.item}} expiring?"},
+			{ID: "fix", Type: types.PlanNodeAction, Prompt: "Rotate the cert on {{.item}}"},
+			{ID: "done", Type: types.PlanNodeAction, Prompt: "Summarize"},
+		},
+		Edges: []types.PlanEdge{
+			{ID: "e1", Source: "start", Target: "loop"},
+			{ID: "e2", Source: "loop", Target: "check", Label: "each"},
+			{ID: "e3", Source: "check", Target: "fix", Label: "yes"},
+			{ID: "e4", Source: "loop", Target: "done"},
+		},
+	}
+}
+
+// --- validateGraph with loops ---
+
+func TestValidateGraph_Foreach(t *testing.T) {
+	if err := validateGraph(loopGraph()); err != nil {
+		t.Fatalf("expected valid loop, got %v", err)
+	}
+}
+
+func TestValidateGraph_ForeachErrors(t *testing.T) {
+	cases := map[string]struct {
+		edit func(*types.PlanGraph)
+		want string
+	}{
+		"no each edge": {
+			edit: func(g *types.PlanGraph) { g.Edges = append(g.Edges[:1], g.Edges[2:]...) },
+			want: "exactly one",
+		},
+		"body leaves the loop": {
+			edit: func(g *types.PlanGraph) {
+				g.Edges = append(g.Edges, types.PlanEdge{ID: "e5", Source: "fix", Target: "done"})
+			},
+			want: "crosses the loop body",
+		},
+		"body entered from outside": {
+			edit: func(g *types.PlanGraph) {
+				g.Edges = append(g.Edges, types.PlanEdge{ID: "e5", Source: "done", Target: "fix"})
+			},
+			want: "crosses the loop body",
+		},
+		"body loops back": {
+			edit: func(g *types.PlanGraph) {
+				g.Edges = append(g.Edges, types.PlanEdge{ID: "e5", Source: "fix", Target: "loop"})
+			},
+			want: "leads back",
+		},
+		"approval in body": {
+			edit: func(g *types.PlanGraph) { g.Nodes[3].Type = types.PlanNodeApproval },
+			want: "only contain action and decision",
+		},
+		"bad items": {
+			edit: func(g *types.PlanGraph) { g.Nodes[1].Items = "hosts" },
+			want: "items must be",
+		},
+		"items from body": {
+			edit: func(g *types.PlanGraph) { g.Nodes[1].Items = "steps.fix" },
+			want: "before the loop",
+		},
+		"parallelism too high": {
+			edit: func(g *types.PlanGraph) { g.Nodes[1].Parallelism = maxForeachParallelism + 1 },
+			want: "parallelism",
+		},
+		"retries on loop": {
+			edit: func(g *types.PlanGraph) { g.Nodes[1].MaxRetries = 2 },
+			want: "inside the loop",
+		},
+		"each from an action": {
+			edit: func(g *types.PlanGraph) { g.Edges[0].Label = "each" },
+			want: "only foreach nodes",
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			g := loopGraph()
+			tc.edit(&g)
+			err := validateGraph(g)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("expected error containing %q, got %v", tc.want, err)
+			}
+		})
+	}
+}
+
+func TestFindNextNode_SkipsEachEdge(t *testing.T) {
+	if got := findNextNode(loopGraph(), "loop"); got != "done" {
+		t.Fatalf("expected done, got %q", got)
+	}
+}
+
+// --- parseItems ---
+
+func TestParseItems(t *testing.T) {
+	cases := map[string]struct {
+		in   any
+		want []string
+	}{
+		"list":       {in: []any{"a", "b"}, want: []string{"a", "b"}},
+		"json text":  {in: `["a", "b"]`, want: []string{"a", "b"}},
+		"lines":      {in: "- a\n- b\n\n", want: []string{"a", "b"}},
+		"commas":     {in: "a, b ,c", want: []string{"a", "b", "c"}},
+		"empty text": {in: "", want: nil},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := parseItems(tc.in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tc.want) {
+				t.Fatalf("expected %v, got %v", tc.want, got)
+			}
+			for i := range got {
+				if got[i] != tc.want[i] {
+					t.Fatalf("expected %v, got %v", tc.want, got)
+				}
+			}
+		})
+	}
+	if _, err := parseItems(42.0); err == nil {
+		t.Fatal("expected a number to be rejected")
+	}
+}
+
+func TestJSONArray_InProse(t *testing.T) {
+	items, ok := jsonArray("Here are the hosts:\n```json\n[\"web-1\", {\"host\": \"db-1\"}]\n```")
+	if !ok || len(items) != 2 || items[0] != "web-1" {
+		t.Fatalf("unexpected: %v %v", items, ok)
+	}
+	if _, ok := jsonArray("no list here"); ok {
+		t.Fatal("expected no array")
+	}
+}
+
+func TestItemInput(t *testing.T) {
+	input := map[string]any{"env": "prod"}
+	got := itemInput(input, "web-1", 3)
+	if got["item"] != "web-1" || got["index"] != 3 || got["env"] != "prod" {
+		t.Fatalf("unexpected: %v", got)
+	}
+	if _, ok := input["item"]; ok {
+		t.Fatal("run input must not be modified")
+	}
+	if p := renderPrompt("Rotate {{.item}} in {{.env}}", got); p != "Rotate web-1 in prod" {
+		t.Fatalf("unexpected prompt %q", p)
+	}
+}
+
+// --- loop results ---
+
+func TestSummarizeBodySteps(t *testing.T) {
+	start := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	end := start.Add(time.Minute)
+	run := types.PlanRun{Steps: initSteps(loopGraph())}
+	items := []types.PlanItemRun{
+		{Index: 0, Status: "completed", Steps: []types.PlanStepRun{
+			{NodeID: "check", Status: "completed", StartedAt: &start},
+			{NodeID: "fix", Status: "completed"},
+		}},
+		{Index: 1, Status: "failed", Steps: []types.PlanStepRun{
+			{NodeID: "check", Status: "completed"},
+			{NodeID: "fix", Status: "failed"},
+		}},
+	}
+	summarizeBodySteps(&run, loopBody(loopGraph(), "check"), items, end)
+
+	byID := map[string]types.PlanStepRun{}
+	for _, s := range run.Steps {
+		byID[s.NodeID] = s
+	}
+	if s := byID["check"]; s.Status != "completed" || s.Result != "ran 2 times" || !s.StartedAt.Equal(start) {
+		t.Fatalf("unexpected check step: %+v", s)
+	}
+	if s := byID["fix"]; s.Status != "failed" || s.Result != "failed 1 of 2 times" {
+		t.Fatalf("unexpected fix step: %+v", s)
+	}
+	if s := byID["done"]; s.Status != "pending" {
+		t.Fatalf("steps outside the body must not change, got %+v", s)
+	}
+}
+
+func TestLoopSummary(t *testing.T) {
+	got := loopSummary(types.PlanNode{ID: "loop"}, []types.PlanItemRun{
+		{Item: "web-1", Status: "completed", Result: "rotated\nok"},
+		{Item: map[string]any{"host": "db-1"}, Status: "failed"},
+	})
+	want := "1 of 2 items completed\n- web-1: completed — rotated ok\n- {\"host\":\"db-1\"}: failed"
+	if got != want {
+		t.Fatalf("unexpected summary:\n%s", got)
+	}
+}
@@ -0,0 +1,102 @@
+package plans
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// lookupJSONPath evaluates a simple JSONPath expression against a decoded
+// JSON document. Supported syntax: "$", ".key", "['key']", "[\"key\"]" and
+// "[index]" (negative indexes count from the end). Wildcards and filters are
+// intentionally not supported — webhook mappings only pick single values.
+func lookupJSONPath(doc any, path string) (any, bool, error) {
+	segments, err := parseJSONPath(path)
+	if err != nil {
+		return nil, false, err
+	}
+	cur := doc
+	for _, seg := range segments {
+		switch v := cur.(type) {
+		case map[string]any:
+			if seg.isIndex {
+				return nil, false, nil
+			}
+			next, ok := v[seg.key]
+			if !ok {
+				return nil, false, nil
+			}
+			cur = next
+		case []any:
+			if !seg.isIndex {
+				return nil, false, nil
+			}
+			idx := seg.index
+			if idx < 0 {
+				idx += len(v)
+			}
+			if idx < 0 || idx >= len(v) {
+				return nil, false, nil
+			}
+			cur = v[idx]
+		default:
+			return nil, false, nil
+		}
+	}
+	return cur, true, nil
+}
+
+type jsonPathSegment struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+func parseJSONPath(path string) ([]jsonPathSegment, error) {
+	p := strings.TrimSpace(path)
+	if p == "" {
+		return nil, fmt.Errorf("empty JSONPath")
+	}
+	if p[0] == '$' {
+		p = p[1:]
+	} else if p[0] != '.' && p[0] != '[' {
+		p = "." + p
+	}
+
+	var segments []jsonPathSegment
+	for len(p) > 0 {
+		switch p[0] {
+		case '.':
+			p = p[1:]
+			end := strings.IndexAny(p, ".[")
+			if end < 0 {
+				end = len(p)
+			}
+			key := p[:end]
+			if key == "" {
+				return nil, fmt.Errorf("invalid JSONPath %q: empty key", path)
+			}
+			segments = append(segments, jsonPathSegment{key: key})
+			p = p[end:]
+		case '[':
+			end := strings.IndexByte(p, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("invalid JSONPath %q: unclosed bracket", path)
+			}
+			inner := strings.TrimSpace(p[1:end])
+			p = p[end+1:]
+			if len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0] {
+				segments = append(segments, jsonPathSegment{key: inner[1 : len(inner)-1]})
+				continue
+			}
+			idx, err := strconv.Atoi(inner)
+			if err != nil {
+				return nil, fmt.Errorf("invalid JSONPath %q: unsupported selector [%s]", path, inner)
+			}
+			segments = append(segments, jsonPathSegment{index: idx, isIndex: true})
+		default:
+			return nil, fmt.Errorf("invalid JSONPath %q: unexpected %q", path, p[0])
+		}
+	}
+	return segments, nil
+}
@@ -0,0 +1,147 @@
+package plans
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"mantis/core/types"
+)
+
+// memStore is an in-memory store for tests. Items keep the order they were
+// first saved in. List applies filters through field, when set, then sorts
+// with less, when set, then the page limit; clone, when set, copies items
+// as they are saved so later changes by the caller don't leak in.
+type memStore[T any] struct {
+	mu    sync.Mutex
+	items []T
+	id    func(T) string
+	field func(T, string) string
+	less  func(a, b T) bool
+	clone func(T) T
+}
+
+func newMemStore[T any](id func(T) string, items ...T) *memStore[T] {
+	return &memStore[T]{id: id, items: items}
+}
+
+func (s *memStore[T]) Create(ctx context.Context, items []T) ([]T, error) {
+	return s.Update(ctx, items)
+}
+
+func (s *memStore[T]) Get(_ context.Context, ids []string) (map[string]T, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := map[string]T{}
+	for _, it := range s.items {
+		if slices.Contains(ids, s.id(it)) {
+			out[s.id(it)] = it
+		}
+	}
+	return out, nil
+}
+
+func (s *memStore[T]) List(_ context.Context, q types.ListQuery) ([]T, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []T
+	for _, it := range s.items {
+		ok := true
+		for col, v := range q.Filter {
+			ok = ok && (s.field == nil || s.field(it, col) == v)
+		}
+		if ok {
+			out = append(out, it)
+		}
+	}
+	if s.less != nil {
+		slices.SortStableFunc(out, func(a, b T) int {
+			switch {
+			case s.less(a, b):
+				return -1
+			case s.less(b, a):
+				return 1
+			}
+			return 0
+		})
+	}
+	if q.Page.Limit > 0 && len(out) > q.Page.Limit {
+		out = out[:q.Page.Limit]
+	}
+	return out, nil
+}
+
+func (s *memStore[T]) Update(_ context.Context, items []T) ([]T, error) {
+	for _, it := range items {
+		s.put(it)
+	}
+	return items, nil
+}
+
+func (s *memStore[T]) Delete(_ context.Context, ids []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.items = slices.DeleteFunc(s.items, func(it T) bool { return slices.Contains(ids, s.id(it)) })
+	return nil
+}
+
+// put saves an item, replacing the one with the same ID.
+func (s *memStore[T]) put(it T) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.clone != nil {
+		it = s.clone(it)
+	}
+	for i := range s.items {
+		if s.id(s.items[i]) == s.id(it) {
+			s.items[i] = it
+			return
+		}
+	}
+	s.items = append(s.items, it)
+}
+
+// get returns the item with an ID, or the zero value.
+func (s *memStore[T]) get(id string) T {
+	items, _ := s.Get(context.Background(), []string{id})
+	return items[id]
+}
+
+func newPlanStore(items ...types.Plan) *memStore[types.Plan] {
+	return newMemStore(func(p types.Plan) string { return p.ID }, items...)
+}
+
+// newRunStore filters on plan_id and status. Steps are copied on save, as
+// the runner keeps changing the run it saved.
+func newRunStore(items ...types.PlanRun) *memStore[types.PlanRun] {
+	s := newMemStore(func(r types.PlanRun) string { return r.ID }, items...)
+	s.field = func(r types.PlanRun, col string) string {
+		switch col {
+		case "plan_id":
+			return r.PlanID
+		case "status":
+			return r.Status
+		case "idempotency_key":
+			return r.IdempotencyKey
+		}
+		return ""
+	}
+	s.clone = func(r types.PlanRun) types.PlanRun {
+		r.Steps = slices.Clone(r.Steps)
+		return r
+	}
+	return s
+}
+
+// newRevisionStore filters on plan_id and lists the newest version first.
+func newRevisionStore() *memStore[types.PlanRevision] {
+	s := newMemStore(func(r types.PlanRevision) string { return r.ID })
+	s.field = func(r types.PlanRevision, col string) string {
+		if col == "plan_id" {
+			return r.PlanID
+		}
+		return ""
+	}
+	s.less = func(a, b types.PlanRevision) bool { return a.Version > b.Version }
+	return s
+}
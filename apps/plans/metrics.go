@@ -0,0 +1,273 @@
+package plans
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	"mantis/core/base"
+	"mantis/core/types"
+)
+
+const (
+	// DefaultMetricsWindow is how far back plan metrics look by default.
+	DefaultMetricsWindow = 30 * 24 * time.Hour
+	// maxMetricsRuns bounds the runs aggregated per plan, newest first.
+	maxMetricsRuns = 500
+)
+
+// PlanMetrics aggregates a plan's finished runs started since the given
+// time: success rate, run and per-node duration percentiles, retries, the
+// node that fails most, token usage from the step messages and the plan's
+// current SLA breaches.
+func (r *Runner) PlanMetrics(ctx context.Context, planID string, since time.Time) (types.PlanMetrics, error) {
+	plans, err := r.planStore.Get(ctx, []string{planID})
+	if err != nil {
+		return types.PlanMetrics{}, err
+	}
+	plan, ok := plans[planID]
+	if !ok {
+		return types.PlanMetrics{}, base.ErrNotFound
+	}
+	return r.planMetrics(ctx, plan, since)
+}
+
+// AllPlanMetrics returns metrics for every plan, least healthy first: plans
+// breaching their SLA, then by failure streak and success rate.
+func (r *Runner) AllPlanMetrics(ctx context.Context, since time.Time) ([]types.PlanMetrics, error) {
+	plans, err := r.planStore.List(ctx, types.ListQuery{})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]types.PlanMetrics, 0, len(plans))
+	for _, plan := range plans {
+		m, err := r.planMetrics(ctx, plan, since)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, m)
+	}
+	slices.SortStableFunc(out, compareHealth)
+	return out, nil
+}
+
+func (r *Runner) planMetrics(ctx context.Context, plan types.Plan, since time.Time) (types.PlanMetrics, error) {
+	runs, err := r.runStore.List(ctx, types.ListQuery{
+		Filter: map[string]string{"plan_id": plan.ID},
+		Sort:   []types.Sort{{Field: "started_at", Dir: types.SortDirDesc}},
+		Page:   types.Page{Limit: maxMetricsRuns},
+	})
+	if err != nil {
+		return types.PlanMetrics{}, fmt.Errorf("load runs of plan %s: %w", plan.ID, err)
+	}
+	runs = slices.DeleteFunc(runs, func(run types.PlanRun) bool {
+		return run.StartedAt.Before(since) || !countsForMetrics(run)
+	})
+	usage, err := r.stepUsage(ctx, runs)
+	if err != nil {
+		return types.PlanMetrics{}, err
+	}
+	return computeMetrics(plan, runs, usage, since), nil
+}
+
+// stepUsage loads the token usage of every step message in runs, loop
+// items included.
+func (r *Runner) stepUsage(ctx context.Context, runs []types.PlanRun) (map[string]types.PlanTokenUsage, error) {
+	var ids []string
+	for _, run := range runs {
+		for _, s := range run.Steps {
+			if s.MessageID != "" {
+				ids = append(ids, s.MessageID)
+			}
+			for _, it := range s.Items {
+				for _, is := range it.Steps {
+					if is.MessageID != "" {
+						ids = append(ids, is.MessageID)
+					}
+				}
+			}
+		}
+	}
+	if len(ids) == 0 || r.messageStore == nil {
+		return nil, nil
+	}
+	msgs, err := r.messageStore.Get(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("load step messages: %w", err)
+	}
+	usage := make(map[string]types.PlanTokenUsage, len(msgs))
+	for id, m := range msgs {
+		total := m.Tokens
+		if total == 0 {
+			total = m.PromptTokens + m.CompletionTokens
+		}
+		usage[id] = types.PlanTokenUsage{Prompt: m.PromptTokens, Completion: m.CompletionTokens, Total: total}
+	}
+	return usage, nil
+}
+
+// countsForMetrics keeps finished real runs that actually executed.
+func countsForMetrics(run types.PlanRun) bool {
+	if isSimulation(run) || run.FinishedAt == nil {
+		return false
+	}
+	switch run.Status {
+	case "completed", "failed", "cancelled":
+		return true
+	}
+	return false
+}
+
+// computeMetrics aggregates runs, newest first, that all count for metrics.
+func computeMetrics(plan types.Plan, runs []types.PlanRun, usage map[string]types.PlanTokenUsage, since time.Time) types.PlanMetrics {
+	m := types.PlanMetrics{
+		PlanID:   plan.ID,
+		PlanName: plan.Name,
+		Since:    since,
+		Runs:     len(runs),
+		SLA:      plan.SLA,
+		Nodes:    []types.PlanNodeMetrics{},
+	}
+	if len(runs) > 0 {
+		last := runs[0].StartedAt
+		m.LastRunAt = &last
+	}
+
+	type nodeAcc struct {
+		metrics   types.PlanNodeMetrics
+		durations []time.Duration
+	}
+	nodes := make(map[string]*nodeAcc, len(plan.Graph.Nodes))
+	for _, n := range plan.Graph.Nodes {
+		nodes[n.ID] = &nodeAcc{metrics: types.PlanNodeMetrics{NodeID: n.ID, Label: nodeName(n)}}
+	}
+
+	var durations []time.Duration
+	for _, run := range runs {
+		switch run.Status {
+		case "completed":
+			m.Completed++
+		case "failed":
+			m.Failed++
+		case "cancelled":
+			m.Cancelled++
+		}
+		if run.Status != "cancelled" {
+			durations = append(durations, run.FinishedAt.Sub(run.StartedAt))
+		}
+		for _, s := range run.Steps {
+			acc, ok := nodes[s.NodeID]
+			if !ok || s.StartedAt == nil || s.FinishedAt == nil {
+				continue
+			}
+			if s.Status != "completed" && s.Status != "failed" {
+				continue
+			}
+			acc.metrics.Runs++
+			if s.Status == "failed" {
+				acc.metrics.Failures++
+			}
+			retries := max(s.Attempts-1, 0)
+			acc.metrics.Retries += retries
+			m.Retries += retries
+			acc.durations = append(acc.durations, s.FinishedAt.Sub(*s.StartedAt))
+			if u, ok := usage[s.MessageID]; ok {
+				acc.metrics.Tokens = addUsage(acc.metrics.Tokens, u)
+				m.Tokens = addUsage(m.Tokens, u)
+			}
+			for _, it := range s.Items {
+				for _, is := range it.Steps {
+					body, ok := nodes[is.NodeID]
+					if !ok {
+						continue
+					}
+					retries := max(is.Attempts-1, 0)
+					body.metrics.Retries += retries
+					m.Retries += retries
+					if u, ok := usage[is.MessageID]; ok {
+						body.metrics.Tokens = addUsage(body.metrics.Tokens, u)
+						m.Tokens = addUsage(m.Tokens, u)
+					}
+				}
+			}
+		}
+	}
+
+	if finished := m.Completed + m.Failed; finished > 0 {
+		m.SuccessRate = float64(m.Completed) / float64(finished)
+	}
+	m.ConsecutiveFailures = consecutiveFailures(runs)
+	m.DurationP50Ms = percentileMs(durations, 0.50)
+	m.DurationP95Ms = percentileMs(durations, 0.95)
+
+	mostFailures := 0
+	for _, n := range plan.Graph.Nodes {
+		acc := nodes[n.ID]
+		acc.metrics.DurationP50Ms = percentileMs(acc.durations, 0.50)
+		acc.metrics.DurationP95Ms = percentileMs(acc.durations, 0.95)
+		m.Nodes = append(m.Nodes, acc.metrics)
+		if acc.metrics.Failures > mostFailures {
+			mostFailures = acc.metrics.Failures
+			m.MostFailingNode = n.ID
+		}
+	}
+
+	if len(runs) > 0 {
+		m.SLABreaches = slaBreaches(plan.SLA, runs[0], m.ConsecutiveFailures)
+	}
+	return m
+}
+
+func addUsage(a, b types.PlanTokenUsage) types.PlanTokenUsage {
+	return types.PlanTokenUsage{
+		Prompt:     a.Prompt + b.Prompt,
+		Completion: a.Completion + b.Completion,
+		Total:      a.Total + b.Total,
+	}
+}
+
+// consecutiveFailures counts the failed runs at the head of runs (newest
+// first). Cancelled runs neither extend nor break the streak.
+func consecutiveFailures(runs []types.PlanRun) int {
+	n := 0
+	for _, run := range runs {
+		switch run.Status {
+		case "failed":
+			n++
+		case "completed":
+			return n
+		}
+	}
+	return n
+}
+
+// percentileMs returns the nearest-rank percentile of ds in milliseconds.
+func percentileMs(ds []time.Duration, p float64) int64 {
+	if len(ds) == 0 {
+		return 0
+	}
+	sorted := slices.Clone(ds)
+	slices.Sort(sorted)
+	i := int(math.Ceil(p*float64(len(sorted)))) - 1
+	i = min(max(i, 0), len(sorted)-1)
+	return sorted[i].Milliseconds()
+}
+
+// compareHealth orders plans least healthy first. Plans without runs go last.
+func compareHealth(a, b types.PlanMetrics) int {
+	if (a.Runs == 0) != (b.Runs == 0) {
+		if a.Runs == 0 {
+			return 1
+		}
+		return -1
+	}
+	return cmp.Or(
+		cmp.Compare(len(b.SLABreaches), len(a.SLABreaches)),
+		cmp.Compare(b.ConsecutiveFailures, a.ConsecutiveFailures),
+		cmp.Compare(a.SuccessRate, b.SuccessRate),
+		cmp.Compare(a.PlanName, b.PlanName),
+	)
+}
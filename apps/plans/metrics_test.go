@@ -0,0 +1,194 @@
+package plans
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"mantis/core/base"
+	"mantis/core/types"
+)
+
+var metricsBase = time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+
+func metricsStep(nodeID, status string, start, took time.Duration, attempts int, messageID string) types.PlanStepRun {
+	s := metricsBase.Add(start)
+	f := s.Add(took)
+	return types.PlanStepRun{NodeID: nodeID, Status: status, StartedAt: &s, FinishedAt: &f, Attempts: attempts, MessageID: messageID}
+}
+
+func metricsRun(id, status string, start, took time.Duration, steps ...types.PlanStepRun) types.PlanRun {
+	s := metricsBase.Add(start)
+	f := s.Add(took)
+	return types.PlanRun{ID: id, Status: status, Trigger: "schedule", StartedAt: s, FinishedAt: &f, Steps: steps}
+}
+
+func metricsPlan() types.Plan {
+	return types.Plan{
+		ID:   "p1",
+		Name: "Backups",
+		Graph: types.PlanGraph{Nodes: []types.PlanNode{
+			{ID: "dump", Type: types.PlanNodeAction, Label: "Dump DB"},
+			{ID: "upload", Type: types.PlanNodeAction, Label: "Upload"},
+		}},
+		SLA: &types.PlanSLA{MaxDuration: "5m", MaxConsecutiveFailures: 2},
+	}
+}
+
+// --- computeMetrics ---
+
+func TestComputeMetrics(t *testing.T) {
+	runs := []types.PlanRun{
+		metricsRun("r4", "failed", 3*time.Hour, 6*time.Minute,
+			metricsStep("dump", "completed", 3*time.Hour, time.Minute, 1, "m4"),
+			metricsStep("upload", "failed", 3*time.Hour+time.Minute, 5*time.Minute, 3, "")),
+		metricsRun("r3", "failed", 2*time.Hour, 2*time.Minute,
+			metricsStep("dump", "completed", 2*time.Hour, time.Minute, 1, ""),
+			metricsStep("upload", "failed", 2*time.Hour+time.Minute, time.Minute, 2, "")),
+		metricsRun("r2", "cancelled", time.Hour, time.Minute,
+			metricsStep("dump", "failed", time.Hour, time.Minute, 1, "")),
+		metricsRun("r1", "completed", 0, 3*time.Minute,
+			metricsStep("dump", "completed", 0, 2*time.Minute, 1, "m1"),
+			metricsStep("upload", "completed", 2*time.Minute, time.Minute, 1, "")),
+	}
+	usage := map[string]types.PlanTokenUsage{
+		"m1": {Prompt: 100, Completion: 20, Total: 120},
+		"m4": {Prompt: 50, Completion: 10, Total: 60},
+	}
+	m := computeMetrics(metricsPlan(), runs, usage, metricsBase)
+
+	if m.Runs != 4 || m.Completed != 1 || m.Failed != 2 || m.Cancelled != 1 {
+		t.Fatalf("unexpected counts %+v", m)
+	}
+	if m.SuccessRate < 0.33 || m.SuccessRate > 0.34 {
+		t.Fatalf("success rate should ignore cancelled runs, got %v", m.SuccessRate)
+	}
+	if m.ConsecutiveFailures != 2 {
+		t.Fatalf("expected a streak of 2, got %d", m.ConsecutiveFailures)
+	}
+	if m.DurationP50Ms != (3*time.Minute).Milliseconds() || m.DurationP95Ms != (6*time.Minute).Milliseconds() {
+		t.Fatalf("unexpected run percentiles p50=%d p95=%d", m.DurationP50Ms, m.DurationP95Ms)
+	}
+	if m.Retries != 3 {
+		t.Fatalf("expected 3 retries, got %d", m.Retries)
+	}
+	if m.MostFailingNode != "upload" {
+		t.Fatalf("expected upload to fail most, got %q", m.MostFailingNode)
+	}
+	if m.Tokens.Total != 180 || m.Tokens.Prompt != 150 {
+		t.Fatalf("unexpected token usage %+v", m.Tokens)
+	}
+	if len(m.Nodes) != 2 || m.Nodes[0].NodeID != "dump" || m.Nodes[0].Label != "Dump DB" {
+		t.Fatalf("nodes should follow graph order, got %+v", m.Nodes)
+	}
+	if dump := m.Nodes[0]; dump.Runs != 4 || dump.Failures != 1 || dump.Tokens.Total != 180 {
+		t.Fatalf("unexpected dump metrics %+v", dump)
+	}
+	if upload := m.Nodes[1]; upload.Retries != 3 || upload.DurationP95Ms != (5*time.Minute).Milliseconds() {
+		t.Fatalf("unexpected upload metrics %+v", upload)
+	}
+	if len(m.SLABreaches) != 2 {
+		t.Fatalf("expected duration and streak breaches, got %v", m.SLABreaches)
+	}
+	if m.LastRunAt == nil || !m.LastRunAt.Equal(runs[0].StartedAt) {
+		t.Fatalf("unexpected last run %v", m.LastRunAt)
+	}
+}
+
+func TestComputeMetrics_NoRuns(t *testing.T) {
+	m := computeMetrics(metricsPlan(), nil, nil, metricsBase)
+	if m.Runs != 0 || m.SuccessRate != 0 || m.LastRunAt != nil || len(m.SLABreaches) != 0 {
+		t.Fatalf("unexpected metrics for an idle plan %+v", m)
+	}
+	if len(m.Nodes) != 2 {
+		t.Fatalf("idle plans still list their nodes, got %+v", m.Nodes)
+	}
+}
+
+func TestCountsForMetrics(t *testing.T) {
+	done := metricsRun("r", "completed", 0, time.Minute)
+	sim := done
+	sim.Trigger = simulationTrigger
+	skipped := done
+	skipped.Status = "skipped"
+	running := types.PlanRun{Status: "running"}
+	if !countsForMetrics(done) || countsForMetrics(sim) || countsForMetrics(skipped) || countsForMetrics(running) {
+		t.Fatal("only finished real runs that executed should count")
+	}
+}
+
+// --- percentileMs / consecutiveFailures / compareHealth ---
+
+func TestPercentileMs(t *testing.T) {
+	var ds []time.Duration
+	for i := 1; i <= 20; i++ {
+		ds = append(ds, time.Duration(i)*time.Second)
+	}
+	if got := percentileMs(ds, 0.5); got != 10000 {
+		t.Fatalf("p50: got %d", got)
+	}
+	if got := percentileMs(ds, 0.95); got != 19000 {
+		t.Fatalf("p95: got %d", got)
+	}
+	if got := percentileMs(nil, 0.5); got != 0 {
+		t.Fatalf("empty: got %d", got)
+	}
+}
+
+func TestConsecutiveFailures(t *testing.T) {
+	runs := []types.PlanRun{{Status: "failed"}, {Status: "cancelled"}, {Status: "failed"}, {Status: "completed"}, {Status: "failed"}}
+	if got := consecutiveFailures(runs); got != 2 {
+		t.Fatalf("expected 2, got %d", got)
+	}
+}
+
+func TestCompareHealth(t *testing.T) {
+	items := []types.PlanMetrics{
+		{PlanName: "idle"},
+		{PlanName: "healthy", Runs: 10, SuccessRate: 1},
+		{PlanName: "flaky", Runs: 10, SuccessRate: 0.6},
+		{PlanName: "breaching", Runs: 10, SuccessRate: 0.9, SLABreaches: []string{"slow"}},
+		{PlanName: "broken", Runs: 10, ConsecutiveFailures: 3, SuccessRate: 0.7},
+	}
+	slices.SortStableFunc(items, compareHealth)
+	var got []string
+	for _, m := range items {
+		got = append(got, m.PlanName)
+	}
+	want := []string{"breaching", "broken", "flaky", "healthy", "idle"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+// --- ValidateSLA / slaBreaches ---
+
+func TestValidateSLA(t *testing.T) {
+	if err := ValidateSLA(types.PlanSLA{MaxDuration: "10m", MaxConsecutiveFailures: 3}); err != nil {
+		t.Fatalf("expected valid SLA, got %v", err)
+	}
+	for _, sla := range []types.PlanSLA{{MaxDuration: "soon"}, {MaxDuration: "-1m"}, {MaxConsecutiveFailures: -1}, {MaxConsecutiveFailures: 101}} {
+		if err := ValidateSLA(sla); !errors.Is(err, base.ErrValidation) {
+			t.Fatalf("%+v: expected validation error, got %v", sla, err)
+		}
+	}
+}
+
+func TestSLABreaches(t *testing.T) {
+	sla := &types.PlanSLA{MaxDuration: "5m", MaxConsecutiveFailures: 3}
+	fast := metricsRun("r", "completed", 0, time.Minute)
+	slow := metricsRun("r", "completed", 0, 7*time.Minute)
+	if b := slaBreaches(sla, fast, 2); len(b) != 0 {
+		t.Fatalf("expected no breach, got %v", b)
+	}
+	if b := slaBreaches(sla, slow, 0); len(b) != 1 || b[0] != "last run took 7m0s (SLA 5m0s)" {
+		t.Fatalf("expected a duration breach, got %v", b)
+	}
+	if b := slaBreaches(sla, fast, 3); len(b) != 1 {
+		t.Fatalf("expected a streak breach, got %v", b)
+	}
+	if b := slaBreaches(nil, slow, 5); b != nil {
+		t.Fatalf("plans without an SLA never breach, got %v", b)
+	}
+}
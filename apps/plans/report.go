@@ -0,0 +1,226 @@
+package plans
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"mantis/core/base"
+	"mantis/core/protocols"
+	"mantis/core/types"
+	adapter "mantis/infrastructure/adapters/channel"
+)
+
+const (
+	reportDeliverWait = 30 * time.Second
+	maxReportResult   = 300
+)
+
+// ValidateNotify checks a plan's run report settings. An empty When turns
+// reports off.
+func ValidateNotify(n types.PlanNotify) error {
+	switch n.When {
+	case "", types.PlanNotifySuccess, types.PlanNotifyFailure, types.PlanNotifyAlways:
+	default:
+		return fmt.Errorf("%w: unknown notify setting %q (use success, failure or always)", base.ErrValidation, n.When)
+	}
+	if r := strings.TrimSpace(n.Recipient); r != "" {
+		if _, err := strconv.ParseInt(r, 10, 64); err != nil {
+			return fmt.Errorf("%w: notify recipient must be a numeric chat id", base.ErrValidation)
+		}
+	}
+	return nil
+}
+
+// SetPublicURL sets the address the web UI is reachable at, used to link
+// run reports to the plan session. Without it reports name the session.
+func (r *Runner) SetPublicURL(url string) {
+	r.publicURL = strings.TrimRight(strings.TrimSpace(url), "/")
+}
+
+func shouldReport(n *types.PlanNotify, status string) bool {
+	if n == nil {
+		return false
+	}
+	switch n.When {
+	case types.PlanNotifyAlways:
+		return true
+	case types.PlanNotifySuccess:
+		return status == "completed"
+	case types.PlanNotifyFailure:
+		return status == "failed"
+	}
+	return false
+}
+
+// reportRun delivers the run report a plan asks for. The run is already
+// saved, so delivery failures are only logged.
+func (r *Runner) reportRun(plan types.Plan, run types.PlanRun) {
+	if isSimulation(run) || !shouldReport(plan.Notify, run.Status) || r.channelStore == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), reportDeliverWait)
+	defer cancel()
+
+	sender, err := r.reportSender(ctx, *plan.Notify)
+	if err != nil {
+		log.Printf("plans: run report for run %s: %v", run.ID, err)
+		return
+	}
+	text := renderRunReport(plan, run, r.stepOutputs(ctx, run), r.sessionLink(plan, run))
+	if err := sender.Execute(ctx, protocols.DeliveryRequest{Text: text}); err != nil {
+		log.Printf("plans: deliver run report for run %s via %s: %v", run.ID, sender.Channel(), err)
+	}
+}
+
+// reportSender picks the channel and chat a report goes to and returns the
+// channel's ResponseTo.
+func (r *Runner) reportSender(ctx context.Context, n types.PlanNotify) (protocols.ResponseTo, error) {
+	channels, err := r.channelStore.List(ctx, types.ListQuery{})
+	if err != nil {
+		return nil, fmt.Errorf("load channels: %w", err)
+	}
+	var ch *types.Channel
+	for i := range channels {
+		if n.ChannelID != "" && channels[i].ID != n.ChannelID {
+			continue
+		}
+		if n.ChannelID == "" && channels[i].Type != "telegram" {
+			continue
+		}
+		ch = &channels[i]
+		break
+	}
+	if ch == nil {
+		if n.ChannelID != "" {
+			return nil, fmt.Errorf("channel %s not found", n.ChannelID)
+		}
+		return nil, fmt.Errorf("no telegram channel configured")
+	}
+
+	recipient := strings.TrimSpace(n.Recipient)
+	if recipient == "" && len(ch.AllowedUserIDs) > 0 {
+		recipient = strconv.FormatInt(ch.AllowedUserIDs[0], 10)
+	}
+	if recipient == "" {
+		return nil, fmt.Errorf("channel %s has no recipient: set one on the plan or allow a user on the channel", ch.Name)
+	}
+	switch ch.Type {
+	case "telegram":
+		return adapter.NewTelegramResponseTo(ch.Token, recipient), nil
+	}
+	return nil, fmt.Errorf("channel type %q cannot deliver run reports", ch.Type)
+}
+
+// stepOutputs loads the reply each completed action step produced.
+func (r *Runner) stepOutputs(ctx context.Context, run types.PlanRun) map[string]string {
+	ids := make([]string, 0, len(run.Steps))
+	for _, s := range run.Steps {
+		if s.MessageID != "" {
+			ids = append(ids, s.MessageID)
+		}
+	}
+	if len(ids) == 0 || r.messageStore == nil {
+		return nil
+	}
+	msgs, err := r.messageStore.Get(ctx, ids)
+	if err != nil {
+		log.Printf("plans: load step outputs for run %s: %v", run.ID, err)
+		return nil
+	}
+	out := make(map[string]string, len(msgs))
+	for _, s := range run.Steps {
+		if m, ok := msgs[s.MessageID]; ok {
+			out[s.NodeID] = m.Content
+		}
+	}
+	return out
+}
+
+func (r *Runner) sessionLink(plan types.Plan, run types.PlanRun) string {
+	sessionID := fmt.Sprintf("plan:%s:%s", plan.ID, run.ID)
+	if r.publicURL == "" {
+		return "Session: " + sessionID
+	}
+	return r.publicURL + "/chat/" + sessionID
+}
+
+var reportStatusMark = map[string]string{
+	"completed": "✓",
+	"failed":    "✗",
+	"cancelled": "■",
+	"skipped":   "–",
+	"pending":   "–",
+}
+
+// renderRunReport renders a plain-text summary of a finished run: overall
+// status, then each step that ran in the order it ran, with its duration and
+// a clipped result.
+func renderRunReport(plan types.Plan, run types.PlanRun, outputs map[string]string, link string) string {
+	nodes := make(map[string]types.PlanNode, len(plan.Graph.Nodes))
+	for _, n := range plan.Graph.Nodes {
+		nodes[n.ID] = n
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Plan %q %s\n", plan.Name, run.Status)
+	fmt.Fprintf(&sb, "Run %s · %s", run.ID[:min(8, len(run.ID))], run.Trigger)
+	if run.FinishedAt != nil {
+		fmt.Fprintf(&sb, " · %s", formatStepDuration(run.FinishedAt.Sub(run.StartedAt)))
+	}
+	sb.WriteString("\n")
+
+	steps := slices.Clone(run.Steps)
+	slices.SortStableFunc(steps, func(a, b types.PlanStepRun) int {
+		switch {
+		case a.StartedAt == nil && b.StartedAt == nil:
+			return 0
+		case a.StartedAt == nil:
+			return 1
+		case b.StartedAt == nil:
+			return -1
+		}
+		return a.StartedAt.Compare(*b.StartedAt)
+	})
+	for _, step := range steps {
+		if step.StartedAt == nil && step.Status != "failed" {
+			continue
+		}
+		mark := reportStatusMark[step.Status]
+		if mark == "" {
+			mark = "•"
+		}
+		name := step.NodeID
+		if node, ok := nodes[step.NodeID]; ok {
+			name = nodeName(node)
+		}
+		fmt.Fprintf(&sb, "\n%s %s", mark, name)
+		if step.StartedAt != nil && step.FinishedAt != nil {
+			fmt.Fprintf(&sb, " — %s", formatStepDuration(step.FinishedAt.Sub(*step.StartedAt)))
+		}
+		sb.WriteString("\n")
+		result := step.Result
+		if out, ok := outputs[step.NodeID]; ok && result == "" {
+			result = out
+		}
+		if result = strings.TrimSpace(result); result != "" {
+			fmt.Fprintf(&sb, "  %s\n", strings.ReplaceAll(clip(result, maxReportResult), "\n", "\n  "))
+		}
+	}
+
+	if link != "" {
+		fmt.Fprintf(&sb, "\n%s\n", link)
+	}
+	return sb.String()
+}
+
+func formatStepDuration(d time.Duration) string {
+	if d < time.Second {
+		return "<1s"
+	}
+	return d.Round(time.Second).String()
+}
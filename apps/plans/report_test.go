@@ -0,0 +1,117 @@
+package plans
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"mantis/core/base"
+	"mantis/core/types"
+)
+
+// --- shouldReport / ValidateNotify ---
+
+func TestShouldReport(t *testing.T) {
+	cases := []struct {
+		when   types.PlanNotifyWhen
+		status string
+		want   bool
+	}{
+		{types.PlanNotifyFailure, "failed", true},
+		{types.PlanNotifyFailure, "completed", false},
+		{types.PlanNotifyFailure, "cancelled", false},
+		{types.PlanNotifySuccess, "completed", true},
+		{types.PlanNotifySuccess, "failed", false},
+		{types.PlanNotifyAlways, "cancelled", true},
+		{"", "failed", false},
+	}
+	for _, c := range cases {
+		if got := shouldReport(&types.PlanNotify{When: c.when}, c.status); got != c.want {
+			t.Fatalf("%s/%s: expected %v", c.when, c.status, c.want)
+		}
+	}
+	if shouldReport(nil, "failed") {
+		t.Fatal("plans without notify settings should not report")
+	}
+}
+
+func TestValidateNotify(t *testing.T) {
+	if err := ValidateNotify(types.PlanNotify{When: types.PlanNotifyAlways, Recipient: "-100123"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range []types.PlanNotify{{When: "sometimes"}, {When: types.PlanNotifyFailure, Recipient: "@ops"}} {
+		if err := ValidateNotify(n); !errors.Is(err, base.ErrValidation) {
+			t.Fatalf("expected validation error for %+v, got %v", n, err)
+		}
+	}
+}
+
+// --- renderRunReport ---
+
+func TestRenderRunReport(t *testing.T) {
+	start := time.Date(2026, 5, 1, 3, 0, 0, 0, time.UTC)
+	at := func(sec int) *time.Time {
+		t := start.Add(time.Duration(sec) * time.Second)
+		return &t
+	}
+	plan := types.Plan{ID: "p1", Name: "Nightly backup", Graph: types.PlanGraph{Nodes: []types.PlanNode{
+		{ID: "dump", Type: types.PlanNodeAction, Label: "Dump database"},
+		{ID: "upload", Type: types.PlanNodeAction, Label: "Upload"},
+		{ID: "notify", Type: types.PlanNodeAction, Label: "Notify"},
+	}}}
+	run := types.PlanRun{
+		ID: "0123456789abcdef", Status: "failed", Trigger: "schedule", StartedAt: start, FinishedAt: at(75),
+		Steps: []types.PlanStepRun{
+			{NodeID: "upload", Status: "failed", Result: "connection refused", StartedAt: at(45), FinishedAt: at(75)},
+			{NodeID: "dump", Status: "completed", MessageID: "m1", StartedAt: at(0), FinishedAt: at(45)},
+			{NodeID: "notify", Status: "skipped"},
+		},
+	}
+	report := renderRunReport(plan, run, map[string]string{"dump": "Dumped 2.1 GB"}, "https://mantis.example/chat/plan:p1:0123456789abcdef")
+
+	for _, want := range []string{
+		`Plan "Nightly backup" failed`,
+		"Run 01234567 · schedule · 1m15s",
+		"✓ Dump database — 45s\n  Dumped 2.1 GB",
+		"✗ Upload — 30s\n  connection refused",
+		"https://mantis.example/chat/plan:p1:0123456789abcdef",
+	} {
+		if !strings.Contains(report, want) {
+			t.Fatalf("report missing %q:\n%s", want, report)
+		}
+	}
+	if strings.Index(report, "Dump database") > strings.Index(report, "Upload") {
+		t.Fatalf("steps should be listed in the order they ran:\n%s", report)
+	}
+	if strings.Contains(report, "Notify") {
+		t.Fatalf("steps that never ran should be left out:\n%s", report)
+	}
+}
+
+// --- reportSender ---
+
+func TestReportSender(t *testing.T) {
+	r := &Runner{channelStore: newMemStore(func(c types.Channel) string { return c.ID },
+		types.Channel{ID: "c1", Type: "web", Name: "Web"},
+		types.Channel{ID: "c2", Type: "telegram", Name: "Ops bot", Token: "t", AllowedUserIDs: []int64{42}},
+	)}
+	sender, err := r.reportSender(context.Background(), types.PlanNotify{When: types.PlanNotifyFailure})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sender.Channel() != "telegram" || sender.Recipient() != "42" {
+		t.Fatalf("expected the first telegram channel's user, got %s/%s", sender.Channel(), sender.Recipient())
+	}
+	sender, err = r.reportSender(context.Background(), types.PlanNotify{When: types.PlanNotifyFailure, ChannelID: "c2", Recipient: "-100"})
+	if err != nil || sender.Recipient() != "-100" {
+		t.Fatalf("explicit recipient should win, got %v %v", sender, err)
+	}
+	if _, err := r.reportSender(context.Background(), types.PlanNotify{When: types.PlanNotifyFailure, ChannelID: "c1"}); err == nil {
+		t.Fatal("expected error for a channel without a recipient")
+	}
+	if _, err := r.reportSender(context.Background(), types.PlanNotify{When: types.PlanNotifyFailure, ChannelID: "missing"}); err == nil {
+		t.Fatal("expected error for an unknown channel")
+	}
+}
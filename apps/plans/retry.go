@@ -0,0 +1,139 @@
+package plans
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"time"
+
+	"mantis/core/types"
+)
+
+const (
+	defaultRetryDelay = 2 * time.Second
+	maxRetryDelay     = 5 * time.Minute
+	maxFailureContext = 2000
+)
+
+var retryOnClasses = []string{
+	types.PlanRetryOnTimeout,
+	types.PlanRetryOnError,
+	types.PlanRetryOnStopped,
+	types.PlanRetryOnReported,
+}
+
+// stepFailure is a node failure tagged with its retry class.
+type stepFailure struct {
+	class string
+	msg   string
+}
+
+func (e *stepFailure) Error() string { return e.msg }
+
+func failureClass(err error) string {
+	var f *stepFailure
+	if errors.As(err, &f) {
+		return f.class
+	}
+	return types.PlanRetryOnError
+}
+
+func shouldRetry(node types.PlanNode, err error) bool {
+	if len(node.RetryOn) == 0 {
+		return true
+	}
+	return slices.Contains(node.RetryOn, failureClass(err))
+}
+
+func nodeTimeout(node types.PlanNode, fallback time.Duration) time.Duration {
+	if d, err := time.ParseDuration(strings.TrimSpace(node.Timeout)); err == nil && d > 0 {
+		return d
+	}
+	return fallback
+}
+
+// retryBackoff returns the wait before the given retry (1-based): the base
+// delay doubled per attempt, capped at maxRetryDelay, with the upper half
+// jittered so parallel runs hitting the same flaky host spread out.
+func retryBackoff(node types.PlanNode, attempt int) time.Duration {
+	base := defaultRetryDelay
+	if d, err := time.ParseDuration(strings.TrimSpace(node.RetryDelay)); err == nil && d > 0 {
+		base = d
+	}
+	delay := base
+	for i := 1; i < attempt && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	delay = min(delay, maxRetryDelay)
+	half := delay / 2
+	return half + rand.N(delay-half+1)
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func validateNode(node types.PlanNode) error {
+	if node.MaxRetries < 0 {
+		return fmt.Errorf("node %q: maxRetries must not be negative", node.ID)
+	}
+	for name, raw := range map[string]string{"timeout": node.Timeout, "retryDelay": node.RetryDelay} {
+		if strings.TrimSpace(raw) == "" {
+			continue
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(raw))
+		if err != nil || d <= 0 {
+			return fmt.Errorf("node %q: invalid %s %q (use a duration like 90s or 5m)", node.ID, name, raw)
+		}
+	}
+	switch strings.ToLower(strings.TrimSpace(node.OnTimeout)) {
+	case "", types.PlanEdgeApprove, types.PlanEdgeReject:
+	default:
+		return fmt.Errorf("node %q: onTimeout must be %q or %q", node.ID, types.PlanEdgeApprove, types.PlanEdgeReject)
+	}
+	for _, class := range node.RetryOn {
+		if !slices.Contains(retryOnClasses, class) {
+			return fmt.Errorf("node %q: unknown retryOn %q (use %s)", node.ID, class, strings.Join(retryOnClasses, ", "))
+		}
+	}
+	return nil
+}
+
+// failureInput exposes the failed node to an error handler's prompt template
+// as {{.error.node}} and {{.error.message}} without touching the run input.
+func failureInput(input map[string]any, failed types.PlanNode, err error) map[string]any {
+	out := make(map[string]any, len(input)+1)
+	for k, v := range input {
+		out[k] = v
+	}
+	out[types.PlanEdgeError] = map[string]any{
+		"node":    nodeName(failed),
+		"message": failureMessage(err),
+	}
+	return out
+}
+
+func errorHandlerPrompt(failed types.PlanNode, err error, prompt string) string {
+	return fmt.Sprintf("The previous step %q failed: %s\n\n%s", nodeName(failed), failureMessage(err), prompt)
+}
+
+func nodeName(node types.PlanNode) string {
+	if node.Label != "" {
+		return node.Label
+	}
+	return node.ID
+}
+
+func failureMessage(err error) string {
+	return clip(err.Error(), maxFailureContext)
+}
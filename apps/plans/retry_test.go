@@ -0,0 +1,184 @@
+package plans
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"mantis/core/types"
+	"mantis/shared"
+)
+
+// --- retryBackoff ---
+
+func TestRetryBackoff_ExponentialWithJitter(t *testing.T) {
+	node := types.PlanNode{RetryDelay: "1s"}
+	for attempt, full := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second} {
+		for range 20 {
+			d := retryBackoff(node, attempt)
+			if d < full/2 || d > full {
+				t.Fatalf("attempt %d: delay %s outside [%s, %s]", attempt, d, full/2, full)
+			}
+		}
+	}
+}
+
+func TestRetryBackoff_DefaultAndCap(t *testing.T) {
+	if d := retryBackoff(types.PlanNode{}, 1); d < defaultRetryDelay/2 || d > defaultRetryDelay {
+		t.Fatalf("default delay out of range: %s", d)
+	}
+	if d := retryBackoff(types.PlanNode{RetryDelay: "1m"}, 30); d > maxRetryDelay {
+		t.Fatalf("delay %s exceeds cap %s", d, maxRetryDelay)
+	}
+}
+
+// --- shouldRetry ---
+
+func TestShouldRetry(t *testing.T) {
+	timeout := &stepFailure{class: types.PlanRetryOnTimeout, msg: "timed out"}
+	reported := &stepFailure{class: types.PlanRetryOnReported, msg: "[ERROR]: nope"}
+
+	if !shouldRetry(types.PlanNode{}, reported) {
+		t.Fatal("empty retryOn should retry every failure")
+	}
+	node := types.PlanNode{RetryOn: []string{types.PlanRetryOnTimeout}}
+	if !shouldRetry(node, timeout) {
+		t.Fatal("timeout should be retried")
+	}
+	if shouldRetry(node, reported) {
+		t.Fatal("reported error should not be retried")
+	}
+	if !shouldRetry(types.PlanNode{RetryOn: []string{types.PlanRetryOnError}}, errors.New("store down")) {
+		t.Fatal("untyped errors count as error class")
+	}
+}
+
+// --- nodeTimeout ---
+
+func TestNodeTimeout(t *testing.T) {
+	if got := nodeTimeout(types.PlanNode{Timeout: "90s"}, time.Hour); got != 90*time.Second {
+		t.Fatalf("got %s", got)
+	}
+	if got := nodeTimeout(types.PlanNode{}, time.Hour); got != time.Hour {
+		t.Fatalf("expected fallback, got %s", got)
+	}
+}
+
+// --- classifyMessage ---
+
+func TestClassifyMessage(t *testing.T) {
+	marker := shared.StopReasonPlanStepTimeout(time.Minute)
+	cases := []struct {
+		msg  types.ChatMessage
+		want string
+	}{
+		{types.ChatMessage{Status: "error", Content: "boom"}, types.PlanRetryOnError},
+		{types.ChatMessage{Status: "cancelled", Content: "partial " + marker}, types.PlanRetryOnTimeout},
+		{types.ChatMessage{Status: "cancelled", Content: "[stopped: max iterations]"}, types.PlanRetryOnStopped},
+		{types.ChatMessage{Status: "done", Content: "[ERROR]: disk full"}, types.PlanRetryOnReported},
+		{types.ChatMessage{Status: "cancelled", Content: shared.StopReasonUser()}, ""},
+		{types.ChatMessage{Status: "done", Content: "all good"}, ""},
+	}
+	for _, c := range cases {
+		err := classifyMessage(c.msg, marker)
+		if c.want == "" {
+			if err != nil {
+				t.Errorf("%q: unexpected error %v", c.msg.Content, err)
+			}
+			continue
+		}
+		if err == nil || failureClass(err) != c.want {
+			t.Errorf("%q: got %v, want class %s", c.msg.Content, err, c.want)
+		}
+	}
+}
+
+// --- sleepContext ---
+
+func TestSleepContext_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := sleepContext(ctx, time.Hour); err == nil {
+		t.Fatal("expected cancellation error")
+	}
+}
+
+// --- validateNode ---
+
+func TestValidateNode(t *testing.T) {
+	ok := types.PlanNode{ID: "n1", Timeout: "5m", RetryDelay: "10s", RetryOn: []string{"timeout", "error"}}
+	if err := validateNode(ok); err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range []types.PlanNode{
+		{ID: "n1", Timeout: "soon"},
+		{ID: "n1", RetryDelay: "-1s"},
+		{ID: "n1", RetryOn: []string{"flaky"}},
+		{ID: "n1", MaxRetries: -1},
+	} {
+		if err := validateNode(n); err == nil {
+			t.Errorf("expected error for %+v", n)
+		}
+	}
+}
+
+// --- error edges ---
+
+func errorEdgeGraph() types.PlanGraph {
+	return types.PlanGraph{
+		Nodes: []types.PlanNode{
+			{ID: "a", Type: types.PlanNodeAction},
+			{ID: "b", Type: types.PlanNodeAction},
+			{ID: "alert", Type: types.PlanNodeAction},
+		},
+		Edges: []types.PlanEdge{
+			{ID: "e1", Source: "a", Target: "alert", Label: "error"},
+			{ID: "e2", Source: "a", Target: "b"},
+		},
+	}
+}
+
+func TestValidateGraph_ErrorEdgeDoesNotCountAsOutgoing(t *testing.T) {
+	if err := validateGraph(errorEdgeGraph()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestValidateGraph_TwoErrorEdges(t *testing.T) {
+	g := errorEdgeGraph()
+	g.Edges = append(g.Edges, types.PlanEdge{ID: "e3", Source: "a", Target: "b", Label: "Error"})
+	if err := validateGraph(g); err == nil || !strings.Contains(err.Error(), "error edges") {
+		t.Fatalf("expected error edge limit, got %v", err)
+	}
+}
+
+func TestFindNextNode_SkipsErrorEdge(t *testing.T) {
+	g := errorEdgeGraph()
+	if got := findNextNode(g, "a"); got != "b" {
+		t.Fatalf("expected b, got %q", got)
+	}
+	if got := findEdgeTarget(g, "a", "maybe"); got != "b" {
+		t.Fatalf("fallback should skip error edge, got %q", got)
+	}
+	if got := findErrorTarget(g, "a"); got != "alert" {
+		t.Fatalf("expected alert, got %q", got)
+	}
+	if got := findErrorTarget(g, "b"); got != "" {
+		t.Fatalf("expected no handler, got %q", got)
+	}
+}
+
+func TestErrorHandlerPrompt(t *testing.T) {
+	failed := types.PlanNode{ID: "a", Label: "Deploy"}
+	err := errors.New("step timed out")
+	input := failureInput(map[string]any{"host": "web-1"}, failed, err)
+	got := renderPrompt("Alert about {{.host}}: {{.error.node}} — {{.error.message}}", input)
+	if got != "Alert about web-1: Deploy — step timed out" {
+		t.Fatalf("unexpected: %q", got)
+	}
+	if p := errorHandlerPrompt(failed, err, "Notify ops"); !strings.Contains(p, `"Deploy" failed: step timed out`) || !strings.HasSuffix(p, "Notify ops") {
+		t.Fatalf("unexpected: %q", p)
+	}
+}
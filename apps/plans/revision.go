@@ -0,0 +1,127 @@
+package plans
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"mantis/core/auth"
+	"mantis/core/protocols"
+	"mantis/core/types"
+)
+
+// RevisionedStore wraps the plan store and records a revision each time a
+// plan is created or changed, whichever path saved it (editor, import or an
+// agent tool). Saves that don't change the plan add no revision.
+type RevisionedStore struct {
+	protocols.Store[string, types.Plan]
+	revisions protocols.Store[string, types.PlanRevision]
+
+	mu sync.Mutex
+}
+
+func NewRevisionedStore(plans protocols.Store[string, types.Plan], revisions protocols.Store[string, types.PlanRevision]) *RevisionedStore {
+	return &RevisionedStore{Store: plans, revisions: revisions}
+}
+
+func (s *RevisionedStore) Create(ctx context.Context, items []types.Plan) ([]types.Plan, error) {
+	out, err := s.Store.Create(ctx, items)
+	if err == nil {
+		s.record(ctx, out)
+	}
+	return out, err
+}
+
+func (s *RevisionedStore) Update(ctx context.Context, items []types.Plan) ([]types.Plan, error) {
+	out, err := s.Store.Update(ctx, items)
+	if err == nil {
+		s.record(ctx, out)
+	}
+	return out, err
+}
+
+// record is best effort: the plan is already saved, so a failure only loses
+// a history entry and is logged.
+func (s *RevisionedStore) record(ctx context.Context, items []types.Plan) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var createdBy string
+	if id, ok := auth.FromContext(ctx); ok {
+		createdBy = id.Name
+	}
+	for _, p := range items {
+		snapshot := revisionSnapshot(p)
+		latest, err := s.latest(ctx, p.ID)
+		if err != nil {
+			log.Printf("plans: load revisions of %s: %v", p.ID, err)
+			continue
+		}
+		if latest != nil && samePlan(latest.Plan, snapshot) {
+			continue
+		}
+		version := 1
+		if latest != nil {
+			version = latest.Version + 1
+		}
+		rev := types.PlanRevision{
+			ID:        uuid.New().String(),
+			PlanID:    p.ID,
+			Version:   version,
+			Plan:      snapshot,
+			CreatedBy: createdBy,
+			CreatedAt: time.Now().UTC(),
+		}
+		if _, err := s.revisions.Create(ctx, []types.PlanRevision{rev}); err != nil {
+			log.Printf("plans: record revision %d of %s: %v", version, p.ID, err)
+		}
+	}
+}
+
+func (s *RevisionedStore) latest(ctx context.Context, planID string) (*types.PlanRevision, error) {
+	items, err := s.revisions.List(ctx, types.ListQuery{
+		Filter: map[string]string{"plan_id": planID},
+		Sort:   []types.Sort{{Field: "version", Dir: types.SortDirDesc}},
+		Page:   types.Page{Limit: 1},
+	})
+	if err != nil || len(items) == 0 {
+		return nil, err
+	}
+	return &items[0], nil
+}
+
+// revisionSnapshot is the plan as kept in history, without the webhook
+// secret.
+func revisionSnapshot(p types.Plan) types.Plan {
+	if p.Webhook != nil {
+		hook := *p.Webhook
+		hook.Secret = ""
+		p.Webhook = &hook
+	}
+	return p
+}
+
+// samePlan compares plans as canonical JSON, so a parameter schema that only
+// differs in key order or whitespace (as jsonb returns it) counts as equal.
+func samePlan(a, b types.Plan) bool {
+	ja, errA := canonicalJSON(a)
+	jb, errB := canonicalJSON(b)
+	return errA == nil && errB == nil && bytes.Equal(ja, jb)
+}
+
+func canonicalJSON(v any) ([]byte, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var generic any
+	if err := json.Unmarshal(raw, &generic); err != nil {
+		return nil, err
+	}
+	return json.Marshal(generic)
+}
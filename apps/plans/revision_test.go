@@ -0,0 +1,57 @@
+package plans
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"mantis/core/auth"
+	"mantis/core/types"
+)
+
+// --- RevisionedStore ---
+
+func TestRevisionedStore_RecordsVersions(t *testing.T) {
+	revs := newRevisionStore()
+	store := NewRevisionedStore(newPlanStore(), revs)
+	ctx := auth.WithIdentity(context.Background(), auth.Identity{UserID: "u1", Name: "alice"})
+
+	p := samplePlan()
+	if _, err := store.Create(ctx, []types.Plan{p}); err != nil {
+		t.Fatal(err)
+	}
+	p.Description = "Changed"
+	if _, err := store.Update(ctx, []types.Plan{p}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(revs.items) != 2 {
+		t.Fatalf("expected 2 revisions, got %d", len(revs.items))
+	}
+	if revs.items[0].Version != 1 || revs.items[1].Version != 2 {
+		t.Fatalf("unexpected versions: %d, %d", revs.items[0].Version, revs.items[1].Version)
+	}
+	if revs.items[1].Plan.Description != "Changed" || revs.items[1].CreatedBy != "alice" {
+		t.Fatalf("unexpected revision: %+v", revs.items[1])
+	}
+	if revs.items[0].Plan.Webhook.Secret != "" {
+		t.Fatal("revision must not keep the webhook secret")
+	}
+	if p.Webhook.Secret != "s3cret" {
+		t.Fatal("snapshot must not modify the saved plan")
+	}
+}
+
+func TestRevisionedStore_SkipsUnchanged(t *testing.T) {
+	revs := newRevisionStore()
+	store := NewRevisionedStore(newPlanStore(), revs)
+
+	p := samplePlan()
+	_, _ = store.Create(context.Background(), []types.Plan{p})
+	p.Parameters = json.RawMessage(`{"required": ["host"], "properties": {"host": {"description": "Target host", "type": "string"}}, "type": "object"}`)
+	_, _ = store.Update(context.Background(), []types.Plan{p})
+
+	if len(revs.items) != 1 {
+		t.Fatalf("reordered but equal plan should not add a revision, got %d", len(revs.items))
+	}
+}
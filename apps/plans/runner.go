@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"sort"
 	"strings"
 	"sync"
 	"text/template"
@@ -11,6 +12,7 @@ import (
 
 	"github.com/google/uuid"
 
+	"mantis/core/agents"
 	modelplugin "mantis/core/plugins/model"
 	sessionplugin "mantis/core/plugins/session"
 	"mantis/core/protocols"
@@ -19,26 +21,44 @@ import (
 	"mantis/shared"
 )
 
-const maxTransitions = 50
+const (
+	maxTransitions = 50
+	// idempotencyWindow bounds how long an idempotency key suppresses new
+	// runs, so a recurring alert with a stable key can fire again next day.
+	idempotencyWindow = 24 * time.Hour
+)
 
 type Runner struct {
 	planStore     protocols.Store[string, types.Plan]
 	runStore      protocols.Store[string, types.PlanRun]
 	messageStore  protocols.Store[string, types.ChatMessage]
+	channelStore  protocols.Store[string, types.Channel]
 	sessionPolicy *sessionplugin.Policy
 	workflow      *messageworkflow.Workflow
 	buffer        *shared.Buffer
 	limits        shared.Limits
+	publicURL     string
+	blackouts     *Blackouts
+
+	triggerMu sync.Mutex
+
+	queueMu       sync.Mutex
+	maxConcurrent int
+	active        map[string]string
+	waiting       map[string]bool
+	queue         []queuedRun
 
-	mu      sync.Mutex
-	cancels map[string]context.CancelFunc
-	done    map[string]chan struct{}
+	mu        sync.Mutex
+	cancels   map[string]context.CancelFunc
+	done      map[string]chan struct{}
+	approvals map[string]chan approvalDecision
 }
 
 func NewRunner(
 	planStore protocols.Store[string, types.Plan],
 	runStore protocols.Store[string, types.PlanRun],
 	messageStore protocols.Store[string, types.ChatMessage],
+	channelStore protocols.Store[string, types.Channel],
 	sessionPolicy *sessionplugin.Policy,
 	workflow *messageworkflow.Workflow,
 	buffer *shared.Buffer,
@@ -48,56 +68,178 @@ func NewRunner(
 		planStore:     planStore,
 		runStore:      runStore,
 		messageStore:  messageStore,
+		channelStore:  channelStore,
 		sessionPolicy: sessionPolicy,
 		workflow:      workflow,
 		buffer:        buffer,
 		limits:        limits,
+		maxConcurrent: limits.PlanMaxConcurrentRuns,
+		active:        make(map[string]string),
+		waiting:       make(map[string]bool),
 		cancels:       make(map[string]context.CancelFunc),
 		done:          make(map[string]chan struct{}),
+		approvals:     make(map[string]chan approvalDecision),
 	}
 }
 
-func (r *Runner) TriggerRun(ctx context.Context, planID, trigger string, input map[string]any) (types.PlanRun, error) {
-	plans, err := r.planStore.Get(ctx, []string{planID})
+// TriggerRun starts a run of a plan. force lets a manual run start inside a
+// blackout window.
+func (r *Runner) TriggerRun(ctx context.Context, planID, trigger string, input map[string]any, force bool) (types.PlanRun, error) {
+	plan, err := r.loadPlan(ctx, planID)
 	if err != nil {
 		return types.PlanRun{}, err
 	}
+	run, _, err := r.startRun(ctx, plan, trigger, "", input, nil, force)
+	return run, err
+}
+
+func (r *Runner) loadPlan(ctx context.Context, planID string) (types.Plan, error) {
+	plans, err := r.planStore.Get(ctx, []string{planID})
+	if err != nil {
+		return types.Plan{}, err
+	}
 	plan, ok := plans[planID]
 	if !ok {
-		return types.PlanRun{}, fmt.Errorf("plan not found: %s", planID)
+		return types.Plan{}, fmt.Errorf("plan not found: %s", planID)
 	}
+	return plan, nil
+}
 
+// startRun creates a run record and starts executing it. When idempotencyKey
+// is set and a run with the same key was started within the dedupe window,
+// that run is returned instead and the second result is true. Mocks only
+// matter for simulation runs; force only for manual runs in a blackout.
+func (r *Runner) startRun(ctx context.Context, plan types.Plan, trigger, idempotencyKey string, input map[string]any, mocks map[string]string, force bool) (types.PlanRun, bool, error) {
 	if err := validateGraph(plan.Graph); err != nil {
-		return types.PlanRun{}, fmt.Errorf("invalid graph: %w", err)
+		return types.PlanRun{}, false, fmt.Errorf("invalid graph: %w", err)
 	}
 
 	if input == nil {
 		input = map[string]any{}
 	}
+	input = withParameterDefaults(plan.Parameters, input)
+
+	if idempotencyKey != "" {
+		r.triggerMu.Lock()
+		defer r.triggerMu.Unlock()
+		existing, found, err := r.findDuplicateRun(ctx, plan.ID, idempotencyKey)
+		if err != nil {
+			return types.PlanRun{}, false, err
+		}
+		if found {
+			return existing, true, nil
+		}
+	}
 
 	now := time.Now().UTC()
 	run := types.PlanRun{
-		ID:        uuid.New().String(),
-		PlanID:    planID,
-		Status:    "running",
-		Trigger:   trigger,
-		Input:     input,
-		Steps:     initSteps(plan.Graph),
-		StartedAt: now,
+		ID:             uuid.New().String(),
+		PlanID:         plan.ID,
+		Status:         "running",
+		Trigger:        trigger,
+		Input:          input,
+		IdempotencyKey: idempotencyKey,
+		Mocks:          mocks,
+		Steps:          initSteps(plan.Graph),
+		StartedAt:      now,
 	}
+	if err := r.applyBlackout(ctx, plan, &run, force); err != nil {
+		return types.PlanRun{}, false, err
+	}
+
+	policy := concurrencyPolicy(plan.Concurrency)
+	if trigger == simulationTrigger {
+		policy = types.PlanConcurrencyAllow
+	}
+	if policy == types.PlanConcurrencyCancelPrevious && run.Status == "running" {
+		r.cancelPlanRuns(ctx, plan.ID)
+	}
+
+	r.queueMu.Lock()
+	defer r.queueMu.Unlock()
+
+	r.admitLocked(plan.ID, policy, &run)
 
 	created, err := r.runStore.Create(ctx, []types.PlanRun{run})
 	if err != nil {
-		return types.PlanRun{}, err
+		return types.PlanRun{}, false, err
 	}
 	run = created[0]
+	r.startAdmittedLocked(plan, run)
 
-	go r.execute(context.Background(), plan, run)
+	return run, false, nil
+}
 
-	return run, nil
+// admitLocked applies the plan's concurrency policy to a run about to start:
+// it is skipped while another run is busy, queued, or left running.
+func (r *Runner) admitLocked(planID string, policy types.PlanConcurrency, run *types.PlanRun) {
+	if run.Status != "running" {
+		return
+	}
+	switch {
+	case policy == types.PlanConcurrencySkip && r.planBusyLocked(planID):
+		now := time.Now().UTC()
+		run.Status = "skipped"
+		run.Reason = "previous run still active"
+		run.FinishedAt = &now
+		skipPending(run)
+	case r.mustQueueLocked(planID, policy):
+		run.Status = "queued"
+	}
+}
+
+// startAdmittedLocked launches or queues a saved run by its status.
+func (r *Runner) startAdmittedLocked(plan types.Plan, run types.PlanRun) {
+	switch run.Status {
+	case "skipped":
+		log.Printf("plans: skipped %s run for plan=%s: %s", run.Trigger, plan.ID, run.Reason)
+	case "deferred":
+		log.Printf("plans: deferred %s run %s for plan=%s: %s", run.Trigger, run.ID, plan.ID, run.Reason)
+	case "queued":
+		r.queue = append(r.queue, queuedRun{plan: plan, run: run})
+		log.Printf("plans: queued run %s for plan=%s (position %d)", run.ID, plan.ID, len(r.queue))
+	default:
+		r.launchLocked(plan, run)
+	}
+}
+
+func (r *Runner) findDuplicateRun(ctx context.Context, planID, idempotencyKey string) (types.PlanRun, bool, error) {
+	runs, err := r.runStore.List(ctx, types.ListQuery{
+		Filter: map[string]string{"plan_id": planID, "idempotency_key": idempotencyKey},
+		Sort:   []types.Sort{{Field: "started_at", Dir: types.SortDirDesc}},
+		Page:   types.Page{Limit: 1},
+	})
+	if err != nil {
+		return types.PlanRun{}, false, err
+	}
+	if len(runs) == 0 || time.Since(runs[0].StartedAt) > idempotencyWindow {
+		return types.PlanRun{}, false, nil
+	}
+	return runs[0], true, nil
+}
+
+// lastStartedAt returns when the plan's most recent real run started, if
+// any. Simulations don't count: they say nothing about missed schedules.
+func (r *Runner) lastStartedAt(ctx context.Context, planID string) (time.Time, bool, error) {
+	runs, err := r.runStore.List(ctx, types.ListQuery{
+		Filter: map[string]string{"plan_id": planID},
+		Sort:   []types.Sort{{Field: "started_at", Dir: types.SortDirDesc}},
+		Page:   types.Page{Limit: 20},
+	})
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	for _, run := range runs {
+		if !isSimulation(run) {
+			return run.StartedAt, true, nil
+		}
+	}
+	return time.Time{}, false, nil
 }
 
 func (r *Runner) CancelRun(ctx context.Context, runID string) (types.PlanRun, error) {
+	r.dequeue(runID)
+
 	r.mu.Lock()
 	cancel, inMemory := r.cancels[runID]
 	doneCh := r.done[runID]
@@ -120,7 +262,7 @@ func (r *Runner) CancelRun(ctx context.Context, runID string) (types.PlanRun, er
 		return types.PlanRun{}, fmt.Errorf("run not found: %s", runID)
 	}
 
-	if run.Status == "running" {
+	if run.Status == "running" || run.Status == "queued" || run.Status == "waiting" || run.Status == "deferred" {
 		now := time.Now().UTC()
 		run.Status = "cancelled"
 		run.FinishedAt = &now
@@ -133,47 +275,53 @@ func (r *Runner) CancelRun(ctx context.Context, runID string) (types.PlanRun, er
 	return run, nil
 }
 
+// ActiveRuns returns running and waiting runs oldest first, followed by
+// queued runs in the order they will be started.
 func (r *Runner) ActiveRuns(ctx context.Context) ([]types.PlanRun, error) {
-	runs, err := r.runStore.List(ctx, types.ListQuery{
-		Filter: map[string]string{"status": "running"},
-	})
-	if err != nil {
-		return nil, err
-	}
-	if runs == nil {
-		runs = []types.PlanRun{}
+	runs := []types.PlanRun{}
+	for _, status := range []string{"running", "waiting"} {
+		items, err := r.runStore.List(ctx, types.ListQuery{
+			Filter: map[string]string{"status": status},
+			Sort:   []types.Sort{{Field: "started_at", Dir: types.SortDirAsc}},
+		})
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, items...)
 	}
-	return runs, nil
+	sort.SliceStable(runs, func(i, j int) bool { return runs[i].StartedAt.Before(runs[j].StartedAt) })
+	return append(runs, r.queuedRuns()...), nil
 }
 
 func (r *Runner) RecoverStaleRuns(ctx context.Context) {
-	runs, err := r.runStore.List(ctx, types.ListQuery{
-		Filter: map[string]string{"status": "running"},
-	})
-	if err != nil {
-		log.Printf("plans: recover stale runs: %v", err)
-		return
-	}
 	now := time.Now().UTC()
-	for _, run := range runs {
-		run.Status = "failed"
-		run.FinishedAt = &now
-		markStaleSteps(&run, now, "interrupted by server restart")
-		if _, err := r.runStore.Update(ctx, []types.PlanRun{run}); err != nil {
-			log.Printf("plans: recover run %s: %v", run.ID, err)
-		} else {
-			log.Printf("plans: recovered stale run %s (marked as failed)", run.ID)
+	for _, status := range []string{"running", "queued", "waiting"} {
+		runs, err := r.runStore.List(ctx, types.ListQuery{
+			Filter: map[string]string{"status": status},
+		})
+		if err != nil {
+			log.Printf("plans: recover stale runs: %v", err)
+			return
+		}
+		for _, run := range runs {
+			run.Status = "failed"
+			run.FinishedAt = &now
+			markStaleSteps(&run, now, "interrupted by server restart")
+			if _, err := r.runStore.Update(ctx, []types.PlanRun{run}); err != nil {
+				log.Printf("plans: recover run %s: %v", run.ID, err)
+			} else {
+				log.Printf("plans: recovered stale %s run %s (marked as failed)", status, run.ID)
+			}
 		}
 	}
 }
 
-func (r *Runner) execute(ctx context.Context, plan types.Plan, run types.PlanRun) {
-	ctx, cancel := context.WithCancel(ctx)
-	doneCh := make(chan struct{})
-	r.mu.Lock()
-	r.cancels[run.ID] = cancel
-	r.done[run.ID] = doneCh
-	r.mu.Unlock()
+// execute runs a plan until it finishes or ctx is cancelled. cancel and
+// doneCh are the ones launchLocked registered for the run; they are removed
+// and doneCh closed on exit.
+func (r *Runner) execute(ctx context.Context, cancel context.CancelFunc, doneCh chan struct{}, plan types.Plan, run types.PlanRun) {
+	defer r.releaseSlot(run.ID)
+
 	defer func() {
 		r.mu.Lock()
 		delete(r.cancels, run.ID)
@@ -182,6 +330,10 @@ func (r *Runner) execute(ctx context.Context, plan types.Plan, run types.PlanRun
 		cancel()
 		close(doneCh)
 	}()
+	defer func() {
+		go r.reportRun(plan, run)
+		go r.checkSLA(plan, run)
+	}()
 
 	sessionID := fmt.Sprintf("plan:%s:%s", plan.ID, run.ID)
 
@@ -214,6 +366,9 @@ func (r *Runner) execute(ctx context.Context, plan types.Plan, run types.PlanRun
 
 	transitions := 0
 	current := startNodes[0]
+	var failedNode, loopNode *types.PlanNode
+	var failedErr error
+	var lastOutput string
 	for {
 		transitions++
 		if transitions > maxTransitions {
@@ -237,29 +392,88 @@ func (r *Runner) execute(ctx context.Context, plan types.Plan, run types.PlanRun
 
 		r.markStepRunning(&run, current)
 
-		prompt := renderPrompt(node.Prompt, run.Input)
+		input := run.Input
+		if failedNode != nil {
+			input = failureInput(run.Input, *failedNode, failedErr)
+		}
+		prompt := renderPrompt(node.Prompt, input)
 		if node.Type == types.PlanNodeDecision {
-			prompt = decisionPrompt(node, run.Input)
+			prompt = decisionPrompt(node, input)
 		}
+		if failedNode != nil {
+			prompt = errorHandlerPrompt(*failedNode, failedErr, prompt)
+			failedNode, failedErr = nil, nil
+		}
+		if loopNode != nil && node.Type != types.PlanNodeApproval {
+			prompt = loopResultPrompt(*loopNode, lastOutput, prompt)
+		}
+		loopNode = nil
 
-		res, err := r.executeWithRetry(ctx, sessionID, node, prompt)
+		if node.Type == types.PlanNodeApproval {
+			decision, err := r.awaitApproval(ctx, plan, &run, node, prompt, lastOutput)
+			if err != nil {
+				r.failStep(&run, current, "cancelled")
+				r.finishRun(&run, "cancelled")
+				return
+			}
+			next := findLabeledEdge(plan.Graph, current, decision)
+			if next == "" {
+				skipPending(&run)
+				r.saveRun(&run)
+				if decision == types.PlanEdgeReject {
+					r.finishRun(&run, "cancelled")
+				} else {
+					r.finishRun(&run, "completed")
+				}
+				return
+			}
+			current = next
+			continue
+		}
+
+		var res nodeResult
+		var err error
+		if node.Type == types.PlanNodeForeach {
+			res, err = r.runForeach(ctx, plan, &run, node, input)
+		} else {
+			sim := stepSimulation(run)
+			var attempts int
+			res, attempts, err = r.executeWithRetry(ctx, sessionID, node, nodeModel(plan, node), prompt, sim)
+			recordSimulatedCalls(&run, current, sim)
+			setStepAttempts(&run, current, attempts)
+			setStepModel(&run, current, res)
+		}
 		if err != nil {
+			setStepMessage(&run, current, res.messageID)
 			if ctx.Err() != nil {
 				r.failStep(&run, current, "cancelled")
 				r.finishRun(&run, "cancelled")
 				return
 			}
 			r.failStep(&run, current, err.Error())
+			if handler := findErrorTarget(plan.Graph, current); handler != "" {
+				log.Printf("plans: node %s failed, continuing with error handler %s", current, handler)
+				failedNode, failedErr = &node, err
+				current = handler
+				continue
+			}
 			r.finishRun(&run, "failed")
 			return
 		}
 
+		lastOutput = res.content
+
 		switch node.Type {
 		case types.PlanNodeAction:
 			r.completeStep(&run, current, res.messageID)
 
+		case types.PlanNodeForeach:
+			r.setStepResult(&run, current, "completed", res.content)
+			loopNode = &node
+
 		case types.PlanNodeDecision:
 			branch := parseDecision(res.content)
+			setStepMessage(&run, current, res.messageID)
 			r.setStepResult(&run, current, "completed", branch)
 
 			next := findEdgeTarget(plan.Graph, current, branch)
@@ -289,43 +503,74 @@ func (r *Runner) execute(ctx context.Context, plan types.Plan, run types.PlanRun
 	}
 }
 
-func (r *Runner) executeWithRetry(ctx context.Context, sessionID string, node types.PlanNode, prompt string) (nodeResult, error) {
+// executeWithRetry runs a node until it succeeds or its retries run out, and
+// reports how many attempts were made. On failure the result still carries
+// the last attempt's message, if it got that far.
+func (r *Runner) executeWithRetry(ctx context.Context, sessionID string, node types.PlanNode, model modelplugin.Input, prompt string, sim *agents.Simulation) (nodeResult, int, error) {
 	maxRetries := node.MaxRetries
 	if maxRetries < 0 {
 		maxRetries = 0
 	}
 	var lastErr error
+	var last nodeResult
+	attempts := 0
 	for attempt := 0; attempt <= maxRetries; attempt++ {
 		if attempt > 0 {
-			log.Printf("plans: retrying node %s (attempt %d/%d)", node.ID, attempt+1, maxRetries+1)
-			time.Sleep(time.Duration(attempt) * 2 * time.Second)
+			if !shouldRetry(node, lastErr) {
+				break
+			}
+			delay := retryBackoff(node, attempt)
+			log.Printf("plans: retrying node %s in %s (attempt %d/%d, %s)", node.ID, delay.Round(time.Millisecond), attempt+1, maxRetries+1, failureClass(lastErr))
+			if err := sleepContext(ctx, delay); err != nil {
+				return nodeResult{}, attempts, err
+			}
 		}
 		if ctx.Err() != nil {
-			return nodeResult{}, ctx.Err()
+			return nodeResult{}, attempts, ctx.Err()
 		}
-		res, err := r.executeNode(ctx, sessionID, prompt, node.ClearContext)
+		attempts++
+		res, err := r.executeNode(ctx, sessionID, prompt, model, node.ClearContext, nodeTimeout(node, r.limits.PlanStepTimeout), sim)
 		if err == nil {
-			return res, nil
+			return res, attempts, nil
 		}
-		lastErr = err
+		last, lastErr = res, err
 	}
-	return nodeResult{}, lastErr
+	return last, attempts, lastErr
 }
 
 type nodeResult struct {
-	content   string
-	messageID string
+	content    string
+	messageID  string
+	modelID    string
+	modelName  string
+	presetName string
+}
+
+// nodeModel picks the model a node runs with: the node's own model or
+// preset, else the plan's, else the default chat preset. A model wins over
+// a preset set at the same level.
+func nodeModel(plan types.Plan, node types.PlanNode) modelplugin.Input {
+	in := modelplugin.Input{DefaultPreset: "chat"}
+	if node.ModelID != "" || node.PresetID != "" {
+		in.ExplicitModelID, in.PresetID = node.ModelID, node.PresetID
+	} else {
+		in.ExplicitModelID, in.PresetID = plan.ModelID, plan.PresetID
+	}
+	return in
 }
 
-func (r *Runner) executeNode(ctx context.Context, sessionID, prompt string, clearContext bool) (nodeResult, error) {
+func (r *Runner) executeNode(ctx context.Context, sessionID, prompt string, model modelplugin.Input, clearContext bool, timeout time.Duration, sim *agents.Simulation) (nodeResult, error) {
 	done := make(chan struct{})
+	timeoutMarker := shared.StopReasonPlanStepTimeout(timeout)
 	out, err := r.workflow.Execute(ctx, messageworkflow.Input{
 		SessionID:      sessionID,
 		Content:        prompt,
 		Source:         "plan",
-		ModelConfig:    modelplugin.Input{DefaultPreset: "chat"},
+		ModelConfig:    model,
 		DisableHistory: clearContext,
-		Timeout:        r.limits.PlanStepTimeout,
+		Timeout:        timeout,
+		TimeoutMarker:  timeoutMarker,
+		Simulation:     sim,
 		Finally:        func() { close(done) },
 	})
 	if err != nil {
@@ -346,16 +591,26 @@ func (r *Runner) executeNode(ctx context.Context, sessionID, prompt string, clea
 	if !ok {
 		return nodeResult{}, fmt.Errorf("assistant message not found")
 	}
-	if msg.Status == "error" {
-		return nodeResult{messageID: msg.ID}, fmt.Errorf("step error: %s", msg.Content)
+	res := nodeResult{messageID: msg.ID, modelID: msg.ModelID, modelName: msg.ModelName, presetName: msg.PresetName}
+	if err := classifyMessage(msg, timeoutMarker); err != nil {
+		return res, err
 	}
-	if msg.Status == "cancelled" && !strings.Contains(msg.Content, shared.StopReasonUser()) {
-		return nodeResult{messageID: msg.ID}, fmt.Errorf("step stopped: %s", msg.Content)
-	}
-	if strings.Contains(msg.Content, "[ERROR]:") {
-		return nodeResult{messageID: msg.ID}, fmt.Errorf("step reported error: %s", msg.Content)
+	res.content = msg.Content
+	return res, nil
+}
+
+func classifyMessage(msg types.ChatMessage, timeoutMarker string) error {
+	switch {
+	case msg.Status == "error":
+		return &stepFailure{class: types.PlanRetryOnError, msg: "step error: " + msg.Content}
+	case msg.Status == "cancelled" && strings.Contains(msg.Content, timeoutMarker):
+		return &stepFailure{class: types.PlanRetryOnTimeout, msg: "step timed out: " + msg.Content}
+	case msg.Status == "cancelled" && !strings.Contains(msg.Content, shared.StopReasonUser()):
+		return &stepFailure{class: types.PlanRetryOnStopped, msg: "step stopped: " + msg.Content}
+	case strings.Contains(msg.Content, "[ERROR]:"):
+		return &stepFailure{class: types.PlanRetryOnReported, msg: "step reported error: " + msg.Content}
 	}
-	return nodeResult{content: msg.Content, messageID: msg.ID}, nil
+	return nil
 }
 
 func renderPrompt(raw string, input map[string]any) string {
@@ -455,6 +710,39 @@ func (r *Runner) setStepResult(run *types.PlanRun, nodeID, status, result string
 	r.saveRun(run)
 }
 
+// setStepAttempts records how many times a step ran. The step is saved by
+// the update that follows.
+func setStepAttempts(run *types.PlanRun, nodeID string, attempts int) {
+	for i := range run.Steps {
+		if run.Steps[i].NodeID == nodeID {
+			run.Steps[i].Attempts = attempts
+			break
+		}
+	}
+}
+
+// setStepModel records the model a step ran with. The step is saved by the
+// update that follows.
+func setStepModel(run *types.PlanRun, nodeID string, res nodeResult) {
+	for i := range run.Steps {
+		if run.Steps[i].NodeID == nodeID {
+			run.Steps[i].ModelID = res.modelID
+			run.Steps[i].ModelName = res.modelName
+			run.Steps[i].PresetName = res.presetName
+			break
+		}
+	}
+}
+
+func setStepMessage(run *types.PlanRun, nodeID, messageID string) {
+	for i := range run.Steps {
+		if run.Steps[i].NodeID == nodeID {
+			run.Steps[i].MessageID = messageID
+			break
+		}
+	}
+}
+
 func (r *Runner) finishRun(run *types.PlanRun, status string) {
 	now := time.Now().UTC()
 	run.Status = status
@@ -471,6 +759,15 @@ func (r *Runner) saveRun(run *types.PlanRun) {
 
 func markStaleSteps(run *types.PlanRun, now time.Time, reason string) {
 	for i := range run.Steps {
+		for j := range run.Steps[i].Items {
+			item := &run.Steps[i].Items[j]
+			switch item.Status {
+			case "running":
+				item.Status, item.Result, item.FinishedAt = "failed", reason, &now
+			case "pending":
+				item.Status = "skipped"
+			}
+		}
 		switch run.Steps[i].Status {
 		case "running":
 			run.Steps[i].Status = "failed"
@@ -502,11 +799,35 @@ func validateGraph(graph types.PlanGraph) error {
 	nodeTypes := make(map[string]types.PlanNodeType, len(graph.Nodes))
 	for _, n := range graph.Nodes {
 		nodeTypes[n.ID] = n.Type
+		if err := validateNode(n); err != nil {
+			return err
+		}
+		if n.Type == types.PlanNodeForeach {
+			if err := validateForeachNode(n); err != nil {
+				return err
+			}
+		}
 	}
 	outCount := make(map[string]int, len(graph.Edges))
+	errorCount := make(map[string]int)
 	for _, e := range graph.Edges {
+		if isEachEdge(e) {
+			continue
+		}
+		if isErrorEdge(e) {
+			if e.Target == e.Source {
+				return fmt.Errorf("node %q: error edge must not point to itself", e.Source)
+			}
+			errorCount[e.Source]++
+			continue
+		}
 		outCount[e.Source]++
 	}
+	for nodeID, count := range errorCount {
+		if count > 1 {
+			return fmt.Errorf("node %q has %d error edges (max 1)", nodeID, count)
+		}
+	}
 	for nodeID, count := range outCount {
 		nt := nodeTypes[nodeID]
 		if nt == types.PlanNodeAction && count > 1 {
@@ -515,8 +836,27 @@ func validateGraph(graph types.PlanGraph) error {
 		if nt == types.PlanNodeDecision && count > 2 {
 			return fmt.Errorf("decision node %q has %d outgoing edges (max 2)", nodeID, count)
 		}
+		if nt == types.PlanNodeApproval && count > 2 {
+			return fmt.Errorf("approval node %q has %d outgoing edges (max 2)", nodeID, count)
+		}
+		if nt == types.PlanNodeForeach && count > 1 {
+			return fmt.Errorf("foreach node %q has %d outgoing edges besides %q (max 1)", nodeID, count, types.PlanEdgeEach)
+		}
 	}
-	return nil
+	for _, e := range graph.Edges {
+		if nodeTypes[e.Source] != types.PlanNodeApproval || isErrorEdge(e) {
+			continue
+		}
+		label := strings.ToLower(strings.TrimSpace(e.Label))
+		if label != types.PlanEdgeApprove && label != types.PlanEdgeReject {
+			return fmt.Errorf("approval node %q: edge label must be %q or %q, got %q", e.Source, types.PlanEdgeApprove, types.PlanEdgeReject, e.Label)
+		}
+	}
+	return validateLoops(graph, nodeTypes)
+}
+
+func isErrorEdge(e types.PlanEdge) bool {
+	return strings.EqualFold(strings.TrimSpace(e.Label), types.PlanEdgeError)
 }
 
 func findStartNodes(graph types.PlanGraph) []string {
@@ -535,7 +875,7 @@ func findStartNodes(graph types.PlanGraph) []string {
 
 func findNextNode(graph types.PlanGraph, fromNodeID string) string {
 	for _, e := range graph.Edges {
-		if e.Source == fromNodeID {
+		if e.Source == fromNodeID && !isErrorEdge(e) && !isEachEdge(e) {
 			return e.Target
 		}
 	}
@@ -543,16 +883,36 @@ func findNextNode(graph types.PlanGraph, fromNodeID string) string {
 }
 
 func findEdgeTarget(graph types.PlanGraph, fromNodeID, label string) string {
+	if target := findLabeledEdge(graph, fromNodeID, label); target != "" {
+		return target
+	}
+	return findNextNode(graph, fromNodeID)
+}
+
+// findLabeledEdge returns the target of the edge with exactly this label,
+// without falling back to other edges.
+func findLabeledEdge(graph types.PlanGraph, fromNodeID, label string) string {
 	label = strings.TrimSpace(strings.ToLower(label))
 	for _, e := range graph.Edges {
-		if e.Source == fromNodeID && strings.TrimSpace(strings.ToLower(e.Label)) == label {
+		if e.Source == fromNodeID && !isErrorEdge(e) && strings.TrimSpace(strings.ToLower(e.Label)) == label {
 			return e.Target
 		}
 	}
+	return ""
+}
+
+func findErrorTarget(graph types.PlanGraph, fromNodeID string) string {
 	for _, e := range graph.Edges {
-		if e.Source == fromNodeID {
+		if e.Source == fromNodeID && isErrorEdge(e) {
 			return e.Target
 		}
 	}
 	return ""
 }
+
+func clip(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return strings.ToValidUTF8(s[:max], "") + "…"
+}
@@ -0,0 +1,188 @@
+package plans
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	robcron "github.com/robfig/cron/v3"
+
+	"mantis/core/base"
+	"mantis/core/types"
+)
+
+const (
+	catchUpTrigger        = "catch_up"
+	catchUpScheduledAtKey = "scheduledAt"
+)
+
+// maxJitter keeps a jittered run close enough to its slot that it can't run
+// into the next fire time of an hourly schedule.
+const maxJitter = time.Hour
+
+// ParseSchedule parses a plan's cron expression in its timezone. An empty
+// timezone means server-local time.
+func ParseSchedule(schedule, timezone string) (robcron.Schedule, error) {
+	schedule = strings.TrimSpace(schedule)
+	if schedule == "" {
+		return nil, fmt.Errorf("schedule is empty")
+	}
+	timezone = strings.TrimSpace(timezone)
+	if timezone == "" {
+		return scheduleParser.Parse(schedule)
+	}
+	if strings.HasPrefix(schedule, "TZ=") || strings.HasPrefix(schedule, "CRON_TZ=") {
+		return nil, fmt.Errorf("set the timezone field instead of a TZ= prefix")
+	}
+	if _, err := time.LoadLocation(timezone); err != nil {
+		return nil, fmt.Errorf("unknown timezone %q", timezone)
+	}
+	return scheduleParser.Parse("CRON_TZ=" + timezone + " " + schedule)
+}
+
+// NextFireTimes returns the next n times a schedule fires after from, in the
+// schedule's timezone.
+func NextFireTimes(schedule, timezone string, from time.Time, n int) ([]time.Time, error) {
+	sched, err := ParseSchedule(schedule, timezone)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", base.ErrValidation, err)
+	}
+	from = from.In(time.Local)
+	if tz := strings.TrimSpace(timezone); tz != "" {
+		if loc, err := time.LoadLocation(tz); err == nil {
+			from = from.In(loc)
+		}
+	}
+	times := make([]time.Time, 0, n)
+	t := from
+	for range n {
+		t = sched.Next(t)
+		if t.IsZero() {
+			break
+		}
+		times = append(times, t)
+	}
+	return times, nil
+}
+
+// ParseJitter parses a plan's start jitter; empty means none.
+func ParseJitter(jitter string) (time.Duration, error) {
+	jitter = strings.TrimSpace(jitter)
+	if jitter == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(jitter)
+	if err != nil {
+		return 0, fmt.Errorf("invalid jitter %q: use a duration like 30s or 5m", jitter)
+	}
+	if d < 0 || d > maxJitter {
+		return 0, fmt.Errorf("jitter %q must be between 0 and %s", jitter, maxJitter)
+	}
+	return d, nil
+}
+
+// ValidCatchUp reports whether c is a known catch-up policy; empty means skip.
+func ValidCatchUp(c types.PlanCatchUp) bool {
+	switch c {
+	case "", types.PlanCatchUpSkip, types.PlanCatchUpRunOnce, types.PlanCatchUpRunAll:
+		return true
+	}
+	return false
+}
+
+// ValidateScheduleOptions checks a plan's schedule together with its
+// timezone, jitter and catch-up policy.
+func ValidateScheduleOptions(plan types.Plan) error {
+	if strings.TrimSpace(plan.Schedule) != "" {
+		if _, err := ParseSchedule(plan.Schedule, plan.Timezone); err != nil {
+			return fmt.Errorf("invalid schedule %q: %v", plan.Schedule, err)
+		}
+	} else if tz := strings.TrimSpace(plan.Timezone); tz != "" {
+		if _, err := time.LoadLocation(tz); err != nil {
+			return fmt.Errorf("unknown timezone %q", tz)
+		}
+	}
+	if _, err := ParseJitter(plan.Jitter); err != nil {
+		return err
+	}
+	if !ValidCatchUp(plan.CatchUp) {
+		return fmt.Errorf("unknown catch-up policy %q", plan.CatchUp)
+	}
+	return nil
+}
+
+// jitterDelay picks a random start delay in [0, jitter).
+func jitterDelay(plan types.Plan) time.Duration {
+	d, err := ParseJitter(plan.Jitter)
+	if err != nil || d <= 0 {
+		return 0
+	}
+	return rand.N(d)
+}
+
+// missedFireTimes counts the fire times in (since, now] and returns the
+// latest keep of them, oldest first.
+func missedFireTimes(sched robcron.Schedule, since, now time.Time, keep int) (int, []time.Time) {
+	count := 0
+	var latest []time.Time
+	for t := sched.Next(since); !t.IsZero() && !t.After(now); t = sched.Next(t) {
+		count++
+		latest = append(latest, t)
+		if len(latest) > keep {
+			latest = latest[1:]
+		}
+	}
+	return count, latest
+}
+
+// catchUpRuns decides how many missed runs to start: one for run_once, all
+// of them for run_all (capped at limit), none for skip.
+func catchUpRuns(policy types.PlanCatchUp, limit int) int {
+	switch policy {
+	case types.PlanCatchUpRunOnce:
+		return 1
+	case types.PlanCatchUpRunAll:
+		return max(limit, 1)
+	}
+	return 0
+}
+
+// catchUp starts runs for fire times missed since a plan's last run. Plans
+// that never ran have nothing to catch up. The runs start oldest first and
+// go through the plan's concurrency policy like any other run.
+func (a *App) catchUp(ctx context.Context, plans []types.Plan, now time.Time) {
+	for _, plan := range plans {
+		keep := catchUpRuns(plan.CatchUp, a.runner.limits.PlanCatchUpMaxRuns)
+		if !plan.Enabled || plan.Schedule == "" || keep == 0 {
+			continue
+		}
+		sched, err := ParseSchedule(plan.Schedule, plan.Timezone)
+		if err != nil {
+			continue
+		}
+		last, ok, err := a.runner.lastStartedAt(ctx, plan.ID)
+		if err != nil {
+			log.Printf("plans: catch-up for plan=%s: %v", plan.ID, err)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		count, missed := missedFireTimes(sched, last, now, keep)
+		if count == 0 {
+			continue
+		}
+		log.Printf("plans: plan=%s (%s) missed %d scheduled run(s) since %s, catching up %d",
+			plan.ID, plan.Name, count, last.Format(time.RFC3339), len(missed))
+		for _, at := range missed {
+			input := map[string]any{catchUpScheduledAtKey: at.Format(time.RFC3339)}
+			if _, err := a.runner.TriggerRun(ctx, plan.ID, catchUpTrigger, input, false); err != nil {
+				log.Printf("plans: catch-up run for plan=%s: %v", plan.ID, err)
+				break
+			}
+		}
+	}
+}
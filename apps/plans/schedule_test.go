@@ -0,0 +1,120 @@
+package plans
+
+import (
+	"testing"
+	"time"
+
+	"mantis/core/types"
+)
+
+// --- ParseSchedule / NextFireTimes ---
+
+func TestNextFireTimes_Timezone(t *testing.T) {
+	from := time.Date(2026, 3, 27, 12, 0, 0, 0, time.UTC)
+	times, err := NextFireTimes("0 9 * * *", "Europe/Berlin", from, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Berlin switches to summer time on 2026-03-29: 9am moves from 08:00 to
+	// 07:00 UTC.
+	want := []string{"2026-03-28T08:00:00Z", "2026-03-29T07:00:00Z", "2026-03-30T07:00:00Z"}
+	if len(times) != len(want) {
+		t.Fatalf("expected %d times, got %v", len(want), times)
+	}
+	for i, w := range want {
+		if got := times[i].UTC().Format(time.RFC3339); got != w {
+			t.Fatalf("time %d: expected %s, got %s", i, w, got)
+		}
+		if times[i].Location().String() != "Europe/Berlin" {
+			t.Fatalf("times should be in the plan timezone, got %s", times[i].Location())
+		}
+	}
+}
+
+func TestParseSchedule_Errors(t *testing.T) {
+	cases := []struct{ schedule, tz string }{
+		{"", ""},
+		{"not a cron", ""},
+		{"0 9 * * *", "Mars/Olympus"},
+		{"CRON_TZ=UTC 0 9 * * *", "Europe/Berlin"},
+	}
+	for _, c := range cases {
+		if _, err := ParseSchedule(c.schedule, c.tz); err == nil {
+			t.Fatalf("expected error for %q in %q", c.schedule, c.tz)
+		}
+	}
+	if _, err := ParseSchedule("@daily", "America/New_York"); err != nil {
+		t.Fatalf("descriptors should accept a timezone: %v", err)
+	}
+}
+
+// --- ValidateScheduleOptions ---
+
+func TestValidateScheduleOptions(t *testing.T) {
+	ok := types.Plan{Schedule: "0 9 * * 1-5", Timezone: "Asia/Tokyo", Jitter: "90s", CatchUp: types.PlanCatchUpRunAll}
+	if err := ValidateScheduleOptions(ok); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []types.Plan{
+		{Timezone: "Nowhere/Town"},
+		{Jitter: "soon"},
+		{Jitter: "-1m"},
+		{Jitter: "3h"},
+		{CatchUp: "sometimes"},
+	} {
+		if err := ValidateScheduleOptions(p); err == nil {
+			t.Fatalf("expected error for %+v", p)
+		}
+	}
+}
+
+func TestJitterDelay(t *testing.T) {
+	if d := jitterDelay(types.Plan{}); d != 0 {
+		t.Fatalf("no jitter should mean no delay, got %s", d)
+	}
+	for range 100 {
+		if d := jitterDelay(types.Plan{Jitter: "10s"}); d < 0 || d >= 10*time.Second {
+			t.Fatalf("delay %s out of range", d)
+		}
+	}
+}
+
+// --- catch-up ---
+
+func TestMissedFireTimes(t *testing.T) {
+	sched, err := ParseSchedule("0 * * * *", "UTC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := time.Date(2026, 5, 1, 9, 0, 30, 0, time.UTC)
+	now := time.Date(2026, 5, 1, 14, 30, 0, 0, time.UTC)
+
+	count, latest := missedFireTimes(sched, last, now, 2)
+	if count != 5 {
+		t.Fatalf("expected 5 missed runs (10:00-14:00), got %d", count)
+	}
+	if len(latest) != 2 || latest[0].Hour() != 13 || latest[1].Hour() != 14 {
+		t.Fatalf("expected the latest two fire times, got %v", latest)
+	}
+
+	if count, _ := missedFireTimes(sched, now, now, 1); count != 0 {
+		t.Fatalf("nothing should be missed, got %d", count)
+	}
+}
+
+func TestCatchUpRuns(t *testing.T) {
+	cases := []struct {
+		policy types.PlanCatchUp
+		want   int
+	}{
+		{"", 0},
+		{types.PlanCatchUpSkip, 0},
+		{types.PlanCatchUpRunOnce, 1},
+		{types.PlanCatchUpRunAll, 10},
+	}
+	for _, c := range cases {
+		if got := catchUpRuns(c.policy, 10); got != c.want {
+			t.Fatalf("%q: expected %d, got %d", c.policy, c.want, got)
+		}
+	}
+}
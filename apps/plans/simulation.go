@@ -0,0 +1,87 @@
+package plans
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"mantis/core/agents"
+	"mantis/core/base"
+	"mantis/core/types"
+)
+
+const (
+	simulationTrigger = "simulation"
+	maxMocks          = 50
+	maxMockResult     = 16 * 1024
+)
+
+// SimulateRun starts a dry run of a plan. The graph is walked like any other
+// run, but SSH, skill, upload and notification tools are stubbed: each call
+// is recorded on its step and answered from mocks (keyed by tool name, "*"
+// for any tool) or, without a mock, left for the model to imagine. Run
+// reports and approval messages are not sent, and the plan's concurrency
+// policy does not apply.
+func (r *Runner) SimulateRun(ctx context.Context, planID string, input map[string]any, mocks map[string]string) (types.PlanRun, error) {
+	if err := ValidateMocks(mocks); err != nil {
+		return types.PlanRun{}, err
+	}
+	plan, err := r.loadPlan(ctx, planID)
+	if err != nil {
+		return types.PlanRun{}, err
+	}
+	run, _, err := r.startRun(ctx, plan, simulationTrigger, "", input, mocks, false)
+	return run, err
+}
+
+// ValidateMocks checks the canned results of a simulation: each must name a
+// tool the simulation stubs out, or "*".
+func ValidateMocks(mocks map[string]string) error {
+	if len(mocks) > maxMocks {
+		return fmt.Errorf("%w: at most %d mocks per simulation", base.ErrValidation, maxMocks)
+	}
+	for name, result := range mocks {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return fmt.Errorf("%w: mock tool names must not be empty", base.ErrValidation)
+		}
+		if name != agents.MockAnyTool && !agents.IsSimulatedTool(name) {
+			return fmt.Errorf("%w: mock %q: only ssh_*, skill_*, send_notification and send_file tools are simulated", base.ErrValidation, name)
+		}
+		if len(result) > maxMockResult {
+			return fmt.Errorf("%w: mock %q is longer than %d bytes", base.ErrValidation, name, maxMockResult)
+		}
+	}
+	return nil
+}
+
+func isSimulation(run types.PlanRun) bool {
+	return run.Trigger == simulationTrigger
+}
+
+// stepSimulation returns the tool stubs for one step of a simulation run, or
+// nil for a real run.
+func stepSimulation(run types.PlanRun) *agents.Simulation {
+	if !isSimulation(run) {
+		return nil
+	}
+	return agents.NewSimulation(run.Mocks)
+}
+
+// recordSimulatedCalls stores the calls a step's stubs captured. The step is
+// saved by the completeStep or failStep call that follows.
+func recordSimulatedCalls(run *types.PlanRun, nodeID string, sim *agents.Simulation) {
+	if sim == nil {
+		return
+	}
+	calls := sim.Calls()
+	if len(calls) == 0 {
+		return
+	}
+	for i := range run.Steps {
+		if run.Steps[i].NodeID == nodeID {
+			run.Steps[i].SimulatedCalls = calls
+			break
+		}
+	}
+}
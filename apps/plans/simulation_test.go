@@ -0,0 +1,67 @@
+package plans
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"mantis/core/agents"
+	"mantis/core/base"
+	"mantis/core/types"
+)
+
+// --- ValidateMocks ---
+
+func TestValidateMocks(t *testing.T) {
+	valid := map[string]string{"ssh_web1": "ok", "skill_restart_ab12cd34": "done", "send_notification": "sent", "*": "fine"}
+	if err := ValidateMocks(valid); err != nil {
+		t.Fatalf("expected valid mocks, got %v", err)
+	}
+	for _, mocks := range []map[string]string{
+		{"": "x"},
+		{"plan_list": "x"},
+		{"ssh_web1": strings.Repeat("x", maxMockResult+1)},
+	} {
+		if err := ValidateMocks(mocks); !errors.Is(err, base.ErrValidation) {
+			t.Fatalf("%v: expected validation error, got %v", mocks, err)
+		}
+	}
+}
+
+// --- stepSimulation / recordSimulatedCalls ---
+
+func TestStepSimulation_OnlyForSimulationRuns(t *testing.T) {
+	if stepSimulation(types.PlanRun{Trigger: "manual"}) != nil {
+		t.Fatal("real runs must not be simulated")
+	}
+	if stepSimulation(types.PlanRun{Trigger: simulationTrigger}) == nil {
+		t.Fatal("simulation runs need tool stubs")
+	}
+}
+
+func TestRecordSimulatedCalls(t *testing.T) {
+	run := types.PlanRun{
+		Trigger: simulationTrigger,
+		Mocks:   map[string]string{"ssh_web1": "disk 95%"},
+		Steps:   []types.PlanStepRun{{NodeID: "a"}, {NodeID: "b"}},
+	}
+	sim := stepSimulation(run)
+	tools := sim.Apply([]types.Tool{{Name: "ssh_web1"}})
+	if _, err := tools[0].Execute(t.Context(), `{"task":"check disk"}`); err != nil {
+		t.Fatal(err)
+	}
+	recordSimulatedCalls(&run, "b", sim)
+
+	if len(run.Steps[0].SimulatedCalls) != 0 {
+		t.Fatal("calls recorded on the wrong step")
+	}
+	calls := run.Steps[1].SimulatedCalls
+	if len(calls) != 1 || calls[0].Tool != "ssh_web1" || calls[0].Result != "disk 95%" {
+		t.Fatalf("unexpected calls %+v", calls)
+	}
+
+	recordSimulatedCalls(&run, "a", agents.NewSimulation(nil))
+	if run.Steps[0].SimulatedCalls != nil {
+		t.Fatal("steps without calls should stay empty")
+	}
+}
@@ -0,0 +1,116 @@
+package plans
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"mantis/core/base"
+	"mantis/core/protocols"
+	"mantis/core/types"
+)
+
+const (
+	maxSLAFailures = 100
+	// slaLookback is how many extra runs a failure streak check loads, so
+	// simulations and cancelled runs in between don't cut the streak short.
+	slaLookback = 20
+)
+
+// ValidateSLA checks a plan's SLA. A zero field is not checked.
+func ValidateSLA(sla types.PlanSLA) error {
+	if d := strings.TrimSpace(sla.MaxDuration); d != "" {
+		v, err := time.ParseDuration(d)
+		if err != nil || v <= 0 {
+			return fmt.Errorf("%w: invalid SLA max duration %q: use a duration like 10m or 1h", base.ErrValidation, sla.MaxDuration)
+		}
+	}
+	if sla.MaxConsecutiveFailures < 0 || sla.MaxConsecutiveFailures > maxSLAFailures {
+		return fmt.Errorf("%w: SLA max consecutive failures must be between 0 and %d", base.ErrValidation, maxSLAFailures)
+	}
+	return nil
+}
+
+// slaBreaches describes how the latest run and the current failure streak
+// break the SLA.
+func slaBreaches(sla *types.PlanSLA, latest types.PlanRun, failures int) []string {
+	if sla == nil {
+		return nil
+	}
+	var out []string
+	if limit, err := time.ParseDuration(strings.TrimSpace(sla.MaxDuration)); err == nil && limit > 0 && latest.FinishedAt != nil {
+		if took := latest.FinishedAt.Sub(latest.StartedAt); took > limit {
+			out = append(out, fmt.Sprintf("last run took %s (SLA %s)", formatStepDuration(took), limit))
+		}
+	}
+	if sla.MaxConsecutiveFailures > 0 && failures >= sla.MaxConsecutiveFailures {
+		out = append(out, fmt.Sprintf("%d failed runs in a row (SLA %d)", failures, sla.MaxConsecutiveFailures))
+	}
+	return out
+}
+
+// checkSLA notifies when a finished run breaks the plan's SLA. A failure
+// streak alerts once, when it reaches the limit, not on every failure after.
+// Alerts go where run reports go, or to the first Telegram bot.
+func (r *Runner) checkSLA(plan types.Plan, run types.PlanRun) {
+	if plan.SLA == nil || isSimulation(run) || r.channelStore == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), reportDeliverWait)
+	defer cancel()
+
+	failures := 0
+	if limit := plan.SLA.MaxConsecutiveFailures; limit > 0 && run.Status == "failed" {
+		runs, err := r.runStore.List(ctx, types.ListQuery{
+			Filter: map[string]string{"plan_id": plan.ID},
+			Sort:   []types.Sort{{Field: "started_at", Dir: types.SortDirDesc}},
+			Page:   types.Page{Limit: limit + slaLookback},
+		})
+		if err != nil {
+			log.Printf("plans: SLA check for run %s: %v", run.ID, err)
+			return
+		}
+		var finished []types.PlanRun
+		for _, rr := range runs {
+			if countsForMetrics(rr) {
+				finished = append(finished, rr)
+			}
+		}
+		if failures = consecutiveFailures(finished); failures != limit {
+			failures = 0
+		}
+	}
+	breaches := slaBreaches(plan.SLA, run, failures)
+	if len(breaches) == 0 {
+		return
+	}
+
+	var target types.PlanNotify
+	if plan.Notify != nil {
+		target = *plan.Notify
+	}
+	sender, err := r.reportSender(ctx, target)
+	if err != nil {
+		log.Printf("plans: SLA alert for run %s: %v", run.ID, err)
+		return
+	}
+	text := renderSLAAlert(plan, run, breaches, r.sessionLink(plan, run))
+	if err := sender.Execute(ctx, protocols.DeliveryRequest{Text: text}); err != nil {
+		log.Printf("plans: deliver SLA alert for run %s via %s: %v", run.ID, sender.Channel(), err)
+	}
+}
+
+func renderSLAAlert(plan types.Plan, run types.PlanRun, breaches []string, link string) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "SLA breached: plan %q\n", plan.Name)
+	fmt.Fprintf(&sb, "Run %s · %s\n\n", run.ID[:min(8, len(run.ID))], run.Status)
+	for _, b := range breaches {
+		fmt.Fprintf(&sb, "- %s\n", b)
+	}
+	if link != "" {
+		fmt.Fprintf(&sb, "\n%s\n", link)
+	}
+	return sb.String()
+}
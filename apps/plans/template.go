@@ -0,0 +1,334 @@
+package plans
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log"
+	"maps"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"mantis/core/auth"
+	"mantis/core/base"
+	"mantis/core/protocols"
+	"mantis/core/types"
+)
+
+const builtinTemplatePrefix = "builtin-"
+
+//go:embed templates/*.plan.yaml
+var builtinTemplateFiles embed.FS
+
+// BuiltinTemplates returns the templates shipped with Mantis, one per plan
+// document in templates/. A template's ID is "builtin-" and its file name.
+var BuiltinTemplates = sync.OnceValue(func() []types.PlanTemplate {
+	files, _ := fs.Glob(builtinTemplateFiles, "templates/*.plan.yaml")
+	out := make([]types.PlanTemplate, 0, len(files))
+	for _, file := range files {
+		data, err := builtinTemplateFiles.ReadFile(file)
+		if err != nil {
+			log.Printf("plans: read template %s: %v", file, err)
+			continue
+		}
+		plan, err := ParsePlanDocument(data)
+		if err != nil {
+			log.Printf("plans: parse template %s: %v", file, err)
+			continue
+		}
+		out = append(out, types.PlanTemplate{
+			ID:          builtinTemplatePrefix + strings.TrimSuffix(path.Base(file), ".plan.yaml"),
+			Name:        plan.Name,
+			Description: plan.Description,
+			Builtin:     true,
+			Plan:        plan,
+		})
+	}
+	return out
+})
+
+// Templates is the plan template library: the built-in templates plus the
+// ones users saved from their own plans.
+type Templates struct {
+	store      protocols.Store[string, types.PlanTemplate]
+	planStore  protocols.Store[string, types.Plan]
+	createPlan func(context.Context, types.Plan) (types.Plan, error)
+}
+
+func NewTemplates(store protocols.Store[string, types.PlanTemplate], planStore protocols.Store[string, types.Plan]) *Templates {
+	return &Templates{store: store, planStore: planStore}
+}
+
+// SetCreatePlan sets how instantiated plans are created. It is the plans
+// API's create use case, so a plan made from a template gets the same
+// defaults and webhook, notify and SLA checks as one created directly.
+func (t *Templates) SetCreatePlan(create func(context.Context, types.Plan) (types.Plan, error)) {
+	t.createPlan = create
+}
+
+// List returns the built-in templates first, then saved ones by name.
+func (t *Templates) List(ctx context.Context) ([]types.PlanTemplate, error) {
+	saved, err := t.store.List(ctx, types.ListQuery{
+		Sort: []types.Sort{{Field: "name", Dir: types.SortDirAsc}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append(slices.Clone(BuiltinTemplates()), saved...), nil
+}
+
+func (t *Templates) Get(ctx context.Context, id string) (types.PlanTemplate, error) {
+	if strings.HasPrefix(id, builtinTemplatePrefix) {
+		for _, tpl := range BuiltinTemplates() {
+			if tpl.ID == id {
+				return tpl, nil
+			}
+		}
+	}
+	items, err := t.store.Get(ctx, []string{id})
+	if err != nil {
+		return types.PlanTemplate{}, err
+	}
+	tpl, ok := items[id]
+	if !ok {
+		return types.PlanTemplate{}, fmt.Errorf("%w: plan template %s", base.ErrNotFound, id)
+	}
+	return tpl, nil
+}
+
+// SaveFromPlan saves an existing plan as a template. Its parameter defaults
+// carry over as the template's; the webhook is left out because its secret
+// and mapping belong to one integration.
+func (t *Templates) SaveFromPlan(ctx context.Context, planID, name, description string) (types.PlanTemplate, error) {
+	items, err := t.planStore.Get(ctx, []string{planID})
+	if err != nil {
+		return types.PlanTemplate{}, err
+	}
+	plan, ok := items[planID]
+	if !ok {
+		return types.PlanTemplate{}, fmt.Errorf("%w: plan %s", base.ErrNotFound, planID)
+	}
+	plan.ID = ""
+	plan.Webhook = nil
+	if name = strings.TrimSpace(name); name == "" {
+		name = plan.Name
+	}
+	if description = strings.TrimSpace(description); description == "" {
+		description = plan.Description
+	}
+	var createdBy string
+	if id, ok := auth.FromContext(ctx); ok {
+		createdBy = id.Name
+	}
+	now := time.Now().UTC()
+	created, err := t.store.Create(ctx, []types.PlanTemplate{{
+		ID:          uuid.New().String(),
+		Name:        name,
+		Description: description,
+		Plan:        plan,
+		CreatedBy:   createdBy,
+		CreatedAt:   &now,
+	}})
+	if err != nil {
+		return types.PlanTemplate{}, err
+	}
+	return created[0], nil
+}
+
+func (t *Templates) Delete(ctx context.Context, id string) error {
+	if strings.HasPrefix(id, builtinTemplatePrefix) {
+		return fmt.Errorf("%w: built-in templates cannot be deleted", base.ErrValidation)
+	}
+	return t.store.Delete(ctx, []string{id})
+}
+
+// Instantiate creates a plan from a template. See InstantiateTemplate.
+func (t *Templates) Instantiate(ctx context.Context, id string, in types.PlanTemplateInstance) (types.Plan, error) {
+	tpl, err := t.Get(ctx, id)
+	if err != nil {
+		return types.Plan{}, err
+	}
+	if t.createPlan == nil {
+		return types.Plan{}, fmt.Errorf("plan creation is not configured")
+	}
+	plan, err := InstantiateTemplate(tpl, in)
+	if err != nil {
+		return types.Plan{}, err
+	}
+	return t.createPlan(ctx, plan)
+}
+
+// InstantiateTemplate builds a new, unsaved plan from a template. Parameter
+// values are checked against the template's schema and stored as defaults,
+// so scheduled runs use them and manual runs start from them; a required
+// parameter with no default must be given.
+func InstantiateTemplate(tpl types.PlanTemplate, in types.PlanTemplateInstance) (types.Plan, error) {
+	plan := tpl.Plan
+	plan.ID = uuid.New().String()
+	plan.Graph = types.PlanGraph{
+		Nodes: slices.Clone(plan.Graph.Nodes),
+		Edges: slices.Clone(plan.Graph.Edges),
+	}
+	if name := strings.TrimSpace(in.Name); name != "" {
+		plan.Name = name
+	}
+	if in.Schedule != nil {
+		plan.Schedule = strings.TrimSpace(*in.Schedule)
+	}
+	if tz := strings.TrimSpace(in.Timezone); tz != "" {
+		plan.Timezone = tz
+	}
+	plan.Enabled = plan.Schedule != ""
+	if in.Enabled != nil {
+		plan.Enabled = *in.Enabled
+	}
+	if plan.Concurrency == "" {
+		plan.Concurrency = types.PlanConcurrencyAllow
+	}
+	if plan.CatchUp == "" {
+		plan.CatchUp = types.PlanCatchUpSkip
+	}
+	params, err := fillParameters(plan.Parameters, in.Parameters)
+	if err != nil {
+		return types.Plan{}, err
+	}
+	plan.Parameters = params
+	if problems := ValidatePlan(plan); len(problems) > 0 {
+		return types.Plan{}, fmt.Errorf("%w: %s", base.ErrValidation, strings.Join(problems, "; "))
+	}
+	return plan, nil
+}
+
+// fillParameters sets the given values as defaults in a parameter schema,
+// converting each to its declared type.
+func fillParameters(raw json.RawMessage, values map[string]any) (json.RawMessage, error) {
+	schema := map[string]any{}
+	if len(raw) > 0 && string(raw) != "null" {
+		if err := json.Unmarshal(raw, &schema); err != nil {
+			return nil, fmt.Errorf("%w: parameters: %v", base.ErrValidation, err)
+		}
+	}
+	props, _ := schema["properties"].(map[string]any)
+	for _, name := range slices.Sorted(maps.Keys(values)) {
+		prop, ok := props[name].(map[string]any)
+		if !ok {
+			known := slices.Sorted(maps.Keys(props))
+			return nil, fmt.Errorf("%w: unknown parameter %q (template takes %s)", base.ErrValidation, name, strings.Join(known, ", "))
+		}
+		typ, _ := prop["type"].(string)
+		v, err := coerceParameter(typ, values[name])
+		if err != nil {
+			return nil, fmt.Errorf("%w: parameter %q: %v", base.ErrValidation, name, err)
+		}
+		prop["default"] = v
+	}
+	required, _ := schema["required"].([]any)
+	for _, r := range required {
+		name, _ := r.(string)
+		prop, _ := props[name].(map[string]any)
+		if _, ok := prop["default"]; !ok {
+			return nil, fmt.Errorf("%w: parameter %q is required", base.ErrValidation, name)
+		}
+	}
+	if len(values) == 0 {
+		return raw, nil
+	}
+	return json.Marshal(schema)
+}
+
+// coerceParameter converts a value to a parameter type, accepting strings
+// for numbers and booleans since chat and forms send text.
+func coerceParameter(typ string, v any) (any, error) {
+	s, isString := v.(string)
+	if isString {
+		s = strings.TrimSpace(s)
+	}
+	switch typ {
+	case "number":
+		if isString {
+			f, err := strconv.ParseFloat(s, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%q is not a number", s)
+			}
+			return f, nil
+		}
+		if f, ok := v.(float64); ok {
+			return f, nil
+		}
+		return nil, fmt.Errorf("expected a number")
+	case "integer":
+		if isString {
+			n, err := strconv.ParseInt(s, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%q is not an integer", s)
+			}
+			return n, nil
+		}
+		if f, ok := v.(float64); ok && f == float64(int64(f)) {
+			return int64(f), nil
+		}
+		return nil, fmt.Errorf("expected an integer")
+	case "boolean":
+		if isString {
+			b, err := strconv.ParseBool(s)
+			if err != nil {
+				return nil, fmt.Errorf("%q is not true or false", s)
+			}
+			return b, nil
+		}
+		if b, ok := v.(bool); ok {
+			return b, nil
+		}
+		return nil, fmt.Errorf("expected true or false")
+	default:
+		switch val := v.(type) {
+		case string:
+			return s, nil
+		case float64, bool:
+			return fmt.Sprint(val), nil
+		default:
+			return nil, fmt.Errorf("expected text")
+		}
+	}
+}
+
+// withParameterDefaults fills run input the caller left out from the
+// defaults in the plan's parameter schema. The input map is not modified.
+func withParameterDefaults(raw json.RawMessage, input map[string]any) map[string]any {
+	var schema struct {
+		Properties map[string]struct {
+			Default any `json:"default"`
+		} `json:"properties"`
+	}
+	if len(raw) == 0 || json.Unmarshal(raw, &schema) != nil {
+		return input
+	}
+	var out map[string]any
+	for name, prop := range schema.Properties {
+		if prop.Default == nil {
+			continue
+		}
+		if _, ok := input[name]; ok {
+			continue
+		}
+		if out == nil {
+			out = maps.Clone(input)
+			if out == nil {
+				out = map[string]any{}
+			}
+		}
+		out[name] = prop.Default
+	}
+	if out == nil {
+		return input
+	}
+	return out
+}
@@ -0,0 +1,249 @@
+package plans
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+
+	"mantis/core/types"
+)
+
+func templateFixture() types.PlanTemplate {
+	return types.PlanTemplate{
+		ID:   "t1",
+		Name: "Disk check",
+		Plan: types.Plan{
+			Name:     "Disk check",
+			Schedule: "0 4 * * *",
+			Parameters: json.RawMessage(`{"type":"object","properties":{
+				"server":{"type":"string"},
+				"threshold":{"type":"integer","default":85},
+				"dry":{"type":"boolean"}
+			},"required":["server"]}`),
+			Graph: types.PlanGraph{
+				Nodes: []types.PlanNode{{ID: "check", Type: types.PlanNodeAction, Prompt: "Check {{.server}}"}},
+				Edges: []types.PlanEdge{},
+			},
+		},
+	}
+}
+
+func parameterDefaults(t *testing.T, raw json.RawMessage) map[string]any {
+	t.Helper()
+	var schema struct {
+		Properties map[string]struct {
+			Default any `json:"default"`
+		} `json:"properties"`
+	}
+	if err := json.Unmarshal(raw, &schema); err != nil {
+		t.Fatal(err)
+	}
+	out := map[string]any{}
+	for name, p := range schema.Properties {
+		if p.Default != nil {
+			out[name] = p.Default
+		}
+	}
+	return out
+}
+
+// --- built-in templates ---
+
+func TestBuiltinTemplates_Valid(t *testing.T) {
+	templates := BuiltinTemplates()
+	if len(templates) < 4 {
+		t.Fatalf("expected at least 4 built-in templates, got %d", len(templates))
+	}
+	for _, tpl := range templates {
+		t.Run(tpl.ID, func(t *testing.T) {
+			if !tpl.Builtin || !strings.HasPrefix(tpl.ID, builtinTemplatePrefix) || tpl.Name == "" {
+				t.Fatalf("unexpected template header: %+v", tpl)
+			}
+			if problems := ValidatePlan(tpl.Plan); len(problems) > 0 {
+				t.Fatalf("template is invalid: %v", problems)
+			}
+			values := map[string]any{}
+			var schema struct {
+				Required []string `json:"required"`
+			}
+			_ = json.Unmarshal(tpl.Plan.Parameters, &schema)
+			for _, name := range schema.Required {
+				values[name] = "web-1"
+			}
+			if _, err := InstantiateTemplate(tpl, types.PlanTemplateInstance{Parameters: values}); err != nil {
+				t.Fatalf("instantiate: %v", err)
+			}
+		})
+	}
+}
+
+// failureMarkerRe finds the prefix a template prompt tells the model to
+// start a failed step's answer with.
+var failureMarkerRe = regexp.MustCompile(`"(\[ERROR\][^"]*)"`)
+
+func TestBuiltinTemplates_FailureWordingFailsStep(t *testing.T) {
+	for _, tpl := range BuiltinTemplates() {
+		for _, node := range tpl.Plan.Graph.Nodes {
+			if node.Type != types.PlanNodeAction || !strings.Contains(node.Prompt, "[ERROR]") {
+				continue
+			}
+			t.Run(tpl.ID+"/"+node.ID, func(t *testing.T) {
+				m := failureMarkerRe.FindStringSubmatch(node.Prompt)
+				if m == nil {
+					t.Fatalf("prompt mentions [ERROR] without a quoted answer prefix: %q", node.Prompt)
+				}
+				answer := types.ChatMessage{Status: "completed", Content: m[1] + "the check failed"}
+				var failure *stepFailure
+				if err := classifyMessage(answer, "timed out"); !errors.As(err, &failure) || failure.class != types.PlanRetryOnReported {
+					t.Fatalf("answer %q: got %v, want a reported failure", answer.Content, err)
+				}
+			})
+		}
+	}
+}
+
+// --- InstantiateTemplate ---
+
+func TestInstantiateTemplate(t *testing.T) {
+	tpl := templateFixture()
+	plan, err := InstantiateTemplate(tpl, types.PlanTemplateInstance{
+		Name:       "Disk check web-1",
+		Parameters: map[string]any{"server": "web-1", "threshold": "90", "dry": "true"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.ID == "" || plan.Name != "Disk check web-1" || plan.Schedule != "0 4 * * *" || !plan.Enabled {
+		t.Fatalf("unexpected plan: %+v", plan)
+	}
+	if plan.Concurrency != types.PlanConcurrencyAllow || plan.CatchUp != types.PlanCatchUpSkip {
+		t.Fatalf("expected default policies, got %q %q", plan.Concurrency, plan.CatchUp)
+	}
+	got := parameterDefaults(t, plan.Parameters)
+	if got["server"] != "web-1" || got["threshold"] != 90.0 || got["dry"] != true {
+		t.Fatalf("unexpected defaults: %v", got)
+	}
+	if d := parameterDefaults(t, tpl.Plan.Parameters); d["server"] != nil {
+		t.Fatal("template must not be modified")
+	}
+}
+
+func TestInstantiateTemplate_ManualOnly(t *testing.T) {
+	empty := ""
+	plan, err := InstantiateTemplate(templateFixture(), types.PlanTemplateInstance{
+		Schedule:   &empty,
+		Parameters: map[string]any{"server": "web-1"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.Schedule != "" || plan.Enabled {
+		t.Fatalf("expected a disabled manual plan, got %q enabled=%v", plan.Schedule, plan.Enabled)
+	}
+}
+
+func TestTemplatesInstantiate_UsesCreatePlan(t *testing.T) {
+	planStore := newPlanStore()
+	templates := NewTemplates(nil, planStore)
+	if _, err := templates.Instantiate(context.Background(), builtinTemplatePrefix+"disk-cleanup", types.PlanTemplateInstance{
+		Parameters: map[string]any{"server": "web-1"},
+	}); err == nil {
+		t.Fatal("expected an error without a create use case")
+	}
+
+	var created []types.Plan
+	templates.SetCreatePlan(func(_ context.Context, p types.Plan) (types.Plan, error) {
+		created = append(created, p)
+		p.ID = "created"
+		return p, nil
+	})
+	plan, err := templates.Instantiate(context.Background(), builtinTemplatePrefix+"disk-cleanup", types.PlanTemplateInstance{
+		Parameters: map[string]any{"server": "web-1"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.ID != "created" || len(created) != 1 {
+		t.Fatalf("plan was not created through the use case: %+v", plan)
+	}
+	if len(planStore.items) != 0 {
+		t.Fatal("instantiate must not write to the plan store directly")
+	}
+}
+
+func TestInstantiateTemplate_Errors(t *testing.T) {
+	badCron := "every day"
+	cases := map[string]struct {
+		in   types.PlanTemplateInstance
+		want string
+	}{
+		"missing required": {in: types.PlanTemplateInstance{}, want: `"server" is required`},
+		"unknown parameter": {
+			in:   types.PlanTemplateInstance{Parameters: map[string]any{"server": "a", "host": "b"}},
+			want: `unknown parameter "host" (template takes dry, server, threshold)`,
+		},
+		"wrong type": {
+			in:   types.PlanTemplateInstance{Parameters: map[string]any{"server": "a", "threshold": "lots"}},
+			want: "not an integer",
+		},
+		"bad schedule": {
+			in:   types.PlanTemplateInstance{Schedule: &badCron, Parameters: map[string]any{"server": "a"}},
+			want: "schedule",
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := InstantiateTemplate(templateFixture(), tc.in)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("expected error containing %q, got %v", tc.want, err)
+			}
+		})
+	}
+}
+
+func TestCoerceParameter(t *testing.T) {
+	cases := []struct {
+		typ  string
+		in   any
+		want any
+	}{
+		{"string", " web-1 ", "web-1"},
+		{"string", 42.0, "42"},
+		{"number", "1.5", 1.5},
+		{"number", 2.0, 2.0},
+		{"integer", 3.0, int64(3)},
+		{"integer", "7", int64(7)},
+		{"boolean", "false", false},
+		{"boolean", true, true},
+		{"", "x", "x"},
+	}
+	for _, tc := range cases {
+		got, err := coerceParameter(tc.typ, tc.in)
+		if err != nil || got != tc.want {
+			t.Fatalf("coerceParameter(%q, %v) = %v, %v; want %v", tc.typ, tc.in, got, err, tc.want)
+		}
+	}
+	if _, err := coerceParameter("integer", 2.5); err == nil {
+		t.Fatal("expected 2.5 to be rejected as an integer")
+	}
+}
+
+// --- run input defaults ---
+
+func TestWithParameterDefaults(t *testing.T) {
+	schema := json.RawMessage(`{"properties":{"server":{"type":"string","default":"web-1"},"days":{"type":"integer","default":21},"path":{"type":"string"}}}`)
+	input := map[string]any{"days": 7}
+	got := withParameterDefaults(schema, input)
+	if got["server"] != "web-1" || got["days"] != 7 || len(got) != 2 {
+		t.Fatalf("unexpected input: %v", got)
+	}
+	if _, ok := input["server"]; ok {
+		t.Fatal("caller's input must not be modified")
+	}
+	if got := withParameterDefaults(json.RawMessage(`{}`), input); len(got) != 1 {
+		t.Fatalf("expected input unchanged, got %v", got)
+	}
+}
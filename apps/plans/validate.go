@@ -0,0 +1,150 @@
+package plans
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	robcron "github.com/robfig/cron/v3"
+
+	"mantis/core/types"
+)
+
+// DocumentSchema is the JSON Schema of the YAML plan document, for editors
+// and CI checks.
+//
+//go:embed plan.schema.json
+var DocumentSchema []byte
+
+var scheduleParser = robcron.NewParser(robcron.Minute | robcron.Hour | robcron.Dom | robcron.Month | robcron.Dow | robcron.Descriptor)
+
+var parameterTypes = []string{"string", "number", "integer", "boolean"}
+
+// ValidatePlan runs every check a plan must pass before it can be imported or
+// run: settings, graph structure (validateGraph) and the parameter schema. It
+// returns one message per problem; an empty result means the plan is valid.
+func ValidatePlan(plan types.Plan) []string {
+	var problems []string
+	if strings.TrimSpace(plan.Name) == "" {
+		problems = append(problems, "name is required")
+	}
+	if !ValidConcurrency(plan.Concurrency) {
+		problems = append(problems, fmt.Sprintf("unknown concurrency policy %q", plan.Concurrency))
+	}
+	if err := ValidateScheduleOptions(plan); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if err := validateParameters(plan.Parameters); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if plan.Webhook != nil {
+		if err := ValidateWebhook(*plan.Webhook); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	if plan.Notify != nil {
+		if err := ValidateNotify(*plan.Notify); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	if plan.SLA != nil {
+		if err := ValidateSLA(*plan.SLA); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	if err := validateStructure(plan.Graph); err != nil {
+		problems = append(problems, err.Error())
+	} else if err := validateGraph(plan.Graph); err != nil {
+		problems = append(problems, err.Error())
+	}
+	return problems
+}
+
+// ValidateGraph reports whether the runner can execute a graph, for callers
+// that edit graphs outside the plan editor.
+func (r *Runner) ValidateGraph(graph types.PlanGraph) error {
+	if err := validateStructure(graph); err != nil {
+		return err
+	}
+	return validateGraph(graph)
+}
+
+// validateStructure checks what the editor guarantees but a hand-written
+// document may not: unique node and edge IDs, known node types and edges
+// between existing nodes.
+func validateStructure(graph types.PlanGraph) error {
+	nodes := make(map[string]bool, len(graph.Nodes))
+	for _, n := range graph.Nodes {
+		if strings.TrimSpace(n.ID) == "" {
+			return fmt.Errorf("every node needs an id")
+		}
+		if nodes[n.ID] {
+			return fmt.Errorf("duplicate node id %q", n.ID)
+		}
+		nodes[n.ID] = true
+		switch n.Type {
+		case types.PlanNodeAction, types.PlanNodeDecision, types.PlanNodeApproval, types.PlanNodeForeach:
+		default:
+			return fmt.Errorf("node %q: unknown type %q", n.ID, n.Type)
+		}
+	}
+	edges := make(map[string]bool, len(graph.Edges))
+	for _, e := range graph.Edges {
+		if strings.TrimSpace(e.ID) == "" {
+			return fmt.Errorf("every edge needs an id")
+		}
+		if edges[e.ID] {
+			return fmt.Errorf("duplicate edge id %q", e.ID)
+		}
+		edges[e.ID] = true
+		if !nodes[e.Source] {
+			return fmt.Errorf("edge %q: unknown source node %q", e.ID, e.Source)
+		}
+		if !nodes[e.Target] {
+			return fmt.Errorf("edge %q: unknown target node %q", e.ID, e.Target)
+		}
+	}
+	return nil
+}
+
+// validateParameters checks the subset of JSON Schema the run dialog and
+// webhook mapping understand: an object with typed properties and a required
+// list naming declared properties.
+func validateParameters(raw json.RawMessage) error {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil
+	}
+	var schema struct {
+		Type       string                     `json:"type"`
+		Properties map[string]json.RawMessage `json:"properties"`
+		Required   []string                   `json:"required"`
+	}
+	if err := json.Unmarshal(raw, &schema); err != nil {
+		return fmt.Errorf("parameters: %v", err)
+	}
+	if schema.Type != "" && schema.Type != "object" {
+		return fmt.Errorf("parameters: type must be \"object\", got %q", schema.Type)
+	}
+	for name, rawProp := range schema.Properties {
+		if strings.TrimSpace(name) == "" {
+			return fmt.Errorf("parameters: property names must not be empty")
+		}
+		var prop struct {
+			Type string `json:"type"`
+		}
+		if err := json.Unmarshal(rawProp, &prop); err != nil {
+			return fmt.Errorf("parameters: property %q: %v", name, err)
+		}
+		if prop.Type != "" && !slices.Contains(parameterTypes, prop.Type) {
+			return fmt.Errorf("parameters: property %q has unsupported type %q (use %s)", name, prop.Type, strings.Join(parameterTypes, ", "))
+		}
+	}
+	for _, name := range schema.Required {
+		if _, ok := schema.Properties[name]; !ok {
+			return fmt.Errorf("parameters: required parameter %q is not declared", name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+package plans
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"mantis/core/base"
+	"mantis/core/types"
+)
+
+const (
+	webhookTrigger       = "webhook"
+	webhookPayloadKey    = "payload"
+	maxIdempotencyKeyLen = 200
+)
+
+// WebhookRequest is an inbound webhook delivery. Token is the shared secret
+// passed as a bearer token, header or query parameter; Signature is a
+// GitHub-style "sha256=<hex>" HMAC of Body keyed with the same secret.
+type WebhookRequest struct {
+	Source         string
+	Token          string
+	Signature      string
+	IdempotencyKey string
+	Body           []byte
+}
+
+type WebhookResult struct {
+	Run       types.PlanRun
+	Duplicate bool
+}
+
+// TriggerWebhook authenticates a webhook delivery against the plan's secret,
+// maps the payload to plan parameters and starts a run, deduplicating
+// deliveries that carry an idempotency key already seen for this plan.
+func (r *Runner) TriggerWebhook(ctx context.Context, planID string, req WebhookRequest) (WebhookResult, error) {
+	plans, err := r.planStore.Get(ctx, []string{planID})
+	if err != nil {
+		return WebhookResult{}, err
+	}
+	plan, ok := plans[planID]
+	if !ok || plan.Webhook == nil || !plan.Webhook.Enabled || plan.Webhook.Secret == "" {
+		return WebhookResult{}, base.ErrNotFound
+	}
+	hook := *plan.Webhook
+
+	if !verifyWebhook(hook.Secret, req) {
+		return WebhookResult{}, base.ErrForbidden
+	}
+
+	payload, err := decodeWebhookPayload(req.Body)
+	if err != nil {
+		return WebhookResult{}, err
+	}
+	input, err := mapWebhookInput(payload, hook.Mapping)
+	if err != nil {
+		return WebhookResult{}, err
+	}
+
+	key := strings.TrimSpace(req.IdempotencyKey)
+	if key == "" && strings.TrimSpace(hook.IdempotencyKey) != "" {
+		v, found, err := lookupJSONPath(payload, hook.IdempotencyKey)
+		if err != nil {
+			return WebhookResult{}, fmt.Errorf("%w: idempotency key: %s", base.ErrValidation, err)
+		}
+		if found {
+			key = stringifyWebhookValue(v)
+		}
+	}
+
+	run, duplicate, err := r.startRun(ctx, plan, webhookTriggerName(req.Source), normalizeIdempotencyKey(key), input, nil, false)
+	if err != nil {
+		return WebhookResult{}, err
+	}
+	return WebhookResult{Run: run, Duplicate: duplicate}, nil
+}
+
+func verifyWebhook(secret string, req WebhookRequest) bool {
+	if req.Token != "" {
+		return subtle.ConstantTimeCompare([]byte(req.Token), []byte(secret)) == 1
+	}
+	sig, ok := strings.CutPrefix(strings.TrimSpace(req.Signature), "sha256=")
+	if !ok {
+		return false
+	}
+	got, err := hex.DecodeString(sig)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(req.Body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
+
+func decodeWebhookPayload(body []byte) (any, error) {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return map[string]any{}, nil
+	}
+	var payload any
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("%w: payload is not valid JSON: %s", base.ErrValidation, err)
+	}
+	return payload, nil
+}
+
+// mapWebhookInput builds run input from the payload. Mapped parameters whose
+// path does not match are left unset so prompts fall back to their zero
+// value; the raw payload is always kept under "payload" unless a mapping
+// claims that name.
+func mapWebhookInput(payload any, mapping map[string]string) (map[string]any, error) {
+	input := make(map[string]any, len(mapping)+1)
+	for param, path := range mapping {
+		param = strings.TrimSpace(param)
+		if param == "" || strings.TrimSpace(path) == "" {
+			continue
+		}
+		v, found, err := lookupJSONPath(payload, path)
+		if err != nil {
+			return nil, fmt.Errorf("%w: mapping for %q: %s", base.ErrValidation, param, err)
+		}
+		if found {
+			input[param] = v
+		}
+	}
+	if _, taken := mapping[webhookPayloadKey]; !taken {
+		input[webhookPayloadKey] = payload
+	}
+	return input, nil
+}
+
+func webhookTriggerName(source string) string {
+	source = strings.ToLower(strings.TrimSpace(source))
+	source = strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '-' || r == '_' {
+			return r
+		}
+		return -1
+	}, source)
+	if source == "" {
+		return webhookTrigger
+	}
+	return webhookTrigger + ":" + source
+}
+
+func stringifyWebhookValue(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	default:
+		b, _ := json.Marshal(val)
+		return string(b)
+	}
+}
+
+func normalizeIdempotencyKey(key string) string {
+	key = strings.TrimSpace(key)
+	if len(key) <= maxIdempotencyKeyLen {
+		return key
+	}
+	sum := sha256.Sum256([]byte(key))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// ValidateWebhook checks that every mapping and the idempotency key are
+// parseable JSONPath expressions.
+func ValidateWebhook(hook types.PlanWebhook) error {
+	for param, path := range hook.Mapping {
+		if strings.TrimSpace(param) == "" {
+			return fmt.Errorf("%w: webhook mapping has an empty parameter name", base.ErrValidation)
+		}
+		if _, err := parseJSONPath(path); err != nil {
+			return fmt.Errorf("%w: webhook mapping for %q: %s", base.ErrValidation, param, err)
+		}
+	}
+	if strings.TrimSpace(hook.IdempotencyKey) != "" {
+		if _, err := parseJSONPath(hook.IdempotencyKey); err != nil {
+			return fmt.Errorf("%w: webhook idempotency key: %s", base.ErrValidation, err)
+		}
+	}
+	return nil
+}
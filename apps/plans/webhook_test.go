@@ -0,0 +1,195 @@
+package plans
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"mantis/core/types"
+)
+
+func decode(t *testing.T, raw string) any {
+	t.Helper()
+	var v any
+	if err := json.Unmarshal([]byte(raw), &v); err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+// --- lookupJSONPath ---
+
+func TestLookupJSONPath(t *testing.T) {
+	doc := decode(t, `{"status":"firing","alerts":[{"labels":{"instance":"web-1","alert.name":"DiskFull"}},{"labels":{"instance":"web-2"}}],"count":2}`)
+	cases := []struct {
+		path string
+		want any
+	}{
+		{"$.status", "firing"},
+		{"status", "firing"},
+		{"$.count", float64(2)},
+		{"$.alerts[0].labels.instance", "web-1"},
+		{"$.alerts[-1].labels.instance", "web-2"},
+		{"$.alerts[0].labels['alert.name']", "DiskFull"},
+		{`$["status"]`, "firing"},
+	}
+	for _, c := range cases {
+		got, found, err := lookupJSONPath(doc, c.path)
+		if err != nil || !found {
+			t.Errorf("%s: found=%v err=%v", c.path, found, err)
+			continue
+		}
+		if got != c.want {
+			t.Errorf("%s: got %v, want %v", c.path, got, c.want)
+		}
+	}
+}
+
+func TestLookupJSONPath_Missing(t *testing.T) {
+	doc := decode(t, `{"alerts":[{"labels":{}}]}`)
+	for _, path := range []string{"$.nope", "$.alerts[5]", "$.alerts.labels", "$.alerts[0].labels.instance"} {
+		if _, found, err := lookupJSONPath(doc, path); err != nil || found {
+			t.Errorf("%s: expected not found, got found=%v err=%v", path, found, err)
+		}
+	}
+}
+
+func TestLookupJSONPath_Invalid(t *testing.T) {
+	for _, path := range []string{"", "$.", "$.a[", "$.a[*]", "$..a"} {
+		if _, _, err := lookupJSONPath(map[string]any{}, path); err == nil {
+			t.Errorf("%q: expected error", path)
+		}
+	}
+}
+
+// --- mapWebhookInput ---
+
+func TestMapWebhookInput(t *testing.T) {
+	payload := decode(t, `{"alerts":[{"labels":{"instance":"web-1"}}],"status":"firing"}`)
+	input, err := mapWebhookInput(payload, map[string]string{
+		"host":    "$.alerts[0].labels.instance",
+		"missing": "$.alerts[0].annotations.summary",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if input["host"] != "web-1" {
+		t.Fatalf("host: %v", input["host"])
+	}
+	if _, ok := input["missing"]; ok {
+		t.Fatal("unmatched mapping should be left unset")
+	}
+	if _, ok := input[webhookPayloadKey]; !ok {
+		t.Fatal("raw payload should be recorded")
+	}
+}
+
+func TestMapWebhookInput_PayloadKeyClaimedByMapping(t *testing.T) {
+	payload := decode(t, `{"status":"firing"}`)
+	input, err := mapWebhookInput(payload, map[string]string{"payload": "$.status"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if input["payload"] != "firing" {
+		t.Fatalf("mapping should win over raw payload, got %v", input["payload"])
+	}
+}
+
+func TestMapWebhookInput_InvalidPath(t *testing.T) {
+	if _, err := mapWebhookInput(map[string]any{}, map[string]string{"x": "$.a["}); err == nil {
+		t.Fatal("expected error for invalid path")
+	}
+}
+
+// --- verifyWebhook ---
+
+func TestVerifyWebhook_Token(t *testing.T) {
+	if !verifyWebhook("s3cret", WebhookRequest{Token: "s3cret"}) {
+		t.Fatal("matching token should verify")
+	}
+	if verifyWebhook("s3cret", WebhookRequest{Token: "wrong"}) {
+		t.Fatal("wrong token should not verify")
+	}
+	if verifyWebhook("s3cret", WebhookRequest{}) {
+		t.Fatal("missing credentials should not verify")
+	}
+}
+
+func TestVerifyWebhook_Signature(t *testing.T) {
+	body := []byte(`{"action":"opened"}`)
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write(body)
+	sig := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	if !verifyWebhook("s3cret", WebhookRequest{Signature: sig, Body: body}) {
+		t.Fatal("valid signature should verify")
+	}
+	if verifyWebhook("s3cret", WebhookRequest{Signature: sig, Body: []byte(`{}`)}) {
+		t.Fatal("signature over different body should not verify")
+	}
+	if verifyWebhook("s3cret", WebhookRequest{Signature: "sha1=abc", Body: body}) {
+		t.Fatal("unsupported signature scheme should not verify")
+	}
+}
+
+// --- decodeWebhookPayload ---
+
+func TestDecodeWebhookPayload(t *testing.T) {
+	if v, err := decodeWebhookPayload(nil); err != nil || v == nil {
+		t.Fatalf("empty body should decode to empty object, got %v %v", v, err)
+	}
+	if _, err := decodeWebhookPayload([]byte("not json")); err == nil {
+		t.Fatal("expected error for invalid JSON")
+	}
+}
+
+// --- helpers ---
+
+func TestWebhookTriggerName(t *testing.T) {
+	cases := map[string]string{
+		"":              "webhook",
+		"GitHub":        "webhook:github",
+		" alertmanager": "webhook:alertmanager",
+		"a b/c":         "webhook:abc",
+	}
+	for in, want := range cases {
+		if got := webhookTriggerName(in); got != want {
+			t.Errorf("webhookTriggerName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestNormalizeIdempotencyKey(t *testing.T) {
+	if got := normalizeIdempotencyKey("  abc "); got != "abc" {
+		t.Fatalf("got %q", got)
+	}
+	long := strings.Repeat("k", maxIdempotencyKeyLen+1)
+	got := normalizeIdempotencyKey(long)
+	if !strings.HasPrefix(got, "sha256:") || len(got) > maxIdempotencyKeyLen {
+		t.Fatalf("long key should be hashed, got %q", got)
+	}
+}
+
+func TestStringifyWebhookValue(t *testing.T) {
+	if got := stringifyWebhookValue("abc"); got != "abc" {
+		t.Fatalf("got %q", got)
+	}
+	if got := stringifyWebhookValue(map[string]any{"a": float64(1)}); got != `{"a":1}` {
+		t.Fatalf("got %q", got)
+	}
+}
+
+func TestValidateWebhook(t *testing.T) {
+	if err := ValidateWebhook(types.PlanWebhook{Mapping: map[string]string{"host": "$.a.b"}, IdempotencyKey: "$.id"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateWebhook(types.PlanWebhook{Mapping: map[string]string{"host": "$.a["}}); err == nil {
+		t.Fatal("expected error for invalid mapping")
+	}
+	if err := ValidateWebhook(types.PlanWebhook{IdempotencyKey: "$..x"}); err == nil {
+		t.Fatal("expected error for invalid idempotency key")
+	}
+}
@@ -26,6 +26,7 @@ type App struct {
 	ucModelCommand  *usecases.HandleModelCommand
 	ucHandleMessage *usecases.HandleMessage
 	ucSyncBots      *usecases.SyncBots
+	workflow        *messageworkflow.Workflow
 	endpoints       *api.Endpoints
 	syncFreq        time.Duration
 }
@@ -56,12 +57,13 @@ func NewApp(
 	sessionUC := usecases.NewSession(sessionplugin.NewPolicy(sessionStore))
 	modelCommandUC := usecases.NewHandleModelCommand(presetStore, channelStore)
 	stopUC := chatusecases.NewStopGeneration(cancellations, planRunner)
-	handleMessageUC := usecases.NewHandleMessage(sessionUC, modelCommandUC, channelStore, messageStore, workflow, buffer, asr, tts, stopUC, agent.Limits())
+	handleMessageUC := usecases.NewHandleMessage(sessionUC, modelCommandUC, channelStore, messageStore, workflow, buffer, asr, tts, stopUC, planRunner, agent.Limits())
 
 	app := &App{
 		ucSession:       sessionUC,
 		ucModelCommand:  modelCommandUC,
 		ucHandleMessage: handleMessageUC,
+		workflow:        workflow,
 		endpoints:       api.NewEndpoints(api.UseCases{Wizard: usecases.NewWizard()}),
 		syncFreq:        30 * time.Second,
 	}
@@ -69,6 +71,11 @@ func NewApp(
 	return app
 }
 
+// SetRouter routes bot messages to cheaper models when their preset asks.
+func (a *App) SetRouter(r *modelplugin.Router) {
+	a.workflow.SetRouter(r)
+}
+
 func (a *App) Register(humaAPI huma.API) {
 	a.endpoints.Register(humaAPI)
 }
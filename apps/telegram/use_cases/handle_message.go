@@ -36,6 +36,7 @@ type HandleMessage struct {
 	asr            protocols.ASR
 	tts            protocols.TTS
 	stopUC         *chatusecases.StopGeneration
+	planRunner     protocols.PlanRunner
 	limits         shared.Limits
 }
 
@@ -49,6 +50,7 @@ func NewHandleMessage(
 	asr protocols.ASR,
 	tts protocols.TTS,
 	stopUC *chatusecases.StopGeneration,
+	planRunner protocols.PlanRunner,
 	limits shared.Limits,
 ) *HandleMessage {
 	return &HandleMessage{
@@ -61,6 +63,7 @@ func NewHandleMessage(
 		asr:            asr,
 		tts:            tts,
 		stopUC:         stopUC,
+		planRunner:     planRunner,
 		limits:         limits,
 	}
 }
@@ -81,7 +84,7 @@ func (uc *HandleMessage) Execute(ctx context.Context, in MessageInput) (adapter.
 		switch cmd {
 		case "start":
 			_, _ = uc.sessionUC.Execute(ctx, SessionModeGetOrCreate)
-			return adapter.Reply{Text: "Mantis\n\nSend a message to get started.\nCommands are available via the Menu button.\n\n/model - switch model\n/reset - reset context\n/stop - stop current generation\n/voice - read last message aloud"}, nil
+			return adapter.Reply{Text: "Mantis\n\nSend a message to get started.\nCommands are available via the Menu button.\n\n/model - switch model\n/reset - reset context\n/stop - stop current generation\n/voice - read last message aloud\n/approve, /reject - answer a plan waiting for approval"}, nil
 		case "reset":
 			if _, err := uc.sessionUC.Execute(ctx, SessionModeReset); err != nil {
 				return adapter.Reply{}, err
@@ -93,6 +96,8 @@ func (uc *HandleMessage) Execute(ctx context.Context, in MessageInput) (adapter.
 			return uc.modelCommandUC.Execute(ctx, in.ChannelID, args)
 		case "voice":
 			return uc.handleVoiceCommand(ctx, in)
+		case "approve", "reject":
+			return uc.handleApprovalCommand(ctx, in, cmd, args)
 		}
 	}
 	if isTelegramLinkCode(in.Text) && len(in.Incoming) == 0 {
@@ -211,6 +216,55 @@ func (uc *HandleMessage) handleStopCommand(ctx context.Context) (adapter.Reply,
 	return adapter.Reply{Text: "Nothing to stop."}, nil
 }
 
+// handleApprovalCommand resolves a plan run waiting for approval. The run
+// can be given by full ID or prefix; without one, the only waiting run is
+// used. Anything after the run ID is kept as the approver's comment.
+func (uc *HandleMessage) handleApprovalCommand(ctx context.Context, in MessageInput, decision, args string) (adapter.Reply, error) {
+	if uc.planRunner == nil {
+		return adapter.Reply{Text: "Plans are not available."}, nil
+	}
+	active, err := uc.planRunner.ActiveRuns(ctx)
+	if err != nil {
+		return adapter.Reply{}, err
+	}
+	var waiting []types.PlanRun
+	for _, r := range active {
+		if r.Status == "waiting" {
+			waiting = append(waiting, r)
+		}
+	}
+
+	ref, comment, _ := strings.Cut(strings.TrimSpace(args), " ")
+	var matches []types.PlanRun
+	for _, r := range waiting {
+		if ref == "" || strings.HasPrefix(r.ID, ref) {
+			matches = append(matches, r)
+		}
+	}
+	switch {
+	case len(waiting) == 0:
+		return adapter.Reply{Text: "No plan runs are waiting for approval."}, nil
+	case len(matches) == 0:
+		return adapter.Reply{Text: fmt.Sprintf("No waiting plan run matches %q.", ref)}, nil
+	case len(matches) > 1:
+		ids := make([]string, len(matches))
+		for i, r := range matches {
+			ids[i] = r.ID[:min(8, len(r.ID))]
+		}
+		return adapter.Reply{Text: fmt.Sprintf("Several runs are waiting, specify one: /%s <run> [comment]\n%s", decision, strings.Join(ids, "\n"))}, nil
+	}
+
+	run, err := uc.planRunner.ResolveApproval(ctx, matches[0].ID, decision, "telegram:"+in.ChatID, comment)
+	if err != nil {
+		return adapter.Reply{Text: fmt.Sprintf("Approval failed: %v", err)}, nil
+	}
+	verb := "Approved"
+	if decision == types.PlanEdgeReject {
+		verb = "Rejected"
+	}
+	return adapter.Reply{Text: fmt.Sprintf("%s run %s (now %s).", verb, run.ID[:min(8, len(run.ID))], run.Status)}, nil
+}
+
 func (uc *HandleMessage) handleVoiceCommand(ctx context.Context, in MessageInput) (adapter.Reply, error) {
 	if uc.tts == nil {
 		return adapter.Reply{Text: "TTS is not configured."}, nil
@@ -0,0 +1,127 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/danielgtaylor/huma/v2"
+
+	usecases "mantis/apps/usage/use_cases"
+	"mantis/core/base"
+	"mantis/core/plugins/usage"
+	"mantis/core/types"
+)
+
+type UseCases struct {
+	GetDailyUsage *usecases.GetDailyUsage
+	GetPlanUsage  *usecases.GetPlanUsage
+	ListBudgets   *usecases.ListBudgets
+	CreateBudget  *usecases.CreateBudget
+	UpdateBudget  *usecases.UpdateBudget
+	DeleteBudget  *usecases.DeleteBudget
+	ToolCallStats *usecases.GetToolCallStats
+}
+
+type Endpoints struct {
+	uc UseCases
+}
+
+func NewEndpoints(uc UseCases) *Endpoints {
+	return &Endpoints{uc: uc}
+}
+
+func (e *Endpoints) Register(api huma.API) {
+	huma.Register(api, huma.Operation{OperationID: "get-daily-usage", Method: http.MethodGet, Path: "/api/usage/daily"}, e.getDailyUsage)
+	huma.Register(api, huma.Operation{OperationID: "get-plan-usage", Method: http.MethodGet, Path: "/api/usage/plans"}, e.getPlanUsage)
+	huma.Register(api, huma.Operation{OperationID: "get-tool-call-stats", Method: http.MethodGet, Path: "/api/usage/tool-calls"}, e.getToolCallStats)
+	huma.Register(api, huma.Operation{OperationID: "list-usage-budgets", Method: http.MethodGet, Path: "/api/usage/budgets"}, e.listBudgets)
+	huma.Register(api, huma.Operation{OperationID: "create-usage-budget", Method: http.MethodPost, Path: "/api/usage/budgets", DefaultStatus: 201}, e.createBudget)
+	huma.Register(api, huma.Operation{OperationID: "update-usage-budget", Method: http.MethodPut, Path: "/api/usage/budgets/{id}"}, e.updateBudget)
+	huma.Register(api, huma.Operation{OperationID: "delete-usage-budget", Method: http.MethodDelete, Path: "/api/usage/budgets/{id}", DefaultStatus: 204}, e.deleteBudget)
+}
+
+func (e *Endpoints) getDailyUsage(ctx context.Context, input *DailyUsageInput) (*DailyUsageOutput, error) {
+	days, err := e.uc.GetDailyUsage.Execute(ctx, input.Days, usage.Filter{
+		ModelID:      input.ModelID,
+		ConnectionID: input.ConnectionID,
+		PlanID:       input.PlanID,
+		Caller:       input.Caller,
+	})
+	if err != nil {
+		return nil, mapErr(err)
+	}
+	return &DailyUsageOutput{Body: days}, nil
+}
+
+func (e *Endpoints) getPlanUsage(ctx context.Context, input *PlanUsageInput) (*PlanUsageOutput, error) {
+	plans, err := e.uc.GetPlanUsage.Execute(ctx, input.Days, input.PlanID)
+	if err != nil {
+		return nil, mapErr(err)
+	}
+	return &PlanUsageOutput{Body: plans}, nil
+}
+
+func (e *Endpoints) getToolCallStats(_ context.Context, _ *struct{}) (*ToolCallStatsOutput, error) {
+	return &ToolCallStatsOutput{Body: e.uc.ToolCallStats.Execute()}, nil
+}
+
+func (e *Endpoints) listBudgets(ctx context.Context, _ *struct{}) (*BudgetStatusesOutput, error) {
+	items, err := e.uc.ListBudgets.Execute(ctx)
+	if err != nil {
+		return nil, mapErr(err)
+	}
+	return &BudgetStatusesOutput{Body: items}, nil
+}
+
+func (e *Endpoints) createBudget(ctx context.Context, input *BudgetInput) (*BudgetOutput, error) {
+	b, err := e.uc.CreateBudget.Execute(ctx, budgetFromBody("", input.Body))
+	if err != nil {
+		return nil, mapErr(err)
+	}
+	return &BudgetOutput{Body: b}, nil
+}
+
+func (e *Endpoints) updateBudget(ctx context.Context, input *UpdateBudgetInput) (*BudgetOutput, error) {
+	b, err := e.uc.UpdateBudget.Execute(ctx, budgetFromBody(input.ID, input.Body))
+	if err != nil {
+		return nil, mapErr(err)
+	}
+	return &BudgetOutput{Body: b}, nil
+}
+
+func (e *Endpoints) deleteBudget(ctx context.Context, input *BudgetIDInput) (*struct{}, error) {
+	if err := e.uc.DeleteBudget.Execute(ctx, input.ID); err != nil {
+		return nil, mapErr(err)
+	}
+	return nil, nil
+}
+
+func budgetFromBody(id string, b BudgetBody) types.UsageBudget {
+	enabled := true
+	if b.Enabled != nil {
+		enabled = *b.Enabled
+	}
+	return types.UsageBudget{
+		ID:        id,
+		Name:      b.Name,
+		Scope:     b.Scope,
+		ScopeID:   b.ScopeID,
+		Period:    b.Period,
+		MaxCost:   b.MaxCost,
+		MaxTokens: b.MaxTokens,
+		Action:    b.Action,
+		Enabled:   enabled,
+	}
+}
+
+func mapErr(err error) error {
+	switch {
+	case errors.Is(err, base.ErrNotFound):
+		return huma.NewError(http.StatusNotFound, err.Error())
+	case errors.Is(err, base.ErrValidation):
+		return huma.NewError(http.StatusUnprocessableEntity, err.Error())
+	default:
+		return huma.NewError(http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,60 @@
+package api
+
+import "mantis/core/types"
+
+type DailyUsageInput struct {
+	Days         int    `query:"days" minimum:"0" maximum:"366" doc:"Days back from today; defaults to 30"`
+	ModelID      string `query:"modelId"`
+	ConnectionID string `query:"connectionId" doc:"LLM connection"`
+	PlanID       string `query:"planId"`
+	Caller       string `query:"caller" enum:",supervisor,ssh,summarizer,memory,vision"`
+}
+
+type DailyUsageOutput struct {
+	Body []types.UsageDay
+}
+
+type PlanUsageInput struct {
+	Days   int    `query:"days" minimum:"0" maximum:"366" doc:"Days back from today; defaults to 30"`
+	PlanID string `query:"planId"`
+}
+
+type PlanUsageOutput struct {
+	Body []types.PlanUsage
+}
+
+type BudgetBody struct {
+	Name      string                  `json:"name" minLength:"1"`
+	Scope     types.UsageBudgetScope  `json:"scope,omitempty" enum:"global,model,connection,plan" doc:"Defaults to global"`
+	ScopeID   string                  `json:"scopeId,omitempty" doc:"Model, LLM connection or plan ID; required unless the scope is global"`
+	Period    types.UsageBudgetPeriod `json:"period,omitempty" enum:"day,month" doc:"UTC day or month the limits apply to; defaults to day"`
+	MaxCost   float64                 `json:"maxCost,omitempty" minimum:"0" doc:"Limit in USD"`
+	MaxTokens int                     `json:"maxTokens,omitempty" minimum:"0" doc:"Limit on prompt and completion tokens"`
+	Action    types.UsageBudgetAction `json:"action,omitempty" enum:"warn,block" doc:"What happens once the budget is exceeded; defaults to warn"`
+	Enabled   *bool                   `json:"enabled,omitempty" doc:"Defaults to true"`
+}
+
+type BudgetInput struct {
+	Body BudgetBody
+}
+
+type UpdateBudgetInput struct {
+	ID   string `path:"id"`
+	Body BudgetBody
+}
+
+type BudgetIDInput struct {
+	ID string `path:"id"`
+}
+
+type BudgetOutput struct {
+	Body types.UsageBudget
+}
+
+type BudgetStatusesOutput struct {
+	Body []types.UsageBudgetStatus
+}
+
+type ToolCallStatsOutput struct {
+	Body []types.ToolCallStat
+}
@@ -0,0 +1,44 @@
+package usage
+
+import (
+	"net/http"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/adapters/humachi"
+	"github.com/go-chi/chi/v5"
+
+	"mantis/apps/usage/api"
+	usecases "mantis/apps/usage/use_cases"
+	"mantis/core/plugins/agent"
+	"mantis/core/plugins/usage"
+	"mantis/core/protocols"
+	"mantis/core/types"
+)
+
+type App struct {
+	endpoints *api.Endpoints
+}
+
+func NewApp(ledger *usage.Ledger, budgetStore protocols.Store[string, types.UsageBudget], toolStats *agent.ToolCallStats) *App {
+	return &App{
+		endpoints: api.NewEndpoints(api.UseCases{
+			GetDailyUsage: usecases.NewGetDailyUsage(ledger),
+			GetPlanUsage:  usecases.NewGetPlanUsage(ledger),
+			ListBudgets:   usecases.NewListBudgets(ledger),
+			CreateBudget:  usecases.NewCreateBudget(budgetStore),
+			UpdateBudget:  usecases.NewUpdateBudget(budgetStore),
+			DeleteBudget:  usecases.NewDeleteBudget(budgetStore),
+			ToolCallStats: usecases.NewGetToolCallStats(toolStats),
+		}),
+	}
+}
+
+func (a *App) Register(api huma.API) {
+	a.endpoints.Register(api)
+}
+
+func (a *App) Handler() http.Handler {
+	r := chi.NewMux()
+	a.Register(humachi.New(r, huma.DefaultConfig("Mantis Usage API", "1.0.0")))
+	return r
+}
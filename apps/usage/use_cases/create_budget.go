@@ -0,0 +1,33 @@
+package usecases
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"mantis/core/protocols"
+	"mantis/core/types"
+)
+
+type CreateBudget struct {
+	store protocols.Store[string, types.UsageBudget]
+}
+
+func NewCreateBudget(store protocols.Store[string, types.UsageBudget]) *CreateBudget {
+	return &CreateBudget{store: store}
+}
+
+func (uc *CreateBudget) Execute(ctx context.Context, b types.UsageBudget) (types.UsageBudget, error) {
+	if err := validateBudget(&b); err != nil {
+		return types.UsageBudget{}, err
+	}
+	now := time.Now().UTC()
+	b.ID = uuid.New().String()
+	b.CreatedAt = &now
+	result, err := uc.store.Create(ctx, []types.UsageBudget{b})
+	if err != nil {
+		return types.UsageBudget{}, err
+	}
+	return result[0], nil
+}
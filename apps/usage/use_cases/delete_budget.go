@@ -0,0 +1,20 @@
+package usecases
+
+import (
+	"context"
+
+	"mantis/core/protocols"
+	"mantis/core/types"
+)
+
+type DeleteBudget struct {
+	store protocols.Store[string, types.UsageBudget]
+}
+
+func NewDeleteBudget(store protocols.Store[string, types.UsageBudget]) *DeleteBudget {
+	return &DeleteBudget{store: store}
+}
+
+func (uc *DeleteBudget) Execute(ctx context.Context, id string) error {
+	return uc.store.Delete(ctx, []string{id})
+}
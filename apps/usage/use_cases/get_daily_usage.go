@@ -0,0 +1,20 @@
+package usecases
+
+import (
+	"context"
+
+	"mantis/core/plugins/usage"
+	"mantis/core/types"
+)
+
+type GetDailyUsage struct {
+	ledger *usage.Ledger
+}
+
+func NewGetDailyUsage(ledger *usage.Ledger) *GetDailyUsage {
+	return &GetDailyUsage{ledger: ledger}
+}
+
+func (uc *GetDailyUsage) Execute(ctx context.Context, days int, f usage.Filter) ([]types.UsageDay, error) {
+	return uc.ledger.Daily(ctx, days, f)
+}
@@ -0,0 +1,20 @@
+package usecases
+
+import (
+	"context"
+
+	"mantis/core/plugins/usage"
+	"mantis/core/types"
+)
+
+type GetPlanUsage struct {
+	ledger *usage.Ledger
+}
+
+func NewGetPlanUsage(ledger *usage.Ledger) *GetPlanUsage {
+	return &GetPlanUsage{ledger: ledger}
+}
+
+func (uc *GetPlanUsage) Execute(ctx context.Context, days int, planID string) ([]types.PlanUsage, error) {
+	return uc.ledger.Plans(ctx, days, planID)
+}
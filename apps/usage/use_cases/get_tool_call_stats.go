@@ -0,0 +1,18 @@
+package usecases
+
+import (
+	"mantis/core/plugins/agent"
+	"mantis/core/types"
+)
+
+type GetToolCallStats struct {
+	stats *agent.ToolCallStats
+}
+
+func NewGetToolCallStats(stats *agent.ToolCallStats) *GetToolCallStats {
+	return &GetToolCallStats{stats: stats}
+}
+
+func (uc *GetToolCallStats) Execute() []types.ToolCallStat {
+	return uc.stats.Snapshot()
+}
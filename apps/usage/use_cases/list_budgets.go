@@ -0,0 +1,20 @@
+package usecases
+
+import (
+	"context"
+
+	"mantis/core/plugins/usage"
+	"mantis/core/types"
+)
+
+type ListBudgets struct {
+	ledger *usage.Ledger
+}
+
+func NewListBudgets(ledger *usage.Ledger) *ListBudgets {
+	return &ListBudgets{ledger: ledger}
+}
+
+func (uc *ListBudgets) Execute(ctx context.Context) ([]types.UsageBudgetStatus, error) {
+	return uc.ledger.BudgetStatuses(ctx)
+}
@@ -0,0 +1,37 @@
+package usecases
+
+import (
+	"context"
+
+	"mantis/core/base"
+	"mantis/core/protocols"
+	"mantis/core/types"
+)
+
+type UpdateBudget struct {
+	store protocols.Store[string, types.UsageBudget]
+}
+
+func NewUpdateBudget(store protocols.Store[string, types.UsageBudget]) *UpdateBudget {
+	return &UpdateBudget{store: store}
+}
+
+func (uc *UpdateBudget) Execute(ctx context.Context, b types.UsageBudget) (types.UsageBudget, error) {
+	if err := validateBudget(&b); err != nil {
+		return types.UsageBudget{}, err
+	}
+	existing, err := uc.store.Get(ctx, []string{b.ID})
+	if err != nil {
+		return types.UsageBudget{}, err
+	}
+	prev, ok := existing[b.ID]
+	if !ok {
+		return types.UsageBudget{}, base.ErrNotFound
+	}
+	b.CreatedAt = prev.CreatedAt
+	result, err := uc.store.Update(ctx, []types.UsageBudget{b})
+	if err != nil {
+		return types.UsageBudget{}, err
+	}
+	return result[0], nil
+}
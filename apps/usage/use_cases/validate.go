@@ -0,0 +1,34 @@
+package usecases
+
+import (
+	"fmt"
+	"strings"
+
+	"mantis/core/base"
+	"mantis/core/types"
+)
+
+// validateBudget fills in a budget's defaults and rejects one that can never
+// be exceeded or has no target for its scope.
+func validateBudget(b *types.UsageBudget) error {
+	b.Name = strings.TrimSpace(b.Name)
+	b.ScopeID = strings.TrimSpace(b.ScopeID)
+	if b.Scope == "" {
+		b.Scope = types.UsageBudgetGlobal
+	}
+	if b.Period == "" {
+		b.Period = types.UsageBudgetDay
+	}
+	if b.Action == "" {
+		b.Action = types.UsageBudgetWarn
+	}
+	if b.Scope == types.UsageBudgetGlobal {
+		b.ScopeID = ""
+	} else if b.ScopeID == "" {
+		return fmt.Errorf("%w: scopeId is required for a %s budget", base.ErrValidation, b.Scope)
+	}
+	if b.MaxCost <= 0 && b.MaxTokens <= 0 {
+		return fmt.Errorf("%w: set maxCost or maxTokens", base.ErrValidation)
+	}
+	return nil
+}
@@ -30,13 +30,16 @@ import (
 	runtimekeys "mantis/apps/runtime/keys"
 	runtimespec "mantis/apps/runtime/spec"
 	"mantis/apps/telegram"
+	usageapp "mantis/apps/usage"
 	"mantis/core/agents"
 	"mantis/core/auth"
 	artifactplugin "mantis/core/plugins/artifact"
 	"mantis/core/plugins/guard"
 	"mantis/core/plugins/memory"
+	modelplugin "mantis/core/plugins/model"
 	"mantis/core/plugins/pipeline"
 	"mantis/core/plugins/summarizer"
+	"mantis/core/plugins/usage"
 	"mantis/core/protocols"
 	"mantis/core/types"
 	artifactadapter "mantis/infrastructure/adapters/artifact"
@@ -96,12 +99,30 @@ func main() {
 		mappers.SkillToRow,
 		mappers.SkillFromRow,
 	)
-	planStore := store.NewPostgres[string, types.Plan, models.PlanRow](
+	planRevisionStore := store.NewPostgres[string, types.PlanRevision, models.PlanRevisionRow](
+		db,
+		func(r types.PlanRevision) string { return r.ID },
+		mappers.PlanRevisionToRow,
+		mappers.PlanRevisionFromRow,
+	)
+	planStore := plansapp.NewRevisionedStore(store.NewPostgres[string, types.Plan, models.PlanRow](
 		db,
 		func(p types.Plan) string { return p.ID },
 		mappers.PlanToRow,
 		mappers.PlanFromRow,
-	)
+	), planRevisionStore)
+	planTemplates := plansapp.NewTemplates(store.NewPostgres[string, types.PlanTemplate, models.PlanTemplateRow](
+		db,
+		func(t types.PlanTemplate) string { return t.ID },
+		mappers.PlanTemplateToRow,
+		mappers.PlanTemplateFromRow,
+	), planStore)
+	planBlackouts := plansapp.NewBlackouts(store.NewPostgres[string, types.PlanBlackout, models.PlanBlackoutRow](
+		db,
+		func(b types.PlanBlackout) string { return b.ID },
+		mappers.PlanBlackoutToRow,
+		mappers.PlanBlackoutFromRow,
+	), planStore)
 	planRunStore := store.NewPostgres[string, types.PlanRun, models.PlanRunRow](
 		db,
 		func(r types.PlanRun) string { return r.ID },
@@ -148,13 +169,48 @@ func main() {
 
 	openaiAdapter := llm.NewOpenAI()
 	gonkaAdapter := llm.NewGonka()
-	llmAdapter := llm.NewRouter("openai", map[string]protocols.LLM{
-		"openai": openaiAdapter,
-		"gonka":  gonkaAdapter,
+	anthropicAdapter := llm.NewAnthropic()
+	var llmAdapter protocols.LLM = llm.NewRouter("openai", map[string]protocols.LLM{
+		"openai":    openaiAdapter,
+		"gonka":     gonkaAdapter,
+		"anthropic": anthropicAdapter,
 	})
+	usageBudgetStore := store.NewPostgres[string, types.UsageBudget, models.UsageBudgetRow](
+		db,
+		func(b types.UsageBudget) string { return b.ID },
+		mappers.UsageBudgetToRow,
+		mappers.UsageBudgetFromRow,
+	)
+	usageLedger := usage.NewLedger(store.NewPostgres[string, types.UsageRecord, models.UsageRecordRow](
+		db,
+		func(r types.UsageRecord) string { return r.ID },
+		mappers.UsageRecordToRow,
+		mappers.UsageRecordFromRow,
+	), usageBudgetStore, modelStore)
+	llmAdapter = usage.NewMeter(llmAdapter, usageLedger)
+	cacheMode, err := llm.ParseCacheMode(env("LLM_CACHE_MODE", "off"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	if cacheMode != llm.CacheOff {
+		var cacheStore protocols.Store[string, types.LLMCacheEntry]
+		if dir := env("LLM_CACHE_DIR", ""); dir != "" {
+			cacheStore = store.NewJSONDir(dir, func(e types.LLMCacheEntry) string { return e.ID })
+		} else {
+			cacheStore = store.NewPostgres[string, types.LLMCacheEntry, models.LLMCacheEntryRow](
+				db,
+				func(e types.LLMCacheEntry) string { return e.ID },
+				mappers.LLMCacheEntryToRow,
+				mappers.LLMCacheEntryFromRow,
+			)
+		}
+		llmAdapter = llm.NewCache(llmAdapter, cacheStore, cacheMode)
+		log.Printf("llm cache: %s", cacheMode)
+	}
 	llmCatalogs := map[string]protocols.LLMCatalog{
-		"openai": openaiAdapter,
-		"gonka":  gonkaAdapter,
+		"openai":    openaiAdapter,
+		"gonka":     gonkaAdapter,
+		"anthropic": anthropicAdapter,
 	}
 	sessionLogger := shared.NewSessionLogger(logStore)
 	commandGuard := guard.New(guardProfileStore)
@@ -172,12 +228,13 @@ func main() {
 		ttsAdapter = tts.NewCosyVoice(u, 5*time.Minute)
 	}
 
-	visionAdapter := llm.NewVision()
+	visionAdapter := usage.NewVisionMeter(llm.NewVision(), usageLedger)
 	limits := shared.LoadLimits()
-	log.Printf("limits: supervisor=%s/%d, server=%s/%d, plan_step=%s",
+	log.Printf("limits: supervisor=%s/%d, server=%s/%d, plan_step=%s, plan_runs=%d, plan_approval=%s",
 		shared.FormatDuration(limits.SupervisorTimeout), limits.SupervisorMaxIterations,
 		shared.FormatDuration(limits.ServerTimeout), limits.ServerMaxIterations,
-		shared.FormatDuration(limits.PlanStepTimeout))
+		shared.FormatDuration(limits.PlanStepTimeout), limits.PlanMaxConcurrentRuns,
+		shared.FormatDuration(limits.PlanApprovalTimeout))
 	mantisAgent := agents.NewMantisAgent(messageStore, modelStore, presetStore, llmConnStore, connectionStore, skillStore, planStore, channelStore, settingsStore, sessionStore, llmAdapter, commandGuard, sessionLogger, asrAdapter, ocrAdapter, visionAdapter, limits)
 
 	buf := shared.NewBuffer()
@@ -190,16 +247,24 @@ func main() {
 
 	cancellations := pipeline.NewCancellations()
 
-	plansApp := plansapp.NewApp(settingsStore, sessionStore, messageStore, modelStore, presetStore, planStore, planRunStore, mantisAgent, artifactMgr, memoryExtractor, summ, buf)
+	plansApp := plansapp.NewApp(settingsStore, sessionStore, messageStore, modelStore, presetStore, planStore, planRunStore, channelStore, mantisAgent, artifactMgr, memoryExtractor, summ, buf)
+	plansApp.SetBlackouts(planBlackouts)
 	mantisAgent.SetPlanRunner(plansApp.Runner())
+	mantisAgent.SetPlanTemplates(planTemplates)
+	mantisAgent.SetPlanHistory(planRunStore, logStore)
 
-	metadataApp := metadata.NewApp(settingsStore, llmConnStore, modelStore, presetStore, connectionStore, skillStore, planStore, planRunStore, plansApp.Runner(), guardProfileStore, channelStore, llmCatalogs)
+	metadataApp := metadata.NewApp(settingsStore, llmConnStore, modelStore, presetStore, connectionStore, skillStore, planStore, planRunStore, planRevisionStore, plansApp.Runner(), planTemplates, planBlackouts, guardProfileStore, channelStore, llmCatalogs)
 	chatApp := chat.NewApp(sessionStore, messageStore, modelStore, presetStore, channelStore, settingsStore, mantisAgent, buf, artifactMgr, memoryExtractor, summ, cancellations, plansApp.Runner())
 	logsApp := logs.NewApp(logStore)
+	usageApp := usageapp.NewApp(usageLedger, usageBudgetStore, mantisAgent.ToolCallStats())
 	telegramApp := telegram.NewApp(channelStore, sessionStore, messageStore, modelStore, presetStore, settingsStore, mantisAgent, buf, artifactMgr, asrAdapter, ttsAdapter, memoryExtractor, summ, cancellations, plansApp.Runner())
 
+	router := modelplugin.NewRouter(presetStore, connectionStore)
+	chatApp.SetRouter(router)
+	telegramApp.SetRouter(router)
 	chatApp.SetAttachmentDir(attachmentDir)
 	plansApp.SetAttachmentDir(attachmentDir)
+	plansApp.SetPublicURL(env("MANTIS_PUBLIC_URL", ""))
 
 	go telegramApp.Start(context.Background())
 	go plansApp.Start(context.Background())
@@ -231,6 +296,7 @@ func main() {
 	metadataApp.Register(api)
 	chatApp.Register(api)
 	logsApp.Register(api)
+	usageApp.Register(api)
 	telegramApp.Register(api)
 
 	gonkaApp := gonkaapp.NewApp(gonkaapp.Options{
@@ -309,6 +375,9 @@ func isPublicPathFactory(runtimeToken string) func(*http.Request) bool {
 		case "/api/auth/login", "/api/auth/logout", "/docs", "/openapi.json", "/openapi.yaml":
 			return true
 		}
+		if strings.HasPrefix(p, "/api/hooks/") {
+			return true
+		}
 		if strings.HasPrefix(p, "/api/runtime/") {
 			if runtimeToken == "" {
 				return true
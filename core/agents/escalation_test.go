@@ -0,0 +1,42 @@
+package agents
+
+import (
+	"context"
+	"testing"
+
+	"mantis/core/types"
+)
+
+func TestEscalationTarget_RoutedModelIsTheFallback(t *testing.T) {
+	a := &MantisAgent{
+		presetStore: &memStore[types.Preset]{
+			id:    func(p types.Preset) string { return p.ID },
+			items: []types.Preset{{ID: "p1", ChatModelID: "big", FallbackModelID: "small"}},
+		},
+		modelStore: &memStore[types.Model]{
+			id: func(m types.Model) string { return m.ID },
+			items: []types.Model{
+				{ID: "big", Name: "gpt-big", ConnectionID: "c1"},
+				{ID: "small", Name: "gpt-small", ConnectionID: "c1"},
+			},
+		},
+		llmConnStore: &memStore[types.LlmConnection]{
+			id:    func(c types.LlmConnection) string { return c.ID },
+			items: []types.LlmConnection{{ID: "c1", Provider: "openai"}},
+		},
+	}
+	ctx := context.Background()
+
+	// Routed to the light model, which is also the fallback model: the
+	// failover chain after it is empty.
+	if chain := a.fallbackChain(ctx, "p1", "small"); len(chain) != 0 {
+		t.Fatalf("fallback chain = %+v", chain)
+	}
+	target := a.escalationTarget(ctx, "p1", "big")
+	if target == nil || target.ModelID != "big" || target.ModelName != "gpt-big" || target.Provider != "openai" {
+		t.Fatalf("escalation target = %+v", target)
+	}
+	if a.escalationTarget(ctx, "p1", "") != nil || a.escalationTarget(ctx, "p1", "gone") != nil {
+		t.Fatal("no or an unknown model must not escalate")
+	}
+}
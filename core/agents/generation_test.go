@@ -0,0 +1,45 @@
+package agents
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"mantis/core/types"
+)
+
+func TestGenerationOptions_PresetOverridesModel(t *testing.T) {
+	modelTemp, presetTemp, legacyTemp := 0.2, 0.9, 0.7
+	parallel := false
+	presets := &memStore[types.Preset]{
+		id: func(p types.Preset) string { return p.ID },
+		items: []types.Preset{
+			{ID: "p1", Generation: types.GenerationOptions{Temperature: &presetTemp, Stop: []string{"END"}, ParallelToolCalls: &parallel}},
+			{ID: "p2", Temperature: &legacyTemp, Generation: types.GenerationOptions{Temperature: &presetTemp}},
+		},
+	}
+	a := &MantisAgent{presetStore: presets}
+	model := types.Model{Generation: types.GenerationOptions{Temperature: &modelTemp, MaxTokens: 1024, Stop: []string{"STOP"}}}
+
+	got := a.generationOptions(context.Background(), "p1", model)
+	if *got.Temperature != presetTemp || got.MaxTokens != 1024 || strings.Join(got.Stop, ",") != "END" || *got.ParallelToolCalls {
+		t.Fatalf("p1 options = %+v", got)
+	}
+	if got := a.generationOptions(context.Background(), "p2", model); *got.Temperature != legacyTemp {
+		t.Fatalf("preset temperature should win over its generation options, got %v", *got.Temperature)
+	}
+	if got := a.generationOptions(context.Background(), "", model); *got.Temperature != modelTemp || got.MaxTokens != 1024 {
+		t.Fatalf("without a preset the model's options apply, got %+v", got)
+	}
+}
+
+func TestBuildSystemPrompt_PresetPrompt(t *testing.T) {
+	a := &MantisAgent{}
+	prompt := a.buildSystemPrompt(nil, nil, "", "", "", "  Answer in French.  ")
+	if !strings.Contains(prompt, "Additional instructions:\nAnswer in French.") {
+		t.Fatalf("preset prompt missing from system prompt")
+	}
+	if strings.Contains(a.buildSystemPrompt(nil, nil, "", "", "", " "), "Additional instructions") {
+		t.Fatalf("blank preset prompt should add nothing")
+	}
+}
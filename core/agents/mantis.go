@@ -4,6 +4,8 @@ import (
 	"context"
 	_ "embed"
 	"fmt"
+	"html"
+	"regexp"
 	"strings"
 	"time"
 
@@ -19,6 +21,14 @@ import (
 //go:embed soul.md
 var mantisSoul string
 
+var attachmentRe = regexp.MustCompile(`<attachment id="([^"]+)"`)
+
+// inlineImageMIME lists the image formats every vision API accepts inline.
+var inlineImageMIME = map[string]bool{"image/png": true, "image/jpeg": true, "image/gif": true, "image/webp": true}
+
+// maxInlineImageBytes keeps inline images under the providers' size limits.
+const maxInlineImageBytes = 5 << 20
+
 const (
 	telegramMarkdownV2ReservedChars = "_ * [ ] ( ) ~ ` > # + - = | { } . !"
 	mdBacktick                      = "`"
@@ -89,7 +99,7 @@ plan_get — get full details of a plan by id: all steps (nodes with prompts), e
   Parameter id: plan ID.
 
 plan_run — trigger execution of a plan by id. The plan runs asynchronously.
-  Parameters: id (plan ID), input (optional object with parameter values if the plan defines parameters).
+  Parameters: id (plan ID), input (optional object with parameter values if the plan defines parameters), simulate (optional, dry run: server, upload and notification calls are recorded instead of executed), mocks (optional with simulate, canned tool results keyed by tool name or "*").
 
 plan_create — create a multi-step agentic workflow plan.
   Parameters:
@@ -107,10 +117,11 @@ plan_create — create a multi-step agentic workflow plan.
     Multi-step with branching: steps=[{"type":"action","prompt":"Run health check"}, {"type":"decision","prompt":"Any issues found?","yes":"next","no":"ok"}, {"type":"action","prompt":"Send alert about issues"}, {"type":"action","prompt":"Log all clear","id":"ok"}]
 
 plan_update — update plan settings by id. All fields except id are optional — only provided fields are changed.
-  Parameters: id (required), enabled (bool), schedule (string, cron expression or empty to remove schedule), name (string), description (string).
-  Examples: change schedule: {"id":"...","schedule":"0 */6 * * *"}, disable: {"id":"...","enabled":false}, remove schedule: {"id":"...","schedule":""}.
+  Parameters: id (required), enabled (bool), schedule (string, cron expression or empty to remove schedule), timezone (IANA name such as "Europe/Berlin"), name (string), description (string), edits (array of step edits).
+  Step edits (use plan_get first to see step ids): {"op":"insert","after":"<id>|start","branch":"yes|no (decisions only)","step":{...}}, {"op":"replace","id":"<id>","step":{...}}, {"op":"remove","id":"<id>"}, {"op":"rewire","id":"<id>","branch":"yes|no|next","target":"<id>|end"}.
+  Examples: change schedule: {"id":"...","schedule":"0 */6 * * *"}, disable: {"id":"...","enabled":false}, remove schedule: {"id":"...","schedule":""}, add a step: {"id":"...","edits":[{"op":"insert","after":"n2","step":{"type":"action","prompt":"Send a summary"}}]}.
 
-plan_delete — delete a plan by id. To modify plan steps, delete the plan and create a new one.
+plan_delete — delete a plan by id. To change its steps, use plan_update with edits instead.
 
 plan_active — list currently running plan executions. Returns run IDs, plan IDs, and start times.
 
@@ -141,6 +152,7 @@ code
 type MantisInput struct {
 	SessionID string
 	ModelID   string
+	PresetID  string // the preset ModelID came from; its fallback models are tried when ModelID fails
 	Content   string
 	Artifacts *shared.ArtifactStore
 	RequestID string // per-request id (e.g. assistant message id)
@@ -152,6 +164,14 @@ type MantisInput struct {
 
 	// If true, do not include message history in the LLM context.
 	DisableHistory bool
+
+	// Simulation, when set, stubs out tools with side effects (plan dry runs).
+	Simulation *Simulation
+
+	// EscalateTo is the model a request routed to a cheaper tier moves to
+	// after EscalateAfter failed tool iterations (see agent.LoopInput).
+	EscalateTo    string
+	EscalateAfter int
 }
 
 type MantisAgent struct {
@@ -163,6 +183,9 @@ type MantisAgent struct {
 	skillStore      protocols.Store[string, types.Skill]
 	planStore       protocols.Store[string, types.Plan]
 	planRunner      protocols.PlanRunner
+	planTemplates   protocols.PlanTemplates
+	planRunStore    protocols.Store[string, types.PlanRun]
+	logStore        protocols.Store[string, types.SessionLog]
 	channelStore    protocols.Store[string, types.Channel]
 	settingsStore   protocols.Store[string, types.Settings]
 	sessionStore    protocols.Store[string, types.ChatSession]
@@ -173,6 +196,7 @@ type MantisAgent struct {
 	ocr             protocols.OCR
 	vision          protocols.VisionLLM
 	limits          shared.Limits
+	toolStats       *agent.ToolCallStats
 }
 
 func NewMantisAgent(
@@ -194,6 +218,8 @@ func NewMantisAgent(
 	vision protocols.VisionLLM,
 	limits shared.Limits,
 ) *MantisAgent {
+	breaker := agent.NewBreaker(0, 0)
+	toolStats := agent.NewToolCallStats()
 	return &MantisAgent{
 		messageStore:    messageStore,
 		modelStore:      modelStore,
@@ -205,8 +231,9 @@ func NewMantisAgent(
 		channelStore:    channelStore,
 		settingsStore:   settingsStore,
 		sessionStore:    sessionStore,
-		agent:           agent.New(llm),
-		sshAgent:        NewSSHAgent(llmConnStore, llm, g, sessionLogger, limits),
+		agent:           agent.New(llm, breaker, toolStats),
+		sshAgent:        NewSSHAgent(llmConnStore, llm, breaker, toolStats, g, sessionLogger, limits),
+		toolStats:       toolStats,
 		asr:             asr,
 		ocr:             ocr,
 		vision:          vision,
@@ -216,10 +243,24 @@ func NewMantisAgent(
 
 func (a *MantisAgent) Limits() shared.Limits { return a.limits }
 
+// ToolCallStats counts the tool calls of the chat and SSH agents per model.
+func (a *MantisAgent) ToolCallStats() *agent.ToolCallStats { return a.toolStats }
+
 func (a *MantisAgent) SetPlanRunner(r protocols.PlanRunner) {
 	a.planRunner = r
 }
 
+func (a *MantisAgent) SetPlanTemplates(t protocols.PlanTemplates) {
+	a.planTemplates = t
+}
+
+// SetPlanHistory gives the agent read access to past plan runs and the SSH
+// session logs of their steps.
+func (a *MantisAgent) SetPlanHistory(runs protocols.Store[string, types.PlanRun], logs protocols.Store[string, types.SessionLog]) {
+	a.planRunStore = runs
+	a.logStore = logs
+}
+
 func (a *MantisAgent) SetRuntime(rt protocols.Runtime) {
 	a.runtime = rt
 }
@@ -269,8 +310,9 @@ func (a *MantisAgent) Execute(ctx context.Context, in MantisInput) (<-chan types
 		requestID = uuid.New().String()
 	}
 
-	tools := a.buildTools(connections, skills, artifacts, requestID, in.Source)
-	prompt := a.buildSystemPrompt(connections, artifacts, in.Source, in.ReplyChannel, in.ReplyTo)
+	tools := in.Simulation.Apply(a.buildTools(connections, skills, artifacts, requestID, in.Source))
+	preset, _ := a.resolvePreset(ctx, in.PresetID)
+	prompt := a.buildSystemPrompt(connections, artifacts, in.Source, in.ReplyChannel, in.ReplyTo, preset.SystemPrompt)
 
 	toolsProvider := func(pctx context.Context) []types.Tool {
 		latestConnections, cerr := a.connectionStore.List(pctx, types.ListQuery{})
@@ -287,7 +329,7 @@ func (a *MantisAgent) Execute(ctx context.Context, in MantisInput) (<-chan types
 		if latestSkills == nil {
 			latestSkills = []types.Skill{}
 		}
-		return a.buildTools(latestConnections, latestSkills, artifacts, requestID, in.Source)
+		return in.Simulation.Apply(a.buildTools(latestConnections, latestSkills, artifacts, requestID, in.Source))
 	}
 
 	messages := []protocols.LLMMessage{{Role: "system", Content: prompt}}
@@ -304,21 +346,32 @@ func (a *MantisAgent) Execute(ctx context.Context, in MantisInput) (<-chan types
 			}
 		}
 	}
+	if model.Vision {
+		attachImages(messages, artifacts)
+	}
 
-	ch, err := a.agent.Execute(ctx, agent.AgentInput{
+	ch, err := a.agent.Execute(shared.WithUsageCaller(ctx, shared.UsageCallerSupervisor), agent.AgentInput{
 		LoopInput: agent.LoopInput{
 			ActionInput: agent.ActionInput{
+				ConnectionID: conn.ID,
 				Provider:     conn.Provider,
 				BaseURL:      conn.BaseURL,
 				APIKey:       conn.APIKey,
+				ModelID:      model.ID,
 				Model:        model.Name,
 				Messages:     messages,
 				Tools:        tools,
 				ThinkingMode: model.ThinkingMode,
+				ToolCalling:  model.ToolCalling,
+				Vision:       model.Vision,
+				Options:      model.Generation.Merge(preset.GenerationOptions()),
+				Fallbacks:    a.fallbackChain(ctx, in.PresetID, model.ID),
 			},
 			MaxIterations: a.limits.SupervisorMaxIterations,
 			MessageID:     in.RequestID,
 			ToolsProvider: toolsProvider,
+			EscalateTo:    a.escalationTarget(ctx, in.PresetID, in.EscalateTo),
+			EscalateAfter: in.EscalateAfter,
 		},
 	})
 	if err != nil {
@@ -328,6 +381,32 @@ func (a *MantisAgent) Execute(ctx context.Context, in MantisInput) (<-chan types
 	return ch, nil
 }
 
+// attachImages adds the images attached in the current turn to the last user
+// message as content parts, so a model that takes images sees them directly
+// instead of describing them with artifact_read_image.
+func attachImages(messages []protocols.LLMMessage, artifacts *shared.ArtifactStore) {
+	last := len(messages) - 1
+	for last >= 0 && messages[last].Role != "user" {
+		last--
+	}
+	if last < 0 {
+		return
+	}
+	var images []types.ContentPart
+	for _, m := range attachmentRe.FindAllStringSubmatch(messages[last].Content, -1) {
+		art, ok := artifacts.Get(html.UnescapeString(m[1]))
+		if !ok || !inlineImageMIME[art.MIME] || len(art.Bytes) > maxInlineImageBytes {
+			continue
+		}
+		images = append(images, types.ImagePart(art.MIME, art.Bytes))
+	}
+	if len(images) == 0 {
+		return
+	}
+	text := messages[last].Content + "\n\n[The attached images are included in this message.]"
+	messages[last].Parts = append([]types.ContentPart{types.TextPart(text)}, images...)
+}
+
 func (a *MantisAgent) loadUserMemories() []string {
 	if a.settingsStore == nil {
 		return nil
@@ -352,7 +431,7 @@ func (a *MantisAgent) loadUserMemories() []string {
 	return facts
 }
 
-func (a *MantisAgent) buildSystemPrompt(connections []types.Connection, artifacts *shared.ArtifactStore, source, replyChannel, replyTo string) string {
+func (a *MantisAgent) buildSystemPrompt(connections []types.Connection, artifacts *shared.ArtifactStore, source, replyChannel, replyTo, presetPrompt string) string {
 	var sb strings.Builder
 	prompt := mantisBasePrompt
 	prompt = strings.Replace(prompt,
@@ -380,6 +459,11 @@ func (a *MantisAgent) buildSystemPrompt(connections []types.Connection, artifact
 		}
 	}
 
+	if presetPrompt = strings.TrimSpace(presetPrompt); presetPrompt != "" {
+		sb.WriteString("\n\nAdditional instructions:\n")
+		sb.WriteString(presetPrompt)
+	}
+
 	if source != "" || replyChannel != "" || replyTo != "" {
 		sb.WriteString("\n\nRequest context:")
 		if source != "" {
@@ -487,9 +571,13 @@ func (a *MantisAgent) buildTools(connections []types.Connection, skills []types.
 			a.planGetTool(),
 			a.planRunTool(),
 			a.planCreateTool(),
+			a.planTemplateListTool(),
+			a.planFromTemplateTool(),
 			a.planUpdateTool(),
 			a.planDeleteTool(),
 			a.planActiveTool(),
+			a.planRunsTool(),
+			a.planRunGetTool(),
 			a.planStopTool(),
 		)
 	}
@@ -535,6 +623,88 @@ func presetChatModelID(p types.Preset) (string, string) {
 	return "", ""
 }
 
+// fallbackChain resolves the models a call to modelID fails over to: the
+// preset's chat, fallback and further fallback models that come after it.
+// Models that no longer resolve are left out.
+func (a *MantisAgent) fallbackChain(ctx context.Context, presetID, modelID string) []agent.Fallback {
+	p, ok := a.resolvePreset(ctx, presetID)
+	if !ok {
+		return nil
+	}
+	chain := append([]string{p.ChatModelID, p.FallbackModelID}, p.FallbackModelIDs...)
+	for i, id := range chain {
+		if strings.TrimSpace(id) == modelID {
+			chain = chain[i+1:]
+			break
+		}
+	}
+	seen := map[string]bool{modelID: true}
+	var out []agent.Fallback
+	for _, id := range chain {
+		id = strings.TrimSpace(id)
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		if f, ok := a.resolveFallback(ctx, id, p); ok {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// escalationTarget resolves the model a routed request escalates to. It is
+// resolved on its own: the routed model may itself sit in the preset's
+// fallback chain, which then no longer holds the model to escalate to.
+func (a *MantisAgent) escalationTarget(ctx context.Context, presetID, modelID string) *agent.Fallback {
+	if modelID = strings.TrimSpace(modelID); modelID == "" {
+		return nil
+	}
+	p, _ := a.resolvePreset(ctx, presetID)
+	f, ok := a.resolveFallback(ctx, modelID, p)
+	if !ok {
+		return nil
+	}
+	return &f
+}
+
+// resolveFallback loads a model and its connection as a target to move a
+// call to, with the preset's generation options applied.
+func (a *MantisAgent) resolveFallback(ctx context.Context, modelID string, p types.Preset) (agent.Fallback, bool) {
+	model, err := shared.ResolveModel(ctx, a.modelStore, modelID)
+	if err != nil {
+		return agent.Fallback{}, false
+	}
+	conn, err := shared.ResolveConnection(ctx, a.llmConnStore, model.ConnectionID)
+	if err != nil {
+		return agent.Fallback{}, false
+	}
+	return agent.Fallback{
+		ConnectionID: conn.ID, Provider: conn.Provider, BaseURL: conn.BaseURL, APIKey: conn.APIKey,
+		ModelID: model.ID, ModelName: model.Name, ThinkingMode: model.ThinkingMode, ToolCalling: model.ToolCalling, Vision: model.Vision,
+		Options: model.Generation.Merge(p.GenerationOptions()),
+	}, true
+}
+
+// resolvePreset loads preset id, reporting false when there is none.
+func (a *MantisAgent) resolvePreset(ctx context.Context, id string) (types.Preset, bool) {
+	if strings.TrimSpace(id) == "" || a.presetStore == nil {
+		return types.Preset{}, false
+	}
+	p, err := shared.ResolvePreset(ctx, a.presetStore, id)
+	if err != nil {
+		return types.Preset{}, false
+	}
+	return p, true
+}
+
+// generationOptions returns the model's generation options overridden by
+// those of preset presetID.
+func (a *MantisAgent) generationOptions(ctx context.Context, presetID string, model types.Model) types.GenerationOptions {
+	p, _ := a.resolvePreset(ctx, presetID)
+	return model.Generation.Merge(p.GenerationOptions())
+}
+
 func presetImageModelID(p types.Preset) (string, string) {
 	if strings.TrimSpace(p.ImageModelID) != "" {
 		return strings.TrimSpace(p.ImageModelID), "primary"
@@ -0,0 +1,40 @@
+package agents
+
+import (
+	"context"
+
+	"mantis/core/types"
+)
+
+// memStore is a read-only in-memory store for tests, keyed by ID; List
+// returns items in the order they were given and honours the page limit.
+type memStore[T any] struct {
+	items []T
+	id    func(T) string
+	query types.ListQuery
+}
+
+func (s *memStore[T]) Create(_ context.Context, items []T) ([]T, error) { return items, nil }
+
+func (s *memStore[T]) Get(_ context.Context, ids []string) (map[string]T, error) {
+	out := make(map[string]T)
+	for _, id := range ids {
+		for _, it := range s.items {
+			if s.id(it) == id {
+				out[id] = it
+			}
+		}
+	}
+	return out, nil
+}
+
+func (s *memStore[T]) List(_ context.Context, q types.ListQuery) ([]T, error) {
+	s.query = q
+	if q.Page.Limit > 0 && len(s.items) > q.Page.Limit {
+		return s.items[:q.Page.Limit], nil
+	}
+	return s.items, nil
+}
+
+func (s *memStore[T]) Update(_ context.Context, items []T) ([]T, error) { return items, nil }
+func (s *memStore[T]) Delete(_ context.Context, _ []string) error       { return nil }
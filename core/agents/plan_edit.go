@@ -0,0 +1,509 @@
+package agents
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"mantis/core/types"
+)
+
+// planEdit is one step-level change requested through plan_update.
+type planEdit struct {
+	Op     string    `json:"op"`
+	ID     string    `json:"id,omitempty"`
+	After  string    `json:"after,omitempty"`
+	Branch string    `json:"branch,omitempty"`
+	Target string    `json:"target,omitempty"`
+	Step   *planStep `json:"step,omitempty"`
+}
+
+const (
+	planEditInsert  = "insert"
+	planEditReplace = "replace"
+	planEditRemove  = "remove"
+	planEditRewire  = "rewire"
+
+	stepEnd   = "end"
+	stepStart = "start"
+)
+
+const planLayoutGap = 150
+
+var planEditSchema = map[string]any{
+	"type":        "array",
+	"description": "Step-level edits, applied in order. Use plan_get to see step ids. insert: add 'step' after step 'after' ('start' for the beginning; for a decision pick the 'branch'), the new step continues where 'after' used to go. replace: change the prompt/label/type/branches of step 'id'. remove: delete step 'id'; anything pointing at it continues to its next step (decision: its yes branch). rewire: point 'branch' (yes/no for decisions, approve/reject for approvals, next for actions) of step 'id' at 'target' (step id or 'end').",
+	"items": map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"op":     map[string]any{"type": "string", "enum": []string{planEditInsert, planEditReplace, planEditRemove, planEditRewire}},
+			"id":     map[string]any{"type": "string", "description": "Step to replace, remove or rewire"},
+			"after":  map[string]any{"type": "string", "description": "insert: step id to insert after, or 'start'"},
+			"branch": map[string]any{"type": "string", "description": "rewire, or insert after a decision/approval: yes, no, approve, reject or next"},
+			"target": map[string]any{"type": "string", "description": "rewire: step id or 'end'"},
+			"step":   planStepSchema,
+		},
+		"required": []string{"op"},
+	},
+}
+
+// graphToSteps turns a plan graph back into the step DSL. Steps are ordered
+// by walking the flow from its start nodes (main branch first) and every
+// branch is spelled out as a step id or "end", so the list can be edited and
+// reordered without changing where each step goes.
+func graphToSteps(graph types.PlanGraph) []planStep {
+	nodes := make(map[string]types.PlanNode, len(graph.Nodes))
+	incoming := make(map[string]bool, len(graph.Edges))
+	for _, n := range graph.Nodes {
+		nodes[n.ID] = n
+	}
+	for _, e := range graph.Edges {
+		incoming[e.Target] = true
+	}
+
+	branches := func(n types.PlanNode) (string, string, string) {
+		var yes, no, next string
+		for _, e := range graph.Edges {
+			if e.Source != n.ID {
+				continue
+			}
+			switch strings.ToLower(strings.TrimSpace(e.Label)) {
+			case types.PlanEdgeError:
+			case "yes", types.PlanEdgeApprove:
+				yes = e.Target
+			case "no", types.PlanEdgeReject:
+				no = e.Target
+			default:
+				if n.Type == types.PlanNodeAction {
+					next = e.Target
+				} else if yes == "" {
+					yes = e.Target
+				}
+			}
+		}
+		return yes, no, next
+	}
+
+	var order []string
+	visited := make(map[string]bool, len(graph.Nodes))
+	var visit func(id string)
+	visit = func(id string) {
+		n, ok := nodes[id]
+		if !ok || visited[id] {
+			return
+		}
+		visited[id] = true
+		order = append(order, id)
+		yes, no, next := branches(n)
+		visit(next)
+		visit(yes)
+		visit(no)
+	}
+	for _, n := range graph.Nodes {
+		if !incoming[n.ID] {
+			visit(n.ID)
+		}
+	}
+	for _, n := range graph.Nodes {
+		visit(n.ID)
+	}
+
+	steps := make([]planStep, 0, len(order))
+	for _, id := range order {
+		n := nodes[id]
+		yes, no, next := branches(n)
+		step := planStep{ID: n.ID, Type: string(n.Type), Label: n.Label, Prompt: n.Prompt}
+		if n.Type == types.PlanNodeAction {
+			step.Next = orEnd(next)
+		} else {
+			step.Yes, step.No = orEnd(yes), orEnd(no)
+		}
+		steps = append(steps, step)
+	}
+	return steps
+}
+
+func orEnd(target string) string {
+	if target == "" {
+		return stepEnd
+	}
+	return target
+}
+
+// applyPlanEdits applies edits to a step list produced by graphToSteps.
+func applyPlanEdits(steps []planStep, edits []planEdit) ([]planStep, error) {
+	steps = slices.Clone(steps)
+	for i, edit := range edits {
+		var err error
+		switch strings.ToLower(strings.TrimSpace(edit.Op)) {
+		case planEditInsert:
+			steps, err = insertStep(steps, edit)
+		case planEditReplace:
+			err = replaceStep(steps, edit)
+		case planEditRemove:
+			steps, err = removeStep(steps, edit.ID)
+		case planEditRewire:
+			err = rewireStep(steps, edit)
+		default:
+			err = fmt.Errorf("unknown op %q (use insert, replace, remove or rewire)", edit.Op)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("edit %d: %w", i+1, err)
+		}
+	}
+	return steps, nil
+}
+
+func insertStep(steps []planStep, edit planEdit) ([]planStep, error) {
+	if edit.Step == nil {
+		return nil, fmt.Errorf("insert needs a step")
+	}
+	step := *edit.Step
+	if err := checkStepType(step.Type); err != nil {
+		return nil, err
+	}
+	step.Prompt = strings.TrimSpace(step.Prompt)
+	step.ID = strings.TrimSpace(step.ID)
+	if step.ID == "" {
+		step.ID = newStepID(steps)
+	} else if stepIndex(steps, step.ID) >= 0 || step.ID == stepEnd || step.ID == stepStart || step.ID == "next" {
+		return nil, fmt.Errorf("step id %q is already taken", step.ID)
+	}
+
+	after := strings.TrimSpace(edit.After)
+	if after == "" {
+		return nil, fmt.Errorf("insert needs 'after' (a step id or 'start')")
+	}
+	pos := 0
+	continueTo := stepEnd
+	if after == stepStart {
+		if len(steps) > 0 {
+			continueTo = steps[0].ID
+		}
+	} else {
+		idx := stepIndex(steps, after)
+		if idx < 0 {
+			return nil, fmt.Errorf("unknown step %q", after)
+		}
+		ref, err := branchRef(&steps[idx], edit.Branch)
+		if err != nil {
+			return nil, err
+		}
+		continueTo = *ref
+		*ref = step.ID
+		pos = idx + 1
+	}
+
+	if err := fillBranches(&step, continueTo, steps); err != nil {
+		return nil, err
+	}
+	return slices.Insert(steps, pos, step), nil
+}
+
+func replaceStep(steps []planStep, edit planEdit) error {
+	if edit.Step == nil {
+		return fmt.Errorf("replace needs a step")
+	}
+	idx := stepIndex(steps, edit.ID)
+	if idx < 0 {
+		return fmt.Errorf("unknown step %q", edit.ID)
+	}
+	old := steps[idx]
+	step := *edit.Step
+	if step.Type == "" {
+		step.Type = old.Type
+	}
+	if err := checkStepType(step.Type); err != nil {
+		return err
+	}
+	step.ID = old.ID
+	step.Prompt = strings.TrimSpace(step.Prompt)
+	if step.Prompt == "" {
+		step.Prompt = old.Prompt
+	}
+	if step.Label == "" {
+		step.Label = old.Label
+	}
+	continueTo := old.Next
+	if old.Type != string(types.PlanNodeAction) {
+		continueTo = old.Yes
+		if step.Type != string(types.PlanNodeAction) && step.No == "" {
+			step.No = old.No
+		}
+	}
+	if err := fillBranches(&step, continueTo, steps); err != nil {
+		return err
+	}
+	steps[idx] = step
+	return nil
+}
+
+// removeStep drops a step and sends everything that pointed at it to where
+// it would have continued: the next step of an action, the yes branch of a
+// decision or approval.
+func removeStep(steps []planStep, id string) ([]planStep, error) {
+	idx := stepIndex(steps, id)
+	if idx < 0 {
+		return nil, fmt.Errorf("unknown step %q", id)
+	}
+	removed := steps[idx]
+	successor := removed.Next
+	if removed.Type != string(types.PlanNodeAction) {
+		successor = removed.Yes
+	}
+	if successor == "" || successor == id {
+		successor = stepEnd
+	}
+	steps = slices.Delete(steps, idx, idx+1)
+	for i := range steps {
+		for _, ref := range []*string{&steps[i].Yes, &steps[i].No, &steps[i].Next} {
+			if *ref == id {
+				*ref = successor
+			}
+		}
+	}
+	return steps, nil
+}
+
+func rewireStep(steps []planStep, edit planEdit) error {
+	idx := stepIndex(steps, edit.ID)
+	if idx < 0 {
+		return fmt.Errorf("unknown step %q", edit.ID)
+	}
+	target := strings.TrimSpace(edit.Target)
+	if target == "" {
+		return fmt.Errorf("rewire needs a target (step id or 'end')")
+	}
+	if target != stepEnd && stepIndex(steps, target) < 0 {
+		return fmt.Errorf("unknown target step %q", target)
+	}
+	if target == edit.ID {
+		return fmt.Errorf("step %q cannot point at itself", edit.ID)
+	}
+	ref, err := branchRef(&steps[idx], edit.Branch)
+	if err != nil {
+		return err
+	}
+	*ref = target
+	return nil
+}
+
+// branchRef returns the branch field of a step an edit refers to. Actions
+// only have "next"; an empty branch means next for actions and yes/approve
+// for decisions and approvals.
+func branchRef(step *planStep, branch string) (*string, error) {
+	branch = strings.ToLower(strings.TrimSpace(branch))
+	if step.Type == string(types.PlanNodeAction) {
+		if branch != "" && branch != "next" {
+			return nil, fmt.Errorf("step %q is an action and only has a 'next' branch", step.ID)
+		}
+		return &step.Next, nil
+	}
+	switch branch {
+	case "", "yes", types.PlanEdgeApprove:
+		return &step.Yes, nil
+	case "no", types.PlanEdgeReject:
+		return &step.No, nil
+	default:
+		return nil, fmt.Errorf("step %q is a %s: use branch yes/no (approve/reject)", step.ID, step.Type)
+	}
+}
+
+// fillBranches resolves the branches of a new or replaced step to explicit
+// step ids: omitted or "next" continues to continueTo, and the no branch of
+// a decision defaults to the end of the plan.
+func fillBranches(step *planStep, continueTo string, steps []planStep) error {
+	resolve := func(ref, fallback string) (string, error) {
+		ref = strings.TrimSpace(ref)
+		switch {
+		case ref == "" || ref == "next":
+			return fallback, nil
+		case ref == stepEnd || ref == "stop":
+			return stepEnd, nil
+		case ref == step.ID:
+			return "", fmt.Errorf("step %q cannot point at itself", step.ID)
+		case stepIndex(steps, ref) < 0:
+			return "", fmt.Errorf("unknown target step %q", ref)
+		}
+		return ref, nil
+	}
+	var err error
+	if step.Type == string(types.PlanNodeAction) {
+		step.Yes, step.No = "", ""
+		step.Next, err = resolve(step.Next, continueTo)
+		return err
+	}
+	step.Next = ""
+	if step.Yes, err = resolve(step.Yes, continueTo); err != nil {
+		return err
+	}
+	step.No, err = resolve(step.No, stepEnd)
+	return err
+}
+
+func checkStepType(t string) error {
+	switch types.PlanNodeType(t) {
+	case types.PlanNodeAction, types.PlanNodeDecision, types.PlanNodeApproval:
+		return nil
+	}
+	return fmt.Errorf("unknown step type %q (use action, decision or approval)", t)
+}
+
+func stepIndex(steps []planStep, id string) int {
+	id = strings.TrimSpace(id)
+	for i, s := range steps {
+		if s.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func newStepID(steps []planStep) string {
+	for i := len(steps) + 1; ; i++ {
+		id := fmt.Sprintf("n%d", i)
+		if stepIndex(steps, id) < 0 {
+			return id
+		}
+	}
+}
+
+// editPlanSteps applies step edits to a plan graph and checks the result
+// with the plan runner before anything is saved. The step DSL has no loops,
+// so plans with a foreach node are left to the plan editor.
+func (a *MantisAgent) editPlanSteps(graph types.PlanGraph, edits []planEdit) (types.PlanGraph, error) {
+	for _, n := range graph.Nodes {
+		if n.Type == types.PlanNodeForeach {
+			return types.PlanGraph{}, fmt.Errorf("step %q is a foreach loop, which step edits cannot change; edit this plan in the plan editor", n.ID)
+		}
+	}
+	steps, err := applyPlanEdits(graphToSteps(graph), edits)
+	if err != nil {
+		return types.PlanGraph{}, err
+	}
+	edited, err := rebuildGraph(graph, steps)
+	if err != nil {
+		return types.PlanGraph{}, err
+	}
+	if a.planRunner != nil {
+		if err := a.planRunner.ValidateGraph(edited); err != nil {
+			return types.PlanGraph{}, fmt.Errorf("edited plan is invalid: %w", err)
+		}
+	}
+	return edited, nil
+}
+
+// rebuildGraph builds the graph for edited steps with stepsToGraph and then
+// carries over what the step DSL does not describe: node ids, canvas
+// positions, per-node settings (timeouts, retries, clear context, pinned
+// model) and error edges between nodes that still exist. New nodes are
+// placed where the step they follow was, pushing the nodes below them down.
+func rebuildGraph(old types.PlanGraph, steps []planStep) (types.PlanGraph, error) {
+	graph, err := stepsToGraph(steps)
+	if err != nil {
+		return types.PlanGraph{}, err
+	}
+	rename := make(map[string]string, len(steps))
+	for i, s := range steps {
+		rename[fmt.Sprintf("n%d", i+1)] = s.ID
+	}
+	oldNodes := make(map[string]types.PlanNode, len(old.Nodes))
+	for _, n := range old.Nodes {
+		oldNodes[n.ID] = n
+	}
+
+	positions := make(map[string]planPoint, len(graph.Nodes))
+	for _, n := range old.Nodes {
+		if p, ok := parsePoint(n.Position); ok {
+			positions[n.ID] = p
+		}
+	}
+
+	for i := range graph.Nodes {
+		n := &graph.Nodes[i]
+		n.ID = rename[n.ID]
+		prev, ok := oldNodes[n.ID]
+		if !ok {
+			continue
+		}
+		n.Position = prev.Position
+		n.ClearContext = prev.ClearContext
+		n.MaxRetries = prev.MaxRetries
+		n.Timeout = prev.Timeout
+		n.RetryDelay = prev.RetryDelay
+		n.RetryOn = prev.RetryOn
+		n.PresetID = prev.PresetID
+		n.ModelID = prev.ModelID
+		if n.Type == types.PlanNodeApproval {
+			n.OnTimeout = prev.OnTimeout
+		}
+	}
+	for i := range graph.Edges {
+		graph.Edges[i].Source = rename[graph.Edges[i].Source]
+		graph.Edges[i].Target = rename[graph.Edges[i].Target]
+	}
+
+	placeNewNodes(graph, steps, oldNodes, positions)
+
+	exists := make(map[string]bool, len(graph.Nodes))
+	for _, n := range graph.Nodes {
+		exists[n.ID] = true
+	}
+	for _, e := range old.Edges {
+		if strings.EqualFold(strings.TrimSpace(e.Label), types.PlanEdgeError) && exists[e.Source] && exists[e.Target] {
+			e.ID = fmt.Sprintf("e%d", len(graph.Edges)+1)
+			graph.Edges = append(graph.Edges, e)
+		}
+	}
+	return graph, nil
+}
+
+type planPoint struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+func parsePoint(raw json.RawMessage) (planPoint, bool) {
+	var p planPoint
+	if len(raw) == 0 || json.Unmarshal(raw, &p) != nil {
+		return planPoint{}, false
+	}
+	return p, true
+}
+
+func placeNewNodes(graph types.PlanGraph, steps []planStep, oldNodes map[string]types.PlanNode, positions map[string]planPoint) {
+	if len(positions) == 0 {
+		return
+	}
+	placed := false
+	for i := range graph.Nodes {
+		n := &graph.Nodes[i]
+		if _, ok := oldNodes[n.ID]; ok {
+			continue
+		}
+		at := planPoint{X: 250}
+		if i > 0 {
+			if prev, ok := positions[steps[i-1].ID]; ok {
+				at = planPoint{X: prev.X, Y: prev.Y + planLayoutGap}
+			}
+		} else if first, ok := positions[steps[min(1, len(steps)-1)].ID]; ok {
+			at = first
+		}
+		for id, p := range positions {
+			if p.Y >= at.Y {
+				positions[id] = planPoint{X: p.X, Y: p.Y + planLayoutGap}
+			}
+		}
+		positions[n.ID] = at
+		placed = true
+	}
+	if !placed {
+		return
+	}
+	for i := range graph.Nodes {
+		if p, ok := positions[graph.Nodes[i].ID]; ok {
+			graph.Nodes[i].Position, _ = json.Marshal(p)
+		}
+	}
+}
@@ -0,0 +1,274 @@
+package agents
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"mantis/core/types"
+)
+
+func editableGraph() types.PlanGraph {
+	pos := func(y int) json.RawMessage { return json.RawMessage(fmt.Sprintf(`{"x":100,"y":%d}`, y)) }
+	return types.PlanGraph{
+		Nodes: []types.PlanNode{
+			{ID: "check", Type: types.PlanNodeAction, Label: "Check disk", Prompt: "Check disk usage", Position: pos(0), MaxRetries: 2, PresetID: "local"},
+			{ID: "full", Type: types.PlanNodeDecision, Label: "Disk full?", Prompt: "Is the disk over 90%?", Position: pos(150)},
+			{ID: "clean", Type: types.PlanNodeAction, Label: "Clean up", Prompt: "Remove old logs", Position: pos(300), Timeout: "5m"},
+			{ID: "report", Type: types.PlanNodeAction, Label: "Report", Prompt: "Send a report", Position: pos(450)},
+			{ID: "alert", Type: types.PlanNodeAction, Label: "Alert", Prompt: "Alert on-call", Position: pos(600)},
+		},
+		Edges: []types.PlanEdge{
+			{ID: "e1", Source: "check", Target: "full"},
+			{ID: "e2", Source: "full", Target: "clean", Label: "yes"},
+			{ID: "e3", Source: "full", Target: "report", Label: "no"},
+			{ID: "e4", Source: "clean", Target: "report"},
+			{ID: "e5", Source: "clean", Target: "alert", Label: "error"},
+		},
+	}
+}
+
+func edgeTargets(g types.PlanGraph) map[string]string {
+	out := make(map[string]string)
+	for _, e := range g.Edges {
+		out[e.Source+":"+e.Label] = e.Target
+	}
+	return out
+}
+
+func nodeByID(t *testing.T, g types.PlanGraph, id string) types.PlanNode {
+	t.Helper()
+	for _, n := range g.Nodes {
+		if n.ID == id {
+			return n
+		}
+	}
+	t.Fatalf("node %q not found", id)
+	return types.PlanNode{}
+}
+
+func editGraph(t *testing.T, g types.PlanGraph, edits ...planEdit) types.PlanGraph {
+	t.Helper()
+	steps, err := applyPlanEdits(graphToSteps(g), edits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := rebuildGraph(g, steps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+// --- graphToSteps ---
+
+func TestGraphToSteps_FollowsFlow(t *testing.T) {
+	steps := graphToSteps(editableGraph())
+	var ids []string
+	for _, s := range steps {
+		ids = append(ids, s.ID)
+	}
+	if got := strings.Join(ids, ","); got != "check,full,clean,report,alert" {
+		t.Fatalf("unexpected order %s", got)
+	}
+	if steps[1].Yes != "clean" || steps[1].No != "report" {
+		t.Fatalf("decision branches lost: %+v", steps[1])
+	}
+	if steps[2].Next != "report" || steps[3].Next != stepEnd {
+		t.Fatalf("error edges must not become next: %+v %+v", steps[2], steps[3])
+	}
+}
+
+func TestRebuildGraph_NoEditsKeepsGraph(t *testing.T) {
+	g := editableGraph()
+	out := editGraph(t, g)
+	if len(out.Nodes) != len(g.Nodes) || len(out.Edges) != len(g.Edges) {
+		t.Fatalf("expected %d nodes/%d edges, got %d/%d", len(g.Nodes), len(g.Edges), len(out.Nodes), len(out.Edges))
+	}
+	want := edgeTargets(g)
+	for k, v := range edgeTargets(out) {
+		if want[k] != v {
+			t.Fatalf("edge %s: expected %s, got %s", k, want[k], v)
+		}
+	}
+	for _, n := range g.Nodes {
+		got := nodeByID(t, out, n.ID)
+		if string(got.Position) != string(n.Position) || got.MaxRetries != n.MaxRetries || got.Timeout != n.Timeout || got.PresetID != n.PresetID {
+			t.Fatalf("node %s changed: %+v", n.ID, got)
+		}
+	}
+}
+
+// --- applyPlanEdits ---
+
+func TestPlanEdit_InsertAfterAction(t *testing.T) {
+	out := editGraph(t, editableGraph(), planEdit{
+		Op: planEditInsert, After: "clean",
+		Step: &planStep{ID: "verify", Type: "action", Prompt: "Check disk again"},
+	})
+	edges := edgeTargets(out)
+	if edges["clean:"] != "verify" || edges["verify:"] != "report" {
+		t.Fatalf("insert not wired: %v", edges)
+	}
+	if edges["clean:error"] != "alert" {
+		t.Fatalf("error edge lost: %v", edges)
+	}
+	var p planPoint
+	_ = json.Unmarshal(nodeByID(t, out, "verify").Position, &p)
+	if p.Y != 450 {
+		t.Fatalf("new node should take the slot below clean, got %+v", p)
+	}
+	_ = json.Unmarshal(nodeByID(t, out, "report").Position, &p)
+	if p.Y != 600 {
+		t.Fatalf("nodes below should move down, got %+v", p)
+	}
+	_ = json.Unmarshal(nodeByID(t, out, "check").Position, &p)
+	if p.Y != 0 {
+		t.Fatalf("nodes above should stay, got %+v", p)
+	}
+}
+
+func TestPlanEdit_InsertOnDecisionBranch(t *testing.T) {
+	out := editGraph(t, editableGraph(), planEdit{
+		Op: planEditInsert, After: "full", Branch: "no",
+		Step: &planStep{Type: "action", Prompt: "Log that all is fine"},
+	})
+	edges := edgeTargets(out)
+	added := edges["full:no"]
+	if added == "" || added == "report" || edges[added+":"] != "report" {
+		t.Fatalf("expected a new step between full and report, got %v", edges)
+	}
+	if edges["full:yes"] != "clean" {
+		t.Fatalf("yes branch should be unchanged: %v", edges)
+	}
+}
+
+func TestPlanEdit_InsertAtStart(t *testing.T) {
+	out := editGraph(t, editableGraph(), planEdit{
+		Op: planEditInsert, After: stepStart,
+		Step: &planStep{ID: "login", Type: "action", Prompt: "Log in"},
+	})
+	if edgeTargets(out)["login:"] != "check" {
+		t.Fatalf("expected login -> check, got %v", edgeTargets(out))
+	}
+}
+
+func TestPlanEdit_Replace(t *testing.T) {
+	out := editGraph(t, editableGraph(), planEdit{
+		Op: planEditReplace, ID: "clean",
+		Step: &planStep{Prompt: "Remove logs older than 7 days"},
+	})
+	n := nodeByID(t, out, "clean")
+	if n.Prompt != "Remove logs older than 7 days" || n.Label != "Clean up" || n.Timeout != "5m" {
+		t.Fatalf("unexpected node after replace: %+v", n)
+	}
+	if edgeTargets(out)["clean:"] != "report" {
+		t.Fatal("replace should keep where the step goes")
+	}
+}
+
+func TestPlanEdit_ReplaceActionWithApproval(t *testing.T) {
+	out := editGraph(t, editableGraph(), planEdit{
+		Op: planEditReplace, ID: "clean",
+		Step: &planStep{Type: "approval", Prompt: "Delete old logs?", Yes: "report", No: "alert"},
+	})
+	edges := edgeTargets(out)
+	if edges["clean:approve"] != "report" || edges["clean:reject"] != "alert" {
+		t.Fatalf("unexpected approval edges: %v", edges)
+	}
+}
+
+func TestPlanEdit_Remove(t *testing.T) {
+	out := editGraph(t, editableGraph(), planEdit{Op: planEditRemove, ID: "clean"})
+	edges := edgeTargets(out)
+	if edges["full:yes"] != "report" {
+		t.Fatalf("references should move to the removed step's successor: %v", edges)
+	}
+	for _, e := range out.Edges {
+		if e.Source == "clean" || e.Target == "clean" {
+			t.Fatalf("edge to removed node kept: %+v", e)
+		}
+	}
+	var p planPoint
+	_ = json.Unmarshal(nodeByID(t, out, "report").Position, &p)
+	if p.Y != 450 {
+		t.Fatalf("remaining nodes should keep their positions, got %+v", p)
+	}
+}
+
+func TestPlanEdit_Rewire(t *testing.T) {
+	out := editGraph(t, editableGraph(), planEdit{Op: planEditRewire, ID: "full", Branch: "no", Target: stepEnd})
+	edges := edgeTargets(out)
+	if _, ok := edges["full:no"]; ok {
+		t.Fatalf("no branch should end the plan: %v", edges)
+	}
+	if edges["full:yes"] != "clean" {
+		t.Fatalf("yes branch should be unchanged: %v", edges)
+	}
+}
+
+func TestPlanEdit_Errors(t *testing.T) {
+	steps := graphToSteps(editableGraph())
+	cases := []planEdit{
+		{Op: "move", ID: "clean"},
+		{Op: planEditRemove, ID: "missing"},
+		{Op: planEditInsert, Step: &planStep{Type: "action", Prompt: "x"}},
+		{Op: planEditInsert, After: "clean", Step: &planStep{ID: "report", Type: "action", Prompt: "x"}},
+		{Op: planEditInsert, After: "clean", Step: &planStep{Type: "loop", Prompt: "x"}},
+		{Op: planEditRewire, ID: "clean", Branch: "yes", Target: "report"},
+		{Op: planEditRewire, ID: "full", Branch: "no", Target: "nowhere"},
+		{Op: planEditRewire, ID: "full", Branch: "no", Target: "full"},
+	}
+	for _, edit := range cases {
+		if _, err := applyPlanEdits(steps, []planEdit{edit}); err == nil {
+			t.Fatalf("expected error for %+v", edit)
+		}
+	}
+}
+
+// --- plan_update edits ---
+
+func TestPlanUpdateTool_Edits(t *testing.T) {
+	store := &planStoreMock{plans: map[string]types.Plan{
+		"p1": {ID: "p1", Name: "Disk", Graph: editableGraph()},
+	}}
+	agent := newAgentWithPlanStore(store)
+	tool := agent.planUpdateTool()
+
+	result, err := tool.Execute(context.Background(), `{"id":"p1","edits":[{"op":"remove","id":"alert"},{"op":"insert","after":"report","step":{"id":"done","type":"action","prompt":"Say done"}}]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out struct {
+		Steps []planStep `json:"steps"`
+	}
+	_ = json.Unmarshal([]byte(result), &out)
+	if len(out.Steps) != 5 {
+		t.Fatalf("expected 5 steps in result, got %s", result)
+	}
+	edges := edgeTargets(store.plans["p1"].Graph)
+	if edges["report:"] != "done" {
+		t.Fatalf("plan graph not saved: %v", edges)
+	}
+	if _, ok := edges["clean:error"]; ok {
+		t.Fatal("error edge to removed node should be dropped")
+	}
+	if got := tool.Label(`{"id":"p1","edits":[{"op":"remove","id":"x"}]}`); got != "Edit plan steps" {
+		t.Fatalf("unexpected label %q", got)
+	}
+}
+
+func TestPlanUpdateTool_EditErrorDoesNotSave(t *testing.T) {
+	store := &planStoreMock{plans: map[string]types.Plan{
+		"p1": {ID: "p1", Name: "Disk", Graph: editableGraph()},
+	}}
+	agent := newAgentWithPlanStore(store)
+	if _, err := agent.planUpdateTool().Execute(context.Background(), `{"id":"p1","edits":[{"op":"remove","id":"missing"}]}`); err == nil {
+		t.Fatal("expected error")
+	}
+	if store.updated != nil {
+		t.Fatal("plan should not be saved when an edit fails")
+	}
+}
@@ -0,0 +1,486 @@
+package agents
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"mantis/core/types"
+)
+
+// Output bounds for plan_runs and plan_run_get. Results and messages keep
+// their beginning, logs their end, where errors usually are.
+const (
+	defaultPlanRunsLimit = 10
+	maxPlanRunsLimit     = 30
+
+	maxRunOutput      = 12000
+	maxStepResult     = 600
+	maxStepMessage    = 1200
+	maxStepTools      = 10
+	maxToolResult     = 300
+	maxStepLogs       = 3
+	maxLogTail        = 1500
+	maxRunItems       = 20
+	maxRunItemResult  = 200
+	maxRunInput       = 500
+	maxRunErrorResult = 200
+)
+
+const runTimeLayout = "2006-01-02 15:04:05 UTC"
+
+type runSummary struct {
+	RunID      string `json:"runId"`
+	Status     string `json:"status"`
+	Trigger    string `json:"trigger"`
+	StartedAt  string `json:"startedAt"`
+	Duration   string `json:"duration,omitempty"`
+	Reason     string `json:"reason,omitempty"`
+	FailedStep string `json:"failedStep,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+type runStepDetail struct {
+	NodeID   string           `json:"nodeId"`
+	Label    string           `json:"label,omitempty"`
+	Type     string           `json:"type,omitempty"`
+	Status   string           `json:"status"`
+	Attempts int              `json:"attempts,omitempty"`
+	Model    string           `json:"model,omitempty"`
+	Duration string           `json:"duration,omitempty"`
+	Result   string           `json:"result,omitempty"`
+	Message  string           `json:"message,omitempty"`
+	Tools    []runToolCall    `json:"tools,omitempty"`
+	Logs     []runSessionLog  `json:"sshLogs,omitempty"`
+	Items    []runItemSummary `json:"items,omitempty"`
+
+	messageID string
+	logIDs    []string
+}
+
+type runToolCall struct {
+	Tool   string `json:"tool"`
+	Status string `json:"status"`
+	Result string `json:"result,omitempty"`
+}
+
+type runSessionLog struct {
+	Agent  string `json:"agent"`
+	Status string `json:"status"`
+	Tail   string `json:"tail"`
+}
+
+type runItemSummary struct {
+	Index     int    `json:"index"`
+	Item      string `json:"item"`
+	Status    string `json:"status"`
+	Result    string `json:"result,omitempty"`
+	SessionID string `json:"sessionId,omitempty"`
+}
+
+func (a *MantisAgent) planRunsTool() types.Tool {
+	return types.Tool{
+		Name:        "plan_runs",
+		Description: "List past runs of a plan, newest first, with status, trigger, duration and the failing step. Use plan_run_get with a run ID to see why a run failed.",
+		Icon:        "git-branch",
+		Label: func(args string) string {
+			var input struct {
+				PlanID string `json:"planId"`
+			}
+			_ = json.Unmarshal([]byte(args), &input)
+			if input.PlanID != "" {
+				return "Runs of plan " + input.PlanID[:min(8, len(input.PlanID))]
+			}
+			return "Plan runs"
+		},
+		Parameters: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"planId": map[string]any{"type": "string", "description": "Plan ID (see plan_list)"},
+				"status": map[string]any{
+					"type":        "string",
+					"enum":        []string{"running", "waiting", "queued", "deferred", "completed", "failed", "cancelled", "skipped"},
+					"description": "Only runs with this status",
+				},
+				"limit": map[string]any{"type": "integer", "description": fmt.Sprintf("How many runs (default %d, max %d)", defaultPlanRunsLimit, maxPlanRunsLimit)},
+			},
+			"required": []string{"planId"},
+		},
+		Execute: func(ctx context.Context, args string) (string, error) {
+			if a.planRunStore == nil {
+				return "", fmt.Errorf("plan run history is not configured")
+			}
+			var input struct {
+				PlanID string `json:"planId"`
+				Status string `json:"status"`
+				Limit  int    `json:"limit"`
+			}
+			if err := json.Unmarshal([]byte(args), &input); err != nil {
+				return "", err
+			}
+			if strings.TrimSpace(input.PlanID) == "" {
+				return "", fmt.Errorf("planId is required")
+			}
+			limit := input.Limit
+			if limit <= 0 {
+				limit = defaultPlanRunsLimit
+			}
+			limit = min(limit, maxPlanRunsLimit)
+			filter := map[string]string{"plan_id": input.PlanID}
+			if s := strings.TrimSpace(input.Status); s != "" {
+				filter["status"] = s
+			}
+			runs, err := a.planRunStore.List(ctx, types.ListQuery{
+				Filter: filter,
+				Sort:   []types.Sort{{Field: "started_at", Dir: types.SortDirDesc}},
+				Page:   types.Page{Limit: limit},
+			})
+			if err != nil {
+				return "", err
+			}
+			graph := a.planGraph(ctx, input.PlanID)
+			summaries := make([]runSummary, len(runs))
+			for i, r := range runs {
+				summaries[i] = summarizeRun(r, graph)
+			}
+			out, _ := json.Marshal(map[string]any{"planId": input.PlanID, "runs": summaries, "count": len(summaries)})
+			return string(out), nil
+		},
+	}
+}
+
+func (a *MantisAgent) planRunGetTool() types.Tool {
+	return types.Tool{
+		Name:        "plan_run_get",
+		Description: "Inspect one plan run: each step's status, result, an excerpt of the agent's answer, the tools it called and the end of its SSH session logs. Output is size-limited; pass nodeId to see one step in full detail.",
+		Icon:        "git-branch",
+		Label: func(args string) string {
+			var input struct {
+				RunID string `json:"runId"`
+			}
+			_ = json.Unmarshal([]byte(args), &input)
+			if input.RunID != "" {
+				return "Inspect run " + input.RunID[:min(8, len(input.RunID))]
+			}
+			return "Inspect plan run"
+		},
+		Parameters: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"runId":  map[string]any{"type": "string", "description": "Run ID (see plan_runs or plan_active)"},
+				"nodeId": map[string]any{"type": "string", "description": "Only this step"},
+			},
+			"required": []string{"runId"},
+		},
+		Execute: func(ctx context.Context, args string) (string, error) {
+			if a.planRunStore == nil {
+				return "", fmt.Errorf("plan run history is not configured")
+			}
+			var input struct {
+				RunID  string `json:"runId"`
+				NodeID string `json:"nodeId"`
+			}
+			if err := json.Unmarshal([]byte(args), &input); err != nil {
+				return "", err
+			}
+			if strings.TrimSpace(input.RunID) == "" {
+				return "", fmt.Errorf("runId is required")
+			}
+			runs, err := a.planRunStore.Get(ctx, []string{input.RunID})
+			if err != nil {
+				return "", err
+			}
+			run, ok := runs[input.RunID]
+			if !ok {
+				return "", fmt.Errorf("plan run not found: %s", input.RunID)
+			}
+			graph := a.planGraph(ctx, run.PlanID)
+
+			var steps []runStepDetail
+			for _, s := range run.Steps {
+				if input.NodeID != "" && s.NodeID != input.NodeID {
+					continue
+				}
+				if s.Status == "pending" && input.NodeID == "" {
+					continue
+				}
+				steps = append(steps, stepDetail(s, graph))
+			}
+			if input.NodeID != "" && len(steps) == 0 {
+				return "", fmt.Errorf("run %s has no step %q", input.RunID, input.NodeID)
+			}
+			a.attachStepMessages(ctx, steps)
+
+			out := map[string]any{
+				"runId":     run.ID,
+				"planId":    run.PlanID,
+				"status":    run.Status,
+				"trigger":   run.Trigger,
+				"startedAt": run.StartedAt.UTC().Format(runTimeLayout),
+				"steps":     steps,
+			}
+			if run.FinishedAt != nil {
+				out["duration"] = runDuration(run.StartedAt, run.FinishedAt)
+			}
+			if run.Reason != "" {
+				out["reason"] = run.Reason
+			}
+			if len(run.Input) > 0 {
+				raw, _ := json.Marshal(run.Input)
+				out["input"] = truncateForLabel(string(raw), maxRunInput)
+			}
+			return boundRunOutput(out, steps), nil
+		},
+	}
+}
+
+// planGraph returns the graph of a plan for step labels, or an empty graph
+// if the plan is gone.
+func (a *MantisAgent) planGraph(ctx context.Context, planID string) types.PlanGraph {
+	if a.planStore == nil {
+		return types.PlanGraph{}
+	}
+	plans, err := a.planStore.Get(ctx, []string{planID})
+	if err != nil {
+		return types.PlanGraph{}
+	}
+	return plans[planID].Graph
+}
+
+func planNodeByID(graph types.PlanGraph, id string) (types.PlanNode, bool) {
+	for _, n := range graph.Nodes {
+		if n.ID == id {
+			return n, true
+		}
+	}
+	return types.PlanNode{}, false
+}
+
+func summarizeRun(r types.PlanRun, graph types.PlanGraph) runSummary {
+	s := runSummary{
+		RunID:     r.ID,
+		Status:    r.Status,
+		Trigger:   r.Trigger,
+		StartedAt: r.StartedAt.UTC().Format(runTimeLayout),
+		Reason:    r.Reason,
+	}
+	if r.FinishedAt != nil {
+		s.Duration = runDuration(r.StartedAt, r.FinishedAt)
+	}
+	for _, step := range r.Steps {
+		if step.Status != "failed" {
+			continue
+		}
+		s.FailedStep = step.NodeID
+		if n, ok := planNodeByID(graph, step.NodeID); ok && n.Label != "" {
+			s.FailedStep = n.Label + " (" + step.NodeID + ")"
+		}
+		s.Error = truncateForLabel(step.Result, maxRunErrorResult)
+		break
+	}
+	return s
+}
+
+func stepDetail(s types.PlanStepRun, graph types.PlanGraph) runStepDetail {
+	d := runStepDetail{
+		NodeID:    s.NodeID,
+		Status:    s.Status,
+		Attempts:  s.Attempts,
+		Model:     s.ModelName,
+		Result:    truncateForLabel(s.Result, maxStepResult),
+		messageID: s.MessageID,
+	}
+	if n, ok := planNodeByID(graph, s.NodeID); ok {
+		d.Label, d.Type = n.Label, string(n.Type)
+	}
+	if s.StartedAt != nil && s.FinishedAt != nil {
+		d.Duration = runDuration(*s.StartedAt, s.FinishedAt)
+	}
+	for i, it := range s.Items {
+		if i == maxRunItems {
+			break
+		}
+		d.Items = append(d.Items, runItemSummary{
+			Index:     it.Index,
+			Item:      truncateForLabel(itemText(it.Item), 80),
+			Status:    it.Status,
+			Result:    truncateForLabel(it.Result, maxRunItemResult),
+			SessionID: it.SessionID,
+		})
+	}
+	return d
+}
+
+func itemText(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	raw, _ := json.Marshal(v)
+	return string(raw)
+}
+
+// attachStepMessages adds each step's answer, tool calls and SSH session
+// logs from its linked chat message.
+func (a *MantisAgent) attachStepMessages(ctx context.Context, steps []runStepDetail) {
+	if a.messageStore == nil {
+		return
+	}
+	var ids []string
+	for _, s := range steps {
+		if s.messageID != "" {
+			ids = append(ids, s.messageID)
+		}
+	}
+	if len(ids) == 0 {
+		return
+	}
+	messages, err := a.messageStore.Get(ctx, ids)
+	if err != nil {
+		return
+	}
+	var logIDs []string
+	for i := range steps {
+		msg, ok := messages[steps[i].messageID]
+		if !ok {
+			continue
+		}
+		steps[i].Message = truncateForLabel(msg.Content, maxStepMessage)
+		var calls []types.Step
+		_ = json.Unmarshal(msg.Steps, &calls)
+		for _, c := range calls {
+			if len(steps[i].Tools) < maxStepTools {
+				steps[i].Tools = append(steps[i].Tools, runToolCall{
+					Tool:   c.Tool,
+					Status: c.Status,
+					Result: truncateForLabel(c.Result, maxToolResult),
+				})
+			}
+			if c.LogID != "" && len(steps[i].logIDs) < maxStepLogs {
+				steps[i].logIDs = append(steps[i].logIDs, c.LogID)
+				logIDs = append(logIDs, c.LogID)
+			}
+		}
+	}
+	if a.logStore == nil || len(logIDs) == 0 {
+		return
+	}
+	logs, err := a.logStore.Get(ctx, logIDs)
+	if err != nil {
+		return
+	}
+	for i := range steps {
+		for _, id := range steps[i].logIDs {
+			if l, ok := logs[id]; ok {
+				steps[i].Logs = append(steps[i].Logs, runSessionLog{
+					Agent:  l.AgentName,
+					Status: l.Status,
+					Tail:   logTail(l.Entries, maxLogTail),
+				})
+			}
+		}
+	}
+}
+
+// logTail joins session log entries and keeps the last max runes.
+func logTail(entries []types.LogEntry, max int) string {
+	var sb strings.Builder
+	for _, e := range entries {
+		if c := strings.TrimSpace(e.Content); c != "" {
+			fmt.Fprintf(&sb, "[%s] %s\n", e.Type, c)
+		}
+	}
+	r := []rune(strings.TrimSpace(sb.String()))
+	if len(r) <= max {
+		return string(r)
+	}
+	return "..." + string(r[len(r)-max:])
+}
+
+// boundRunOutput marshals a run detail within maxRunOutput bytes. Detail is
+// dropped from healthy steps first, then from all of them, then results are
+// cut short; if that is still too much, healthy steps are left out from the
+// end, and failed ones last. The output says so; a single step asked for by
+// nodeId still goes through the same bound.
+func boundRunOutput(out map[string]any, steps []runStepDetail) string {
+	raw, _ := json.Marshal(out)
+	if len(raw) <= maxRunOutput {
+		return string(raw)
+	}
+	shed := []func(s *runStepDetail, failed bool){
+		func(s *runStepDetail, failed bool) {
+			if !failed {
+				s.Logs, s.Tools, s.Items = nil, nil, nil
+			}
+		},
+		func(s *runStepDetail, failed bool) {
+			if !failed {
+				s.Message = truncateForLabel(s.Message, maxToolResult)
+			}
+		},
+		func(s *runStepDetail, _ bool) {
+			s.Tools = nil
+			for i := range s.Logs {
+				s.Logs[i].Tail = lastRunes(s.Logs[i].Tail, maxLogTail/3)
+			}
+			if len(s.Items) > 5 {
+				s.Items = s.Items[:5]
+			}
+		},
+		func(s *runStepDetail, _ bool) {
+			s.Logs = nil
+			s.Message = truncateForLabel(s.Message, maxToolResult)
+		},
+		func(s *runStepDetail, _ bool) {
+			s.Items = nil
+			s.Result = truncateForLabel(s.Result, maxToolResult)
+			s.Message = truncateForLabel(s.Message, maxRunErrorResult)
+		},
+	}
+	const note = "output was shortened; pass nodeId for one step's details"
+	out["truncated"] = note
+	for _, fn := range shed {
+		for i := range steps {
+			fn(&steps[i], steps[i].Status == "failed")
+		}
+		out["steps"] = steps
+		raw, _ = json.Marshal(out)
+		if len(raw) <= maxRunOutput {
+			return string(raw)
+		}
+	}
+
+	// Still too long: leave steps out, healthy ones from the end first.
+	kept := slices.Clone(steps)
+	omitted := 0
+	for _, failed := range []bool{false, true} {
+		for i := len(kept) - 1; i >= 0 && len(raw) > maxRunOutput; i-- {
+			if (kept[i].Status == "failed") != failed {
+				continue
+			}
+			kept = slices.Delete(kept, i, i+1)
+			omitted++
+			out["steps"] = kept
+			out["truncated"] = fmt.Sprintf("%s; %d of %d steps left out, failed steps kept where possible", note, omitted, len(steps))
+			raw, _ = json.Marshal(out)
+		}
+	}
+	return string(raw)
+}
+
+func lastRunes(s string, max int) string {
+	r := []rune(s)
+	if len(r) <= max {
+		return s
+	}
+	return "..." + string(r[len(r)-max:])
+}
+
+func runDuration(start time.Time, end *time.Time) string {
+	if end == nil {
+		return ""
+	}
+	return end.Sub(start).Round(time.Second).String()
+}
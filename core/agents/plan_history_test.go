@@ -0,0 +1,182 @@
+package agents
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"mantis/core/types"
+)
+
+func historyFixture(result string) (*MantisAgent, *memStore[types.PlanRun]) {
+	started := time.Date(2026, 5, 1, 3, 0, 0, 0, time.UTC)
+	finished := started.Add(90 * time.Second)
+	runs := &memStore[types.PlanRun]{
+		id: func(r types.PlanRun) string { return r.ID },
+		items: []types.PlanRun{{
+			ID: "r1", PlanID: "p1", Status: "failed", Trigger: "schedule",
+			StartedAt: started, FinishedAt: &finished,
+			Steps: []types.PlanStepRun{
+				{NodeID: "a", Status: "completed", Result: "ok", MessageID: "m1"},
+				{NodeID: "b", Status: "failed", Result: result, MessageID: "m2", ModelName: "gpt"},
+				{NodeID: "c", Status: "pending"},
+			},
+		}},
+	}
+	toolSteps, _ := json.Marshal([]types.Step{{Tool: "ssh_web", Status: "error", Result: "exit 1", LogID: "l1"}})
+	messages := &memStore[types.ChatMessage]{
+		id: func(m types.ChatMessage) string { return m.ID },
+		items: []types.ChatMessage{
+			{ID: "m1", Content: "all good"},
+			{ID: "m2", Content: "disk is full", Steps: toolSteps},
+		},
+	}
+	logs := &memStore[types.SessionLog]{
+		id: func(l types.SessionLog) string { return l.ID },
+		items: []types.SessionLog{{ID: "l1", AgentName: "web", Status: "error", Entries: []types.LogEntry{
+			{Type: "command", Content: "df -h"},
+			{Type: "output", Content: "/dev/sda1 100%"},
+		}}},
+	}
+	plans := &planStoreMock{plans: map[string]types.Plan{"p1": {ID: "p1", Graph: types.PlanGraph{
+		Nodes: []types.PlanNode{{ID: "b", Type: types.PlanNodeAction, Label: "Check disk"}},
+	}}}}
+	a := &MantisAgent{planStore: plans, messageStore: messages}
+	a.SetPlanHistory(runs, logs)
+	return a, runs
+}
+
+// --- plan_runs ---
+
+func TestPlanRunsTool(t *testing.T) {
+	a, runs := historyFixture("no space left")
+	result, err := a.planRunsTool().Execute(context.Background(), `{"planId":"p1","status":"failed","limit":500}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runs.query.Page.Limit != maxPlanRunsLimit || runs.query.Filter["status"] != "failed" || runs.query.Filter["plan_id"] != "p1" {
+		t.Fatalf("unexpected query: %+v", runs.query)
+	}
+	var out struct {
+		Runs []runSummary `json:"runs"`
+	}
+	_ = json.Unmarshal([]byte(result), &out)
+	if len(out.Runs) != 1 {
+		t.Fatalf("expected one run, got %s", result)
+	}
+	got := out.Runs[0]
+	if got.Duration != "1m30s" || got.FailedStep != "Check disk (b)" || got.Error != "no space left" {
+		t.Fatalf("unexpected summary: %+v", got)
+	}
+}
+
+// --- plan_run_get ---
+
+func TestPlanRunGetTool(t *testing.T) {
+	a, _ := historyFixture("no space left")
+	result, err := a.planRunGetTool().Execute(context.Background(), `{"runId":"r1"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out struct {
+		Steps     []runStepDetail `json:"steps"`
+		Truncated string          `json:"truncated"`
+	}
+	_ = json.Unmarshal([]byte(result), &out)
+	if len(out.Steps) != 2 {
+		t.Fatalf("expected pending steps to be left out, got %d", len(out.Steps))
+	}
+	failed := out.Steps[1]
+	if failed.Label != "Check disk" || failed.Message != "disk is full" || failed.Model != "gpt" {
+		t.Fatalf("unexpected step: %+v", failed)
+	}
+	if len(failed.Tools) != 1 || failed.Tools[0].Result != "exit 1" {
+		t.Fatalf("expected the tool call, got %+v", failed.Tools)
+	}
+	if len(failed.Logs) != 1 || !strings.HasSuffix(failed.Logs[0].Tail, "[output] /dev/sda1 100%") {
+		t.Fatalf("expected the session log tail, got %+v", failed.Logs)
+	}
+	if out.Truncated != "" {
+		t.Fatal("small output must not be truncated")
+	}
+
+	if _, err := a.planRunGetTool().Execute(context.Background(), `{"runId":"r1","nodeId":"zz"}`); err == nil {
+		t.Fatal("expected an error for an unknown step")
+	}
+	if _, err := a.planRunGetTool().Execute(context.Background(), `{"runId":"nope"}`); err == nil {
+		t.Fatal("expected an error for an unknown run")
+	}
+}
+
+func TestPlanRunGetTool_Bounded(t *testing.T) {
+	a, runs := historyFixture(strings.Repeat("x", 5000))
+	for i := range 40 {
+		runs.items[0].Steps = append(runs.items[0].Steps, types.PlanStepRun{
+			NodeID: "n" + string(rune('a'+i%26)), Status: "completed", Result: strings.Repeat("y", 2000), MessageID: "m2",
+		})
+	}
+	result, err := a.planRunGetTool().Execute(context.Background(), `{"runId":"r1"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out struct {
+		Steps     []runStepDetail `json:"steps"`
+		Truncated string          `json:"truncated"`
+	}
+	_ = json.Unmarshal([]byte(result), &out)
+	if out.Truncated == "" {
+		t.Fatal("expected the output to be marked truncated")
+	}
+	if len(out.Steps[1].Result) > maxStepResult+3 {
+		t.Fatalf("step result not cut: %d bytes", len(out.Steps[1].Result))
+	}
+	if out.Steps[2].Tools != nil {
+		t.Fatal("expected detail dropped from healthy steps")
+	}
+	if len(result) > maxRunOutput {
+		t.Fatalf("output is %d bytes, over the %d bound", len(result), maxRunOutput)
+	}
+
+	// Hundreds of steps: results are cut and healthy steps left out, but the
+	// failed ones stay.
+	for i := range 300 {
+		status := "completed"
+		if i%50 == 0 {
+			status = "failed"
+		}
+		runs.items[0].Steps = append(runs.items[0].Steps, types.PlanStepRun{
+			NodeID: fmt.Sprintf("s%d", i), Status: status, Result: strings.Repeat("z", 700),
+		})
+	}
+	result, err = a.planRunGetTool().Execute(context.Background(), `{"runId":"r1"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result) > maxRunOutput {
+		t.Fatalf("output is %d bytes, over the %d bound", len(result), maxRunOutput)
+	}
+	out.Steps = nil
+	_ = json.Unmarshal([]byte(result), &out)
+	failed := 0
+	for _, s := range out.Steps {
+		if s.Status == "failed" {
+			failed++
+		}
+	}
+	if failed != 7 || !strings.Contains(out.Truncated, "steps left out") {
+		t.Fatalf("expected all 7 failed steps and a note, got %d failed, %q", failed, out.Truncated)
+	}
+}
+
+func TestLogTail(t *testing.T) {
+	entries := []types.LogEntry{{Type: "command", Content: "uptime"}, {Type: "output", Content: strings.Repeat("z", 50)}}
+	if got := logTail(entries, 1000); got != "[command] uptime\n[output] "+strings.Repeat("z", 50) {
+		t.Fatalf("unexpected tail: %q", got)
+	}
+	if got := logTail(entries, 10); got != "..."+strings.Repeat("z", 10) {
+		t.Fatalf("unexpected tail: %q", got)
+	}
+}
@@ -0,0 +1,40 @@
+package agents
+
+import (
+	"context"
+	"testing"
+
+	"mantis/core/protocols"
+	"mantis/core/types"
+)
+
+// triggerRecorder is a plan runner that records the trigger calls it gets.
+type triggerRecorder struct {
+	protocols.PlanRunner
+	triggers []string
+	forced   []bool
+}
+
+func (r *triggerRecorder) TriggerRun(_ context.Context, planID, trigger string, _ map[string]any, force bool) (types.PlanRun, error) {
+	r.triggers = append(r.triggers, trigger)
+	r.forced = append(r.forced, force)
+	return types.PlanRun{ID: "r1", PlanID: planID, Status: "running"}, nil
+}
+
+func TestPlanRunTool_Force(t *testing.T) {
+	runner := &triggerRecorder{}
+	tool := (&MantisAgent{planRunner: runner}).planRunTool()
+
+	props := tool.Parameters["properties"].(map[string]any)
+	if force, ok := props["force"].(map[string]any); !ok || force["type"] != "boolean" {
+		t.Fatalf("force must be declared as a boolean, got %v", props["force"])
+	}
+	for _, args := range []string{`{"id":"p1"}`, `{"id":"p1","force":true}`} {
+		if _, err := tool.Execute(context.Background(), args); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(runner.forced) != 2 || runner.forced[0] || !runner.forced[1] || runner.triggers[1] != "chat" {
+		t.Fatalf("unexpected trigger calls: %v %v", runner.triggers, runner.forced)
+	}
+}
@@ -260,3 +260,20 @@ func TestPlanUpdateTool_Labels(t *testing.T) {
 		t.Fatalf("expected generic label, got %q", label)
 	}
 }
+
+func TestPlanUpdateTool_Concurrency(t *testing.T) {
+	store := &planStoreMock{plans: map[string]types.Plan{
+		"p1": {ID: "p1", Name: "Cleanup", Concurrency: types.PlanConcurrencyAllow},
+	}}
+	tool := newAgentWithPlanStore(store).planUpdateTool()
+
+	if _, err := tool.Execute(context.Background(), `{"id":"p1","concurrency":"skip"}`); err != nil {
+		t.Fatal(err)
+	}
+	if store.plans["p1"].Concurrency != types.PlanConcurrencySkip {
+		t.Fatalf("expected skip, got %q", store.plans["p1"].Concurrency)
+	}
+	if _, err := tool.Execute(context.Background(), `{"id":"p1","concurrency":"sometimes"}`); err == nil {
+		t.Fatal("expected error for unknown policy")
+	}
+}
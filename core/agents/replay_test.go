@@ -0,0 +1,118 @@
+package agents
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"mantis/core/protocols"
+	"mantis/core/types"
+	"mantis/infrastructure/adapters/llm"
+	"mantis/infrastructure/adapters/store"
+	"mantis/shared"
+)
+
+// sessionServer serves the responses in a testdata session file from an
+// OpenAI-compatible chat completions endpoint, one per request.
+func sessionServer(t *testing.T, file string) (*httptest.Server, func() int) {
+	t.Helper()
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var responses [][]json.RawMessage
+	if err := json.Unmarshal(data, &responses); err != nil {
+		t.Fatal(err)
+	}
+	var mu sync.Mutex
+	hits := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		n := hits
+		hits++
+		mu.Unlock()
+		if n >= len(responses) {
+			http.Error(w, "session has no more responses", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, chunk := range responses[n] {
+			fmt.Fprintf(w, "data: %s\n\n", chunk)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	t.Cleanup(server.Close)
+	return server, func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return hits
+	}
+}
+
+func replayAgent(model protocols.LLM, baseURL string) *MantisAgent {
+	models := &memStore[types.Model]{
+		id:    func(m types.Model) string { return m.ID },
+		items: []types.Model{{ID: "m1", Name: "gpt-test", ConnectionID: "c1"}},
+	}
+	conns := &memStore[types.LlmConnection]{
+		id:    func(c types.LlmConnection) string { return c.ID },
+		items: []types.LlmConnection{{ID: "c1", Provider: "openai", BaseURL: baseURL, APIKey: "test-key"}},
+	}
+	connections := &memStore[types.Connection]{id: func(c types.Connection) string { return c.ID }}
+	return NewMantisAgent(nil, models, nil, conns, connections, nil, nil, nil, nil, nil,
+		model, nil, nil, nil, nil, nil, shared.DefaultLimits())
+}
+
+// transcript is what a run of the agent showed the user: tool results and
+// the answer.
+func transcript(t *testing.T, a *MantisAgent) string {
+	t.Helper()
+	ch, err := a.Execute(context.Background(), MantisInput{ModelID: "m1", Content: "What is 2 + 3?", DisableHistory: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sb strings.Builder
+	for ev := range ch {
+		switch ev.Type {
+		case "error":
+			t.Fatalf("agent error: %s", ev.Delta)
+		case "tool_end":
+			sb.WriteString("[tool] " + ev.Delta + "\n")
+		case "text":
+			sb.WriteString(ev.Delta)
+		}
+	}
+	return sb.String()
+}
+
+func TestMantisAgent_RecordThenReplay(t *testing.T) {
+	server, hits := sessionServer(t, "testdata/sum_session.json")
+	recordings := store.NewJSONDir(t.TempDir(), func(e types.LLMCacheEntry) string { return e.ID })
+
+	recorded := transcript(t, replayAgent(llm.NewCache(llm.NewOpenAI(), recordings, llm.CacheRecord), server.URL))
+	if recorded != "[tool] 5\n2 + 3 = 5" {
+		t.Fatalf("recorded transcript = %q", recorded)
+	}
+	if entries, _ := recordings.List(context.Background(), types.ListQuery{}); len(entries) != 2 || hits() != 2 {
+		t.Fatalf("expected 2 recordings from 2 model calls, got %d from %d", len(entries), hits())
+	}
+
+	// The system prompt carries the current time to the second; replay in the
+	// next second so the recording has to match despite it.
+	time.Sleep(time.Until(time.Now().Truncate(time.Second).Add(time.Second)))
+
+	replayed := transcript(t, replayAgent(llm.NewCache(nil, recordings, llm.CacheReplay), "http://unused"))
+	if replayed != recorded {
+		t.Fatalf("replayed transcript = %q, recorded %q", replayed, recorded)
+	}
+	if hits() != 2 {
+		t.Fatalf("replay reached the model: %d calls", hits())
+	}
+}
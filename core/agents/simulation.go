@@ -0,0 +1,98 @@
+package agents
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"mantis/core/types"
+)
+
+// MockAnyTool is the mock key that answers every stubbed tool without its
+// own entry.
+const MockAnyTool = "*"
+
+const simulatedResult = "[SIMULATION] This call was recorded but not executed. " +
+	"Assume it ran and produced a plausible, typical result for the task, and continue from there."
+
+// Simulation stands in for tools with side effects during a plan dry run:
+// SSH and skill tools, uploads, notifications and plan changes are replaced
+// by stubs that record the intended call and answer with a canned result.
+// Without a mock the stub asks the model to imagine a typical outcome.
+type Simulation struct {
+	mocks map[string]string
+
+	mu    sync.Mutex
+	calls []types.SimulatedCall
+}
+
+func NewSimulation(mocks map[string]string) *Simulation {
+	return &Simulation{mocks: mocks}
+}
+
+// Calls returns the calls recorded so far, in the order they were made.
+func (s *Simulation) Calls() []types.SimulatedCall {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]types.SimulatedCall, len(s.calls))
+	copy(out, s.calls)
+	return out
+}
+
+// Apply swaps every tool with side effects for a recording stub. A nil
+// simulation leaves the tools untouched.
+func (s *Simulation) Apply(tools []types.Tool) []types.Tool {
+	if s == nil {
+		return tools
+	}
+	out := make([]types.Tool, len(tools))
+	for i, t := range tools {
+		if IsSimulatedTool(t.Name) {
+			t = s.stub(t)
+		}
+		out[i] = t
+	}
+	return out
+}
+
+func (s *Simulation) stub(t types.Tool) types.Tool {
+	name := t.Name
+	t.Execute = func(_ context.Context, args string) (string, error) {
+		result := s.result(name)
+		s.mu.Lock()
+		s.calls = append(s.calls, types.SimulatedCall{Tool: name, Args: args, Result: result})
+		s.mu.Unlock()
+		return result, nil
+	}
+	return t
+}
+
+func (s *Simulation) result(tool string) string {
+	if r, ok := s.mocks[tool]; ok {
+		return r
+	}
+	if r, ok := s.mocks[MockAnyTool]; ok {
+		return r
+	}
+	return simulatedResult
+}
+
+// simulatedTools are the tools without a prefix rule that a simulation
+// stubs out: they send messages or files, or start, change or stop plans.
+var simulatedTools = map[string]bool{
+	"send_notification":  true,
+	"send_file":          true,
+	"send_file_telegram": true,
+	"plan_run":           true,
+	"plan_create":        true,
+	"plan_from_template": true,
+	"plan_update":        true,
+	"plan_delete":        true,
+	"plan_stop":          true,
+}
+
+// IsSimulatedTool reports whether a simulation stubs a tool out: anything
+// that runs on a server, changes connections or plans, or sends a message.
+func IsSimulatedTool(name string) bool {
+	return simulatedTools[name] || strings.HasPrefix(name, "ssh_") || strings.HasPrefix(name, "skill_")
+}
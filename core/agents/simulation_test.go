@@ -0,0 +1,107 @@
+package agents
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"mantis/core/types"
+	"mantis/shared"
+)
+
+func liveTool(name string, ran *[]string) types.Tool {
+	return types.Tool{
+		Name: name,
+		Execute: func(_ context.Context, args string) (string, error) {
+			*ran = append(*ran, name)
+			return "live " + name, nil
+		},
+	}
+}
+
+func TestSimulationApply_StubsSideEffects(t *testing.T) {
+	var ran []string
+	tools := []types.Tool{
+		liveTool("ssh_web1", &ran),
+		liveTool("ssh_upload_web1", &ran),
+		liveTool("skill_restart_ab12cd34", &ran),
+		liveTool("send_notification", &ran),
+		liveTool("artifacts_list", &ran),
+	}
+	sim := NewSimulation(map[string]string{"ssh_web1": "disk usage 95%"})
+	stubbed := sim.Apply(tools)
+
+	results := map[string]string{}
+	for _, tool := range stubbed {
+		out, err := tool.Execute(context.Background(), `{"task":"x"}`)
+		if err != nil {
+			t.Fatalf("%s: %v", tool.Name, err)
+		}
+		results[tool.Name] = out
+	}
+
+	if len(ran) != 1 || ran[0] != "artifacts_list" {
+		t.Fatalf("only artifacts_list should run for real, ran %v", ran)
+	}
+	if results["ssh_web1"] != "disk usage 95%" {
+		t.Fatalf("expected mocked result, got %q", results["ssh_web1"])
+	}
+	if !strings.HasPrefix(results["send_notification"], "[SIMULATION]") {
+		t.Fatalf("expected default simulated result, got %q", results["send_notification"])
+	}
+
+	calls := sim.Calls()
+	if len(calls) != 4 {
+		t.Fatalf("expected 4 recorded calls, got %d", len(calls))
+	}
+	if calls[0].Tool != "ssh_web1" || calls[0].Args != `{"task":"x"}` || calls[0].Result != "disk usage 95%" {
+		t.Fatalf("unexpected first call %+v", calls[0])
+	}
+}
+
+func TestSimulationApply_WildcardMock(t *testing.T) {
+	var ran []string
+	sim := NewSimulation(map[string]string{MockAnyTool: "ok", "ssh_db": "down"})
+	tools := sim.Apply([]types.Tool{liveTool("ssh_web1", &ran), liveTool("ssh_db", &ran)})
+	web, _ := tools[0].Execute(context.Background(), "{}")
+	db, _ := tools[1].Execute(context.Background(), "{}")
+	if web != "ok" || db != "down" {
+		t.Fatalf("expected wildcard then exact mock, got %q and %q", web, db)
+	}
+}
+
+func TestSimulationApply_NilLeavesToolsAlone(t *testing.T) {
+	var ran []string
+	var sim *Simulation
+	tools := sim.Apply([]types.Tool{liveTool("ssh_web1", &ran)})
+	if out, _ := tools[0].Execute(context.Background(), "{}"); out != "live ssh_web1" {
+		t.Fatalf("expected the real tool to run, got %q", out)
+	}
+}
+
+func TestIsSimulatedTool_CoversEveryToolWithSideEffects(t *testing.T) {
+	// Tools that only read. Every other tool buildTools returns must be
+	// stubbed by a simulation; a new tool has to be added to one side.
+	readOnly := map[string]bool{
+		"artifacts_list": true, "artifact_read_text": true, "artifact_transcribe": true, "artifact_read_image": true,
+		"sum": true, "plan_list": true, "plan_get": true, "plan_template_list": true, "plan_active": true,
+		"plan_runs": true, "plan_run_get": true,
+	}
+	a := &MantisAgent{}
+	conns := []types.Connection{{ID: "c1", Type: "ssh", Name: "web1"}}
+	skills := []types.Skill{{ID: "abcdef1234", ConnectionID: "c1", Name: "restart"}}
+	seen := map[string]bool{}
+	for _, source := range []string{"web", "plan"} {
+		for _, tool := range a.buildTools(conns, skills, shared.NewArtifactStore(), "r1", source) {
+			seen[tool.Name] = true
+			if IsSimulatedTool(tool.Name) == readOnly[tool.Name] {
+				t.Errorf("%s: simulated = %v, read-only = %v", tool.Name, IsSimulatedTool(tool.Name), readOnly[tool.Name])
+			}
+		}
+	}
+	for _, name := range []string{"plan_run", "plan_create", "plan_from_template", "plan_update", "plan_delete", "plan_stop", "send_file_telegram"} {
+		if !seen[name] {
+			t.Errorf("expected buildTools to return %s", name)
+		}
+	}
+}
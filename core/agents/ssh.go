@@ -43,6 +43,8 @@ type SSHInput struct {
 	SSHConfig  SSHConfig
 	Connection types.Connection
 	Task       string
+	Options    types.GenerationOptions
+	Fallbacks  []agent.Fallback
 }
 
 type SSHAgent struct {
@@ -53,10 +55,10 @@ type SSHAgent struct {
 	limits        shared.Limits
 }
 
-func NewSSHAgent(llmConnStore protocols.Store[string, types.LlmConnection], llm protocols.LLM, g *guard.Guard, sessionLogger *shared.SessionLogger, limits shared.Limits) *SSHAgent {
+func NewSSHAgent(llmConnStore protocols.Store[string, types.LlmConnection], llm protocols.LLM, breaker *agent.Breaker, stats *agent.ToolCallStats, g *guard.Guard, sessionLogger *shared.SessionLogger, limits shared.Limits) *SSHAgent {
 	return &SSHAgent{
 		llmConnStore:  llmConnStore,
-		agent:         agent.New(llm),
+		agent:         agent.New(llm, breaker, stats),
 		guard:         g,
 		sessionLogger: sessionLogger,
 		limits:        limits,
@@ -84,16 +86,22 @@ func (a *SSHAgent) Execute(ctx context.Context, in SSHInput) (<-chan types.Strea
 		{Role: "user", Content: in.Task},
 	}
 
-	ch, err := a.agent.Execute(ctx, agent.AgentInput{
+	ch, err := a.agent.Execute(shared.WithUsageCaller(ctx, shared.UsageCallerSSH), agent.AgentInput{
 		LoopInput: agent.LoopInput{
 			ActionInput: agent.ActionInput{
+				ConnectionID: conn.ID,
 				Provider:     conn.Provider,
 				BaseURL:      conn.BaseURL,
 				APIKey:       conn.APIKey,
+				ModelID:      in.Model.ID,
 				Model:        in.Model.Name,
 				Messages:     messages,
 				Tools:        tools,
 				ThinkingMode: in.Model.ThinkingMode,
+				ToolCalling:  in.Model.ToolCalling,
+				Vision:       in.Model.Vision,
+				Options:      in.Options,
+				Fallbacks:    in.Fallbacks,
 			},
 			MaxIterations: a.limits.ServerMaxIterations,
 		},
@@ -200,3 +200,32 @@ func TestStepsToGraph_EdgeIDsUnique(t *testing.T) {
 		seen[e.ID] = true
 	}
 }
+
+func TestStepsToGraph_ApprovalAndNext(t *testing.T) {
+	steps := []planStep{
+		{Type: "action", Prompt: "Prepare", Next: "gate"},
+		{Type: "action", Prompt: "Skipped", ID: "n1"},
+		{Type: "approval", Prompt: "Apply?", ID: "gate", Yes: "n1", No: "end"},
+	}
+	g, err := stepsToGraph(steps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Nodes[2].Type != types.PlanNodeApproval {
+		t.Fatalf("expected approval node, got %s", g.Nodes[2].Type)
+	}
+	edgeMap := make(map[string]string)
+	for _, e := range g.Edges {
+		edgeMap[e.Source+":"+e.Label] = e.Target
+	}
+	if edgeMap["n1:"] != "n3" {
+		t.Fatalf("action next should jump to gate, got %v", edgeMap)
+	}
+	// The explicit id "n1" refers to the second step, not the first.
+	if edgeMap["n3:approve"] != "n2" {
+		t.Fatalf("expected approve edge to the step with id n1, got %v", edgeMap)
+	}
+	if _, ok := edgeMap["n3:reject"]; ok {
+		t.Fatalf("reject to end should add no edge, got %v", edgeMap)
+	}
+}
@@ -99,6 +99,8 @@ func (a *MantisAgent) sshTool(c types.Connection) types.Tool {
 				SSHConfig:  sshCfg,
 				Connection: connCopy,
 				Task:       input.Task,
+				Options:    a.generationOptions(ctx, selection.PresetID, model),
+				Fallbacks:  a.fallbackChain(ctx, selection.PresetID, model.ID),
 			})
 			if err != nil {
 				return "", fmt.Errorf("agent %s: %w", connName, err)
@@ -121,6 +123,8 @@ func annotateServerLimit(partial string, runErr error, ctx context.Context, limi
 		marker = shared.StopReasonUser()
 	case runErr != nil && strings.Contains(runErr.Error(), "max iterations reached"):
 		marker = shared.StopReasonServerIterations(limits.ServerMaxIterations)
+	case runErr != nil && strings.HasPrefix(runErr.Error(), shared.AgentStuckPrefix):
+		marker = shared.StopReasonStuck(strings.TrimPrefix(runErr.Error(), shared.AgentStuckPrefix))
 	default:
 		if partial != "" {
 			return partial + "\nerror: " + runErr.Error()
@@ -689,6 +693,8 @@ func (a *MantisAgent) planGetTool() types.Tool {
 				"name":        p.Name,
 				"description": p.Description,
 				"schedule":    p.Schedule,
+				"timezone":    p.Timezone,
+				"notify":      p.Notify,
 				"enabled":     p.Enabled,
 				"parameters":  p.Parameters,
 				"nodes":       nodes,
@@ -706,13 +712,18 @@ func (a *MantisAgent) planRunTool() types.Tool {
 		Icon:        "play",
 		Label: func(args string) string {
 			var input struct {
-				ID string `json:"id"`
+				ID       string `json:"id"`
+				Simulate bool   `json:"simulate"`
 			}
 			_ = json.Unmarshal([]byte(args), &input)
+			label := "Run plan"
+			if input.Simulate {
+				label = "Dry-run plan"
+			}
 			if input.ID != "" {
-				return "Run plan " + input.ID[:min(8, len(input.ID))]
+				return label + " " + input.ID[:min(8, len(input.ID))]
 			}
-			return "Run plan"
+			return label
 		},
 		Parameters: map[string]any{
 			"type": "object",
@@ -725,6 +736,19 @@ func (a *MantisAgent) planRunTool() types.Tool {
 					"type":        "object",
 					"description": "Input parameters for the plan (if it requires any)",
 				},
+				"simulate": map[string]any{
+					"type":        "boolean",
+					"description": "Dry run: SSH, upload and notification calls are recorded instead of executed, so branching can be tested without touching servers",
+				},
+				"mocks": map[string]any{
+					"type":                 "object",
+					"description":          "Simulation only: canned results for stubbed tools, keyed by tool name (e.g. ssh_web1) or '*' for any tool. Unmocked calls get an imagined typical result.",
+					"additionalProperties": map[string]any{"type": "string"},
+				},
+				"force": map[string]any{
+					"type":        "boolean",
+					"description": "Start the run even though the plan is in a blackout window. Set it only when the user explicitly asks to run during the blackout, never on your own",
+				},
 			},
 			"required": []string{"id"},
 		},
@@ -733,8 +757,11 @@ func (a *MantisAgent) planRunTool() types.Tool {
 				return "", fmt.Errorf("plan runner is not configured")
 			}
 			var input struct {
-				ID    string         `json:"id"`
-				Input map[string]any `json:"input"`
+				ID       string            `json:"id"`
+				Input    map[string]any    `json:"input"`
+				Simulate bool              `json:"simulate"`
+				Mocks    map[string]string `json:"mocks"`
+				Force    bool              `json:"force"`
 			}
 			if err := json.Unmarshal([]byte(args), &input); err != nil {
 				return "", err
@@ -742,21 +769,102 @@ func (a *MantisAgent) planRunTool() types.Tool {
 			if strings.TrimSpace(input.ID) == "" {
 				return "", fmt.Errorf("plan id is required")
 			}
-			run, err := a.planRunner.TriggerRun(ctx, input.ID, "chat", input.Input)
+			if len(input.Mocks) > 0 && !input.Simulate {
+				return "", fmt.Errorf("mocks only apply to simulation runs: set simulate to true")
+			}
+			var run types.PlanRun
+			var err error
+			if input.Simulate {
+				run, err = a.planRunner.SimulateRun(ctx, input.ID, input.Input, input.Mocks)
+			} else {
+				run, err = a.planRunner.TriggerRun(ctx, input.ID, "chat", input.Input, input.Force)
+			}
 			if err != nil {
 				return "", err
 			}
-			out, _ := json.Marshal(map[string]any{
+			result := map[string]any{
 				"ok":     true,
 				"run_id": run.ID,
 				"status": run.Status,
 				"steps":  len(run.Steps),
-			})
+			}
+			if run.Reason != "" {
+				result["reason"] = run.Reason
+			}
+			out, _ := json.Marshal(result)
 			return string(out), nil
 		},
 	}
 }
 
+var planConcurrencySchema = map[string]any{
+	"type":        "string",
+	"enum":        []string{"allow", "skip", "queue", "cancel_previous"},
+	"description": "What to do when the plan is triggered while a previous run is still active: 'allow' (run in parallel, default), 'skip' (drop the new run), 'queue' (wait for the previous run), 'cancel_previous' (stop the previous run)",
+}
+
+func parsePlanConcurrency(raw string) (types.PlanConcurrency, error) {
+	switch c := types.PlanConcurrency(strings.TrimSpace(raw)); c {
+	case "":
+		return types.PlanConcurrencyAllow, nil
+	case types.PlanConcurrencyAllow, types.PlanConcurrencySkip, types.PlanConcurrencyQueue, types.PlanConcurrencyCancelPrevious:
+		return c, nil
+	default:
+		return "", fmt.Errorf("unknown concurrency policy %q (use allow, skip, queue or cancel_previous)", raw)
+	}
+}
+
+var planTimezoneSchema = map[string]any{
+	"type":        "string",
+	"description": "IANA timezone the schedule runs in, e.g. 'Europe/Berlin' or 'America/New_York' (empty = server time). Set it when the user names a local time so DST changes don't shift the run",
+}
+
+func parsePlanTimezone(raw string) (string, error) {
+	tz := strings.TrimSpace(raw)
+	if tz == "" {
+		return "", nil
+	}
+	if _, err := time.LoadLocation(tz); err != nil {
+		return "", fmt.Errorf("unknown timezone %q (use an IANA name like Europe/Berlin)", raw)
+	}
+	return tz, nil
+}
+
+// parsePlanNotify turns the plan_update notify option into report settings,
+// keeping the channel and recipient of an existing config.
+func parsePlanNotify(raw string, existing *types.PlanNotify) (*types.PlanNotify, error) {
+	when := types.PlanNotifyWhen(strings.ToLower(strings.TrimSpace(raw)))
+	switch when {
+	case "off", "":
+		return nil, nil
+	case types.PlanNotifySuccess, types.PlanNotifyFailure, types.PlanNotifyAlways:
+	default:
+		return nil, fmt.Errorf("unknown notify option %q (use off, failure, success or always)", raw)
+	}
+	n := types.PlanNotify{When: when}
+	if existing != nil {
+		n.ChannelID, n.Recipient = existing.ChannelID, existing.Recipient
+	}
+	return &n, nil
+}
+
+// planStep is the step DSL the agent uses for plans. Yes/No are the
+// branches of a decision (approve/reject for an approval step), Next is
+// where an action goes afterwards; each is "next", a step id or "end".
+var planStepSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"type":   map[string]any{"type": "string", "enum": []string{"action", "decision", "approval"}, "description": "Step type. An approval step pauses the run until a human approves or rejects it"},
+		"prompt": map[string]any{"type": "string", "description": "What the agent should do (action), decide (decision, must be a yes/no question) or ask the approver (approval)"},
+		"label":  map[string]any{"type": "string", "description": "Short label for the step (optional)"},
+		"id":     map[string]any{"type": "string", "description": "Step ID for referencing from branches (optional, needed only if another step targets this step)"},
+		"yes":    map[string]any{"type": "string", "description": "Decision/approval only: where to go on YES (approve) — 'next' (default), step id, or 'end'"},
+		"no":     map[string]any{"type": "string", "description": "Decision/approval only: where to go on NO (reject) — 'next', step id, or 'end' (default)"},
+		"next":   map[string]any{"type": "string", "description": "Action only: where to go afterwards — 'next' (default), step id, or 'end'"},
+	},
+	"required": []string{"type", "prompt"},
+}
+
 type planStep struct {
 	ID     string `json:"id,omitempty"`
 	Type   string `json:"type"`
@@ -764,6 +872,7 @@ type planStep struct {
 	Prompt string `json:"prompt,omitempty"`
 	Yes    string `json:"yes,omitempty"`
 	No     string `json:"no,omitempty"`
+	Next   string `json:"next,omitempty"`
 }
 
 const maxAgentPlanSteps = 15
@@ -782,18 +891,32 @@ func stepsToGraph(steps []planStep) (types.PlanGraph, error) {
 	}
 	byUserID := map[string]resolved{}
 
+	// Explicit step ids win over generated ones, so a step called "n2"
+	// is not shadowed by whichever step happens to be second.
+	for i, s := range steps {
+		if s.ID != "" {
+			byUserID[s.ID] = resolved{nodeID: fmt.Sprintf("n%d", i+1), index: i}
+		}
+	}
+
 	nodes := make([]types.PlanNode, 0, len(steps))
 	for i, s := range steps {
 		nid := fmt.Sprintf("n%d", i+1)
 		nodeType := types.PlanNodeAction
-		if s.Type == "decision" {
+		switch s.Type {
+		case "decision":
 			nodeType = types.PlanNodeDecision
+		case "approval":
+			nodeType = types.PlanNodeApproval
 		}
 		label := s.Label
 		if label == "" {
-			if nodeType == types.PlanNodeDecision {
+			switch nodeType {
+			case types.PlanNodeDecision:
 				label = fmt.Sprintf("Decision %d", i+1)
-			} else {
+			case types.PlanNodeApproval:
+				label = fmt.Sprintf("Approval %d", i+1)
+			default:
 				label = fmt.Sprintf("Step %d", i+1)
 			}
 		}
@@ -810,10 +933,9 @@ func stepsToGraph(steps []planStep) (types.PlanGraph, error) {
 			Position: pos,
 		})
 
-		if s.ID != "" {
-			byUserID[s.ID] = resolved{nodeID: nid, index: i}
+		if _, taken := byUserID[nid]; !taken {
+			byUserID[nid] = resolved{nodeID: nid, index: i}
 		}
-		byUserID[nid] = resolved{nodeID: nid, index: i}
 	}
 
 	resolveTarget := func(ref string, fromIdx int) string {
@@ -839,24 +961,28 @@ func stepsToGraph(steps []planStep) (types.PlanGraph, error) {
 		node := nodes[i]
 		switch node.Type {
 		case types.PlanNodeAction:
-			target := resolveTarget("next", i)
+			target := resolveTarget(s.Next, i)
 			if target != "" {
 				edgeID++
 				edges = append(edges, types.PlanEdge{
 					ID: fmt.Sprintf("e%d", edgeID), Source: node.ID, Target: target,
 				})
 			}
-		case types.PlanNodeDecision:
+		case types.PlanNodeDecision, types.PlanNodeApproval:
+			yesLabel, noLabel := "yes", "no"
+			if node.Type == types.PlanNodeApproval {
+				yesLabel, noLabel = types.PlanEdgeApprove, types.PlanEdgeReject
+			}
 			if yesTarget := resolveTarget(s.Yes, i); yesTarget != "" {
 				edgeID++
 				edges = append(edges, types.PlanEdge{
-					ID: fmt.Sprintf("e%d", edgeID), Source: node.ID, Target: yesTarget, Label: "yes",
+					ID: fmt.Sprintf("e%d", edgeID), Source: node.ID, Target: yesTarget, Label: yesLabel,
 				})
 			}
 			if noTarget := resolveTarget(s.No, i); noTarget != "" {
 				edgeID++
 				edges = append(edges, types.PlanEdge{
-					ID: fmt.Sprintf("e%d", edgeID), Source: node.ID, Target: noTarget, Label: "no",
+					ID: fmt.Sprintf("e%d", edgeID), Source: node.ID, Target: noTarget, Label: noLabel,
 				})
 			}
 		}
@@ -868,7 +994,7 @@ func stepsToGraph(steps []planStep) (types.PlanGraph, error) {
 func (a *MantisAgent) planCreateTool() types.Tool {
 	return types.Tool{
 		Name:        "plan_create",
-		Description: "Create an agentic workflow plan from a list of steps. Each step is either an action (the agent executes a prompt) or a decision (the agent answers yes/no and branches). Plans can optionally have a cron schedule. To modify a plan, delete it and create a new one.",
+		Description: "Create an agentic workflow plan from a list of steps. Each step is either an action (the agent executes a prompt) or a decision (the agent answers yes/no and branches). Plans can optionally have a cron schedule. To change steps later, use plan_update with edits.",
 		Icon:        "git-branch",
 		Label: func(args string) string {
 			var input struct {
@@ -886,22 +1012,13 @@ func (a *MantisAgent) planCreateTool() types.Tool {
 				"name":        map[string]any{"type": "string", "description": "Plan name"},
 				"description": map[string]any{"type": "string", "description": "What this plan does (1-2 sentences)"},
 				"schedule":    map[string]any{"type": "string", "description": "Cron expression for recurring execution (empty = manual only). Examples: '0 9 * * *' daily at 9am, '*/30 * * * *' every 30 min"},
+				"timezone":    planTimezoneSchema,
 				"enabled":     map[string]any{"type": "boolean", "description": "Enable the plan (default: true if schedule is set, false otherwise)"},
+				"concurrency": planConcurrencySchema,
 				"steps": map[string]any{
 					"type":        "array",
 					"description": "Ordered list of plan steps (max 15)",
-					"items": map[string]any{
-						"type": "object",
-						"properties": map[string]any{
-							"type":   map[string]any{"type": "string", "enum": []string{"action", "decision"}, "description": "Step type"},
-							"prompt": map[string]any{"type": "string", "description": "What the agent should do (action) or decide (decision, must be a yes/no question)"},
-							"label":  map[string]any{"type": "string", "description": "Short label for the step (optional)"},
-							"id":     map[string]any{"type": "string", "description": "Step ID for referencing from decision branches (optional, needed only if a decision targets this step)"},
-							"yes":    map[string]any{"type": "string", "description": "Decision only: where to go on YES — 'next' (default), step id, or 'end'"},
-							"no":     map[string]any{"type": "string", "description": "Decision only: where to go on NO — 'next', step id, or 'end' (default)"},
-						},
-						"required": []string{"type", "prompt"},
-					},
+					"items":       planStepSchema,
 				},
 			},
 			"required": []string{"name", "steps"},
@@ -914,7 +1031,9 @@ func (a *MantisAgent) planCreateTool() types.Tool {
 				Name        string     `json:"name"`
 				Description string     `json:"description"`
 				Schedule    string     `json:"schedule"`
+				Timezone    string     `json:"timezone"`
 				Enabled     *bool      `json:"enabled"`
+				Concurrency string     `json:"concurrency"`
 				Steps       []planStep `json:"steps"`
 			}
 			if err := json.Unmarshal([]byte(args), &input); err != nil {
@@ -936,11 +1055,21 @@ func (a *MantisAgent) planCreateTool() types.Tool {
 				}
 			}
 
+			timezone, err := parsePlanTimezone(input.Timezone)
+			if err != nil {
+				return "", err
+			}
+
 			graph, err := stepsToGraph(input.Steps)
 			if err != nil {
 				return "", err
 			}
 
+			concurrency, err := parsePlanConcurrency(input.Concurrency)
+			if err != nil {
+				return "", err
+			}
+
 			enabled := schedule != ""
 			if input.Enabled != nil {
 				enabled = *input.Enabled
@@ -951,7 +1080,9 @@ func (a *MantisAgent) planCreateTool() types.Tool {
 				Name:        name,
 				Description: strings.TrimSpace(input.Description),
 				Schedule:    schedule,
+				Timezone:    timezone,
 				Enabled:     enabled,
+				Concurrency: concurrency,
 				Graph:       graph,
 			}
 			created, err := a.planStore.Create(ctx, []types.Plan{plan})
@@ -964,6 +1095,7 @@ func (a *MantisAgent) planCreateTool() types.Tool {
 				"id":       p.ID,
 				"name":     p.Name,
 				"schedule": p.Schedule,
+				"timezone": p.Timezone,
 				"enabled":  p.Enabled,
 				"nodes":    len(p.Graph.Nodes),
 				"edges":    len(p.Graph.Edges),
@@ -973,17 +1105,138 @@ func (a *MantisAgent) planCreateTool() types.Tool {
 	}
 }
 
+func (a *MantisAgent) planTemplateListTool() types.Tool {
+	return types.Tool{
+		Name:        "plan_template_list",
+		Description: "List plan templates: ready-made plans for common jobs (disk cleanup, certificate expiry, backup checks, package updates) and ones saved by users. Prefer a template over plan_create when one fits, and create the plan with plan_from_template.",
+		Icon:        "git-branch",
+		Label:       func(_ string) string { return "List plan templates" },
+		Parameters:  map[string]any{"type": "object", "properties": map[string]any{}},
+		Execute: func(ctx context.Context, _ string) (string, error) {
+			if a.planTemplates == nil {
+				return "", fmt.Errorf("plan templates are not configured")
+			}
+			items, err := a.planTemplates.List(ctx)
+			if err != nil {
+				return "", err
+			}
+			type templateSummary struct {
+				ID          string          `json:"id"`
+				Name        string          `json:"name"`
+				Description string          `json:"description,omitempty"`
+				Builtin     bool            `json:"builtin,omitempty"`
+				Schedule    string          `json:"schedule,omitempty"`
+				Steps       int             `json:"steps"`
+				Parameters  json.RawMessage `json:"parameters,omitempty"`
+			}
+			summaries := make([]templateSummary, len(items))
+			for i, t := range items {
+				summaries[i] = templateSummary{
+					ID: t.ID, Name: t.Name, Description: t.Description, Builtin: t.Builtin,
+					Schedule: t.Plan.Schedule, Steps: len(t.Plan.Graph.Nodes), Parameters: t.Plan.Parameters,
+				}
+			}
+			out, _ := json.Marshal(map[string]any{"templates": summaries})
+			return string(out), nil
+		},
+	}
+}
+
+func (a *MantisAgent) planFromTemplateTool() types.Tool {
+	return types.Tool{
+		Name:        "plan_from_template",
+		Description: "Create a plan from a template (see plan_template_list). Parameter values become the plan's defaults, so scheduled runs use them; required parameters without a default must be given. The template's schedule is kept unless 'schedule' is set.",
+		Icon:        "git-branch",
+		Label: func(args string) string {
+			var input struct {
+				Template string `json:"template"`
+				Name     string `json:"name"`
+			}
+			_ = json.Unmarshal([]byte(args), &input)
+			switch {
+			case input.Name != "":
+				return "Create plan from template: " + input.Name
+			case input.Template != "":
+				return "Create plan from template " + input.Template
+			}
+			return "Create plan from template"
+		},
+		Parameters: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"template": map[string]any{"type": "string", "description": "Template ID from plan_template_list"},
+				"name":     map[string]any{"type": "string", "description": "Plan name (default: the template's)"},
+				"schedule": map[string]any{"type": "string", "description": "Cron expression replacing the template's schedule; empty string for manual only"},
+				"timezone": planTimezoneSchema,
+				"enabled":  map[string]any{"type": "boolean", "description": "Enable the plan (default: true if it has a schedule)"},
+				"parameters": map[string]any{
+					"type":        "object",
+					"description": "Values for the template's parameters, by name",
+				},
+			},
+			"required": []string{"template"},
+		},
+		Execute: func(ctx context.Context, args string) (string, error) {
+			if a.planTemplates == nil {
+				return "", fmt.Errorf("plan templates are not configured")
+			}
+			var input struct {
+				Template   string         `json:"template"`
+				Name       string         `json:"name"`
+				Schedule   *string        `json:"schedule"`
+				Timezone   string         `json:"timezone"`
+				Enabled    *bool          `json:"enabled"`
+				Parameters map[string]any `json:"parameters"`
+			}
+			if err := json.Unmarshal([]byte(args), &input); err != nil {
+				return "", err
+			}
+			if strings.TrimSpace(input.Template) == "" {
+				return "", fmt.Errorf("template is required")
+			}
+			timezone, err := parsePlanTimezone(input.Timezone)
+			if err != nil {
+				return "", err
+			}
+			p, err := a.planTemplates.Instantiate(ctx, strings.TrimSpace(input.Template), types.PlanTemplateInstance{
+				Name:       input.Name,
+				Schedule:   input.Schedule,
+				Timezone:   timezone,
+				Enabled:    input.Enabled,
+				Parameters: input.Parameters,
+			})
+			if err != nil {
+				return "", err
+			}
+			out, _ := json.Marshal(map[string]any{
+				"ok":       true,
+				"id":       p.ID,
+				"name":     p.Name,
+				"schedule": p.Schedule,
+				"timezone": p.Timezone,
+				"enabled":  p.Enabled,
+				"nodes":    len(p.Graph.Nodes),
+			})
+			return string(out), nil
+		},
+	}
+}
+
 func (a *MantisAgent) planUpdateTool() types.Tool {
 	return types.Tool{
 		Name:        "plan_update",
-		Description: "Update plan settings (schedule, enabled, name, description). Use plan_list first to find the plan ID. To change plan steps, delete and recreate the plan.",
+		Description: "Update plan settings (schedule, enabled, name, description) and edit its steps in place with 'edits' (insert, replace, remove or rewire steps by id). Use plan_list to find the plan ID and plan_get to see step ids. The plan keeps its ID, run history and layout.",
 		Icon:        "git-branch",
 		Label: func(args string) string {
 			var input struct {
-				Schedule *string `json:"schedule"`
-				Enabled  *bool   `json:"enabled"`
+				Schedule *string    `json:"schedule"`
+				Enabled  *bool      `json:"enabled"`
+				Edits    []planEdit `json:"edits"`
 			}
 			_ = json.Unmarshal([]byte(args), &input)
+			if len(input.Edits) > 0 {
+				return "Edit plan steps"
+			}
 			if input.Schedule != nil {
 				return "Update plan schedule"
 			}
@@ -1001,8 +1254,12 @@ func (a *MantisAgent) planUpdateTool() types.Tool {
 				"id":          map[string]any{"type": "string", "description": "Plan ID"},
 				"enabled":     map[string]any{"type": "boolean", "description": "true to enable, false to disable"},
 				"schedule":    map[string]any{"type": "string", "description": "Cron expression (e.g. '0 9 * * *') or empty string to remove schedule"},
+				"timezone":    planTimezoneSchema,
+				"notify":      map[string]any{"type": "string", "enum": []string{"off", "failure", "success", "always"}, "description": "Send a run report to Telegram when a run fails, succeeds or always finishes; 'off' stops reports"},
 				"name":        map[string]any{"type": "string", "description": "New plan name"},
 				"description": map[string]any{"type": "string", "description": "New plan description"},
+				"concurrency": planConcurrencySchema,
+				"edits":       planEditSchema,
 			},
 			"required": []string{"id"},
 		},
@@ -1011,11 +1268,15 @@ func (a *MantisAgent) planUpdateTool() types.Tool {
 				return "", fmt.Errorf("plan store is not configured")
 			}
 			var input struct {
-				ID          string  `json:"id"`
-				Enabled     *bool   `json:"enabled"`
-				Schedule    *string `json:"schedule"`
-				Name        *string `json:"name"`
-				Description *string `json:"description"`
+				ID          string     `json:"id"`
+				Enabled     *bool      `json:"enabled"`
+				Schedule    *string    `json:"schedule"`
+				Timezone    *string    `json:"timezone"`
+				Notify      *string    `json:"notify"`
+				Name        *string    `json:"name"`
+				Description *string    `json:"description"`
+				Concurrency *string    `json:"concurrency"`
+				Edits       []planEdit `json:"edits"`
 			}
 			if err := json.Unmarshal([]byte(args), &input); err != nil {
 				return "", err
@@ -1037,23 +1298,58 @@ func (a *MantisAgent) planUpdateTool() types.Tool {
 			if input.Schedule != nil {
 				plan.Schedule = *input.Schedule
 			}
+			if input.Timezone != nil {
+				tz, err := parsePlanTimezone(*input.Timezone)
+				if err != nil {
+					return "", err
+				}
+				plan.Timezone = tz
+			}
+			if input.Notify != nil {
+				n, err := parsePlanNotify(*input.Notify, plan.Notify)
+				if err != nil {
+					return "", err
+				}
+				plan.Notify = n
+			}
 			if input.Name != nil && strings.TrimSpace(*input.Name) != "" {
 				plan.Name = strings.TrimSpace(*input.Name)
 			}
 			if input.Description != nil {
 				plan.Description = *input.Description
 			}
+			if input.Concurrency != nil {
+				c, err := parsePlanConcurrency(*input.Concurrency)
+				if err != nil {
+					return "", err
+				}
+				plan.Concurrency = c
+			}
+			if len(input.Edits) > 0 {
+				graph, err := a.editPlanSteps(plan.Graph, input.Edits)
+				if err != nil {
+					return "", err
+				}
+				plan.Graph = graph
+			}
 			updated, err := a.planStore.Update(ctx, []types.Plan{plan})
 			if err != nil {
 				return "", err
 			}
-			out, _ := json.Marshal(map[string]any{
-				"ok":       true,
-				"id":       updated[0].ID,
-				"name":     updated[0].Name,
-				"schedule": updated[0].Schedule,
-				"enabled":  updated[0].Enabled,
-			})
+			result := map[string]any{
+				"ok":          true,
+				"id":          updated[0].ID,
+				"name":        updated[0].Name,
+				"schedule":    updated[0].Schedule,
+				"timezone":    updated[0].Timezone,
+				"notify":      updated[0].Notify,
+				"enabled":     updated[0].Enabled,
+				"concurrency": updated[0].Concurrency,
+			}
+			if len(input.Edits) > 0 {
+				result["steps"] = graphToSteps(updated[0].Graph)
+			}
+			out, _ := json.Marshal(result)
 			return string(out), nil
 		},
 	}
@@ -1097,7 +1393,7 @@ func (a *MantisAgent) planDeleteTool() types.Tool {
 func (a *MantisAgent) planActiveTool() types.Tool {
 	return types.Tool{
 		Name:        "plan_active",
-		Description: "List currently running plan executions.",
+		Description: "List currently running plan executions, followed by queued runs in the order they will start.",
 		Icon:        "play",
 		Label:       func(_ string) string { return "Active runs" },
 		Parameters:  map[string]any{"type": "object", "properties": map[string]any{}},
@@ -1112,13 +1408,14 @@ func (a *MantisAgent) planActiveTool() types.Tool {
 			type runSummary struct {
 				RunID     string `json:"runId"`
 				PlanID    string `json:"planId"`
+				Status    string `json:"status"`
 				Trigger   string `json:"trigger"`
 				StartedAt string `json:"startedAt"`
 			}
 			summaries := make([]runSummary, len(runs))
 			for i, r := range runs {
 				summaries[i] = runSummary{
-					RunID: r.ID, PlanID: r.PlanID, Trigger: r.Trigger,
+					RunID: r.ID, PlanID: r.PlanID, Status: r.Status, Trigger: r.Trigger,
 					StartedAt: r.StartedAt.Format("2006-01-02 15:04:05 UTC"),
 				}
 			}
@@ -1567,7 +1864,8 @@ func (a *MantisAgent) artifactReadImageTool(artifacts *shared.ArtifactStore) typ
 						if ocrText != "" {
 							prompt = prompt + " OCR extracted the following text from the image:\n" + ocrText
 						}
-						visionText, visionErr = a.vision.Describe(ctx, conn.BaseURL, conn.APIKey, model.Name, art.Bytes, format, prompt)
+						visionCtx := shared.WithUsageModel(shared.WithUsageCaller(ctx, shared.UsageCallerVision), conn.ID, model.ID)
+						visionText, _, visionErr = a.vision.Describe(visionCtx, conn.BaseURL, conn.APIKey, model.Name, art.Bytes, format, prompt)
 						visionText = strings.TrimSpace(visionText)
 					}
 				}
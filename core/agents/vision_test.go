@@ -0,0 +1,36 @@
+package agents
+
+import (
+	"strings"
+	"testing"
+
+	"mantis/core/protocols"
+	"mantis/shared"
+)
+
+func TestAttachImages(t *testing.T) {
+	artifacts := shared.NewArtifactStore()
+	png, err := artifacts.Put("shot.png", []byte("png"), "image/png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, err := artifacts.Put("notes.txt", []byte("text"), "text/plain")
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := `what broke?
+
+<attachment id="` + png.ID + `" name="shot.png" mime="image/png" size="3" created="x" />
+<attachment id="` + doc.ID + `" name="notes.txt" mime="text/plain" size="4" created="x" />`
+	messages := []protocols.LLMMessage{{Role: "system", Content: "sys"}, {Role: "user", Content: content}}
+
+	attachImages(messages, artifacts)
+
+	parts := messages[1].Parts
+	if len(parts) != 2 || !strings.HasPrefix(parts[0].Text, "what broke?") || parts[1].MIME != "image/png" || string(parts[1].Data) != "png" {
+		t.Fatalf("parts = %+v", parts)
+	}
+	if messages[0].Parts != nil {
+		t.Fatal("only the user message gets parts")
+	}
+}
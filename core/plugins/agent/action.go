@@ -2,29 +2,196 @@ package agent
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
 
 	"mantis/core/protocols"
 	"mantis/core/types"
+	"mantis/shared"
 )
 
 type ActionInput struct {
+	ConnectionID string
 	Provider     string
 	BaseURL      string
 	APIKey       string
+	ModelID      string
 	Model        string
 	Messages     []protocols.LLMMessage
 	Tools        []types.Tool
 	ThinkingMode string
+	ToolCalling  string
+	Vision       bool
+	Options      types.GenerationOptions
+
+	// Fallbacks are tried in order when the model fails before its first
+	// token with a failover error (see shared.IsFailoverError).
+	Fallbacks []Fallback
+}
+
+// Fallback is a model to retry a failed call on, with its connection.
+type Fallback struct {
+	ConnectionID string
+	Provider     string
+	BaseURL      string
+	APIKey       string
+	ModelID      string
+	ModelName    string
+	ThinkingMode string
+	ToolCalling  string
+	Vision       bool
+	Options      types.GenerationOptions
+}
+
+// promote returns the input with fallback modelID as its model and only the
+// fallbacks after it left, so later iterations stay on the model that works.
+func (in ActionInput) promote(modelID string) ActionInput {
+	for i, f := range in.Fallbacks {
+		if f.ModelID != modelID {
+			continue
+		}
+		in = in.use(f)
+		in.Fallbacks = in.Fallbacks[i+1:]
+		break
+	}
+	return in
+}
+
+// escalate returns the input with f as its model. f need not be one of the
+// fallbacks; if it is, it leaves the chain, which otherwise stays as it is.
+func (in ActionInput) escalate(f Fallback) ActionInput {
+	in = in.use(f)
+	in.Fallbacks = slices.DeleteFunc(slices.Clone(in.Fallbacks), func(fb Fallback) bool { return fb.ModelID == f.ModelID })
+	return in
+}
+
+func (in ActionInput) use(f Fallback) ActionInput {
+	in.ConnectionID, in.Provider, in.BaseURL, in.APIKey = f.ConnectionID, f.Provider, f.BaseURL, f.APIKey
+	in.ModelID, in.Model, in.ThinkingMode, in.ToolCalling, in.Vision, in.Options = f.ModelID, f.ModelName, f.ThinkingMode, f.ToolCalling, f.Vision, f.Options
+	return in
 }
 
 type AgentAction struct {
-	llm protocols.LLM
+	llm     protocols.LLM
+	breaker *Breaker
 }
 
-func NewAgentAction(llm protocols.LLM) *AgentAction {
-	return &AgentAction{llm: llm}
+func NewAgentAction(llm protocols.LLM, breaker *Breaker) *AgentAction {
+	return &AgentAction{llm: llm, breaker: breaker}
 }
 
+// Execute streams one model call. When it fails with a failover error
+// before any output, or its connection's circuit is open, the call moves on
+// to the next fallback and the stream starts with a "failover" event naming
+// the model that answers. If every circuit is open the primary is tried
+// anyway.
 func (a *AgentAction) Execute(ctx context.Context, in ActionInput) (<-chan types.StreamEvent, error) {
-	return a.llm.ChatStream(ctx, in.Provider, in.BaseURL, in.APIKey, in.Messages, in.Model, in.Tools, in.ThinkingMode)
+	targets := append([]Fallback{{
+		ConnectionID: in.ConnectionID, Provider: in.Provider, BaseURL: in.BaseURL, APIKey: in.APIKey,
+		ModelID: in.ModelID, ModelName: in.Model, ThinkingMode: in.ThinkingMode, ToolCalling: in.ToolCalling, Vision: in.Vision, Options: in.Options,
+	}}, in.Fallbacks...)
+
+	var reason string
+	var lastErr error
+	attempted := false
+	for i, t := range targets {
+		if !a.breaker.Allow(t.ConnectionID) {
+			if reason == "" {
+				reason = fmt.Sprintf("%s: circuit open for connection %s", t.ModelName, t.ConnectionID)
+			}
+			continue
+		}
+		attempted = true
+		ch, err := a.call(ctx, in, t)
+		if err == nil {
+			a.breaker.Success(t.ConnectionID)
+			if i == 0 {
+				return ch, nil
+			}
+			log.Printf("llm: falling back to %s (%s)", t.ModelName, reason)
+			return withFailover(ch, t, reason), nil
+		}
+		if ctx.Err() != nil || !shared.IsFailoverError(err) {
+			a.breaker.Release(t.ConnectionID)
+			return nil, err
+		}
+		if a.breaker.Failure(t.ConnectionID) {
+			log.Printf("llm: circuit for connection %s is open: %v", t.ConnectionID, err)
+		}
+		if reason == "" {
+			reason = fmt.Sprintf("%s: %v", t.ModelName, err)
+		}
+		lastErr = err
+	}
+	if !attempted {
+		return a.call(ctx, in, targets[0])
+	}
+	return nil, lastErr
+}
+
+// call starts the stream on target t and waits for its first event, so an
+// error the provider reports in-stream can still fail over.
+func (a *AgentAction) call(ctx context.Context, in ActionInput, t Fallback) (<-chan types.StreamEvent, error) {
+	ctx = shared.WithUsageModel(ctx, t.ConnectionID, t.ModelID)
+	messages, tools := in.Messages, in.Tools
+	if !t.Vision {
+		messages = textOnly(messages)
+	}
+	if t.ToolCalling == types.ToolCallingPrompt {
+		messages, tools = promptToolMessages(messages, tools), nil
+	}
+	ch, err := a.llm.ChatStream(ctx, t.Provider, t.BaseURL, t.APIKey, messages, t.ModelName, tools, t.ThinkingMode, t.Options)
+	if err == nil && t.ToolCalling == types.ToolCallingPrompt && len(in.Tools) > 0 {
+		ch = parsePromptToolCalls(ch)
+	}
+	if err != nil || len(in.Fallbacks) == 0 {
+		return ch, err
+	}
+	first, ok := <-ch
+	if !ok {
+		return ch, nil
+	}
+	if first.Type == "error" && shared.IsFailoverError(errors.New(first.Delta)) {
+		go func() {
+			for range ch {
+			}
+		}()
+		return nil, errors.New(first.Delta)
+	}
+	out := make(chan types.StreamEvent, 32)
+	go func() {
+		defer close(out)
+		out <- first
+		for ev := range ch {
+			out <- ev
+		}
+	}()
+	return out, nil
+}
+
+func withFailover(ch <-chan types.StreamEvent, t Fallback, reason string) <-chan types.StreamEvent {
+	out := make(chan types.StreamEvent, 32)
+	go func() {
+		defer close(out)
+		out <- types.StreamEvent{Type: "failover", Delta: reason, ModelID: t.ModelID, ModelName: t.ModelName, ModelRole: "fallback"}
+		for ev := range ch {
+			out <- ev
+		}
+	}()
+	return out
+}
+
+// textOnly drops the content parts of messages for a model that only takes
+// text; their Content already holds the text.
+func textOnly(messages []protocols.LLMMessage) []protocols.LLMMessage {
+	if !slices.ContainsFunc(messages, func(m protocols.LLMMessage) bool { return len(m.Parts) > 0 }) {
+		return messages
+	}
+	out := slices.Clone(messages)
+	for i := range out {
+		out[i].Parts = nil
+	}
+	return out
 }
@@ -0,0 +1,200 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"mantis/core/protocols"
+	"mantis/core/types"
+)
+
+// modelLLM answers per model name: an error, an in-stream error event, or
+// the text "ok from <model>".
+type modelLLM struct {
+	errs      map[string]error
+	streamErr map[string]string
+	calls     []string
+}
+
+func (m *modelLLM) ChatStream(_ context.Context, _ string, _ string, _ string, _ []protocols.LLMMessage, model string, _ []types.Tool, _ string, _ types.GenerationOptions) (<-chan types.StreamEvent, error) {
+	m.calls = append(m.calls, model)
+	if err := m.errs[model]; err != nil {
+		return nil, err
+	}
+	ch := make(chan types.StreamEvent, 2)
+	if msg, ok := m.streamErr[model]; ok {
+		ch <- types.StreamEvent{Type: "error", Delta: msg}
+	} else {
+		ch <- types.StreamEvent{Type: "text", Delta: "ok from " + model}
+	}
+	close(ch)
+	return ch, nil
+}
+
+func withFallbacks() ActionInput {
+	return ActionInput{
+		ConnectionID: "ollama", Model: "llama",
+		Fallbacks: []Fallback{
+			{ConnectionID: "openai", ModelID: "m-gpt", ModelName: "gpt"},
+			{ConnectionID: "anthropic", ModelID: "m-claude", ModelName: "claude"},
+		},
+	}
+}
+
+func TestAgentAction_FailsOverOnProviderError(t *testing.T) {
+	llm := &modelLLM{errs: map[string]error{"llama": errors.New("LLM API error 503: model is loading")}}
+	ch, err := NewAgentAction(llm, nil).Execute(context.Background(), withFallbacks())
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := collect(ch)
+	if len(events) != 2 || events[0].Type != "failover" || events[1].Delta != "ok from gpt" {
+		t.Fatalf("events = %+v", events)
+	}
+	if ev := events[0]; ev.ModelID != "m-gpt" || ev.ModelName != "gpt" || ev.ModelRole != "fallback" {
+		t.Fatalf("failover event = %+v", ev)
+	}
+}
+
+func TestAgentAction_FailsOverOnStreamErrorAlongChain(t *testing.T) {
+	llm := &modelLLM{
+		errs:      map[string]error{"llama": errors.New("dial tcp 10.0.0.5:11434: connect: connection refused")},
+		streamErr: map[string]string{"gpt": "insufficient_quota: You exceeded your current quota"},
+	}
+	ch, err := NewAgentAction(llm, nil).Execute(context.Background(), withFallbacks())
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := collect(ch)
+	if len(events) != 2 || events[0].ModelName != "claude" || events[1].Delta != "ok from claude" {
+		t.Fatalf("events = %+v", events)
+	}
+	if len(llm.calls) != 3 {
+		t.Fatalf("calls = %v", llm.calls)
+	}
+}
+
+func TestAgentAction_DoesNotFailOverOnRequestError(t *testing.T) {
+	llm := &modelLLM{errs: map[string]error{"llama": errors.New("LLM API error 400: invalid tool schema")}}
+	if _, err := NewAgentAction(llm, nil).Execute(context.Background(), withFallbacks()); err == nil {
+		t.Fatal("expected the request error")
+	}
+	if len(llm.calls) != 1 {
+		t.Fatalf("calls = %v", llm.calls)
+	}
+}
+
+func TestAgentAction_AllFallbacksFail(t *testing.T) {
+	down := errors.New("LLM API error 502: bad gateway")
+	llm := &modelLLM{errs: map[string]error{"llama": down, "gpt": down, "claude": down}}
+	if _, err := NewAgentAction(llm, nil).Execute(context.Background(), withFallbacks()); err == nil {
+		t.Fatal("expected an error")
+	}
+	if len(llm.calls) != 3 {
+		t.Fatalf("calls = %v", llm.calls)
+	}
+}
+
+func TestAgentAction_OpenCircuitSkipsPrimary(t *testing.T) {
+	breaker := NewBreaker(2, time.Minute)
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	breaker.now = func() time.Time { return now }
+	llm := &modelLLM{errs: map[string]error{"llama": errors.New("LLM API error 500: internal error")}}
+	action := NewAgentAction(llm, breaker)
+
+	for i := 0; i < 2; i++ {
+		if _, err := action.Execute(context.Background(), withFallbacks()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	llm.calls = nil
+	ch, err := action.Execute(context.Background(), withFallbacks())
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := collect(ch)
+	if len(llm.calls) != 1 || llm.calls[0] != "gpt" {
+		t.Fatalf("calls with open circuit = %v", llm.calls)
+	}
+	if events[0].Type != "failover" {
+		t.Fatalf("events = %+v", events)
+	}
+
+	// After the cooldown one probe reaches the primary again; it recovered.
+	now = now.Add(time.Minute)
+	delete(llm.errs, "llama")
+	llm.calls = nil
+	ch, err = action.Execute(context.Background(), withFallbacks())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if events := collect(ch); len(llm.calls) != 1 || llm.calls[0] != "llama" || events[0].Type != "text" {
+		t.Fatalf("probe calls = %v, events = %+v", llm.calls, events)
+	}
+	if _, open := breaker.OpenUntil("ollama"); open {
+		t.Fatal("circuit should be closed after a successful probe")
+	}
+}
+
+func TestBreaker_HalfOpenLetsOneProbeThrough(t *testing.T) {
+	b := NewBreaker(1, time.Minute)
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	b.now = func() time.Time { return now }
+
+	if !b.Failure("c") {
+		t.Fatal("threshold 1 should open the circuit")
+	}
+	if b.Allow("c") {
+		t.Fatal("open circuit allowed a request")
+	}
+	now = now.Add(time.Minute)
+	if !b.Allow("c") || b.Allow("c") {
+		t.Fatal("expected exactly one probe after cooldown")
+	}
+	b.Failure("c")
+	if until, open := b.OpenUntil("c"); !open || !until.Equal(now.Add(time.Minute)) {
+		t.Fatalf("failed probe: open=%v until=%v", open, until)
+	}
+	if !b.Allow("other") {
+		t.Fatal("circuits are per connection")
+	}
+}
+
+func TestAgentLoop_StaysOnFallbackAfterFailover(t *testing.T) {
+	llm := &modelLLM{errs: map[string]error{"llama": errors.New("LLM API error 503: unavailable")}}
+	scripted := &scriptedLLM{streams: [][]types.StreamEvent{
+		{{Type: "tool_calls", ToolCalls: []types.ToolCall{{ID: "1", Name: "sum"}}}},
+		{{Type: "text", Delta: "done"}},
+	}}
+	router := llmFunc(func(ctx context.Context, model string) (<-chan types.StreamEvent, error) {
+		if _, err := llm.ChatStream(ctx, "", "", "", nil, model, nil, "", types.GenerationOptions{}); err != nil {
+			return nil, err
+		}
+		return scripted.ChatStream(ctx, "", "", "", nil, model, nil, "", types.GenerationOptions{})
+	})
+
+	in := withFallbacks()
+	in.Tools = []types.Tool{{Name: "sum", Execute: func(context.Context, string) (string, error) { return "2", nil }}}
+	ch, err := NewAgentLoop(NewAgentAction(router, nil), nil).Execute(context.Background(), LoopInput{ActionInput: in, MaxIterations: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	collect(ch)
+	want := []string{"llama", "gpt", "gpt"}
+	if len(llm.calls) != len(want) {
+		t.Fatalf("calls = %v, want %v", llm.calls, want)
+	}
+	for i := range want {
+		if llm.calls[i] != want[i] {
+			t.Fatalf("calls = %v, want %v", llm.calls, want)
+		}
+	}
+}
+
+type llmFunc func(ctx context.Context, model string) (<-chan types.StreamEvent, error)
+
+func (f llmFunc) ChatStream(ctx context.Context, _ string, _ string, _ string, _ []protocols.LLMMessage, model string, _ []types.Tool, _ string, _ types.GenerationOptions) (<-chan types.StreamEvent, error) {
+	return f(ctx, model)
+}
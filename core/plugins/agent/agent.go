@@ -15,9 +15,9 @@ type Agent struct {
 	loop *AgentLoop
 }
 
-func New(llm protocols.LLM) *Agent {
-	action := NewAgentAction(llm)
-	return &Agent{loop: NewAgentLoop(action)}
+func New(llm protocols.LLM, breaker *Breaker, stats *ToolCallStats) *Agent {
+	action := NewAgentAction(llm, breaker)
+	return &Agent{loop: NewAgentLoop(action, stats)}
 }
 
 func (a *Agent) Execute(ctx context.Context, in AgentInput) (<-chan types.StreamEvent, error) {
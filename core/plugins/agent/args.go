@@ -0,0 +1,400 @@
+package agent
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxViolations caps how many schema violations are reported back at once.
+const maxViolations = 5
+
+// checkArgs validates a tool call's arguments against the tool's parameter
+// schema. Arguments that aren't valid JSON are repaired where the intent is
+// clear (code fences, trailing commas, unclosed brackets, an object sent as
+// a JSON string), and scalars of the wrong type are converted when they
+// convert losslessly ("5" for an integer, "true" for a boolean). It returns
+// the arguments to call the tool with, whether they had to be repaired, and
+// the violations the model has to fix when they can't be used. Tools without
+// a schema get raw unchanged.
+func checkArgs(schema map[string]any, raw string) (string, bool, error) {
+	if len(schema) == 0 {
+		return raw, false, nil
+	}
+	text := strings.TrimSpace(raw)
+	if text == "" {
+		text = "{}"
+	}
+	v, err := decodeArgs(text)
+	repaired := false
+	if err != nil {
+		fixed := repairJSON(text)
+		v, err = decodeArgs(fixed)
+		if err != nil {
+			return raw, false, fmt.Errorf("arguments are not valid JSON: %v", err)
+		}
+		repaired = true
+	}
+	if s, ok := v.(string); ok && slices.Contains(schemaTypes(schema), "object") {
+		// Arguments encoded twice, as a JSON string holding the object.
+		if inner, err := decodeArgs(s); err == nil {
+			v, repaired = inner, true
+		}
+	}
+
+	v, coerced := coerce(v, schema)
+	var violations []string
+	validate(v, schema, "$", &violations)
+	if len(violations) > 0 {
+		if len(violations) > maxViolations {
+			violations = append(violations[:maxViolations], fmt.Sprintf("and %d more", len(violations)-maxViolations))
+		}
+		return raw, repaired || coerced, fmt.Errorf("arguments do not match the tool schema: %s", strings.Join(violations, "; "))
+	}
+	if !repaired && !coerced {
+		return text, false, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return raw, false, err
+	}
+	return string(data), repaired || coerced, nil
+}
+
+func decodeArgs(text string) (any, error) {
+	dec := json.NewDecoder(strings.NewReader(text))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("unexpected data after the arguments object")
+	}
+	return v, nil
+}
+
+var fenceRe = regexp.MustCompile("(?s)^```[a-zA-Z]*\\s*(.*?)\\s*```$")
+
+// repairJSON fixes the mistakes models commonly make in tool arguments:
+// a markdown code fence or prose around the object, trailing commas, and
+// brackets left open at the end. Everything inside strings is kept as is.
+func repairJSON(text string) string {
+	if m := fenceRe.FindStringSubmatch(text); m != nil {
+		text = m[1]
+	}
+	if i := strings.IndexAny(text, "{["); i > 0 {
+		text = text[i:]
+	}
+
+	var out bytes.Buffer
+	var open []byte
+	inString, escaped := false, false
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		if inString {
+			out.WriteByte(c)
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			inString = true
+		case '{':
+			open = append(open, '}')
+		case '[':
+			open = append(open, ']')
+		case '}', ']':
+			trimTrailingComma(&out)
+			if len(open) > 0 {
+				open = open[:len(open)-1]
+			}
+			if len(open) == 0 {
+				out.WriteByte(c)
+				return out.String()
+			}
+		}
+		out.WriteByte(c)
+	}
+	if inString {
+		out.WriteByte('"')
+	}
+	for i := len(open) - 1; i >= 0; i-- {
+		trimTrailingComma(&out)
+		out.WriteByte(open[i])
+	}
+	return out.String()
+}
+
+func trimTrailingComma(b *bytes.Buffer) {
+	s := bytes.TrimRight(b.Bytes(), " \t\r\n")
+	if len(s) > 0 && s[len(s)-1] == ',' {
+		b.Truncate(len(s) - 1)
+	}
+}
+
+// coerce converts scalars to the type their schema asks for when that loses
+// nothing, and reports whether it changed anything.
+func coerce(v any, schema map[string]any) (any, bool) {
+	want := schemaTypes(schema)
+	switch val := v.(type) {
+	case map[string]any:
+		props, _ := schema["properties"].(map[string]any)
+		changed := false
+		for k, item := range val {
+			sub, ok := props[k].(map[string]any)
+			if !ok {
+				continue
+			}
+			next, c := coerce(item, sub)
+			if c {
+				val[k], changed = next, true
+			}
+		}
+		return val, changed
+	case []any:
+		sub, ok := schema["items"].(map[string]any)
+		if !ok {
+			return val, false
+		}
+		changed := false
+		for i, item := range val {
+			next, c := coerce(item, sub)
+			if c {
+				val[i], changed = next, true
+			}
+		}
+		return val, changed
+	case string:
+		if len(want) == 0 || slices.Contains(want, "string") {
+			return val, false
+		}
+		s := strings.TrimSpace(val)
+		switch {
+		case slices.Contains(want, "integer"):
+			if _, err := strconv.ParseInt(s, 10, 64); err == nil {
+				return json.Number(s), true
+			}
+		case slices.Contains(want, "number"):
+			if _, err := strconv.ParseFloat(s, 64); err == nil {
+				return json.Number(s), true
+			}
+		case slices.Contains(want, "boolean"):
+			if b, err := strconv.ParseBool(s); err == nil {
+				return b, true
+			}
+		}
+	case json.Number:
+		if len(want) == 1 && want[0] == "string" {
+			return val.String(), true
+		}
+	case bool:
+		if len(want) == 1 && want[0] == "string" {
+			return strconv.FormatBool(val), true
+		}
+	}
+	return v, false
+}
+
+// validate checks v against the subset of JSON Schema tool definitions use:
+// type, properties, required, additionalProperties, items, enum, minimum,
+// maximum, minLength, maxLength, minItems and maxItems.
+func validate(v any, schema map[string]any, path string, violations *[]string) {
+	if want := schemaTypes(schema); len(want) > 0 && !slices.ContainsFunc(want, func(t string) bool { return hasType(v, t) }) {
+		*violations = append(*violations, fmt.Sprintf("%s: expected %s, got %s", path, strings.Join(want, " or "), typeName(v)))
+		return
+	}
+	if enum := schemaList(schema["enum"]); len(enum) > 0 && !slices.ContainsFunc(enum, func(e any) bool { return equalJSON(e, v) }) {
+		*violations = append(*violations, fmt.Sprintf("%s: must be one of %s", path, formatEnum(enum)))
+	}
+
+	switch val := v.(type) {
+	case map[string]any:
+		props, _ := schema["properties"].(map[string]any)
+		for _, name := range schemaList(schema["required"]) {
+			key, _ := name.(string)
+			if _, ok := val[key]; !ok {
+				*violations = append(*violations, fmt.Sprintf("%s: missing required property %q", path, key))
+			}
+		}
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			sub, ok := props[k].(map[string]any)
+			if !ok {
+				if extra, set := schema["additionalProperties"].(bool); set && !extra {
+					*violations = append(*violations, fmt.Sprintf("%s: unknown property %q", path, k))
+				}
+				continue
+			}
+			validate(val[k], sub, path+"."+k, violations)
+		}
+	case []any:
+		if n, ok := schemaNumber(schema["minItems"]); ok && float64(len(val)) < n {
+			*violations = append(*violations, fmt.Sprintf("%s: needs at least %v items", path, n))
+		}
+		if n, ok := schemaNumber(schema["maxItems"]); ok && float64(len(val)) > n {
+			*violations = append(*violations, fmt.Sprintf("%s: allows at most %v items", path, n))
+		}
+		if sub, ok := schema["items"].(map[string]any); ok {
+			for i, item := range val {
+				validate(item, sub, fmt.Sprintf("%s[%d]", path, i), violations)
+			}
+		}
+	case string:
+		if n, ok := schemaNumber(schema["minLength"]); ok && float64(len([]rune(val))) < n {
+			*violations = append(*violations, fmt.Sprintf("%s: must be at least %v characters", path, n))
+		}
+		if n, ok := schemaNumber(schema["maxLength"]); ok && float64(len([]rune(val))) > n {
+			*violations = append(*violations, fmt.Sprintf("%s: must be at most %v characters", path, n))
+		}
+	case json.Number:
+		f, _ := val.Float64()
+		if n, ok := schemaNumber(schema["minimum"]); ok && f < n {
+			*violations = append(*violations, fmt.Sprintf("%s: must be >= %v", path, n))
+		}
+		if n, ok := schemaNumber(schema["maximum"]); ok && f > n {
+			*violations = append(*violations, fmt.Sprintf("%s: must be <= %v", path, n))
+		}
+	}
+}
+
+func schemaTypes(schema map[string]any) []string {
+	switch t := schema["type"].(type) {
+	case string:
+		return []string{t}
+	case []string:
+		return t
+	case []any:
+		var out []string
+		for _, item := range t {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+// schemaList reads a list keyword, which Go-defined schemas hold as a typed
+// slice and decoded ones as []any.
+func schemaList(v any) []any {
+	switch l := v.(type) {
+	case []any:
+		return l
+	case []string:
+		out := make([]any, len(l))
+		for i, s := range l {
+			out[i] = s
+		}
+		return out
+	case []int:
+		out := make([]any, len(l))
+		for i, n := range l {
+			out[i] = n
+		}
+		return out
+	}
+	return nil
+}
+
+func schemaNumber(v any) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func hasType(v any, t string) bool {
+	switch t {
+	case "object":
+		_, ok := v.(map[string]any)
+		return ok
+	case "array":
+		_, ok := v.([]any)
+		return ok
+	case "string":
+		_, ok := v.(string)
+		return ok
+	case "boolean":
+		_, ok := v.(bool)
+		return ok
+	case "null":
+		return v == nil
+	case "number":
+		_, ok := v.(json.Number)
+		return ok
+	case "integer":
+		n, ok := v.(json.Number)
+		if !ok {
+			return false
+		}
+		if _, err := n.Int64(); err == nil {
+			return true
+		}
+		f, err := n.Float64()
+		return err == nil && f == math.Trunc(f)
+	}
+	return true
+}
+
+func typeName(v any) string {
+	switch val := v.(type) {
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case nil:
+		return "null"
+	case json.Number:
+		if hasType(val, "integer") {
+			return "integer"
+		}
+		return "number"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func equalJSON(a, b any) bool {
+	x, _ := json.Marshal(a)
+	y, _ := json.Marshal(b)
+	return bytes.Equal(x, y)
+}
+
+func formatEnum(enum []any) string {
+	parts := make([]string, len(enum))
+	for i, e := range enum {
+		data, _ := json.Marshal(e)
+		parts[i] = string(data)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,127 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"mantis/core/protocols"
+	"mantis/core/types"
+)
+
+var sshSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"task":    map[string]any{"type": "string", "minLength": 1},
+		"timeout": map[string]any{"type": "integer", "minimum": 1},
+		"mode":    map[string]any{"type": "string", "enum": []string{"read", "write"}},
+		"hosts":   map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+		"dryRun":  map[string]any{"type": "boolean"},
+	},
+	"required":             []string{"task"},
+	"additionalProperties": false,
+}
+
+func TestCheckArgs_Repairs(t *testing.T) {
+	cases := map[string]string{
+		"trailing commas":   `{"task": "df -h", "hosts": ["web",],}`,
+		"code fence":        "```json\n{\"task\": \"df -h\", \"hosts\": [\"web\"]}\n```",
+		"prose around":      `Sure: {"task": "df -h", "hosts": ["web"]} done`,
+		"unclosed":          `{"task": "df -h", "hosts": ["web"`,
+		"encoded twice":     `"{\"task\": \"df -h\", \"hosts\": [\"web\"]}"`,
+		"string for number": `{"task": "df -h", "hosts": ["web"], "timeout": "30"}`,
+	}
+	for name, raw := range cases {
+		t.Run(name, func(t *testing.T) {
+			args, repaired, err := checkArgs(sshSchema, raw)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !repaired {
+				t.Error("expected the call to count as repaired")
+			}
+			if !strings.Contains(args, `"task":"df -h"`) || !strings.Contains(args, `"hosts":["web"]`) {
+				t.Errorf("args = %s", args)
+			}
+			if name == "string for number" && !strings.Contains(args, `"timeout":30`) {
+				t.Errorf("timeout not converted: %s", args)
+			}
+		})
+	}
+}
+
+func TestCheckArgs_ValidPassesThrough(t *testing.T) {
+	raw := `{"timeout": 5, "task": "uptime"}`
+	args, repaired, err := checkArgs(sshSchema, raw)
+	if err != nil || repaired || args != raw {
+		t.Fatalf("args = %s, repaired = %v, err = %v", args, repaired, err)
+	}
+	if args, _, err := checkArgs(nil, "1+1"); err != nil || args != "1+1" {
+		t.Fatalf("tools without a schema get raw arguments, got %q, %v", args, err)
+	}
+}
+
+func TestCheckArgs_Violations(t *testing.T) {
+	cases := map[string]struct{ raw, want string }{
+		"missing required": {`{"timeout": 5}`, `$: missing required property "task"`},
+		"wrong type":       {`{"task": "x", "dryRun": "maybe"}`, "$.dryRun: expected boolean, got string"},
+		"enum":             {`{"task": "x", "mode": "delete"}`, `$.mode: must be one of "read", "write"`},
+		"minimum":          {`{"task": "x", "timeout": 0}`, "$.timeout: must be >= 1"},
+		"fraction":         {`{"task": "x", "timeout": 1.5}`, "$.timeout: expected integer, got number"},
+		"unknown property": {`{"task": "x", "command": "ls"}`, `$: unknown property "command"`},
+		"item type":        {`{"task": "x", "hosts": ["web", {"name": "db"}]}`, "$.hosts[1]: expected string, got object"},
+		"empty string":     {`{"task": ""}`, "$.task: must be at least 1 characters"},
+		"not json":         {`task=df`, "arguments are not valid JSON"},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, _, err := checkArgs(sshSchema, c.raw)
+			if err == nil || !strings.Contains(err.Error(), c.want) {
+				t.Fatalf("err = %v, want %q", err, c.want)
+			}
+		})
+	}
+}
+
+func TestAgentLoop_RejectsInvalidArgumentsWithoutRunningTool(t *testing.T) {
+	var seen []protocols.LLMMessage
+	llm := &scriptedLLM{streams: [][]types.StreamEvent{
+		{{Type: "tool_calls", ToolCalls: []types.ToolCall{{ID: "1", Name: "ssh", Arguments: `{"timeout": 5}`}}}},
+		{{Type: "tool_calls", ToolCalls: []types.ToolCall{{ID: "2", Name: "ssh", Arguments: `{"task": "uptime",}`}}}},
+		{{Type: "text", Delta: "done"}},
+	}}
+	llm.onCall = func(messages []protocols.LLMMessage) { seen = messages }
+
+	var ran []string
+	stats := NewToolCallStats()
+	loop := NewAgentLoop(NewAgentAction(llm, nil), stats)
+	ch, err := loop.Execute(context.Background(), LoopInput{
+		ActionInput: ActionInput{
+			ModelID: "m1", Model: "local-7b",
+			Messages: []protocols.LLMMessage{{Role: "user", Content: "uptime?"}},
+			Tools: []types.Tool{{
+				Name: "ssh", Parameters: sshSchema,
+				Execute: func(_ context.Context, args string) (string, error) {
+					ran = append(ran, args)
+					return "up 3 days", nil
+				},
+			}},
+		},
+		MaxIterations: 3,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	collect(ch)
+
+	if len(ran) != 1 || ran[0] != `{"task":"uptime"}` {
+		t.Fatalf("tool ran with %q", ran)
+	}
+	if len(seen) < 3 || !strings.Contains(seen[2].Content, `missing required property "task"`) || !strings.Contains(seen[2].Content, "not run") {
+		t.Fatalf("model was not told about the violation: %+v", seen)
+	}
+	got := stats.Snapshot()
+	if len(got) != 1 || got[0].ModelName != "local-7b" || got[0].Calls != 2 || got[0].Invalid != 1 || got[0].Repaired != 1 || got[0].InvalidRate != 0.5 {
+		t.Fatalf("stats = %+v", got)
+	}
+}
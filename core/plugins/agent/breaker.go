@@ -0,0 +1,116 @@
+package agent
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	defaultBreakerThreshold = 3
+	defaultBreakerCooldown  = time.Minute
+)
+
+// Breaker is a circuit breaker per LLM connection. After threshold
+// consecutive failover errors a connection is open: requests go straight to
+// the fallback models until cooldown has passed, when one request is let
+// through to probe it. A success closes the circuit, a failed probe opens it
+// for another cooldown.
+type Breaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	circuits  map[string]*circuit
+	now       func() time.Time
+}
+
+type circuit struct {
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+func NewBreaker(threshold int, cooldown time.Duration) *Breaker {
+	if threshold <= 0 {
+		threshold = defaultBreakerThreshold
+	}
+	if cooldown <= 0 {
+		cooldown = defaultBreakerCooldown
+	}
+	return &Breaker{threshold: threshold, cooldown: cooldown, circuits: map[string]*circuit{}, now: time.Now}
+}
+
+// Allow reports whether a request may go to the connection. Once the
+// cooldown is over, only the first caller gets through until it reports back.
+func (b *Breaker) Allow(connectionID string) bool {
+	if b == nil || connectionID == "" {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c, ok := b.circuits[connectionID]
+	if !ok || c.failures < b.threshold {
+		return true
+	}
+	if b.now().Before(c.openUntil) || c.probing {
+		return false
+	}
+	c.probing = true
+	return true
+}
+
+func (b *Breaker) Success(connectionID string) {
+	if b == nil || connectionID == "" {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.circuits, connectionID)
+}
+
+// Failure counts a failover error and reports whether it opened the circuit.
+func (b *Breaker) Failure(connectionID string) bool {
+	if b == nil || connectionID == "" {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c, ok := b.circuits[connectionID]
+	if !ok {
+		c = &circuit{}
+		b.circuits[connectionID] = c
+	}
+	c.failures++
+	c.probing = false
+	if c.failures < b.threshold {
+		return false
+	}
+	c.openUntil = b.now().Add(b.cooldown)
+	return true
+}
+
+// Release gives up a probe that ended without a verdict, e.g. because the
+// request was cancelled, so the next request can probe instead.
+func (b *Breaker) Release(connectionID string) {
+	if b == nil || connectionID == "" {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if c, ok := b.circuits[connectionID]; ok {
+		c.probing = false
+	}
+}
+
+// OpenUntil returns when an open circuit lets the next probe through.
+func (b *Breaker) OpenUntil(connectionID string) (time.Time, bool) {
+	if b == nil {
+		return time.Time{}, false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c, ok := b.circuits[connectionID]
+	if !ok || c.failures < b.threshold {
+		return time.Time{}, false
+	}
+	return c.openUntil, true
+}
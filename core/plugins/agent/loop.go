@@ -21,14 +21,22 @@ type LoopInput struct {
 	MaxIterations int
 	MessageID     string
 	ToolsProvider func(context.Context) []types.Tool
+
+	// EscalateTo is the model the loop moves to for the rest of the request
+	// once EscalateAfter tool iterations have failed, each with every tool
+	// call in it failing. It is resolved on its own rather than looked up in
+	// Fallbacks. A nil EscalateTo or zero EscalateAfter never escalates.
+	EscalateTo    *Fallback
+	EscalateAfter int
 }
 
 type AgentLoop struct {
 	action *AgentAction
+	stats  *ToolCallStats
 }
 
-func NewAgentLoop(action *AgentAction) *AgentLoop {
-	return &AgentLoop{action: action}
+func NewAgentLoop(action *AgentAction, stats *ToolCallStats) *AgentLoop {
+	return &AgentLoop{action: action, stats: stats}
 }
 
 func (l *AgentLoop) Execute(ctx context.Context, in LoopInput) (<-chan types.StreamEvent, error) {
@@ -49,6 +57,9 @@ func (l *AgentLoop) Execute(ctx context.Context, in LoopInput) (<-chan types.Str
 
 		messages := make([]protocols.LLMMessage, len(in.Messages))
 		copy(messages, in.Messages)
+		active := in.ActionInput
+		failedIters, escalated := 0, false
+		stuck := newStuckDetector()
 
 		for iter := 0; iter < maxIter; iter++ {
 			if in.ToolsProvider != nil {
@@ -58,12 +69,9 @@ func (l *AgentLoop) Execute(ctx context.Context, in LoopInput) (<-chan types.Str
 					toolMap[t.Name] = t
 				}
 			}
-			actionCh, err := l.action.Execute(ctx, ActionInput{
-				Provider: in.Provider,
-				BaseURL:  in.BaseURL, APIKey: in.APIKey,
-				Model: in.Model, Messages: messages, Tools: tools,
-				ThinkingMode: in.ThinkingMode,
-			})
+			call := active
+			call.Messages, call.Tools = messages, tools
+			actionCh, err := l.action.Execute(ctx, call)
 			if err != nil {
 				ch <- types.StreamEvent{Type: "error", Delta: err.Error(), Iteration: iter, IsFinal: true}
 				return
@@ -71,6 +79,7 @@ func (l *AgentLoop) Execute(ctx context.Context, in LoopInput) (<-chan types.Str
 
 			var reply strings.Builder
 			var toolCalls []types.ToolCall
+			var thinking []types.ThinkingBlock
 
 			for event := range actionCh {
 				event.Iteration = iter
@@ -80,6 +89,14 @@ func (l *AgentLoop) Execute(ctx context.Context, in LoopInput) (<-chan types.Str
 					ch <- event
 				case "thinking":
 					ch <- event
+				case "failover":
+					active = active.promote(event.ModelID)
+					shared.SetFallbackModel(ctx, event.ModelID, event.ModelName)
+					ch <- event
+				case "thinking_block":
+					if event.Thinking != nil {
+						thinking = append(thinking, *event.Thinking)
+					}
 				case "tool_calls":
 					toolCalls = event.ToolCalls
 				case "error":
@@ -96,17 +113,31 @@ func (l *AgentLoop) Execute(ctx context.Context, in LoopInput) (<-chan types.Str
 				Role:      "assistant",
 				Content:   reply.String(),
 				ToolCalls: toolCalls,
+				Thinking:  thinking,
 			})
 
+			iterFailed := true
+			results := make([]string, 0, len(toolCalls))
 			for _, tc := range toolCalls {
 				tool, ok := toolMap[tc.Name]
 				if !ok {
+					l.stats.Record(active.ModelID, active.Model, false, true)
+					results = append(results, "error: unknown tool "+tc.Name)
 					messages = append(messages, protocols.LLMMessage{
-						Role: "tool", ToolCallID: tc.ID,
-						Content: "error: unknown tool " + tc.Name,
+						Role: "tool", ToolCallID: tc.ID, Content: results[len(results)-1],
 					})
 					continue
 				}
+				args, repaired, argsErr := checkArgs(tool.Parameters, tc.Arguments)
+				l.stats.Record(active.ModelID, active.Model, repaired, argsErr != nil)
+				if argsErr != nil {
+					results = append(results, fmt.Sprintf("error: invalid call to %s, the tool was not run: %v. Call it again with corrected arguments.", tc.Name, argsErr))
+					messages = append(messages, protocols.LLMMessage{
+						Role: "tool", ToolCallID: tc.ID, Content: results[len(results)-1],
+					})
+					continue
+				}
+				tc.Arguments = args
 
 				stepID := uuid.New().String()
 				label := tc.Name
@@ -172,11 +203,37 @@ func (l *AgentLoop) Execute(ctx context.Context, in LoopInput) (<-chan types.Str
 					ev.ModelRole = meta.ModelRole
 				}
 				ch <- ev
+				if !strings.HasPrefix(result, "error:") {
+					iterFailed = false
+				}
+				results = append(results, result)
 
 				messages = append(messages, protocols.LLMMessage{
 					Role: "tool", ToolCallID: tc.ID, Content: result,
 				})
 			}
+
+			if reason := stuck.observe(toolCalls, results); reason != "" {
+				if stuck.warned {
+					ch <- types.StreamEvent{Type: "error", Delta: shared.AgentStuckPrefix + reason, Iteration: iter, IsFinal: true}
+					return
+				}
+				messages = append(messages, protocols.LLMMessage{Role: "system", Content: stuck.warn(reason)})
+			}
+
+			if iterFailed {
+				failedIters++
+			}
+			if !escalated && in.EscalateTo != nil && in.EscalateAfter > 0 && failedIters >= in.EscalateAfter {
+				escalated = true
+				if in.EscalateTo.ModelID != active.ModelID {
+					active = active.escalate(*in.EscalateTo)
+					ch <- types.StreamEvent{
+						Type: "escalation", Iteration: iter, ModelID: active.ModelID, ModelName: active.Model, ModelRole: "primary",
+						Delta: fmt.Sprintf("escalated to %s after %d failed tool iterations", active.Model, failedIters),
+					}
+				}
+			}
 		}
 
 		ch <- types.StreamEvent{Type: "error", Delta: fmt.Sprintf("max iterations reached: %d", maxIter), IsFinal: true}
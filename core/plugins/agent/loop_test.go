@@ -2,6 +2,7 @@ package agent
 
 import (
 	"context"
+	"errors"
 	"strings"
 	"testing"
 
@@ -12,9 +13,15 @@ import (
 type scriptedLLM struct {
 	streams [][]types.StreamEvent
 	calls   int
+	models  []string
+	onCall  func(messages []protocols.LLMMessage)
 }
 
-func (s *scriptedLLM) ChatStream(_ context.Context, _ string, _ string, _ string, _ []protocols.LLMMessage, _ string, _ []types.Tool, _ string) (<-chan types.StreamEvent, error) {
+func (s *scriptedLLM) ChatStream(_ context.Context, _ string, _ string, _ string, messages []protocols.LLMMessage, model string, _ []types.Tool, _ string, _ types.GenerationOptions) (<-chan types.StreamEvent, error) {
+	s.models = append(s.models, model)
+	if s.onCall != nil {
+		s.onCall(messages)
+	}
 	ch := make(chan types.StreamEvent, 8)
 	idx := s.calls
 	s.calls++
@@ -51,7 +58,7 @@ func TestAgentLoop_ExecutesToolAndStopsWithoutError(t *testing.T) {
 	}
 
 	var gotArgs string
-	loop := NewAgentLoop(NewAgentAction(llm))
+	loop := NewAgentLoop(NewAgentAction(llm, nil), nil)
 	ch, err := loop.Execute(context.Background(), LoopInput{
 		ActionInput: ActionInput{
 			Messages: []protocols.LLMMessage{{Role: "user", Content: "x"}},
@@ -103,7 +110,7 @@ func TestAgentLoop_MaxIterationsReached(t *testing.T) {
 		},
 	}
 
-	loop := NewAgentLoop(NewAgentAction(llm))
+	loop := NewAgentLoop(NewAgentAction(llm, nil), nil)
 	ch, err := loop.Execute(context.Background(), LoopInput{
 		ActionInput: ActionInput{
 			Tools: []types.Tool{
@@ -132,3 +139,72 @@ func TestAgentLoop_MaxIterationsReached(t *testing.T) {
 		t.Fatal("expected max iterations error")
 	}
 }
+
+func TestAgentLoop_EscalatesAfterFailedToolIterations(t *testing.T) {
+	failing := func(id string) []types.StreamEvent {
+		return []types.StreamEvent{{Type: "tool_calls", ToolCalls: []types.ToolCall{{ID: id, Name: "ssh", Arguments: "{}"}}}}
+	}
+	llm := &scriptedLLM{streams: [][]types.StreamEvent{
+		failing("1"), failing("2"), failing("3"), {{Type: "text", Delta: "fixed"}},
+	}}
+
+	loop := NewAgentLoop(NewAgentAction(llm, nil), nil)
+	ch, err := loop.Execute(context.Background(), LoopInput{
+		ActionInput: ActionInput{
+			ModelID: "m-small", Model: "small",
+			Messages:  []protocols.LLMMessage{{Role: "user", Content: "check web"}},
+			Fallbacks: []Fallback{{ModelID: "m-big", ModelName: "big"}},
+			Tools: []types.Tool{{Name: "ssh", Execute: func(context.Context, string) (string, error) {
+				return "", errors.New("connection refused")
+			}}},
+		},
+		MaxIterations: 5,
+		EscalateTo:    &Fallback{ModelID: "m-big", ModelName: "big"},
+		EscalateAfter: 2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var escalations []types.StreamEvent
+	for _, ev := range collect(ch) {
+		if ev.Type == "escalation" {
+			escalations = append(escalations, ev)
+		}
+	}
+	if len(escalations) != 1 || escalations[0].ModelID != "m-big" || !strings.Contains(escalations[0].Delta, "2 failed tool iterations") {
+		t.Fatalf("escalations = %+v", escalations)
+	}
+	if strings.Join(llm.models, ",") != "small,small,big,big" {
+		t.Fatalf("models called = %v", llm.models)
+	}
+}
+
+func TestAgentLoop_EscalatesOutsideTheFallbackChain(t *testing.T) {
+	// The routed model is the preset's fallback model, so its chain no
+	// longer holds the chat model it escalates to.
+	failing := []types.StreamEvent{{Type: "tool_calls", ToolCalls: []types.ToolCall{{ID: "1", Name: "ssh", Arguments: "{}"}}}}
+	llm := &scriptedLLM{streams: [][]types.StreamEvent{failing, {{Type: "text", Delta: "fixed"}}}}
+
+	loop := NewAgentLoop(NewAgentAction(llm, nil), nil)
+	ch, err := loop.Execute(context.Background(), LoopInput{
+		ActionInput: ActionInput{
+			ModelID: "m-small", Model: "small",
+			Messages:  []protocols.LLMMessage{{Role: "user", Content: "check web"}},
+			Fallbacks: []Fallback{{ModelID: "m-other", ModelName: "other"}},
+			Tools: []types.Tool{{Name: "ssh", Execute: func(context.Context, string) (string, error) {
+				return "", errors.New("connection refused")
+			}}},
+		},
+		MaxIterations: 3,
+		EscalateTo:    &Fallback{ModelID: "m-big", ModelName: "big"},
+		EscalateAfter: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	collect(ch)
+	if strings.Join(llm.models, ",") != "small,big" {
+		t.Fatalf("models called = %v", llm.models)
+	}
+}
@@ -0,0 +1,224 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"mantis/core/protocols"
+	"mantis/core/types"
+)
+
+const (
+	toolCallOpen    = "<tool_call>"
+	toolCallClose   = "</tool_call>"
+	toolResultClose = "</tool_result>"
+)
+
+const promptToolsHeader = `# Tools
+
+You can call the tools listed below. To call a tool, write a block like this and nothing else on its lines:
+
+<tool_call>
+{"name": "<tool name>", "arguments": {<arguments as a JSON object>}}
+</tool_call>
+
+Write one block per call. After your tool calls, stop and wait: the results come back in the next message inside <tool_result> blocks. When you need no tool, answer normally without a block.
+
+## Available tools
+`
+
+// promptToolMessages prepares a conversation for a model without native
+// function calling: the tool list is appended to the system prompt, earlier
+// tool calls are written back as <tool_call> blocks, and tool results become
+// user messages with <tool_result> blocks.
+func promptToolMessages(messages []protocols.LLMMessage, tools []types.Tool) []protocols.LLMMessage {
+	out := make([]protocols.LLMMessage, 0, len(messages)+1)
+	names := map[string]string{}
+	for _, m := range messages {
+		switch {
+		case m.Role == "assistant" && len(m.ToolCalls) > 0:
+			var sb strings.Builder
+			sb.WriteString(m.Content)
+			for _, tc := range m.ToolCalls {
+				names[tc.ID] = tc.Name
+				if sb.Len() > 0 {
+					sb.WriteString("\n")
+				}
+				sb.WriteString(renderToolCall(tc))
+			}
+			out = append(out, protocols.LLMMessage{Role: "assistant", Content: sb.String()})
+		case m.Role == "tool":
+			result := fmt.Sprintf("<tool_result name=%q>\n%s\n%s", names[m.ToolCallID], m.Content, toolResultClose)
+			// Results of one turn go back together, so user and assistant
+			// messages keep alternating.
+			if last := len(out) - 1; last >= 0 && out[last].Role == "user" && strings.HasSuffix(out[last].Content, toolResultClose) {
+				out[last].Content += "\n" + result
+				continue
+			}
+			out = append(out, protocols.LLMMessage{Role: "user", Content: result})
+		default:
+			out = append(out, m)
+		}
+	}
+	if len(tools) == 0 {
+		return out
+	}
+	catalog := renderToolCatalog(tools)
+	if len(out) > 0 && out[0].Role == "system" {
+		out[0].Content += "\n\n" + catalog
+		return out
+	}
+	return append([]protocols.LLMMessage{{Role: "system", Content: catalog}}, out...)
+}
+
+func renderToolCatalog(tools []types.Tool) string {
+	var sb strings.Builder
+	sb.WriteString(promptToolsHeader)
+	for _, t := range tools {
+		sb.WriteString("\n### ")
+		sb.WriteString(t.Name)
+		sb.WriteString("\n")
+		if t.Description != "" {
+			sb.WriteString(t.Description)
+			sb.WriteString("\n")
+		}
+		params := t.Parameters
+		if params == nil {
+			params = map[string]any{"type": "object", "properties": map[string]any{}}
+		}
+		schema, _ := json.Marshal(params)
+		sb.WriteString("Arguments schema: ")
+		sb.Write(schema)
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+func renderToolCall(tc types.ToolCall) string {
+	args := json.RawMessage(strings.TrimSpace(tc.Arguments))
+	if !json.Valid(args) {
+		args, _ = json.Marshal(tc.Arguments)
+	}
+	call, _ := json.Marshal(struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	}{tc.Name, args})
+	return toolCallOpen + "\n" + string(call) + "\n" + toolCallClose
+}
+
+// parsePromptToolCalls turns the <tool_call> blocks in a model's streamed
+// text into a tool_calls event at the end of the stream. Text outside the
+// blocks streams through as it arrives; a block that can't be parsed is kept
+// as text so it stays visible. A block left open when the stream ends is
+// parsed too, since models often stop right after the call.
+func parsePromptToolCalls(in <-chan types.StreamEvent) <-chan types.StreamEvent {
+	out := make(chan types.StreamEvent, 32)
+	go func() {
+		defer close(out)
+		var p toolCallParser
+		var native []types.ToolCall
+		for ev := range in {
+			switch ev.Type {
+			case "text":
+				if text := p.feed(ev.Delta); text != "" {
+					out <- types.StreamEvent{Type: "text", Delta: text}
+				}
+			case "tool_calls":
+				native = append(native, ev.ToolCalls...)
+			default:
+				out <- ev
+			}
+		}
+		if text := p.flush(); text != "" {
+			out <- types.StreamEvent{Type: "text", Delta: text}
+		}
+		if calls := append(native, p.calls...); len(calls) > 0 {
+			out <- types.StreamEvent{Type: "tool_calls", ToolCalls: calls}
+		}
+	}()
+	return out
+}
+
+type toolCallParser struct {
+	pending string
+	inCall  bool
+	calls   []types.ToolCall
+}
+
+// feed takes the next chunk of text and returns what can be shown already.
+// Text that might be the start of a <tool_call> tag is held back.
+func (p *toolCallParser) feed(delta string) string {
+	p.pending += delta
+	var text strings.Builder
+	for {
+		if p.inCall {
+			end := strings.Index(p.pending, toolCallClose)
+			if end < 0 {
+				return text.String()
+			}
+			text.WriteString(p.parse(p.pending[:end]))
+			p.pending = strings.TrimPrefix(p.pending[end+len(toolCallClose):], "\n")
+			p.inCall = false
+			continue
+		}
+		if start := strings.Index(p.pending, toolCallOpen); start >= 0 {
+			text.WriteString(p.pending[:start])
+			p.pending = p.pending[start+len(toolCallOpen):]
+			p.inCall = true
+			continue
+		}
+		keep := partialPrefix(p.pending, toolCallOpen)
+		text.WriteString(p.pending[:len(p.pending)-keep])
+		p.pending = p.pending[len(p.pending)-keep:]
+		return text.String()
+	}
+}
+
+func (p *toolCallParser) flush() string {
+	rest := p.pending
+	p.pending = ""
+	if p.inCall {
+		p.inCall = false
+		return p.parse(rest)
+	}
+	return rest
+}
+
+// parse records the call in a block's body, or returns the block as text
+// when it isn't one.
+func (p *toolCallParser) parse(body string) string {
+	v, err := decodeArgs(strings.TrimSpace(body))
+	if err != nil {
+		v, err = decodeArgs(repairJSON(strings.TrimSpace(body)))
+	}
+	call, _ := v.(map[string]any)
+	name, _ := call["name"].(string)
+	if err != nil || name == "" {
+		return toolCallOpen + body + toolCallClose
+	}
+	args := "{}"
+	switch a := call["arguments"].(type) {
+	case string:
+		args = a
+	case nil:
+	default:
+		data, _ := json.Marshal(a)
+		args = string(data)
+	}
+	p.calls = append(p.calls, types.ToolCall{ID: "call_" + uuid.New().String()[:8], Name: name, Arguments: args})
+	return ""
+}
+
+// partialPrefix returns the length of the longest suffix of s that is a
+// proper prefix of tag.
+func partialPrefix(s, tag string) int {
+	for n := min(len(s), len(tag)-1); n > 0; n-- {
+		if strings.HasSuffix(s, tag[:n]) {
+			return n
+		}
+	}
+	return 0
+}
@@ -0,0 +1,151 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"mantis/core/protocols"
+	"mantis/core/types"
+)
+
+func textStream(chunks ...string) <-chan types.StreamEvent {
+	ch := make(chan types.StreamEvent, len(chunks)+1)
+	for _, c := range chunks {
+		ch <- types.StreamEvent{Type: "text", Delta: c}
+	}
+	ch <- types.StreamEvent{Type: "usage", Usage: &types.LLMUsage{PromptTokens: 1}}
+	close(ch)
+	return ch
+}
+
+func TestParsePromptToolCalls_SplitAcrossChunks(t *testing.T) {
+	events := collect(parsePromptToolCalls(textStream(
+		"Checking disk. <tool", "_call>\n{\"name\": \"ssh_web\", ",
+		"\"arguments\": {\"task\": \"df -h\"}}\n</tool_", "call>\n<tool_call>{\"name\": \"ssh_db\", \"arguments\": \"{\\\"task\\\":\\\"uptime\\\"}\"}",
+	)))
+
+	var text strings.Builder
+	var calls []types.ToolCall
+	sawUsage := false
+	for _, ev := range events {
+		switch ev.Type {
+		case "text":
+			text.WriteString(ev.Delta)
+		case "tool_calls":
+			calls = ev.ToolCalls
+		case "usage":
+			sawUsage = true
+		}
+	}
+	if text.String() != "Checking disk. " {
+		t.Fatalf("text = %q", text.String())
+	}
+	if !sawUsage {
+		t.Fatal("other events should pass through")
+	}
+	if len(calls) != 2 || calls[0].Name != "ssh_web" || calls[0].Arguments != `{"task":"df -h"}` ||
+		calls[1].Name != "ssh_db" || calls[1].Arguments != `{"task":"uptime"}` || calls[0].ID == "" || calls[0].ID == calls[1].ID {
+		t.Fatalf("calls = %+v", calls)
+	}
+}
+
+func TestParsePromptToolCalls_KeepsUnparseableBlocksAsText(t *testing.T) {
+	events := collect(parsePromptToolCalls(textStream("a < b and <tool_call>not a call</tool_call> done")))
+	var text strings.Builder
+	for _, ev := range events {
+		if ev.Type == "tool_calls" {
+			t.Fatalf("unexpected calls: %+v", ev.ToolCalls)
+		}
+		text.WriteString(ev.Delta)
+	}
+	if text.String() != "a < b and <tool_call>not a call</tool_call> done" {
+		t.Fatalf("text = %q", text.String())
+	}
+}
+
+func TestPromptToolMessages(t *testing.T) {
+	tools := []types.Tool{{Name: "ssh_web", Description: "Run a task on web", Parameters: map[string]any{"type": "object"}}}
+	out := promptToolMessages([]protocols.LLMMessage{
+		{Role: "system", Content: "You are Mantis."},
+		{Role: "user", Content: "disk?"},
+		{Role: "assistant", Content: "Checking.", ToolCalls: []types.ToolCall{
+			{ID: "1", Name: "ssh_web", Arguments: `{"task":"df -h"}`},
+			{ID: "2", Name: "ssh_web", Arguments: `{"task":"free -m"}`},
+		}},
+		{Role: "tool", ToolCallID: "1", Content: "40% used"},
+		{Role: "tool", ToolCallID: "2", Content: "2G free"},
+	}, tools)
+
+	if len(out) != 4 {
+		t.Fatalf("messages = %+v", out)
+	}
+	if !strings.HasPrefix(out[0].Content, "You are Mantis.\n\n# Tools") || !strings.Contains(out[0].Content, "### ssh_web\nRun a task on web\n") {
+		t.Fatalf("system prompt = %q", out[0].Content)
+	}
+	if out[2].ToolCalls != nil || !strings.Contains(out[2].Content, "<tool_call>\n{\"name\":\"ssh_web\",\"arguments\":{\"task\":\"free -m\"}}\n</tool_call>") {
+		t.Fatalf("assistant turn = %+v", out[2])
+	}
+	if out[3].Role != "user" || !strings.Contains(out[3].Content, "<tool_result name=\"ssh_web\">\n40% used\n</tool_result>\n<tool_result") {
+		t.Fatalf("tool results = %+v", out[3])
+	}
+}
+
+type recordingLLM struct {
+	messages []protocols.LLMMessage
+	tools    []types.Tool
+	reply    string
+}
+
+func (r *recordingLLM) ChatStream(_ context.Context, _, _, _ string, messages []protocols.LLMMessage, _ string, tools []types.Tool, _ string, _ types.GenerationOptions) (<-chan types.StreamEvent, error) {
+	r.messages, r.tools = messages, tools
+	return textStream(r.reply), nil
+}
+
+func TestAgentAction_PromptToolCalling(t *testing.T) {
+	llm := &recordingLLM{reply: "<tool_call>\n{\"name\": \"uptime\", \"arguments\": {}}\n</tool_call>"}
+	ch, err := NewAgentAction(llm, nil).Execute(context.Background(), ActionInput{
+		Model: "llama", ToolCalling: types.ToolCallingPrompt,
+		Messages: []protocols.LLMMessage{{Role: "user", Content: "uptime?"}},
+		Tools:    []types.Tool{{Name: "uptime"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var calls []types.ToolCall
+	for ev := range ch {
+		if ev.Type == "tool_calls" {
+			calls = ev.ToolCalls
+		}
+	}
+	if llm.tools != nil {
+		t.Fatalf("tools were sent natively: %+v", llm.tools)
+	}
+	if llm.messages[0].Role != "system" || !strings.Contains(llm.messages[0].Content, "### uptime") {
+		t.Fatalf("tool list missing from the prompt: %+v", llm.messages)
+	}
+	if len(calls) != 1 || calls[0].Name != "uptime" || calls[0].Arguments != "{}" {
+		t.Fatalf("calls = %+v", calls)
+	}
+}
+
+func TestAgentAction_StripsImagesForTextOnlyModels(t *testing.T) {
+	messages := []protocols.LLMMessage{{
+		Role: "user", Content: "what is this?",
+		Parts: []types.ContentPart{types.TextPart("what is this?"), types.ImagePart("image/png", []byte("png"))},
+	}}
+	for _, vision := range []bool{false, true} {
+		llm := &recordingLLM{reply: "a cat"}
+		ch, err := NewAgentAction(llm, nil).Execute(context.Background(), ActionInput{Model: "m", Vision: vision, Messages: messages})
+		if err != nil {
+			t.Fatal(err)
+		}
+		collect(ch)
+		if got := len(llm.messages[0].Parts); (got > 0) != vision {
+			t.Fatalf("vision = %v: sent %d parts", vision, got)
+		}
+	}
+	if len(messages[0].Parts) != 2 {
+		t.Fatal("the caller's messages were modified")
+	}
+}
@@ -0,0 +1,98 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"strings"
+
+	"mantis/core/types"
+)
+
+const (
+	// stuckRepeats is how many times the same call may return the same
+	// result before the loop counts as stuck.
+	stuckRepeats = 3
+	// stuckErrorStreak is how many iterations in a row may fail, each with
+	// every tool call in it failing.
+	stuckErrorStreak = 4
+)
+
+const stuckNote = "You appear to be stuck: %s. Repeating it will not change the result. " +
+	"Do not make the same call again. Try a different approach, or stop and tell the user " +
+	"what is blocking you (for example a command the guard refuses) and what they can do about it."
+
+// stuckDetector watches an agent loop for tool calls that go nowhere: the
+// same call returning the same result again and again, two rounds of calls
+// alternating, and a streak of iterations where every call failed.
+type stuckDetector struct {
+	seen      map[string]int
+	rounds    []string
+	errStreak int
+	warned    bool
+}
+
+func newStuckDetector() *stuckDetector {
+	return &stuckDetector{seen: map[string]int{}}
+}
+
+// observe records one iteration's tool calls with their results and
+// returns why the loop looks stuck, or "" when it doesn't.
+func (d *stuckDetector) observe(calls []types.ToolCall, results []string) string {
+	reason := ""
+	failed := len(calls) > 0
+	keys := make([]string, len(calls))
+	for i, tc := range calls {
+		keys[i] = callKey(tc) + "\x00" + hashResult(results[i])
+		d.seen[keys[i]]++
+		if n := d.seen[keys[i]]; n >= stuckRepeats && reason == "" {
+			reason = fmt.Sprintf("the same %s call returned the same result %d times", tc.Name, n)
+		}
+		if !strings.HasPrefix(results[i], "error:") {
+			failed = false
+		}
+	}
+
+	d.rounds = append(d.rounds, strings.Join(keys, "\x01"))
+	if n := len(d.rounds); reason == "" && n >= 4 &&
+		d.rounds[n-1] == d.rounds[n-3] && d.rounds[n-2] == d.rounds[n-4] && d.rounds[n-1] != d.rounds[n-2] {
+		reason = "the same two rounds of tool calls keep alternating"
+	}
+
+	if failed {
+		d.errStreak++
+	} else {
+		d.errStreak = 0
+	}
+	if reason == "" && d.errStreak >= stuckErrorStreak {
+		reason = fmt.Sprintf("%d tool iterations in a row failed", d.errStreak)
+	}
+	return reason
+}
+
+// warn marks that the model was told it is stuck, and returns the note to
+// tell it with. The error streak starts over so a new approach gets a fair
+// run; repeats of the same call still count.
+func (d *stuckDetector) warn(reason string) string {
+	d.warned = true
+	d.errStreak = 0
+	return fmt.Sprintf(stuckNote, reason)
+}
+
+// callKey identifies a call by tool and arguments, ignoring key order and
+// whitespace in the arguments.
+func callKey(tc types.ToolCall) string {
+	args := strings.TrimSpace(tc.Arguments)
+	if v, err := decodeArgs(args); err == nil {
+		if data, err := json.Marshal(v); err == nil {
+			args = string(data)
+		}
+	}
+	return tc.Name + "(" + args + ")"
+}
+
+func hashResult(s string) string {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	return fmt.Sprintf("%x", h.Sum64())
+}
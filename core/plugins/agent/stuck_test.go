@@ -0,0 +1,99 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"mantis/core/protocols"
+	"mantis/core/types"
+	"mantis/shared"
+)
+
+func call(name, args string) []types.ToolCall {
+	return []types.ToolCall{{ID: "1", Name: name, Arguments: args}}
+}
+
+func TestStuckDetector(t *testing.T) {
+	t.Run("repeated call and result", func(t *testing.T) {
+		d := newStuckDetector()
+		d.observe(call("ssh", `{"task": "sudo ls"}`), []string{"blocked"})
+		d.observe(call("ssh", `{"task":"sudo ls"}`), []string{"blocked"})
+		if got := d.observe(call("ssh", `{ "task":"sudo ls" }`), []string{"blocked"}); !strings.Contains(got, "same ssh call returned the same result 3 times") {
+			t.Fatalf("reason = %q", got)
+		}
+	})
+	t.Run("same call with new results", func(t *testing.T) {
+		d := newStuckDetector()
+		for i := range 5 {
+			if got := d.observe(call("ssh", `{"task":"uptime"}`), []string{fmt.Sprintf("up %d min", i)}); got != "" {
+				t.Fatalf("polling flagged as stuck: %q", got)
+			}
+		}
+	})
+	t.Run("alternating rounds", func(t *testing.T) {
+		d := newStuckDetector()
+		var got string
+		for i := range 4 {
+			args := []string{`{"task":"a"}`, `{"task":"b"}`}[i%2]
+			got = d.observe(call("ssh", args), []string{"x"})
+		}
+		if !strings.Contains(got, "alternating") {
+			t.Fatalf("reason = %q", got)
+		}
+	})
+	t.Run("error streak", func(t *testing.T) {
+		d := newStuckDetector()
+		var got string
+		for i := range stuckErrorStreak {
+			got = d.observe(call("ssh", fmt.Sprintf(`{"task":"try %d"}`, i)), []string{"error: denied"})
+		}
+		if !strings.Contains(got, "4 tool iterations in a row failed") {
+			t.Fatalf("reason = %q", got)
+		}
+		d.warn(got)
+		if got := d.observe(call("ssh", `{"task":"other"}`), []string{"error: denied"}); got != "" {
+			t.Fatalf("streak should start over after the warning, got %q", got)
+		}
+	})
+}
+
+func TestAgentLoop_StopsWhenStuck(t *testing.T) {
+	blocked := []types.StreamEvent{{Type: "tool_calls", ToolCalls: call("ssh", `{"task":"sudo systemctl restart nginx"}`)}}
+	var streams [][]types.StreamEvent
+	for range 10 {
+		streams = append(streams, blocked)
+	}
+	var seen []protocols.LLMMessage
+	llm := &scriptedLLM{streams: streams}
+	llm.onCall = func(messages []protocols.LLMMessage) { seen = messages }
+
+	loop := NewAgentLoop(NewAgentAction(llm, nil), nil)
+	ch, err := loop.Execute(context.Background(), LoopInput{
+		ActionInput: ActionInput{
+			Model:    "m",
+			Messages: []protocols.LLMMessage{{Role: "user", Content: "restart nginx"}},
+			Tools: []types.Tool{{Name: "ssh", Execute: func(context.Context, string) (string, error) {
+				return "command refused by guard: sudo is not allowed", nil
+			}}},
+		},
+		MaxIterations: 10,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := collect(ch)
+
+	last := events[len(events)-1]
+	if last.Type != "error" || !strings.HasPrefix(last.Delta, shared.AgentStuckPrefix) {
+		t.Fatalf("last event = %+v", last)
+	}
+	if llm.calls != 4 {
+		t.Fatalf("model called %d times, want a warning after 3 and a stop after 4", llm.calls)
+	}
+	note := seen[len(seen)-1]
+	if note.Role != "system" || !strings.Contains(note.Content, "You appear to be stuck") {
+		t.Fatalf("no corrective note before the last call: %+v", note)
+	}
+}
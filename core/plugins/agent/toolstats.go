@@ -0,0 +1,60 @@
+package agent
+
+import (
+	"cmp"
+	"slices"
+	"sync"
+
+	"mantis/core/types"
+)
+
+// ToolCallStats counts tool calls per model, to show which models handle
+// tools poorly. Counts live in memory and start over on restart.
+type ToolCallStats struct {
+	mu     sync.Mutex
+	models map[string]*types.ToolCallStat
+}
+
+func NewToolCallStats() *ToolCallStats {
+	return &ToolCallStats{models: map[string]*types.ToolCallStat{}}
+}
+
+// Record counts one tool call of a model.
+func (s *ToolCallStats) Record(modelID, modelName string, repaired, invalid bool) {
+	if s == nil {
+		return
+	}
+	key := cmp.Or(modelID, modelName)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st, ok := s.models[key]
+	if !ok {
+		st = &types.ToolCallStat{ModelID: modelID, ModelName: modelName}
+		s.models[key] = st
+	}
+	st.Calls++
+	if repaired {
+		st.Repaired++
+	}
+	if invalid {
+		st.Invalid++
+	}
+	st.InvalidRate = float64(st.Invalid) / float64(st.Calls)
+}
+
+// Snapshot returns the counts of every model, worst invalid rate first.
+func (s *ToolCallStats) Snapshot() []types.ToolCallStat {
+	if s == nil {
+		return []types.ToolCallStat{}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]types.ToolCallStat, 0, len(s.models))
+	for _, st := range s.models {
+		out = append(out, *st)
+	}
+	slices.SortFunc(out, func(a, b types.ToolCallStat) int {
+		return cmp.Or(cmp.Compare(b.InvalidRate, a.InvalidRate), cmp.Compare(a.ModelName, b.ModelName))
+	})
+	return out
+}
@@ -289,7 +289,8 @@ func (e *Extractor) callLLM(ctx context.Context, llmConn types.LlmConnection, mo
 		{Role: "user", Content: userInput},
 	}
 
-	stream, err := e.llm.ChatStream(ctx, llmConn.Provider, llmConn.BaseURL, llmConn.APIKey, messages, model.Name, nil, "skip")
+	ctx = shared.WithUsageModel(shared.WithUsageCaller(ctx, shared.UsageCallerMemory), llmConn.ID, model.ID)
+	stream, err := e.llm.ChatStream(ctx, llmConn.Provider, llmConn.BaseURL, llmConn.APIKey, messages, model.Name, nil, "skip", model.Generation)
 	if err != nil {
 		return "", err
 	}
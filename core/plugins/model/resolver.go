@@ -9,15 +9,19 @@ import (
 	"mantis/core/types"
 )
 
+// Input selects the model for a request. ExplicitModelID and PresetID pin
+// the model or preset for this request alone; a pinned preset that resolves
+// to no model is an error rather than a silent fallback.
 type Input struct {
 	ExplicitModelID string
+	PresetID        string
 	ChannelID       string
 	DefaultPreset   string
 }
 
 type Output struct {
 	ModelID    string
-	Source     string // explicit | channel | settings | none
+	Source     string // explicit | preset | channel | settings | none
 	PresetID   string
 	PresetName string
 	ModelRole  string // primary | fallback | explicit | legacy
@@ -41,6 +45,17 @@ func (r *Resolver) Execute(ctx context.Context, in Input) (Output, error) {
 	if id := strings.TrimSpace(in.ExplicitModelID); id != "" {
 		return Output{ModelID: id, Source: "explicit", ModelRole: "explicit"}, nil
 	}
+	if id := strings.TrimSpace(in.PresetID); id != "" {
+		out := r.resolvePresetToModel(ctx, id)
+		if out.ModelID == "" {
+			if out.PresetID == "" {
+				return Output{}, fmt.Errorf("preset %q not found", id)
+			}
+			return Output{}, fmt.Errorf("preset %q has no model", out.PresetName)
+		}
+		out.Source = "preset"
+		return out, nil
+	}
 
 	var channelLegacy Output
 	if strings.TrimSpace(in.ChannelID) != "" {
@@ -274,3 +274,41 @@ func TestResolve_ChannelStoreError(t *testing.T) {
 		t.Fatal("expected error from store")
 	}
 }
+
+func TestResolve_PinnedPreset(t *testing.T) {
+	channels := &memStore[string, types.Channel]{
+		data: map[string]types.Channel{
+			"ch1": {ID: "ch1", PresetID: "pr1"},
+		},
+	}
+	presets := &memStore[string, types.Preset]{
+		data: map[string]types.Preset{
+			"pr1": {ID: "pr1", Name: "Fast", ChatModelID: "claude-3"},
+			"pr2": {ID: "pr2", Name: "Local", ChatModelID: "llama"},
+			"pr3": {ID: "pr3", Name: "Empty"},
+		},
+	}
+	r := NewResolver(channels, nil, presets)
+	out, err := r.Execute(context.Background(), Input{PresetID: "pr2", ChannelID: "ch1", DefaultPreset: "chat"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.ModelID != "llama" || out.Source != "preset" || out.PresetName != "Local" {
+		t.Fatalf("expected pinned preset to win, got %+v", out)
+	}
+
+	if _, err := r.Execute(context.Background(), Input{PresetID: "pr3"}); err == nil {
+		t.Fatal("expected error for a preset without models")
+	}
+	if _, err := r.Execute(context.Background(), Input{PresetID: "missing"}); err == nil {
+		t.Fatal("expected error for an unknown preset")
+	}
+
+	out, err = r.Execute(context.Background(), Input{ExplicitModelID: "gpt-4", PresetID: "pr2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.ModelID != "gpt-4" {
+		t.Fatalf("explicit model should win over a pinned preset, got %s", out.ModelID)
+	}
+}
@@ -0,0 +1,148 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"mantis/core/protocols"
+	"mantis/core/types"
+)
+
+// RouteInput is a resolved model and the message it is for.
+type RouteInput struct {
+	Resolved Output
+	Content  string
+}
+
+// Route is the model a message goes to. Tier is empty when the message
+// isn't routed and ModelID is the resolved model. A message routed to a
+// cheaper tier escalates to EscalateTo after EscalateAfter failed tool
+// iterations; EscalateAfter is zero when it never does.
+type Route struct {
+	ModelID       string
+	Tier          string
+	Reason        string
+	EscalateTo    string
+	EscalateAfter int
+}
+
+// Router picks the model tier for each message from the routing settings of
+// the preset it resolved to. Only a preset's primary model is routed;
+// explicit, channel and fallback picks are left alone.
+type Router struct {
+	presetStore     protocols.Store[string, types.Preset]
+	connectionStore protocols.Store[string, types.Connection]
+}
+
+func NewRouter(
+	presetStore protocols.Store[string, types.Preset],
+	connectionStore protocols.Store[string, types.Connection],
+) *Router {
+	return &Router{presetStore: presetStore, connectionStore: connectionStore}
+}
+
+func (r *Router) Execute(ctx context.Context, in RouteInput) (Route, error) {
+	out := Route{ModelID: in.Resolved.ModelID}
+	if r == nil || r.presetStore == nil || in.Resolved.PresetID == "" || in.Resolved.ModelRole != "primary" {
+		return out, nil
+	}
+	presets, err := r.presetStore.Get(ctx, []string{in.Resolved.PresetID})
+	if err != nil {
+		return out, err
+	}
+	routing := presets[in.Resolved.PresetID].Routing
+	if !routing.Enabled {
+		return out, nil
+	}
+
+	var servers []string
+	if r.connectionStore != nil {
+		connections, err := r.connectionStore.List(ctx, types.ListQuery{})
+		if err != nil {
+			return out, err
+		}
+		for _, c := range connections {
+			servers = append(servers, c.Name)
+		}
+	}
+
+	tier, reason := Classify(in.Content, servers)
+	out.Tier, out.Reason = types.RouteTierFlagship, reason
+	candidates := map[string][]string{
+		types.RouteTierLight: {types.RouteTierLight, types.RouteTierTask},
+		types.RouteTierTask:  {types.RouteTierTask},
+	}[tier]
+	models := map[string]string{
+		types.RouteTierLight: strings.TrimSpace(routing.LightModelID),
+		types.RouteTierTask:  strings.TrimSpace(routing.TaskModelID),
+	}
+	for _, t := range candidates {
+		if models[t] == "" {
+			continue
+		}
+		out.ModelID, out.Tier = models[t], t
+		out.EscalateTo, out.EscalateAfter = in.Resolved.ModelID, max(routing.EscalateAfter, 0)
+		break
+	}
+	return out, nil
+}
+
+var (
+	attachmentTagRe = regexp.MustCompile(`<attachment [^>]*/>`)
+	listItemRe      = regexp.MustCompile(`(?m)^\s*(?:[-*•]|\d+[.)])\s+\S`)
+
+	investigationRe = wordsRe(
+		"why", "investigate", "debug", "diagnose", "troubleshoot", "root cause", "figure out",
+		"compare", "audit", "analyze", "analyse", "migrate", "every server", "all servers",
+		"step by step", "and then", "after that",
+	)
+	taskRe = wordsRe(
+		"check", "restart", "start", "stop", "show", "list", "run", "install", "update", "upgrade",
+		"deploy", "tail", "grep", "kill", "disk", "memory", "cpu", "load", "uptime", "logs?",
+		"status", "df", "ps", "docker", "systemctl", "nginx", "server", "host",
+	)
+)
+
+func wordsRe(words ...string) *regexp.Regexp {
+	return regexp.MustCompile(`(?i)\b(` + strings.Join(words, "|") + `)\b`)
+}
+
+// maxTaskChars is the length past which a message is treated as a
+// multi-step request.
+const maxTaskChars = 600
+
+// Classify picks the tier a message needs: light for trivial chat, task for
+// a single server task, flagship for a multi-step investigation. servers are
+// the names of the configured connections. It uses the wording alone, so it
+// costs no model call.
+func Classify(content string, servers []string) (tier, reason string) {
+	text := strings.TrimSpace(attachmentTagRe.ReplaceAllString(content, ""))
+	attached := text != strings.TrimSpace(content)
+
+	var mentioned []string
+	for _, name := range servers {
+		if name = strings.TrimSpace(name); name != "" && wordsRe(regexp.QuoteMeta(name)).MatchString(text) {
+			mentioned = append(mentioned, name)
+		}
+	}
+
+	switch {
+	case len(mentioned) > 1:
+		return types.RouteTierFlagship, fmt.Sprintf("investigation: mentions %d servers", len(mentioned))
+	case investigationRe.MatchString(text):
+		return types.RouteTierFlagship, fmt.Sprintf("investigation: asks to %q", strings.ToLower(investigationRe.FindString(text)))
+	case len(listItemRe.FindAllString(text, -1)) > 2:
+		return types.RouteTierFlagship, "investigation: lists several steps"
+	case len([]rune(text)) > maxTaskChars:
+		return types.RouteTierFlagship, fmt.Sprintf("investigation: long request (%d characters)", len([]rune(text)))
+	case len(mentioned) == 1:
+		return types.RouteTierTask, fmt.Sprintf("server task on %s", mentioned[0])
+	case taskRe.MatchString(text):
+		return types.RouteTierTask, fmt.Sprintf("server task: %q", strings.ToLower(taskRe.FindString(text)))
+	case attached:
+		return types.RouteTierTask, "server task: message has attachments"
+	}
+	return types.RouteTierLight, "chat: no server task"
+}
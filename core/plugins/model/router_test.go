@@ -0,0 +1,63 @@
+package model
+
+import (
+	"context"
+	"testing"
+
+	"mantis/core/types"
+)
+
+func TestClassify(t *testing.T) {
+	servers := []string{"web-1", "db"}
+	cases := map[string]string{
+		"hi, thanks!":                         types.RouteTierLight,
+		"what's a good name for a cat?":       types.RouteTierLight,
+		"check disk on web-1":                 types.RouteTierTask,
+		"restart nginx":                       types.RouteTierTask,
+		"why is db slow since the deploy?":    types.RouteTierFlagship,
+		"compare the configs":                 types.RouteTierFlagship,
+		"sync web-1 with db":                  types.RouteTierFlagship,
+		"do this:\n1. a\n2. b\n3. c":          types.RouteTierFlagship,
+		`look <attachment id="a" mime="x" />`: types.RouteTierTask,
+		"databases are neat, aren't they":     types.RouteTierLight,
+	}
+	for content, want := range cases {
+		if tier, reason := Classify(content, servers); tier != want || reason == "" {
+			t.Errorf("Classify(%q) = %s (%s), want %s", content, tier, reason, want)
+		}
+	}
+}
+
+func TestRouter_PicksTierModel(t *testing.T) {
+	presets := &memStore[string, types.Preset]{data: map[string]types.Preset{
+		"p1": {ID: "p1", ChatModelID: "big", Routing: types.PresetRouting{Enabled: true, TaskModelID: "mid", EscalateAfter: 2}},
+		"p2": {ID: "p2", ChatModelID: "big"},
+	}}
+	r := NewRouter(presets, nil)
+	ctx := context.Background()
+	primary := Output{ModelID: "big", PresetID: "p1", ModelRole: "primary"}
+
+	// No light model: trivial chat goes to the task tier.
+	out, err := r.Execute(ctx, RouteInput{Resolved: primary, Content: "thanks"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.ModelID != "mid" || out.Tier != types.RouteTierTask || out.EscalateTo != "big" || out.EscalateAfter != 2 {
+		t.Fatalf("route = %+v", out)
+	}
+
+	out, _ = r.Execute(ctx, RouteInput{Resolved: primary, Content: "why did the backup fail?"})
+	if out.ModelID != "big" || out.Tier != types.RouteTierFlagship || out.EscalateAfter != 0 {
+		t.Fatalf("investigation route = %+v", out)
+	}
+
+	for _, resolved := range []Output{
+		{ModelID: "big", PresetID: "p2", ModelRole: "primary"},
+		{ModelID: "pinned", ModelRole: "explicit"},
+	} {
+		out, _ = r.Execute(ctx, RouteInput{Resolved: resolved, Content: "thanks"})
+		if out.ModelID != resolved.ModelID || out.Tier != "" {
+			t.Fatalf("unrouted %+v got %+v", resolved, out)
+		}
+	}
+}
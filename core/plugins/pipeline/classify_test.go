@@ -20,7 +20,7 @@ func newTestPipeline() *RequestHandlePipeline {
 func TestClassifyStop_UserCancel(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	cancel()
-	marker, stopped := newTestPipeline().classifyStop(ctx, nil)
+	marker, stopped := newTestPipeline().classifyStop(ctx, nil, "")
 	if !stopped || !strings.Contains(marker, "stopped by user") {
 		t.Fatalf("expected user marker, got stopped=%v marker=%q", stopped, marker)
 	}
@@ -30,21 +30,40 @@ func TestClassifyStop_Timeout(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Microsecond)
 	defer cancel()
 	time.Sleep(2 * time.Millisecond)
-	marker, stopped := newTestPipeline().classifyStop(ctx, nil)
+	marker, stopped := newTestPipeline().classifyStop(ctx, nil, "")
 	if !stopped || !strings.Contains(marker, "supervisor timeout") || !strings.Contains(marker, shared.EnvSupervisorTimeout) {
 		t.Fatalf("expected timeout marker with env hint, got %q", marker)
 	}
 }
 
+func TestClassifyStop_TimeoutMarkerOverride(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Microsecond)
+	defer cancel()
+	time.Sleep(2 * time.Millisecond)
+	want := shared.StopReasonPlanStepTimeout(30 * time.Second)
+	marker, stopped := newTestPipeline().classifyStop(ctx, nil, want)
+	if !stopped || marker != want {
+		t.Fatalf("expected override marker %q, got %q", want, marker)
+	}
+}
+
 func TestClassifyStop_Iterations(t *testing.T) {
-	marker, stopped := newTestPipeline().classifyStop(context.Background(), errors.New("max iterations reached: 7"))
+	marker, stopped := newTestPipeline().classifyStop(context.Background(), errors.New("max iterations reached: 7"), "")
 	if !stopped || !strings.Contains(marker, "max 7 tool iterations") || !strings.Contains(marker, shared.EnvSupervisorMaxIterations) {
 		t.Fatalf("expected iterations marker with env hint, got %q", marker)
 	}
 }
 
+func TestClassifyStop_Stuck(t *testing.T) {
+	err := errors.New(shared.AgentStuckPrefix + "4 tool iterations in a row failed")
+	marker, stopped := newTestPipeline().classifyStop(context.Background(), err, "")
+	if !stopped || marker != shared.StopReasonStuck("4 tool iterations in a row failed") {
+		t.Fatalf("expected stuck marker, got stopped=%v marker=%q", stopped, marker)
+	}
+}
+
 func TestClassifyStop_RealError(t *testing.T) {
-	marker, stopped := newTestPipeline().classifyStop(context.Background(), errors.New("upstream 500"))
+	marker, stopped := newTestPipeline().classifyStop(context.Background(), errors.New("upstream 500"), "")
 	if stopped || marker != "" {
 		t.Fatalf("expected not stopped, got stopped=%v marker=%q", stopped, marker)
 	}
@@ -30,6 +30,8 @@ type Input struct {
 	DisableHistory bool
 	ErrorPrefix    string
 	Timeout        time.Duration
+	TimeoutMarker  string
+	Simulation     *agents.Simulation
 	Finally        func()
 }
 
@@ -57,6 +59,7 @@ type RequestHandlePipeline struct {
 	modelStore      protocols.Store[string, types.Model]
 	sessionStore    protocols.Store[string, types.ChatSession]
 	modelResolver   *modelplugin.Resolver
+	router          *modelplugin.Router
 	memoryExtractor MemoryExtractor
 	summarizer      *summarizer.Summarizer
 	attachmentDir   string
@@ -91,6 +94,11 @@ func (p *RequestHandlePipeline) SetAttachmentDir(dir string) {
 	p.attachmentDir = dir
 }
 
+// SetRouter enables per-message model routing for presets that turn it on.
+func (p *RequestHandlePipeline) SetRouter(r *modelplugin.Router) {
+	p.router = r
+}
+
 func (p *RequestHandlePipeline) Execute(ctx context.Context, in Input) Result {
 	if in.Finally != nil {
 		defer in.Finally()
@@ -100,6 +108,7 @@ func (p *RequestHandlePipeline) Execute(ctx context.Context, in Input) Result {
 		ctx, cancel = context.WithTimeout(ctx, in.Timeout)
 		defer cancel()
 	}
+	ctx = shared.WithUsageSession(ctx, in.SessionID, in.Message.ID)
 
 	modelOut, err := p.modelResolver.Execute(ctx, in.ModelConfig)
 	if err != nil {
@@ -114,6 +123,19 @@ func (p *RequestHandlePipeline) Execute(ctx context.Context, in Input) Result {
 	in.Message.PresetName = strings.TrimSpace(modelOut.PresetName)
 	in.Message.ModelRole = strings.TrimSpace(modelOut.ModelRole)
 
+	var route modelplugin.Route
+	if p.router != nil {
+		route, err = p.router.Execute(ctx, modelplugin.RouteInput{Resolved: modelOut, Content: in.Content})
+		if err != nil {
+			log.Printf("pipeline: route: %v", err)
+		} else if route.Tier != "" {
+			modelID = route.ModelID
+			in.Message.ModelID = modelID
+			in.Message.RouteTier = route.Tier
+			in.Message.RouteReason = route.Reason
+		}
+	}
+
 	if model, err := shared.ResolveModel(ctx, p.modelStore, modelID); err == nil {
 		in.Message.ModelID = model.ID
 		in.Message.ModelName = model.Name
@@ -146,6 +168,7 @@ func (p *RequestHandlePipeline) Execute(ctx context.Context, in Input) Result {
 	agentInput := agents.MantisInput{
 		SessionID:      in.SessionID,
 		ModelID:        modelID,
+		PresetID:       strings.TrimSpace(modelOut.PresetID),
 		Content:        in.Content,
 		Artifacts:      in.Artifacts,
 		RequestID:      in.Message.ID,
@@ -153,6 +176,9 @@ func (p *RequestHandlePipeline) Execute(ctx context.Context, in Input) Result {
 		ReplyChannel:   replyChannel,
 		ReplyTo:        replyTo,
 		DisableHistory: in.DisableHistory,
+		Simulation:     in.Simulation,
+		EscalateTo:     route.EscalateTo,
+		EscalateAfter:  route.EscalateAfter,
 	}
 
 	stream, runErr := p.agent.Execute(ctx, agentInput)
@@ -161,11 +187,11 @@ func (p *RequestHandlePipeline) Execute(ctx context.Context, in Input) Result {
 	var steps []types.Step
 	var usage *types.LLMUsage
 	if runErr == nil && stream != nil {
-		content, steps, usage, runErr = p.collectStream(in.Message.ID, stream)
+		content, steps, usage, runErr = p.collectStream(&in.Message, stream)
 	}
 
 	if runErr != nil && shared.IsContextOverflow(runErr) && p.summarizer != nil && !in.DisableHistory {
-		if retryStep, retried := p.retryOverflow(ctx, in, modelID, strings.TrimSpace(modelOut.PresetID), agentInput); retried {
+		if retryStep, retried := p.retryOverflow(ctx, &in, modelID, strings.TrimSpace(modelOut.PresetID), agentInput); retried {
 			compactStep = retryStep.compact
 			content = retryStep.content
 			steps = retryStep.steps
@@ -178,7 +204,7 @@ func (p *RequestHandlePipeline) Execute(ctx context.Context, in Input) Result {
 		steps = append([]types.Step{*compactStep}, steps...)
 	}
 
-	stopMarker, stopped := p.classifyStop(ctx, runErr)
+	stopMarker, stopped := p.classifyStop(ctx, runErr, in.TimeoutMarker)
 	if stopped {
 		steps = markCancelledSteps(steps)
 	}
@@ -208,7 +234,7 @@ func (p *RequestHandlePipeline) Execute(ctx context.Context, in Input) Result {
 
 	if p.memoryExtractor != nil && msg.Status != "error" && in.Content != "" && msg.Content != "" {
 		sshSteps := collectSSHSteps(steps)
-		go p.memoryExtractor.Extract(context.Background(), in.Content, msg.Content, sshSteps)
+		go p.memoryExtractor.Extract(shared.WithUsageSession(context.Background(), in.SessionID, in.Message.ID), in.Content, msg.Content, sshSteps)
 	}
 
 	sendErr := p.send(ctx, in.ResponseTo, msg.Content, steps, outgoing)
@@ -219,7 +245,7 @@ func (p *RequestHandlePipeline) Execute(ctx context.Context, in Input) Result {
 }
 
 func (p *RequestHandlePipeline) fail(ctx context.Context, in Input, err error) Result {
-	stopMarker, stopped := p.classifyStop(ctx, err)
+	stopMarker, stopped := p.classifyStop(ctx, err, in.TimeoutMarker)
 	msg := p.finalizeMessage(in.Message, "", nil, err, in.ErrorPrefix, stopMarker, stopped)
 	p.saveMessage(msg)
 	sendErr := p.send(ctx, in.ResponseTo, msg.Content, nil, nil)
@@ -235,7 +261,7 @@ type overflowRetryResult struct {
 	err     error
 }
 
-func (p *RequestHandlePipeline) retryOverflow(ctx context.Context, in Input, modelID, presetID string, agentInput agents.MantisInput) (overflowRetryResult, bool) {
+func (p *RequestHandlePipeline) retryOverflow(ctx context.Context, in *Input, modelID, presetID string, agentInput agents.MantisInput) (overflowRetryResult, bool) {
 	log.Printf("pipeline: context overflow detected, forcing compaction and retrying once")
 	res, err := p.summarizer.ForceCompact(ctx, summarizer.Input{
 		SessionID: in.SessionID,
@@ -253,7 +279,7 @@ func (p *RequestHandlePipeline) retryOverflow(ctx context.Context, in Input, mod
 		usage   *types.LLMUsage
 	)
 	if runErr == nil && stream != nil {
-		content, steps, usage, runErr = p.collectStream(in.Message.ID, stream)
+		content, steps, usage, runErr = p.collectStream(&in.Message, stream)
 	}
 	return overflowRetryResult{
 		compact: res.Step,
@@ -290,8 +316,14 @@ func (p *RequestHandlePipeline) updateSessionContextTokens(sessionID string, tok
 	}
 }
 
-func (p *RequestHandlePipeline) classifyStop(ctx context.Context, runErr error) (string, bool) {
+// classifyStop maps an interrupted run to its stop marker. timeoutMarker
+// overrides the supervisor timeout marker for callers that set their own
+// Timeout, so the message names the limit that actually fired.
+func (p *RequestHandlePipeline) classifyStop(ctx context.Context, runErr error, timeoutMarker string) (string, bool) {
 	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		if timeoutMarker != "" {
+			return timeoutMarker, true
+		}
 		return shared.StopReasonSupervisorTimeout(p.limits.SupervisorTimeout), true
 	}
 	if errors.Is(ctx.Err(), context.Canceled) {
@@ -300,10 +332,16 @@ func (p *RequestHandlePipeline) classifyStop(ctx context.Context, runErr error)
 	if runErr != nil && strings.Contains(runErr.Error(), "max iterations reached") {
 		return shared.StopReasonSupervisorIterations(p.limits.SupervisorMaxIterations), true
 	}
+	if runErr != nil && strings.HasPrefix(runErr.Error(), shared.AgentStuckPrefix) {
+		return shared.StopReasonStuck(strings.TrimPrefix(runErr.Error(), shared.AgentStuckPrefix)), true
+	}
 	return "", false
 }
 
-func (p *RequestHandlePipeline) collectStream(requestID string, stream <-chan types.StreamEvent) (string, []types.Step, *types.LLMUsage, error) {
+// collectStream drains the agent's stream into the reply text and steps. A
+// failover event switches msg to the fallback model that answered.
+func (p *RequestHandlePipeline) collectStream(msg *types.ChatMessage, stream <-chan types.StreamEvent) (string, []types.Step, *types.LLMUsage, error) {
+	requestID := msg.ID
 	var sb strings.Builder
 	var steps []types.Step
 	var err error
@@ -327,6 +365,15 @@ func (p *RequestHandlePipeline) collectStream(requestID string, stream <-chan ty
 			if event.Usage != nil {
 				usage = event.Usage
 			}
+		case "failover":
+			msg.ModelID = event.ModelID
+			msg.ModelName = event.ModelName
+			msg.ModelRole = event.ModelRole
+		case "escalation":
+			msg.ModelID = event.ModelID
+			msg.ModelName = event.ModelName
+			msg.RouteTier = types.RouteTierFlagship
+			msg.RouteReason += "; " + event.Delta
 		case "text":
 			closeThinking()
 			sb.WriteString(event.Delta)
@@ -141,6 +141,9 @@ func (s *Summarizer) compact(ctx context.Context, in Input, force bool) (Result,
 	if sessionID == "" || strings.HasPrefix(sessionID, "plan:") {
 		return Result{}, nil
 	}
+	if shared.UsageScopeFromContext(ctx).SessionID == "" {
+		ctx = shared.WithUsageSession(ctx, sessionID, "")
+	}
 
 	model, err := shared.ResolveModel(ctx, s.modelStore, in.ModelID)
 	if err != nil {
@@ -349,7 +352,8 @@ func (s *Summarizer) generateSummary(ctx context.Context, conn types.LlmConnecti
 		{Role: "user", Content: input.String()},
 	}
 
-	stream, err := s.llm.ChatStream(ctx, conn.Provider, conn.BaseURL, conn.APIKey, messages, model.Name, nil, "skip")
+	ctx = shared.WithUsageModel(shared.WithUsageCaller(ctx, shared.UsageCallerSummarizer), conn.ID, model.ID)
+	stream, err := s.llm.ChatStream(ctx, conn.Provider, conn.BaseURL, conn.APIKey, messages, model.Name, nil, "skip", model.Generation)
 	if err != nil {
 		return "", err
 	}
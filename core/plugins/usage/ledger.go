@@ -0,0 +1,338 @@
+package usage
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"mantis/core/protocols"
+	"mantis/core/types"
+	"mantis/shared"
+)
+
+const (
+	dayLayout   = "2006-01-02"
+	monthLayout = "2006-01"
+
+	// MaxDays bounds the window of the usage queries.
+	MaxDays = 366
+)
+
+// ErrBudgetExceeded is returned for a call a blocking budget is exceeded for.
+var ErrBudgetExceeded = errors.New("usage budget exceeded")
+
+// Ledger records every model call with its token usage and cost, and keeps
+// the current day's and month's totals in memory for budget checks. Totals
+// are loaded from the records the first time a period is checked.
+type Ledger struct {
+	records protocols.Store[string, types.UsageRecord]
+	budgets protocols.Store[string, types.UsageBudget]
+	models  protocols.Store[string, types.Model]
+	now     func() time.Time
+
+	mu      sync.Mutex
+	periods map[types.UsageBudgetPeriod]*periodTotals
+}
+
+// periodTotals sums a period's records per budget scope key (see scopeKeys).
+type periodTotals struct {
+	key    string
+	scopes map[string]types.UsageTotals
+}
+
+func NewLedger(records protocols.Store[string, types.UsageRecord], budgets protocols.Store[string, types.UsageBudget], models protocols.Store[string, types.Model]) *Ledger {
+	return &Ledger{
+		records: records,
+		budgets: budgets,
+		models:  models,
+		now:     time.Now,
+		periods: map[types.UsageBudgetPeriod]*periodTotals{},
+	}
+}
+
+// Record prices a call's usage at its model's price and stores it.
+func (l *Ledger) Record(ctx context.Context, scope shared.UsageScope, modelName string, u types.LLMUsage) (types.UsageRecord, error) {
+	now := l.now().UTC()
+	planID, runID := scope.PlanRun()
+	rec := types.UsageRecord{
+		ID:               uuid.New().String(),
+		Caller:           scope.Caller,
+		ModelID:          scope.ModelID,
+		ModelName:        modelName,
+		ConnectionID:     scope.ConnectionID,
+		SessionID:        scope.SessionID,
+		MessageID:        scope.MessageID,
+		PlanID:           planID,
+		PlanRunID:        runID,
+		PromptTokens:     u.PromptTokens,
+		CompletionTokens: u.CompletionTokens,
+		CachedTokens:     u.CachedTokens,
+		CacheWriteTokens: u.CacheWriteTokens,
+		Day:              now.Format(dayLayout),
+		CreatedAt:        &now,
+	}
+	if rec.Caller == "" {
+		rec.Caller = shared.UsageCallerSupervisor
+	}
+	if price := l.price(ctx, scope.ModelID); price != nil {
+		rec.Cost = price.Cost(u)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, err := l.records.Create(ctx, []types.UsageRecord{rec}); err != nil {
+		return rec, err
+	}
+	for period, p := range l.periods {
+		if p.key != periodKey(period, now) {
+			continue
+		}
+		for _, key := range scopeKeys(rec.ModelID, rec.ConnectionID, rec.PlanID) {
+			t := p.scopes[key]
+			t.Add(rec)
+			p.scopes[key] = t
+		}
+	}
+	return rec, nil
+}
+
+func (l *Ledger) price(ctx context.Context, modelID string) *types.ModelPrice {
+	if modelID == "" || l.models == nil {
+		return nil
+	}
+	models, err := l.models.Get(ctx, []string{modelID})
+	if err != nil {
+		return nil
+	}
+	return models[modelID].Price
+}
+
+// Check fails with ErrBudgetExceeded when an enabled blocking budget that
+// covers scope is exceeded. Exceeded warn budgets are only logged, and so
+// is a ledger that can't be read: budgets never fail a call on their own.
+func (l *Ledger) Check(ctx context.Context, scope shared.UsageScope) error {
+	statuses, err := l.BudgetStatuses(ctx)
+	if err != nil {
+		log.Printf("usage: check budgets: %v", err)
+		return nil
+	}
+	planID, _ := scope.PlanRun()
+	for _, s := range statuses {
+		if !s.Enabled || !s.Exceeded || !covers(s.UsageBudget, scope.ModelID, scope.ConnectionID, planID) {
+			continue
+		}
+		if s.Action == types.UsageBudgetBlock {
+			return fmt.Errorf("%w: %s", ErrBudgetExceeded, describe(s))
+		}
+		log.Printf("usage: budget warning: %s", describe(s))
+	}
+	return nil
+}
+
+// BudgetStatuses returns every budget with its spend in the current period.
+func (l *Ledger) BudgetStatuses(ctx context.Context) ([]types.UsageBudgetStatus, error) {
+	budgets, err := l.budgets.List(ctx, types.ListQuery{Sort: []types.Sort{{Field: "created_at", Dir: types.SortDirAsc}}})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]types.UsageBudgetStatus, 0, len(budgets))
+	for _, b := range budgets {
+		totals, err := l.periodTotals(ctx, b.Period)
+		if err != nil {
+			return nil, err
+		}
+		spent := totals[budgetKey(b)]
+		out = append(out, types.UsageBudgetStatus{
+			UsageBudget: b,
+			Spent:       spent,
+			Exceeded: (b.MaxCost > 0 && spent.Cost >= b.MaxCost) ||
+				(b.MaxTokens > 0 && spent.Tokens() >= b.MaxTokens),
+		})
+	}
+	return out, nil
+}
+
+// periodTotals returns the current period's totals, loading them on first
+// use and again once the period has rolled over.
+func (l *Ledger) periodTotals(ctx context.Context, period types.UsageBudgetPeriod) (map[string]types.UsageTotals, error) {
+	now := l.now().UTC()
+	key := periodKey(period, now)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if p, ok := l.periods[period]; ok && p.key == key {
+		return maps.Clone(p.scopes), nil
+	}
+	start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if period == types.UsageBudgetMonth {
+		start = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+	p := &periodTotals{key: key, scopes: map[string]types.UsageTotals{}}
+	for day := start; !day.After(now); day = day.AddDate(0, 0, 1) {
+		records, err := l.records.List(ctx, types.ListQuery{Filter: map[string]string{"day": day.Format(dayLayout)}})
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range records {
+			for _, k := range scopeKeys(r.ModelID, r.ConnectionID, r.PlanID) {
+				t := p.scopes[k]
+				t.Add(r)
+				p.scopes[k] = t
+			}
+		}
+	}
+	l.periods[period] = p
+	return maps.Clone(p.scopes), nil
+}
+
+// Filter narrows the usage queries; empty fields match everything.
+type Filter struct {
+	ModelID      string
+	ConnectionID string
+	PlanID       string
+	Caller       string
+}
+
+func (f Filter) query(day string) types.ListQuery {
+	q := types.ListQuery{Filter: map[string]string{"day": day}}
+	for col, v := range map[string]string{"model_id": f.ModelID, "connection_id": f.ConnectionID, "plan_id": f.PlanID, "caller": f.Caller} {
+		if v != "" {
+			q.Filter[col] = v
+		}
+	}
+	return q
+}
+
+// Daily returns the spend of each of the last days days, oldest first.
+func (l *Ledger) Daily(ctx context.Context, days int, f Filter) ([]types.UsageDay, error) {
+	out := []types.UsageDay{}
+	err := l.eachDay(ctx, days, f, func(day string, records []types.UsageRecord) {
+		d := types.UsageDay{Day: day, ByCaller: map[string]types.UsageTotals{}}
+		for _, r := range records {
+			d.Add(r)
+			t := d.ByCaller[r.Caller]
+			t.Add(r)
+			d.ByCaller[r.Caller] = t
+		}
+		out = append(out, d)
+	})
+	return out, err
+}
+
+// Plans returns the spend of each plan over the last days days, split by
+// run, most expensive first.
+func (l *Ledger) Plans(ctx context.Context, days int, planID string) ([]types.PlanUsage, error) {
+	plans := map[string]*types.PlanUsage{}
+	runs := map[string]map[string]*types.PlanRunUsage{}
+	err := l.eachDay(ctx, days, Filter{PlanID: planID}, func(_ string, records []types.UsageRecord) {
+		for _, r := range records {
+			if r.PlanID == "" {
+				continue
+			}
+			p, ok := plans[r.PlanID]
+			if !ok {
+				p = &types.PlanUsage{PlanID: r.PlanID}
+				plans[r.PlanID] = p
+				runs[r.PlanID] = map[string]*types.PlanRunUsage{}
+			}
+			p.Add(r)
+			run, ok := runs[r.PlanID][r.PlanRunID]
+			if !ok {
+				run = &types.PlanRunUsage{RunID: r.PlanRunID}
+				runs[r.PlanID][r.PlanRunID] = run
+			}
+			run.Add(r)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]types.PlanUsage, 0, len(plans))
+	for id, p := range plans {
+		for _, run := range runs[id] {
+			p.Runs = append(p.Runs, *run)
+		}
+		slices.SortFunc(p.Runs, func(a, b types.PlanRunUsage) int {
+			return cmp.Or(cmp.Compare(b.Cost, a.Cost), cmp.Compare(a.RunID, b.RunID))
+		})
+		out = append(out, *p)
+	}
+	slices.SortFunc(out, func(a, b types.PlanUsage) int {
+		return cmp.Or(cmp.Compare(b.Cost, a.Cost), cmp.Compare(a.PlanID, b.PlanID))
+	})
+	return out, nil
+}
+
+func (l *Ledger) eachDay(ctx context.Context, days int, f Filter, fn func(day string, records []types.UsageRecord)) error {
+	if days <= 0 {
+		days = 30
+	}
+	days = min(days, MaxDays)
+	today := l.now().UTC()
+	for i := days - 1; i >= 0; i-- {
+		day := today.AddDate(0, 0, -i).Format(dayLayout)
+		records, err := l.records.List(ctx, f.query(day))
+		if err != nil {
+			return err
+		}
+		fn(day, records)
+	}
+	return nil
+}
+
+func periodKey(period types.UsageBudgetPeriod, t time.Time) string {
+	if period == types.UsageBudgetMonth {
+		return t.Format(monthLayout)
+	}
+	return t.Format(dayLayout)
+}
+
+// scopeKeys lists the budget scopes a record counts towards.
+func scopeKeys(modelID, connectionID, planID string) []string {
+	keys := []string{string(types.UsageBudgetGlobal)}
+	if modelID != "" {
+		keys = append(keys, "model:"+modelID)
+	}
+	if connectionID != "" {
+		keys = append(keys, "connection:"+connectionID)
+	}
+	if planID != "" {
+		keys = append(keys, "plan:"+planID)
+	}
+	return keys
+}
+
+func budgetKey(b types.UsageBudget) string {
+	if b.Scope == types.UsageBudgetGlobal || b.Scope == "" {
+		return string(types.UsageBudgetGlobal)
+	}
+	return string(b.Scope) + ":" + b.ScopeID
+}
+
+func covers(b types.UsageBudget, modelID, connectionID, planID string) bool {
+	switch b.Scope {
+	case types.UsageBudgetModel:
+		return b.ScopeID == modelID
+	case types.UsageBudgetConnection:
+		return b.ScopeID == connectionID
+	case types.UsageBudgetPlan:
+		return b.ScopeID == planID
+	default:
+		return true
+	}
+}
+
+func describe(s types.UsageBudgetStatus) string {
+	spent := fmt.Sprintf("%d of %d tokens", s.Spent.Tokens(), s.MaxTokens)
+	if s.MaxCost > 0 && s.Spent.Cost >= s.MaxCost {
+		spent = fmt.Sprintf("$%.2f of $%.2f", s.Spent.Cost, s.MaxCost)
+	}
+	return fmt.Sprintf("budget %q spent %s this %s", s.Name, spent, s.Period)
+}
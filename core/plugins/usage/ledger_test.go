@@ -0,0 +1,207 @@
+package usage
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"mantis/core/protocols"
+	"mantis/core/types"
+	"mantis/shared"
+)
+
+type memStore[T any] struct {
+	mu    sync.Mutex
+	items []T
+	id    func(T) string
+	// field returns the value of a filter column, for the columns the
+	// ledger filters on.
+	field func(T, string) string
+}
+
+func (s *memStore[T]) Create(_ context.Context, items []T) ([]T, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.items = append(s.items, items...)
+	return items, nil
+}
+
+func (s *memStore[T]) Get(_ context.Context, ids []string) (map[string]T, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := map[string]T{}
+	for _, item := range s.items {
+		for _, id := range ids {
+			if s.id(item) == id {
+				out[id] = item
+			}
+		}
+	}
+	return out, nil
+}
+
+func (s *memStore[T]) List(_ context.Context, q types.ListQuery) ([]T, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []T
+	for _, item := range s.items {
+		ok := true
+		for col, v := range q.Filter {
+			ok = ok && s.field(item, col) == v
+		}
+		if ok {
+			out = append(out, item)
+		}
+	}
+	return out, nil
+}
+
+func (s *memStore[T]) Update(_ context.Context, items []T) ([]T, error) { return items, nil }
+func (s *memStore[T]) Delete(context.Context, []string) error           { return nil }
+
+func recordField(r types.UsageRecord, col string) string {
+	return map[string]string{
+		"day": r.Day, "model_id": r.ModelID, "connection_id": r.ConnectionID, "plan_id": r.PlanID, "caller": r.Caller,
+	}[col]
+}
+
+func newTestLedger(now time.Time, budgets ...types.UsageBudget) (*Ledger, *memStore[types.UsageRecord]) {
+	records := &memStore[types.UsageRecord]{id: func(r types.UsageRecord) string { return r.ID }, field: recordField}
+	models := &memStore[types.Model]{id: func(m types.Model) string { return m.ID }, items: []types.Model{
+		{ID: "gpt", Name: "gpt-x", Price: &types.ModelPrice{Input: 2, Output: 8, CachedInput: 0.5}},
+		{ID: "local", Name: "llama"},
+	}}
+	var budgetStore protocols.Store[string, types.UsageBudget] = &memStore[types.UsageBudget]{
+		id: func(b types.UsageBudget) string { return b.ID }, items: budgets,
+	}
+	l := NewLedger(records, budgetStore, models)
+	l.now = func() time.Time { return now }
+	return l, records
+}
+
+func TestModelPrice_Cost(t *testing.T) {
+	p := types.ModelPrice{Input: 2, Output: 8, CachedInput: 0.5}
+	got := p.Cost(types.LLMUsage{PromptTokens: 1_000_000, CachedTokens: 400_000, CacheWriteTokens: 100_000, CompletionTokens: 250_000})
+	// 500k uncached at 2, 400k cached at 0.5, 100k cache writes at the input price, 250k out at 8.
+	if want := 1.0 + 0.2 + 0.2 + 2.0; math.Abs(got-want) > 1e-9 {
+		t.Fatalf("cost = %v, want %v", got, want)
+	}
+}
+
+func TestLedger_RecordAttributesAndPrices(t *testing.T) {
+	now := time.Date(2026, 3, 14, 9, 0, 0, 0, time.UTC)
+	l, records := newTestLedger(now)
+	scope := shared.UsageScope{Caller: shared.UsageCallerSSH, SessionID: "plan:p1:r1:step", ModelID: "gpt", ConnectionID: "c1"}
+	rec, err := l.Record(context.Background(), scope, "gpt-x", types.LLMUsage{PromptTokens: 1000, CompletionTokens: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.PlanID != "p1" || rec.PlanRunID != "r1" || rec.Day != "2026-03-14" || rec.Caller != "ssh" {
+		t.Fatalf("record = %+v", rec)
+	}
+	if math.Abs(rec.Cost-0.0028) > 1e-12 {
+		t.Fatalf("cost = %v", rec.Cost)
+	}
+	if len(records.items) != 1 {
+		t.Fatalf("stored %d records", len(records.items))
+	}
+
+	unpriced, _ := l.Record(context.Background(), shared.UsageScope{ModelID: "local"}, "llama", types.LLMUsage{PromptTokens: 10})
+	if unpriced.Cost != 0 || unpriced.Caller != shared.UsageCallerSupervisor {
+		t.Fatalf("unpriced record = %+v", unpriced)
+	}
+}
+
+func TestLedger_CheckBlocksExceededBudgets(t *testing.T) {
+	now := time.Date(2026, 3, 14, 9, 0, 0, 0, time.UTC)
+	l, records := newTestLedger(now,
+		types.UsageBudget{ID: "b1", Name: "gpt daily", Scope: types.UsageBudgetModel, ScopeID: "gpt", Period: types.UsageBudgetDay, MaxCost: 0.01, Action: types.UsageBudgetBlock, Enabled: true},
+		types.UsageBudget{ID: "b2", Name: "all monthly", Scope: types.UsageBudgetGlobal, Period: types.UsageBudgetMonth, MaxTokens: 100, Action: types.UsageBudgetWarn, Enabled: true},
+	)
+	// Yesterday's spend counts towards the month but not the day.
+	records.items = append(records.items, types.UsageRecord{ID: "old", ModelID: "gpt", Day: "2026-03-13", PromptTokens: 5000, Cost: 1})
+
+	ctx := context.Background()
+	gpt := shared.UsageScope{ModelID: "gpt"}
+	if err := l.Check(ctx, gpt); err != nil {
+		t.Fatalf("fresh day should pass, got %v", err)
+	}
+	statuses, err := l.BudgetStatuses(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if statuses[0].Exceeded || !statuses[1].Exceeded || statuses[1].Spent.Tokens() != 5000 {
+		t.Fatalf("statuses = %+v", statuses)
+	}
+
+	if _, err := l.Record(ctx, gpt, "gpt-x", types.LLMUsage{PromptTokens: 5000}); err != nil {
+		t.Fatal(err)
+	}
+	err = l.Check(ctx, gpt)
+	if !errors.Is(err, ErrBudgetExceeded) || !strings.Contains(err.Error(), `"gpt daily"`) {
+		t.Fatalf("expected the daily budget to block, got %v", err)
+	}
+	if err := l.Check(ctx, shared.UsageScope{ModelID: "local"}); err != nil {
+		t.Fatalf("other models are outside the budget, got %v", err)
+	}
+
+	// A new day starts from zero again.
+	l.now = func() time.Time { return now.AddDate(0, 0, 1) }
+	if err := l.Check(ctx, gpt); err != nil {
+		t.Fatalf("next day should pass, got %v", err)
+	}
+}
+
+func TestLedger_DailyAndPlans(t *testing.T) {
+	now := time.Date(2026, 3, 14, 9, 0, 0, 0, time.UTC)
+	l, records := newTestLedger(now)
+	records.items = []types.UsageRecord{
+		{ID: "1", Caller: "supervisor", Day: "2026-03-13", PromptTokens: 10, Cost: 0.1},
+		{ID: "2", Caller: "ssh", Day: "2026-03-14", PromptTokens: 20, Cost: 0.2, PlanID: "p1", PlanRunID: "r1"},
+		{ID: "3", Caller: "ssh", Day: "2026-03-14", PromptTokens: 30, Cost: 0.3, PlanID: "p1", PlanRunID: "r2"},
+		{ID: "4", Caller: "supervisor", Day: "2026-03-14", PromptTokens: 40, Cost: 0.05, PlanID: "p2", PlanRunID: "r3"},
+		{ID: "5", Caller: "supervisor", Day: "2026-02-01", PromptTokens: 50, Cost: 9},
+	}
+	ctx := context.Background()
+
+	days, err := l.Daily(ctx, 2, Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(days) != 2 || days[0].Day != "2026-03-13" || days[1].Calls != 3 || days[1].ByCaller["ssh"].PromptTokens != 50 {
+		t.Fatalf("daily = %+v", days)
+	}
+	days, _ = l.Daily(ctx, 2, Filter{Caller: "supervisor"})
+	if days[1].Calls != 1 {
+		t.Fatalf("caller filter: %+v", days)
+	}
+
+	plans, err := l.Plans(ctx, 7, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plans) != 2 || plans[0].PlanID != "p1" || len(plans[0].Runs) != 2 || plans[0].Runs[0].RunID != "r2" {
+		t.Fatalf("plans = %+v", plans)
+	}
+	if math.Abs(plans[0].Cost-0.5) > 1e-9 {
+		t.Fatalf("plan cost = %v", plans[0].Cost)
+	}
+}
+
+func TestUsageScope_PlanRun(t *testing.T) {
+	for session, want := range map[string][2]string{
+		"plan:p1:r1:s2": {"p1", "r1"},
+		"plan:p1:r1":    {"p1", "r1"},
+		"plan:p1":       {"p1", ""},
+		"chat-session":  {"", ""},
+	} {
+		plan, run := shared.UsageScope{SessionID: session}.PlanRun()
+		if plan != want[0] || run != want[1] {
+			t.Errorf("PlanRun(%q) = %q, %q", session, plan, run)
+		}
+	}
+}
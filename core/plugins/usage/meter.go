@@ -0,0 +1,75 @@
+package usage
+
+import (
+	"context"
+	"log"
+
+	"mantis/core/protocols"
+	"mantis/core/types"
+	"mantis/shared"
+)
+
+// Meter records the usage every model call reports in the ledger,
+// attributed by the shared.UsageScope of its context, and refuses calls a
+// blocking budget is exceeded for.
+type Meter struct {
+	next   protocols.LLM
+	ledger *Ledger
+}
+
+func NewMeter(next protocols.LLM, ledger *Ledger) *Meter {
+	return &Meter{next: next, ledger: ledger}
+}
+
+func (m *Meter) ChatStream(ctx context.Context, provider, baseURL, apiKey string, messages []protocols.LLMMessage, model string, tools []types.Tool, thinkingMode string, opts types.GenerationOptions) (<-chan types.StreamEvent, error) {
+	scope := shared.UsageScopeFromContext(ctx)
+	if err := m.ledger.Check(ctx, scope); err != nil {
+		return nil, err
+	}
+	ch, err := m.next.ChatStream(ctx, provider, baseURL, apiKey, messages, model, tools, thinkingMode, opts)
+	if err != nil {
+		return nil, err
+	}
+	out := make(chan types.StreamEvent, 32)
+	go func() {
+		defer close(out)
+		var usage *types.LLMUsage
+		for ev := range ch {
+			if ev.Type == "usage" && ev.Usage != nil {
+				usage = ev.Usage
+			}
+			out <- ev
+		}
+		m.record(ctx, scope, model, usage)
+	}()
+	return out, nil
+}
+
+func (m *Meter) record(ctx context.Context, scope shared.UsageScope, model string, usage *types.LLMUsage) {
+	if usage == nil {
+		return
+	}
+	if _, err := m.ledger.Record(context.WithoutCancel(ctx), scope, model, *usage); err != nil {
+		log.Printf("usage: record %s call to %s: %v", scope.Caller, model, err)
+	}
+}
+
+// VisionMeter does for image descriptions what Meter does for chat calls.
+type VisionMeter struct {
+	meter *Meter
+	next  protocols.VisionLLM
+}
+
+func NewVisionMeter(next protocols.VisionLLM, ledger *Ledger) *VisionMeter {
+	return &VisionMeter{meter: &Meter{ledger: ledger}, next: next}
+}
+
+func (v *VisionMeter) Describe(ctx context.Context, baseURL, apiKey, model string, image []byte, format, prompt string) (string, *types.LLMUsage, error) {
+	scope := shared.UsageScopeFromContext(ctx)
+	if err := v.meter.ledger.Check(ctx, scope); err != nil {
+		return "", nil, err
+	}
+	text, usage, err := v.next.Describe(ctx, baseURL, apiKey, model, image, format, prompt)
+	v.meter.record(ctx, scope, model, usage)
+	return text, usage, err
+}
@@ -0,0 +1,62 @@
+package usage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"mantis/core/protocols"
+	"mantis/core/types"
+	"mantis/shared"
+)
+
+type usageLLM struct{ calls int }
+
+func (u *usageLLM) ChatStream(context.Context, string, string, string, []protocols.LLMMessage, string, []types.Tool, string, types.GenerationOptions) (<-chan types.StreamEvent, error) {
+	u.calls++
+	ch := make(chan types.StreamEvent, 2)
+	ch <- types.StreamEvent{Type: "text", Delta: "ok"}
+	ch <- types.StreamEvent{Type: "usage", Usage: &types.LLMUsage{PromptTokens: 600, CompletionTokens: 50}}
+	close(ch)
+	return ch, nil
+}
+
+func TestMeter_RecordsAndBlocks(t *testing.T) {
+	l, records := newTestLedger(time.Date(2026, 3, 14, 9, 0, 0, 0, time.UTC),
+		types.UsageBudget{ID: "b", Name: "conn", Scope: types.UsageBudgetConnection, ScopeID: "c1", Period: types.UsageBudgetDay, MaxTokens: 1000, Action: types.UsageBudgetBlock, Enabled: true},
+	)
+	llm := &usageLLM{}
+	m := NewMeter(llm, l)
+	ctx := shared.WithUsageModel(shared.WithUsageCaller(context.Background(), shared.UsageCallerSummarizer), "c1", "gpt")
+
+	for i := 0; i < 2; i++ {
+		ch, err := m.ChatStream(ctx, "openai", "", "", nil, "gpt-x", nil, "", types.GenerationOptions{})
+		if err != nil {
+			t.Fatalf("call %d: %v", i, err)
+		}
+		for range ch {
+		}
+	}
+	// The stream closes before the record is written.
+	deadline := time.Now().Add(time.Second)
+	for {
+		records.mu.Lock()
+		n := len(records.items)
+		records.mu.Unlock()
+		if n == 2 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if records.items[1].Caller != "summarizer" || records.items[1].ConnectionID != "c1" {
+		t.Fatalf("records = %+v", records.items)
+	}
+
+	if _, err := m.ChatStream(ctx, "openai", "", "", nil, "gpt-x", nil, "", types.GenerationOptions{}); !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("expected the connection budget to block, got %v", err)
+	}
+	if llm.calls != 2 {
+		t.Fatalf("blocked call reached the model: %d calls", llm.calls)
+	}
+}
@@ -7,12 +7,19 @@ import (
 )
 
 type LLMMessage struct {
-	Role       string
-	Content    string
+	Role    string
+	Content string
+	// Parts, when set, is the message's content for models that take
+	// images. Content still holds its text, for adapters and models that
+	// only take text and for token estimates.
+	Parts      []types.ContentPart
 	ToolCalls  []types.ToolCall
 	ToolCallID string
+	// Thinking keeps an assistant turn's signed reasoning blocks for
+	// providers that require them back alongside its tool calls.
+	Thinking []types.ThinkingBlock
 }
 
 type LLM interface {
-	ChatStream(ctx context.Context, provider, baseURL, apiKey string, messages []LLMMessage, model string, tools []types.Tool, thinkingMode string) (<-chan types.StreamEvent, error)
+	ChatStream(ctx context.Context, provider, baseURL, apiKey string, messages []LLMMessage, model string, tools []types.Tool, thinkingMode string, opts types.GenerationOptions) (<-chan types.StreamEvent, error)
 }
@@ -7,7 +7,16 @@ import (
 )
 
 type PlanRunner interface {
-	TriggerRun(ctx context.Context, planID, trigger string, input map[string]any) (types.PlanRun, error)
+	TriggerRun(ctx context.Context, planID, trigger string, input map[string]any, force bool) (types.PlanRun, error)
+	SimulateRun(ctx context.Context, planID string, input map[string]any, mocks map[string]string) (types.PlanRun, error)
 	CancelRun(ctx context.Context, runID string) (types.PlanRun, error)
 	ActiveRuns(ctx context.Context) ([]types.PlanRun, error)
+	ResolveApproval(ctx context.Context, runID, decision, resolvedBy, comment string) (types.PlanRun, error)
+	ValidateGraph(graph types.PlanGraph) error
+}
+
+// PlanTemplates is the plan template library as the agent uses it.
+type PlanTemplates interface {
+	List(ctx context.Context) ([]types.PlanTemplate, error)
+	Instantiate(ctx context.Context, id string, in types.PlanTemplateInstance) (types.Plan, error)
 }
@@ -1,7 +1,11 @@
 package protocols
 
-import "context"
+import (
+	"context"
+
+	"mantis/core/types"
+)
 
 type VisionLLM interface {
-	Describe(ctx context.Context, baseURL, apiKey, model string, image []byte, format, prompt string) (string, error)
+	Describe(ctx context.Context, baseURL, apiKey, model string, image []byte, format, prompt string) (string, *types.LLMUsage, error)
 }
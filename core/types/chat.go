@@ -36,6 +36,8 @@ type ChatMessage struct {
 	PresetID    string          `json:"presetId,omitempty"`
 	PresetName  string          `json:"presetName,omitempty"`
 	ModelRole   string          `json:"modelRole,omitempty"` // primary | fallback | explicit | legacy
+	RouteTier   string          `json:"routeTier,omitempty"` // light | task | flagship, when the preset routes
+	RouteReason string          `json:"routeReason,omitempty"`
 	Steps       json.RawMessage `json:"steps,omitempty"`
 	Attachments []Attachment    `json:"attachments,omitempty"`
 	CreatedAt        time.Time       `json:"createdAt"`
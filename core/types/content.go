@@ -0,0 +1,24 @@
+package types
+
+// Kinds of ContentPart.
+const (
+	ContentPartText  = "text"
+	ContentPartImage = "image"
+)
+
+// ContentPart is one part of a multimodal message: text, or media bytes
+// with their MIME type. Audio is expected to follow as another media kind.
+type ContentPart struct {
+	Type string `json:"type"`
+	Text string `json:"text,omitempty"`
+	MIME string `json:"mime,omitempty"`
+	Data []byte `json:"data,omitempty"`
+}
+
+func TextPart(text string) ContentPart {
+	return ContentPart{Type: ContentPartText, Text: text}
+}
+
+func ImagePart(mime string, data []byte) ContentPart {
+	return ContentPart{Type: ContentPartImage, MIME: mime, Data: data}
+}
@@ -0,0 +1,43 @@
+package types
+
+// GenerationOptions tune a model call. Unset fields leave the provider's
+// default; adapters drop what their API does not support.
+type GenerationOptions struct {
+	Temperature       *float64 `json:"temperature,omitempty"`
+	TopP              *float64 `json:"topP,omitempty"`
+	MaxTokens         int      `json:"maxTokens,omitempty"`
+	Stop              []string `json:"stop,omitempty"`
+	Seed              *int64   `json:"seed,omitempty"`
+	ToolChoice        string   `json:"toolChoice,omitempty"`     // auto | none | required | a tool name
+	ResponseFormat    string   `json:"responseFormat,omitempty"` // text | json_object
+	ParallelToolCalls *bool    `json:"parallelToolCalls,omitempty"`
+}
+
+// Merge returns o overridden by every field set in over.
+func (o GenerationOptions) Merge(over GenerationOptions) GenerationOptions {
+	if over.Temperature != nil {
+		o.Temperature = over.Temperature
+	}
+	if over.TopP != nil {
+		o.TopP = over.TopP
+	}
+	if over.MaxTokens > 0 {
+		o.MaxTokens = over.MaxTokens
+	}
+	if len(over.Stop) > 0 {
+		o.Stop = over.Stop
+	}
+	if over.Seed != nil {
+		o.Seed = over.Seed
+	}
+	if over.ToolChoice != "" {
+		o.ToolChoice = over.ToolChoice
+	}
+	if over.ResponseFormat != "" {
+		o.ResponseFormat = over.ResponseFormat
+	}
+	if over.ParallelToolCalls != nil {
+		o.ParallelToolCalls = over.ParallelToolCalls
+	}
+	return o
+}
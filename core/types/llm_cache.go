@@ -0,0 +1,13 @@
+package types
+
+import "time"
+
+// LLMCacheEntry is a recorded model response: the events one ChatStream call
+// produced, stored under a hash of the request (see llm.CacheKey).
+type LLMCacheEntry struct {
+	ID        string        `json:"id"`
+	Provider  string        `json:"provider"`
+	Model     string        `json:"model"`
+	Events    []StreamEvent `json:"events"`
+	CreatedAt *time.Time    `json:"createdAt,omitempty"`
+}
@@ -1,5 +1,15 @@
 package types
 
+// Ways a model can be given tools.
+const (
+	// ToolCallingNative passes tools in the API's tools field.
+	ToolCallingNative = "native"
+	// ToolCallingPrompt lists tools in the system prompt and reads
+	// <tool_call> blocks back out of the reply, for models served without
+	// function calling.
+	ToolCallingPrompt = "prompt"
+)
+
 type Model struct {
 	ID            string `json:"id"`
 	ConnectionID  string `json:"connectionId"`
@@ -8,4 +18,16 @@ type Model struct {
 	ContextWindow int    `json:"contextWindow"`
 	ReserveTokens int    `json:"reserveTokens"`
 	CompactTokens int    `json:"compactTokens"`
+	// ToolCalling is ToolCallingNative (the default when empty) or
+	// ToolCallingPrompt.
+	ToolCalling string `json:"toolCalling,omitempty"`
+	// Vision marks a model that takes images in its messages. Images
+	// attached to a chat message go to it directly; other models describe
+	// them with the preset's image model through artifact_read_image.
+	Vision bool `json:"vision,omitempty"`
+
+	// Generation holds the model's default generation options.
+	Generation GenerationOptions `json:"generation"`
+	// Price, when set, prices the model's calls in the usage ledger.
+	Price *ModelPrice `json:"price,omitempty"`
 }
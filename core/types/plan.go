@@ -7,8 +7,42 @@ type PlanNodeType string
 const (
 	PlanNodeAction   PlanNodeType = "action"
 	PlanNodeDecision PlanNodeType = "decision"
+	PlanNodeApproval PlanNodeType = "approval"
+	PlanNodeForeach  PlanNodeType = "foreach"
 )
 
+// PlanEdgeError labels the edge a node follows when it fails after all
+// retries, instead of failing the whole run.
+const PlanEdgeError = "error"
+
+// PlanEdgeEach labels the edge from a foreach node to the first step of the
+// loop body it runs once per item. Its unlabeled edge continues after the
+// loop.
+const PlanEdgeEach = "each"
+
+// Edge labels an approval node branches on.
+const (
+	PlanEdgeApprove = "approve"
+	PlanEdgeReject  = "reject"
+)
+
+// Failure classes a node can be retried on (PlanNode.RetryOn).
+const (
+	PlanRetryOnTimeout  = "timeout"
+	PlanRetryOnError    = "error"
+	PlanRetryOnStopped  = "stopped"
+	PlanRetryOnReported = "reported"
+)
+
+// PlanNode timings are Go duration strings ("90s", "5m"). Timeout overrides
+// MANTIS_PLAN_STEP_TIMEOUT for this node (MANTIS_PLAN_APPROVAL_TIMEOUT for
+// approval nodes); RetryDelay is the base of the exponential backoff between
+// retries. An empty RetryOn retries every failure class. OnTimeout is the
+// branch an approval node takes when nobody answers in time (default reject).
+// Items is the list a foreach node iterates: "input.<parameter>" or
+// "steps.<nodeId>" for a JSON array in that step's output. Parallelism caps
+// how many items run at once; zero runs them one by one. PresetID or ModelID
+// pins the model a node runs with over the plan's default.
 type PlanNode struct {
 	ID           string          `json:"id"`
 	Type         PlanNodeType    `json:"type"`
@@ -17,6 +51,14 @@ type PlanNode struct {
 	Position     json.RawMessage `json:"position"`
 	ClearContext bool            `json:"clearContext,omitempty"`
 	MaxRetries   int             `json:"maxRetries,omitempty"`
+	Timeout      string          `json:"timeout,omitempty"`
+	RetryDelay   string          `json:"retryDelay,omitempty"`
+	RetryOn      []string        `json:"retryOn,omitempty"`
+	OnTimeout    string          `json:"onTimeout,omitempty"`
+	Items        string          `json:"items,omitempty"`
+	Parallelism  int             `json:"parallelism,omitempty"`
+	PresetID     string          `json:"presetId,omitempty"`
+	ModelID      string          `json:"modelId,omitempty"`
 }
 
 type PlanEdge struct {
@@ -31,6 +73,69 @@ type PlanGraph struct {
 	Edges []PlanEdge `json:"edges"`
 }
 
+// PlanConcurrency decides what happens when a plan is triggered while a
+// previous run of the same plan is still active.
+type PlanConcurrency string
+
+const (
+	PlanConcurrencyAllow          PlanConcurrency = "allow"
+	PlanConcurrencySkip           PlanConcurrency = "skip"
+	PlanConcurrencyQueue          PlanConcurrency = "queue"
+	PlanConcurrencyCancelPrevious PlanConcurrency = "cancel_previous"
+)
+
+// PlanWebhook configures the inbound webhook endpoint of a plan. Mapping
+// maps plan parameter names to JSONPath expressions evaluated against the
+// request payload; IdempotencyKey is an optional JSONPath used to dedupe
+// repeated deliveries when the sender does not set an Idempotency-Key header.
+type PlanWebhook struct {
+	Enabled        bool              `json:"enabled"`
+	Secret         string            `json:"secret,omitempty"`
+	Mapping        map[string]string `json:"mapping,omitempty"`
+	IdempotencyKey string            `json:"idempotencyKey,omitempty"`
+}
+
+// PlanNotifyWhen selects which finished runs send a report.
+type PlanNotifyWhen string
+
+const (
+	PlanNotifySuccess PlanNotifyWhen = "success"
+	PlanNotifyFailure PlanNotifyWhen = "failure"
+	PlanNotifyAlways  PlanNotifyWhen = "always"
+)
+
+// PlanNotify sends a run report when a run finishes. ChannelID picks the
+// channel to deliver through (the first Telegram channel when empty) and
+// Recipient the chat (the channel's first allowed user when empty).
+type PlanNotify struct {
+	When      PlanNotifyWhen `json:"when"`
+	ChannelID string         `json:"channelId,omitempty"`
+	Recipient string         `json:"recipient,omitempty"`
+}
+
+// PlanSLA is the service level a plan is expected to keep. MaxDuration is a
+// Go duration a run must finish within; MaxConsecutiveFailures is how many
+// failed runs in a row are tolerated. Zero values are not checked.
+type PlanSLA struct {
+	MaxDuration            string `json:"maxDuration,omitempty"`
+	MaxConsecutiveFailures int    `json:"maxConsecutiveFailures,omitempty"`
+}
+
+// PlanCatchUp decides what happens at startup to scheduled runs that were
+// missed while Mantis was down.
+type PlanCatchUp string
+
+const (
+	PlanCatchUpSkip    PlanCatchUp = "skip"
+	PlanCatchUpRunOnce PlanCatchUp = "run_once"
+	PlanCatchUpRunAll  PlanCatchUp = "run_all"
+)
+
+// Plan schedules are cron expressions evaluated in Timezone (an IANA name,
+// server-local time when empty). Jitter is a Go duration; each scheduled run
+// starts after a random delay of up to Jitter. PresetID or ModelID sets the
+// model for nodes that don't pin their own; without either nodes use the
+// default chat preset.
 type Plan struct {
 	ID          string          `json:"id"`
 	Name        string          `json:"name"`
@@ -39,4 +144,13 @@ type Plan struct {
 	Enabled     bool            `json:"enabled"`
 	Parameters  json.RawMessage `json:"parameters"`
 	Graph       PlanGraph       `json:"graph"`
+	Timezone    string          `json:"timezone,omitempty"`
+	Jitter      string          `json:"jitter,omitempty"`
+	CatchUp     PlanCatchUp     `json:"catchUp,omitempty"`
+	Concurrency PlanConcurrency `json:"concurrency,omitempty"`
+	Webhook     *PlanWebhook    `json:"webhook,omitempty"`
+	Notify      *PlanNotify     `json:"notify,omitempty"`
+	SLA         *PlanSLA        `json:"sla,omitempty"`
+	PresetID    string          `json:"presetId,omitempty"`
+	ModelID     string          `json:"modelId,omitempty"`
 }
@@ -0,0 +1,32 @@
+package types
+
+import "time"
+
+// PlanBlackoutAction decides what happens to an automatic trigger that fires
+// inside a blackout window.
+type PlanBlackoutAction string
+
+const (
+	PlanBlackoutSkip  PlanBlackoutAction = "skip"
+	PlanBlackoutDefer PlanBlackoutAction = "defer"
+)
+
+// PlanBlackout is a window in which plans don't run on their own. It covers
+// one plan, or all of them when PlanID is empty. A window is either one-off,
+// from StartsAt to EndsAt, or recurring: it opens each time the cron
+// expression Schedule fires in Timezone and stays open for Duration (a Go
+// duration). Scheduled, catch-up and webhook runs are skipped or deferred to
+// the end of the window by Action; manual runs need to be forced.
+type PlanBlackout struct {
+	ID        string             `json:"id"`
+	Name      string             `json:"name"`
+	PlanID    string             `json:"planId,omitempty"`
+	Enabled   bool               `json:"enabled"`
+	Action    PlanBlackoutAction `json:"action"`
+	StartsAt  *time.Time         `json:"startsAt,omitempty"`
+	EndsAt    *time.Time         `json:"endsAt,omitempty"`
+	Schedule  string             `json:"schedule,omitempty"`
+	Duration  string             `json:"duration,omitempty"`
+	Timezone  string             `json:"timezone,omitempty"`
+	CreatedAt *time.Time         `json:"createdAt,omitempty"`
+}
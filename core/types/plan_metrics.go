@@ -0,0 +1,45 @@
+package types
+
+import "time"
+
+// PlanMetrics aggregates a plan's finished runs since a point in time.
+// Simulation runs are not counted. SuccessRate is completed runs over
+// completed and failed ones; durations are in milliseconds.
+type PlanMetrics struct {
+	PlanID              string            `json:"planId"`
+	PlanName            string            `json:"planName"`
+	Since               time.Time         `json:"since"`
+	Runs                int               `json:"runs"`
+	Completed           int               `json:"completed"`
+	Failed              int               `json:"failed"`
+	Cancelled           int               `json:"cancelled"`
+	SuccessRate         float64           `json:"successRate"`
+	ConsecutiveFailures int               `json:"consecutiveFailures"`
+	DurationP50Ms       int64             `json:"durationP50Ms"`
+	DurationP95Ms       int64             `json:"durationP95Ms"`
+	Retries             int               `json:"retries"`
+	MostFailingNode     string            `json:"mostFailingNode,omitempty"`
+	Tokens              PlanTokenUsage    `json:"tokens"`
+	Nodes               []PlanNodeMetrics `json:"nodes"`
+	SLA                 *PlanSLA          `json:"sla,omitempty"`
+	SLABreaches         []string          `json:"slaBreaches,omitempty"`
+	LastRunAt           *time.Time        `json:"lastRunAt,omitempty"`
+}
+
+// PlanNodeMetrics aggregates the step runs of one plan node.
+type PlanNodeMetrics struct {
+	NodeID        string         `json:"nodeId"`
+	Label         string         `json:"label"`
+	Runs          int            `json:"runs"`
+	Failures      int            `json:"failures"`
+	Retries       int            `json:"retries"`
+	DurationP50Ms int64          `json:"durationP50Ms"`
+	DurationP95Ms int64          `json:"durationP95Ms"`
+	Tokens        PlanTokenUsage `json:"tokens"`
+}
+
+type PlanTokenUsage struct {
+	Prompt     int `json:"prompt"`
+	Completion int `json:"completion"`
+	Total      int `json:"total"`
+}
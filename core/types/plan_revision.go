@@ -0,0 +1,14 @@
+package types
+
+import "time"
+
+// PlanRevision is a snapshot of a plan taken every time it is saved. Version
+// starts at 1 and increases per plan.
+type PlanRevision struct {
+	ID        string    `json:"id"`
+	PlanID    string    `json:"planId"`
+	Version   int       `json:"version"`
+	Plan      Plan      `json:"plan"`
+	CreatedBy string    `json:"createdBy,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
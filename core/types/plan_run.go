@@ -2,22 +2,71 @@ package types
 
 import "time"
 
+// PlanRun is one execution of a plan. Mocks holds the canned tool results of
+// a simulation run, keyed by tool name. Reason says why a run was skipped,
+// deferred or forced through a blackout window.
 type PlanRun struct {
-	ID         string         `json:"id"`
-	PlanID     string         `json:"planId"`
-	Status     string         `json:"status"`
-	Trigger    string         `json:"trigger"`
-	Input      map[string]any `json:"input"`
-	Steps      []PlanStepRun  `json:"steps"`
-	StartedAt  time.Time      `json:"startedAt"`
-	FinishedAt *time.Time     `json:"finishedAt,omitempty"`
+	ID             string            `json:"id"`
+	PlanID         string            `json:"planId"`
+	Status         string            `json:"status"`
+	Trigger        string            `json:"trigger"`
+	Input          map[string]any    `json:"input"`
+	IdempotencyKey string            `json:"idempotencyKey,omitempty"`
+	Mocks          map[string]string `json:"mocks,omitempty"`
+	Reason         string            `json:"reason,omitempty"`
+	Steps          []PlanStepRun     `json:"steps"`
+	StartedAt      time.Time         `json:"startedAt"`
+	FinishedAt     *time.Time        `json:"finishedAt,omitempty"`
 }
 
+// PlanStepRun is one node of a run. ModelID, ModelName and PresetName record
+// the model the step's last attempt ran with.
 type PlanStepRun struct {
-	NodeID     string     `json:"nodeId"`
-	Status     string     `json:"status"`
-	Result     string     `json:"result,omitempty"`
-	MessageID  string     `json:"messageId,omitempty"`
-	StartedAt  *time.Time `json:"startedAt,omitempty"`
-	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+	NodeID         string          `json:"nodeId"`
+	Status         string          `json:"status"`
+	Result         string          `json:"result,omitempty"`
+	MessageID      string          `json:"messageId,omitempty"`
+	ModelID        string          `json:"modelId,omitempty"`
+	ModelName      string          `json:"modelName,omitempty"`
+	PresetName     string          `json:"presetName,omitempty"`
+	Approval       *PlanApproval   `json:"approval,omitempty"`
+	SimulatedCalls []SimulatedCall `json:"simulatedCalls,omitempty"`
+	Attempts       int             `json:"attempts,omitempty"`
+	Items          []PlanItemRun   `json:"items,omitempty"`
+	StartedAt      *time.Time      `json:"startedAt,omitempty"`
+	FinishedAt     *time.Time      `json:"finishedAt,omitempty"`
+}
+
+// PlanItemRun is one item of a foreach step: the value, the session its
+// loop body ran in, the body steps it went through in order and the output
+// of the last one.
+type PlanItemRun struct {
+	Index      int           `json:"index"`
+	Item       any           `json:"item"`
+	SessionID  string        `json:"sessionId,omitempty"`
+	Status     string        `json:"status"`
+	Result     string        `json:"result,omitempty"`
+	Steps      []PlanStepRun `json:"steps,omitempty"`
+	StartedAt  *time.Time    `json:"startedAt,omitempty"`
+	FinishedAt *time.Time    `json:"finishedAt,omitempty"`
+}
+
+// PlanApproval is the state of an approval step: what the approver was
+// asked, the output that led to it, and how the wait was resolved.
+type PlanApproval struct {
+	Message    string     `json:"message"`
+	Context    string     `json:"context,omitempty"`
+	ExpiresAt  time.Time  `json:"expiresAt"`
+	Decision   string     `json:"decision,omitempty"`
+	ResolvedBy string     `json:"resolvedBy,omitempty"`
+	Comment    string     `json:"comment,omitempty"`
+	ResolvedAt *time.Time `json:"resolvedAt,omitempty"`
+}
+
+// SimulatedCall is a tool call that a simulation run recorded instead of
+// executing, with the result the agent was given in its place.
+type SimulatedCall struct {
+	Tool   string `json:"tool"`
+	Args   string `json:"args"`
+	Result string `json:"result"`
 }
@@ -0,0 +1,28 @@
+package types
+
+import "time"
+
+// PlanTemplate is a reusable plan. Plan.Parameters declares what the template
+// asks for; the values given when a plan is created from it become that
+// plan's parameter defaults. Built-in templates ship with Mantis and cannot
+// be deleted.
+type PlanTemplate struct {
+	ID          string     `json:"id"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Builtin     bool       `json:"builtin"`
+	Plan        Plan       `json:"plan"`
+	CreatedBy   string     `json:"createdBy,omitempty"`
+	CreatedAt   *time.Time `json:"createdAt,omitempty"`
+}
+
+// PlanTemplateInstance is what creating a plan from a template takes. Empty
+// fields keep the template's values; a Schedule of "" makes the plan manual
+// only. Enabled defaults to whether the plan has a schedule.
+type PlanTemplateInstance struct {
+	Name       string         `json:"name,omitempty"`
+	Schedule   *string        `json:"schedule,omitempty"`
+	Timezone   string         `json:"timezone,omitempty"`
+	Enabled    *bool          `json:"enabled,omitempty"`
+	Parameters map[string]any `json:"parameters,omitempty"`
+}
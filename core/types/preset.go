@@ -1,12 +1,51 @@
 package types
 
+// Preset assigns models to roles. When a chat request to ChatModelID fails
+// with a provider error, it is retried on FallbackModelID and then on each of
+// FallbackModelIDs in order. Generation and Temperature override the
+// generation options of the model in use, and SystemPrompt is added to the
+// chat agent's system prompt.
 type Preset struct {
-	ID              string   `json:"id"`
-	Name            string   `json:"name"`
-	ChatModelID     string   `json:"chatModelId"`
-	SummaryModelID  string   `json:"summaryModelId"`
-	ImageModelID    string   `json:"imageModelId"`
-	FallbackModelID string   `json:"fallbackModelId"`
-	Temperature     *float64 `json:"temperature"`
-	SystemPrompt    string   `json:"systemPrompt"`
+	ID               string   `json:"id"`
+	Name             string   `json:"name"`
+	ChatModelID      string   `json:"chatModelId"`
+	SummaryModelID   string   `json:"summaryModelId"`
+	ImageModelID     string   `json:"imageModelId"`
+	FallbackModelID  string   `json:"fallbackModelId"`
+	FallbackModelIDs []string `json:"fallbackModelIds"`
+	Temperature      *float64 `json:"temperature"`
+	SystemPrompt     string   `json:"systemPrompt"`
+
+	Generation GenerationOptions `json:"generation"`
+	Routing    PresetRouting     `json:"routing"`
+}
+
+// PresetRouting sends each chat request to the cheapest model tier that can
+// handle it: trivial chat to LightModelID, a single server task to
+// TaskModelID, and multi-step investigations to the preset's ChatModelID.
+// A class whose tier has no model goes to the next stronger tier. When
+// EscalateAfter is set, a request on a cheaper tier moves to ChatModelID
+// once that many tool iterations have failed.
+type PresetRouting struct {
+	Enabled       bool   `json:"enabled"`
+	LightModelID  string `json:"lightModelId,omitempty"`
+	TaskModelID   string `json:"taskModelId,omitempty"`
+	EscalateAfter int    `json:"escalateAfter,omitempty"`
+}
+
+// Route tiers recorded on routed messages.
+const (
+	RouteTierLight    = "light"
+	RouteTierTask     = "task"
+	RouteTierFlagship = "flagship"
+)
+
+// GenerationOptions returns the preset's overrides, with Temperature taking
+// precedence over Generation.Temperature.
+func (p Preset) GenerationOptions() GenerationOptions {
+	g := p.Generation
+	if p.Temperature != nil {
+		g.Temperature = p.Temperature
+	}
+	return g
 }
@@ -6,11 +6,24 @@ type ToolCall struct {
 	Arguments string
 }
 
+// LLMUsage counts the tokens of one model call. PromptTokens includes the
+// cached ones: CachedTokens were read from the provider's prompt cache and
+// CacheWriteTokens were written to it.
 type LLMUsage struct {
 	PromptTokens     int `json:"promptTokens,omitempty"`
 	CompletionTokens int `json:"completionTokens,omitempty"`
 	TotalTokens      int `json:"totalTokens,omitempty"`
 	CachedTokens     int `json:"cachedTokens,omitempty"`
+	CacheWriteTokens int `json:"cacheWriteTokens,omitempty"`
+}
+
+// ThinkingBlock is a complete reasoning block as the provider signed it.
+// Providers that check the signature need it back unchanged with the
+// assistant turn it belongs to; Redacted holds an encrypted block instead.
+type ThinkingBlock struct {
+	Text      string
+	Signature string
+	Redacted  string
 }
 
 type StreamEvent struct {
@@ -26,6 +39,7 @@ type StreamEvent struct {
 	PresetName string
 	ModelRole  string
 	ToolCalls  []ToolCall
+	Thinking   *ThinkingBlock
 	Usage      *LLMUsage
 	IsFinal    bool
 }
@@ -29,3 +29,15 @@ type Step struct {
 	StartedAt     string `json:"startedAt"`
 	FinishedAt    string `json:"finishedAt,omitempty"`
 }
+
+// ToolCallStat counts one model's tool calls since startup. Repaired calls
+// had their arguments fixed up before the tool ran; invalid ones named an
+// unknown tool or broke its schema and were sent back to the model.
+type ToolCallStat struct {
+	ModelID     string  `json:"modelId,omitempty"`
+	ModelName   string  `json:"modelName"`
+	Calls       int     `json:"calls"`
+	Repaired    int     `json:"repaired"`
+	Invalid     int     `json:"invalid"`
+	InvalidRate float64 `json:"invalidRate"`
+}
@@ -0,0 +1,138 @@
+package types
+
+import "time"
+
+// UsageRecord is one model call in the usage ledger. Caller names the part
+// of Mantis that made it: supervisor, ssh, summarizer, memory or vision.
+// Cost is in USD at the model's price when the call was made, zero when the
+// model has none. Day is the UTC date the call was made on.
+type UsageRecord struct {
+	ID               string     `json:"id"`
+	Caller           string     `json:"caller"`
+	ModelID          string     `json:"modelId,omitempty"`
+	ModelName        string     `json:"modelName"`
+	ConnectionID     string     `json:"connectionId,omitempty"`
+	SessionID        string     `json:"sessionId,omitempty"`
+	MessageID        string     `json:"messageId,omitempty"`
+	PlanID           string     `json:"planId,omitempty"`
+	PlanRunID        string     `json:"planRunId,omitempty"`
+	PromptTokens     int        `json:"promptTokens"`
+	CompletionTokens int        `json:"completionTokens"`
+	CachedTokens     int        `json:"cachedTokens"`
+	CacheWriteTokens int        `json:"cacheWriteTokens"`
+	Cost             float64    `json:"cost"`
+	Day              string     `json:"day"`
+	CreatedAt        *time.Time `json:"createdAt,omitempty"`
+}
+
+// ModelPrice is what a model costs in USD per million tokens. Cached and
+// cache-write input tokens are charged at Input when their price is unset.
+type ModelPrice struct {
+	Input       float64 `json:"input"`
+	Output      float64 `json:"output"`
+	CachedInput float64 `json:"cachedInput,omitempty"`
+	CacheWrite  float64 `json:"cacheWrite,omitempty"`
+}
+
+// Cost prices a call's token usage.
+func (p ModelPrice) Cost(u LLMUsage) float64 {
+	cachedPrice, writePrice := p.CachedInput, p.CacheWrite
+	if cachedPrice == 0 {
+		cachedPrice = p.Input
+	}
+	if writePrice == 0 {
+		writePrice = p.Input
+	}
+	uncached := max(u.PromptTokens-u.CachedTokens-u.CacheWriteTokens, 0)
+	return (float64(uncached)*p.Input +
+		float64(u.CachedTokens)*cachedPrice +
+		float64(u.CacheWriteTokens)*writePrice +
+		float64(u.CompletionTokens)*p.Output) / 1e6
+}
+
+// UsageTotals sums ledger records.
+type UsageTotals struct {
+	Calls            int     `json:"calls"`
+	PromptTokens     int     `json:"promptTokens"`
+	CompletionTokens int     `json:"completionTokens"`
+	CachedTokens     int     `json:"cachedTokens"`
+	CacheWriteTokens int     `json:"cacheWriteTokens"`
+	Cost             float64 `json:"cost"`
+}
+
+func (t *UsageTotals) Add(r UsageRecord) {
+	t.Calls++
+	t.PromptTokens += r.PromptTokens
+	t.CompletionTokens += r.CompletionTokens
+	t.CachedTokens += r.CachedTokens
+	t.CacheWriteTokens += r.CacheWriteTokens
+	t.Cost += r.Cost
+}
+
+// Tokens is the prompt and completion tokens together.
+func (t UsageTotals) Tokens() int {
+	return t.PromptTokens + t.CompletionTokens
+}
+
+// UsageDay is one day of spend, split by caller.
+type UsageDay struct {
+	Day string `json:"day"`
+	UsageTotals
+	ByCaller map[string]UsageTotals `json:"byCaller"`
+}
+
+// PlanUsage is a plan's spend over a window, split by run.
+type PlanUsage struct {
+	PlanID string `json:"planId"`
+	UsageTotals
+	Runs []PlanRunUsage `json:"runs,omitempty"`
+}
+
+type PlanRunUsage struct {
+	RunID string `json:"runId"`
+	UsageTotals
+}
+
+type (
+	UsageBudgetScope  string
+	UsageBudgetPeriod string
+	UsageBudgetAction string
+)
+
+const (
+	UsageBudgetGlobal     UsageBudgetScope = "global"
+	UsageBudgetModel      UsageBudgetScope = "model"
+	UsageBudgetConnection UsageBudgetScope = "connection"
+	UsageBudgetPlan       UsageBudgetScope = "plan"
+
+	UsageBudgetDay   UsageBudgetPeriod = "day"
+	UsageBudgetMonth UsageBudgetPeriod = "month"
+
+	UsageBudgetWarn  UsageBudgetAction = "warn"
+	UsageBudgetBlock UsageBudgetAction = "block"
+)
+
+// UsageBudget caps spend per UTC day or month, over all calls or those of
+// one model, LLM connection or plan (ScopeID). It is exceeded once either
+// limit set is reached: MaxCost in USD or MaxTokens. A warn budget only
+// reports it; a block budget refuses further calls in its scope until the
+// period ends.
+type UsageBudget struct {
+	ID        string            `json:"id"`
+	Name      string            `json:"name"`
+	Scope     UsageBudgetScope  `json:"scope"`
+	ScopeID   string            `json:"scopeId,omitempty"`
+	Period    UsageBudgetPeriod `json:"period"`
+	MaxCost   float64           `json:"maxCost,omitempty"`
+	MaxTokens int               `json:"maxTokens,omitempty"`
+	Action    UsageBudgetAction `json:"action"`
+	Enabled   bool              `json:"enabled"`
+	CreatedAt *time.Time        `json:"createdAt,omitempty"`
+}
+
+// UsageBudgetStatus is a budget with its spend in the current period.
+type UsageBudgetStatus struct {
+	UsageBudget
+	Spent    UsageTotals `json:"spent"`
+	Exceeded bool        `json:"exceeded"`
+}
@@ -29,6 +29,8 @@ type Input struct {
 	DisableHistory bool
 	ErrorPrefix    string
 	Timeout        time.Duration
+	TimeoutMarker  string
+	Simulation     *agents.Simulation
 	Finally        func()
 }
 
@@ -71,6 +73,10 @@ func (w *Workflow) SetAttachmentDir(dir string) {
 	w.pipeline.SetAttachmentDir(dir)
 }
 
+func (w *Workflow) SetRouter(r *modelplugin.Router) {
+	w.pipeline.SetRouter(r)
+}
+
 type RegenerateInput struct {
 	SessionID    string
 	UserContent  string
@@ -215,6 +221,8 @@ func (w *Workflow) Execute(ctx context.Context, in Input) (Output, error) {
 			DisableHistory: in.DisableHistory,
 			ErrorPrefix:    in.ErrorPrefix,
 			Timeout:        in.Timeout,
+			TimeoutMarker:  in.TimeoutMarker,
+			Simulation:     in.Simulation,
 			Finally:        in.Finally,
 		})
 	}()
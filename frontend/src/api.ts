@@ -1,4 +1,4 @@
-import type { Settings, Model, Preset, Connection, Skill, Plan, PlanRun, GuardProfile, ChatSession, ChatMessage, SessionLog, LlmConnection, ProviderModel, InferenceLimit, Channel, User, ContextStatus, SandboxStatus, GonkaConfig, GonkaWallet, GonkaBalance, GonkaAccountStatus, TelegramWizardBot, TelegramWizardUser } from './types'
+import type { Settings, Model, Preset, Connection, Skill, Plan, PlanMetrics, PlanRun, PlanRevision, PlanBlackout, PlanBlackoutInput, PlanTemplate, PlanTemplateInstance, PlanValidation, GuardProfile, ChatSession, ChatMessage, SessionLog, LlmConnection, ProviderModel, InferenceLimit, Channel, User, ContextStatus, SandboxStatus, GonkaConfig, GonkaWallet, GonkaBalance, GonkaAccountStatus, TelegramWizardBot, TelegramWizardUser } from './types'
 
 export class UnauthorizedError extends Error {
   constructor(message = 'Unauthorized') {
@@ -7,6 +7,15 @@ export class UnauthorizedError extends Error {
   }
 }
 
+// ApiError carries the HTTP status and the server's explanation, so callers
+// can react to a specific status such as 409.
+export class ApiError extends Error {
+  constructor(message: string, public status: number, public detail?: string) {
+    super(message)
+    this.name = 'ApiError'
+  }
+}
+
 type UnauthorizedHandler = () => void
 let unauthorizedHandler: UnauthorizedHandler | null = null
 
@@ -26,7 +35,7 @@ async function request<T>(path: string, options?: RequestInit): Promise<T> {
   }
   if (!res.ok) {
     const body = await res.json().catch(() => null)
-    throw new Error(body?.title ?? `${res.status} ${res.statusText}`)
+    throw new ApiError(body?.title ?? `${res.status} ${res.statusText}`, res.status, body?.detail)
   }
   if (res.status === 204) return undefined as T
   return res.json()
@@ -107,13 +116,44 @@ export const api = {
     update: (id: string, data: Omit<Plan, 'id'>) =>
       request<Plan>(`/plans/${id}`, { method: 'PUT', body: JSON.stringify(data) }),
     delete: (id: string) => request<void>(`/plans/${id}`, { method: 'DELETE' }),
+    exportUrl: (id: string, version?: number) =>
+      `/api/plans/${id}/export${version ? `?version=${version}` : ''}`,
+    import: (document: string, planId?: string) =>
+      request<Plan>('/plans/import', { method: 'POST', body: JSON.stringify({ document, planId }) }),
+    validate: (document: string) =>
+      request<PlanValidation>('/plans/validate', { method: 'POST', body: JSON.stringify({ document }) }),
+    revisions: (id: string) => request<PlanRevision[]>(`/plans/${id}/revisions`),
+    schedulePreview: (schedule: string, timezone?: string, count = 5) =>
+      request<{ times: string[] }>('/plans/schedule-preview', { method: 'POST', body: JSON.stringify({ schedule, timezone, count }) }),
+    metrics: (id: string, days?: number) => request<PlanMetrics>(`/plans/${id}/metrics${days ? `?days=${days}` : ''}`),
+    allMetrics: (days?: number) => request<PlanMetrics[]>(`/plans/metrics${days ? `?days=${days}` : ''}`),
+  },
+  planTemplates: {
+    list: () => request<PlanTemplate[]>('/plan-templates'),
+    create: (planId: string, name?: string, description?: string) =>
+      request<PlanTemplate>('/plan-templates', { method: 'POST', body: JSON.stringify({ planId, name, description }) }),
+    delete: (id: string) => request<void>(`/plan-templates/${id}`, { method: 'DELETE' }),
+    instantiate: (id: string, data: PlanTemplateInstance) =>
+      request<Plan>(`/plan-templates/${id}/instantiate`, { method: 'POST', body: JSON.stringify(data) }),
+  },
+  planBlackouts: {
+    list: (planId?: string) => request<PlanBlackout[]>(`/plan-blackouts${planId ? `?planId=${planId}` : ''}`),
+    create: (data: PlanBlackoutInput) =>
+      request<PlanBlackout>('/plan-blackouts', { method: 'POST', body: JSON.stringify(data) }),
+    update: (id: string, data: PlanBlackoutInput) =>
+      request<PlanBlackout>(`/plan-blackouts/${id}`, { method: 'PUT', body: JSON.stringify(data) }),
+    delete: (id: string) => request<void>(`/plan-blackouts/${id}`, { method: 'DELETE' }),
   },
   planRuns: {
     list: (planId: string) => request<PlanRun[]>(`/plans/${planId}/runs`),
     get: (id: string) => request<PlanRun>(`/plan-runs/${id}`),
-    trigger: (planId: string, input?: Record<string, unknown>) =>
-      request<PlanRun>(`/plans/${planId}/runs`, { method: 'POST', body: JSON.stringify({ input: input ?? {} }) }),
+    trigger: (planId: string, input?: Record<string, unknown>, force?: boolean) =>
+      request<PlanRun>(`/plans/${planId}/runs`, { method: 'POST', body: JSON.stringify({ input: input ?? {}, force }) }),
+    simulate: (planId: string, input?: Record<string, unknown>, mocks?: Record<string, string>) =>
+      request<PlanRun>(`/plans/${planId}/runs`, { method: 'POST', body: JSON.stringify({ input: input ?? {}, simulate: true, mocks }) }),
     cancel: (id: string) => request<PlanRun>(`/plan-runs/${id}/cancel`, { method: 'POST' }),
+    resolveApproval: (id: string, decision: 'approve' | 'reject', comment?: string) =>
+      request<PlanRun>(`/plan-runs/${id}/approval`, { method: 'POST', body: JSON.stringify({ decision, comment }) }),
   },
   telegram: {
     verify: (token: string) =>
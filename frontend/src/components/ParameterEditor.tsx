@@ -11,6 +11,7 @@ interface Param {
   name: string
   type: ParamType
   description: string
+  default: string
   required: boolean
 }
 
@@ -20,23 +21,38 @@ interface Props {
 }
 
 function fromSchema(schema: Record<string, unknown>): Param[] {
-  const props = (schema?.properties ?? {}) as Record<string, { type?: string; description?: string }>
+  const props = (schema?.properties ?? {}) as Record<string, { type?: string; description?: string; default?: unknown }>
   const req = Array.isArray(schema?.required) ? (schema.required as string[]) : []
   return Object.entries(props).map(([name, p]) => ({
     name,
     type: paramTypes.includes(p.type as ParamType) ? (p.type as ParamType) : 'string',
     description: p.description ?? '',
+    default: p.default == null ? '' : String(p.default),
     required: req.includes(name),
   }))
 }
 
+// defaultValue converts a default typed in the editor to the parameter's
+// type; a value that doesn't convert is dropped.
+function defaultValue(type: ParamType, raw: string): unknown {
+  const v = raw.trim()
+  if (!v) return undefined
+  if (type === 'boolean') return v === 'true' ? true : v === 'false' ? false : undefined
+  if (type === 'number' || type === 'integer') {
+    const n = Number(v)
+    return Number.isFinite(n) && (type === 'number' || Number.isInteger(n)) ? n : undefined
+  }
+  return v
+}
+
 function toSchema(params: Param[]): Record<string, unknown> {
   const properties: Record<string, unknown> = {}
   const required: string[] = []
   for (const p of params) {
     const key = p.name.trim()
     if (!key) continue
-    properties[key] = { type: p.type, description: p.description }
+    const def = defaultValue(p.type, p.default)
+    properties[key] = def === undefined ? { type: p.type, description: p.description } : { type: p.type, description: p.description, default: def }
     if (p.required) required.push(key)
   }
   const schema: Record<string, unknown> = { type: 'object', properties }
@@ -53,7 +69,7 @@ export function ParameterEditor({ value, onChange }: Props) {
   }
 
   const add = () => {
-    emit([...params, { name: '', type: 'string', description: '', required: false }])
+    emit([...params, { name: '', type: 'string', description: '', default: '', required: false }])
   }
 
   const update = (idx: number, patch: Partial<Param>) => {
@@ -67,10 +83,11 @@ export function ParameterEditor({ value, onChange }: Props) {
   return (
     <div className="space-y-2">
       {params.length > 0 && (
-        <div className="grid grid-cols-[1fr_100px_1.5fr_auto_auto] gap-x-2 items-center px-1 text-[10px] font-semibold uppercase tracking-wider text-zinc-500 dark:text-zinc-600">
+        <div className="grid grid-cols-[1fr_100px_1.5fr_1fr_auto_auto] gap-x-2 items-center px-1 text-[10px] font-semibold uppercase tracking-wider text-zinc-500 dark:text-zinc-600">
           <span>Name</span>
           <span>Type</span>
           <span>Description</span>
+          <span>Default</span>
           <span className="text-center w-14">Required</span>
           <span className="w-8" />
         </div>
@@ -79,7 +96,7 @@ export function ParameterEditor({ value, onChange }: Props) {
       {params.map((p, idx) => (
         <div
           key={idx}
-          className="grid grid-cols-[1fr_100px_1.5fr_auto_auto] gap-x-2 items-center rounded-md bg-zinc-50 dark:bg-zinc-800/50 px-1 py-1.5"
+          className="grid grid-cols-[1fr_100px_1.5fr_1fr_auto_auto] gap-x-2 items-center rounded-md bg-zinc-50 dark:bg-zinc-800/50 px-1 py-1.5"
         >
           <Input
             value={p.name}
@@ -102,6 +119,12 @@ export function ParameterEditor({ value, onChange }: Props) {
             placeholder="What this parameter does"
             className="h-8 text-xs"
           />
+          <Input
+            value={p.default}
+            onChange={e => update(idx, { default: e.target.value })}
+            placeholder={p.type === 'boolean' ? 'true / false' : 'none'}
+            className="h-8 text-xs font-mono"
+          />
           <div className="flex justify-center w-14">
             <Switch checked={p.required} onCheckedChange={v => update(idx, { required: v })} />
           </div>
@@ -75,6 +75,8 @@ export function AssistantBubble({ msg, onStepClick, canRegenerate, onRegenerate,
             presetName={msg.presetName}
             modelName={msg.modelName}
             modelRole={msg.modelRole}
+            routeTier={msg.routeTier}
+            routeReason={msg.routeReason}
             showMsgDuration={showMsgDuration}
             msgElapsed={msgElapsed}
             showTokens={showTokens}
@@ -150,13 +152,15 @@ interface HeaderProps {
   presetName?: string
   modelName?: string
   modelRole?: string
+  routeTier?: string
+  routeReason?: string
   showMsgDuration: boolean
   msgElapsed: number
   showTokens: boolean
   estimatedTokens: number
 }
 
-function Header({ presetName, modelName, modelRole, showMsgDuration, msgElapsed, showTokens, estimatedTokens }: HeaderProps) {
+function Header({ presetName, modelName, modelRole, routeTier, routeReason, showMsgDuration, msgElapsed, showTokens, estimatedTokens }: HeaderProps) {
   return (
     <div className="flex items-center gap-x-2 gap-y-1 flex-wrap font-mono text-[10px] lowercase tracking-tight text-zinc-500 dark:text-zinc-500">
       {presetName && <span className="text-zinc-600 dark:text-zinc-400">{presetName.toLowerCase()}</span>}
@@ -169,6 +173,11 @@ function Header({ presetName, modelName, modelRole, showMsgDuration, msgElapsed,
       {modelRole === 'fallback' && (
         <span className="px-1 py-px rounded-sm bg-amber-500/15 text-amber-600 dark:text-amber-400">fallback</span>
       )}
+      {routeTier && (
+        <span className="px-1 py-px rounded-sm bg-teal-500/15 text-teal-600 dark:text-teal-400" title={routeReason}>
+          {routeTier}
+        </span>
+      )}
       {showMsgDuration && (
         <>
           <span className="text-zinc-300 dark:text-zinc-700">·</span>
@@ -0,0 +1,252 @@
+import { useEffect, useState } from 'react'
+import { ArrowLeft, CalendarOff, Plus, Trash2 } from '@/lib/icons'
+import { toast } from 'sonner'
+import { api } from '../../api'
+import type { Plan, PlanBlackout, PlanBlackoutAction, PlanBlackoutInput } from '../../types'
+import { describeCron } from '../../lib/cron'
+import { Button } from '@/components/ui/button'
+import { Badge } from '@/components/ui/badge'
+import { Input } from '@/components/ui/input'
+import { Switch } from '@/components/ui/switch'
+import { Dialog, DialogContent, DialogHeader, DialogTitle, DialogDescription, DialogFooter } from '@/components/ui/dialog'
+import { FormField } from '@/components/FormField'
+
+const selectClass = 'h-8 w-full rounded-md border border-zinc-300 dark:border-zinc-700 bg-white dark:bg-zinc-800 px-2 text-xs text-zinc-900 dark:text-zinc-100 focus:outline-none focus:border-teal-500/50'
+
+type Kind = 'once' | 'recurring'
+
+interface BlackoutForm {
+  name: string
+  planId: string
+  action: PlanBlackoutAction
+  kind: Kind
+  startsAt: string
+  endsAt: string
+  schedule: string
+  duration: string
+  timezone: string
+}
+
+const emptyForm: BlackoutForm = {
+  name: '', planId: '', action: 'skip', kind: 'once',
+  startsAt: '', endsAt: '', schedule: '0 16 * * 5', duration: '8h', timezone: '',
+}
+
+// toLocalInput formats an ISO time for a datetime-local input.
+function toLocalInput(iso?: string) {
+  if (!iso) return ''
+  const d = new Date(iso)
+  return new Date(d.getTime() - d.getTimezoneOffset() * 60000).toISOString().slice(0, 16)
+}
+
+function describeWindow(b: PlanBlackout) {
+  if (b.schedule) {
+    return `${describeCron(b.schedule)} for ${b.duration}${b.timezone ? ` (${b.timezone})` : ''}`
+  }
+  return `${b.startsAt ? new Date(b.startsAt).toLocaleString() : '?'} – ${b.endsAt ? new Date(b.endsAt).toLocaleString() : '?'}`
+}
+
+function toInput(f: BlackoutForm, enabled: boolean): PlanBlackoutInput {
+  const base = { name: f.name.trim(), planId: f.planId || undefined, action: f.action, enabled }
+  if (f.kind === 'recurring') {
+    return { ...base, schedule: f.schedule.trim(), duration: f.duration.trim(), timezone: f.timezone.trim() || undefined }
+  }
+  return {
+    ...base,
+    startsAt: f.startsAt ? new Date(f.startsAt).toISOString() : undefined,
+    endsAt: f.endsAt ? new Date(f.endsAt).toISOString() : undefined,
+  }
+}
+
+export function PlanBlackoutsDialog({ open, onOpenChange, plans, onChanged }: {
+  open: boolean
+  onOpenChange: (open: boolean) => void
+  plans: Plan[]
+  onChanged?: () => void
+}) {
+  const [items, setItems] = useState<PlanBlackout[]>([])
+  const [editing, setEditing] = useState<PlanBlackout | 'new' | null>(null)
+  const [form, setForm] = useState<BlackoutForm>(emptyForm)
+  const [busy, setBusy] = useState(false)
+
+  const load = () => api.planBlackouts.list().then(setItems).catch(() => setItems([]))
+
+  useEffect(() => {
+    if (!open) return
+    setEditing(null)
+    load()
+  }, [open])
+
+  const planName = (id?: string) => id ? (plans.find(p => p.id === id)?.name ?? id.slice(0, 8)) : 'All plans'
+
+  const edit = (b: PlanBlackout | 'new') => {
+    setEditing(b)
+    setForm(b === 'new' ? emptyForm : {
+      name: b.name,
+      planId: b.planId ?? '',
+      action: b.action,
+      kind: b.schedule ? 'recurring' : 'once',
+      startsAt: toLocalInput(b.startsAt),
+      endsAt: toLocalInput(b.endsAt),
+      schedule: b.schedule ?? '',
+      duration: b.duration ?? '',
+      timezone: b.timezone ?? '',
+    })
+  }
+
+  const changed = () => {
+    load()
+    onChanged?.()
+  }
+
+  const save = async () => {
+    setBusy(true)
+    try {
+      if (editing === 'new') {
+        await api.planBlackouts.create(toInput(form, true))
+      } else if (editing) {
+        await api.planBlackouts.update(editing.id, toInput(form, editing.enabled))
+      }
+      toast.success('Blackout saved')
+      setEditing(null)
+      changed()
+    } catch (e: unknown) {
+      toast.error(e instanceof Error ? e.message : 'Save failed')
+    } finally {
+      setBusy(false)
+    }
+  }
+
+  const toggle = async (b: PlanBlackout, enabled: boolean) => {
+    try {
+      await api.planBlackouts.update(b.id, {
+        name: b.name, planId: b.planId, action: b.action, enabled,
+        startsAt: b.startsAt, endsAt: b.endsAt, schedule: b.schedule, duration: b.duration, timezone: b.timezone,
+      })
+      changed()
+    } catch (e: unknown) {
+      toast.error(e instanceof Error ? e.message : 'Update failed')
+    }
+  }
+
+  const remove = async (b: PlanBlackout) => {
+    try {
+      await api.planBlackouts.delete(b.id)
+      toast.success('Blackout deleted')
+      changed()
+    } catch (e: unknown) {
+      toast.error(e instanceof Error ? e.message : 'Delete failed')
+    }
+  }
+
+  const incomplete = !form.name.trim() || (form.kind === 'once'
+    ? !form.startsAt || !form.endsAt
+    : !form.schedule.trim() || !form.duration.trim())
+
+  return (
+    <Dialog open={open} onOpenChange={onOpenChange}>
+      <DialogContent className="max-w-2xl">
+        <DialogHeader>
+          <DialogTitle>{editing === 'new' ? 'New blackout' : editing ? editing.name : 'Blackout windows'}</DialogTitle>
+          <DialogDescription>
+            Within a window, scheduled and webhook runs are skipped or deferred until it ends, and manual runs must be forced.
+          </DialogDescription>
+        </DialogHeader>
+        {!editing ? (
+          <div className="space-y-2 max-h-[60vh] overflow-auto">
+            {items.map(b => (
+              <div
+                key={b.id}
+                className="rounded-lg border border-zinc-200 dark:border-zinc-800 px-3 py-2.5 cursor-pointer hover:border-zinc-300 dark:hover:border-zinc-700 transition-colors"
+                onClick={() => edit(b)}
+              >
+                <div className="flex items-center gap-2">
+                  <CalendarOff size={14} className="text-amber-500 shrink-0" />
+                  <span className="text-sm font-medium text-zinc-800 dark:text-zinc-200">{b.name}</span>
+                  <Badge variant="outline">{planName(b.planId)}</Badge>
+                  <Badge variant="secondary">{b.action === 'defer' ? 'Defer' : 'Skip'}</Badge>
+                  {b.activeUntil && (
+                    <Badge variant="warning" title={`Until ${new Date(b.activeUntil).toLocaleString()}`}>Active</Badge>
+                  )}
+                  <div className="ml-auto flex items-center gap-1" onClick={e => e.stopPropagation()}>
+                    <Switch checked={b.enabled} onCheckedChange={v => toggle(b, v)} />
+                    <Button variant="ghost" size="icon" className="h-7 w-7 text-zinc-400 hover:text-red-500" onClick={() => remove(b)} title="Delete blackout">
+                      <Trash2 size={12} />
+                    </Button>
+                  </div>
+                </div>
+                <p className="text-xs text-zinc-500 mt-1 ml-6">{describeWindow(b)}</p>
+              </div>
+            ))}
+            {items.length === 0 && <p className="text-center py-6 text-xs text-zinc-500">No blackout windows</p>}
+          </div>
+        ) : (
+          <div className="space-y-3">
+            <FormField label="Name">
+              <Input value={form.name} onChange={e => setForm(f => ({ ...f, name: e.target.value }))} placeholder="Release freeze" />
+            </FormField>
+            <div className="grid grid-cols-2 gap-3">
+              <FormField label="Applies to">
+                <select className={selectClass} value={form.planId} onChange={e => setForm(f => ({ ...f, planId: e.target.value }))}>
+                  <option value="">All plans</option>
+                  {plans.map(p => <option key={p.id} value={p.id}>{p.name}</option>)}
+                </select>
+              </FormField>
+              <FormField label="Scheduled and webhook runs">
+                <select className={selectClass} value={form.action} onChange={e => setForm(f => ({ ...f, action: e.target.value as PlanBlackoutAction }))}>
+                  <option value="skip">Skip</option>
+                  <option value="defer">Defer until the window ends</option>
+                </select>
+              </FormField>
+            </div>
+            <FormField label="Window">
+              <select className={selectClass} value={form.kind} onChange={e => setForm(f => ({ ...f, kind: e.target.value as Kind }))}>
+                <option value="once">One-off dates</option>
+                <option value="recurring">Recurring</option>
+              </select>
+            </FormField>
+            {form.kind === 'once' ? (
+              <div className="grid grid-cols-2 gap-3">
+                <FormField label="Starts">
+                  <Input type="datetime-local" value={form.startsAt} onChange={e => setForm(f => ({ ...f, startsAt: e.target.value }))} />
+                </FormField>
+                <FormField label="Ends">
+                  <Input type="datetime-local" value={form.endsAt} onChange={e => setForm(f => ({ ...f, endsAt: e.target.value }))} />
+                </FormField>
+              </div>
+            ) : (
+              <div className="grid grid-cols-3 gap-3">
+                <FormField label="Opens at (cron)" hint={form.schedule.trim() ? describeCron(form.schedule) : undefined}>
+                  <Input value={form.schedule} onChange={e => setForm(f => ({ ...f, schedule: e.target.value }))} className="font-mono" placeholder="0 16 * * 5" />
+                </FormField>
+                <FormField label="Open for" hint="e.g. 8h or 64h">
+                  <Input value={form.duration} onChange={e => setForm(f => ({ ...f, duration: e.target.value }))} className="font-mono" placeholder="8h" />
+                </FormField>
+                <FormField label="Timezone" hint="Server time when empty">
+                  <Input value={form.timezone} onChange={e => setForm(f => ({ ...f, timezone: e.target.value }))} placeholder="Europe/Berlin" />
+                </FormField>
+              </div>
+            )}
+          </div>
+        )}
+        <DialogFooter>
+          {editing ? (
+            <>
+              <Button variant="secondary" onClick={() => setEditing(null)}>
+                <ArrowLeft size={12} /> Back
+              </Button>
+              <Button onClick={save} disabled={busy || incomplete}>Save</Button>
+            </>
+          ) : (
+            <>
+              <Button variant="secondary" onClick={() => onOpenChange(false)}>Close</Button>
+              <Button onClick={() => edit('new')}>
+                <Plus size={12} /> New Blackout
+              </Button>
+            </>
+          )}
+        </DialogFooter>
+      </DialogContent>
+    </Dialog>
+  )
+}
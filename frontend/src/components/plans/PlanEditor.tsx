@@ -1,4 +1,4 @@
-import { useState, useCallback, useMemo, useRef } from 'react'
+import { useState, useCallback, useEffect, useMemo, useRef } from 'react'
 import {
   ReactFlow,
   Background,
@@ -13,13 +13,14 @@ import {
   type Edge,
 } from '@xyflow/react'
 import '@xyflow/react/dist/style.css'
-import { ArrowLeft, Pencil, Zap, GitFork, Trash2, Save, Pause, Play } from '@/lib/icons'
+import { ArrowLeft, Pencil, Zap, GitFork, ShieldCheck, Repeat, Trash2, Save, Pause, Play } from '@/lib/icons'
 import { toast } from 'sonner'
 import { api } from '../../api'
-import type { Plan, PlanGraph, PlanStepRun, PlanStepStatus } from '../../types'
+import type { Channel, Model, Plan, PlanCatchUp, PlanGraph, PlanNotifyWhen, PlanNodeType, PlanRetryOn, PlanStepRun, PlanStepStatus, Preset } from '../../types'
 import { describeCron } from '../../lib/cron'
-import { planNodeTypes, toFlowNodes, toFlowEdges, fromFlowNodes, fromFlowEdges } from './PlanFlowNodes'
+import { planNodeTypes, toFlowNodes, toFlowEdges, fromFlowNodes, fromFlowEdges, edgeColor } from './PlanFlowNodes'
 import PlanRuns from './PlanRuns'
+import SchedulePreview from './SchedulePreview'
 import { Button } from '@/components/ui/button'
 import { Input } from '@/components/ui/input'
 import { Textarea } from '@/components/ui/textarea'
@@ -31,6 +32,80 @@ import { ParamButtons, insertAtCursor } from '@/components/ParamButtons'
 
 let nodeIdCounter = 0
 
+const timezones: string[] = typeof Intl.supportedValuesOf === 'function' ? Intl.supportedValuesOf('timeZone') : []
+
+const retryOnOptions: { value: PlanRetryOn; label: string }[] = [
+  { value: 'timeout', label: 'Timeout' },
+  { value: 'error', label: 'Model error' },
+  { value: 'stopped', label: 'Stopped by limits' },
+  { value: 'reported', label: 'Reported [ERROR]' },
+]
+
+interface NodeForm {
+  label: string
+  prompt: string
+  clearContext: boolean
+  maxRetries: number
+  timeout: string
+  retryDelay: string
+  retryOn: PlanRetryOn[]
+  onTimeout: 'approve' | 'reject'
+  items: string
+  parallelism: number
+  model: string
+}
+
+const emptyNodeForm: NodeForm = { label: '', prompt: '', clearContext: false, maxRetries: 0, timeout: '', retryDelay: '', retryOn: [], onTimeout: 'reject', items: '', parallelism: 0, model: '' }
+
+// Model choices are encoded as "preset:<id>" or "model:<id>" so one select
+// can offer both; an empty value falls back to the plan default.
+function modelChoice(presetId?: string, modelId?: string): string {
+  if (modelId) return `model:${modelId}`
+  if (presetId) return `preset:${presetId}`
+  return ''
+}
+
+function parseModelChoice(choice: string): { presetId?: string; modelId?: string } {
+  if (choice.startsWith('model:')) return { modelId: choice.slice(6) }
+  if (choice.startsWith('preset:')) return { presetId: choice.slice(7) }
+  return {}
+}
+
+function ModelSelect({ value, onChange, presets, models, emptyLabel }: {
+  value: string
+  onChange: (v: string) => void
+  presets: Preset[]
+  models: Model[]
+  emptyLabel: string
+}) {
+  return (
+    <select
+      value={value}
+      onChange={e => onChange(e.target.value)}
+      className="h-8 w-full rounded-md border border-zinc-300 dark:border-zinc-700 bg-white dark:bg-zinc-800 px-2 text-xs text-zinc-900 dark:text-zinc-100 focus:outline-none focus:border-teal-500/50"
+    >
+      <option value="">{emptyLabel}</option>
+      {presets.length > 0 && (
+        <optgroup label="Presets">
+          {presets.map(p => <option key={p.id} value={`preset:${p.id}`}>{p.name}</option>)}
+        </optgroup>
+      )}
+      {models.length > 0 && (
+        <optgroup label="Models">
+          {models.map(m => <option key={m.id} value={`model:${m.id}`}>{m.name}</option>)}
+        </optgroup>
+      )}
+    </select>
+  )
+}
+
+const newNodeLabel: Record<PlanNodeType, string> = {
+  action: 'New Action',
+  decision: 'New Decision',
+  approval: 'Approval',
+  foreach: 'For Each',
+}
+
 interface Props {
   plan: Plan
   onBack: () => void
@@ -44,14 +119,37 @@ export default function PlanEditor({ plan: initialPlan, onBack, onSaved }: Props
     name: initialPlan.name,
     description: initialPlan.description,
     schedule: initialPlan.schedule,
+    timezone: initialPlan.timezone ?? '',
+    jitter: initialPlan.jitter ?? '',
+    catchUp: initialPlan.catchUp ?? 'skip',
+    notifyWhen: (initialPlan.notify?.when ?? '') as PlanNotifyWhen | '',
+    notifyChannelId: initialPlan.notify?.channelId ?? '',
+    notifyRecipient: initialPlan.notify?.recipient ?? '',
+    slaMaxDuration: initialPlan.sla?.maxDuration ?? '',
+    slaMaxFailures: initialPlan.sla?.maxConsecutiveFailures ? String(initialPlan.sla.maxConsecutiveFailures) : '',
+    model: modelChoice(initialPlan.presetId, initialPlan.modelId),
     enabled: initialPlan.enabled,
     parameters: initialPlan.parameters ?? { type: 'object', properties: {} },
   })
 
+  const [channels, setChannels] = useState<Channel[]>([])
+  const [presets, setPresets] = useState<Preset[]>([])
+  const [models, setModels] = useState<Model[]>([])
+
+  useEffect(() => {
+    api.presets.list().then(setPresets).catch(() => setPresets([]))
+    api.models.list().then(setModels).catch(() => setModels([]))
+  }, [])
+
+  useEffect(() => {
+    if (!metaOpen) return
+    api.channels.list().then(setChannels).catch(() => setChannels([]))
+  }, [metaOpen])
+
   const [nodes, setNodes, onNodesChange] = useNodesState<Node>(toFlowNodes(initialPlan.graph.nodes))
   const [edges, setEdges, onEdgesChange] = useEdgesState<Edge>(toFlowEdges(initialPlan.graph.edges))
   const [selectedNode, setSelectedNode] = useState<Node | null>(null)
-  const [nodeForm, setNodeForm] = useState({ label: '', prompt: '', clearContext: false, maxRetries: 0 })
+  const [nodeForm, setNodeForm] = useState<NodeForm>(emptyNodeForm)
   const [selectedEdge, setSelectedEdge] = useState<Edge | null>(null)
   const [edgeLabel, setEdgeLabel] = useState('')
   const [activeRunSteps, setActiveRunSteps] = useState<PlanStepRun[] | null>(null)
@@ -77,31 +175,49 @@ export default function PlanEditor({ plan: initialPlan, onBack, onSaved }: Props
     }))
   }, [nodes, activeRunSteps])
 
-  const addNode = (type: 'action' | 'decision') => {
+  const addNode = (type: PlanNodeType) => {
     nodeIdCounter++
     const id = `n${nodeIdCounter}`
     const newNode: Node = {
       id,
       type,
       position: { x: 250 + Math.random() * 100 - 50, y: 100 + nodes.length * 120 },
-      data: { label: type === 'action' ? 'New Action' : 'New Decision', prompt: '' },
+      data: { label: newNodeLabel[type], prompt: '' },
     }
     setNodes(nds => [...nds, newNode])
   }
 
   const onConnect = useCallback((params: Connection) => {
     const sourceNode = nodes.find(n => n.id === params.source)
-    const existingOut = edges.filter(e => e.source === params.source)
-    if (sourceNode?.type === 'action' && existingOut.length >= 1) {
+    const isError = params.sourceHandle === 'error'
+    const existingOut = edges.filter(e => e.source === params.source && (e.sourceHandle === 'error') === isError)
+    if (isError && existingOut.length >= 1) {
+      toast.error('A node can only have one error connection')
+      return
+    }
+    if (isError && params.target === params.source) {
+      toast.error('An error connection cannot point back to the same node')
+      return
+    }
+    if (!isError && sourceNode?.type === 'action' && existingOut.length >= 1) {
       toast.error('Action nodes can only have one outgoing connection')
       return
     }
-    if (sourceNode?.type === 'decision' && existingOut.length >= 2) {
+    if (!isError && sourceNode?.type === 'decision' && existingOut.length >= 2) {
       toast.error('Decision nodes can have at most two connections (yes/no)')
       return
     }
-    const label = sourceNode?.type === 'decision' ? (params.sourceHandle || '') : ''
-    const color = label === 'no' ? '#f87171' : label === 'yes' ? '#34d399' : '#71717a'
+    if (sourceNode?.type === 'approval' && existingOut.some(e => e.sourceHandle === params.sourceHandle)) {
+      toast.error(`Approval nodes can have one "${params.sourceHandle}" connection`)
+      return
+    }
+    if (sourceNode?.type === 'foreach' && existingOut.some(e => (e.sourceHandle || null) === (params.sourceHandle || null))) {
+      toast.error(params.sourceHandle === 'each' ? 'A loop has one body connection' : 'A loop has one connection to the step after it')
+      return
+    }
+    const branched = sourceNode?.type === 'decision' || sourceNode?.type === 'approval' || params.sourceHandle === 'each'
+    const label = branched || isError ? (params.sourceHandle || '') : ''
+    const color = edgeColor(label)
     setEdges(eds => addEdge({
       ...params,
       id: `e${params.source}-${params.target}-${label}`,
@@ -119,6 +235,13 @@ export default function PlanEditor({ plan: initialPlan, onBack, onSaved }: Props
       prompt: (node.data.prompt as string) || '',
       clearContext: (node.data.clearContext as boolean) || false,
       maxRetries: (node.data.maxRetries as number) || 0,
+      timeout: (node.data.timeout as string) || '',
+      retryDelay: (node.data.retryDelay as string) || '',
+      retryOn: (node.data.retryOn as PlanRetryOn[]) || [],
+      onTimeout: (node.data.onTimeout as 'approve' | 'reject') || 'reject',
+      items: (node.data.items as string) || '',
+      parallelism: (node.data.parallelism as number) || 0,
+      model: modelChoice(node.data.presetId as string | undefined, node.data.modelId as string | undefined),
     })
     setSelectedEdge(null)
   }, [])
@@ -137,7 +260,25 @@ export default function PlanEditor({ plan: initialPlan, onBack, onSaved }: Props
   const updateSelectedNode = () => {
     if (!selectedNode) return
     setNodes(nds => nds.map(n =>
-      n.id === selectedNode.id ? { ...n, data: { ...n.data, label: nodeForm.label, prompt: nodeForm.prompt, clearContext: nodeForm.clearContext, maxRetries: nodeForm.maxRetries } } : n
+      n.id === selectedNode.id ? {
+        ...n,
+        data: {
+          ...n.data,
+          label: nodeForm.label,
+          prompt: nodeForm.prompt,
+          clearContext: nodeForm.clearContext,
+          maxRetries: nodeForm.maxRetries,
+          timeout: nodeForm.timeout.trim(),
+          retryDelay: nodeForm.retryDelay.trim(),
+          retryOn: nodeForm.retryOn,
+          onTimeout: selectedNode.type === 'approval' && nodeForm.onTimeout === 'approve' ? 'approve' : undefined,
+          items: selectedNode.type === 'foreach' ? nodeForm.items.trim() : undefined,
+          parallelism: selectedNode.type === 'foreach' ? nodeForm.parallelism : undefined,
+          presetId: undefined,
+          modelId: undefined,
+          ...(selectedNode.type === 'action' || selectedNode.type === 'decision' ? parseModelChoice(nodeForm.model) : {}),
+        },
+      } : n
     ))
     setSelectedNode(null)
   }
@@ -151,7 +292,7 @@ export default function PlanEditor({ plan: initialPlan, onBack, onSaved }: Props
 
   const updateSelectedEdge = () => {
     if (!selectedEdge) return
-    const color = edgeLabel === 'no' ? '#f87171' : edgeLabel === 'yes' ? '#34d399' : '#71717a'
+    const color = edgeColor(edgeLabel)
     setEdges(eds => eds.map(e =>
       e.id === selectedEdge.id ? {
         ...e,
@@ -175,7 +316,19 @@ export default function PlanEditor({ plan: initialPlan, onBack, onSaved }: Props
       toast.error('Name is required')
       return
     }
-    setPlan(p => ({ ...p, ...metaForm }))
+    const { notifyWhen, notifyChannelId, notifyRecipient, slaMaxDuration, slaMaxFailures, model, ...meta } = metaForm
+    const sla = { maxDuration: slaMaxDuration.trim(), maxConsecutiveFailures: Number(slaMaxFailures) || 0 }
+    const { presetId = '', modelId = '' } = parseModelChoice(model)
+    setPlan(p => ({
+      ...p,
+      ...meta,
+      presetId,
+      modelId,
+      notify: notifyWhen
+        ? { when: notifyWhen, channelId: notifyChannelId, recipient: notifyRecipient.trim() }
+        : (p.notify ? { when: '' } : undefined),
+      sla: sla.maxDuration || sla.maxConsecutiveFailures ? sla : (p.sla ? {} : undefined),
+    }))
     setMetaOpen(false)
   }
 
@@ -186,7 +339,7 @@ export default function PlanEditor({ plan: initialPlan, onBack, onSaved }: Props
 
   const savePlan = async () => {
     const graph: PlanGraph = { nodes: fromFlowNodes(nodes), edges: fromFlowEdges(edges) }
-    const payload = { name: plan.name, description: plan.description, schedule: plan.schedule, enabled: plan.enabled, parameters: plan.parameters ?? {}, graph }
+    const payload = { name: plan.name, description: plan.description, schedule: plan.schedule, timezone: plan.timezone ?? '', jitter: plan.jitter ?? '', catchUp: plan.catchUp, notify: plan.notify, sla: plan.sla, presetId: plan.presetId ?? '', modelId: plan.modelId ?? '', enabled: plan.enabled, parameters: plan.parameters ?? {}, graph }
     try {
       if (plan.id) {
         await api.plans.update(plan.id, payload)
@@ -216,6 +369,11 @@ export default function PlanEditor({ plan: initialPlan, onBack, onSaved }: Props
       name: plan.name,
       description: plan.description,
       schedule: plan.schedule,
+      timezone: plan.timezone ?? '',
+      jitter: plan.jitter ?? '',
+      catchUp: plan.catchUp,
+      presetId: plan.presetId ?? '',
+      modelId: plan.modelId ?? '',
       enabled: nextEnabled,
       parameters: plan.parameters ?? {},
       graph,
@@ -264,7 +422,7 @@ export default function PlanEditor({ plan: initialPlan, onBack, onSaved }: Props
                     className="px-1.5 py-0.5 text-[10px] rounded border border-zinc-300 dark:border-zinc-700 font-mono text-zinc-500 dark:text-zinc-500"
                     title="Cron expression"
                   >
-                    {plan.schedule}
+                    {plan.schedule}{plan.timezone ? ` · ${plan.timezone}` : ''}
                   </span>
                 )}
               </div>
@@ -309,6 +467,12 @@ export default function PlanEditor({ plan: initialPlan, onBack, onSaved }: Props
             <Button variant="secondary" size="sm" onClick={() => addNode('decision')}>
               <GitFork size={12} /> Decision
             </Button>
+            <Button variant="secondary" size="sm" onClick={() => addNode('approval')}>
+              <ShieldCheck size={12} /> Approval
+            </Button>
+            <Button variant="secondary" size="sm" onClick={() => addNode('foreach')}>
+              <Repeat size={12} /> For Each
+            </Button>
             <Button size="sm" onClick={savePlan} disabled={!plan.name}>
               <Save size={14} /> Save
             </Button>
@@ -335,7 +499,7 @@ export default function PlanEditor({ plan: initialPlan, onBack, onSaved }: Props
             <Background gap={20} size={1} />
             <Controls className="!bg-white dark:!bg-zinc-900 !border-zinc-200 dark:!border-zinc-800 !rounded-lg !shadow-sm [&>button]:!bg-white [&>button]:dark:!bg-zinc-900 [&>button]:!border-zinc-200 [&>button]:dark:!border-zinc-800 [&>button]:!text-zinc-600 [&>button]:dark:!text-zinc-400" />
             <MiniMap
-              nodeColor={n => n.type === 'decision' ? '#f59e0b' : '#14b8a6'}
+              nodeColor={n => n.type === 'decision' ? '#f59e0b' : n.type === 'approval' ? '#8b5cf6' : n.type === 'foreach' ? '#0ea5e9' : '#14b8a6'}
               className="!bg-white dark:!bg-zinc-900 !border-zinc-200 dark:!border-zinc-800 !rounded-lg !shadow-sm"
             />
           </ReactFlow>
@@ -349,6 +513,8 @@ export default function PlanEditor({ plan: initialPlan, onBack, onSaved }: Props
             onApply={updateSelectedNode}
             onDelete={deleteSelectedNode}
             planParameters={plan.parameters as Record<string, unknown> ?? {}}
+            presets={presets}
+            models={models}
           />
         )}
 
@@ -389,6 +555,94 @@ export default function PlanEditor({ plan: initialPlan, onBack, onSaved }: Props
             <FormField label="Schedule (cron)" hint="Leave empty for manual trigger only">
               <Input value={metaForm.schedule} onChange={e => setMetaForm(f => ({ ...f, schedule: e.target.value }))} className="font-mono" placeholder="0 3 * * *" />
             </FormField>
+            {metaForm.schedule.trim() && (
+              <>
+                <div className="grid grid-cols-2 gap-3">
+                  <FormField label="Timezone" hint="Empty uses server time">
+                    <Input
+                      value={metaForm.timezone}
+                      onChange={e => setMetaForm(f => ({ ...f, timezone: e.target.value }))}
+                      list="plan-timezones"
+                      placeholder={Intl.DateTimeFormat().resolvedOptions().timeZone}
+                    />
+                    <datalist id="plan-timezones">
+                      {timezones.map(tz => <option key={tz} value={tz} />)}
+                    </datalist>
+                  </FormField>
+                  <FormField label="Start jitter" hint="Random delay up to, e.g. 2m">
+                    <Input value={metaForm.jitter} onChange={e => setMetaForm(f => ({ ...f, jitter: e.target.value }))} className="font-mono" placeholder="none" />
+                  </FormField>
+                </div>
+                <FormField label="Missed runs" hint="What to do at startup with runs missed while Mantis was down">
+                  <select
+                    value={metaForm.catchUp}
+                    onChange={e => setMetaForm(f => ({ ...f, catchUp: e.target.value as PlanCatchUp }))}
+                    className="h-8 w-full rounded-md border border-zinc-300 dark:border-zinc-700 bg-white dark:bg-zinc-800 px-2 text-xs text-zinc-900 dark:text-zinc-100 focus:outline-none focus:border-teal-500/50"
+                  >
+                    <option value="skip">Skip them</option>
+                    <option value="run_once">Run once</option>
+                    <option value="run_all">Run each missed run</option>
+                  </select>
+                </FormField>
+                <SchedulePreview schedule={metaForm.schedule} timezone={metaForm.timezone} />
+              </>
+            )}
+            <FormField label="Run reports" hint="Send a summary of each finished run with step results and a link to the session">
+              <div className="grid grid-cols-3 gap-2">
+                <select
+                  value={metaForm.notifyWhen}
+                  onChange={e => setMetaForm(f => ({ ...f, notifyWhen: e.target.value as PlanNotifyWhen | '' }))}
+                  className="h-8 rounded-md border border-zinc-300 dark:border-zinc-700 bg-white dark:bg-zinc-800 px-2 text-xs text-zinc-900 dark:text-zinc-100 focus:outline-none focus:border-teal-500/50"
+                >
+                  <option value="">Off</option>
+                  <option value="failure">On failure</option>
+                  <option value="success">On success</option>
+                  <option value="always">Always</option>
+                </select>
+                <select
+                  value={metaForm.notifyChannelId}
+                  onChange={e => setMetaForm(f => ({ ...f, notifyChannelId: e.target.value }))}
+                  disabled={!metaForm.notifyWhen}
+                  className="h-8 rounded-md border border-zinc-300 dark:border-zinc-700 bg-white dark:bg-zinc-800 px-2 text-xs text-zinc-900 dark:text-zinc-100 focus:outline-none focus:border-teal-500/50 disabled:opacity-50"
+                >
+                  <option value="">First Telegram bot</option>
+                  {channels.map(ch => <option key={ch.id} value={ch.id}>{ch.name || ch.type}</option>)}
+                </select>
+                <Input
+                  value={metaForm.notifyRecipient}
+                  onChange={e => setMetaForm(f => ({ ...f, notifyRecipient: e.target.value }))}
+                  disabled={!metaForm.notifyWhen}
+                  className="font-mono"
+                  placeholder="Chat id (default)"
+                />
+              </div>
+            </FormField>
+            <FormField label="SLA" hint="Alert through the run report channel when a run takes longer or fails this many times in a row">
+              <div className="grid grid-cols-2 gap-2">
+                <Input
+                  value={metaForm.slaMaxDuration}
+                  onChange={e => setMetaForm(f => ({ ...f, slaMaxDuration: e.target.value }))}
+                  className="font-mono"
+                  placeholder="Max duration, e.g. 15m"
+                />
+                <Input
+                  type="number"
+                  min={0}
+                  value={metaForm.slaMaxFailures}
+                  onChange={e => setMetaForm(f => ({ ...f, slaMaxFailures: e.target.value }))}
+                  placeholder="Max failures in a row"
+                />
+              </div>
+            </FormField>
+            <FormField label="Default model" hint="Used by steps that don't pick their own preset or model">
+              <ModelSelect
+                value={metaForm.model}
+                onChange={v => setMetaForm(f => ({ ...f, model: v }))}
+                presets={presets}
+                models={models}
+                emptyLabel="Chat preset"
+              />
+            </FormField>
             <div className="flex items-center gap-2">
               <Switch checked={metaForm.enabled} onCheckedChange={v => setMetaForm(f => ({ ...f, enabled: v }))} />
               <span className="text-xs text-zinc-600 dark:text-zinc-400">{metaForm.enabled ? 'Enabled' : 'Disabled'}</span>
@@ -412,13 +666,15 @@ export default function PlanEditor({ plan: initialPlan, onBack, onSaved }: Props
   )
 }
 
-function NodePropertiesPanel({ node, form, onFormChange, onApply, onDelete, planParameters }: {
+function NodePropertiesPanel({ node, form, onFormChange, onApply, onDelete, planParameters, presets, models }: {
   node: Node
-  form: { label: string; prompt: string; clearContext: boolean; maxRetries: number }
-  onFormChange: (f: { label: string; prompt: string; clearContext: boolean; maxRetries: number }) => void
+  form: NodeForm
+  onFormChange: (f: NodeForm) => void
   onApply: () => void
   onDelete: () => void
   planParameters: Record<string, unknown>
+  presets: Preset[]
+  models: Model[]
 }) {
   const promptRef = useRef<HTMLTextAreaElement>(null)
 
@@ -428,11 +684,15 @@ function NodePropertiesPanel({ node, form, onFormChange, onApply, onDelete, plan
         <div className="flex items-center gap-2">
           {node.type === 'decision' ? (
             <GitFork size={14} className="text-amber-500" />
+          ) : node.type === 'approval' ? (
+            <ShieldCheck size={14} className="text-violet-500" />
+          ) : node.type === 'foreach' ? (
+            <Repeat size={14} className="text-sky-500" />
           ) : (
             <Zap size={14} className="text-teal-500" />
           )}
           <span className="text-xs font-semibold uppercase tracking-wider text-zinc-500">
-            {node.type === 'decision' ? 'Decision' : 'Action'}
+            {node.type === 'decision' ? 'Decision' : node.type === 'approval' ? 'Approval' : node.type === 'foreach' ? 'For Each' : 'Action'}
           </span>
         </div>
         <Button variant="destructive" size="icon" className="h-7 w-7" onClick={onDelete}>
@@ -443,38 +703,123 @@ function NodePropertiesPanel({ node, form, onFormChange, onApply, onDelete, plan
         <FormField label="Label">
           <Input value={form.label} onChange={e => onFormChange({ ...form, label: e.target.value })} placeholder="Step name" />
         </FormField>
-        <FormField label={node.type === 'decision' ? 'Question / Condition' : 'Prompt'}>
-          <Textarea
-            ref={promptRef}
-            value={form.prompt}
-            onChange={e => onFormChange({ ...form, prompt: e.target.value })}
-            className="h-32 text-xs"
-            placeholder={node.type === 'decision' ? 'Was the deployment successful?' : 'Deploy the latest version to production...'}
-          />
-        </FormField>
-        <ParamButtons
-          parameters={planParameters}
-          onInsert={snippet => insertAtCursor(promptRef.current, snippet, form.prompt, v => onFormChange({ ...form, prompt: v }))}
-        />
+        {node.type !== 'foreach' && (
+          <>
+            <FormField label={node.type === 'decision' ? 'Question / Condition' : node.type === 'approval' ? 'Message to approver' : 'Prompt'}>
+              <Textarea
+                ref={promptRef}
+                value={form.prompt}
+                onChange={e => onFormChange({ ...form, prompt: e.target.value })}
+                className="h-32 text-xs"
+                placeholder={node.type === 'decision' ? 'Was the deployment successful?' : node.type === 'approval' ? 'Review the prepared change before it is applied' : 'Deploy the latest version to production...'}
+              />
+            </FormField>
+            <ParamButtons
+              parameters={planParameters}
+              onInsert={snippet => insertAtCursor(promptRef.current, snippet, form.prompt, v => onFormChange({ ...form, prompt: v }))}
+            />
+          </>
+        )}
         {node.type === 'decision' && (
           <p className="text-[11px] text-zinc-500 dark:text-zinc-600">
             Connect the green handle (left) for &quot;yes&quot; and red handle (right) for &quot;no&quot;
           </p>
         )}
-        <div className="flex items-center gap-2">
-          <Switch checked={form.clearContext} onCheckedChange={v => onFormChange({ ...form, clearContext: v })} />
-          <span className="text-xs text-zinc-600 dark:text-zinc-400">Clear context</span>
-        </div>
-        <FormField label="Max retries" hint="0 = no retry on failure">
-          <Input
-            type="number"
-            min={0}
-            max={10}
-            value={form.maxRetries}
-            onChange={e => onFormChange({ ...form, maxRetries: Math.max(0, parseInt(e.target.value) || 0) })}
-            className="w-20"
-          />
-        </FormField>
+        {node.type === 'approval' ? (
+          <>
+            <p className="text-[11px] text-zinc-500 dark:text-zinc-600">
+              The run pauses here until someone approves or rejects it in the UI or Telegram. Connect the green handle for &quot;approve&quot; and red handle for &quot;reject&quot;
+            </p>
+            <FormField label="Wait up to" hint="Defaults to MANTIS_PLAN_APPROVAL_TIMEOUT (24h), e.g. 2h">
+              <Input value={form.timeout} onChange={e => onFormChange({ ...form, timeout: e.target.value })} className="font-mono w-24" placeholder="default" />
+            </FormField>
+            <FormField label="On timeout">
+              <select
+                value={form.onTimeout}
+                onChange={e => onFormChange({ ...form, onTimeout: e.target.value as 'approve' | 'reject' })}
+                className="h-8 rounded-md border border-zinc-300 dark:border-zinc-700 bg-white dark:bg-zinc-800 px-2 text-xs text-zinc-900 dark:text-zinc-100 focus:outline-none focus:border-teal-500/50"
+              >
+                <option value="reject">Reject</option>
+                <option value="approve">Approve</option>
+              </select>
+            </FormField>
+          </>
+        ) : node.type === 'foreach' ? (
+          <>
+            <FormField label="Items" hint="input.<parameter> for a list parameter, or steps.<node id> for a JSON array in that step's answer">
+              <Input value={form.items} onChange={e => onFormChange({ ...form, items: e.target.value })} className="font-mono" placeholder="input.hosts" />
+            </FormField>
+            <FormField label="Parallel items" hint="How many items run at once (max 10); 0 or 1 runs them one by one">
+              <Input
+                type="number"
+                min={0}
+                max={10}
+                value={form.parallelism}
+                onChange={e => onFormChange({ ...form, parallelism: Math.min(10, Math.max(0, parseInt(e.target.value) || 0)) })}
+                className="w-20"
+              />
+            </FormField>
+            <p className="text-[11px] text-zinc-500 dark:text-zinc-600">
+              Connect the blue handle (left) to the first step of the loop body. Body steps run once per item in their own session and can use {'{{.item}}'} and {'{{.index}}'}; the bottom handle continues after all items finish
+            </p>
+          </>
+        ) : (
+          <>
+            <div className="flex items-center gap-2">
+              <Switch checked={form.clearContext} onCheckedChange={v => onFormChange({ ...form, clearContext: v })} />
+              <span className="text-xs text-zinc-600 dark:text-zinc-400">Clear context</span>
+            </div>
+            <FormField label="Model" hint="A cheap model for routine checks, a stronger one for hard steps">
+              <ModelSelect
+                value={form.model}
+                onChange={v => onFormChange({ ...form, model: v })}
+                presets={presets}
+                models={models}
+                emptyLabel="Plan default"
+              />
+            </FormField>
+            <FormField label="Max retries" hint="0 = no retry on failure">
+              <Input
+                type="number"
+                min={0}
+                max={10}
+                value={form.maxRetries}
+                onChange={e => onFormChange({ ...form, maxRetries: Math.max(0, parseInt(e.target.value) || 0) })}
+                className="w-20"
+              />
+            </FormField>
+            {form.maxRetries > 0 && (
+              <>
+                <FormField label="Retry delay" hint="Base delay, doubled on each retry with jitter (default 2s)">
+                  <Input value={form.retryDelay} onChange={e => onFormChange({ ...form, retryDelay: e.target.value })} className="font-mono w-24" placeholder="2s" />
+                </FormField>
+                <FormField label="Retry on" hint="Leave all unchecked to retry any failure">
+                  <div className="space-y-1">
+                    {retryOnOptions.map(o => (
+                      <label key={o.value} className="flex items-center gap-2 text-xs text-zinc-600 dark:text-zinc-400">
+                        <input
+                          type="checkbox"
+                          checked={form.retryOn.includes(o.value)}
+                          onChange={e => onFormChange({
+                            ...form,
+                            retryOn: e.target.checked ? [...form.retryOn, o.value] : form.retryOn.filter(v => v !== o.value),
+                          })}
+                        />
+                        {o.label}
+                      </label>
+                    ))}
+                  </div>
+                </FormField>
+              </>
+            )}
+            <FormField label="Timeout" hint="Overrides the global step timeout, e.g. 90s or 10m">
+              <Input value={form.timeout} onChange={e => onFormChange({ ...form, timeout: e.target.value })} className="font-mono w-24" placeholder="default" />
+            </FormField>
+            <p className="text-[11px] text-zinc-500 dark:text-zinc-600">
+              Connect the orange handle (right) to a node that should run if this step fails
+            </p>
+          </>
+        )}
         <Button size="sm" className="w-full" onClick={onApply}>Apply</Button>
       </div>
     </div>
@@ -496,8 +841,8 @@ function EdgePropertiesPanel({ label, onLabelChange, onApply, onDelete }: {
         </Button>
       </div>
       <div className="space-y-3">
-        <FormField label="Label" hint="Use 'yes' or 'no' for decision branches">
-          <Input value={label} onChange={e => onLabelChange(e.target.value)} placeholder="yes / no" />
+        <FormField label="Label" hint="Use 'yes'/'no' for decision branches, 'approve'/'reject' for approvals, 'each' for a loop body, 'error' for failure handlers">
+          <Input value={label} onChange={e => onLabelChange(e.target.value)} placeholder="yes / no / approve / reject / each / error" />
         </FormField>
         <Button size="sm" className="w-full" onClick={onApply}>Apply</Button>
       </div>
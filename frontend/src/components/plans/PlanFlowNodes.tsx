@@ -1,10 +1,11 @@
 import { Handle, Position, MarkerType, type NodeProps, type Node, type Edge } from '@xyflow/react'
-import { Zap, GitFork } from '@/lib/icons'
-import type { PlanNode, PlanEdge, PlanStepStatus } from '../../types'
+import { Zap, GitFork, ShieldCheck, Repeat } from '@/lib/icons'
+import type { PlanNode, PlanNodeType, PlanEdge, PlanRetryOn, PlanStepStatus } from '../../types'
 
 const statusBorder: Record<PlanStepStatus, string> = {
   pending: 'border-zinc-300 dark:border-zinc-700',
   running: 'border-blue-500 animate-pulse',
+  waiting: 'border-violet-500 animate-pulse',
   completed: 'border-emerald-500',
   failed: 'border-red-500',
   skipped: 'border-zinc-400 dark:border-zinc-600 opacity-50',
@@ -13,6 +14,7 @@ const statusBorder: Record<PlanStepStatus, string> = {
 const statusDot: Record<PlanStepStatus, string> = {
   pending: 'bg-zinc-400',
   running: 'bg-blue-500 animate-pulse',
+  waiting: 'bg-violet-500 animate-pulse',
   completed: 'bg-emerald-500',
   failed: 'bg-red-500',
   skipped: 'bg-zinc-400',
@@ -36,6 +38,7 @@ export function ActionNode({ data, selected }: NodeProps) {
       ) : null}
       <NodeBadges data={data} />
       <Handle type="source" position={Position.Bottom} className="!w-3 !h-3 !bg-teal-500 !border-2 !border-white dark:!border-zinc-900" />
+      <ErrorHandle />
     </div>
   )
 }
@@ -59,27 +62,88 @@ export function DecisionNode({ data, selected }: NodeProps) {
       <NodeBadges data={data} />
       <Handle type="source" position={Position.Bottom} id="yes" style={{ left: '30%' }} className="!w-3 !h-3 !bg-emerald-500 !border-2 !border-white dark:!border-zinc-900" />
       <Handle type="source" position={Position.Bottom} id="no" style={{ left: '70%' }} className="!w-3 !h-3 !bg-red-400 !border-2 !border-white dark:!border-zinc-900" />
+      <ErrorHandle />
     </div>
   )
 }
 
+export function ApprovalNode({ data, selected }: NodeProps) {
+  const status = data.status as PlanStepStatus | undefined
+  const borderClass = status ? statusBorder[status] : (selected ? 'border-violet-500' : 'border-zinc-300 dark:border-zinc-700')
+
+  return (
+    <div className={`px-4 py-3 rounded-lg border-2 bg-white dark:bg-zinc-900 min-w-[180px] max-w-[240px] shadow-sm ${borderClass}`}>
+      <Handle type="target" position={Position.Top} className="!w-3 !h-3 !bg-violet-500 !border-2 !border-white dark:!border-zinc-900" />
+      <div className="flex items-center gap-2 mb-1">
+        <ShieldCheck size={12} className="text-violet-500 shrink-0" />
+        <span className="text-[10px] font-semibold uppercase tracking-wider text-violet-600 dark:text-violet-400">Approval</span>
+        {status && <div className={`w-2 h-2 rounded-full ml-auto ${statusDot[status]}`} />}
+      </div>
+      <p className="text-sm font-medium text-zinc-800 dark:text-zinc-200 truncate">{String(data.label || 'Untitled')}</p>
+      {data.prompt ? (
+        <p className="text-[11px] text-zinc-500 mt-1 line-clamp-2">{String(data.prompt)}</p>
+      ) : null}
+      <NodeBadges data={data} />
+      <Handle type="source" position={Position.Bottom} id="approve" style={{ left: '30%' }} className="!w-3 !h-3 !bg-emerald-500 !border-2 !border-white dark:!border-zinc-900" />
+      <Handle type="source" position={Position.Bottom} id="reject" style={{ left: '70%' }} className="!w-3 !h-3 !bg-red-400 !border-2 !border-white dark:!border-zinc-900" />
+    </div>
+  )
+}
+
+export function ForeachNode({ data, selected }: NodeProps) {
+  const status = data.status as PlanStepStatus | undefined
+  const borderClass = status ? statusBorder[status] : (selected ? 'border-sky-500' : 'border-zinc-300 dark:border-zinc-700')
+  const parallelism = data.parallelism as number | undefined
+
+  return (
+    <div className={`px-4 py-3 rounded-lg border-2 border-dashed bg-white dark:bg-zinc-900 min-w-[180px] max-w-[240px] shadow-sm ${borderClass}`}>
+      <Handle type="target" position={Position.Top} className="!w-3 !h-3 !bg-sky-500 !border-2 !border-white dark:!border-zinc-900" />
+      <div className="flex items-center gap-2 mb-1">
+        <Repeat size={12} className="text-sky-500 shrink-0" />
+        <span className="text-[10px] font-semibold uppercase tracking-wider text-sky-600 dark:text-sky-400">For each</span>
+        {status && <div className={`w-2 h-2 rounded-full ml-auto ${statusDot[status]}`} />}
+      </div>
+      <p className="text-sm font-medium text-zinc-800 dark:text-zinc-200 truncate">{String(data.label || 'Untitled')}</p>
+      <p className="text-[11px] text-zinc-500 mt-1 font-mono truncate">{String(data.items || 'no items set')}</p>
+      {!!parallelism && parallelism > 1 && (
+        <div className="flex gap-1 mt-1.5">
+          <span className="px-1 py-0.5 text-[9px] rounded bg-sky-500/10 text-sky-500 font-medium">×{parallelism} parallel</span>
+        </div>
+      )}
+      <Handle type="source" position={Position.Left} id="each" title="Loop body" className="!w-3 !h-3 !bg-sky-500 !border-2 !border-white dark:!border-zinc-900" />
+      <Handle type="source" position={Position.Bottom} className="!w-3 !h-3 !bg-teal-500 !border-2 !border-white dark:!border-zinc-900" />
+      <ErrorHandle />
+    </div>
+  )
+}
+
+function ErrorHandle() {
+  return (
+    <Handle type="source" position={Position.Right} id="error" title="On error" className="!w-3 !h-3 !bg-orange-500 !border-2 !border-white dark:!border-zinc-900" />
+  )
+}
+
 function NodeBadges({ data }: { data: Record<string, unknown> }) {
   const cc = data.clearContext as boolean | undefined
   const retries = data.maxRetries as number | undefined
-  if (!cc && !retries) return null
+  const timeout = data.timeout as string | undefined
+  if (!cc && !retries && !timeout) return null
   return (
     <div className="flex gap-1 mt-1.5">
       {cc && <span className="px-1 py-0.5 text-[9px] rounded bg-violet-500/10 text-violet-500 font-medium">clean ctx</span>}
       {!!retries && retries > 0 && <span className="px-1 py-0.5 text-[9px] rounded bg-amber-500/10 text-amber-500 font-medium">retry ×{retries}</span>}
+      {timeout && <span className="px-1 py-0.5 text-[9px] rounded bg-sky-500/10 text-sky-500 font-medium">⏱ {timeout}</span>}
     </div>
   )
 }
 
-export const planNodeTypes = { action: ActionNode, decision: DecisionNode }
+export const planNodeTypes = { action: ActionNode, decision: DecisionNode, approval: ApprovalNode, foreach: ForeachNode }
 
-function edgeColor(label: string) {
-  if (label === 'no') return '#f87171'
-  if (label === 'yes') return '#34d399'
+export function edgeColor(label: string) {
+  if (label === 'error') return '#f97316'
+  if (label === 'each') return '#0ea5e9'
+  if (label === 'no' || label === 'reject') return '#f87171'
+  if (label === 'yes' || label === 'approve') return '#34d399'
   return '#71717a'
 }
 
@@ -93,6 +157,14 @@ export function toFlowNodes(planNodes: PlanNode[], stepStatuses?: Map<string, Pl
       prompt: n.prompt,
       clearContext: n.clearContext,
       maxRetries: n.maxRetries,
+      timeout: n.timeout,
+      retryDelay: n.retryDelay,
+      retryOn: n.retryOn,
+      onTimeout: n.onTimeout,
+      items: n.items,
+      parallelism: n.parallelism,
+      presetId: n.presetId,
+      modelId: n.modelId,
       status: stepStatuses?.get(n.id),
     },
     selected: false,
@@ -115,12 +187,20 @@ export function toFlowEdges(planEdges: PlanEdge[]): Edge[] {
 export function fromFlowNodes(nodes: Node[]): PlanNode[] {
   return nodes.map(n => ({
     id: n.id,
-    type: (n.type || 'action') as 'action' | 'decision',
+    type: (n.type || 'action') as PlanNodeType,
     label: (n.data.label as string) || '',
     prompt: (n.data.prompt as string) || '',
     position: { x: n.position.x, y: n.position.y },
     clearContext: (n.data.clearContext as boolean) || false,
     maxRetries: (n.data.maxRetries as number) || 0,
+    timeout: (n.data.timeout as string) || undefined,
+    retryDelay: (n.data.retryDelay as string) || undefined,
+    retryOn: (n.data.retryOn as PlanRetryOn[] | undefined)?.length ? (n.data.retryOn as PlanRetryOn[]) : undefined,
+    onTimeout: (n.data.onTimeout as 'approve' | 'reject') || undefined,
+    items: (n.data.items as string) || undefined,
+    parallelism: (n.data.parallelism as number) || undefined,
+    presetId: (n.data.presetId as string) || undefined,
+    modelId: (n.data.modelId as string) || undefined,
   }))
 }
 
@@ -0,0 +1,99 @@
+import { useState } from 'react'
+import { Check, Upload } from '@/lib/icons'
+import { toast } from 'sonner'
+import { api } from '../../api'
+import type { Plan } from '../../types'
+import { Button } from '@/components/ui/button'
+import { Textarea } from '@/components/ui/textarea'
+import { Dialog, DialogContent, DialogHeader, DialogTitle, DialogDescription, DialogFooter } from '@/components/ui/dialog'
+
+export function PlanImportDialog({ open, onOpenChange, onImported }: {
+  open: boolean
+  onOpenChange: (open: boolean) => void
+  onImported: (plan: Plan) => void
+}) {
+  const [document, setDocument] = useState('')
+  const [errors, setErrors] = useState<string[]>([])
+  const [checked, setChecked] = useState(false)
+  const [busy, setBusy] = useState(false)
+
+  const change = (value: string) => {
+    setDocument(value)
+    setErrors([])
+    setChecked(false)
+  }
+
+  const loadFile = async (file?: File) => {
+    if (file) change(await file.text())
+  }
+
+  const validate = async () => {
+    setBusy(true)
+    try {
+      const res = await api.plans.validate(document)
+      setErrors(res.errors)
+      setChecked(true)
+      return res.valid
+    } catch (e: unknown) {
+      toast.error(e instanceof Error ? e.message : 'Validation failed')
+      return false
+    } finally {
+      setBusy(false)
+    }
+  }
+
+  const submit = async () => {
+    if (!(await validate())) return
+    setBusy(true)
+    try {
+      const plan = await api.plans.import(document)
+      toast.success(`Imported "${plan.name}"`)
+      change('')
+      onOpenChange(false)
+      onImported(plan)
+    } catch (e: unknown) {
+      toast.error(e instanceof Error ? e.message : 'Import failed')
+    } finally {
+      setBusy(false)
+    }
+  }
+
+  return (
+    <Dialog open={open} onOpenChange={onOpenChange}>
+      <DialogContent className="max-w-2xl">
+        <DialogHeader>
+          <DialogTitle>Import plan</DialogTitle>
+          <DialogDescription>Paste an exported plan document (YAML) or pick a file.</DialogDescription>
+        </DialogHeader>
+        <div className="space-y-2">
+          <input
+            type="file"
+            accept=".yaml,.yml,application/yaml,text/yaml"
+            onChange={e => loadFile(e.target.files?.[0])}
+            className="block text-xs text-zinc-500 file:mr-3 file:px-2 file:py-1 file:rounded file:border-0 file:bg-zinc-100 dark:file:bg-zinc-800 file:text-zinc-700 dark:file:text-zinc-300"
+          />
+          <Textarea
+            value={document}
+            onChange={e => change(e.target.value)}
+            placeholder={'version: 1\nname: My plan\nnodes: []\nedges: []'}
+            className="font-mono text-xs min-h-[280px]"
+          />
+          {errors.length > 0 && (
+            <ul className="px-3 py-2 rounded-md text-xs bg-red-500/5 border border-red-500/10 text-red-500 space-y-0.5">
+              {errors.map(err => <li key={err}>{err}</li>)}
+            </ul>
+          )}
+          {checked && errors.length === 0 && (
+            <p className="text-xs text-emerald-500 flex items-center gap-1"><Check size={12} /> Document is valid</p>
+          )}
+        </div>
+        <DialogFooter>
+          <Button variant="secondary" onClick={validate} disabled={busy || !document.trim()}>Validate</Button>
+          <Button onClick={submit} disabled={busy || !document.trim()}>
+            <Upload size={12} /> Import
+          </Button>
+        </DialogFooter>
+      </DialogContent>
+    </Dialog>
+  )
+}
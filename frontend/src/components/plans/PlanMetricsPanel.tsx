@@ -0,0 +1,92 @@
+import { useEffect, useState } from 'react'
+import { ChevronDown, ChevronRight } from '@/lib/icons'
+import { api } from '../../api'
+import type { PlanMetrics } from '../../types'
+import { Badge } from '@/components/ui/badge'
+
+export function formatMs(ms: number): string {
+  if (ms <= 0) return '—'
+  if (ms < 1000) return `${ms}ms`
+  if (ms < 60000) return `${(ms / 1000).toFixed(1)}s`
+  return `${Math.floor(ms / 60000)}m ${Math.floor((ms % 60000) / 1000)}s`
+}
+
+export function successRateVariant(m: PlanMetrics): 'success' | 'warning' | 'destructive' {
+  if ((m.slaBreaches?.length ?? 0) > 0 || m.successRate < 0.8) return 'destructive'
+  if (m.successRate < 0.95) return 'warning'
+  return 'success'
+}
+
+function Stat({ label, value }: { label: string; value: string }) {
+  return (
+    <div>
+      <p className="text-[10px] uppercase tracking-wider text-zinc-500 dark:text-zinc-600">{label}</p>
+      <p className="text-sm font-medium text-zinc-800 dark:text-zinc-200">{value}</p>
+    </div>
+  )
+}
+
+export default function PlanMetricsPanel({ planId, refreshKey }: { planId: string; refreshKey: number }) {
+  const [metrics, setMetrics] = useState<PlanMetrics | null>(null)
+  const [open, setOpen] = useState(false)
+
+  useEffect(() => {
+    let cancelled = false
+    api.plans.metrics(planId)
+      .then(m => { if (!cancelled) setMetrics(m) })
+      .catch(() => { if (!cancelled) setMetrics(null) })
+    return () => { cancelled = true }
+  }, [planId, refreshKey])
+
+  if (!metrics || metrics.runs === 0) return null
+  const failing = metrics.nodes.find(n => n.nodeId === metrics.mostFailingNode)
+
+  return (
+    <div className="mb-3 rounded-lg border border-zinc-200 dark:border-zinc-800 bg-white dark:bg-zinc-900">
+      <button className="w-full flex items-center gap-2 px-4 py-2.5 text-left" onClick={() => setOpen(o => !o)}>
+        {open ? <ChevronDown size={14} className="text-zinc-400" /> : <ChevronRight size={14} className="text-zinc-400" />}
+        <span className="text-xs font-semibold uppercase tracking-wider text-zinc-500 dark:text-zinc-600">Last 30 days</span>
+        <Badge variant={successRateVariant(metrics)}>{Math.round(metrics.successRate * 100)}% success</Badge>
+        {metrics.slaBreaches?.map(b => (
+          <Badge key={b} variant="destructive">SLA: {b}</Badge>
+        ))}
+      </button>
+      <div className="grid grid-cols-3 sm:grid-cols-6 gap-3 px-4 pb-3">
+        <Stat label="Runs" value={`${metrics.completed}/${metrics.runs}`} />
+        <Stat label="p50" value={formatMs(metrics.durationP50Ms)} />
+        <Stat label="p95" value={formatMs(metrics.durationP95Ms)} />
+        <Stat label="Retries" value={String(metrics.retries)} />
+        <Stat label="Tokens" value={metrics.tokens.total.toLocaleString()} />
+        <Stat label="Fails most" value={failing ? failing.label : '—'} />
+      </div>
+      {open && (
+        <table className="w-full text-xs border-t border-zinc-200 dark:border-zinc-800">
+          <thead>
+            <tr className="text-left text-[10px] uppercase tracking-wider text-zinc-500">
+              <th className="px-4 py-1.5 font-medium">Node</th>
+              <th className="px-2 py-1.5 font-medium">Runs</th>
+              <th className="px-2 py-1.5 font-medium">Failures</th>
+              <th className="px-2 py-1.5 font-medium">Retries</th>
+              <th className="px-2 py-1.5 font-medium">p50</th>
+              <th className="px-2 py-1.5 font-medium">p95</th>
+              <th className="px-4 py-1.5 font-medium text-right">Tokens</th>
+            </tr>
+          </thead>
+          <tbody>
+            {metrics.nodes.map(n => (
+              <tr key={n.nodeId} className="border-t border-zinc-100 dark:border-zinc-800/60 text-zinc-700 dark:text-zinc-300">
+                <td className="px-4 py-1.5">{n.label}</td>
+                <td className="px-2 py-1.5">{n.runs}</td>
+                <td className={`px-2 py-1.5 ${n.failures > 0 ? 'text-red-400' : ''}`}>{n.failures}</td>
+                <td className="px-2 py-1.5">{n.retries}</td>
+                <td className="px-2 py-1.5 font-mono">{formatMs(n.durationP50Ms)}</td>
+                <td className="px-2 py-1.5 font-mono">{formatMs(n.durationP95Ms)}</td>
+                <td className="px-4 py-1.5 font-mono text-right">{n.tokens.total.toLocaleString()}</td>
+              </tr>
+            ))}
+          </tbody>
+        </table>
+      )}
+    </div>
+  )
+}
@@ -0,0 +1,58 @@
+import { useState, useEffect } from 'react'
+import { Download } from '@/lib/icons'
+import { toast } from 'sonner'
+import { api } from '../../api'
+import type { Plan, PlanRevision } from '../../types'
+import { Badge } from '@/components/ui/badge'
+import { Dialog, DialogContent, DialogHeader, DialogTitle, DialogDescription } from '@/components/ui/dialog'
+
+export function PlanRevisionsDialog({ plan, onClose }: { plan: Plan | null; onClose: () => void }) {
+  const [revisions, setRevisions] = useState<PlanRevision[]>([])
+  const [loading, setLoading] = useState(false)
+
+  useEffect(() => {
+    if (!plan) return
+    setLoading(true)
+    api.plans.revisions(plan.id)
+      .then(setRevisions)
+      .catch((e: unknown) => toast.error(e instanceof Error ? e.message : 'Failed to load history'))
+      .finally(() => setLoading(false))
+  }, [plan])
+
+  return (
+    <Dialog open={!!plan} onOpenChange={open => !open && onClose()}>
+      <DialogContent>
+        <DialogHeader>
+          <DialogTitle>History of {plan?.name}</DialogTitle>
+          <DialogDescription>Every saved version of this plan. Download one to diff or restore it via import.</DialogDescription>
+        </DialogHeader>
+        {loading ? (
+          <div className="text-center py-6 text-zinc-500 text-sm">Loading...</div>
+        ) : revisions.length === 0 ? (
+          <div className="text-center py-6 text-zinc-500 text-sm">No revisions recorded yet</div>
+        ) : (
+          <div className="max-h-80 overflow-y-auto divide-y divide-zinc-200 dark:divide-zinc-800">
+            {revisions.map((rev, i) => (
+              <div key={rev.id} className="flex items-center justify-between py-2 text-xs">
+                <div className="flex items-center gap-2 min-w-0">
+                  <Badge variant={i === 0 ? 'success' : 'secondary'}>v{rev.version}</Badge>
+                  <span className="text-zinc-600 dark:text-zinc-400">{new Date(rev.createdAt).toLocaleString()}</span>
+                  {rev.createdBy && <span className="text-zinc-500 truncate">by {rev.createdBy}</span>}
+                  <span className="text-zinc-500">· {rev.plan.graph.nodes.length} nodes</span>
+                </div>
+                <a
+                  href={plan ? api.plans.exportUrl(plan.id, rev.version) : undefined}
+                  download
+                  className="p-1 rounded text-zinc-500 hover:text-zinc-800 dark:hover:text-zinc-200"
+                  title="Download YAML"
+                >
+                  <Download size={14} />
+                </a>
+              </div>
+            ))}
+          </div>
+        )}
+      </DialogContent>
+    </Dialog>
+  )
+}
@@ -1,28 +1,36 @@
 import { useState, useEffect, useCallback, useMemo } from 'react'
-import { Play, Clock, CheckCircle2, XCircle, Loader2, PauseCircle, Circle, SkipForward, ChevronDown, ChevronRight, Ban } from '@/lib/icons'
+import { Play, Clock, CheckCircle2, XCircle, Loader2, PauseCircle, Circle, SkipForward, ChevronDown, ChevronRight, Ban, ShieldCheck, FlaskConical, CalendarOff } from '@/lib/icons'
 import { toast } from 'sonner'
-import { api } from '../../api'
-import type { PlanNode, PlanRun, PlanRunStatus, PlanStepRun, PlanStepStatus, ChatMessage, Step } from '../../types'
+import { api, ApiError } from '../../api'
+import type { PlanApproval, PlanItemRun, PlanNode, PlanRun, PlanRunStatus, PlanStepRun, PlanStepStatus, ChatMessage, SimulatedCall, Step } from '../../types'
 import { StepBadge, StepPanel } from '../ChatMessages'
 import { Markdown } from '../Markdown'
 import { Button } from '@/components/ui/button'
 import { Badge } from '@/components/ui/badge'
 import { Input } from '@/components/ui/input'
+import { Textarea } from '@/components/ui/textarea'
 import { EmptyState } from '@/components/EmptyState'
 import { Dialog, DialogContent, DialogHeader, DialogTitle, DialogDescription, DialogFooter } from '@/components/ui/dialog'
 import { FormField } from '@/components/FormField'
+import PlanMetricsPanel from './PlanMetricsPanel'
+import { navigate } from '../../router'
 
 const runStatusCfg: Record<PlanRunStatus, { icon: typeof CheckCircle2; color: string; variant: 'success' | 'warning' | 'destructive' | 'muted' }> = {
+  queued:    { icon: Clock,        color: 'text-zinc-400',              variant: 'muted' },
+  deferred:  { icon: CalendarOff,  color: 'text-amber-400',             variant: 'muted' },
   running:   { icon: Loader2,     color: 'text-blue-400 animate-spin', variant: 'warning' },
+  waiting:   { icon: ShieldCheck,  color: 'text-violet-400',            variant: 'warning' },
   completed: { icon: CheckCircle2, color: 'text-emerald-400',           variant: 'success' },
   failed:    { icon: XCircle,      color: 'text-red-400',               variant: 'destructive' },
   cancelled: { icon: Ban,          color: 'text-zinc-400',              variant: 'muted' },
+  skipped:   { icon: SkipForward,  color: 'text-zinc-400',              variant: 'muted' },
   paused:    { icon: PauseCircle,  color: 'text-amber-400',             variant: 'muted' },
 }
 
 const stepStatusCfg: Record<PlanStepStatus, { icon: typeof CheckCircle2; color: string; variant: 'success' | 'warning' | 'destructive' | 'muted' | 'default' }> = {
   pending:   { icon: Circle,       color: 'text-zinc-400',              variant: 'muted' },
   running:   { icon: Loader2,      color: 'text-blue-400 animate-spin', variant: 'warning' },
+  waiting:   { icon: ShieldCheck,  color: 'text-violet-400',            variant: 'warning' },
   completed: { icon: CheckCircle2, color: 'text-emerald-400',           variant: 'success' },
   failed:    { icon: XCircle,      color: 'text-red-400',               variant: 'destructive' },
   skipped:   { icon: SkipForward,  color: 'text-zinc-400',              variant: 'muted' },
@@ -44,18 +52,33 @@ function duration(start?: string, end?: string) {
   return `${Math.floor(ms / 60000)}m ${Math.floor((ms % 60000) / 1000)}s`
 }
 
+// parseMocks reads one "tool = result" pair per line; "*" as the tool answers
+// every stubbed call without its own mock.
+function parseMocks(text: string): Record<string, string> | undefined {
+  const mocks: Record<string, string> = {}
+  for (const line of text.split('\n')) {
+    const i = line.indexOf('=')
+    if (i <= 0) continue
+    const tool = line.slice(0, i).trim()
+    if (tool) mocks[tool] = line.slice(i + 1).trim()
+  }
+  return Object.keys(mocks).length > 0 ? mocks : undefined
+}
+
 interface ParamDef {
   name: string
   type: string
   description: string
+  default?: unknown
 }
 
 function extractParams(schema: Record<string, unknown>): ParamDef[] {
-  const props = (schema?.properties ?? {}) as Record<string, { type?: string; description?: string }>
+  const props = (schema?.properties ?? {}) as Record<string, { type?: string; description?: string; default?: unknown }>
   return Object.entries(props).map(([name, p]) => ({
     name,
     type: p.type ?? 'string',
     description: p.description ?? '',
+    default: p.default,
   }))
 }
 
@@ -74,8 +97,12 @@ export default function PlanRuns({ planId, planNodes, planParameters, onActiveSt
   const [openStep, setOpenStep] = useState<Step | null>(null)
   const [inputOpen, setInputOpen] = useState(false)
   const [inputValues, setInputValues] = useState<Record<string, string>>({})
+  const [simulate, setSimulate] = useState(false)
+  const [mocksText, setMocksText] = useState('')
+  const [blackout, setBlackout] = useState<{ input: Record<string, unknown>; detail: string } | null>(null)
 
   const paramDefs = useMemo(() => extractParams(planParameters), [planParameters])
+  const finishedRuns = useMemo(() => runs.filter(r => r.finishedAt).length, [runs])
 
   const nodeMap = useMemo(() => new Map(planNodes.map(n => [n.id, n])), [planNodes])
 
@@ -95,7 +122,7 @@ export default function PlanRuns({ planId, planNodes, planParameters, onActiveSt
   useEffect(() => { loadRuns() }, [loadRuns])
 
   useEffect(() => {
-    const hasRunning = runs.some(r => r.status === 'running')
+    const hasRunning = runs.some(r => r.status === 'running' || r.status === 'queued' || r.status === 'waiting')
     const interval = hasRunning ? 2000 : 10000
     const iv = setInterval(loadRuns, interval)
     return () => clearInterval(iv)
@@ -139,25 +166,42 @@ export default function PlanRuns({ planId, planNodes, planParameters, onActiveSt
     }
   }
 
-  const handleRunClick = () => {
-    if (paramDefs.length > 0) {
+  const resolveApproval = async (runId: string, decision: 'approve' | 'reject', comment: string) => {
+    try {
+      await api.planRuns.resolveApproval(runId, decision, comment || undefined)
+      toast.success(decision === 'approve' ? 'Approved' : 'Rejected')
+      loadRuns()
+    } catch (e: unknown) {
+      toast.error(e instanceof Error ? e.message : 'Failed to resolve approval')
+    }
+  }
+
+  const handleRunClick = (dryRun: boolean) => {
+    setSimulate(dryRun)
+    if (paramDefs.length > 0 || dryRun) {
       const defaults: Record<string, string> = {}
-      for (const p of paramDefs) defaults[p.name] = ''
+      for (const p of paramDefs) defaults[p.name] = p.default == null ? '' : String(p.default)
       setInputValues(defaults)
       setInputOpen(true)
     } else {
-      doTriggerRun({})
+      doTriggerRun({}, false)
     }
   }
 
-  const doTriggerRun = async (input: Record<string, unknown>) => {
+  const doTriggerRun = async (input: Record<string, unknown>, dryRun: boolean, force = false) => {
     try {
-      const newRun = await api.planRuns.trigger(planId, input)
-      toast.success('Plan execution started')
+      const newRun = dryRun
+        ? await api.planRuns.simulate(planId, input, parseMocks(mocksText))
+        : await api.planRuns.trigger(planId, input, force)
+      toast.success(dryRun ? 'Dry run started' : 'Plan execution started')
       setExpandedId(newRun.id)
       onActiveSteps(newRun.steps)
       loadRuns()
     } catch (e: unknown) {
+      if (e instanceof ApiError && e.status === 409 && !dryRun) {
+        setBlackout({ input, detail: e.detail ?? e.message })
+        return
+      }
       toast.error(e instanceof Error ? e.message : 'Failed to trigger run')
     }
   }
@@ -175,7 +219,7 @@ export default function PlanRuns({ planId, planNodes, planParameters, onActiveSt
       }
     }
     setInputOpen(false)
-    doTriggerRun(input)
+    doTriggerRun(input, simulate)
   }
 
   if (loading) {
@@ -188,11 +232,18 @@ export default function PlanRuns({ planId, planNodes, planParameters, onActiveSt
         <span className="text-xs font-semibold uppercase tracking-wider text-zinc-500 dark:text-zinc-600">
           Runs{runs.length > 0 && ` (${runs.length})`}
         </span>
-        <Button size="sm" onClick={handleRunClick}>
-          <Play size={12} /> Run Now
-        </Button>
+        <div className="flex items-center gap-2">
+          <Button size="sm" variant="secondary" onClick={() => handleRunClick(true)} title="Run with SSH, upload and notification tools stubbed out">
+            <FlaskConical size={12} /> Dry Run
+          </Button>
+          <Button size="sm" onClick={() => handleRunClick(false)}>
+            <Play size={12} /> Run Now
+          </Button>
+        </div>
       </div>
 
+      <PlanMetricsPanel planId={planId} refreshKey={finishedRuns} />
+
       {runs.length === 0 ? (
         <EmptyState icon={Clock} title="No runs yet" description="Run this plan manually or wait for the schedule" />
       ) : (
@@ -221,7 +272,12 @@ export default function PlanRuns({ planId, planNodes, planParameters, onActiveSt
                       <StatusIcon size={14} className={cfg.color} />
                       <span className="font-mono text-xs text-zinc-500">{run.id.slice(0, 8)}</span>
                       <Badge variant={cfg.variant}>{run.status}</Badge>
-                      <Badge variant="muted">{run.trigger}</Badge>
+                      <Badge variant={run.trigger === 'simulation' ? 'warning' : 'muted'}>{run.trigger}</Badge>
+                      {run.reason && (
+                        <span className="text-[10px] text-amber-600 dark:text-amber-400 truncate max-w-[260px]" title={run.reason}>
+                          {run.reason}
+                        </span>
+                      )}
                       {run.input && Object.keys(run.input).length > 0 && (
                         <span className="text-[10px] text-zinc-400 font-mono truncate max-w-[200px]" title={JSON.stringify(run.input)}>
                           {Object.entries(run.input).map(([k, v]) => `${k}=${v}`).join(', ')}
@@ -232,7 +288,7 @@ export default function PlanRuns({ planId, planNodes, planParameters, onActiveSt
                       <span className="flex items-center gap-1"><Clock size={10} />{timeAgo(run.startedAt)}</span>
                       <span>{duration(run.startedAt, run.finishedAt)}</span>
                       <span>{completed}/{run.steps.length}</span>
-                      {run.status === 'running' && (
+                      {(run.status === 'running' || run.status === 'queued' || run.status === 'waiting' || run.status === 'deferred') && (
                         <Button
                           variant="destructive"
                           size="sm"
@@ -252,6 +308,7 @@ export default function PlanRuns({ planId, planNodes, planParameters, onActiveSt
                         className={`h-1.5 flex-1 rounded-full ${
                           step.status === 'completed' ? 'bg-emerald-500' :
                           step.status === 'running' ? 'bg-blue-500 animate-pulse' :
+                          step.status === 'waiting' ? 'bg-violet-500 animate-pulse' :
                           step.status === 'failed' ? 'bg-red-500' :
                           step.status === 'skipped' ? 'bg-zinc-400 dark:bg-zinc-600' :
                           'bg-zinc-200 dark:bg-zinc-800'
@@ -270,6 +327,7 @@ export default function PlanRuns({ planId, planNodes, planParameters, onActiveSt
                         node={nodeMap.get(step.nodeId)}
                         message={step.messageId ? msgById.get(step.messageId) : undefined}
                         onStepClick={setOpenStep}
+                        onResolve={(decision, comment) => resolveApproval(run.id, decision, comment)}
                       />
                     ))}
                   </div>
@@ -285,8 +343,12 @@ export default function PlanRuns({ planId, planNodes, planParameters, onActiveSt
       <Dialog open={inputOpen} onOpenChange={setInputOpen}>
         <DialogContent>
           <DialogHeader>
-            <DialogTitle>Run with parameters</DialogTitle>
-            <DialogDescription>Fill in the required inputs for this plan.</DialogDescription>
+            <DialogTitle>{simulate ? 'Dry run' : 'Run with parameters'}</DialogTitle>
+            <DialogDescription>
+              {simulate
+                ? 'Server, upload and notification calls are recorded instead of executed. Unmocked calls get an imagined result.'
+                : 'Fill in the required inputs for this plan.'}
+            </DialogDescription>
           </DialogHeader>
           <div className="space-y-3">
             {paramDefs.map(p => (
@@ -299,11 +361,44 @@ export default function PlanRuns({ planId, planNodes, planParameters, onActiveSt
                 />
               </FormField>
             ))}
+            {simulate && (
+              <FormField label="Mocks" hint='One "tool = result" per line, e.g. ssh_web1 = disk usage is 95%. Use * for any tool.'>
+                <Textarea
+                  value={mocksText}
+                  onChange={e => setMocksText(e.target.value)}
+                  rows={4}
+                  placeholder="ssh_web1 = disk usage is 95%"
+                  className="font-mono text-xs"
+                />
+              </FormField>
+            )}
           </div>
           <DialogFooter>
             <Button variant="secondary" onClick={() => setInputOpen(false)}>Cancel</Button>
             <Button onClick={submitInput}>
-              <Play size={12} /> Run
+              {simulate ? <><FlaskConical size={12} /> Dry Run</> : <><Play size={12} /> Run</>}
+            </Button>
+          </DialogFooter>
+        </DialogContent>
+      </Dialog>
+
+      <Dialog open={blackout !== null} onOpenChange={open => !open && setBlackout(null)}>
+        <DialogContent>
+          <DialogHeader>
+            <DialogTitle className="flex items-center gap-2"><CalendarOff size={16} className="text-amber-500" /> Blackout window</DialogTitle>
+            <DialogDescription>{blackout?.detail}</DialogDescription>
+          </DialogHeader>
+          <DialogFooter>
+            <Button variant="secondary" onClick={() => setBlackout(null)}>Cancel</Button>
+            <Button
+              variant="destructive"
+              onClick={() => {
+                const input = blackout?.input ?? {}
+                setBlackout(null)
+                doTriggerRun(input, false, true)
+              }}
+            >
+              <Play size={12} /> Run anyway
             </Button>
           </DialogFooter>
         </DialogContent>
@@ -312,11 +407,12 @@ export default function PlanRuns({ planId, planNodes, planParameters, onActiveSt
   )
 }
 
-function StepRunRow({ step, node, message, onStepClick }: {
+function StepRunRow({ step, node, message, onStepClick, onResolve }: {
   step: PlanStepRun
   node?: PlanNode
   message?: ChatMessage
   onStepClick: (s: Step) => void
+  onResolve: (decision: 'approve' | 'reject', comment: string) => void
 }) {
   const sc = stepStatusCfg[step.status] ?? stepStatusCfg.pending
   const StepIcon = sc.icon
@@ -331,6 +427,11 @@ function StepRunRow({ step, node, message, onStepClick }: {
             <span className="text-sm font-medium text-zinc-800 dark:text-zinc-200">{node?.label || step.nodeId}</span>
             <span className="text-[10px] text-zinc-500 capitalize">{node?.type}</span>
             <Badge variant={sc.variant} className="text-[10px]">{step.status}</Badge>
+            {step.modelName && (
+              <Badge variant="outline" className="text-[10px] font-mono" title={step.presetName ? `Preset ${step.presetName}` : undefined}>
+                {step.modelName}
+              </Badge>
+            )}
             {step.startedAt && (
               <span className="text-[11px] text-zinc-500">{duration(step.startedAt, step.finishedAt)}</span>
             )}
@@ -350,12 +451,22 @@ function StepRunRow({ step, node, message, onStepClick }: {
             </div>
           )}
 
+          {step.items && step.items.length > 0 && <LoopItems items={step.items} />}
+
           {step.result && step.status === 'failed' && (
             <div className="mt-2 px-3 py-2 rounded-md text-xs bg-red-500/5 border border-red-500/10 text-red-400">
               {step.result}
             </div>
           )}
 
+          {step.simulatedCalls && step.simulatedCalls.length > 0 && (
+            <SimulatedCalls calls={step.simulatedCalls} />
+          )}
+
+          {step.approval && (
+            <ApprovalPanel approval={step.approval} waiting={step.status === 'waiting'} onResolve={onResolve} />
+          )}
+
           {step.status === 'running' && !message && (
             <div className="flex items-center gap-1.5 mt-2 text-xs text-zinc-500">
               <Loader2 size={11} className="animate-spin" />
@@ -367,3 +478,97 @@ function StepRunRow({ step, node, message, onStepClick }: {
     </div>
   )
 }
+
+function LoopItems({ items }: { items: PlanItemRun[] }) {
+  return (
+    <div className="mt-2 space-y-1">
+      {items.map(it => {
+        const sc = stepStatusCfg[it.status as PlanStepStatus] ?? stepStatusCfg.skipped
+        const ItemIcon = sc.icon
+        return (
+          <div key={it.index} className="px-3 py-1.5 rounded-md bg-zinc-100 dark:bg-zinc-800/50 text-xs">
+            <div className="flex items-center gap-2">
+              <ItemIcon size={12} className={`${sc.color} shrink-0`} />
+              <span className="font-mono text-zinc-800 dark:text-zinc-200 truncate">
+                {typeof it.item === 'string' ? it.item : JSON.stringify(it.item)}
+              </span>
+              {it.steps && it.steps.length > 0 && (
+                <span className="text-[10px] text-zinc-500">{it.steps.length} steps</span>
+              )}
+              {it.startedAt && <span className="text-[11px] text-zinc-500">{duration(it.startedAt, it.finishedAt)}</span>}
+              {it.sessionId && (
+                <button
+                  className="ml-auto text-[11px] text-teal-600 dark:text-teal-400 hover:underline"
+                  onClick={() => navigate({ page: 'chat', sessionId: it.sessionId })}
+                >
+                  Session
+                </button>
+              )}
+            </div>
+            {it.result && (
+              <p className={`mt-1 whitespace-pre-wrap line-clamp-3 ${it.status === 'failed' ? 'text-red-400' : 'text-zinc-600 dark:text-zinc-400'}`}>
+                {it.result}
+              </p>
+            )}
+          </div>
+        )
+      })}
+    </div>
+  )
+}
+
+function SimulatedCalls({ calls }: { calls: SimulatedCall[] }) {
+  return (
+    <div className="mt-2 px-3 py-2 rounded-md border border-amber-500/20 bg-amber-500/5 text-xs space-y-1.5">
+      <p className="flex items-center gap-1.5 text-[11px] font-medium text-amber-600 dark:text-amber-400">
+        <FlaskConical size={11} /> Simulated calls
+      </p>
+      {calls.map((c, i) => (
+        <div key={i} className="font-mono text-[11px] text-zinc-600 dark:text-zinc-400">
+          <span className="text-zinc-800 dark:text-zinc-200">{c.tool}</span> {c.args}
+          <p className="text-zinc-500 whitespace-pre-wrap">→ {c.result}</p>
+        </div>
+      ))}
+    </div>
+  )
+}
+
+function ApprovalPanel({ approval, waiting, onResolve }: {
+  approval: PlanApproval
+  waiting: boolean
+  onResolve: (decision: 'approve' | 'reject', comment: string) => void
+}) {
+  const [comment, setComment] = useState('')
+
+  return (
+    <div className="mt-2 px-3 py-2 rounded-md text-xs bg-violet-500/5 border border-violet-500/10 space-y-2" onClick={e => e.stopPropagation()}>
+      {approval.message && <p className="text-zinc-700 dark:text-zinc-300 whitespace-pre-wrap">{approval.message}</p>}
+      {approval.context && (
+        <div className="px-2 py-1.5 rounded bg-zinc-100 dark:bg-zinc-800/50 text-zinc-600 dark:text-zinc-400">
+          <Markdown content={approval.context} />
+        </div>
+      )}
+      {waiting ? (
+        <>
+          <p className="text-[11px] text-zinc-500">Waiting for approval — expires {new Date(approval.expiresAt).toLocaleString()}</p>
+          <div className="flex items-center gap-2">
+            <Input value={comment} onChange={e => setComment(e.target.value)} placeholder="Comment (optional)" className="h-7 text-xs" />
+            <Button size="sm" className="h-7 px-2 text-[11px]" onClick={() => onResolve('approve', comment)}>
+              <CheckCircle2 size={11} /> Approve
+            </Button>
+            <Button variant="destructive" size="sm" className="h-7 px-2 text-[11px]" onClick={() => onResolve('reject', comment)}>
+              <XCircle size={11} /> Reject
+            </Button>
+          </div>
+        </>
+      ) : approval.decision && (
+        <p className={approval.decision === 'approve' ? 'text-emerald-500' : 'text-red-400'}>
+          {approval.decision === 'approve' ? 'Approved' : 'Rejected'}
+          {approval.resolvedBy && ` by ${approval.resolvedBy}`}
+          {approval.resolvedAt && ` · ${timeAgo(approval.resolvedAt)}`}
+          {approval.comment && ` — ${approval.comment}`}
+        </p>
+      )}
+    </div>
+  )
+}
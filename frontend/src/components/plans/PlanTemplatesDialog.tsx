@@ -0,0 +1,159 @@
+import { useEffect, useMemo, useState } from 'react'
+import { ArrowLeft, Layers, Trash2 } from '@/lib/icons'
+import { toast } from 'sonner'
+import { api } from '../../api'
+import type { Plan, PlanTemplate } from '../../types'
+import { describeCron } from '../../lib/cron'
+import { Button } from '@/components/ui/button'
+import { Badge } from '@/components/ui/badge'
+import { Input } from '@/components/ui/input'
+import { Dialog, DialogContent, DialogHeader, DialogTitle, DialogDescription, DialogFooter } from '@/components/ui/dialog'
+import { FormField } from '@/components/FormField'
+
+interface TemplateParam {
+  name: string
+  type: string
+  description: string
+  default?: unknown
+  required: boolean
+}
+
+function templateParams(schema: Record<string, unknown>): TemplateParam[] {
+  const props = (schema?.properties ?? {}) as Record<string, { type?: string; description?: string; default?: unknown }>
+  const req = Array.isArray(schema?.required) ? (schema.required as string[]) : []
+  return Object.entries(props).map(([name, p]) => ({
+    name,
+    type: p.type ?? 'string',
+    description: p.description ?? '',
+    default: p.default,
+    required: req.includes(name),
+  }))
+}
+
+export function PlanTemplatesDialog({ open, onOpenChange, onCreated }: {
+  open: boolean
+  onOpenChange: (open: boolean) => void
+  onCreated: (plan: Plan) => void
+}) {
+  const [templates, setTemplates] = useState<PlanTemplate[]>([])
+  const [selected, setSelected] = useState<PlanTemplate | null>(null)
+  const [name, setName] = useState('')
+  const [schedule, setSchedule] = useState('')
+  const [values, setValues] = useState<Record<string, string>>({})
+  const [busy, setBusy] = useState(false)
+
+  useEffect(() => {
+    if (!open) return
+    setSelected(null)
+    api.planTemplates.list().then(setTemplates).catch(() => setTemplates([]))
+  }, [open])
+
+  const params = useMemo(() => selected ? templateParams(selected.plan.parameters) : [], [selected])
+  const missing = params.some(p => p.required && p.default == null && !(values[p.name] ?? '').trim())
+
+  const pick = (t: PlanTemplate) => {
+    setSelected(t)
+    setName(t.plan.name)
+    setSchedule(t.plan.schedule)
+    setValues({})
+  }
+
+  const remove = async (t: PlanTemplate, e: React.MouseEvent) => {
+    e.stopPropagation()
+    try {
+      await api.planTemplates.delete(t.id)
+      setTemplates(ts => ts.filter(x => x.id !== t.id))
+      toast.success('Template deleted')
+    } catch (err: unknown) {
+      toast.error(err instanceof Error ? err.message : 'Delete failed')
+    }
+  }
+
+  const submit = async () => {
+    if (!selected) return
+    const parameters: Record<string, unknown> = {}
+    for (const [k, v] of Object.entries(values)) {
+      if (v.trim()) parameters[k] = v.trim()
+    }
+    setBusy(true)
+    try {
+      const plan = await api.planTemplates.instantiate(selected.id, { name: name.trim(), schedule: schedule.trim(), parameters })
+      toast.success(`Created "${plan.name}"`)
+      onOpenChange(false)
+      onCreated(plan)
+    } catch (e: unknown) {
+      toast.error(e instanceof Error ? e.message : 'Create failed')
+    } finally {
+      setBusy(false)
+    }
+  }
+
+  return (
+    <Dialog open={open} onOpenChange={onOpenChange}>
+      <DialogContent className="max-w-2xl">
+        <DialogHeader>
+          <DialogTitle>{selected ? selected.name : 'Plan templates'}</DialogTitle>
+          <DialogDescription>
+            {selected ? selected.description : 'Start from a ready-made plan. Parameter values become the new plan\'s defaults.'}
+          </DialogDescription>
+        </DialogHeader>
+        {!selected ? (
+          <div className="space-y-2 max-h-[60vh] overflow-auto">
+            {templates.map(t => (
+              <div
+                key={t.id}
+                className="rounded-lg border border-zinc-200 dark:border-zinc-800 px-3 py-2.5 cursor-pointer hover:border-zinc-300 dark:hover:border-zinc-700 transition-colors"
+                onClick={() => pick(t)}
+              >
+                <div className="flex items-center gap-2">
+                  <Layers size={14} className="text-teal-500 shrink-0" />
+                  <span className="text-sm font-medium text-zinc-800 dark:text-zinc-200">{t.name}</span>
+                  <Badge variant={t.builtin ? 'secondary' : 'outline'}>{t.builtin ? 'Built-in' : 'Saved'}</Badge>
+                  <Badge variant="muted">{t.plan.graph.nodes.length} nodes</Badge>
+                  {!t.builtin && (
+                    <Button variant="ghost" size="icon" className="ml-auto h-7 w-7 text-zinc-400 hover:text-red-500" onClick={e => remove(t, e)} title="Delete template">
+                      <Trash2 size={12} />
+                    </Button>
+                  )}
+                </div>
+                {t.description && <p className="text-xs text-zinc-500 mt-1 ml-6">{t.description}</p>}
+              </div>
+            ))}
+            {templates.length === 0 && <p className="text-center py-6 text-xs text-zinc-500">No templates</p>}
+          </div>
+        ) : (
+          <div className="space-y-3">
+            <FormField label="Name">
+              <Input value={name} onChange={e => setName(e.target.value)} />
+            </FormField>
+            <FormField label="Schedule (cron)" hint={schedule.trim() ? describeCron(schedule) : 'Manual trigger only'}>
+              <Input value={schedule} onChange={e => setSchedule(e.target.value)} className="font-mono" placeholder="0 3 * * *" />
+            </FormField>
+            {params.map(p => (
+              <FormField key={p.name} label={`${p.name}${p.required ? ' *' : ''}`} hint={p.description}>
+                <Input
+                  value={values[p.name] ?? ''}
+                  onChange={e => setValues(v => ({ ...v, [p.name]: e.target.value }))}
+                  placeholder={p.default == null ? p.type : String(p.default)}
+                  className="font-mono text-xs"
+                />
+              </FormField>
+            ))}
+          </div>
+        )}
+        <DialogFooter>
+          {selected ? (
+            <>
+              <Button variant="secondary" onClick={() => setSelected(null)}>
+                <ArrowLeft size={12} /> Back
+              </Button>
+              <Button onClick={submit} disabled={busy || missing || !name.trim()}>Create plan</Button>
+            </>
+          ) : (
+            <Button variant="secondary" onClick={() => onOpenChange(false)}>Close</Button>
+          )}
+        </DialogFooter>
+      </DialogContent>
+    </Dialog>
+  )
+}
@@ -0,0 +1,62 @@
+import { useEffect, useState } from 'react'
+import { api } from '../../api'
+
+function formatFireTime(iso: string, timezone: string): string {
+  const opts: Intl.DateTimeFormatOptions = {
+    weekday: 'short',
+    day: 'numeric',
+    month: 'short',
+    hour: '2-digit',
+    minute: '2-digit',
+    timeZoneName: 'short',
+  }
+  try {
+    return new Date(iso).toLocaleString(undefined, timezone ? { ...opts, timeZone: timezone } : opts)
+  } catch {
+    return new Date(iso).toLocaleString(undefined, opts)
+  }
+}
+
+export default function SchedulePreview({ schedule, timezone }: { schedule: string; timezone: string }) {
+  const [times, setTimes] = useState<string[]>([])
+  const [error, setError] = useState('')
+
+  useEffect(() => {
+    const expr = schedule.trim()
+    if (!expr) return
+    let cancelled = false
+    const timer = setTimeout(async () => {
+      try {
+        const res = await api.plans.schedulePreview(expr, timezone.trim())
+        if (cancelled) return
+        setTimes(res.times)
+        setError('')
+      } catch (e: unknown) {
+        if (cancelled) return
+        setTimes([])
+        setError(e instanceof Error ? e.message : 'Invalid schedule')
+      }
+    }, 400)
+    return () => {
+      cancelled = true
+      clearTimeout(timer)
+    }
+  }, [schedule, timezone])
+
+  if (error) {
+    return <p className="text-[11px] text-red-500">{error}</p>
+  }
+  if (times.length === 0) return null
+  return (
+    <div className="rounded-md border border-zinc-200 dark:border-zinc-800 px-3 py-2">
+      <p className="text-[11px] font-medium text-zinc-600 dark:text-zinc-400 mb-1">Next runs</p>
+      <ul className="space-y-0.5">
+        {times.map(t => (
+          <li key={t} className="text-[11px] font-mono text-zinc-500 dark:text-zinc-500">
+            {formatFireTime(t, timezone.trim())}
+          </li>
+        ))}
+      </ul>
+    </div>
+  )
+}
@@ -34,6 +34,8 @@ import {
   Terminal as PTerminal,
   Calculator as PCalculator,
   DownloadSimple as PDownload,
+  UploadSimple as PUpload,
+  ClockCounterClockwise as PHistory,
   Microphone as PMic,
   Eye as PEye,
   MagicWand as PMagicWand,
@@ -62,6 +64,9 @@ import {
   Path as PPath,
   Wallet as PWallet,
   Infinity as PInfinity,
+  Flask as PFlask,
+  Repeat as PRepeat,
+  CalendarX as PCalendarX,
   type IconProps as PhosphorIconProps,
   type IconWeight,
 } from '@phosphor-icons/react'
@@ -117,6 +122,8 @@ export const Image = adapt(PImage)
 export const Terminal = adapt(PTerminal)
 export const Calculator = adapt(PCalculator)
 export const Download = adapt(PDownload)
+export const Upload = adapt(PUpload)
+export const History = adapt(PHistory)
 export const Mic = adapt(PMic)
 export const Eye = adapt(PEye)
 export const Wand2 = adapt(PMagicWand)
@@ -145,3 +152,6 @@ export const Link2 = adapt(PLinkSimple)
 export const Route = adapt(PPath)
 export const Wallet = adapt(PWallet)
 export const Infinity = adapt(PInfinity)
+export const FlaskConical = adapt(PFlask)
+export const Repeat = adapt(PRepeat)
+export const CalendarOff = adapt(PCalendarX)
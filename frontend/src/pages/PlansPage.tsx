@@ -1,9 +1,14 @@
 import { useState, useEffect, useCallback } from 'react'
-import { Plus, GitBranch, Pencil, Trash2, Play, Pause } from '@/lib/icons'
+import { Plus, GitBranch, Pencil, Trash2, Play, Pause, Download, Upload, History, Layers, CalendarOff } from '@/lib/icons'
 import { toast } from 'sonner'
 import { api } from '../api'
-import type { Plan } from '../types'
+import type { Plan, PlanBlackout, PlanMetrics } from '../types'
 import PlanEditor from '../components/plans/PlanEditor'
+import { PlanImportDialog } from '../components/plans/PlanImportDialog'
+import { PlanRevisionsDialog } from '../components/plans/PlanRevisionsDialog'
+import { PlanTemplatesDialog } from '../components/plans/PlanTemplatesDialog'
+import { PlanBlackoutsDialog } from '../components/plans/PlanBlackoutsDialog'
+import { successRateVariant } from '../components/plans/PlanMetricsPanel'
 import { Button } from '@/components/ui/button'
 import { Badge } from '@/components/ui/badge'
 import { EmptyState } from '@/components/EmptyState'
@@ -20,16 +25,38 @@ const emptyPlan: Plan = {
   graph: { nodes: [], edges: [] },
 }
 
+function BlackoutBadge({ blackout }: { blackout?: PlanBlackout }) {
+  if (!blackout?.activeUntil) return null
+  return (
+    <Badge variant="warning" title={`Blackout until ${new Date(blackout.activeUntil).toLocaleString()}`}>
+      <CalendarOff size={10} /> {blackout.name}
+    </Badge>
+  )
+}
+
 export default function PlansPage({ deepPlanId }: { deepPlanId?: string }) {
   const [plans, setPlans] = useState<Plan[]>([])
   const [loading, setLoading] = useState(true)
   const [activePlan, setActivePlan] = useState<Plan | null>(null)
   const [deleteTarget, setDeleteTarget] = useState<string | null>(null)
+  const [importOpen, setImportOpen] = useState(false)
+  const [templatesOpen, setTemplatesOpen] = useState(false)
+  const [historyPlan, setHistoryPlan] = useState<Plan | null>(null)
+  const [metrics, setMetrics] = useState<Record<string, PlanMetrics>>({})
+  const [blackoutsOpen, setBlackoutsOpen] = useState(false)
+  const [blackouts, setBlackouts] = useState<PlanBlackout[]>([])
+  const loadBlackouts = useCallback(() => {
+    api.planBlackouts.list().then(setBlackouts).catch(() => setBlackouts([]))
+  }, [])
   const load = useCallback(async () => {
     try {
       setLoading(true)
       const list = await api.plans.list()
       setPlans(list)
+      api.plans.allMetrics()
+        .then(items => setMetrics(Object.fromEntries(items.map(m => [m.planId, m]))))
+        .catch(() => setMetrics({}))
+      loadBlackouts()
       return list
     } catch (e: unknown) {
       toast.error(e instanceof Error ? e.message : 'Failed to load')
@@ -37,7 +64,11 @@ export default function PlansPage({ deepPlanId }: { deepPlanId?: string }) {
     } finally {
       setLoading(false)
     }
-  }, [])
+  }, [loadBlackouts])
+
+  // activeBlackout returns the open window covering a plan, if any.
+  const activeBlackout = (planId: string) =>
+    blackouts.find(b => b.activeUntil && (!b.planId || b.planId === planId))
 
   useEffect(() => { load() }, [load])
 
@@ -54,6 +85,11 @@ export default function PlansPage({ deepPlanId }: { deepPlanId?: string }) {
         name: plan.name,
         description: plan.description,
         schedule: plan.schedule,
+        timezone: plan.timezone ?? '',
+        jitter: plan.jitter ?? '',
+        catchUp: plan.catchUp,
+        presetId: plan.presetId ?? '',
+        modelId: plan.modelId ?? '',
         enabled: !plan.enabled,
         parameters: plan.parameters ?? {},
         graph: plan.graph,
@@ -65,6 +101,15 @@ export default function PlansPage({ deepPlanId }: { deepPlanId?: string }) {
     }
   }
 
+  const saveAsTemplate = async (plan: Plan) => {
+    try {
+      await api.planTemplates.create(plan.id)
+      toast.success(`Saved "${plan.name}" as a template`)
+    } catch (e: unknown) {
+      toast.error(e instanceof Error ? e.message : 'Save failed')
+    }
+  }
+
   const remove = async (id: string) => {
     try {
       await api.plans.delete(id)
@@ -97,9 +142,20 @@ export default function PlansPage({ deepPlanId }: { deepPlanId?: string }) {
           <h1 className="text-lg font-semibold text-zinc-900 dark:text-zinc-100">Plans</h1>
           <p className="text-xs text-zinc-500 dark:text-zinc-600 mt-0.5">Agentic workflows with actions and decisions</p>
         </div>
-        <Button size="sm" onClick={() => setActivePlan(emptyPlan)}>
-          <Plus size={14} /> New Plan
-        </Button>
+        <div className="flex gap-2">
+          <Button variant="secondary" size="sm" onClick={() => setImportOpen(true)}>
+            <Upload size={14} /> Import
+          </Button>
+          <Button variant="secondary" size="sm" onClick={() => setBlackoutsOpen(true)}>
+            <CalendarOff size={14} /> Blackouts
+          </Button>
+          <Button variant="secondary" size="sm" onClick={() => setTemplatesOpen(true)}>
+            <Layers size={14} /> From Template
+          </Button>
+          <Button size="sm" onClick={() => setActivePlan(emptyPlan)}>
+            <Plus size={14} /> New Plan
+          </Button>
+        </div>
       </div>
 
       {loading ? (
@@ -125,6 +181,19 @@ export default function PlansPage({ deepPlanId }: { deepPlanId?: string }) {
                   <Badge variant="secondary">
                     {plan.graph.nodes.length} nodes
                   </Badge>
+                  <BlackoutBadge blackout={activeBlackout(plan.id)} />
+                  {metrics[plan.id]?.runs > 0 && (
+                    <Badge
+                      variant={successRateVariant(metrics[plan.id])}
+                      title={[
+                        `${metrics[plan.id].completed}/${metrics[plan.id].runs} runs succeeded in the last 30 days`,
+                        ...(metrics[plan.id].slaBreaches ?? []).map(b => `SLA: ${b}`),
+                      ].join('\n')}
+                    >
+                      {Math.round(metrics[plan.id].successRate * 100)}%
+                      {(metrics[plan.id].slaBreaches?.length ?? 0) > 0 && ' · SLA'}
+                    </Badge>
+                  )}
                 </div>
                 <div className="flex gap-0.5 ml-3" onClick={e => e.stopPropagation()}>
                   <Button variant="ghost" size="icon" onClick={e => toggleEnabled(plan, e)}>
@@ -133,6 +202,17 @@ export default function PlansPage({ deepPlanId }: { deepPlanId?: string }) {
                   <Button variant="ghost" size="icon" onClick={() => setActivePlan(plan)}>
                     <Pencil size={14} />
                   </Button>
+                  <Button variant="ghost" size="icon" onClick={() => setHistoryPlan(plan)} title="History">
+                    <History size={14} />
+                  </Button>
+                  <Button variant="ghost" size="icon" onClick={() => saveAsTemplate(plan)} title="Save as template">
+                    <Layers size={14} />
+                  </Button>
+                  <Button variant="ghost" size="icon" asChild title="Export YAML">
+                    <a href={api.plans.exportUrl(plan.id)} download>
+                      <Download size={14} />
+                    </a>
+                  </Button>
                   <Button variant="destructive" size="icon" onClick={() => setDeleteTarget(plan.id)}>
                     <Trash2 size={14} />
                   </Button>
@@ -146,6 +226,18 @@ export default function PlansPage({ deepPlanId }: { deepPlanId?: string }) {
         </div>
       )}
 
+      <PlanImportDialog open={importOpen} onOpenChange={setImportOpen} onImported={() => load()} />
+      <PlanRevisionsDialog plan={historyPlan} onClose={() => setHistoryPlan(null)} />
+      <PlanBlackoutsDialog open={blackoutsOpen} onOpenChange={setBlackoutsOpen} plans={plans} onChanged={loadBlackouts} />
+      <PlanTemplatesDialog
+        open={templatesOpen}
+        onOpenChange={setTemplatesOpen}
+        onCreated={plan => {
+          load()
+          setActivePlan(plan)
+        }}
+      />
+
       <ConfirmDelete
         open={!!deleteTarget}
         onCancel={() => setDeleteTarget(null)}
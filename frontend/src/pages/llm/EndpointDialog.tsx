@@ -42,6 +42,7 @@ export function EndpointDialog({ open, onOpenChange, editing, form, setForm, onS
             >
               <option value="openai">openai</option>
               <option value="gonka">gonka</option>
+              <option value="anthropic">anthropic</option>
             </select>
           </FormField>
           <FormField
@@ -51,7 +52,11 @@ export function EndpointDialog({ open, onOpenChange, editing, form, setForm, onS
             <Input
               value={form.baseUrl}
               onChange={e => setForm(f => ({ ...f, baseUrl: e.target.value }))}
-              placeholder={form.provider === 'gonka' ? 'https://api.gonka.testnet.example.com' : 'https://api.openai.com/v1'}
+              placeholder={
+                form.provider === 'gonka' ? 'https://api.gonka.testnet.example.com'
+                  : form.provider === 'anthropic' ? 'https://api.anthropic.com/v1'
+                  : 'https://api.openai.com/v1'
+              }
             />
           </FormField>
           <FormField
@@ -62,7 +67,7 @@ export function EndpointDialog({ open, onOpenChange, editing, form, setForm, onS
               type="password"
               value={form.apiKey}
               onChange={e => setForm(f => ({ ...f, apiKey: e.target.value }))}
-              placeholder={form.provider === 'gonka' ? '0x…' : 'sk-…'}
+              placeholder={form.provider === 'gonka' ? '0x…' : form.provider === 'anthropic' ? 'sk-ant-…' : 'sk-…'}
             />
           </FormField>
           <DialogFooter>
@@ -38,9 +38,14 @@ export default function LlmPage() {
     name: '',
     connectionId: '',
     thinkingMode: '',
+    toolCalling: '',
+    vision: false,
     contextWindow: String(DEFAULT_CONTEXT_WINDOW),
     reserveTokens: String(DEFAULT_RESERVE_TOKENS),
     compactTokens: '',
+    maxTokens: '',
+    inputPrice: '',
+    outputPrice: '',
   })
   const [loadingAvailableModels, setLoadingAvailableModels] = useState(false)
   const [availableModelsByEndpoint, setAvailableModelsByEndpoint] = useState<Record<string, ProviderModel[]>>({})
@@ -177,9 +182,14 @@ export default function LlmPage() {
       name: '',
       connectionId: selectedConnection,
       thinkingMode: '',
+      toolCalling: '',
+      vision: false,
       contextWindow: String(DEFAULT_CONTEXT_WINDOW),
       reserveTokens: String(DEFAULT_RESERVE_TOKENS),
       compactTokens: '',
+      maxTokens: '',
+      inputPrice: '',
+      outputPrice: '',
     })
     setModelModalOpen(true)
   }
@@ -189,22 +199,35 @@ export default function LlmPage() {
       name: m.name,
       connectionId: m.connectionId,
       thinkingMode: m.thinkingMode,
+      toolCalling: m.toolCalling ?? '',
+      vision: m.vision ?? false,
       contextWindow: String(m.contextWindow || DEFAULT_CONTEXT_WINDOW),
       reserveTokens: String(m.reserveTokens || DEFAULT_RESERVE_TOKENS),
       compactTokens: m.compactTokens ? String(m.compactTokens) : '',
+      maxTokens: m.generation?.maxTokens ? String(m.generation.maxTokens) : '',
+      inputPrice: m.price ? String(m.price.input) : '',
+      outputPrice: m.price ? String(m.price.output) : '',
     })
     setModelModalOpen(true)
   }
   const submitModel = async () => {
     try {
       const parseInt10 = (s: string) => Math.max(0, parseInt(s, 10) || 0)
+      const parsePrice = (s: string) => Math.max(0, parseFloat(s) || 0)
+      const priced = modelForm.inputPrice.trim() !== '' || modelForm.outputPrice.trim() !== ''
       const payload = {
         connectionId: modelForm.connectionId,
         name: modelForm.name,
-        thinkingMode: modelForm.thinkingMode as '' | 'skip' | 'inline',
+        thinkingMode: modelForm.thinkingMode as Model['thinkingMode'],
+        toolCalling: modelForm.toolCalling as Model['toolCalling'],
+        vision: modelForm.vision,
         contextWindow: parseInt10(modelForm.contextWindow),
         reserveTokens: parseInt10(modelForm.reserveTokens),
         compactTokens: parseInt10(modelForm.compactTokens),
+        generation: { ...editingModel?.generation, maxTokens: parseInt10(modelForm.maxTokens) || undefined },
+        price: priced
+          ? { ...editingModel?.price, input: parsePrice(modelForm.inputPrice), output: parsePrice(modelForm.outputPrice) }
+          : undefined,
       }
       if (editingModel) {
         await api.models.update(editingModel.id, payload)
@@ -243,8 +266,20 @@ export default function LlmPage() {
       summaryModelId: p.summaryModelId,
       imageModelId: p.imageModelId,
       fallbackModelId: p.fallbackModelId,
+      fallbackModelIds: p.fallbackModelIds ?? [],
       temperature: p.temperature != null ? String(p.temperature) : '',
       systemPrompt: p.systemPrompt,
+      topP: p.generation?.topP != null ? String(p.generation.topP) : '',
+      maxTokens: p.generation?.maxTokens ? String(p.generation.maxTokens) : '',
+      stop: (p.generation?.stop ?? []).join('\n'),
+      seed: p.generation?.seed != null ? String(p.generation.seed) : '',
+      responseFormat: p.generation?.responseFormat ?? '',
+      toolChoice: p.generation?.toolChoice ?? '',
+      parallelToolCalls: p.generation?.parallelToolCalls == null ? '' : p.generation.parallelToolCalls ? 'true' : 'false',
+      routingEnabled: p.routing?.enabled ?? false,
+      lightModelId: p.routing?.lightModelId ?? '',
+      taskModelId: p.routing?.taskModelId ?? '',
+      escalateAfter: p.routing?.escalateAfter ? String(p.routing.escalateAfter) : '',
     })
     setProfileModalOpen(true)
   }
@@ -256,8 +291,24 @@ export default function LlmPage() {
         summaryModelId: profileForm.summaryModelId,
         imageModelId: profileForm.imageModelId,
         fallbackModelId: profileForm.fallbackModelId,
+        fallbackModelIds: profileForm.fallbackModelId ? profileForm.fallbackModelIds.filter(Boolean) : [],
         temperature: profileForm.temperature ? parseFloat(profileForm.temperature) : null,
         systemPrompt: profileForm.systemPrompt,
+        generation: {
+          topP: profileForm.topP ? parseFloat(profileForm.topP) : undefined,
+          maxTokens: parseInt(profileForm.maxTokens, 10) || undefined,
+          stop: profileForm.stop.split('\n').map(s => s.trim()).filter(Boolean),
+          seed: profileForm.seed ? parseInt(profileForm.seed, 10) : undefined,
+          responseFormat: profileForm.responseFormat || undefined,
+          toolChoice: profileForm.toolChoice.trim() || undefined,
+          parallelToolCalls: profileForm.parallelToolCalls ? profileForm.parallelToolCalls === 'true' : undefined,
+        },
+        routing: {
+          enabled: profileForm.routingEnabled,
+          lightModelId: profileForm.lightModelId || undefined,
+          taskModelId: profileForm.taskModelId || undefined,
+          escalateAfter: parseInt(profileForm.escalateAfter, 10) || undefined,
+        },
       }
       if (editingProfile) {
         await api.presets.update(editingProfile.id, payload)
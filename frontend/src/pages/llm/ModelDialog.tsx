@@ -1,5 +1,6 @@
 import { Button } from '@/components/ui/button'
 import { Input } from '@/components/ui/input'
+import { Checkbox } from '@/components/ui/checkbox'
 import {
   Dialog, DialogContent, DialogDescription, DialogFooter, DialogHeader, DialogTitle,
 } from '@/components/ui/dialog'
@@ -93,8 +94,25 @@ export function ModelDialog({
               <option value="">Keep as-is (default)</option>
               <option value="skip">Remove reasoning blocks</option>
               <option value="inline">Strip tags, keep content</option>
+              <option value="extended">Request extended thinking (Anthropic)</option>
             </select>
           </FormField>
+          <FormField label="Tool calling" hint="For models served without function calling, tools can be described in the system prompt instead">
+            <select
+              value={form.toolCalling}
+              onChange={e => setForm(f => ({ ...f, toolCalling: e.target.value }))}
+              className={SELECT_CLASS}
+            >
+              <option value="">Native function calling (default)</option>
+              <option value="prompt">Tools in the system prompt</option>
+            </select>
+          </FormField>
+          <FormField label="Images" hint="Without this, attached images are described by the vision model first">
+            <label className="flex items-center gap-2 text-sm text-zinc-700 dark:text-zinc-300 cursor-pointer">
+              <Checkbox checked={form.vision} onCheckedChange={v => setForm(f => ({ ...f, vision: !!v }))} />
+              The model takes images directly
+            </label>
+          </FormField>
           <FormField label="Context window (tokens)" hint="Model's maximum context length">
             <Input
               type="number"
@@ -125,6 +143,36 @@ export function ModelDialog({
               placeholder="auto"
             />
           </FormField>
+          <FormField label="Max output tokens" hint="Default reply length limit; profiles can override it. Empty = provider default">
+            <Input
+              type="number"
+              min={0}
+              step={256}
+              value={form.maxTokens}
+              onChange={e => setForm(f => ({ ...f, maxTokens: e.target.value }))}
+              placeholder="provider default"
+            />
+          </FormField>
+          <FormField label="Price, USD per 1M tokens" hint="Input and output prices used to cost calls in the usage ledger. Empty = not costed">
+            <div className="flex gap-2">
+              <Input
+                type="number"
+                min={0}
+                step={0.01}
+                value={form.inputPrice}
+                onChange={e => setForm(f => ({ ...f, inputPrice: e.target.value }))}
+                placeholder="input"
+              />
+              <Input
+                type="number"
+                min={0}
+                step={0.01}
+                value={form.outputPrice}
+                onChange={e => setForm(f => ({ ...f, outputPrice: e.target.value }))}
+                placeholder="output"
+              />
+            </div>
+          </FormField>
           <DialogFooter>
             <Button variant="secondary" onClick={() => onOpenChange(false)}>Cancel</Button>
             <Button onClick={onSubmit} disabled={!form.name || !form.connectionId}>
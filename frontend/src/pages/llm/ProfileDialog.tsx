@@ -1,10 +1,12 @@
 import { Button } from '@/components/ui/button'
 import { Input } from '@/components/ui/input'
+import { Checkbox } from '@/components/ui/checkbox'
 import { Textarea } from '@/components/ui/textarea'
 import {
   Dialog, DialogContent, DialogDescription, DialogFooter, DialogHeader, DialogTitle,
 } from '@/components/ui/dialog'
 import { FormField } from '@/components/FormField'
+import { Plus, X } from '@/lib/icons'
 import type { LlmConnection, Model, Preset } from '@/types'
 import type { ProfileForm } from './types'
 import { ROLE_DEFS, SELECT_CLASS } from './types'
@@ -21,6 +23,16 @@ interface Props {
 }
 
 export function ProfileDialog({ open, onOpenChange, editing, form, setForm, onSubmit, models, endpointById }: Props) {
+  const modelOptions = models.map(m => {
+    const ep = endpointById.get(m.connectionId)
+    return (
+      <option key={m.id} value={m.id}>
+        {m.name}{ep ? ` · ${ep.id}` : ''}
+      </option>
+    )
+  })
+  const setChain = (chain: string[]) => setForm(f => ({ ...f, fallbackModelIds: chain }))
+
   return (
     <Dialog open={open} onOpenChange={onOpenChange}>
       <DialogContent className="max-h-[85vh] overflow-y-auto sm:max-w-xl">
@@ -55,20 +67,95 @@ export function ProfileDialog({ open, onOpenChange, editing, form, setForm, onSu
                       className={SELECT_CLASS}
                     >
                       <option value="">{required ? 'Select a model…' : emptyLabel}</option>
-                      {models.map(m => {
-                        const ep = endpointById.get(m.connectionId)
-                        return (
-                          <option key={m.id} value={m.id}>
-                            {m.name}{ep ? ` · ${ep.id}` : ''}
-                          </option>
-                        )
-                      })}
+                      {modelOptions}
                     </select>
                     <div />
                     <p className="text-[11px] text-zinc-500 dark:text-zinc-600">{hint}</p>
                   </div>
                 )
               })}
+              {form.fallbackModelId && (
+                <div className="px-3 py-3 grid grid-cols-[7.5rem_1fr] gap-x-3 gap-y-2 items-center">
+                  {form.fallbackModelIds.map((id, i) => (
+                    <div key={i} className="contents">
+                      <span className="text-xs text-zinc-500">Then</span>
+                      <div className="flex items-center gap-1">
+                        <select
+                          value={id}
+                          onChange={e => setChain(form.fallbackModelIds.map((x, j) => j === i ? e.target.value : x))}
+                          className={SELECT_CLASS}
+                        >
+                          <option value="">Select a model…</option>
+                          {modelOptions}
+                        </select>
+                        <Button
+                          variant="ghost" size="icon" className="h-8 w-8 shrink-0 text-zinc-400 hover:text-red-500"
+                          onClick={() => setChain(form.fallbackModelIds.filter((_, j) => j !== i))}
+                          title="Remove fallback"
+                        >
+                          <X size={12} />
+                        </Button>
+                      </div>
+                    </div>
+                  ))}
+                  <div />
+                  <div>
+                    <Button variant="ghost" size="sm" onClick={() => setChain([...form.fallbackModelIds, ''])}>
+                      <Plus size={12} /> Add fallback
+                    </Button>
+                    <p className="text-[11px] text-zinc-500 dark:text-zinc-600 mt-1">
+                      Tried in order; a provider that keeps failing is skipped for a minute.
+                    </p>
+                  </div>
+                </div>
+              )}
+            </div>
+          </div>
+
+          <div>
+            <div className="text-xs font-semibold text-zinc-700 dark:text-zinc-300 mb-2">Routing</div>
+            <p className="text-[11px] text-zinc-500 dark:text-zinc-600 mb-2">
+              Sends trivial chat and single server tasks to cheaper models; investigations stay on the chat model.
+            </p>
+            <div className="space-y-3">
+              <label className="flex items-center gap-2 text-sm text-zinc-700 dark:text-zinc-300 cursor-pointer">
+                <Checkbox checked={form.routingEnabled} onCheckedChange={v => setForm(f => ({ ...f, routingEnabled: !!v }))} />
+                Route each message by what it asks
+              </label>
+              {form.routingEnabled && (
+                <>
+                  <div className="grid grid-cols-2 gap-3">
+                    <FormField label="Light model" hint="Trivial chat · empty = task model">
+                      <select
+                        value={form.lightModelId}
+                        onChange={e => setForm(f => ({ ...f, lightModelId: e.target.value }))}
+                        className={SELECT_CLASS}
+                      >
+                        <option value="">Not set</option>
+                        {modelOptions}
+                      </select>
+                    </FormField>
+                    <FormField label="Task model" hint="One server task · empty = chat model">
+                      <select
+                        value={form.taskModelId}
+                        onChange={e => setForm(f => ({ ...f, taskModelId: e.target.value }))}
+                        className={SELECT_CLASS}
+                      >
+                        <option value="">Not set</option>
+                        {modelOptions}
+                      </select>
+                    </FormField>
+                  </div>
+                  <FormField label="Escalate after failed tool rounds" hint="Moves to the chat model · empty = never">
+                    <Input
+                      type="number" min="0" step="1"
+                      value={form.escalateAfter}
+                      onChange={e => setForm(f => ({ ...f, escalateAfter: e.target.value }))}
+                      placeholder="e.g. 2"
+                    />
+                  </FormField>
+                </>
+              )}
             </div>
           </div>
 
@@ -94,6 +181,77 @@ export function ProfileDialog({ open, onOpenChange, editing, form, setForm, onSu
             </div>
           </div>
 
+          <div>
+            <div className="text-xs font-semibold text-zinc-700 dark:text-zinc-300 mb-2">Generation</div>
+            <p className="text-[11px] text-zinc-500 dark:text-zinc-600 mb-2">
+              Overrides the model's defaults. Empty fields keep them; providers ignore what they do not support.
+            </p>
+            <div className="space-y-3">
+              <div className="grid grid-cols-2 gap-3">
+                <FormField label="Top P" hint="0–1 · empty = model default">
+                  <Input
+                    type="number" step="0.05" min="0" max="1"
+                    value={form.topP}
+                    onChange={e => setForm(f => ({ ...f, topP: e.target.value }))}
+                    placeholder="e.g. 0.9"
+                  />
+                </FormField>
+                <FormField label="Max output tokens" hint="Empty = model default">
+                  <Input
+                    type="number" step="256" min="0"
+                    value={form.maxTokens}
+                    onChange={e => setForm(f => ({ ...f, maxTokens: e.target.value }))}
+                    placeholder="e.g. 4096"
+                  />
+                </FormField>
+                <FormField label="Seed" hint="Same seed, same answer where supported">
+                  <Input
+                    type="number" step="1"
+                    value={form.seed}
+                    onChange={e => setForm(f => ({ ...f, seed: e.target.value }))}
+                    placeholder="random"
+                  />
+                </FormField>
+                <FormField label="Response format">
+                  <select
+                    value={form.responseFormat}
+                    onChange={e => setForm(f => ({ ...f, responseFormat: e.target.value as ProfileForm['responseFormat'] }))}
+                    className={SELECT_CLASS}
+                  >
+                    <option value="">Model default</option>
+                    <option value="text">Text</option>
+                    <option value="json_object">JSON object</option>
+                  </select>
+                </FormField>
+                <FormField label="Tool choice" hint="auto, none, required or a tool name">
+                  <Input
+                    value={form.toolChoice}
+                    onChange={e => setForm(f => ({ ...f, toolChoice: e.target.value }))}
+                    placeholder="auto"
+                  />
+                </FormField>
+                <FormField label="Parallel tool calls">
+                  <select
+                    value={form.parallelToolCalls}
+                    onChange={e => setForm(f => ({ ...f, parallelToolCalls: e.target.value as ProfileForm['parallelToolCalls'] }))}
+                    className={SELECT_CLASS}
+                  >
+                    <option value="">Model default</option>
+                    <option value="true">Allowed</option>
+                    <option value="false">One call at a time</option>
+                  </select>
+                </FormField>
+              </div>
+              <FormField label="Stop sequences" hint="One per line. Generation stops before any of them.">
+                <Textarea
+                  value={form.stop}
+                  onChange={e => setForm(f => ({ ...f, stop: e.target.value }))}
+                  className="h-16"
+                />
+              </FormField>
+            </div>
+          </div>
+
           <DialogFooter>
             <Button variant="secondary" onClick={() => onOpenChange(false)}>Cancel</Button>
             <Button onClick={onSubmit} disabled={!form.name || !form.chatModelId}>
@@ -6,9 +6,14 @@ export type ModelForm = {
   name: string
   connectionId: string
   thinkingMode: string
+  toolCalling: string
+  vision: boolean
   contextWindow: string
   reserveTokens: string
   compactTokens: string
+  maxTokens: string
+  inputPrice: string
+  outputPrice: string
 }
 
 export type ProfileForm = {
@@ -17,8 +22,20 @@ export type ProfileForm = {
   summaryModelId: string
   imageModelId: string
   fallbackModelId: string
+  fallbackModelIds: string[]
   temperature: string
   systemPrompt: string
+  topP: string
+  maxTokens: string
+  stop: string
+  seed: string
+  responseFormat: '' | 'text' | 'json_object'
+  toolChoice: string
+  parallelToolCalls: '' | 'true' | 'false'
+  routingEnabled: boolean
+  lightModelId: string
+  taskModelId: string
+  escalateAfter: string
 }
 
 export type RoleKey = 'chat' | 'summary' | 'image' | 'fallback'
@@ -41,8 +58,20 @@ export const EMPTY_PROFILE_FORM: ProfileForm = {
   summaryModelId: '',
   imageModelId: '',
   fallbackModelId: '',
+  fallbackModelIds: [],
   temperature: '',
   systemPrompt: '',
+  topP: '',
+  maxTokens: '',
+  stop: '',
+  seed: '',
+  responseFormat: '',
+  toolChoice: '',
+  parallelToolCalls: '',
+  routingEnabled: false,
+  lightModelId: '',
+  taskModelId: '',
+  escalateAfter: '',
 }
 
 export const SELECT_CLASS =
@@ -52,7 +81,7 @@ export const ROLE_DEFS: RoleDef[] = [
   { key: 'chat',     field: 'chatModelId',     icon: MessageSquare, label: 'Chat',     hint: 'Main conversations and tool use', required: true },
   { key: 'summary',  field: 'summaryModelId',  icon: FileText,      label: 'Summary',  hint: 'Memory extraction and session titles' },
   { key: 'image',    field: 'imageModelId',    icon: Eye,           label: 'Vision',   hint: 'Used when a user sends an image' },
-  { key: 'fallback', field: 'fallbackModelId', icon: RotateCcw,     label: 'Fallback', hint: 'Used when the chat model errors out' },
+  { key: 'fallback', field: 'fallbackModelId', icon: RotateCcw,     label: 'Fallback', hint: 'Takes over when the chat model fails (5xx, 429, timeouts, empty balance)' },
 ]
 
 export const ROUTING_DEFS: { key: 'chatPresetId' | 'serverPresetId'; label: string; hint: string }[] = [
@@ -74,14 +74,36 @@ export interface Settings {
   userMemories: string[]
 }
 
+export interface GenerationOptions {
+  temperature?: number
+  topP?: number
+  maxTokens?: number
+  stop?: string[]
+  seed?: number
+  toolChoice?: string
+  responseFormat?: 'text' | 'json_object'
+  parallelToolCalls?: boolean
+}
+
 export interface Model {
   id: string
   connectionId: string
   name: string
-  thinkingMode: '' | 'skip' | 'inline'
+  thinkingMode: '' | 'skip' | 'inline' | 'extended'
+  toolCalling?: '' | 'native' | 'prompt'
+  vision?: boolean
   contextWindow: number
   reserveTokens: number
   compactTokens: number
+  generation?: GenerationOptions
+  price?: ModelPrice
+}
+
+export interface ModelPrice {
+  input: number
+  output: number
+  cachedInput?: number
+  cacheWrite?: number
 }
 
 export interface Preset {
@@ -91,8 +113,18 @@ export interface Preset {
   summaryModelId: string
   imageModelId: string
   fallbackModelId: string
+  fallbackModelIds?: string[]
   temperature: number | null
   systemPrompt: string
+  generation?: GenerationOptions
+  routing?: PresetRouting
+}
+
+export interface PresetRouting {
+  enabled: boolean
+  lightModelId?: string
+  taskModelId?: string
+  escalateAfter?: number
 }
 
 export interface Memory {
@@ -145,16 +177,28 @@ export interface PlanNodePosition {
   y: number
 }
 
+export type PlanNodeType = 'action' | 'decision' | 'approval' | 'foreach'
+
 export interface PlanNode {
   id: string
-  type: 'action' | 'decision'
+  type: PlanNodeType
   label: string
   prompt: string
   position: PlanNodePosition
   clearContext?: boolean
   maxRetries?: number
+  timeout?: string
+  retryDelay?: string
+  retryOn?: PlanRetryOn[]
+  onTimeout?: 'approve' | 'reject'
+  items?: string
+  parallelism?: number
+  presetId?: string
+  modelId?: string
 }
 
+export type PlanRetryOn = 'timeout' | 'error' | 'stopped' | 'reported'
+
 export interface PlanEdge {
   id: string
   source: string
@@ -167,6 +211,28 @@ export interface PlanGraph {
   edges: PlanEdge[]
 }
 
+export interface PlanWebhook {
+  enabled: boolean
+  secret?: string
+  mapping?: Record<string, string>
+  idempotencyKey?: string
+}
+
+export type PlanConcurrency = 'allow' | 'skip' | 'queue' | 'cancel_previous'
+export type PlanCatchUp = 'skip' | 'run_once' | 'run_all'
+export type PlanNotifyWhen = 'success' | 'failure' | 'always'
+
+export interface PlanNotify {
+  when: PlanNotifyWhen | ''
+  channelId?: string
+  recipient?: string
+}
+
+export interface PlanSLA {
+  maxDuration?: string
+  maxConsecutiveFailures?: number
+}
+
 export interface Plan {
   id: string
   name: string
@@ -175,26 +241,161 @@ export interface Plan {
   enabled: boolean
   parameters: Record<string, unknown>
   graph: PlanGraph
+  timezone?: string
+  jitter?: string
+  catchUp?: PlanCatchUp
+  concurrency?: PlanConcurrency
+  webhook?: PlanWebhook
+  notify?: PlanNotify
+  sla?: PlanSLA
+  presetId?: string
+  modelId?: string
+}
+
+export interface PlanTemplate {
+  id: string
+  name: string
+  description: string
+  builtin: boolean
+  plan: Plan
+  createdBy?: string
+  createdAt?: string
+}
+
+export interface PlanTemplateInstance {
+  name?: string
+  schedule?: string
+  timezone?: string
+  enabled?: boolean
+  parameters?: Record<string, unknown>
+}
+
+export interface PlanTokenUsage {
+  prompt: number
+  completion: number
+  total: number
+}
+
+export interface PlanNodeMetrics {
+  nodeId: string
+  label: string
+  runs: number
+  failures: number
+  retries: number
+  durationP50Ms: number
+  durationP95Ms: number
+  tokens: PlanTokenUsage
+}
+
+export interface PlanMetrics {
+  planId: string
+  planName: string
+  since: string
+  runs: number
+  completed: number
+  failed: number
+  cancelled: number
+  successRate: number
+  consecutiveFailures: number
+  durationP50Ms: number
+  durationP95Ms: number
+  retries: number
+  mostFailingNode?: string
+  tokens: PlanTokenUsage
+  nodes: PlanNodeMetrics[]
+  sla?: PlanSLA
+  slaBreaches?: string[]
+  lastRunAt?: string
+}
+
+export interface PlanRevision {
+  id: string
+  planId: string
+  version: number
+  plan: Plan
+  createdBy?: string
+  createdAt: string
+}
+
+export type PlanBlackoutAction = 'skip' | 'defer'
+
+export interface PlanBlackout {
+  id: string
+  name: string
+  planId?: string
+  enabled: boolean
+  action: PlanBlackoutAction
+  startsAt?: string
+  endsAt?: string
+  schedule?: string
+  duration?: string
+  timezone?: string
+  createdAt?: string
+  activeUntil?: string
+}
+
+export type PlanBlackoutInput = Omit<PlanBlackout, 'id' | 'createdAt' | 'activeUntil'>
+
+export interface PlanValidation {
+  valid: boolean
+  errors: string[]
 }
 
-export type PlanRunStatus = 'running' | 'completed' | 'failed' | 'cancelled' | 'paused'
-export type PlanStepStatus = 'pending' | 'running' | 'completed' | 'failed' | 'skipped'
+export type PlanRunStatus = 'queued' | 'deferred' | 'running' | 'waiting' | 'completed' | 'failed' | 'cancelled' | 'skipped' | 'paused'
+export type PlanStepStatus = 'pending' | 'running' | 'waiting' | 'completed' | 'failed' | 'skipped'
+
+export interface PlanApproval {
+  message: string
+  context?: string
+  expiresAt: string
+  decision?: 'approve' | 'reject'
+  resolvedBy?: string
+  comment?: string
+  resolvedAt?: string
+}
 
 export interface PlanStepRun {
   nodeId: string
   status: PlanStepStatus
   result?: string
   messageId?: string
+  approval?: PlanApproval
+  simulatedCalls?: SimulatedCall[]
+  attempts?: number
+  items?: PlanItemRun[]
+  modelId?: string
+  modelName?: string
+  presetName?: string
+  startedAt?: string
+  finishedAt?: string
+}
+
+export interface PlanItemRun {
+  index: number
+  item: unknown
+  sessionId?: string
+  status: PlanStepStatus | 'cancelled'
+  result?: string
+  steps?: PlanStepRun[]
   startedAt?: string
   finishedAt?: string
 }
 
+export interface SimulatedCall {
+  tool: string
+  args: string
+  result: string
+}
+
 export interface PlanRun {
   id: string
   planId: string
   status: PlanRunStatus
-  trigger: 'manual' | 'schedule'
+  trigger: string
   input: Record<string, unknown>
+  idempotencyKey?: string
+  mocks?: Record<string, string>
+  reason?: string
   steps: PlanStepRun[]
   startedAt: string
   finishedAt?: string
@@ -298,6 +499,8 @@ export interface ChatMessage {
   presetId?: string
   presetName?: string
   modelRole?: string
+  routeTier?: 'light' | 'task' | 'flagship'
+  routeReason?: string
   steps?: Step[]
   attachments?: Attachment[]
   createdAt: string
@@ -2,6 +2,7 @@ package channel
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"html"
 	"sort"
@@ -132,6 +133,16 @@ func (s *TelegramResponseTo) SendQuote(ctx context.Context, text string) {
 	_ = s.newTG().SendMessage(ctx, chatID, text)
 }
 
+// SendWithMarkup sends a single message with an inline keyboard or other
+// reply markup attached.
+func (s *TelegramResponseTo) SendWithMarkup(ctx context.Context, text string, markup json.RawMessage) error {
+	chatID, err := strconv.ParseInt(s.recipient, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid telegram recipient/chat id %q: %w", s.recipient, err)
+	}
+	return s.newTG().sendMessageWithMarkup(ctx, chatID, text, markup)
+}
+
 func (s *TelegramResponseTo) newTG() *Telegram {
 	noop := func(context.Context, string, string, []FileAttachment) (Reply, error) {
 		return Reply{}, nil
@@ -114,6 +114,8 @@ func (t *Telegram) setMyCommands(ctx context.Context) error {
 			{"command": "model", "description": "Switch model"},
 			{"command": "reset", "description": "Reset chat context"},
 			{"command": "voice", "description": "Read last message aloud"},
+			{"command": "approve", "description": "Approve a waiting plan run"},
+			{"command": "reject", "description": "Reject a waiting plan run"},
 		},
 	})
 	req, err := http.NewRequestWithContext(ctx, "POST", url, strings.NewReader(string(payload)))
@@ -167,6 +169,11 @@ func (t *Telegram) handleCallback(ctx context.Context, cq *tgCallbackQuery) {
 			text = "/model " + id
 		}
 	}
+	if rest, ok := strings.CutPrefix(data, "plan:"); ok {
+		if action, runID, ok := strings.Cut(rest, ":"); ok && (action == "approve" || action == "reject") && runID != "" {
+			text = "/" + action + " " + runID
+		}
+	}
 	if text == "" {
 		return
 	}
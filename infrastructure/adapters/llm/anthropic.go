@@ -0,0 +1,495 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"mantis/core/protocols"
+	"mantis/core/types"
+	"mantis/shared"
+)
+
+const (
+	anthropicDefaultBaseURL = "https://api.anthropic.com/v1"
+	anthropicVersion        = "2023-06-01"
+	anthropicMaxTokens      = 8192
+
+	// anthropicThinkingBudget is the reasoning budget for models whose
+	// ThinkingMode is "extended"; max_tokens grows by it.
+	anthropicThinkingBudget = 8192
+)
+
+// Anthropic talks to the native Messages API, keeping tool use, extended
+// thinking and prompt caching that OpenAI-compatible proxies drop.
+type Anthropic struct {
+	client *http.Client
+}
+
+func NewAnthropic() *Anthropic {
+	transport := &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout: 10 * time.Second,
+		}).DialContext,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ResponseHeaderTimeout: 60 * time.Second,
+	}
+	return &Anthropic{client: &http.Client{Transport: transport}}
+}
+
+type anthropicReq struct {
+	Model         string               `json:"model"`
+	MaxTokens     int                  `json:"max_tokens"`
+	System        []anthropicBlock     `json:"system,omitempty"`
+	Messages      []anthropicMessage   `json:"messages"`
+	Tools         []anthropicTool      `json:"tools,omitempty"`
+	ToolChoice    *anthropicToolChoice `json:"tool_choice,omitempty"`
+	Thinking      *anthropicThinking   `json:"thinking,omitempty"`
+	Temperature   *float64             `json:"temperature,omitempty"`
+	TopP          *float64             `json:"top_p,omitempty"`
+	StopSequences []string             `json:"stop_sequences,omitempty"`
+	Stream        bool                 `json:"stream"`
+}
+
+type anthropicToolChoice struct {
+	Type                   string `json:"type"`
+	Name                   string `json:"name,omitempty"`
+	DisableParallelToolUse bool   `json:"disable_parallel_tool_use,omitempty"`
+}
+
+type anthropicThinking struct {
+	Type         string `json:"type"`
+	BudgetTokens int    `json:"budget_tokens"`
+}
+
+type anthropicMessage struct {
+	Role    string           `json:"role"`
+	Content []anthropicBlock `json:"content"`
+}
+
+type anthropicBlock struct {
+	Type         string          `json:"type"`
+	Text         string          `json:"text,omitempty"`
+	Thinking     string          `json:"thinking,omitempty"`
+	Signature    string          `json:"signature,omitempty"`
+	Data         string          `json:"data,omitempty"`
+	ID           string          `json:"id,omitempty"`
+	Name         string          `json:"name,omitempty"`
+	Input        json.RawMessage `json:"input,omitempty"`
+	ToolUseID    string          `json:"tool_use_id,omitempty"`
+	Content      string          `json:"content,omitempty"`
+	Source       *imageSource    `json:"source,omitempty"`
+	CacheControl *cacheControl   `json:"cache_control,omitempty"`
+}
+
+type imageSource struct {
+	Type      string `json:"type"`
+	MediaType string `json:"media_type"`
+	Data      string `json:"data"`
+}
+
+type cacheControl struct {
+	Type string `json:"type"`
+}
+
+type anthropicTool struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	InputSchema map[string]any `json:"input_schema"`
+}
+
+type anthropicUsage struct {
+	InputTokens              int `json:"input_tokens"`
+	OutputTokens             int `json:"output_tokens"`
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
+	CacheReadInputTokens     int `json:"cache_read_input_tokens"`
+}
+
+type anthropicEvent struct {
+	Type    string `json:"type"`
+	Index   int    `json:"index"`
+	Message *struct {
+		Usage anthropicUsage `json:"usage"`
+	} `json:"message"`
+	ContentBlock *anthropicBlock `json:"content_block"`
+	Delta        *struct {
+		Type        string `json:"type"`
+		Text        string `json:"text"`
+		Thinking    string `json:"thinking"`
+		Signature   string `json:"signature"`
+		PartialJSON string `json:"partial_json"`
+		StopReason  string `json:"stop_reason"`
+	} `json:"delta"`
+	Usage *anthropicUsage `json:"usage"`
+	Error *struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func anthropicBaseURL(baseURL string) string {
+	base := strings.TrimRight(strings.TrimSpace(baseURL), "/")
+	if base == "" {
+		return anthropicDefaultBaseURL
+	}
+	return base
+}
+
+func (a *Anthropic) newRequest(ctx context.Context, method, url, apiKey string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("anthropic-version", anthropicVersion)
+	if strings.TrimSpace(apiKey) != "" {
+		req.Header.Set("x-api-key", apiKey)
+	}
+	return req, nil
+}
+
+func (a *Anthropic) ListModels(ctx context.Context, baseURL, apiKey string) ([]types.ProviderModel, error) {
+	req, err := a.newRequest(ctx, "GET", anthropicBaseURL(baseURL)+"/models?limit=1000", apiKey, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("LLM models API error %d: %s", resp.StatusCode, string(b))
+	}
+	var payload modelsResp
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return nil, err
+	}
+	items := make([]types.ProviderModel, 0, len(payload.Data))
+	for _, m := range payload.Data {
+		if m.ID == "" {
+			continue
+		}
+		items = append(items, types.ProviderModel{ID: m.ID})
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].ID < items[j].ID })
+	return items, nil
+}
+
+func (a *Anthropic) GetInferenceLimit(_ context.Context, _ string, _ string) (types.InferenceLimit, error) {
+	return types.InferenceLimit{
+		Type:  "unlimited",
+		Label: "No inference limit reported",
+	}, nil
+}
+
+func (a *Anthropic) ChatStream(ctx context.Context, _ string, baseURL, apiKey string, messages []protocols.LLMMessage, model string, tools []types.Tool, thinkingMode string, opts types.GenerationOptions) (<-chan types.StreamEvent, error) {
+	system, msgs := buildAnthropicMessages(messages)
+	payload := anthropicReq{
+		Model:     model,
+		MaxTokens: anthropicMaxTokens,
+		System:    system,
+		Messages:  msgs,
+		Tools:     buildAnthropicTools(tools),
+		Stream:    true,
+	}
+	applyAnthropicGenerationOptions(&payload, opts)
+	if thinkingMode == "extended" {
+		payload.Thinking = &anthropicThinking{Type: "enabled", BudgetTokens: anthropicThinkingBudget}
+		payload.MaxTokens += anthropicThinkingBudget
+		// Sampling parameters are fixed while thinking is enabled.
+		payload.Temperature, payload.TopP = nil, nil
+	}
+	body, _ := json.Marshal(payload)
+
+	url := anthropicBaseURL(baseURL) + "/messages"
+	req, err := a.newRequest(ctx, "POST", url, apiKey, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "text/event-stream")
+
+	log.Printf("LLM stream request: POST %s model=%s messages=%d tools=%d", url, model, len(messages), len(tools))
+	resp, err := a.client.Do(req)
+	if err != nil {
+		log.Printf("LLM stream connect error: %v", err)
+		return nil, err
+	}
+	log.Printf("LLM stream response: status=%d", resp.StatusCode)
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		return nil, fmt.Errorf("LLM API error %d: %s", resp.StatusCode, string(b))
+	}
+
+	ch := make(chan types.StreamEvent, 32)
+	go func() {
+		defer close(ch)
+		defer resp.Body.Close()
+		readAnthropicStream(resp.Body, ch)
+	}()
+
+	// Extended thinking streams thinking and text deltas as they arrive;
+	// ApplyThinkingStream would hold the text back until the end.
+	if thinkingMode == "extended" {
+		return ch, nil
+	}
+	return shared.ApplyThinkingStream(ch, thinkingMode), nil
+}
+
+// readAnthropicStream turns Messages API server-sent events into stream
+// events: text and thinking deltas as they arrive, each finished thinking
+// block with its signature, then usage and the collected tool calls.
+func readAnthropicStream(body io.Reader, ch chan<- types.StreamEvent) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 1024*1024), 1024*1024)
+	seq := 0
+	emit := func(ev types.StreamEvent) {
+		ev.Sequence = seq
+		seq++
+		ch <- ev
+	}
+
+	blocks := map[int]*anthropicBlock{}
+	args := map[int]*strings.Builder{}
+	var toolOrder []int
+	var usage anthropicUsage
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+		var ev anthropicEvent
+		if err := json.Unmarshal([]byte(strings.TrimSpace(strings.TrimPrefix(line, "data:"))), &ev); err != nil {
+			continue
+		}
+		switch ev.Type {
+		case "message_start":
+			if ev.Message != nil {
+				usage = ev.Message.Usage
+			}
+		case "content_block_start":
+			if ev.ContentBlock == nil {
+				continue
+			}
+			block := *ev.ContentBlock
+			blocks[ev.Index] = &block
+			if block.Type == "tool_use" {
+				args[ev.Index] = &strings.Builder{}
+				toolOrder = append(toolOrder, ev.Index)
+			}
+		case "content_block_delta":
+			block, ok := blocks[ev.Index]
+			if !ok || ev.Delta == nil {
+				continue
+			}
+			switch ev.Delta.Type {
+			case "text_delta":
+				if ev.Delta.Text != "" {
+					emit(types.StreamEvent{Type: "text", Delta: ev.Delta.Text})
+				}
+			case "thinking_delta":
+				block.Thinking += ev.Delta.Thinking
+				if ev.Delta.Thinking != "" {
+					emit(types.StreamEvent{Type: "thinking", Delta: ev.Delta.Thinking})
+				}
+			case "signature_delta":
+				block.Signature += ev.Delta.Signature
+			case "input_json_delta":
+				if b, ok := args[ev.Index]; ok {
+					b.WriteString(ev.Delta.PartialJSON)
+				}
+			}
+		case "content_block_stop":
+			block, ok := blocks[ev.Index]
+			if !ok {
+				continue
+			}
+			switch block.Type {
+			case "thinking":
+				emit(types.StreamEvent{Type: "thinking_block", Thinking: &types.ThinkingBlock{Text: block.Thinking, Signature: block.Signature}})
+			case "redacted_thinking":
+				emit(types.StreamEvent{Type: "thinking_block", Thinking: &types.ThinkingBlock{Redacted: block.Data}})
+			}
+		case "message_delta":
+			if ev.Usage != nil {
+				usage.OutputTokens = ev.Usage.OutputTokens
+			}
+		case "error":
+			msg := "stream error"
+			if ev.Error != nil {
+				msg = ev.Error.Type + ": " + ev.Error.Message
+			}
+			emit(types.StreamEvent{Type: "error", Delta: "LLM API error: " + msg, IsFinal: true})
+			return
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		log.Printf("LLM stream read error: %v", err)
+		emit(types.StreamEvent{Type: "error", Delta: err.Error(), IsFinal: true})
+		return
+	}
+
+	prompt := usage.InputTokens + usage.CacheCreationInputTokens + usage.CacheReadInputTokens
+	if prompt > 0 || usage.OutputTokens > 0 {
+		emit(types.StreamEvent{Type: "usage", Usage: &types.LLMUsage{
+			PromptTokens:     prompt,
+			CompletionTokens: usage.OutputTokens,
+			TotalTokens:      prompt + usage.OutputTokens,
+			CachedTokens:     usage.CacheReadInputTokens,
+			CacheWriteTokens: usage.CacheCreationInputTokens,
+		}})
+	}
+	if len(toolOrder) > 0 {
+		calls := make([]types.ToolCall, 0, len(toolOrder))
+		for _, i := range toolOrder {
+			arguments := strings.TrimSpace(args[i].String())
+			if arguments == "" {
+				arguments = "{}"
+			}
+			calls = append(calls, types.ToolCall{ID: blocks[i].ID, Name: blocks[i].Name, Arguments: arguments})
+		}
+		emit(types.StreamEvent{Type: "tool_calls", ToolCalls: calls, IsFinal: true})
+	}
+}
+
+// buildAnthropicMessages splits off the system prompt, which gets the cache
+// breakpoint, and maps the rest onto alternating user and assistant turns:
+// tool results become tool_result blocks of the next user turn.
+func buildAnthropicMessages(messages []protocols.LLMMessage) ([]anthropicBlock, []anthropicMessage) {
+	var system []string
+	var out []anthropicMessage
+	add := func(role string, blocks ...anthropicBlock) {
+		if len(blocks) == 0 {
+			return
+		}
+		if n := len(out); n > 0 && out[n-1].Role == role {
+			out[n-1].Content = append(out[n-1].Content, blocks...)
+			return
+		}
+		out = append(out, anthropicMessage{Role: role, Content: blocks})
+	}
+
+	for _, m := range messages {
+		switch m.Role {
+		case "system":
+			if strings.TrimSpace(m.Content) != "" {
+				system = append(system, m.Content)
+			}
+		case "assistant":
+			var blocks []anthropicBlock
+			for _, t := range m.Thinking {
+				if t.Redacted != "" {
+					blocks = append(blocks, anthropicBlock{Type: "redacted_thinking", Data: t.Redacted})
+				} else {
+					blocks = append(blocks, anthropicBlock{Type: "thinking", Thinking: t.Text, Signature: t.Signature})
+				}
+			}
+			if strings.TrimSpace(m.Content) != "" {
+				blocks = append(blocks, anthropicBlock{Type: "text", Text: m.Content})
+			}
+			for _, tc := range m.ToolCalls {
+				input := json.RawMessage(tc.Arguments)
+				if !json.Valid(input) || !strings.HasPrefix(strings.TrimSpace(tc.Arguments), "{") {
+					input = json.RawMessage("{}")
+				}
+				blocks = append(blocks, anthropicBlock{Type: "tool_use", ID: tc.ID, Name: tc.Name, Input: input})
+			}
+			add("assistant", blocks...)
+		case "tool":
+			add("user", anthropicBlock{Type: "tool_result", ToolUseID: m.ToolCallID, Content: m.Content})
+		default:
+			if len(m.Parts) > 0 {
+				add("user", anthropicContentBlocks(m.Parts)...)
+			} else if strings.TrimSpace(m.Content) != "" {
+				add("user", anthropicBlock{Type: "text", Text: m.Content})
+			}
+		}
+	}
+
+	if len(system) == 0 {
+		return nil, out
+	}
+	return []anthropicBlock{{
+		Type:         "text",
+		Text:         strings.Join(system, "\n\n"),
+		CacheControl: &cacheControl{Type: "ephemeral"},
+	}}, out
+}
+
+func anthropicContentBlocks(parts []types.ContentPart) []anthropicBlock {
+	var blocks []anthropicBlock
+	for _, p := range parts {
+		switch p.Type {
+		case types.ContentPartText:
+			if strings.TrimSpace(p.Text) != "" {
+				blocks = append(blocks, anthropicBlock{Type: "text", Text: p.Text})
+			}
+		case types.ContentPartImage:
+			blocks = append(blocks, anthropicBlock{Type: "image", Source: &imageSource{
+				Type: "base64", MediaType: p.MIME, Data: base64.StdEncoding.EncodeToString(p.Data),
+			}})
+		}
+	}
+	return blocks
+}
+
+// applyAnthropicGenerationOptions maps opts onto the Messages API. Seed and
+// response format have no counterpart there and are dropped.
+func applyAnthropicGenerationOptions(payload *anthropicReq, opts types.GenerationOptions) {
+	if opts.MaxTokens > 0 {
+		payload.MaxTokens = opts.MaxTokens
+	}
+	payload.Temperature = opts.Temperature
+	payload.TopP = opts.TopP
+	payload.StopSequences = opts.Stop
+	if len(payload.Tools) == 0 {
+		return
+	}
+	choice := &anthropicToolChoice{Type: "auto"}
+	switch opts.ToolChoice {
+	case "", "auto":
+	case "none":
+		choice.Type = "none"
+	case "required":
+		choice.Type = "any"
+	default:
+		choice.Type, choice.Name = "tool", opts.ToolChoice
+	}
+	if opts.ParallelToolCalls != nil && !*opts.ParallelToolCalls && choice.Type != "none" {
+		choice.DisableParallelToolUse = true
+	}
+	if opts.ToolChoice == "" && !choice.DisableParallelToolUse {
+		return
+	}
+	payload.ToolChoice = choice
+}
+
+func buildAnthropicTools(tools []types.Tool) []anthropicTool {
+	if len(tools) == 0 {
+		return nil
+	}
+	out := make([]anthropicTool, len(tools))
+	for i, t := range tools {
+		schema := t.Parameters
+		if schema == nil {
+			schema = map[string]any{"type": "object", "properties": map[string]any{}}
+		}
+		out[i] = anthropicTool{Name: t.Name, Description: t.Description, InputSchema: schema}
+	}
+	return out
+}
@@ -0,0 +1,309 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"mantis/core/protocols"
+	"mantis/core/types"
+	"mantis/shared"
+)
+
+const anthropicSSE = `event: message_start
+data: {"type":"message_start","message":{"id":"msg_1","usage":{"input_tokens":12,"cache_creation_input_tokens":0,"cache_read_input_tokens":2048,"output_tokens":1}}}
+
+event: content_block_start
+data: {"type":"content_block_start","index":0,"content_block":{"type":"thinking","thinking":""}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"thinking_delta","thinking":"Disk first."}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"signature_delta","signature":"sig-abc"}}
+
+event: content_block_stop
+data: {"type":"content_block_stop","index":0}
+
+event: ping
+data: {"type":"ping"}
+
+event: content_block_start
+data: {"type":"content_block_start","index":1,"content_block":{"type":"text","text":""}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":1,"delta":{"type":"text_delta","text":"Checking "}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":1,"delta":{"type":"text_delta","text":"disk."}}
+
+event: content_block_stop
+data: {"type":"content_block_stop","index":1}
+
+event: content_block_start
+data: {"type":"content_block_start","index":2,"content_block":{"type":"tool_use","id":"toolu_1","name":"ssh_web","input":{}}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":2,"delta":{"type":"input_json_delta","partial_json":"{\"task\": \"df "}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":2,"delta":{"type":"input_json_delta","partial_json":"-h\"}"}}
+
+event: content_block_stop
+data: {"type":"content_block_stop","index":2}
+
+event: message_delta
+data: {"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":42}}
+
+event: message_stop
+data: {"type":"message_stop"}
+`
+
+func TestAnthropicChatStream_MapsEvents(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/messages" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		if r.Header.Get("x-api-key") != "test-key" || r.Header.Get("anthropic-version") != anthropicVersion {
+			t.Errorf("unexpected headers: %v", r.Header)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, anthropicSSE)
+	}))
+	defer server.Close()
+
+	ch, err := NewAnthropic().ChatStream(context.Background(), "anthropic", server.URL+"/v1", "test-key",
+		[]protocols.LLMMessage{{Role: "user", Content: "disk?"}}, "claude-sonnet-4-5", nil, "", types.GenerationOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var text, thinking string
+	var block *types.ThinkingBlock
+	var usage *types.LLMUsage
+	var calls []types.ToolCall
+	for ev := range ch {
+		switch ev.Type {
+		case "text":
+			text += ev.Delta
+		case "thinking":
+			thinking += ev.Delta
+		case "thinking_block":
+			block = ev.Thinking
+		case "usage":
+			usage = ev.Usage
+		case "tool_calls":
+			calls = ev.ToolCalls
+		case "error":
+			t.Fatalf("unexpected error: %s", ev.Delta)
+		}
+	}
+	if text != "Checking disk." || thinking != "Disk first." {
+		t.Fatalf("text=%q thinking=%q", text, thinking)
+	}
+	if block == nil || block.Text != "Disk first." || block.Signature != "sig-abc" {
+		t.Fatalf("thinking block = %+v", block)
+	}
+	if usage == nil || usage.PromptTokens != 2060 || usage.CachedTokens != 2048 || usage.CompletionTokens != 42 || usage.TotalTokens != 2102 {
+		t.Fatalf("usage = %+v", usage)
+	}
+	if len(calls) != 1 || calls[0].ID != "toolu_1" || calls[0].Name != "ssh_web" || calls[0].Arguments != `{"task": "df -h"}` {
+		t.Fatalf("tool calls = %+v", calls)
+	}
+}
+
+func TestAnthropicChatStream_BuildsRequest(t *testing.T) {
+	var got map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &got); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n")
+	}))
+	defer server.Close()
+
+	messages := []protocols.LLMMessage{
+		{Role: "system", Content: "You are Mantis."},
+		{Role: "user", Content: "check both servers"},
+		{
+			Role: "assistant", Content: "On it.",
+			Thinking:  []types.ThinkingBlock{{Text: "two calls", Signature: "sig-1"}},
+			ToolCalls: []types.ToolCall{{ID: "t1", Name: "ssh_a", Arguments: `{"task":"uptime"}`}, {ID: "t2", Name: "ssh_b", Arguments: "not json"}},
+		},
+		{Role: "tool", ToolCallID: "t1", Content: "up 3 days"},
+		{Role: "tool", ToolCallID: "t2", Content: "up 1 day"},
+	}
+	tools := []types.Tool{{Name: "ssh_a", Description: "Server A", Parameters: map[string]any{"type": "object"}}, {Name: "ssh_b"}}
+	ch, err := NewAnthropic().ChatStream(context.Background(), "anthropic", server.URL, "k", messages, "claude-opus-4-1", tools, "extended", types.GenerationOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range ch {
+	}
+
+	system := got["system"].([]any)[0].(map[string]any)
+	if system["text"] != "You are Mantis." || system["cache_control"].(map[string]any)["type"] != "ephemeral" {
+		t.Fatalf("system = %v", system)
+	}
+	if th := got["thinking"].(map[string]any); th["type"] != "enabled" || th["budget_tokens"].(float64) != anthropicThinkingBudget {
+		t.Fatalf("thinking = %v", th)
+	}
+	if got["max_tokens"].(float64) != anthropicMaxTokens+anthropicThinkingBudget {
+		t.Fatalf("max_tokens = %v", got["max_tokens"])
+	}
+	if tl := got["tools"].([]any); len(tl) != 2 || tl[1].(map[string]any)["input_schema"] == nil {
+		t.Fatalf("tools = %v", tl)
+	}
+
+	msgs := got["messages"].([]any)
+	if len(msgs) != 3 {
+		t.Fatalf("want user, assistant, user turns, got %v", msgs)
+	}
+	assistant := msgs[1].(map[string]any)["content"].([]any)
+	var blockTypes []string
+	for _, b := range assistant {
+		blockTypes = append(blockTypes, b.(map[string]any)["type"].(string))
+	}
+	if strings.Join(blockTypes, ",") != "thinking,text,tool_use,tool_use" {
+		t.Fatalf("assistant blocks = %v", blockTypes)
+	}
+	if sig := assistant[0].(map[string]any)["signature"]; sig != "sig-1" {
+		t.Fatalf("thinking signature = %v", sig)
+	}
+	if input := assistant[3].(map[string]any)["input"]; len(input.(map[string]any)) != 0 {
+		t.Fatalf("invalid arguments should become an empty object, got %v", input)
+	}
+	results := msgs[2].(map[string]any)["content"].([]any)
+	if len(results) != 2 || results[0].(map[string]any)["tool_use_id"] != "t1" || results[1].(map[string]any)["content"] != "up 1 day" {
+		t.Fatalf("tool results = %v", results)
+	}
+}
+
+func TestAnthropicChatStream_ImageBlocks(t *testing.T) {
+	var got map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &got); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n")
+	}))
+	defer server.Close()
+
+	messages := []protocols.LLMMessage{{
+		Role: "user", Content: "read this",
+		Parts: []types.ContentPart{types.TextPart("read this"), types.ImagePart("image/jpeg", []byte("jpg"))},
+	}}
+	ch, err := NewAnthropic().ChatStream(context.Background(), "anthropic", server.URL, "k", messages, "claude-sonnet-4", nil, "", types.GenerationOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range ch {
+	}
+
+	blocks := got["messages"].([]any)[0].(map[string]any)["content"].([]any)
+	if len(blocks) != 2 || blocks[0].(map[string]any)["text"] != "read this" {
+		t.Fatalf("blocks = %v", blocks)
+	}
+	image := blocks[1].(map[string]any)
+	source := image["source"].(map[string]any)
+	if image["type"] != "image" || source["type"] != "base64" || source["media_type"] != "image/jpeg" || source["data"] != "anBn" {
+		t.Fatalf("image block = %v", image)
+	}
+}
+
+func TestAnthropicChatStream_GenerationOptions(t *testing.T) {
+	var bodies []map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		b, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(b, &body); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		bodies = append(bodies, body)
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n")
+	}))
+	defer server.Close()
+
+	temp, seed, parallel := 0.3, int64(7), false
+	opts := types.GenerationOptions{
+		Temperature: &temp, MaxTokens: 1024, Stop: []string{"END"}, Seed: &seed,
+		ToolChoice: "required", ResponseFormat: "json_object", ParallelToolCalls: &parallel,
+	}
+	tools := []types.Tool{{Name: "ssh_web"}}
+	for _, mode := range []string{"", "extended"} {
+		ch, err := NewAnthropic().ChatStream(context.Background(), "anthropic", server.URL, "k", nil, "m", tools, mode, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		collectStreamEvents(ch)
+	}
+
+	got := bodies[0]
+	if got["temperature"] != 0.3 || got["max_tokens"] != float64(1024) || got["stop_sequences"].([]any)[0] != "END" {
+		t.Fatalf("request = %v", got)
+	}
+	if tc := got["tool_choice"].(map[string]any); tc["type"] != "any" || tc["disable_parallel_tool_use"] != true {
+		t.Fatalf("tool_choice = %v", tc)
+	}
+	for _, key := range []string{"seed", "response_format"} {
+		if _, ok := got[key]; ok {
+			t.Fatalf("%s has no Messages API counterpart: %v", key, got)
+		}
+	}
+	if _, ok := bodies[1]["temperature"]; ok || bodies[1]["max_tokens"] != float64(1024+anthropicThinkingBudget) {
+		t.Fatalf("extended thinking request = %v", bodies[1])
+	}
+}
+
+func TestAnthropicChatStream_Errors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.Header.Get("x-api-key"), "busy") {
+			http.Error(w, `{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`, 529)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "event: error\ndata: {\"type\":\"error\",\"error\":{\"type\":\"overloaded_error\",\"message\":\"Overloaded\"}}\n\n")
+	}))
+	defer server.Close()
+
+	_, err := NewAnthropic().ChatStream(context.Background(), "anthropic", server.URL, "busy", nil, "m", nil, "", types.GenerationOptions{})
+	if err == nil || !strings.Contains(err.Error(), "LLM API error 529") || !shared.IsFailoverError(err) {
+		t.Fatalf("status error = %v", err)
+	}
+
+	ch, err := NewAnthropic().ChatStream(context.Background(), "anthropic", server.URL, "k", nil, "m", nil, "", types.GenerationOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := collectStreamEvents(ch)
+	if len(events) != 1 || events[0].Type != "error" || !strings.Contains(events[0].Delta, "overloaded_error") {
+		t.Fatalf("events = %+v", events)
+	}
+}
+
+func TestAnthropicListModels(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/models" || r.Header.Get("x-api-key") != "k" {
+			t.Errorf("unexpected request: %s %v", r.URL.Path, r.Header)
+		}
+		fmt.Fprint(w, `{"data":[{"id":"claude-sonnet-4-5","type":"model"},{"id":"claude-haiku-4-5","type":"model"}],"has_more":false}`)
+	}))
+	defer server.Close()
+
+	items, err := NewAnthropic().ListModels(context.Background(), server.URL+"/v1/", "k")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 || items[0].ID != "claude-haiku-4-5" {
+		t.Fatalf("models = %+v", items)
+	}
+}
@@ -0,0 +1,172 @@
+package llm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"slices"
+	"strings"
+
+	"mantis/core/protocols"
+	"mantis/core/types"
+)
+
+// CacheMode selects how Cache treats model calls.
+type CacheMode string
+
+const (
+	// CacheOff passes every call through.
+	CacheOff CacheMode = "off"
+	// CacheRecord answers from a recording when there is one and records
+	// every call it passes through.
+	CacheRecord CacheMode = "record"
+	// CacheReplay only answers from recordings; a call without one fails
+	// with ErrCacheMiss. Tests use it to run agents without a live model.
+	CacheReplay CacheMode = "replay"
+)
+
+// ErrCacheMiss is returned in replay mode for a request nothing was recorded for.
+var ErrCacheMiss = errors.New("llm cache: no recorded response")
+
+func ParseCacheMode(s string) (CacheMode, error) {
+	switch mode := CacheMode(strings.ToLower(strings.TrimSpace(s))); mode {
+	case "", CacheOff:
+		return CacheOff, nil
+	case CacheRecord, CacheReplay:
+		return mode, nil
+	default:
+		return CacheOff, fmt.Errorf("unknown LLM cache mode %q (want off, record or replay)", s)
+	}
+}
+
+// Cache records model responses and plays them back. Responses are keyed by
+// CacheKey, so the same request against another endpoint or API key hits the
+// same recording. Streams that fail or are cancelled are not recorded.
+type Cache struct {
+	next  protocols.LLM
+	store protocols.Store[string, types.LLMCacheEntry]
+	mode  CacheMode
+}
+
+func NewCache(next protocols.LLM, store protocols.Store[string, types.LLMCacheEntry], mode CacheMode) *Cache {
+	return &Cache{next: next, store: store, mode: mode}
+}
+
+func (c *Cache) ChatStream(ctx context.Context, provider, baseURL, apiKey string, messages []protocols.LLMMessage, model string, tools []types.Tool, thinkingMode string, opts types.GenerationOptions) (<-chan types.StreamEvent, error) {
+	if c.mode != CacheRecord && c.mode != CacheReplay {
+		return c.next.ChatStream(ctx, provider, baseURL, apiKey, messages, model, tools, thinkingMode, opts)
+	}
+
+	key := CacheKey(provider, model, messages, tools, thinkingMode, opts)
+	entries, err := c.store.Get(ctx, []string{key})
+	if err != nil {
+		if c.mode == CacheReplay {
+			return nil, fmt.Errorf("llm cache: %w", err)
+		}
+		log.Printf("llm cache: lookup %s: %v", key, err)
+	}
+	if entry, ok := entries[key]; ok {
+		return replayEvents(entry.Events), nil
+	}
+	if c.mode == CacheReplay {
+		return nil, fmt.Errorf("%w for model %s (key %s)", ErrCacheMiss, model, key)
+	}
+
+	ch, err := c.next.ChatStream(ctx, provider, baseURL, apiKey, messages, model, tools, thinkingMode, opts)
+	if err != nil {
+		return nil, err
+	}
+	return c.record(ctx, types.LLMCacheEntry{ID: key, Provider: normalizeProvider(provider), Model: model}, ch), nil
+}
+
+// record forwards ch and stores its events once it ends cleanly.
+func (c *Cache) record(ctx context.Context, entry types.LLMCacheEntry, ch <-chan types.StreamEvent) <-chan types.StreamEvent {
+	out := make(chan types.StreamEvent, 32)
+	go func() {
+		defer close(out)
+		failed := false
+		for ev := range ch {
+			if ev.Type == "error" {
+				failed = true
+			}
+			entry.Events = append(entry.Events, ev)
+			out <- ev
+		}
+		if failed || ctx.Err() != nil {
+			return
+		}
+		if _, err := c.store.Create(context.WithoutCancel(ctx), []types.LLMCacheEntry{entry}); err != nil {
+			log.Printf("llm cache: record %s: %v", entry.ID, err)
+		}
+	}()
+	return out
+}
+
+func replayEvents(events []types.StreamEvent) <-chan types.StreamEvent {
+	out := make(chan types.StreamEvent, len(events))
+	for _, ev := range events {
+		out <- ev
+	}
+	close(out)
+	return out
+}
+
+// cacheRequest is what a recording is keyed on. Tools are reduced to what the
+// model sees; endpoint and credentials are left out on purpose.
+type cacheRequest struct {
+	Provider     string                  `json:"provider"`
+	Model        string                  `json:"model"`
+	ThinkingMode string                  `json:"thinkingMode,omitempty"`
+	Messages     []protocols.LLMMessage  `json:"messages"`
+	Tools        []cacheTool             `json:"tools,omitempty"`
+	Options      types.GenerationOptions `json:"options"`
+}
+
+type cacheTool struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Parameters  map[string]any `json:"parameters,omitempty"`
+}
+
+// volatilePromptRe matches system prompt lines that change on every request,
+// like the current time, and would otherwise keep a recording from ever
+// matching again.
+var volatilePromptRe = regexp.MustCompile(`(?m)^(Current date/time:).*$`)
+
+// CacheKey hashes a model request into the ID of its recording. The current
+// time in system prompts is left out, so a recording replays on another day.
+func CacheKey(provider, model string, messages []protocols.LLMMessage, tools []types.Tool, thinkingMode string, opts types.GenerationOptions) string {
+	req := cacheRequest{
+		Provider:     normalizeProvider(provider),
+		Model:        model,
+		ThinkingMode: thinkingMode,
+		Messages:     stableMessages(messages),
+		Options:      opts,
+	}
+	for _, t := range tools {
+		req.Tools = append(req.Tools, cacheTool{Name: t.Name, Description: t.Description, Parameters: t.Parameters})
+	}
+	data, _ := json.Marshal(req)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// stableMessages returns messages with volatile system prompt lines blanked.
+func stableMessages(messages []protocols.LLMMessage) []protocols.LLMMessage {
+	out := slices.Clone(messages)
+	for i, m := range out {
+		if m.Role == "system" {
+			out[i].Content = volatilePromptRe.ReplaceAllString(m.Content, "$1")
+		}
+	}
+	return out
+}
+
+func normalizeProvider(provider string) string {
+	return strings.ToLower(strings.TrimSpace(provider))
+}
@@ -0,0 +1,143 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"mantis/core/protocols"
+	"mantis/core/types"
+	"mantis/infrastructure/adapters/store"
+)
+
+type countingLLM struct {
+	calls  int
+	events []types.StreamEvent
+}
+
+func (c *countingLLM) ChatStream(_ context.Context, _, _, _ string, _ []protocols.LLMMessage, _ string, _ []types.Tool, _ string, _ types.GenerationOptions) (<-chan types.StreamEvent, error) {
+	c.calls++
+	return replayEvents(c.events), nil
+}
+
+func cacheDir(t *testing.T) *store.JSONDir[types.LLMCacheEntry] {
+	return store.NewJSONDir(t.TempDir(), func(e types.LLMCacheEntry) string { return e.ID })
+}
+
+func TestCache_RecordThenReplay(t *testing.T) {
+	live := &countingLLM{events: []types.StreamEvent{
+		{Type: "text", Delta: "disk is 40% full"},
+		{Type: "tool_calls", ToolCalls: []types.ToolCall{{ID: "c1", Name: "ssh_web", Arguments: `{"task":"df -h"}`}}},
+		{Type: "usage", Usage: &types.LLMUsage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15}},
+	}}
+	dir := cacheDir(t)
+	messages := []protocols.LLMMessage{{Role: "user", Content: "disk?"}}
+	tools := []types.Tool{{Name: "ssh_web", Parameters: map[string]any{"type": "object"}}}
+
+	recorder := NewCache(live, dir, CacheRecord)
+	for i := 0; i < 2; i++ {
+		ch, err := recorder.ChatStream(context.Background(), "OpenAI", "http://a", "k1", messages, "m", tools, "", types.GenerationOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		collectStreamEvents(ch)
+	}
+	if live.calls != 1 {
+		t.Fatalf("second call should be served from the recording, live calls = %d", live.calls)
+	}
+
+	replayer := NewCache(nil, dir, CacheReplay)
+	ch, err := replayer.ChatStream(context.Background(), "openai", "http://b", "k2", messages, "m", tools, "", types.GenerationOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := collectStreamEvents(ch)
+	if len(events) != 3 || events[0].Delta != "disk is 40% full" || events[1].ToolCalls[0].Arguments != `{"task":"df -h"}` || events[2].Usage.TotalTokens != 15 {
+		t.Fatalf("replayed events = %+v", events)
+	}
+
+	temp := 0.1
+	_, err = replayer.ChatStream(context.Background(), "openai", "", "", messages, "m", tools, "", types.GenerationOptions{Temperature: &temp})
+	if !errors.Is(err, ErrCacheMiss) {
+		t.Fatalf("changed options should miss, got %v", err)
+	}
+}
+
+func TestCache_SkipsFailedStreams(t *testing.T) {
+	live := &countingLLM{events: []types.StreamEvent{{Type: "text", Delta: "par"}, {Type: "error", Delta: "LLM API error 503"}}}
+	dir := cacheDir(t)
+	ch, err := NewCache(live, dir, CacheRecord).ChatStream(context.Background(), "openai", "", "", nil, "m", nil, "", types.GenerationOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	collectStreamEvents(ch)
+	if items, _ := dir.List(context.Background(), types.ListQuery{}); len(items) != 0 {
+		t.Fatalf("failed stream was recorded: %+v", items)
+	}
+}
+
+func TestCache_OffPassesThrough(t *testing.T) {
+	live := &countingLLM{}
+	c := NewCache(live, nil, CacheOff)
+	for i := 0; i < 2; i++ {
+		if _, err := c.ChatStream(context.Background(), "openai", "", "", nil, "m", nil, "", types.GenerationOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if live.calls != 2 {
+		t.Fatalf("live calls = %d", live.calls)
+	}
+}
+
+func TestCacheKey(t *testing.T) {
+	messages := []protocols.LLMMessage{{Role: "user", Content: "hi"}}
+	base := CacheKey("openai", "m", messages, nil, "", types.GenerationOptions{})
+	if CacheKey(" OpenAI ", "m", messages, []types.Tool{}, "", types.GenerationOptions{}) != base {
+		t.Fatal("provider case and empty tools should not change the key")
+	}
+	for name, key := range map[string]string{
+		"model":    CacheKey("openai", "m2", messages, nil, "", types.GenerationOptions{}),
+		"messages": CacheKey("openai", "m", []protocols.LLMMessage{{Role: "user", Content: "hello"}}, nil, "", types.GenerationOptions{}),
+		"tools":    CacheKey("openai", "m", messages, []types.Tool{{Name: "t"}}, "", types.GenerationOptions{}),
+		"thinking": CacheKey("openai", "m", messages, nil, "extended", types.GenerationOptions{}),
+		"options":  CacheKey("openai", "m", messages, nil, "", types.GenerationOptions{MaxTokens: 10}),
+	} {
+		if key == base {
+			t.Errorf("changing %s should change the key", name)
+		}
+	}
+}
+
+func TestCacheKey_IgnoresCurrentTime(t *testing.T) {
+	prompt := func(now string) []protocols.LLMMessage {
+		return []protocols.LLMMessage{
+			{Role: "system", Content: "You are Mantis.\n\nCurrent date/time: " + now + "\n\nBe brief."},
+			{Role: "user", Content: "hi"},
+		}
+	}
+	monday := prompt("Monday, 2026-10-12 09:00:00 UTC")
+	key := CacheKey("openai", "m", monday, nil, "", types.GenerationOptions{})
+	if CacheKey("openai", "m", prompt("Sunday, 2026-10-18 23:59:59 UTC"), nil, "", types.GenerationOptions{}) != key {
+		t.Fatal("the current time should not change the key")
+	}
+	if !strings.Contains(monday[0].Content, "09:00:00") {
+		t.Fatal("CacheKey must not modify the messages")
+	}
+	edited := prompt("Monday, 2026-10-12 09:00:00 UTC")
+	edited[0].Content = strings.Replace(edited[0].Content, "Be brief.", "Be verbose.", 1)
+	if CacheKey("openai", "m", edited, nil, "", types.GenerationOptions{}) == key {
+		t.Fatal("the rest of the system prompt should still change the key")
+	}
+}
+
+func TestParseCacheMode(t *testing.T) {
+	for in, want := range map[string]CacheMode{"": CacheOff, "off": CacheOff, " Record ": CacheRecord, "replay": CacheReplay} {
+		if got, err := ParseCacheMode(in); err != nil || got != want {
+			t.Errorf("ParseCacheMode(%q) = %q, %v", in, got, err)
+		}
+	}
+	if _, err := ParseCacheMode("sometimes"); err == nil {
+		t.Error("unknown mode should fail")
+	}
+}
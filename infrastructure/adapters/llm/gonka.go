@@ -10,6 +10,7 @@ import (
 
 	gonkaopenai "github.com/gonka-ai/gonka-openai/go"
 	"github.com/openai/openai-go"
+	openaishared "github.com/openai/openai-go/shared"
 
 	"mantis/core/protocols"
 	"mantis/core/types"
@@ -75,7 +76,7 @@ func (g *Gonka) GetInferenceLimit(ctx context.Context, baseURL, apiKey string) (
 	}, nil
 }
 
-func (g *Gonka) ChatStream(ctx context.Context, _ string, baseURL, apiKey string, messages []protocols.LLMMessage, model string, tools []types.Tool, thinkingMode string) (<-chan types.StreamEvent, error) {
+func (g *Gonka) ChatStream(ctx context.Context, _ string, baseURL, apiKey string, messages []protocols.LLMMessage, model string, tools []types.Tool, thinkingMode string, opts types.GenerationOptions) (<-chan types.StreamEvent, error) {
 	client, err := g.newClient(baseURL, apiKey)
 	if err != nil {
 		return nil, err
@@ -91,6 +92,7 @@ func (g *Gonka) ChatStream(ctx context.Context, _ string, baseURL, apiKey string
 	if reqTools := buildGonkaTools(tools); len(reqTools) > 0 {
 		params.Tools = reqTools
 	}
+	applyGonkaGenerationOptions(&params, opts)
 
 	stream := client.Chat.Completions.NewStreaming(ctx, params)
 	ch := make(chan types.StreamEvent, 32)
@@ -255,12 +257,29 @@ func buildGonkaMessages(messages []protocols.LLMMessage) []openai.ChatCompletion
 			}
 			out = append(out, openai.ToolMessage(m.Content, m.ToolCallID))
 		default:
+			if len(m.Parts) > 0 {
+				out = append(out, openai.UserMessage(buildGonkaContentParts(m.Parts)))
+				continue
+			}
 			out = append(out, openai.UserMessage(m.Content))
 		}
 	}
 	return out
 }
 
+func buildGonkaContentParts(parts []types.ContentPart) []openai.ChatCompletionContentPartUnionParam {
+	out := make([]openai.ChatCompletionContentPartUnionParam, 0, len(parts))
+	for _, p := range parts {
+		switch p.Type {
+		case types.ContentPartText:
+			out = append(out, openai.TextContentPart(p.Text))
+		case types.ContentPartImage:
+			out = append(out, openai.ImageContentPart(openai.ChatCompletionContentPartImageImageURLParam{URL: dataURL(p)}))
+		}
+	}
+	return out
+}
+
 func buildGonkaAssistantToolCalls(toolCalls []types.ToolCall) []openai.ChatCompletionMessageToolCallParam {
 	out := make([]openai.ChatCompletionMessageToolCallParam, 0, len(toolCalls))
 	for i, tc := range toolCalls {
@@ -283,6 +302,47 @@ func buildGonkaAssistantToolCalls(toolCalls []types.ToolCall) []openai.ChatCompl
 	return out
 }
 
+func applyGonkaGenerationOptions(params *openai.ChatCompletionNewParams, opts types.GenerationOptions) {
+	if opts.Temperature != nil {
+		params.Temperature = openai.Float(*opts.Temperature)
+	}
+	if opts.TopP != nil {
+		params.TopP = openai.Float(*opts.TopP)
+	}
+	if opts.MaxTokens > 0 {
+		params.MaxTokens = openai.Int(int64(opts.MaxTokens))
+	}
+	if len(opts.Stop) > 0 {
+		params.Stop = openai.ChatCompletionNewParamsStopUnion{OfChatCompletionNewsStopArray: opts.Stop}
+	}
+	if opts.Seed != nil {
+		params.Seed = openai.Int(*opts.Seed)
+	}
+	switch opts.ResponseFormat {
+	case "json_object":
+		params.ResponseFormat = openai.ChatCompletionNewParamsResponseFormatUnion{OfJSONObject: &openaishared.ResponseFormatJSONObjectParam{}}
+	case "text":
+		params.ResponseFormat = openai.ChatCompletionNewParamsResponseFormatUnion{OfText: &openaishared.ResponseFormatTextParam{}}
+	}
+	if len(params.Tools) == 0 {
+		return
+	}
+	if opts.ParallelToolCalls != nil {
+		params.ParallelToolCalls = openai.Bool(*opts.ParallelToolCalls)
+	}
+	switch opts.ToolChoice {
+	case "":
+	case "auto", "none", "required":
+		params.ToolChoice = openai.ChatCompletionToolChoiceOptionUnionParam{OfAuto: openai.String(opts.ToolChoice)}
+	default:
+		params.ToolChoice = openai.ChatCompletionToolChoiceOptionUnionParam{
+			OfChatCompletionNamedToolChoice: &openai.ChatCompletionNamedToolChoiceParam{
+				Function: openai.ChatCompletionNamedToolChoiceFunctionParam{Name: opts.ToolChoice},
+			},
+		}
+	}
+}
+
 func buildGonkaTools(tools []types.Tool) []openai.ChatCompletionToolParam {
 	out := make([]openai.ChatCompletionToolParam, 0, len(tools))
 	for _, t := range tools {
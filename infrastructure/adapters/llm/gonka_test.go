@@ -2,11 +2,16 @@ package llm
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+
+	"github.com/openai/openai-go"
+
+	"mantis/core/types"
 )
 
 const gonkaTestPrivateKey = "1a30d0695812c21d6c6bfc59630c1753888c23fdbe63f897686c95f2924879d2"
@@ -76,3 +81,28 @@ func TestGonkaGetInferenceLimit_ServerError(t *testing.T) {
 		t.Fatalf("expected chain API error, got %v", err)
 	}
 }
+
+func TestApplyGonkaGenerationOptions(t *testing.T) {
+	topP, seed := 0.9, int64(7)
+	params := openai.ChatCompletionNewParams{Model: "m", Tools: buildGonkaTools([]types.Tool{{Name: "ssh_web"}})}
+	applyGonkaGenerationOptions(&params, types.GenerationOptions{
+		TopP: &topP, MaxTokens: 256, Stop: []string{"END"}, Seed: &seed, ToolChoice: "required", ResponseFormat: "json_object",
+	})
+	b, err := json.Marshal(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got["top_p"] != 0.9 || got["max_tokens"] != float64(256) || got["seed"] != float64(7) || got["tool_choice"] != "required" {
+		t.Fatalf("params = %s", b)
+	}
+	if got["response_format"].(map[string]any)["type"] != "json_object" || got["stop"].([]any)[0] != "END" {
+		t.Fatalf("params = %s", b)
+	}
+	if _, ok := got["temperature"]; ok {
+		t.Fatalf("unset temperature should be omitted: %s", b)
+	}
+}
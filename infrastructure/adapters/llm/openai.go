@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -34,13 +35,25 @@ func NewOpenAI() *OpenAI {
 	return &OpenAI{client: &http.Client{Transport: transport}}
 }
 
+// reqMessage.Content is a string, nil, or []reqContentPart for a message
+// with images.
 type reqMessage struct {
 	Role       string        `json:"role"`
-	Content    *string       `json:"content"`
+	Content    any           `json:"content"`
 	ToolCalls  []reqToolCall `json:"tool_calls,omitempty"`
 	ToolCallID string        `json:"tool_call_id,omitempty"`
 }
 
+type reqContentPart struct {
+	Type     string       `json:"type"`
+	Text     string       `json:"text,omitempty"`
+	ImageURL *reqImageURL `json:"image_url,omitempty"`
+}
+
+type reqImageURL struct {
+	URL string `json:"url"`
+}
+
 type reqToolCall struct {
 	ID       string      `json:"id"`
 	Type     string      `json:"type"`
@@ -64,11 +77,23 @@ type reqToolDef struct {
 }
 
 type chatReq struct {
-	Model         string        `json:"model"`
-	Messages      []reqMessage  `json:"messages"`
-	Tools         []reqTool     `json:"tools,omitempty"`
-	Stream        bool          `json:"stream"`
-	StreamOptions streamOptions `json:"stream_options"`
+	Model             string          `json:"model"`
+	Messages          []reqMessage    `json:"messages"`
+	Tools             []reqTool       `json:"tools,omitempty"`
+	Stream            bool            `json:"stream"`
+	StreamOptions     streamOptions   `json:"stream_options"`
+	Temperature       *float64        `json:"temperature,omitempty"`
+	TopP              *float64        `json:"top_p,omitempty"`
+	MaxTokens         int             `json:"max_tokens,omitempty"`
+	Stop              []string        `json:"stop,omitempty"`
+	Seed              *int64          `json:"seed,omitempty"`
+	ToolChoice        any             `json:"tool_choice,omitempty"`
+	ResponseFormat    *responseFormat `json:"response_format,omitempty"`
+	ParallelToolCalls *bool           `json:"parallel_tool_calls,omitempty"`
+}
+
+type responseFormat struct {
+	Type string `json:"type"`
 }
 
 type streamOptions struct {
@@ -106,6 +131,31 @@ type streamUsage struct {
 	} `json:"prompt_tokens_details"`
 }
 
+// applyGenerationOptions copies opts onto the request. Tool choice and
+// parallel tool calls are only sent with tools, since providers reject them
+// otherwise.
+func applyGenerationOptions(payload *chatReq, opts types.GenerationOptions) {
+	payload.Temperature = opts.Temperature
+	payload.TopP = opts.TopP
+	payload.MaxTokens = opts.MaxTokens
+	payload.Stop = opts.Stop
+	payload.Seed = opts.Seed
+	if opts.ResponseFormat != "" {
+		payload.ResponseFormat = &responseFormat{Type: opts.ResponseFormat}
+	}
+	if len(payload.Tools) == 0 {
+		return
+	}
+	payload.ParallelToolCalls = opts.ParallelToolCalls
+	switch opts.ToolChoice {
+	case "":
+	case "auto", "none", "required":
+		payload.ToolChoice = opts.ToolChoice
+	default:
+		payload.ToolChoice = map[string]any{"type": "function", "function": map[string]string{"name": opts.ToolChoice}}
+	}
+}
+
 type modelsResp struct {
 	Data []struct {
 		ID string `json:"id"`
@@ -154,7 +204,7 @@ func (o *OpenAI) GetInferenceLimit(_ context.Context, _ string, _ string) (types
 	}, nil
 }
 
-func (o *OpenAI) ChatStream(ctx context.Context, _ string, baseURL, apiKey string, messages []protocols.LLMMessage, model string, tools []types.Tool, thinkingMode string) (<-chan types.StreamEvent, error) {
+func (o *OpenAI) ChatStream(ctx context.Context, _ string, baseURL, apiKey string, messages []protocols.LLMMessage, model string, tools []types.Tool, thinkingMode string, opts types.GenerationOptions) (<-chan types.StreamEvent, error) {
 	msgs := buildMessages(messages)
 	reqTools := buildTools(tools)
 
@@ -162,6 +212,7 @@ func (o *OpenAI) ChatStream(ctx context.Context, _ string, baseURL, apiKey strin
 	if len(reqTools) > 0 {
 		payload.Tools = reqTools
 	}
+	applyGenerationOptions(&payload, opts)
 	body, _ := json.Marshal(payload)
 
 	req, err := http.NewRequestWithContext(ctx, "POST", baseURL+"/chat/completions", bytes.NewReader(body))
@@ -288,10 +339,12 @@ func (o *OpenAI) ChatStream(ctx context.Context, _ string, baseURL, apiKey strin
 func buildMessages(messages []protocols.LLMMessage) []reqMessage {
 	out := make([]reqMessage, len(messages))
 	for i, m := range messages {
-		content := m.Content
-		msg := reqMessage{Role: m.Role, Content: &content}
+		msg := reqMessage{Role: m.Role, Content: m.Content}
+		if len(m.Parts) > 0 && m.Role == "user" {
+			msg.Content = buildContentParts(m.Parts)
+		}
 		if len(m.ToolCalls) > 0 {
-			if content == "" {
+			if m.Content == "" {
 				msg.Content = nil
 			}
 			for _, tc := range m.ToolCalls {
@@ -309,6 +362,23 @@ func buildMessages(messages []protocols.LLMMessage) []reqMessage {
 	return out
 }
 
+func buildContentParts(parts []types.ContentPart) []reqContentPart {
+	out := make([]reqContentPart, 0, len(parts))
+	for _, p := range parts {
+		switch p.Type {
+		case types.ContentPartText:
+			out = append(out, reqContentPart{Type: "text", Text: p.Text})
+		case types.ContentPartImage:
+			out = append(out, reqContentPart{Type: "image_url", ImageURL: &reqImageURL{URL: dataURL(p)}})
+		}
+	}
+	return out
+}
+
+func dataURL(p types.ContentPart) string {
+	return "data:" + p.MIME + ";base64," + base64.StdEncoding.EncodeToString(p.Data)
+}
+
 func buildTools(tools []types.Tool) []reqTool {
 	if len(tools) == 0 {
 		return nil
@@ -2,12 +2,15 @@ package llm
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
 
+	"mantis/core/protocols"
 	"mantis/core/types"
 )
 
@@ -91,7 +94,7 @@ data: [DONE]
 	defer server.Close()
 
 	o := NewOpenAI()
-	ch, err := o.ChatStream(context.Background(), "openai", server.URL, "test-key", nil, "test-model", nil, "")
+	ch, err := o.ChatStream(context.Background(), "openai", server.URL, "test-key", nil, "test-model", nil, "", types.GenerationOptions{})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -131,7 +134,7 @@ data: [DONE]
 	defer server.Close()
 
 	o := NewOpenAI()
-	ch, err := o.ChatStream(context.Background(), "openai", server.URL, "test-key", nil, "test-model", nil, "")
+	ch, err := o.ChatStream(context.Background(), "openai", server.URL, "test-key", nil, "test-model", nil, "", types.GenerationOptions{})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -164,7 +167,7 @@ data: [DONE]
 	defer server.Close()
 
 	o := NewOpenAI()
-	ch, err := o.ChatStream(context.Background(), "openai", server.URL, "test-key", nil, "test-model", nil, "")
+	ch, err := o.ChatStream(context.Background(), "openai", server.URL, "test-key", nil, "test-model", nil, "", types.GenerationOptions{})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -198,7 +201,7 @@ data: [DONE]
 	defer server.Close()
 
 	o := NewOpenAI()
-	ch, err := o.ChatStream(context.Background(), "openai", server.URL, "test-key", nil, "test-model", nil, "skip")
+	ch, err := o.ChatStream(context.Background(), "openai", server.URL, "test-key", nil, "test-model", nil, "skip", types.GenerationOptions{})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -240,7 +243,7 @@ data: [DONE]
 	defer server.Close()
 
 	o := NewOpenAI()
-	ch, err := o.ChatStream(context.Background(), "openai", server.URL, "test-key", nil, "test-model", nil, "")
+	ch, err := o.ChatStream(context.Background(), "openai", server.URL, "test-key", nil, "test-model", nil, "", types.GenerationOptions{})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -262,3 +265,86 @@ data: [DONE]
 	}
 	t.Fatal("expected tool_calls event, got none")
 }
+
+func TestChatStream_SendsGenerationOptions(t *testing.T) {
+	var bodies []map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		b, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(b, &body); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		bodies = append(bodies, body)
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	temp, seed, parallel := 0.3, int64(7), false
+	opts := types.GenerationOptions{
+		Temperature: &temp, MaxTokens: 512, Stop: []string{"END"}, Seed: &seed,
+		ToolChoice: "ssh_web", ResponseFormat: "json_object", ParallelToolCalls: &parallel,
+	}
+	tools := []types.Tool{{Name: "ssh_web"}}
+	for _, tl := range [][]types.Tool{tools, nil} {
+		ch, err := NewOpenAI().ChatStream(context.Background(), "openai", server.URL, "k", nil, "m", tl, "", opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		collectStreamEvents(ch)
+	}
+
+	got := bodies[0]
+	if got["temperature"] != 0.3 || got["max_tokens"] != float64(512) || got["seed"] != float64(7) || got["parallel_tool_calls"] != false {
+		t.Fatalf("request = %v", got)
+	}
+	if _, ok := got["top_p"]; ok {
+		t.Fatalf("unset top_p should be omitted: %v", got)
+	}
+	if stop := got["stop"].([]any); len(stop) != 1 || stop[0] != "END" {
+		t.Fatalf("stop = %v", got["stop"])
+	}
+	if rf := got["response_format"].(map[string]any); rf["type"] != "json_object" {
+		t.Fatalf("response_format = %v", rf)
+	}
+	if fn := got["tool_choice"].(map[string]any)["function"].(map[string]any); fn["name"] != "ssh_web" {
+		t.Fatalf("tool_choice = %v", got["tool_choice"])
+	}
+	for _, key := range []string{"tool_choice", "parallel_tool_calls"} {
+		if _, ok := bodies[1][key]; ok {
+			t.Fatalf("%s sent without tools: %v", key, bodies[1])
+		}
+	}
+}
+
+func TestChatStream_SendsImageParts(t *testing.T) {
+	var got map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(b, &got); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	messages := []protocols.LLMMessage{{
+		Role: "user", Content: "what is on the screen?",
+		Parts: []types.ContentPart{types.TextPart("what is on the screen?"), types.ImagePart("image/png", []byte("png"))},
+	}}
+	ch, err := NewOpenAI().ChatStream(context.Background(), "openai", server.URL, "k", messages, "m", nil, "", types.GenerationOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	collectStreamEvents(ch)
+
+	content := got["messages"].([]any)[0].(map[string]any)["content"].([]any)
+	if len(content) != 2 || content[0].(map[string]any)["text"] != "what is on the screen?" {
+		t.Fatalf("content = %v", content)
+	}
+	image := content[1].(map[string]any)
+	if image["type"] != "image_url" || image["image_url"].(map[string]any)["url"] != "data:image/png;base64,cG5n" {
+		t.Fatalf("image part = %v", image)
+	}
+}
@@ -30,7 +30,7 @@ func NewRouter(defaultProvider string, providers map[string]protocols.LLM) *Rout
 	return &Router{defaultProvider: fallback, providers: normalized}
 }
 
-func (r *Router) ChatStream(ctx context.Context, provider, baseURL, apiKey string, messages []protocols.LLMMessage, model string, tools []types.Tool, thinkingMode string) (<-chan types.StreamEvent, error) {
+func (r *Router) ChatStream(ctx context.Context, provider, baseURL, apiKey string, messages []protocols.LLMMessage, model string, tools []types.Tool, thinkingMode string, opts types.GenerationOptions) (<-chan types.StreamEvent, error) {
 	key := strings.ToLower(strings.TrimSpace(provider))
 	if key == "" {
 		key = r.defaultProvider
@@ -45,5 +45,5 @@ func (r *Router) ChatStream(ctx context.Context, provider, baseURL, apiKey strin
 		key = r.defaultProvider
 	}
 
-	return adapter.ChatStream(ctx, key, baseURL, apiKey, messages, model, tools, thinkingMode)
+	return adapter.ChatStream(ctx, key, baseURL, apiKey, messages, model, tools, thinkingMode, opts)
 }
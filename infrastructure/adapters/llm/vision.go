@@ -12,6 +12,8 @@ import (
 	"net/http"
 	"strings"
 	"time"
+
+	"mantis/core/types"
 )
 
 type Vision struct {
@@ -27,7 +29,7 @@ func NewVision() *Vision {
 	return &Vision{client: &http.Client{Transport: transport}}
 }
 
-func (v *Vision) Describe(ctx context.Context, baseURL, apiKey, model string, image []byte, format, prompt string) (string, error) {
+func (v *Vision) Describe(ctx context.Context, baseURL, apiKey, model string, image []byte, format, prompt string) (string, *types.LLMUsage, error) {
 	mime := "image/" + format
 	if format == "jpg" {
 		mime = "image/jpeg"
@@ -55,20 +57,20 @@ func (v *Vision) Describe(ctx context.Context, baseURL, apiKey, model string, im
 
 	req, err := http.NewRequestWithContext(ctx, "POST", baseURL+"/chat/completions", bytes.NewReader(body))
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+apiKey)
 
 	resp, err := v.client.Do(req)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 	defer resp.Body.Close()
 
 	respBody, _ := io.ReadAll(resp.Body)
 	if resp.StatusCode != 200 {
-		return "", fmt.Errorf("Vision API error %d: %s", resp.StatusCode, string(respBody))
+		return "", nil, fmt.Errorf("Vision API error %d: %s", resp.StatusCode, string(respBody))
 	}
 
 	var result struct {
@@ -79,12 +81,13 @@ func (v *Vision) Describe(ctx context.Context, baseURL, apiKey, model string, im
 			} `json:"message"`
 			FinishReason string `json:"finish_reason"`
 		} `json:"choices"`
+		Usage *streamUsage `json:"usage"`
 	}
 	if err := json.Unmarshal(respBody, &result); err != nil {
-		return "", fmt.Errorf("vision parse error: %w; body: %s", err, preview(respBody))
+		return "", nil, fmt.Errorf("vision parse error: %w; body: %s", err, preview(respBody))
 	}
 	if len(result.Choices) == 0 {
-		return "", fmt.Errorf("no response from vision model; body: %s", preview(respBody))
+		return "", nil, fmt.Errorf("no response from vision model; body: %s", preview(respBody))
 	}
 
 	msg := result.Choices[0].Message
@@ -97,10 +100,10 @@ func (v *Vision) Describe(ctx context.Context, baseURL, apiKey, model string, im
 		log.Printf("vision: empty content, model=%s finish_reason=%q raw=%s",
 			model, result.Choices[0].FinishReason, preview(respBody))
 		if result.Choices[0].FinishReason == "length" {
-			return "", fmt.Errorf("vision model hit max_tokens before producing output; try a smaller prompt/image or a different model")
+			return "", convertUsage(result.Usage), fmt.Errorf("vision model hit max_tokens before producing output; try a smaller prompt/image or a different model")
 		}
 	}
-	return content, nil
+	return content, convertUsage(result.Usage), nil
 }
 
 // parseVisionContent handles OpenAI-compatible `content` that can be:
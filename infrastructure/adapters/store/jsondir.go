@@ -0,0 +1,174 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"mantis/core/types"
+)
+
+// JSONDir keeps each entity in its own <id>.json file in dir. It suits small
+// data sets that live next to the code, such as recorded test fixtures.
+// List ignores filters and sorting and returns entities in ID order.
+type JSONDir[Entity any] struct {
+	mu    sync.Mutex
+	dir   string
+	getID func(Entity) string
+}
+
+func NewJSONDir[Entity any](dir string, getID func(Entity) string) *JSONDir[Entity] {
+	return &JSONDir[Entity]{dir: dir, getID: getID}
+}
+
+func (s *JSONDir[Entity]) Create(_ context.Context, items []Entity) ([]Entity, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, item := range items {
+		path, err := s.path(s.getID(item))
+		if err != nil {
+			return nil, err
+		}
+		if _, err := os.Stat(path); err == nil {
+			return nil, fmt.Errorf("%s: %w", path, os.ErrExist)
+		}
+		if err := s.write(path, item); err != nil {
+			return nil, err
+		}
+	}
+	return items, nil
+}
+
+func (s *JSONDir[Entity]) Get(_ context.Context, ids []string) (map[string]Entity, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := make(map[string]Entity, len(ids))
+	for _, id := range ids {
+		path, err := s.path(id)
+		if err != nil {
+			return nil, err
+		}
+		item, err := s.read(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		result[id] = item
+	}
+	return result, nil
+}
+
+func (s *JSONDir[Entity]) List(_ context.Context, query types.ListQuery) ([]Entity, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries, err := os.ReadDir(s.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".json") {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+	if query.Page.Offset > 0 {
+		names = names[min(query.Page.Offset, len(names)):]
+	}
+	if query.Page.Limit > 0 && len(names) > query.Page.Limit {
+		names = names[:query.Page.Limit]
+	}
+	items := make([]Entity, 0, len(names))
+	for _, name := range names {
+		item, err := s.read(filepath.Join(s.dir, name))
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func (s *JSONDir[Entity]) Update(_ context.Context, items []Entity) ([]Entity, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, item := range items {
+		path, err := s.path(s.getID(item))
+		if err != nil {
+			return nil, err
+		}
+		if err := s.write(path, item); err != nil {
+			return nil, err
+		}
+	}
+	return items, nil
+}
+
+func (s *JSONDir[Entity]) Delete(_ context.Context, ids []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, id := range ids {
+		path, err := s.path(id)
+		if err != nil {
+			return err
+		}
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *JSONDir[Entity]) path(id string) (string, error) {
+	if id == "" || id != filepath.Base(id) || strings.HasPrefix(id, ".") {
+		return "", fmt.Errorf("invalid id %q for a file store", id)
+	}
+	return filepath.Join(s.dir, id+".json"), nil
+}
+
+func (s *JSONDir[Entity]) read(path string) (Entity, error) {
+	var item Entity
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return item, err
+	}
+	if err := json.Unmarshal(data, &item); err != nil {
+		return item, fmt.Errorf("%s: %w", path, err)
+	}
+	return item, nil
+}
+
+// write replaces path atomically so a crashed write never leaves half a file.
+func (s *JSONDir[Entity]) write(path string, item Entity) error {
+	data, err := json.MarshalIndent(item, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(s.dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
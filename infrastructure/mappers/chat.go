@@ -50,6 +50,8 @@ func ChatMessageToRow(m types.ChatMessage) models.ChatMessageRow {
 		PresetID:         m.PresetID,
 		PresetName:       m.PresetName,
 		ModelRole:        m.ModelRole,
+		RouteTier:        m.RouteTier,
+		RouteReason:      m.RouteReason,
 		Steps:            m.Steps,
 		Attachments:      att,
 		CreatedAt:        m.CreatedAt,
@@ -76,6 +78,8 @@ func ChatMessageFromRow(r models.ChatMessageRow) types.ChatMessage {
 		PresetID:         r.PresetID,
 		PresetName:       r.PresetName,
 		ModelRole:        r.ModelRole,
+		RouteTier:        r.RouteTier,
+		RouteReason:      r.RouteReason,
 		Steps:            r.Steps,
 		Attachments:      att,
 		CreatedAt:        r.CreatedAt,
@@ -0,0 +1,41 @@
+package mappers
+
+import (
+	"encoding/json"
+	"time"
+
+	"mantis/core/types"
+	"mantis/infrastructure/models"
+)
+
+func LLMCacheEntryToRow(e types.LLMCacheEntry) models.LLMCacheEntryRow {
+	createdAt := time.Now().UTC()
+	if e.CreatedAt != nil {
+		createdAt = *e.CreatedAt
+	}
+	events := e.Events
+	if events == nil {
+		events = []types.StreamEvent{}
+	}
+	raw, _ := json.Marshal(events)
+	return models.LLMCacheEntryRow{
+		ID:        e.ID,
+		Provider:  e.Provider,
+		Model:     e.Model,
+		Events:    raw,
+		CreatedAt: createdAt,
+	}
+}
+
+func LLMCacheEntryFromRow(r models.LLMCacheEntryRow) types.LLMCacheEntry {
+	var events []types.StreamEvent
+	_ = json.Unmarshal(r.Events, &events)
+	createdAt := r.CreatedAt
+	return types.LLMCacheEntry{
+		ID:        r.ID,
+		Provider:  r.Provider,
+		Model:     r.Model,
+		Events:    events,
+		CreatedAt: &createdAt,
+	}
+}
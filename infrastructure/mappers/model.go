@@ -1,30 +1,52 @@
 package mappers
 
 import (
+	"encoding/json"
+
 	"mantis/core/types"
 	"mantis/infrastructure/models"
 )
 
 func ModelToRow(m types.Model) models.ModelRow {
+	generation, _ := json.Marshal(m.Generation)
+	var price json.RawMessage
+	if m.Price != nil {
+		price, _ = json.Marshal(m.Price)
+	}
 	return models.ModelRow{
 		ID:            m.ID,
 		ConnectionID:  m.ConnectionID,
 		Name:          m.Name,
 		ThinkingMode:  m.ThinkingMode,
+		ToolCalling:   m.ToolCalling,
+		Vision:        m.Vision,
 		ContextWindow: m.ContextWindow,
 		ReserveTokens: m.ReserveTokens,
 		CompactTokens: m.CompactTokens,
+		Generation:    generation,
+		Price:         price,
 	}
 }
 
 func ModelFromRow(r models.ModelRow) types.Model {
+	var generation types.GenerationOptions
+	_ = json.Unmarshal(r.Generation, &generation)
+	var price *types.ModelPrice
+	if len(r.Price) > 0 && string(r.Price) != "null" {
+		price = &types.ModelPrice{}
+		_ = json.Unmarshal(r.Price, price)
+	}
 	return types.Model{
 		ID:            r.ID,
 		ConnectionID:  r.ConnectionID,
 		Name:          r.Name,
 		ThinkingMode:  r.ThinkingMode,
+		ToolCalling:   r.ToolCalling,
+		Vision:        r.Vision,
 		ContextWindow: r.ContextWindow,
 		ReserveTokens: r.ReserveTokens,
 		CompactTokens: r.CompactTokens,
+		Generation:    generation,
+		Price:         price,
 	}
 }
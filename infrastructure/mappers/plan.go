@@ -13,6 +13,18 @@ func PlanToRow(p types.Plan) models.PlanRow {
 	if len(params) == 0 {
 		params = json.RawMessage(`{}`)
 	}
+	var webhook json.RawMessage
+	if p.Webhook != nil {
+		webhook, _ = json.Marshal(p.Webhook)
+	}
+	var notify json.RawMessage
+	if p.Notify != nil {
+		notify, _ = json.Marshal(p.Notify)
+	}
+	var sla json.RawMessage
+	if p.SLA != nil {
+		sla, _ = json.Marshal(p.SLA)
+	}
 	return models.PlanRow{
 		ID:          p.ID,
 		Name:        p.Name,
@@ -21,6 +33,15 @@ func PlanToRow(p types.Plan) models.PlanRow {
 		Enabled:     p.Enabled,
 		Parameters:  params,
 		Graph:       graph,
+		Timezone:    p.Timezone,
+		Jitter:      p.Jitter,
+		CatchUp:     string(planCatchUp(p.CatchUp)),
+		Concurrency: string(planConcurrency(p.Concurrency)),
+		Webhook:     webhook,
+		Notify:      notify,
+		SLA:         sla,
+		PresetID:    p.PresetID,
+		ModelID:     p.ModelID,
 	}
 }
 
@@ -37,6 +58,27 @@ func PlanFromRow(r models.PlanRow) types.Plan {
 	if len(params) == 0 {
 		params = json.RawMessage(`{}`)
 	}
+	var webhook *types.PlanWebhook
+	if len(r.Webhook) > 0 && string(r.Webhook) != "null" {
+		webhook = &types.PlanWebhook{}
+		if err := json.Unmarshal(r.Webhook, webhook); err != nil {
+			webhook = nil
+		}
+	}
+	var notify *types.PlanNotify
+	if len(r.Notify) > 0 && string(r.Notify) != "null" {
+		notify = &types.PlanNotify{}
+		if err := json.Unmarshal(r.Notify, notify); err != nil {
+			notify = nil
+		}
+	}
+	var sla *types.PlanSLA
+	if len(r.SLA) > 0 && string(r.SLA) != "null" {
+		sla = &types.PlanSLA{}
+		if err := json.Unmarshal(r.SLA, sla); err != nil {
+			sla = nil
+		}
+	}
 	return types.Plan{
 		ID:          r.ID,
 		Name:        r.Name,
@@ -45,5 +87,28 @@ func PlanFromRow(r models.PlanRow) types.Plan {
 		Enabled:     r.Enabled,
 		Parameters:  params,
 		Graph:       graph,
+		Timezone:    r.Timezone,
+		Jitter:      r.Jitter,
+		CatchUp:     planCatchUp(types.PlanCatchUp(r.CatchUp)),
+		Concurrency: planConcurrency(types.PlanConcurrency(r.Concurrency)),
+		Webhook:     webhook,
+		Notify:      notify,
+		SLA:         sla,
+		PresetID:    r.PresetID,
+		ModelID:     r.ModelID,
+	}
+}
+
+func planConcurrency(c types.PlanConcurrency) types.PlanConcurrency {
+	if c == "" {
+		return types.PlanConcurrencyAllow
+	}
+	return c
+}
+
+func planCatchUp(c types.PlanCatchUp) types.PlanCatchUp {
+	if c == "" {
+		return types.PlanCatchUpSkip
 	}
+	return c
 }
@@ -0,0 +1,45 @@
+package mappers
+
+import (
+	"time"
+
+	"mantis/core/types"
+	"mantis/infrastructure/models"
+)
+
+func PlanBlackoutToRow(b types.PlanBlackout) models.PlanBlackoutRow {
+	createdAt := time.Now().UTC()
+	if b.CreatedAt != nil {
+		createdAt = *b.CreatedAt
+	}
+	return models.PlanBlackoutRow{
+		ID:        b.ID,
+		Name:      b.Name,
+		PlanID:    b.PlanID,
+		Enabled:   b.Enabled,
+		Action:    string(b.Action),
+		StartsAt:  b.StartsAt,
+		EndsAt:    b.EndsAt,
+		Schedule:  b.Schedule,
+		Duration:  b.Duration,
+		Timezone:  b.Timezone,
+		CreatedAt: createdAt,
+	}
+}
+
+func PlanBlackoutFromRow(r models.PlanBlackoutRow) types.PlanBlackout {
+	createdAt := r.CreatedAt
+	return types.PlanBlackout{
+		ID:        r.ID,
+		Name:      r.Name,
+		PlanID:    r.PlanID,
+		Enabled:   r.Enabled,
+		Action:    types.PlanBlackoutAction(r.Action),
+		StartsAt:  r.StartsAt,
+		EndsAt:    r.EndsAt,
+		Schedule:  r.Schedule,
+		Duration:  r.Duration,
+		Timezone:  r.Timezone,
+		CreatedAt: &createdAt,
+	}
+}
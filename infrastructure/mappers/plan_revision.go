@@ -0,0 +1,39 @@
+package mappers
+
+import (
+	"encoding/json"
+
+	"mantis/core/types"
+	"mantis/infrastructure/models"
+)
+
+func PlanRevisionToRow(r types.PlanRevision) models.PlanRevisionRow {
+	snapshot, _ := json.Marshal(r.Plan)
+	return models.PlanRevisionRow{
+		ID:        r.ID,
+		PlanID:    r.PlanID,
+		Version:   r.Version,
+		Snapshot:  snapshot,
+		CreatedBy: r.CreatedBy,
+		CreatedAt: r.CreatedAt,
+	}
+}
+
+func PlanRevisionFromRow(r models.PlanRevisionRow) types.PlanRevision {
+	var plan types.Plan
+	_ = json.Unmarshal(r.Snapshot, &plan)
+	if plan.Graph.Nodes == nil {
+		plan.Graph.Nodes = []types.PlanNode{}
+	}
+	if plan.Graph.Edges == nil {
+		plan.Graph.Edges = []types.PlanEdge{}
+	}
+	return types.PlanRevision{
+		ID:        r.ID,
+		PlanID:    r.PlanID,
+		Version:   r.Version,
+		Plan:      plan,
+		CreatedBy: r.CreatedBy,
+		CreatedAt: r.CreatedAt,
+	}
+}
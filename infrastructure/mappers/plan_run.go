@@ -13,15 +13,22 @@ func PlanRunToRow(r types.PlanRun) models.PlanRunRow {
 	if len(input) == 0 || string(input) == "null" {
 		input = json.RawMessage(`{}`)
 	}
+	var mocks json.RawMessage
+	if len(r.Mocks) > 0 {
+		mocks, _ = json.Marshal(r.Mocks)
+	}
 	return models.PlanRunRow{
-		ID:         r.ID,
-		PlanID:     r.PlanID,
-		Status:     r.Status,
-		Trigger:    r.Trigger,
-		Input:      input,
-		Steps:      steps,
-		StartedAt:  r.StartedAt,
-		FinishedAt: r.FinishedAt,
+		ID:             r.ID,
+		PlanID:         r.PlanID,
+		Status:         r.Status,
+		Trigger:        r.Trigger,
+		Input:          input,
+		IdempotencyKey: r.IdempotencyKey,
+		Mocks:          mocks,
+		Reason:         r.Reason,
+		Steps:          steps,
+		StartedAt:      r.StartedAt,
+		FinishedAt:     r.FinishedAt,
 	}
 }
 
@@ -36,14 +43,21 @@ func PlanRunFromRow(r models.PlanRunRow) types.PlanRun {
 	if input == nil {
 		input = map[string]any{}
 	}
+	var mocks map[string]string
+	if len(r.Mocks) > 0 {
+		_ = json.Unmarshal(r.Mocks, &mocks)
+	}
 	return types.PlanRun{
-		ID:         r.ID,
-		PlanID:     r.PlanID,
-		Status:     r.Status,
-		Trigger:    r.Trigger,
-		Input:      input,
-		Steps:      steps,
-		StartedAt:  r.StartedAt,
-		FinishedAt: r.FinishedAt,
+		ID:             r.ID,
+		PlanID:         r.PlanID,
+		Status:         r.Status,
+		Trigger:        r.Trigger,
+		Input:          input,
+		IdempotencyKey: r.IdempotencyKey,
+		Mocks:          mocks,
+		Reason:         r.Reason,
+		Steps:          steps,
+		StartedAt:      r.StartedAt,
+		FinishedAt:     r.FinishedAt,
 	}
 }
@@ -146,3 +146,14 @@ func TestPlanRunRoundTrip(t *testing.T) {
 		t.Fatal("Step 2 status")
 	}
 }
+
+func TestPlanRunIdempotencyKey_RoundTrip(t *testing.T) {
+	run := types.PlanRun{ID: "r1", Trigger: "webhook:alertmanager", IdempotencyKey: "group-1"}
+	row := PlanRunToRow(run)
+	if row.IdempotencyKey != "group-1" {
+		t.Fatalf("row key: %q", row.IdempotencyKey)
+	}
+	if got := PlanRunFromRow(row); got.IdempotencyKey != "group-1" || got.Trigger != "webhook:alertmanager" {
+		t.Fatalf("round trip mismatch: %+v", got)
+	}
+}
@@ -0,0 +1,45 @@
+package mappers
+
+import (
+	"encoding/json"
+	"time"
+
+	"mantis/core/types"
+	"mantis/infrastructure/models"
+)
+
+func PlanTemplateToRow(t types.PlanTemplate) models.PlanTemplateRow {
+	snapshot, _ := json.Marshal(t.Plan)
+	createdAt := time.Now().UTC()
+	if t.CreatedAt != nil {
+		createdAt = *t.CreatedAt
+	}
+	return models.PlanTemplateRow{
+		ID:          t.ID,
+		Name:        t.Name,
+		Description: t.Description,
+		Snapshot:    snapshot,
+		CreatedBy:   t.CreatedBy,
+		CreatedAt:   createdAt,
+	}
+}
+
+func PlanTemplateFromRow(r models.PlanTemplateRow) types.PlanTemplate {
+	var plan types.Plan
+	_ = json.Unmarshal(r.Snapshot, &plan)
+	if plan.Graph.Nodes == nil {
+		plan.Graph.Nodes = []types.PlanNode{}
+	}
+	if plan.Graph.Edges == nil {
+		plan.Graph.Edges = []types.PlanEdge{}
+	}
+	createdAt := r.CreatedAt
+	return types.PlanTemplate{
+		ID:          r.ID,
+		Name:        r.Name,
+		Description: r.Description,
+		Plan:        plan,
+		CreatedBy:   r.CreatedBy,
+		CreatedAt:   &createdAt,
+	}
+}
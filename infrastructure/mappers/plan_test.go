@@ -153,3 +153,47 @@ func TestPlanRoundTrip(t *testing.T) {
 		t.Fatalf("Prompt mismatch: %q vs %q", restored.Graph.Nodes[0].Prompt, original.Graph.Nodes[0].Prompt)
 	}
 }
+
+func TestPlanWebhook_RoundTrip(t *testing.T) {
+	plan := types.Plan{
+		ID: "p1",
+		Webhook: &types.PlanWebhook{
+			Enabled:        true,
+			Secret:         "s3cret",
+			Mapping:        map[string]string{"host": "$.alerts[0].labels.instance"},
+			IdempotencyKey: "$.groupKey",
+		},
+	}
+	got := PlanFromRow(PlanToRow(plan))
+	if got.Webhook == nil {
+		t.Fatal("expected webhook to survive round trip")
+	}
+	if !got.Webhook.Enabled || got.Webhook.Secret != "s3cret" || got.Webhook.IdempotencyKey != "$.groupKey" {
+		t.Fatalf("webhook mismatch: %+v", got.Webhook)
+	}
+	if got.Webhook.Mapping["host"] != "$.alerts[0].labels.instance" {
+		t.Fatalf("mapping mismatch: %v", got.Webhook.Mapping)
+	}
+}
+
+func TestPlanWebhook_NilStaysNil(t *testing.T) {
+	row := PlanToRow(types.Plan{ID: "p1"})
+	if row.Webhook != nil {
+		t.Fatalf("expected NULL webhook column, got %s", string(row.Webhook))
+	}
+	if PlanFromRow(row).Webhook != nil {
+		t.Fatal("expected nil webhook")
+	}
+}
+
+func TestPlanConcurrency_DefaultsToAllow(t *testing.T) {
+	if got := PlanFromRow(models.PlanRow{ID: "p1"}).Concurrency; got != types.PlanConcurrencyAllow {
+		t.Fatalf("expected allow, got %q", got)
+	}
+	if got := PlanToRow(types.Plan{ID: "p1"}).Concurrency; got != "allow" {
+		t.Fatalf("expected allow, got %q", got)
+	}
+	if got := PlanFromRow(PlanToRow(types.Plan{ID: "p1", Concurrency: types.PlanConcurrencyQueue})).Concurrency; got != types.PlanConcurrencyQueue {
+		t.Fatalf("expected queue, got %q", got)
+	}
+}
@@ -1,32 +1,53 @@
 package mappers
 
 import (
+	"encoding/json"
+
 	"mantis/core/types"
 	"mantis/infrastructure/models"
 )
 
 func PresetToRow(p types.Preset) models.PresetRow {
+	ids := p.FallbackModelIDs
+	if ids == nil {
+		ids = []string{}
+	}
+	fallbacks, _ := json.Marshal(ids)
+	generation, _ := json.Marshal(p.Generation)
+	routing, _ := json.Marshal(p.Routing)
 	return models.PresetRow{
-		ID:              p.ID,
-		Name:            p.Name,
-		ChatModelID:     p.ChatModelID,
-		SummaryModelID:  p.SummaryModelID,
-		ImageModelID:    p.ImageModelID,
-		FallbackModelID: p.FallbackModelID,
-		Temperature:     p.Temperature,
-		SystemPrompt:    p.SystemPrompt,
+		ID:               p.ID,
+		Name:             p.Name,
+		ChatModelID:      p.ChatModelID,
+		SummaryModelID:   p.SummaryModelID,
+		ImageModelID:     p.ImageModelID,
+		FallbackModelID:  p.FallbackModelID,
+		FallbackModelIDs: fallbacks,
+		Temperature:      p.Temperature,
+		SystemPrompt:     p.SystemPrompt,
+		Generation:       generation,
+		Routing:          routing,
 	}
 }
 
 func PresetFromRow(r models.PresetRow) types.Preset {
+	var fallbacks []string
+	_ = json.Unmarshal(r.FallbackModelIDs, &fallbacks)
+	var generation types.GenerationOptions
+	_ = json.Unmarshal(r.Generation, &generation)
+	var routing types.PresetRouting
+	_ = json.Unmarshal(r.Routing, &routing)
 	return types.Preset{
-		ID:              r.ID,
-		Name:            r.Name,
-		ChatModelID:     r.ChatModelID,
-		SummaryModelID:  r.SummaryModelID,
-		ImageModelID:    r.ImageModelID,
-		FallbackModelID: r.FallbackModelID,
-		Temperature:     r.Temperature,
-		SystemPrompt:    r.SystemPrompt,
+		ID:               r.ID,
+		Name:             r.Name,
+		ChatModelID:      r.ChatModelID,
+		SummaryModelID:   r.SummaryModelID,
+		ImageModelID:     r.ImageModelID,
+		FallbackModelID:  r.FallbackModelID,
+		FallbackModelIDs: fallbacks,
+		Temperature:      r.Temperature,
+		SystemPrompt:     r.SystemPrompt,
+		Generation:       generation,
+		Routing:          routing,
 	}
 }
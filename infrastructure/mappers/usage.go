@@ -0,0 +1,90 @@
+package mappers
+
+import (
+	"time"
+
+	"mantis/core/types"
+	"mantis/infrastructure/models"
+)
+
+func UsageRecordToRow(r types.UsageRecord) models.UsageRecordRow {
+	createdAt := time.Now().UTC()
+	if r.CreatedAt != nil {
+		createdAt = *r.CreatedAt
+	}
+	return models.UsageRecordRow{
+		ID:               r.ID,
+		Caller:           r.Caller,
+		ModelID:          r.ModelID,
+		ModelName:        r.ModelName,
+		ConnectionID:     r.ConnectionID,
+		SessionID:        r.SessionID,
+		MessageID:        r.MessageID,
+		PlanID:           r.PlanID,
+		PlanRunID:        r.PlanRunID,
+		PromptTokens:     r.PromptTokens,
+		CompletionTokens: r.CompletionTokens,
+		CachedTokens:     r.CachedTokens,
+		CacheWriteTokens: r.CacheWriteTokens,
+		Cost:             r.Cost,
+		Day:              r.Day,
+		CreatedAt:        createdAt,
+	}
+}
+
+func UsageRecordFromRow(r models.UsageRecordRow) types.UsageRecord {
+	createdAt := r.CreatedAt
+	return types.UsageRecord{
+		ID:               r.ID,
+		Caller:           r.Caller,
+		ModelID:          r.ModelID,
+		ModelName:        r.ModelName,
+		ConnectionID:     r.ConnectionID,
+		SessionID:        r.SessionID,
+		MessageID:        r.MessageID,
+		PlanID:           r.PlanID,
+		PlanRunID:        r.PlanRunID,
+		PromptTokens:     r.PromptTokens,
+		CompletionTokens: r.CompletionTokens,
+		CachedTokens:     r.CachedTokens,
+		CacheWriteTokens: r.CacheWriteTokens,
+		Cost:             r.Cost,
+		Day:              r.Day,
+		CreatedAt:        &createdAt,
+	}
+}
+
+func UsageBudgetToRow(b types.UsageBudget) models.UsageBudgetRow {
+	createdAt := time.Now().UTC()
+	if b.CreatedAt != nil {
+		createdAt = *b.CreatedAt
+	}
+	return models.UsageBudgetRow{
+		ID:        b.ID,
+		Name:      b.Name,
+		Scope:     string(b.Scope),
+		ScopeID:   b.ScopeID,
+		Period:    string(b.Period),
+		MaxCost:   b.MaxCost,
+		MaxTokens: b.MaxTokens,
+		Action:    string(b.Action),
+		Enabled:   b.Enabled,
+		CreatedAt: createdAt,
+	}
+}
+
+func UsageBudgetFromRow(r models.UsageBudgetRow) types.UsageBudget {
+	createdAt := r.CreatedAt
+	return types.UsageBudget{
+		ID:        r.ID,
+		Name:      r.Name,
+		Scope:     types.UsageBudgetScope(r.Scope),
+		ScopeID:   r.ScopeID,
+		Period:    types.UsageBudgetPeriod(r.Period),
+		MaxCost:   r.MaxCost,
+		MaxTokens: r.MaxTokens,
+		Action:    types.UsageBudgetAction(r.Action),
+		Enabled:   r.Enabled,
+		CreatedAt: &createdAt,
+	}
+}
@@ -32,6 +32,8 @@ type ChatMessageRow struct {
 	PresetID         string          `bun:"preset_id"`
 	PresetName       string          `bun:"preset_name"`
 	ModelRole        string          `bun:"model_role"`
+	RouteTier        string          `bun:"route_tier"`
+	RouteReason      string          `bun:"route_reason"`
 	Steps            json.RawMessage `bun:"steps,type:jsonb"`
 	Attachments      json.RawMessage `bun:"attachments,type:jsonb"`
 	CreatedAt        time.Time       `bun:"created_at"`
@@ -0,0 +1,17 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type LLMCacheEntryRow struct {
+	bun.BaseModel `bun:"table:llm_cache"`
+	ID            string          `bun:"id,pk"`
+	Provider      string          `bun:"provider"`
+	Model         string          `bun:"model"`
+	Events        json.RawMessage `bun:"events,type:jsonb"`
+	CreatedAt     time.Time       `bun:"created_at"`
+}
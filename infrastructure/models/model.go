@@ -1,14 +1,22 @@
 package models
 
-import "github.com/uptrace/bun"
+import (
+	"encoding/json"
+
+	"github.com/uptrace/bun"
+)
 
 type ModelRow struct {
 	bun.BaseModel `bun:"table:models"`
-	ID            string `bun:"id,pk"`
-	ConnectionID  string `bun:"connection_id"`
-	Name          string `bun:"name"`
-	ThinkingMode  string `bun:"thinking_mode"`
-	ContextWindow int    `bun:"context_window"`
-	ReserveTokens int    `bun:"reserve_tokens"`
-	CompactTokens int    `bun:"compact_tokens"`
+	ID            string          `bun:"id,pk"`
+	ConnectionID  string          `bun:"connection_id"`
+	Name          string          `bun:"name"`
+	ThinkingMode  string          `bun:"thinking_mode"`
+	ToolCalling   string          `bun:"tool_calling"`
+	Vision        bool            `bun:"vision"`
+	ContextWindow int             `bun:"context_window"`
+	ReserveTokens int             `bun:"reserve_tokens"`
+	CompactTokens int             `bun:"compact_tokens"`
+	Generation    json.RawMessage `bun:"generation,type:jsonb"`
+	Price         json.RawMessage `bun:"price,type:jsonb,nullzero"`
 }
@@ -15,4 +15,13 @@ type PlanRow struct {
 	Enabled       bool            `bun:"enabled"`
 	Parameters    json.RawMessage `bun:"parameters,type:jsonb"`
 	Graph         json.RawMessage `bun:"graph,type:jsonb"`
+	Timezone      string          `bun:"timezone"`
+	Jitter        string          `bun:"jitter"`
+	CatchUp       string          `bun:"catch_up"`
+	Concurrency   string          `bun:"concurrency"`
+	Webhook       json.RawMessage `bun:"webhook,type:jsonb,nullzero"`
+	Notify        json.RawMessage `bun:"notify,type:jsonb,nullzero"`
+	SLA           json.RawMessage `bun:"sla,type:jsonb,nullzero"`
+	PresetID      string          `bun:"preset_id"`
+	ModelID       string          `bun:"model_id"`
 }
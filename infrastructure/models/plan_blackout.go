@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type PlanBlackoutRow struct {
+	bun.BaseModel `bun:"table:plan_blackouts"`
+	ID            string     `bun:"id,pk"`
+	Name          string     `bun:"name"`
+	PlanID        string     `bun:"plan_id,nullzero"`
+	Enabled       bool       `bun:"enabled"`
+	Action        string     `bun:"action"`
+	StartsAt      *time.Time `bun:"starts_at"`
+	EndsAt        *time.Time `bun:"ends_at"`
+	Schedule      string     `bun:"schedule"`
+	Duration      string     `bun:"duration"`
+	Timezone      string     `bun:"timezone"`
+	CreatedAt     time.Time  `bun:"created_at"`
+}
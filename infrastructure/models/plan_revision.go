@@ -0,0 +1,18 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type PlanRevisionRow struct {
+	bun.BaseModel `bun:"table:plan_revisions"`
+	ID            string          `bun:"id,pk"`
+	PlanID        string          `bun:"plan_id"`
+	Version       int             `bun:"version"`
+	Snapshot      json.RawMessage `bun:"snapshot,type:jsonb"`
+	CreatedBy     string          `bun:"created_by"`
+	CreatedAt     time.Time       `bun:"created_at"`
+}
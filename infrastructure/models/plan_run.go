@@ -8,13 +8,16 @@ import (
 )
 
 type PlanRunRow struct {
-	bun.BaseModel `bun:"table:plan_runs"`
-	ID            string          `bun:"id,pk"`
-	PlanID        string          `bun:"plan_id"`
-	Status        string          `bun:"status"`
-	Trigger       string          `bun:"trigger"`
-	Input         json.RawMessage `bun:"input,type:jsonb"`
-	Steps         json.RawMessage `bun:"steps,type:jsonb"`
-	StartedAt     time.Time       `bun:"started_at"`
-	FinishedAt    *time.Time      `bun:"finished_at"`
+	bun.BaseModel  `bun:"table:plan_runs"`
+	ID             string          `bun:"id,pk"`
+	PlanID         string          `bun:"plan_id"`
+	Status         string          `bun:"status"`
+	Trigger        string          `bun:"trigger"`
+	Input          json.RawMessage `bun:"input,type:jsonb"`
+	IdempotencyKey string          `bun:"idempotency_key"`
+	Mocks          json.RawMessage `bun:"mocks,type:jsonb,nullzero"`
+	Reason         string          `bun:"reason"`
+	Steps          json.RawMessage `bun:"steps,type:jsonb"`
+	StartedAt      time.Time       `bun:"started_at"`
+	FinishedAt     *time.Time      `bun:"finished_at"`
 }
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type PlanTemplateRow struct {
+	bun.BaseModel `bun:"table:plan_templates"`
+	ID            string          `bun:"id,pk"`
+	Name          string          `bun:"name"`
+	Description   string          `bun:"description"`
+	Snapshot      json.RawMessage `bun:"snapshot,type:jsonb"`
+	CreatedBy     string          `bun:"created_by"`
+	CreatedAt     time.Time       `bun:"created_at"`
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type UsageRecordRow struct {
+	bun.BaseModel    `bun:"table:usage_records"`
+	ID               string    `bun:"id,pk"`
+	Caller           string    `bun:"caller"`
+	ModelID          string    `bun:"model_id"`
+	ModelName        string    `bun:"model_name"`
+	ConnectionID     string    `bun:"connection_id"`
+	SessionID        string    `bun:"session_id"`
+	MessageID        string    `bun:"message_id"`
+	PlanID           string    `bun:"plan_id"`
+	PlanRunID        string    `bun:"plan_run_id"`
+	PromptTokens     int       `bun:"prompt_tokens"`
+	CompletionTokens int       `bun:"completion_tokens"`
+	CachedTokens     int       `bun:"cached_tokens"`
+	CacheWriteTokens int       `bun:"cache_write_tokens"`
+	Cost             float64   `bun:"cost"`
+	Day              string    `bun:"day"`
+	CreatedAt        time.Time `bun:"created_at"`
+}
+
+type UsageBudgetRow struct {
+	bun.BaseModel `bun:"table:usage_budgets"`
+	ID            string    `bun:"id,pk"`
+	Name          string    `bun:"name"`
+	Scope         string    `bun:"scope"`
+	ScopeID       string    `bun:"scope_id"`
+	Period        string    `bun:"period"`
+	MaxCost       float64   `bun:"max_cost"`
+	MaxTokens     int       `bun:"max_tokens"`
+	Action        string    `bun:"action"`
+	Enabled       bool      `bun:"enabled"`
+	CreatedAt     time.Time `bun:"created_at"`
+}
@@ -0,0 +1,21 @@
+-- +goose Up
+
+ALTER TABLE plans
+    ADD COLUMN IF NOT EXISTS webhook JSONB;
+
+ALTER TABLE plan_runs
+    ADD COLUMN IF NOT EXISTS idempotency_key TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_plan_runs_idempotency
+    ON plan_runs(plan_id, idempotency_key)
+    WHERE idempotency_key <> '';
+
+-- +goose Down
+
+DROP INDEX IF EXISTS idx_plan_runs_idempotency;
+
+ALTER TABLE plan_runs
+    DROP COLUMN IF EXISTS idempotency_key;
+
+ALTER TABLE plans
+    DROP COLUMN IF EXISTS webhook;
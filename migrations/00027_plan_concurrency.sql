@@ -0,0 +1,9 @@
+-- +goose Up
+
+ALTER TABLE plans
+    ADD COLUMN IF NOT EXISTS concurrency TEXT NOT NULL DEFAULT 'allow';
+
+-- +goose Down
+
+ALTER TABLE plans
+    DROP COLUMN IF EXISTS concurrency;
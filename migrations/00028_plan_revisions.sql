@@ -0,0 +1,15 @@
+-- +goose Up
+
+CREATE TABLE IF NOT EXISTS plan_revisions (
+    id          TEXT PRIMARY KEY,
+    plan_id     TEXT NOT NULL REFERENCES plans(id) ON DELETE CASCADE,
+    version     INTEGER NOT NULL,
+    snapshot    JSONB NOT NULL,
+    created_by  TEXT NOT NULL DEFAULT '',
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (plan_id, version)
+);
+
+-- +goose Down
+
+DROP TABLE IF EXISTS plan_revisions;
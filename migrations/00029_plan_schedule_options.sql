@@ -0,0 +1,13 @@
+-- +goose Up
+
+ALTER TABLE plans
+    ADD COLUMN IF NOT EXISTS timezone TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS jitter TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS catch_up TEXT NOT NULL DEFAULT 'skip';
+
+-- +goose Down
+
+ALTER TABLE plans
+    DROP COLUMN IF EXISTS catch_up,
+    DROP COLUMN IF EXISTS jitter,
+    DROP COLUMN IF EXISTS timezone;
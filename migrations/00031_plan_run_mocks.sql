@@ -0,0 +1,9 @@
+-- +goose Up
+
+ALTER TABLE plan_runs
+    ADD COLUMN IF NOT EXISTS mocks JSONB;
+
+-- +goose Down
+
+ALTER TABLE plan_runs
+    DROP COLUMN IF EXISTS mocks;
@@ -0,0 +1,9 @@
+-- +goose Up
+
+ALTER TABLE plans
+    ADD COLUMN IF NOT EXISTS sla JSONB;
+
+-- +goose Down
+
+ALTER TABLE plans
+    DROP COLUMN IF EXISTS sla;
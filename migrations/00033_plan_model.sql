@@ -0,0 +1,11 @@
+-- +goose Up
+
+ALTER TABLE plans
+    ADD COLUMN IF NOT EXISTS preset_id TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS model_id TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+
+ALTER TABLE plans
+    DROP COLUMN IF EXISTS model_id,
+    DROP COLUMN IF EXISTS preset_id;
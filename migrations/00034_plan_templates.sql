@@ -0,0 +1,14 @@
+-- +goose Up
+
+CREATE TABLE IF NOT EXISTS plan_templates (
+    id          TEXT PRIMARY KEY,
+    name        TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    snapshot    JSONB NOT NULL,
+    created_by  TEXT NOT NULL DEFAULT '',
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- +goose Down
+
+DROP TABLE IF EXISTS plan_templates;
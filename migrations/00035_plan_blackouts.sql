@@ -0,0 +1,25 @@
+-- +goose Up
+
+CREATE TABLE IF NOT EXISTS plan_blackouts (
+    id         TEXT PRIMARY KEY,
+    name       TEXT NOT NULL,
+    plan_id    TEXT REFERENCES plans(id) ON DELETE CASCADE,
+    enabled    BOOLEAN NOT NULL DEFAULT true,
+    action     TEXT NOT NULL DEFAULT 'skip',
+    starts_at  TIMESTAMPTZ,
+    ends_at    TIMESTAMPTZ,
+    schedule   TEXT NOT NULL DEFAULT '',
+    duration   TEXT NOT NULL DEFAULT '',
+    timezone   TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+ALTER TABLE plan_runs
+    ADD COLUMN IF NOT EXISTS reason TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+
+ALTER TABLE plan_runs
+    DROP COLUMN IF EXISTS reason;
+
+DROP TABLE IF EXISTS plan_blackouts;
@@ -0,0 +1,9 @@
+-- +goose Up
+
+ALTER TABLE presets
+    ADD COLUMN IF NOT EXISTS fallback_model_ids JSONB NOT NULL DEFAULT '[]';
+
+-- +goose Down
+
+ALTER TABLE presets
+    DROP COLUMN IF EXISTS fallback_model_ids;
@@ -0,0 +1,15 @@
+-- +goose Up
+
+ALTER TABLE models
+    ADD COLUMN IF NOT EXISTS generation JSONB NOT NULL DEFAULT '{}';
+
+ALTER TABLE presets
+    ADD COLUMN IF NOT EXISTS generation JSONB NOT NULL DEFAULT '{}';
+
+-- +goose Down
+
+ALTER TABLE presets
+    DROP COLUMN IF EXISTS generation;
+
+ALTER TABLE models
+    DROP COLUMN IF EXISTS generation;
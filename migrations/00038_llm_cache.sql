@@ -0,0 +1,13 @@
+-- +goose Up
+
+CREATE TABLE IF NOT EXISTS llm_cache (
+    id         TEXT PRIMARY KEY,
+    provider   TEXT NOT NULL DEFAULT '',
+    model      TEXT NOT NULL DEFAULT '',
+    events     JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- +goose Down
+
+DROP TABLE IF EXISTS llm_cache;
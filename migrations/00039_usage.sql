@@ -0,0 +1,44 @@
+-- +goose Up
+
+CREATE TABLE IF NOT EXISTS usage_records (
+    id                 TEXT PRIMARY KEY,
+    caller             TEXT NOT NULL DEFAULT '',
+    model_id           TEXT NOT NULL DEFAULT '',
+    model_name         TEXT NOT NULL DEFAULT '',
+    connection_id      TEXT NOT NULL DEFAULT '',
+    session_id         TEXT NOT NULL DEFAULT '',
+    message_id         TEXT NOT NULL DEFAULT '',
+    plan_id            TEXT NOT NULL DEFAULT '',
+    plan_run_id        TEXT NOT NULL DEFAULT '',
+    prompt_tokens      INTEGER NOT NULL DEFAULT 0,
+    completion_tokens  INTEGER NOT NULL DEFAULT 0,
+    cached_tokens      INTEGER NOT NULL DEFAULT 0,
+    cache_write_tokens INTEGER NOT NULL DEFAULT 0,
+    cost               DOUBLE PRECISION NOT NULL DEFAULT 0,
+    day                TEXT NOT NULL,
+    created_at         TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS usage_records_day_idx ON usage_records (day);
+CREATE INDEX IF NOT EXISTS usage_records_plan_id_idx ON usage_records (plan_id) WHERE plan_id <> '';
+
+CREATE TABLE IF NOT EXISTS usage_budgets (
+    id         TEXT PRIMARY KEY,
+    name       TEXT NOT NULL DEFAULT '',
+    scope      TEXT NOT NULL DEFAULT 'global',
+    scope_id   TEXT NOT NULL DEFAULT '',
+    period     TEXT NOT NULL DEFAULT 'day',
+    max_cost   DOUBLE PRECISION NOT NULL DEFAULT 0,
+    max_tokens INTEGER NOT NULL DEFAULT 0,
+    action     TEXT NOT NULL DEFAULT 'warn',
+    enabled    BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+ALTER TABLE models ADD COLUMN IF NOT EXISTS price JSONB;
+
+-- +goose Down
+
+ALTER TABLE models DROP COLUMN IF EXISTS price;
+DROP TABLE IF EXISTS usage_budgets;
+DROP TABLE IF EXISTS usage_records;
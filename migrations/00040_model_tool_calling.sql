@@ -0,0 +1,9 @@
+-- +goose Up
+
+ALTER TABLE models
+    ADD COLUMN IF NOT EXISTS tool_calling TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+
+ALTER TABLE models
+    DROP COLUMN IF EXISTS tool_calling;
@@ -0,0 +1,9 @@
+-- +goose Up
+
+ALTER TABLE models
+    ADD COLUMN IF NOT EXISTS vision BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- +goose Down
+
+ALTER TABLE models
+    DROP COLUMN IF EXISTS vision;
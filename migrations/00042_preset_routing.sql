@@ -0,0 +1,17 @@
+-- +goose Up
+
+ALTER TABLE presets
+    ADD COLUMN IF NOT EXISTS routing JSONB NOT NULL DEFAULT '{}';
+
+ALTER TABLE chat_messages
+    ADD COLUMN IF NOT EXISTS route_tier TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS route_reason TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+
+ALTER TABLE chat_messages
+    DROP COLUMN IF EXISTS route_reason,
+    DROP COLUMN IF EXISTS route_tier;
+
+ALTER TABLE presets
+    DROP COLUMN IF EXISTS routing;
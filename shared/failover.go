@@ -0,0 +1,29 @@
+package shared
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	failoverStatusPattern = regexp.MustCompile(`(?i)(error|status|code)[^0-9]{0,16}(5\d\d|429|408|402)\b`)
+	failoverTextPattern   = regexp.MustCompile(`(?i)(connection refused|connection reset|no such host|network is unreachable|i/o timeout|timeout awaiting response|unexpected eof|server misbehaving|bad gateway|service unavailable|gateway timeout|overloaded|rate[\s_-]?limit|too many requests|insufficient[\s_-]?(balance|quota|funds|credits?)|out of credits|quota exceeded)`)
+)
+
+// IsFailoverError reports whether an LLM call failed for a reason another
+// provider might not share: 5xx and 429 responses, network and timeout
+// errors, or an exhausted balance. Cancellation and context overflow are not
+// failover errors; the caller handles those itself.
+func IsFailoverError(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := strings.ToLower(err.Error())
+	if strings.Contains(msg, "context canceled") || IsContextOverflow(err) {
+		return false
+	}
+	if strings.Contains(msg, "context deadline exceeded") {
+		return strings.Contains(msg, "client.timeout") || strings.Contains(msg, "awaiting headers")
+	}
+	return failoverStatusPattern.MatchString(msg) || failoverTextPattern.MatchString(msg)
+}
@@ -0,0 +1,36 @@
+package shared
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestIsFailoverError(t *testing.T) {
+	cases := []struct {
+		msg  string
+		want bool
+	}{
+		{"LLM API error 503: upstream unavailable", true},
+		{"LLM API error 502: <html>Bad Gateway</html>", true},
+		{"LLM API error 429: rate limit exceeded", true},
+		{"LLM API error 402: insufficient balance", true},
+		{`Post "http://ollama:11434/v1/chat/completions": dial tcp 10.0.0.5:11434: connect: connection refused`, true},
+		{"net/http: timeout awaiting response headers", true},
+		{"read tcp: i/o timeout", true},
+		{"insufficient_quota: You exceeded your current quota", true},
+		{"LLM API error 400: invalid tool schema", false},
+		{"LLM API error 401: invalid api key", false},
+		{"This model's maximum context length is 128000 tokens", false},
+		{"context canceled", false},
+		{"context deadline exceeded", false},
+		{"", false},
+	}
+	for _, c := range cases {
+		if got := IsFailoverError(errors.New(c.msg)); got != c.want {
+			t.Errorf("IsFailoverError(%q) = %v, want %v", c.msg, got, c.want)
+		}
+	}
+	if IsFailoverError(nil) {
+		t.Errorf("IsFailoverError(nil) should be false")
+	}
+}
@@ -13,6 +13,9 @@ const (
 	EnvServerMaxIterations     = "MANTIS_SERVER_MAX_ITERATIONS"
 	EnvServerTimeout           = "MANTIS_SERVER_TIMEOUT"
 	EnvPlanStepTimeout         = "MANTIS_PLAN_STEP_TIMEOUT"
+	EnvPlanMaxConcurrentRuns   = "MANTIS_PLAN_MAX_CONCURRENT_RUNS"
+	EnvPlanApprovalTimeout     = "MANTIS_PLAN_APPROVAL_TIMEOUT"
+	EnvPlanCatchUpMaxRuns      = "MANTIS_PLAN_CATCHUP_MAX_RUNS"
 )
 
 type Limits struct {
@@ -21,6 +24,9 @@ type Limits struct {
 	ServerMaxIterations     int
 	ServerTimeout           time.Duration
 	PlanStepTimeout         time.Duration
+	PlanMaxConcurrentRuns   int
+	PlanApprovalTimeout     time.Duration
+	PlanCatchUpMaxRuns      int
 }
 
 func DefaultLimits() Limits {
@@ -30,6 +36,9 @@ func DefaultLimits() Limits {
 		ServerMaxIterations:     30,
 		ServerTimeout:           5 * time.Minute,
 		PlanStepTimeout:         10 * time.Minute,
+		PlanMaxConcurrentRuns:   5,
+		PlanApprovalTimeout:     24 * time.Hour,
+		PlanCatchUpMaxRuns:      10,
 	}
 }
 
@@ -40,6 +49,9 @@ func LoadLimits() Limits {
 	l.ServerMaxIterations = envInt(EnvServerMaxIterations, l.ServerMaxIterations)
 	l.ServerTimeout = envDuration(EnvServerTimeout, l.ServerTimeout)
 	l.PlanStepTimeout = envDuration(EnvPlanStepTimeout, l.PlanStepTimeout)
+	l.PlanMaxConcurrentRuns = envInt(EnvPlanMaxConcurrentRuns, l.PlanMaxConcurrentRuns)
+	l.PlanApprovalTimeout = envDuration(EnvPlanApprovalTimeout, l.PlanApprovalTimeout)
+	l.PlanCatchUpMaxRuns = envInt(EnvPlanCatchUpMaxRuns, l.PlanCatchUpMaxRuns)
 	return l
 }
 
@@ -94,6 +106,14 @@ func StopReasonServerIterations(limit int) string {
 	return fmt.Sprintf("[server call stopped: reached max %d tool iterations — raise %s in .env to increase]", limit, EnvServerMaxIterations)
 }
 
+// AgentStuckPrefix starts the error an agent loop stops with when it keeps
+// making tool calls that go nowhere after being told so.
+const AgentStuckPrefix = "agent stuck: "
+
+func StopReasonStuck(detail string) string {
+	return fmt.Sprintf("[stopped: agent stuck — %s; rephrase the request or fix the cause and try again]", detail)
+}
+
 func StopReasonPlanStepTimeout(limit time.Duration) string {
 	return fmt.Sprintf("[plan step stopped: timeout %s exceeded — raise %s in .env to increase]", FormatDuration(limit), EnvPlanStepTimeout)
 }
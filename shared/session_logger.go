@@ -89,6 +89,15 @@ func SetModelMeta(ctx context.Context, modelID, modelName, presetID, presetName,
 	}
 }
 
+// SetFallbackModel records that the step's model failed over to modelID.
+func SetFallbackModel(ctx context.Context, modelID, modelName string) {
+	if m := ToolMetaFromContext(ctx); m != nil {
+		m.ModelID = modelID
+		m.ModelName = modelName
+		m.ModelRole = "fallback"
+	}
+}
+
 func GetModelID(ctx context.Context) string {
 	if m := ToolMetaFromContext(ctx); m != nil {
 		return m.ModelID
@@ -200,6 +209,13 @@ func (l *SessionLogger) Wrap(ctx context.Context, connectionID, agentName, promp
 			case "tool_end":
 				entries = append(entries, types.LogEntry{Type: "output", Content: event.Delta, Timestamp: time.Now().UTC()})
 				save()
+			case "failover":
+				flushAll()
+				session.ModelID = event.ModelID
+				session.ModelName = event.ModelName
+				session.ModelRole = event.ModelRole
+				entries = append(entries, types.LogEntry{Type: "error", Content: "switched to fallback model " + event.ModelName + ": " + event.Delta, Timestamp: time.Now().UTC()})
+				save()
 			case "error":
 				flushAll()
 				entries = append(entries, types.LogEntry{Type: "error", Content: event.Delta, Timestamp: time.Now().UTC()})
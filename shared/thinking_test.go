@@ -147,3 +147,22 @@ func TestApplyThinkingStream_SkipMode_NoToolCalls(t *testing.T) {
 		t.Errorf("event[0].Delta = %q, want %q", events[0].Delta, "Final answer here")
 	}
 }
+
+func TestApplyThinkingStream_DefaultMode_CoalescesText(t *testing.T) {
+	// OpenAI-compatible and Gonka models with no thinking mode set get their
+	// text as one final event, tags and all
+	src := feedEvents([]types.StreamEvent{
+		{Type: "text", Delta: "<think>why</think>"},
+		{Type: "text", Delta: "Disk is "},
+		{Type: "text", Delta: "40% full"},
+	})
+
+	events := collectEvents(ApplyThinkingStream(src, ""))
+
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event, got %d: %+v", len(events), events)
+	}
+	if events[0].Delta != "<think>why</think>Disk is 40% full" || !events[0].IsFinal {
+		t.Errorf("event[0] = %+v, want the whole text as a final event", events[0])
+	}
+}
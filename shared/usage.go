@@ -0,0 +1,66 @@
+package shared
+
+import (
+	"context"
+	"strings"
+)
+
+// Callers recorded in the usage ledger.
+const (
+	UsageCallerSupervisor = "supervisor"
+	UsageCallerSSH        = "ssh"
+	UsageCallerSummarizer = "summarizer"
+	UsageCallerMemory     = "memory"
+	UsageCallerVision     = "vision"
+)
+
+type usageCtxKey struct{}
+
+// UsageScope attributes a model call in the usage ledger. Callers add what
+// they know to the context as the call is built up: the pipeline the session
+// and message, each agent or plugin its caller name, the agent action the
+// model and connection it calls.
+type UsageScope struct {
+	Caller       string
+	SessionID    string
+	MessageID    string
+	ModelID      string
+	ConnectionID string
+}
+
+// PlanRun returns the plan and run a plan session belongs to; plan sessions
+// are named plan:<planID>:<runID>[:...].
+func (s UsageScope) PlanRun() (planID, runID string) {
+	rest, ok := strings.CutPrefix(s.SessionID, "plan:")
+	if !ok {
+		return "", ""
+	}
+	parts := strings.SplitN(rest, ":", 3)
+	if len(parts) < 2 {
+		return parts[0], ""
+	}
+	return parts[0], parts[1]
+}
+
+func UsageScopeFromContext(ctx context.Context) UsageScope {
+	s, _ := ctx.Value(usageCtxKey{}).(UsageScope)
+	return s
+}
+
+func WithUsageCaller(ctx context.Context, caller string) context.Context {
+	s := UsageScopeFromContext(ctx)
+	s.Caller = caller
+	return context.WithValue(ctx, usageCtxKey{}, s)
+}
+
+func WithUsageSession(ctx context.Context, sessionID, messageID string) context.Context {
+	s := UsageScopeFromContext(ctx)
+	s.SessionID, s.MessageID = sessionID, messageID
+	return context.WithValue(ctx, usageCtxKey{}, s)
+}
+
+func WithUsageModel(ctx context.Context, connectionID, modelID string) context.Context {
+	s := UsageScopeFromContext(ctx)
+	s.ConnectionID, s.ModelID = connectionID, modelID
+	return context.WithValue(ctx, usageCtxKey{}, s)
+}